@@ -0,0 +1,34 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// defaultAllowedOrigins localhost para desenvolvimento e domínios de produção
+const defaultAllowedOrigins = "http://localhost:3000, http://127.0.0.1:3000, https://www.frappyou.app, https://frappyou.app"
+
+// AllowedOrigins origens liberadas no CORS, separadas por vírgula (ALLOWED_ORIGINS).
+// É a mesma lista usada pelo middleware HTTP e pela conexão WebSocket do GraphQL.
+func AllowedOrigins() string {
+	if origins := os.Getenv("ALLOWED_ORIGINS"); origins != "" {
+		return origins
+	}
+	return defaultAllowedOrigins
+}
+
+// IsAllowedOrigin verifica se a origem está na lista do CORS. Origem vazia e o curinga "*"
+// não são aceitos: quem chama autentica com o token do usuário (ex: WebSocket).
+func IsAllowedOrigin(origin string) bool {
+	origin = strings.TrimSpace(origin)
+	if origin == "" {
+		return false
+	}
+	for _, allowed := range strings.Split(AllowedOrigins(), ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed != "*" && strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
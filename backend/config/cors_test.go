@@ -0,0 +1,21 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsAllowedOrigin(t *testing.T) {
+	t.Setenv("ALLOWED_ORIGINS", "")
+	assert.True(t, IsAllowedOrigin("https://frappyou.app"))
+	assert.True(t, IsAllowedOrigin("http://localhost:3000"))
+	assert.False(t, IsAllowedOrigin("https://evil.example"))
+	assert.False(t, IsAllowedOrigin(""))
+
+	// O curinga do CORS não libera conexões autenticadas por token
+	t.Setenv("ALLOWED_ORIGINS", "https://rh.frappyou.app, *")
+	assert.True(t, IsAllowedOrigin("https://RH.frappyou.app"))
+	assert.False(t, IsAllowedOrigin("https://frappyou.app"))
+	assert.False(t, IsAllowedOrigin("https://evil.example"))
+}
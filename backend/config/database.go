@@ -61,17 +61,42 @@ func ConnectDB() error {
 
 	log.Println("✅ Conectado ao Azure SQL Server com sucesso!")
 
+	// O índice único (year, cpf) dos informes de rendimentos deu lugar ao índice cego:
+	// com a criptografia ativa a coluna cpf fica vazia
+	if DB.Migrator().HasIndex(&models.IncomeStatement{}, "idx_income_statement_year_cpf") {
+		if err := DB.Migrator().DropIndex(&models.IncomeStatement{}, "idx_income_statement_year_cpf"); err != nil {
+			return fmt.Errorf("erro ao remover o índice de CPF dos informes de rendimentos: %w", err)
+		}
+	}
+
 	// Auto migrate dos modelos
 	if err := DB.AutoMigrate(
 		&models.User{},
+		&models.UserSession{},
+		&models.PasswordResetToken{},
+		&models.UserTwoFactor{},
+		&models.TwoFactorRecoveryCode{},
+		&models.SecuritySettings{},
 		&models.SurveyResponse{},
 		&models.Document{},
 		&models.Employee{},
+		&models.OrgDepartment{},
+		&models.OrgPosition{},
+		&models.OrgAssignment{},
 		&models.Vacation{},
 		&models.VacationBalance{},
+		&models.VacationAcquisitionPeriod{},
+		&models.VacationLedgerEntry{},
+		&models.WorkflowChain{},
+		&models.WorkflowChainStep{},
+		&models.ApprovalRequest{},
+		&models.ApprovalTask{},
+		&models.ApprovalDelegation{},
 		&models.VacationSellRequest{},
 		&models.VacationSettings{},
 		&models.CalendarEvent{},
+		&models.Holiday{},
+		&models.FilialLocation{},
 		&models.AuditLog{},
 		&models.News{},
 		&models.NewsView{},
@@ -92,6 +117,15 @@ func ConnectDB() error {
 		// Holerite/Contracheque
 		&models.Payslip{},
 		&models.PayslipItem{},
+		&models.PayslipIssuance{},
+		&models.PayslipImportTemplate{},
+		&models.PayslipImportBatch{},
+		&models.PayslipPublication{},
+		&models.PayslipAcknowledgement{},
+		&models.PayrollTaxTable{},
+		&models.IncomeStatement{},
+		&models.IncomeStatementBatch{},
+		&models.EmployeeIdentity{},
 		// PDI (Plano de Desenvolvimento Individual)
 		&models.PDI{},
 		&models.PDIGoal{},
@@ -105,14 +139,23 @@ func ConnectDB() error {
 		&models.ChatSession{},
 		&models.ChatMessage{},
 		&models.ChatUsageStats{},
+		&models.ChatPendingAction{},
 		// Base de Conhecimento (RAG)
 		&models.KnowledgeArticle{},
 		&models.KnowledgeFeedback{},
+		&models.KnowledgeChunk{},
 	); err != nil {
 		return fmt.Errorf("erro ao executar migrations: %w", err)
 	}
 
 	log.Println("✅ Migrations executadas com sucesso!")
 
+	// Lotes de holerites já confirmados não guardam mais o conteúdo do arquivo
+	if err := DB.Model(&models.PayslipImportBatch{}).
+		Where("status <> ? AND payload <> ''", models.PayslipImportValidated).
+		Update("payload", "").Error; err != nil {
+		log.Printf("⚠️ Erro ao limpar o conteúdo dos lotes de holerites: %v", err)
+	}
+
 	return nil
 }
@@ -23,27 +23,30 @@ func init() {
 	JWTSecret = []byte(secret)
 }
 
+// Tempo de vida do access token. A sessão é renovada com o refresh token
+// (ver services.Sessions), então o access token pode ser curto.
+const AccessTokenTTL = 15 * time.Minute
+
 // Claims representa os claims do JWT
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"` // Sessão (user_sessions) que emitiu o token
 	jwt.RegisteredClaims
 }
 
-// GenerateToken gera um novo token JWT
-func GenerateToken(userID, role string, rememberMe bool) (string, error) {
-	expiration := 24 * time.Hour
-	if rememberMe {
-		expiration = 7 * 24 * time.Hour // 7 dias
-	}
-
+// GenerateAccessToken gera o access token JWT vinculado a uma sessão
+func GenerateAccessToken(userID, email, role, sessionID string) (string, error) {
+	now := time.Now()
 	claims := &Claims{
-		UserID: userID,
-		Role:   role,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Issuer:    "frappyou",
 		},
 	}
@@ -56,13 +59,63 @@ func GenerateToken(userID, role string, rememberMe bool) (string, error) {
 func ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return JWTSecret, nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens de desafio 2FA não têm user_id e não valem como access token
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid && claims.UserID != "" {
+		return claims, nil
+	}
+
+	return nil, jwt.ErrSignatureInvalid
+}
+
+// Tempo para o usuário informar o código TOTP depois de acertar a senha
+const TwoFactorChallengeTTL = 5 * time.Minute
+
+const twoFactorAudience = "frappyou-2fa"
+
+// TwoFactorChallengeClaims token intermediário do login com 2FA: comprova que a
+// senha foi validada, mas não abre sessão. Subject é o ID do usuário.
+type TwoFactorChallengeClaims struct {
+	RememberMe bool `json:"remember_me"`
+	jwt.RegisteredClaims
+}
+
+// GenerateTwoFactorChallenge gera o token de desafio emitido após a senha correta.
+// challengeID identifica o desafio (jti), para limitar as tentativas de cada um.
+func GenerateTwoFactorChallenge(userID, challengeID string, rememberMe bool) (string, error) {
+	now := time.Now()
+	claims := &TwoFactorChallengeClaims{
+		RememberMe: rememberMe,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        challengeID,
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{twoFactorAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(TwoFactorChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "frappyou",
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(JWTSecret)
+}
+
+// ValidateTwoFactorChallenge valida o token de desafio 2FA
+func ValidateTwoFactorChallenge(tokenString string) (*TwoFactorChallengeClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &TwoFactorChallengeClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return JWTSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(twoFactorAudience))
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+	if claims, ok := token.Claims.(*TwoFactorChallengeClaims); ok && token.Valid && claims.Subject != "" {
 		return claims, nil
 	}
 
@@ -1,32 +1,29 @@
 package config
 
 import (
-	"os"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestGenerateToken(t *testing.T) {
-	// Setup
-	os.Setenv("JWT_SECRET", "test-secret-key-for-testing-purposes")
-
+func TestGenerateAccessToken(t *testing.T) {
 	tests := []struct {
-		name       string
-		userID     string
-		role       string
-		rememberMe bool
+		name      string
+		userID    string
+		role      string
+		sessionID string
 	}{
-		{"Regular user token", "user-123", "user", false},
-		{"Admin token", "admin-456", "admin", false},
-		{"Remember me token", "user-789", "user", true},
+		{"Regular user token", "user-123", "user", "session-1"},
+		{"Admin token", "admin-456", "admin", "session-2"},
+		{"Manager token", "user-789", "manager", "session-3"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			token, err := GenerateToken(tt.userID, tt.role, tt.rememberMe)
+			token, err := GenerateAccessToken(tt.userID, tt.userID+"@frappyou.app", tt.role, tt.sessionID)
 
 			assert.NoError(t, err)
 			assert.NotEmpty(t, token)
@@ -44,23 +41,21 @@ func TestGenerateToken(t *testing.T) {
 }
 
 func TestValidateToken(t *testing.T) {
-	// Setup
-	secret := "test-secret-key-for-testing-purposes"
-	os.Setenv("JWT_SECRET", secret)
-
 	t.Run("Valid token", func(t *testing.T) {
-		token, _ := GenerateToken("user-123", "user", false)
+		token, _ := GenerateAccessToken("user-123", "user@frappyou.app", "user", "session-1")
 		claims, err := ValidateToken(token)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, claims)
 		assert.Equal(t, "user-123", claims.UserID)
+		assert.Equal(t, "user@frappyou.app", claims.Email)
 		assert.Equal(t, "user", claims.Role)
+		assert.Equal(t, "session-1", claims.SessionID)
 	})
 
 	t.Run("Expired token", func(t *testing.T) {
 		// Create an expired token manually
-		claims := &JWTClaims{
+		claims := &Claims{
 			UserID: "user-123",
 			Role:   "user",
 			RegisteredClaims: jwt.RegisteredClaims{
@@ -71,7 +66,15 @@ func TestValidateToken(t *testing.T) {
 		}
 
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		tokenString, _ := token.SignedString([]byte(secret))
+		tokenString, _ := token.SignedString(JWTSecret)
+
+		_, err := ValidateToken(tokenString)
+		assert.Error(t, err)
+	})
+
+	t.Run("Wrong signing method", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodNone, &Claims{UserID: "user-123", Role: "admin"})
+		tokenString, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
 
 		_, err := ValidateToken(tokenString)
 		assert.Error(t, err)
@@ -89,30 +92,32 @@ func TestValidateToken(t *testing.T) {
 }
 
 func TestTokenExpiration(t *testing.T) {
-	os.Setenv("JWT_SECRET", "test-secret-key-for-testing-purposes")
+	token, _ := GenerateAccessToken("user-123", "user@frappyou.app", "user", "session-1")
+	claims, err := ValidateToken(token)
 
-	t.Run("Regular token expiration", func(t *testing.T) {
-		token, _ := GenerateToken("user-123", "user", false)
-		claims, err := ValidateToken(token)
+	assert.NoError(t, err)
 
-		assert.NoError(t, err)
+	// Access token is short-lived; the session is renewed with the refresh token
+	expiresIn := time.Until(claims.ExpiresAt.Time)
+	assert.True(t, expiresIn > AccessTokenTTL-time.Minute, "Access token should expire in ~15 minutes")
+	assert.True(t, expiresIn <= AccessTokenTTL, "Access token should expire in ~15 minutes")
+}
 
-		// Regular token should expire in ~24 hours
-		expiresIn := time.Until(claims.ExpiresAt.Time)
-		assert.True(t, expiresIn > 23*time.Hour, "Regular token should expire in ~24 hours")
-		assert.True(t, expiresIn < 25*time.Hour, "Regular token should expire in ~24 hours")
-	})
+func TestTwoFactorChallenge(t *testing.T) {
+	challenge, err := GenerateTwoFactorChallenge("user-123", "challenge-1", true)
+	require.NoError(t, err)
 
-	t.Run("Remember me token expiration", func(t *testing.T) {
-		token, _ := GenerateToken("user-123", "user", true)
-		claims, err := ValidateToken(token)
+	claims, err := ValidateTwoFactorChallenge(challenge)
+	require.NoError(t, err)
+	assert.Equal(t, "user-123", claims.Subject)
+	assert.Equal(t, "challenge-1", claims.ID)
+	assert.True(t, claims.RememberMe)
 
-		assert.NoError(t, err)
+	// Challenge must not be accepted as an access token, and vice versa
+	_, err = ValidateToken(challenge)
+	assert.Error(t, err)
 
-		// Remember me token should expire in ~30 days
-		expiresIn := time.Until(claims.ExpiresAt.Time)
-		assert.True(t, expiresIn > 29*24*time.Hour, "Remember me token should expire in ~30 days")
-		assert.True(t, expiresIn < 31*24*time.Hour, "Remember me token should expire in ~30 days")
-	})
+	access, _ := GenerateAccessToken("user-123", "user@frappyou.app", "admin", "session-1")
+	_, err = ValidateTwoFactorChallenge(access)
+	assert.Error(t, err)
 }
-
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// DeriveKey chave de 256 bits derivada do JWT_SECRET com HKDF-SHA256, usada quando um
+// recurso (links assinados, holerites) não tem chave própria configurada. Cada uso tem
+// um rótulo fixo: chaves de rótulos diferentes são independentes entre si e do segredo
+// JWT, e alterar o rótulo invalida tudo o que foi assinado com ele.
+func DeriveKey(label string) []byte {
+	return deriveKey(JWTSecret, label)
+}
+
+func deriveKey(secret []byte, label string) []byte {
+	key := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, nil, []byte(label)), key); err != nil {
+		panic(err) // Só falha acima de 255 blocos
+	}
+	return key
+}
@@ -0,0 +1,18 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveKey(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	key := deriveKey(secret, "frappyou/teste/v1")
+	assert.Len(t, key, 32)
+	assert.Equal(t, key, deriveKey(secret, "frappyou/teste/v1"))
+	assert.NotEqual(t, secret, key)
+	assert.NotEqual(t, key, deriveKey(secret, "frappyou/outro-uso/v1"))
+	assert.NotEqual(t, key, deriveKey([]byte("outro-segredo"), "frappyou/teste/v1"))
+}
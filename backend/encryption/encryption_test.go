@@ -0,0 +1,121 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(rune(b)), 32)))
+}
+
+// useKeyring troca o chaveiro durante o teste
+func useKeyring(t *testing.T, spec, active string) {
+	t.Helper()
+	k, err := NewKeyring(spec, active, testKey('i'))
+	require.NoError(t, err)
+	previous := keyring()
+	SetKeyring(k)
+	t.Cleanup(func() { SetKeyring(previous) })
+}
+
+func TestNewKeyring(t *testing.T) {
+	k, err := NewKeyring("2025:"+testKey('a')+", 2026:"+testKey('b'), "", testKey('i'))
+	require.NoError(t, err)
+	assert.Equal(t, "2025", k.active)
+
+	k, err = NewKeyring("2025:"+testKey('a')+",2026:"+testKey('b'), "2026", testKey('i'))
+	require.NoError(t, err)
+	assert.Equal(t, "2026", k.active)
+
+	// Sem chaves: criptografia desligada
+	k, err = NewKeyring("", "", "")
+	require.NoError(t, err)
+	assert.Empty(t, k.active)
+
+	for _, spec := range []string{"2025:curta", "semid", "a:b:" + testKey('a'), "x:" + testKey('a') + ",x:" + testKey('b')} {
+		_, err := NewKeyring(spec, "", testKey('i'))
+		assert.Error(t, err, spec)
+	}
+	_, err = NewKeyring("2025:"+testKey('a'), "2024", testKey('i'))
+	assert.Error(t, err)
+	_, err = NewKeyring("2025:"+testKey('a'), "", "")
+	assert.Error(t, err, "índice cego obrigatório com chaves")
+}
+
+func TestSealOpenRotation(t *testing.T) {
+	useKeyring(t, "old:"+testKey('a'), "")
+	envelope, err := SealJSON(map[string]float64{"net_total": 4321.09})
+	require.NoError(t, err)
+	assert.Equal(t, "old", envelope.KeyID)
+	assert.NotContains(t, envelope.Data, "4321")
+
+	// Nova chave ativa: o registro antigo continua legível com a chave antiga no chaveiro
+	useKeyring(t, "old:"+testKey('a')+",new:"+testKey('b'), "new")
+	var out map[string]float64
+	require.NoError(t, OpenJSON(envelope, &out))
+	assert.Equal(t, 4321.09, out["net_total"])
+
+	resealed, err := Seal([]byte("x"))
+	require.NoError(t, err)
+	assert.Equal(t, "new", resealed.KeyID)
+
+	// Sem a chave antiga, o registro não abre
+	useKeyring(t, "new:"+testKey('b'), "")
+	assert.ErrorIs(t, OpenJSON(envelope, &out), ErrUnknownKey)
+
+	// DEK adulterada ou trocada de chave mestra
+	useKeyring(t, "old:"+testKey('a')+",new:"+testKey('b'), "new")
+	tampered := envelope
+	tampered.KeyID = "new"
+	assert.ErrorIs(t, OpenJSON(tampered, &out), ErrCorrupted)
+}
+
+func TestSealStringToken(t *testing.T) {
+	useKeyring(t, "k1:"+testKey('a'), "")
+	token, err := SealString("12.345.678-9")
+	require.NoError(t, err)
+	assert.True(t, IsToken(token))
+	assert.Equal(t, "k1", TokenKeyID(token))
+	assert.NotContains(t, token, "345")
+
+	plain, err := OpenString(token)
+	require.NoError(t, err)
+	assert.Equal(t, "12.345.678-9", plain)
+
+	// Valores em claro (legado) passam direto
+	plain, err = OpenString("MG-1.234.567")
+	require.NoError(t, err)
+	assert.Equal(t, "MG-1.234.567", plain)
+	assert.Empty(t, TokenKeyID("MG-1.234.567"))
+}
+
+func TestSealDisabled(t *testing.T) {
+	useKeyring(t, "", "")
+	assert.False(t, Enabled())
+	_, err := SealString("x")
+	assert.ErrorIs(t, err, ErrDisabled)
+}
+
+func TestBlindIndexIgnoresFormatting(t *testing.T) {
+	useKeyring(t, "k1:"+testKey('a'), "")
+	assert.Equal(t, BlindIndex("529.982.247-25"), BlindIndex("52998224725"))
+	assert.NotEqual(t, BlindIndex("52998224725"), BlindIndex("39053344705"))
+	assert.Len(t, BlindIndex("52998224725"), 64)
+	assert.Empty(t, BlindIndex(""))
+}
+
+func TestBlindIndexWithoutIndexKey(t *testing.T) {
+	// Sem ENCRYPTION_INDEX_KEY nada é indexado: as buscas usam a coluna em claro
+	k, err := NewKeyring("", "", "")
+	require.NoError(t, err)
+	previous := keyring()
+	SetKeyring(k)
+	t.Cleanup(func() { SetKeyring(previous) })
+
+	assert.Empty(t, BlindIndex("52998224725"))
+}
@@ -0,0 +1,180 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// tokenPrefix marca valores cifrados autocontidos (ver SealString)
+const tokenPrefix = "enc:v1:"
+
+// Envelope conteúdo cifrado de um registro: chave mestra, DEK cifrada e dado cifrado
+type Envelope struct {
+	KeyID   string
+	DataKey string // DEK cifrada pela chave mestra (base64)
+	Data    string // Conteúdo cifrado pela DEK (base64)
+}
+
+// Empty indica registro sem conteúdo cifrado (gravado antes da criptografia)
+func (e Envelope) Empty() bool {
+	return e.KeyID == "" || e.DataKey == ""
+}
+
+// SealBytes cifra o conteúdo com uma DEK nova, protegida pela chave mestra ativa
+func SealBytes(plaintext []byte) (keyID, dataKey string, ciphertext []byte, err error) {
+	k := keyring()
+	if k.active == "" {
+		return "", "", nil, ErrDisabled
+	}
+
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return "", "", nil, err
+	}
+	wrapped, err := gcmSeal(k.keys[k.active], dek, []byte(k.active))
+	if err != nil {
+		return "", "", nil, err
+	}
+	ciphertext, err = gcmSeal(dek, plaintext, nil)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return k.active, base64.StdEncoding.EncodeToString(wrapped), ciphertext, nil
+}
+
+// OpenBytes decifra o conteúdo com a DEK do registro
+func OpenBytes(keyID, dataKey string, ciphertext []byte) ([]byte, error) {
+	kek, ok := keyring().keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(dataKey)
+	if err != nil {
+		return nil, ErrCorrupted
+	}
+	dek, err := gcmOpen(kek, wrapped, []byte(keyID))
+	if err != nil {
+		return nil, err
+	}
+	return gcmOpen(dek, ciphertext, nil)
+}
+
+// Seal cifra o conteúdo de um registro
+func Seal(plaintext []byte) (Envelope, error) {
+	keyID, dataKey, ciphertext, err := SealBytes(plaintext)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{KeyID: keyID, DataKey: dataKey, Data: base64.StdEncoding.EncodeToString(ciphertext)}, nil
+}
+
+// Open decifra o conteúdo de um registro
+func Open(e Envelope) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(e.Data)
+	if err != nil {
+		return nil, ErrCorrupted
+	}
+	return OpenBytes(e.KeyID, e.DataKey, ciphertext)
+}
+
+// SealJSON cifra os campos sensíveis de um registro serializados em JSON
+func SealJSON(v interface{}) (Envelope, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Seal(payload)
+}
+
+// OpenJSON decifra o envelope de volta para os campos sensíveis
+func OpenJSON(e Envelope, v interface{}) error {
+	payload, err := Open(e)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, v)
+}
+
+// IsToken indica se o valor foi cifrado por SealString
+func IsToken(value string) bool {
+	return strings.HasPrefix(value, tokenPrefix)
+}
+
+// TokenKeyID chave mestra de um valor cifrado por SealString ("" se estiver em claro)
+func TokenKeyID(value string) string {
+	if !IsToken(value) {
+		return ""
+	}
+	keyID, _, _ := strings.Cut(strings.TrimPrefix(value, tokenPrefix), ":")
+	return keyID
+}
+
+// SealString cifra um valor avulso em um token autocontido
+// ("enc:v1:<chave>:<dek>:<dado>"), para colunas de tabelas que não têm os campos do envelope
+func SealString(value string) (string, error) {
+	keyID, dataKey, ciphertext, err := SealBytes([]byte(value))
+	if err != nil {
+		return "", err
+	}
+	return tokenPrefix + keyID + ":" + dataKey + ":" + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// OpenString decifra um token de SealString; valores em claro são devolvidos sem alteração
+func OpenString(value string) (string, error) {
+	if !IsToken(value) {
+		return value, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(value, tokenPrefix), ":")
+	if len(parts) != 3 {
+		return "", ErrCorrupted
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrCorrupted
+	}
+	plaintext, err := OpenBytes(parts[0], parts[1], ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// gcmSeal AES-256-GCM com o nonce aleatório no início do resultado
+func gcmSeal(key, plaintext, additional []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func gcmOpen(key, sealed, additional []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrCorrupted
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additional)
+	if err != nil {
+		return nil, ErrCorrupted
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,161 @@
+// Package encryption implementa a criptografia em envelope dos dados sensíveis gravados
+// pela aplicação (valores de holerites, CPF, RG/CNH e arquivos de documentos).
+//
+// Cada registro recebe uma chave de dados (DEK) aleatória que cifra o conteúdo com
+// AES-256-GCM; a DEK é gravada cifrada pela chave mestra ativa (KEK), junto com o
+// identificador dessa chave. Trocar a chave ativa só afeta novos registros: os antigos
+// continuam legíveis enquanto a chave antiga estiver no chaveiro, até serem recifrados
+// pela rotação (services.KeyRotation).
+package encryption
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var (
+	ErrDisabled   = errors.New("criptografia não configurada")
+	ErrUnknownKey = errors.New("chave de criptografia desconhecida")
+	ErrCorrupted  = errors.New("dado cifrado inválido ou corrompido")
+)
+
+// Keyring chaves mestras (KEK) por identificador e chave do índice cego
+type Keyring struct {
+	keys     map[string][]byte
+	active   string
+	indexKey []byte
+}
+
+// NewKeyring monta o chaveiro a partir da lista "id:chave_base64,id:chave_base64".
+// Sem chave ativa informada, a primeira da lista é usada para novos registros.
+func NewKeyring(spec, active, indexKey string) (*Keyring, error) {
+	k := &Keyring{keys: map[string][]byte{}}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || !validKeyID(id) {
+			return nil, fmt.Errorf("identificador de chave inválido em %q", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("a chave %s deve ter 32 bytes em base64", id)
+		}
+		if _, dup := k.keys[id]; dup {
+			return nil, fmt.Errorf("chave %s repetida", id)
+		}
+		k.keys[id] = key
+		if k.active == "" {
+			k.active = id
+		}
+	}
+	if active != "" {
+		if _, ok := k.keys[active]; !ok {
+			return nil, fmt.Errorf("chave ativa %s não está na lista de chaves", active)
+		}
+		k.active = active
+	}
+
+	if indexKey != "" {
+		key, err := base64.StdEncoding.DecodeString(indexKey)
+		if err != nil || len(key) < 32 {
+			return nil, errors.New("a chave do índice cego deve ter pelo menos 32 bytes em base64")
+		}
+		k.indexKey = key
+	} else if len(k.keys) > 0 {
+		return nil, errors.New("ENCRYPTION_INDEX_KEY é obrigatória quando há chaves de criptografia")
+	}
+	return k, nil
+}
+
+// validKeyID identificadores curtos, sem os separadores usados nos tokens
+func validKeyID(id string) bool {
+	if id == "" || len(id) > 40 {
+		return false
+	}
+	for _, r := range id {
+		if !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	mu      sync.RWMutex
+	current = &Keyring{keys: map[string][]byte{}}
+)
+
+func init() {
+	keyring, err := NewKeyring(os.Getenv("ENCRYPTION_KEYS"), os.Getenv("ENCRYPTION_ACTIVE_KEY"), os.Getenv("ENCRYPTION_INDEX_KEY"))
+	if err != nil {
+		panic("ERRO CRÍTICO: configuração de criptografia inválida: " + err.Error())
+	}
+	current = keyring
+}
+
+// SetKeyring substitui o chaveiro em uso (testes e recarga de configuração)
+func SetKeyring(k *Keyring) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = k
+}
+
+func keyring() *Keyring {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Enabled indica se há chave ativa; sem ela os dados continuam sendo gravados em claro
+func Enabled() bool {
+	return keyring().active != ""
+}
+
+// ActiveKeyID identificador da chave mestra usada nos novos registros
+func ActiveKeyID() string {
+	return keyring().active
+}
+
+// KeyIDs identificadores das chaves disponíveis para leitura
+func KeyIDs() []string {
+	k := keyring()
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// BlindIndex HMAC determinístico do valor, para buscas por igualdade em colunas cifradas.
+// Documentos (CPF) são comparados só pelos dígitos, ignorando a formatação. Sem
+// ENCRYPTION_INDEX_KEY retorna "": um índice com chave provisória deixaria de bater
+// quando a chave fosse configurada, e sem criptografia o dado já está em claro.
+func BlindIndex(value string) string {
+	key := keyring().indexKey
+	if key == nil {
+		return ""
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+	if digits == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(digits))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/frappyou/backend/services"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const accessKey contextKey = "access"
+
+// HasPermission implements the @hasPermission(resource, action) directive declared
+// in schema.graphqls (wired through Config.Directives). The resolved scope (own, team,
+// filial or all) is handed to the resolver through the context.
+func HasPermission(ctx context.Context, obj any, next graphql.Resolver, resource string, action string) (any, error) {
+	access, err := resolveAccess(ctx, resource, action)
+	if err != nil {
+		return nil, err
+	}
+	return next(context.WithValue(ctx, accessKey, access))
+}
+
+// resolveAccess resolves resource:action for the caller, returning a FORBIDDEN error
+// when the role does not grant it
+func resolveAccess(ctx context.Context, resource, action string) (*services.Access, error) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := services.Authz.Resolve(userID, resource, action)
+	if err != nil {
+		if errors.Is(err, services.ErrAccessDenied) {
+			return nil, &gqlerror.Error{
+				Message:    "acesso negado",
+				Path:       graphql.GetPath(ctx),
+				Extensions: map[string]any{"code": "FORBIDDEN", "resource": resource, "action": action},
+			}
+		}
+		return nil, errors.New("não autorizado")
+	}
+	return access, nil
+}
+
+// accessOrOwn is used by fields open to every employee that reach further for roles
+// holding resource:action (e.g. cancelling one's own vacation vs. HR cancelling any)
+func accessOrOwn(ctx context.Context, resource, action string) *services.Access {
+	if access, err := resolveAccess(ctx, resource, action); err == nil {
+		return access
+	}
+	userID, _ := getUserIDFromContext(ctx)
+	return services.OwnAccess(userID)
+}
+
+// accessFromContext returns the scope resolved by @hasPermission for the current
+// field; fields without the directive only reach the caller's own data
+func accessFromContext(ctx context.Context) *services.Access {
+	if access, ok := ctx.Value(accessKey).(*services.Access); ok {
+		return access
+	}
+	userID, _ := getUserIDFromContext(ctx)
+	return services.OwnAccess(userID)
+}
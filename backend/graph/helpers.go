@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/frappyou/backend/graph/model"
+	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"gorm.io/gorm"
+)
+
+// Helpers shared by the resolvers. They live outside schema.resolvers.go so that
+// gqlgen generate does not move them into its "unknown code" block.
+
+// contextKey is a custom type for context keys
+type contextKey string
+
+const (
+	userIDKey contextKey = "user_id"
+	roleKey   contextKey = "role"
+)
+
+// Helper to get user ID from context
+func getUserIDFromContext(ctx context.Context) (string, error) {
+	userID := ctx.Value(userIDKey)
+	if userID == nil {
+		return "", errors.New("não autorizado")
+	}
+	return userID.(string), nil
+}
+
+// colaboradorScope restricts a query over dbo.PessoasFisicasFradema (alias p) to the
+// people reached by the access, matched through the CPF of their system user
+func colaboradorScope(access *services.Access) (string, []interface{}) {
+	if access.All() {
+		return "", []interface{}{}
+	}
+	return ` AND REPLACE(REPLACE(REPLACE(p.Cpf, '.', ''), '-', ''), ' ', '') IN (
+			SELECT REPLACE(REPLACE(REPLACE(u.cpf, '.', ''), '-', ''), ' ', '')
+			FROM users u WHERE u.deleted_at IS NULL AND u.id IN ?
+		)`, []interface{}{append(access.UserIDs(), "")}
+}
+
+// colaboradorReachable reports whether the colaborador is within the access scope
+func colaboradorReachable(db *gorm.DB, access *services.Access, colaboradorID int) bool {
+	scope, args := colaboradorScope(access)
+	var count int64
+	db.Raw(`
+		SELECT COUNT(*)
+		FROM dbo.ColaboradoresFradema c
+		INNER JOIN dbo.PessoasFisicasFradema p ON c.PessoaFisicaId = p.Id
+		WHERE c.Id = ?`+scope, append([]interface{}{colaboradorID}, args...)...).Scan(&count)
+	return count > 0
+}
+
+// vacationPolicyError converts vacation policy violations into a GraphQL error
+// whose extensions carry the structured violation codes for the UI
+func vacationPolicyError(ctx context.Context, err error) error {
+	policyErr, ok := services.AsVacationPolicyError(err)
+	if !ok {
+		return err
+	}
+	return &gqlerror.Error{
+		Path:    graphql.GetPath(ctx),
+		Message: policyErr.Violations[0].Message,
+		Extensions: map[string]interface{}{
+			"code":       "VACATION_POLICY_VIOLATION",
+			"violations": policyErr.Violations,
+		},
+	}
+}
+
+// Helper functions
+func strPtr(s string) *string {
+	return &s
+}
+
+// authPayload builds a successful login response with the session tokens
+func authPayload(tokens *services.TokenPair, user *models.User) *model.AuthPayload {
+	return &model.AuthPayload{
+		Success:      true,
+		Token:        &tokens.AccessToken,
+		RefreshToken: &tokens.RefreshToken,
+		User:         user,
+	}
+}
+
+// loginPayload opens the session after the password check, or returns the
+// two-factor challenge when the user has TOTP enabled (or it is mandatory for the role)
+func loginPayload(ctx context.Context, user *models.User, rememberMe bool) *model.AuthPayload {
+	challenge, err := services.TwoFactor.LoginChallenge(user, rememberMe)
+	if err != nil {
+		return &model.AuthPayload{
+			Success: false,
+			Error:   strPtr("Erro ao iniciar verificação em dois fatores"),
+		}
+	}
+	if challenge != nil {
+		payload := &model.AuthPayload{
+			Success:           true,
+			TwoFactorRequired: true,
+			ChallengeToken:    &challenge.ChallengeToken,
+		}
+		if challenge.Enrollment != nil {
+			payload.OtpauthURL = &challenge.Enrollment.OTPAuthURL
+		}
+		return payload
+	}
+
+	tokens, err := services.Sessions.Create(user, rememberMe, clientFromContext(ctx))
+	if err != nil {
+		return &model.AuthPayload{
+			Success: false,
+			Error:   strPtr("Erro ao gerar token"),
+		}
+	}
+	return authPayload(tokens, user)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
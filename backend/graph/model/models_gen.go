@@ -7,16 +7,20 @@ import (
 	"fmt"
 	"io"
 	"strconv"
-	"time"
 
 	"github.com/frappyou/backend/models"
 )
 
 type AuthPayload struct {
-	Success bool         `json:"success"`
-	Token   *string      `json:"token,omitempty"`
-	User    *models.User `json:"user,omitempty"`
-	Error   *string      `json:"error,omitempty"`
+	Success           bool         `json:"success"`
+	Token             *string      `json:"token,omitempty"`
+	RefreshToken      *string      `json:"refreshToken,omitempty"`
+	User              *models.User `json:"user,omitempty"`
+	Error             *string      `json:"error,omitempty"`
+	TwoFactorRequired bool         `json:"twoFactorRequired"`
+	ChallengeToken    *string      `json:"challengeToken,omitempty"`
+	OtpauthURL        *string      `json:"otpauthUrl,omitempty"`
+	RecoveryCodes     []string     `json:"recoveryCodes,omitempty"`
 }
 
 type CPFValidation struct {
@@ -163,14 +167,6 @@ type LoginInput struct {
 type Mutation struct {
 }
 
-type Notification struct {
-	ID        string    `json:"id"`
-	Type      string    `json:"type"`
-	Title     string    `json:"title"`
-	Message   string    `json:"message"`
-	CreatedAt time.Time `json:"createdAt"`
-}
-
 type PageInfo struct {
 	HasNextPage     bool `json:"hasNextPage"`
 	HasPreviousPage bool `json:"hasPreviousPage"`
@@ -270,18 +266,26 @@ type VacationFilterInput struct {
 type UserRole string
 
 const (
-	UserRoleUser  UserRole = "user"
-	UserRoleAdmin UserRole = "admin"
+	UserRoleUser    UserRole = "user"
+	UserRoleManager UserRole = "manager"
+	UserRoleHr      UserRole = "hr"
+	UserRolePayroll UserRole = "payroll"
+	UserRoleAdmin   UserRole = "admin"
+	UserRoleAuditor UserRole = "auditor"
 )
 
 var AllUserRole = []UserRole{
 	UserRoleUser,
+	UserRoleManager,
+	UserRoleHr,
+	UserRolePayroll,
 	UserRoleAdmin,
+	UserRoleAuditor,
 }
 
 func (e UserRole) IsValid() bool {
 	switch e {
-	case UserRoleUser, UserRoleAdmin:
+	case UserRoleUser, UserRoleManager, UserRoleHr, UserRolePayroll, UserRoleAdmin, UserRoleAuditor:
 		return true
 	}
 	return false
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Notification is the GraphQL notification payload. It is pinned in gqlgen.yml so that
+// autobind does not map it to the persisted models.Notification.
+type Notification struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -1,7 +1,12 @@
 package graph
 
 import (
+	"context"
+	"errors"
+
 	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/graph/model"
+	"github.com/frappyou/backend/services"
 	"gorm.io/gorm"
 )
 
@@ -10,12 +15,64 @@ import (
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Events *services.EventBus
 }
 
 // NewResolver creates a new resolver with database connection
 func NewResolver() *Resolver {
 	return &Resolver{
-		DB: config.DB,
+		DB:     config.DB,
+		Events: services.Events,
 	}
 }
+
+const (
+	sessionIDKey contextKey = "session_id"
+	clientKey    contextKey = "client"
+)
+
+// WithUser stores the authenticated user in the context read by the resolvers
+func WithUser(ctx context.Context, userID, role string) context.Context {
+	ctx = context.WithValue(ctx, userIDKey, userID)
+	return context.WithValue(ctx, roleKey, role)
+}
+
+// WithSession stores the session that issued the access token, used by logout
+func WithSession(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionIDKey, sessionID)
+}
+
+// WithClient stores the caller's IP and user agent, recorded on new sessions
+func WithClient(ctx context.Context, ip, userAgent string) context.Context {
+	return context.WithValue(ctx, clientKey, services.SessionClient{IP: ip, UserAgent: userAgent})
+}
+
+func clientFromContext(ctx context.Context) services.SessionClient {
+	client, _ := ctx.Value(clientKey).(services.SessionClient)
+	return client
+}
+
+// sessionIDFromContext returns the current session ("" for unauthenticated requests)
+func sessionIDFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(sessionIDKey).(string)
+	return sessionID
+}
+
+// sealPersonDocuments cifra RG e CNH do perfil antes da gravação em dbo.PessoasFisicasFradema
+func sealPersonDocuments(input *model.UpdateProfileInput) error {
+	var err error
+	if input.Rg, err = services.SealPersonDocument(input.Rg); err == nil {
+		input.Cnh, err = services.SealPersonDocument(input.Cnh)
+	}
+	if err != nil {
+		return errors.New("erro ao proteger documentos")
+	}
+	return nil
+}
+
+// openColaboradorDocuments decifra RG e CNH lidos de dbo.PessoasFisicasFradema
+func openColaboradorDocuments(c *model.Colaborador) {
+	c.Rg = services.OpenPersonDocument(c.Rg)
+	c.Cnh = services.OpenPersonDocument(c.Cnh)
+}
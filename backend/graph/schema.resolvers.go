@@ -9,43 +9,20 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql"
-	"github.com/frappyou/backend/config"
 	"github.com/frappyou/backend/graph/model"
 	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
-// contextKey is a custom type for context keys
-type contextKey string
-
-const (
-	userIDKey contextKey = "user_id"
-	roleKey   contextKey = "role"
-)
-
-// Helper to get user ID from context
-func getUserIDFromContext(ctx context.Context) (string, error) {
-	userID := ctx.Value(userIDKey)
-	if userID == nil {
-		return "", errors.New("não autorizado")
-	}
-	return userID.(string), nil
-}
-
-// Helper to check if user is admin
-func isAdminFromContext(ctx context.Context) bool {
-	role := ctx.Value(roleKey)
-	if role == nil {
-		return false
-	}
-	return role.(string) == "admin"
-}
-
 // ============== CALENDAR EVENT RESOLVER ==============
 
 // Creator is the resolver for the creator field.
@@ -82,24 +59,11 @@ func (r *mutationResolver) Login(ctx context.Context, input model.LoginInput) (*
 		}, nil
 	}
 
-	// Generate JWT token
 	rememberMe := false
 	if input.RememberMe != nil {
 		rememberMe = *input.RememberMe
 	}
-	token, err := config.GenerateToken(user.ID, user.Role, rememberMe)
-	if err != nil {
-		return &model.AuthPayload{
-			Success: false,
-			Error:   strPtr("Erro ao gerar token"),
-		}, nil
-	}
-
-	return &model.AuthPayload{
-		Success: true,
-		Token:   &token,
-		User:    &user,
-	}, nil
+	return loginPayload(ctx, &user, rememberMe), nil
 }
 
 // Signup is the resolver for the signup field.
@@ -150,17 +114,27 @@ func (r *mutationResolver) Signup(ctx context.Context, input model.SignupInput)
 	}
 	r.DB.Create(&surveyResponse)
 
-	token, _ := config.GenerateToken(user.ID, user.Role, false)
+	tokens, err := services.Sessions.Create(&user, false, clientFromContext(ctx))
+	if err != nil {
+		return &model.AuthPayload{
+			Success: false,
+			Error:   strPtr("Erro ao gerar token"),
+		}, nil
+	}
 
-	return &model.AuthPayload{
-		Success: true,
-		Token:   &token,
-		User:    &user,
-	}, nil
+	return authPayload(tokens, &user), nil
 }
 
 // Logout is the resolver for the logout field.
 func (r *mutationResolver) Logout(ctx context.Context) (bool, error) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if err := services.Sessions.Revoke(userID, sessionIDFromContext(ctx), models.SessionRevokedLogout); err != nil {
+		return false, nil
+	}
 	return true, nil
 }
 
@@ -215,28 +189,46 @@ func (r *mutationResolver) ActivateAccount(ctx context.Context, cpf string, pass
 		}, nil
 	}
 
-	token, _ := config.GenerateToken(user.ID, user.Role, false)
+	return loginPayload(ctx, &user, false), nil
+}
 
-	return &model.AuthPayload{
-		Success: true,
-		Token:   &token,
-		User:    &user,
-	}, nil
+// VerifyTwoFactor is the resolver for the verifyTwoFactor field.
+func (r *mutationResolver) VerifyTwoFactor(ctx context.Context, challengeToken string, code string) (*model.AuthPayload, error) {
+	user, tokens, recoveryCodes, err := services.TwoFactor.CompleteLogin(challengeToken, code, clientFromContext(ctx))
+	if err != nil {
+		message := "Erro na verificação em dois fatores"
+		switch {
+		case errors.Is(err, services.ErrTwoFactorInvalidCode):
+			message = "Código de verificação inválido"
+		case errors.Is(err, services.ErrTwoFactorLocked):
+			message = "Muitas tentativas inválidas. Tente novamente mais tarde."
+		case errors.Is(err, services.ErrSessionInvalid):
+			message = "Verificação expirada. Faça login novamente."
+		}
+		return &model.AuthPayload{
+			Success: false,
+			Error:   strPtr(message),
+		}, nil
+	}
+
+	payload := authPayload(tokens, user)
+	payload.RecoveryCodes = recoveryCodes
+	return payload, nil
 }
 
 // CreateUser is the resolver for the createUser field.
 func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUserInput) (*models.User, error) {
-	if !isAdminFromContext(ctx) {
+	role := services.RoleEmployee
+	if input.Role != nil {
+		role = string(*input.Role)
+	}
+	// Granting any role beyond employee takes the same permission as toggleUserRole
+	if role != services.RoleEmployee && services.PolicyScope(accessFromContext(ctx).Role, services.ResourceSettings, services.ActionManage) == services.ScopeNone {
 		return nil, errors.New("acesso negado")
 	}
 
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 
-	role := "user"
-	if input.Role != nil {
-		role = string(*input.Role)
-	}
-
 	user := models.User{
 		ID:       uuid.New().String(),
 		Name:     input.Name,
@@ -255,12 +247,8 @@ func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUse
 
 // UpdateUser is the resolver for the updateUser field.
 func (r *mutationResolver) UpdateUser(ctx context.Context, id string, input model.UpdateUserInput) (*models.User, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
-	}
-
 	var user models.User
-	if err := r.DB.First(&user, "id = ?", id).Error; err != nil {
+	if err := accessFromContext(ctx).Apply(r.DB, "id").First(&user, "id = ?", id).Error; err != nil {
 		return nil, errors.New("usuário não encontrado")
 	}
 
@@ -283,35 +271,33 @@ func (r *mutationResolver) UpdateUser(ctx context.Context, id string, input mode
 
 // DeleteUser is the resolver for the deleteUser field.
 func (r *mutationResolver) DeleteUser(ctx context.Context, id string) (bool, error) {
-	if !isAdminFromContext(ctx) {
-		return false, errors.New("acesso negado")
+	if !accessFromContext(ctx).Allows(id) {
+		return false, errors.New("usuário não encontrado")
 	}
 
 	if err := r.DB.Delete(&models.User{}, "id = ?", id).Error; err != nil {
 		return false, errors.New("erro ao deletar usuário")
 	}
+	services.Sessions.RevokeAll(id, models.SessionRevokedUserDeleted, "")
 	return true, nil
 }
 
 // ResetUserPassword is the resolver for the resetUserPassword field.
 func (r *mutationResolver) ResetUserPassword(ctx context.Context, id string, newPassword string) (bool, error) {
-	if !isAdminFromContext(ctx) {
-		return false, errors.New("acesso negado")
+	if !accessFromContext(ctx).Allows(id) {
+		return false, errors.New("usuário não encontrado")
 	}
 
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err := r.DB.Model(&models.User{}).Where("id = ?", id).Update("password", string(hashedPassword)).Error; err != nil {
 		return false, errors.New("erro ao resetar senha")
 	}
+	services.Sessions.RevokeAll(id, models.SessionRevokedPasswordReset, "")
 	return true, nil
 }
 
 // ToggleUserRole is the resolver for the toggleUserRole field.
 func (r *mutationResolver) ToggleUserRole(ctx context.Context, id string) (*models.User, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
-	}
-
 	var user models.User
 	if err := r.DB.First(&user, "id = ?", id).Error; err != nil {
 		return nil, errors.New("usuário não encontrado")
@@ -371,6 +357,11 @@ func (r *mutationResolver) UpdateFullProfile(ctx context.Context, input model.Up
 
 	cleanCPF := strings.ReplaceAll(strings.ReplaceAll(user.CPF, ".", ""), "-", "")
 
+	// RG e CNH cifrados quando ENCRYPTION_PERSON_DOCUMENTS está ligada
+	if err := sealPersonDocuments(&input); err != nil {
+		return nil, err
+	}
+
 	// Update PessoasFisicasFradema
 	err = r.DB.Exec(`
 		UPDATE dbo.PessoasFisicasFradema
@@ -400,8 +391,8 @@ func (r *mutationResolver) UpdateFullProfile(ctx context.Context, input model.Up
 
 // UpdateColaboradorProfile is the resolver for the updateColaboradorProfile field.
 func (r *mutationResolver) UpdateColaboradorProfile(ctx context.Context, colaboradorID int, input model.UpdateProfileInput) (bool, error) {
-	if !isAdminFromContext(ctx) {
-		return false, errors.New("acesso negado")
+	if !colaboradorReachable(r.DB, accessFromContext(ctx), colaboradorID) {
+		return false, errors.New("colaborador não encontrado")
 	}
 
 	// Get PessoaFisicaId
@@ -414,6 +405,11 @@ func (r *mutationResolver) UpdateColaboradorProfile(ctx context.Context, colabor
 		return false, errors.New("colaborador não encontrado")
 	}
 
+	// RG e CNH cifrados quando ENCRYPTION_PERSON_DOCUMENTS está ligada
+	if err := sealPersonDocuments(&input); err != nil {
+		return false, err
+	}
+
 	// Update PessoasFisicasFradema
 	err = r.DB.Exec(`
 		UPDATE dbo.PessoasFisicasFradema
@@ -466,36 +462,53 @@ func (r *mutationResolver) UploadDocument(ctx context.Context, file graphql.Uplo
 		Type:         typeArg,
 		MimeType:     file.ContentType,
 		Size:         file.Size,
-		Path:         fmt.Sprintf("./uploads/documents/%s/%s", userID, file.Filename),
-		Status:       models.DocumentStatusPending,
+		Path:         services.DocumentKey(userID, filepath.Ext(file.Filename)),
+		Status:       models.DocumentStatusQuarantined,
+		ScanStatus:   models.DocumentScanPending,
 	}
 
 	if description != nil {
 		doc.Description = *description
 	}
 
+	// Arquivo gravado cifrado no armazenamento (ver services.WriteDocumentFile)
+	content, err := io.ReadAll(file.File)
+	if err != nil {
+		return nil, errors.New("erro ao ler arquivo")
+	}
+	if err := services.WriteDocumentFile(&doc, content); err != nil {
+		return nil, errors.New("erro ao salvar arquivo")
+	}
+
 	if err := r.DB.Create(&doc).Error; err != nil {
+		services.DeleteDocumentFile(&doc)
 		return nil, errors.New("erro ao salvar documento")
 	}
 
+	services.DocumentPipeline.Enqueue(doc.ID)
 	return &doc, nil
 }
 
 // DeleteDocument is the resolver for the deleteDocument field.
 func (r *mutationResolver) DeleteDocument(ctx context.Context, id string) (bool, error) {
-	userID, err := getUserIDFromContext(ctx)
-	if err != nil {
+	if _, err := getUserIDFromContext(ctx); err != nil {
 		return false, err
 	}
 
+	// The owner, or whoever manages documents within the owner's scope
 	var doc models.Document
-	if err := r.DB.First(&doc, "id = ? AND user_id = ?", id, userID).Error; err != nil {
-		if !isAdminFromContext(ctx) {
-			return false, errors.New("documento não encontrado")
-		}
+	if err := accessOrOwn(ctx, services.ResourceDocument, services.ActionManage).Apply(r.DB, "user_id").
+		First(&doc, "id = ?", id).Error; err != nil {
+		return false, errors.New("documento não encontrado")
 	}
 
-	if err := r.DB.Delete(&models.Document{}, "id = ?", id).Error; err != nil {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := services.Workflow.Cancel(tx, models.ApprovalSubjectDocument, id); err != nil {
+			return err
+		}
+		return tx.Delete(&models.Document{}, "id = ?", id).Error
+	})
+	if err != nil {
 		return false, errors.New("erro ao deletar documento")
 	}
 	return true, nil
@@ -503,46 +516,37 @@ func (r *mutationResolver) DeleteDocument(ctx context.Context, id string) (bool,
 
 // ApproveDocument is the resolver for the approveDocument field.
 func (r *mutationResolver) ApproveDocument(ctx context.Context, id string) (*models.Document, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	userID, _ := getUserIDFromContext(ctx)
-	now := time.Now()
+	if _, err := services.Workflow.Decide(models.ApprovalSubjectDocument, id, userID, services.ApprovalApprove, ""); err != nil {
+		return nil, err
+	}
 
 	var doc models.Document
 	if err := r.DB.First(&doc, "id = ?", id).Error; err != nil {
 		return nil, errors.New("documento não encontrado")
 	}
-
-	doc.Status = models.DocumentStatusApproved
-	doc.ReviewedBy = &userID
-	doc.ReviewedAt = &now
-
-	r.DB.Save(&doc)
 	return &doc, nil
 }
 
 // RejectDocument is the resolver for the rejectDocument field.
 func (r *mutationResolver) RejectDocument(ctx context.Context, id string, reason string) (*models.Document, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	userID, _ := getUserIDFromContext(ctx)
-	now := time.Now()
+	if _, err := services.Workflow.Decide(models.ApprovalSubjectDocument, id, userID, services.ApprovalReject, reason); err != nil {
+		return nil, err
+	}
 
 	var doc models.Document
 	if err := r.DB.First(&doc, "id = ?", id).Error; err != nil {
 		return nil, errors.New("documento não encontrado")
 	}
-
-	doc.Status = models.DocumentStatusRejected
-	doc.ReviewedBy = &userID
-	doc.ReviewedAt = &now
-	doc.RejectReason = reason
-
-	r.DB.Save(&doc)
 	return &doc, nil
 }
 
@@ -553,18 +557,34 @@ func (r *mutationResolver) CreateVacation(ctx context.Context, input model.Creat
 		return nil, err
 	}
 
-	startDate, _ := time.Parse("2006-01-02", input.StartDate)
-	endDate, _ := time.Parse("2006-01-02", input.EndDate)
-	totalDays := int(endDate.Sub(startDate).Hours()/24) + 1
+	startDate, err := time.Parse("2006-01-02", input.StartDate)
+	if err != nil {
+		return nil, errors.New("data de início inválida")
+	}
+	endDate, err := time.Parse("2006-01-02", input.EndDate)
+	if err != nil {
+		return nil, errors.New("data de término inválida")
+	}
 
-	vacation := models.Vacation{
-		ID:        uuid.New().String(),
+	period, err := services.NewVacationPolicy().Validate(services.VacationPolicyRequest{
 		UserID:    userID,
 		Type:      models.VacationType(input.Type),
 		StartDate: startDate,
 		EndDate:   endDate,
-		TotalDays: totalDays,
-		Status:    models.VacationStatusPending,
+	})
+	if err != nil {
+		return nil, vacationPolicyError(ctx, err)
+	}
+
+	vacation := models.Vacation{
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		Type:         models.VacationType(input.Type),
+		StartDate:    startDate,
+		EndDate:      endDate,
+		TotalDays:    period.TotalDays,
+		BusinessDays: period.BusinessDays,
+		Status:       models.VacationStatusPending,
 	}
 
 	if input.Reason != nil {
@@ -574,9 +594,23 @@ func (r *mutationResolver) CreateVacation(ctx context.Context, input model.Creat
 		vacation.Notes = *input.Notes
 	}
 
-	if err := r.DB.Create(&vacation).Error; err != nil {
+	// The request, its reservation in the vacation ledger and the approval flow are written together
+	var approval *models.ApprovalRequest
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&vacation).Error; err != nil {
+			return err
+		}
+		if _, err := services.NewVacationLedger().SyncVacation(tx, vacation, userID); err != nil {
+			return err
+		}
+		var err error
+		approval, err = services.Workflow.Submit(tx, models.ApprovalSubjectVacation, vacation.ID)
+		return err
+	})
+	if err != nil {
 		return nil, errors.New("erro ao criar solicitação")
 	}
+	services.Workflow.NotifySubmitted(approval)
 
 	return &vacation, nil
 }
@@ -611,19 +645,52 @@ func (r *mutationResolver) UpdateVacation(ctx context.Context, id string, input
 		vacation.Notes = *input.Notes
 	}
 
-	vacation.TotalDays = int(vacation.EndDate.Sub(vacation.StartDate).Hours()/24) + 1
+	period, err := services.NewVacationPolicy().Validate(services.VacationPolicyRequest{
+		UserID:            userID,
+		Type:              vacation.Type,
+		StartDate:         vacation.StartDate,
+		EndDate:           vacation.EndDate,
+		ExcludeVacationID: vacation.ID,
+	})
+	if err != nil {
+		return nil, vacationPolicyError(ctx, err)
+	}
+
+	vacation.TotalDays = period.TotalDays
+	vacation.BusinessDays = period.BusinessDays
 
-	r.DB.Save(&vacation)
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&vacation).Error; err != nil {
+			return err
+		}
+		_, err := services.NewVacationLedger().SyncVacation(tx, vacation, userID)
+		return err
+	})
+	if err != nil {
+		return nil, errors.New("erro ao atualizar solicitação")
+	}
 	return &vacation, nil
 }
 
 // DeleteVacation is the resolver for the deleteVacation field.
 func (r *mutationResolver) DeleteVacation(ctx context.Context, id string) (bool, error) {
-	if !isAdminFromContext(ctx) {
-		return false, errors.New("acesso negado")
+	var vacation models.Vacation
+	if err := accessFromContext(ctx).Apply(r.DB, "user_id").First(&vacation, "id = ?", id).Error; err != nil {
+		return false, errors.New("solicitação não encontrada")
 	}
 
-	if err := r.DB.Delete(&models.Vacation{}, "id = ?", id).Error; err != nil {
+	actorID, _ := getUserIDFromContext(ctx)
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := services.Workflow.Cancel(tx, models.ApprovalSubjectVacation, id); err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Vacation{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		_, err := services.NewVacationLedger().ClearVacation(tx, vacation, actorID)
+		return err
+	})
+	if err != nil {
 		return false, errors.New("erro ao deletar solicitação")
 	}
 	return true, nil
@@ -631,27 +698,28 @@ func (r *mutationResolver) DeleteVacation(ctx context.Context, id string) (bool,
 
 // ApproveVacation is the resolver for the approveVacation field.
 func (r *mutationResolver) ApproveVacation(ctx context.Context, id string, input model.VacationApprovalInput) (*models.Vacation, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	userID, _ := getUserIDFromContext(ctx)
-	now := time.Now()
+	decision := services.ApprovalApprove
+	comment := ""
+	if models.VacationStatus(input.Status) == models.VacationStatusRejected {
+		decision = services.ApprovalReject
+	}
+	if input.RejectReason != nil {
+		comment = *input.RejectReason
+	}
+
+	if _, err := services.Workflow.Decide(models.ApprovalSubjectVacation, id, userID, decision, comment); err != nil {
+		return nil, err
+	}
 
 	var vacation models.Vacation
 	if err := r.DB.First(&vacation, "id = ?", id).Error; err != nil {
 		return nil, errors.New("solicitação não encontrada")
 	}
-
-	vacation.Status = models.VacationStatus(input.Status)
-	vacation.ApprovedBy = &userID
-	vacation.ApprovedAt = &now
-
-	if input.RejectReason != nil {
-		vacation.RejectReason = *input.RejectReason
-	}
-
-	r.DB.Save(&vacation)
 	return &vacation, nil
 }
 
@@ -662,27 +730,32 @@ func (r *mutationResolver) CancelVacation(ctx context.Context, id string) (*mode
 		return nil, err
 	}
 
+	// The requester, or whoever manages vacations within the requester's scope
 	var vacation models.Vacation
-	query := r.DB.Where("id = ?", id)
-	if !isAdminFromContext(ctx) {
-		query = query.Where("user_id = ?", userID)
-	}
-
-	if err := query.First(&vacation).Error; err != nil {
+	query := accessOrOwn(ctx, services.ResourceVacation, services.ActionManage).Apply(r.DB, "user_id")
+	if err := query.First(&vacation, "id = ?", id).Error; err != nil {
 		return nil, errors.New("solicitação não encontrada")
 	}
 
 	vacation.Status = models.VacationStatusCanceled
-	r.DB.Save(&vacation)
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&vacation).Error; err != nil {
+			return err
+		}
+		if _, err := services.NewVacationLedger().SyncVacation(tx, vacation, userID); err != nil {
+			return err
+		}
+		return services.Workflow.Cancel(tx, models.ApprovalSubjectVacation, vacation.ID)
+	})
+	if err != nil {
+		return nil, errors.New("erro ao cancelar solicitação")
+	}
+	r.Events.Publish(services.EventVacationUpdated, vacation.UserID, vacation)
 	return &vacation, nil
 }
 
 // CreateCalendarEvent is the resolver for the createCalendarEvent field.
 func (r *mutationResolver) CreateCalendarEvent(ctx context.Context, input model.CreateCalendarEventInput) (*models.CalendarEvent, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
-	}
-
 	userID, _ := getUserIDFromContext(ctx)
 	startDate, _ := time.Parse("2006-01-02", input.StartDate)
 	endDate, _ := time.Parse("2006-01-02", input.EndDate)
@@ -715,10 +788,6 @@ func (r *mutationResolver) CreateCalendarEvent(ctx context.Context, input model.
 
 // UpdateCalendarEvent is the resolver for the updateCalendarEvent field.
 func (r *mutationResolver) UpdateCalendarEvent(ctx context.Context, id string, input model.UpdateCalendarEventInput) (*models.CalendarEvent, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
-	}
-
 	var event models.CalendarEvent
 	if err := r.DB.First(&event, "id = ?", id).Error; err != nil {
 		return nil, errors.New("evento não encontrado")
@@ -754,10 +823,6 @@ func (r *mutationResolver) UpdateCalendarEvent(ctx context.Context, id string, i
 
 // DeleteCalendarEvent is the resolver for the deleteCalendarEvent field.
 func (r *mutationResolver) DeleteCalendarEvent(ctx context.Context, id string) (bool, error) {
-	if !isAdminFromContext(ctx) {
-		return false, errors.New("acesso negado")
-	}
-
 	if err := r.DB.Delete(&models.CalendarEvent{}, "id = ?", id).Error; err != nil {
 		return false, errors.New("erro ao deletar evento")
 	}
@@ -823,7 +888,7 @@ func (r *queryResolver) Me(ctx context.Context) (*models.User, error) {
 // User is the resolver for the user field.
 func (r *queryResolver) User(ctx context.Context, id string) (*models.User, error) {
 	var user models.User
-	if err := r.DB.First(&user, "id = ?", id).Error; err != nil {
+	if err := accessFromContext(ctx).Apply(r.DB, "id").First(&user, "id = ?", id).Error; err != nil {
 		return nil, errors.New("usuário não encontrado")
 	}
 	return &user, nil
@@ -831,11 +896,7 @@ func (r *queryResolver) User(ctx context.Context, id string) (*models.User, erro
 
 // Users is the resolver for the users field.
 func (r *queryResolver) Users(ctx context.Context, search *string, page *int, perPage *int) (*model.UserConnection, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
-	}
-
-	query := r.DB.Model(&models.User{})
+	query := accessFromContext(ctx).Apply(r.DB.Model(&models.User{}), "id")
 
 	if search != nil && *search != "" {
 		searchTerm := "%" + *search + "%"
@@ -874,12 +935,8 @@ func (r *queryResolver) Users(ctx context.Context, search *string, page *int, pe
 
 // Colaboradores is the resolver for the colaboradores field.
 func (r *queryResolver) Colaboradores(ctx context.Context, filter *model.ColaboradorFilterInput, page *int, perPage *int) (*model.ColaboradorConnection, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
-	}
-
-	whereClause := "WHERE 1=1"
-	args := []interface{}{}
+	scope, args := colaboradorScope(accessFromContext(ctx))
+	whereClause := "WHERE 1=1" + scope
 
 	if filter != nil {
 		if filter.Search != nil && *filter.Search != "" {
@@ -926,6 +983,7 @@ func (r *queryResolver) Colaboradores(ctx context.Context, filter *model.Colabor
 
 	// Check which have system users
 	for _, c := range colaboradores {
+		openColaboradorDocuments(c)
 		if c.Cpf != nil {
 			var count int64
 			cleanCPF := strings.ReplaceAll(strings.ReplaceAll(*c.Cpf, ".", ""), "-", "")
@@ -942,6 +1000,10 @@ func (r *queryResolver) Colaboradores(ctx context.Context, filter *model.Colabor
 
 // Colaborador is the resolver for the colaborador field.
 func (r *queryResolver) Colaborador(ctx context.Context, id int) (*model.Colaborador, error) {
+	if !colaboradorReachable(r.DB, accessFromContext(ctx), id) {
+		return nil, errors.New("colaborador não encontrado")
+	}
+
 	var colaborador model.Colaborador
 	err := r.DB.Raw(`
 		SELECT c.Id, p.Cpf, p.Nome, p.Codinome, p.EmailEmpresarial, p.EmailPessoal,
@@ -956,6 +1018,7 @@ func (r *queryResolver) Colaborador(ctx context.Context, id int) (*model.Colabor
 	if err != nil {
 		return nil, errors.New("colaborador não encontrado")
 	}
+	openColaboradorDocuments(&colaborador)
 
 	return &colaborador, nil
 }
@@ -979,6 +1042,7 @@ func (r *queryResolver) SearchColaboradores(ctx context.Context, search string)
 
 	// Check which have system users
 	for _, c := range colaboradores {
+		openColaboradorDocuments(c)
 		if c.Cpf != nil {
 			var count int64
 			cleanCPF := strings.ReplaceAll(strings.ReplaceAll(*c.Cpf, ".", ""), "-", "")
@@ -1041,14 +1105,16 @@ func (r *queryResolver) FullProfile(ctx context.Context) (*model.FullProfile, er
 	if err != nil {
 		return nil, errors.New("perfil não encontrado")
 	}
+	profile.Rg = services.OpenPersonDocument(profile.Rg)
+	profile.Cnh = services.OpenPersonDocument(profile.Cnh)
 
 	return &profile, nil
 }
 
 // ColaboradorProfile is the resolver for the colaboradorProfile field.
 func (r *queryResolver) ColaboradorProfile(ctx context.Context, colaboradorID int) (*model.FullProfile, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
+	if !colaboradorReachable(r.DB, accessFromContext(ctx), colaboradorID) {
+		return nil, errors.New("perfil não encontrado")
 	}
 
 	var profile model.FullProfile
@@ -1065,17 +1131,15 @@ func (r *queryResolver) ColaboradorProfile(ctx context.Context, colaboradorID in
 	if err != nil {
 		return nil, errors.New("perfil não encontrado")
 	}
+	profile.Rg = services.OpenPersonDocument(profile.Rg)
+	profile.Cnh = services.OpenPersonDocument(profile.Cnh)
 
 	return &profile, nil
 }
 
 // Documents is the resolver for the documents field.
 func (r *queryResolver) Documents(ctx context.Context, filter *model.DocumentFilterInput, page *int, perPage *int) (*model.DocumentConnection, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
-	}
-
-	query := r.DB.Model(&models.Document{})
+	query := accessFromContext(ctx).Apply(r.DB.Model(&models.Document{}), "user_id")
 
 	if filter != nil {
 		if filter.UserID != nil {
@@ -1142,15 +1206,14 @@ func (r *queryResolver) MyDocuments(ctx context.Context) ([]*models.Document, er
 
 // DocumentStats is the resolver for the documentStats field.
 func (r *queryResolver) DocumentStats(ctx context.Context) (*model.DocumentStats, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
-	}
+	access := accessFromContext(ctx)
+	documents := func() *gorm.DB { return access.Apply(r.DB.Model(&models.Document{}), "user_id") }
 
 	var total, pending, approved, rejected int64
-	r.DB.Model(&models.Document{}).Count(&total)
-	r.DB.Model(&models.Document{}).Where("status = ?", "pending").Count(&pending)
-	r.DB.Model(&models.Document{}).Where("status = ?", "approved").Count(&approved)
-	r.DB.Model(&models.Document{}).Where("status = ?", "rejected").Count(&rejected)
+	documents().Count(&total)
+	documents().Where("status = ?", "pending").Count(&pending)
+	documents().Where("status = ?", "approved").Count(&approved)
+	documents().Where("status = ?", "rejected").Count(&rejected)
 
 	return &model.DocumentStats{
 		TotalDocuments:    int(total),
@@ -1162,11 +1225,7 @@ func (r *queryResolver) DocumentStats(ctx context.Context) (*model.DocumentStats
 
 // Vacations is the resolver for the vacations field.
 func (r *queryResolver) Vacations(ctx context.Context, filter *model.VacationFilterInput, page *int, perPage *int) (*model.VacationConnection, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
-	}
-
-	query := r.DB.Model(&models.Vacation{})
+	query := accessFromContext(ctx).Apply(r.DB.Model(&models.Vacation{}), "user_id")
 
 	if filter != nil {
 		if filter.UserID != nil {
@@ -1238,44 +1297,39 @@ func (r *queryResolver) MyVacationBalance(ctx context.Context) (*models.Vacation
 		return nil, err
 	}
 
-	var balance models.VacationBalance
-	if err := r.DB.Where("user_id = ?", userID).First(&balance).Error; err != nil {
-		// Create default balance
-		balance = models.VacationBalance{
-			ID:            uuid.New().String(),
-			UserID:        userID,
-			TotalDays:     30,
-			UsedDays:      0,
-			PendingDays:   0,
-			AvailableDays: 30,
-			PeriodStart:   time.Now(),
-			PeriodEnd:     time.Now().AddDate(1, 0, 0),
-			AbonoDays:     3,
-			UsedAbono:     0,
-		}
-		r.DB.Create(&balance)
+	balance, err := services.NewVacationLedger().Balance(r.DB, userID)
+	if err != nil {
+		return nil, errors.New("erro ao calcular saldo de férias")
 	}
-	return &balance, nil
+	return balance, nil
 }
 
 // VacationStats is the resolver for the vacationStats field.
 func (r *queryResolver) VacationStats(ctx context.Context) (*models.VacationStats, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
-	}
+	access := accessFromContext(ctx)
+	vacations := func() *gorm.DB { return access.Apply(r.DB.Model(&models.Vacation{}), "user_id") }
 
 	var stats models.VacationStats
-	r.DB.Model(&models.Vacation{}).Count(&stats.TotalRequests)
-	r.DB.Model(&models.Vacation{}).Where("status = ?", "pending").Count(&stats.PendingRequests)
-	r.DB.Model(&models.Vacation{}).Where("status = ?", "approved").Count(&stats.ApprovedRequests)
-	r.DB.Model(&models.Vacation{}).Where("status = ?", "rejected").Count(&stats.RejectedRequests)
+	vacations().Count(&stats.TotalRequests)
+	vacations().Where("status = ?", "pending").Count(&stats.PendingRequests)
+	vacations().Where("status = ?", "approved").Count(&stats.ApprovedRequests)
+	vacations().Where("status = ?", "rejected").Count(&stats.RejectedRequests)
 
 	return &stats, nil
 }
 
 // TeamVacations is the resolver for the teamVacations field.
 func (r *queryResolver) TeamVacations(ctx context.Context, month *int, year *int) ([]*models.Vacation, error) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Everyone sees their team; roles reading vacations further also see their scope
 	query := r.DB.Model(&models.Vacation{}).Where("status = ?", "approved")
+	if access := accessOrOwn(ctx, services.ResourceVacation, services.ActionRead); !access.All() {
+		query = query.Where("user_id IN ?", append(append(services.Org.TeamOf(userID), userID), access.UserIDs()...))
+	}
 
 	if month != nil && year != nil {
 		startDate := time.Date(*year, time.Month(*month), 1, 0, 0, 0, 0, time.UTC)
@@ -1349,10 +1403,6 @@ func (r *queryResolver) MySurveyResults(ctx context.Context) (*models.SurveyResu
 
 // DashboardStats is the resolver for the dashboardStats field.
 func (r *queryResolver) DashboardStats(ctx context.Context) (*model.DashboardStats, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
-	}
-
 	var totalUsers, totalAdmins, totalDocs, pendingDocs, totalVac, pendingVac int64
 	r.DB.Model(&models.User{}).Count(&totalUsers)
 	r.DB.Model(&models.User{}).Where("role = ?", "admin").Count(&totalAdmins)
@@ -1373,10 +1423,6 @@ func (r *queryResolver) DashboardStats(ctx context.Context) (*model.DashboardSta
 
 // AuditLogs is the resolver for the auditLogs field.
 func (r *queryResolver) AuditLogs(ctx context.Context, userID *string, page *int, perPage *int) ([]*models.AuditLog, error) {
-	if !isAdminFromContext(ctx) {
-		return nil, errors.New("acesso negado")
-	}
-
 	query := r.DB.Model(&models.AuditLog{})
 
 	if userID != nil {
@@ -1440,18 +1486,133 @@ func (r *queryResolver) ValidateCpf(ctx context.Context, cpf string) (*model.CPF
 
 // VacationUpdated is the resolver for the vacationUpdated field.
 func (r *subscriptionResolver) VacationUpdated(ctx context.Context) (<-chan *models.Vacation, error) {
-	// TODO: Implement WebSocket subscriptions
-	return nil, errors.New("subscriptions não implementadas")
+	if _, err := getUserIDFromContext(ctx); err != nil {
+		return nil, err
+	}
+	access := accessOrOwn(ctx, services.ResourceVacation, services.ActionRead)
+
+	events, unsubscribe := r.Events.Subscribe(services.EventVacationUpdated)
+	out := make(chan *models.Vacation, 1)
+
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok := <-events:
+				if !ok {
+					return
+				}
+				// Only requests within the caller's vacation:read scope
+				if !access.Allows(evt.UserID) {
+					continue
+				}
+				var vacation models.Vacation
+				if err := json.Unmarshal(evt.Payload, &vacation); err != nil {
+					continue
+				}
+				select {
+				case out <- &vacation:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
 }
 
 // DocumentUpdated is the resolver for the documentUpdated field.
 func (r *subscriptionResolver) DocumentUpdated(ctx context.Context) (<-chan *models.Document, error) {
-	return nil, errors.New("subscriptions não implementadas")
+	if _, err := getUserIDFromContext(ctx); err != nil {
+		return nil, err
+	}
+	access := accessOrOwn(ctx, services.ResourceDocument, services.ActionRead)
+
+	events, unsubscribe := r.Events.Subscribe(services.EventDocumentUpdated)
+	out := make(chan *models.Document, 1)
+
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok := <-events:
+				if !ok {
+					return
+				}
+				if !access.Allows(evt.UserID) {
+					continue
+				}
+				var doc models.Document
+				if err := json.Unmarshal(evt.Payload, &doc); err != nil {
+					continue
+				}
+				select {
+				case out <- &doc:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
 }
 
 // NewNotification is the resolver for the newNotification field.
 func (r *subscriptionResolver) NewNotification(ctx context.Context) (<-chan *model.Notification, error) {
-	return nil, errors.New("subscriptions não implementadas")
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events, unsubscribe := r.Events.Subscribe(services.EventNotificationCreated)
+	out := make(chan *model.Notification, 1)
+
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok := <-events:
+				if !ok {
+					return
+				}
+				// Notificações são sempre pessoais, inclusive para admins
+				if evt.UserID != userID {
+					continue
+				}
+				var notification models.Notification
+				if err := json.Unmarshal(evt.Payload, &notification); err != nil {
+					continue
+				}
+				select {
+				case out <- &model.Notification{
+					ID:        notification.ID,
+					Type:      string(notification.Type),
+					Title:     notification.Title,
+					Message:   notification.Message,
+					CreatedAt: notification.CreatedAt,
+				}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
 }
 
 // ============== TYPE RESOLVERS ==============
@@ -1473,10 +1634,11 @@ func (r *surveyResultsResolver) Categories(ctx context.Context, obj *models.Surv
 
 // Role is the resolver for the role field.
 func (r *userResolver) Role(ctx context.Context, obj *models.User) (model.UserRole, error) {
-	if obj.Role == "admin" {
-		return model.UserRoleAdmin, nil
+	role, err := services.NormalizeRole(obj.Role)
+	if err != nil {
+		return model.UserRoleUser, nil
 	}
-	return model.UserRoleUser, nil
+	return model.UserRole(role), nil
 }
 
 // Documents is the resolver for the documents field.
@@ -1553,12 +1715,3 @@ type subscriptionResolver struct{ *Resolver }
 type surveyResultsResolver struct{ *Resolver }
 type userResolver struct{ *Resolver }
 type vacationStatsResolver struct{ *Resolver }
-
-// Helper functions
-func strPtr(s string) *string {
-	return &s
-}
-
-func intPtr(i int) *int {
-	return &i
-}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/frappyou/backend/config"
 	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -112,7 +113,7 @@ func GetAllUsers(c *fiber.Ctx) error {
 	roleJoin := ""
 	if role != "" {
 		roleJoin = "LEFT JOIN users u ON REPLACE(REPLACE(REPLACE(p.Cpf, '.', ''), '-', ''), ' ', '') = REPLACE(REPLACE(REPLACE(u.cpf, '.', ''), '-', ''), ' ', '')"
-		if role == "admin" {
+		if role != services.RoleEmployee {
 			whereConditions = append(whereConditions, "u.role = @role")
 		} else {
 			// Para role="user", incluir quem tem role='user' OU quem não tem registro na tabela users
@@ -481,10 +482,10 @@ func AdminCreateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Define role
-	role := "user"
-	if req.Role == "admin" {
-		role = "admin"
+	// Define role (papéis desconhecidos viram colaborador)
+	role, err := services.NormalizeRole(req.Role)
+	if err != nil {
+		role = services.RoleEmployee
 	}
 
 	// Verifica se já existe uma pessoa física com este CPF na tabela PessoasFisicasFradema
@@ -677,15 +678,17 @@ func UpdateUserRole(c *fiber.Ctx) error {
 	}
 
 	// Valida role
-	if req.Role != "user" && req.Role != "admin" {
+	role, err := services.NormalizeRole(req.Role)
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
-			"error":   "Role inválido. Use 'user' ou 'admin'",
+			"error":   "Role inválido. Use um de: " + strings.Join(services.Roles(), ", "),
 		})
 	}
+	req.Role = role
 
 	// Não permite remover o próprio admin
-	if targetUserID == adminID && req.Role == "user" {
+	if targetUserID == adminID && req.Role != services.RoleAdmin {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"error":   "Você não pode remover seu próprio acesso de admin",
@@ -799,6 +802,11 @@ func UpdateUserByAdmin(c *fiber.Ctx) error {
 		})
 	}
 
+	// Troca de senha pelo admin encerra as sessões abertas
+	if req.Password != nil && *req.Password != "" {
+		services.Sessions.RevokeAll(targetUserID, models.SessionRevokedPasswordReset, "")
+	}
+
 	return c.JSON(fiber.Map{
 		"success": true,
 		"user":    targetUser.ToResponse(),
@@ -1181,6 +1189,9 @@ func AdminResetPassword(c *fiber.Ctx) error {
 		})
 	}
 
+	// Encerra as sessões abertas com a senha antiga
+	services.Sessions.RevokeAll(targetUserID, models.SessionRevokedPasswordReset, "")
+
 	// Log de auditoria
 	CreateAuditLog(adminID, admin.Name, admin.Email, models.ActionUpdate, models.EntityUser, targetUserID, targetUser.Name, "password", "***", "***", "Resetou senha do usuário", c.IP(), c.Get("User-Agent"))
 
@@ -1229,6 +1240,9 @@ func DeleteUser(c *fiber.Ctx) error {
 		})
 	}
 
+	// Encerra as sessões do usuário removido
+	services.Sessions.RevokeAll(targetUserID, models.SessionRevokedUserDeleted, "")
+
 	// Log de auditoria
 	CreateAuditLog(adminID, admin.Name, admin.Email, models.ActionDelete, models.EntityUser, targetUserID, targetUser.Name, "", "", "", "Deletou usuário do sistema", c.IP(), c.Get("User-Agent"))
 
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ==================== CAIXA DE APROVAÇÕES ====================
+
+// approvalErrorStatus converte os erros do workflow em status HTTP
+func approvalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrApprovalNotFound):
+		return 404
+	case errors.Is(err, services.ErrApprovalForbidden), errors.Is(err, services.ErrApprovalSelf):
+		return 403
+	case errors.Is(err, services.ErrApprovalUnknownSubject), errors.Is(err, services.ErrInvalidWorkflowChain):
+		return 400
+	default:
+		return 500
+	}
+}
+
+// approvalMessage monta a mensagem de retorno de uma decisão
+func approvalMessage(outcome *services.ApprovalOutcome, finalMsg string) string {
+	if outcome.Final || outcome.NextTask == nil {
+		return finalMsg
+	}
+	return "Aprovação registrada. Aguardando etapa: " + outcome.NextTask.StepName
+}
+
+// GetMyApprovalInbox retorna as aprovações pendentes do usuário (gestor, RH, delegado)
+func GetMyApprovalInbox(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	tasks, err := services.Workflow.Inbox(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao buscar aprovações pendentes",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"tasks":   tasks,
+		"total":   len(tasks),
+	})
+}
+
+// DecideApprovalTask aprova ou rejeita uma tarefa da caixa de aprovações
+func DecideApprovalTask(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	taskID := c.Params("id")
+
+	var req struct {
+		Decision services.ApprovalDecision `json:"decision"`
+		Comment  string                    `json:"comment"`
+	}
+	if err := c.BodyParser(&req); err != nil || (req.Decision != services.ApprovalApprove && req.Decision != services.ApprovalReject) {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Informe a decisão: approve ou reject",
+		})
+	}
+	if req.Decision == services.ApprovalReject && req.Comment == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Informe o motivo da rejeição",
+		})
+	}
+
+	outcome, err := services.Workflow.DecideTask(taskID, userID, req.Decision, req.Comment)
+	if err != nil {
+		return c.Status(approvalErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	finalMsg := "Solicitação aprovada com sucesso"
+	if req.Decision == services.ApprovalReject {
+		finalMsg = "Solicitação rejeitada"
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": approvalMessage(outcome, finalMsg),
+		"request": outcome.Request,
+	})
+}
+
+// GetApprovalHistory retorna o histórico de aprovação de um item
+func GetApprovalHistory(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	subjectType := models.ApprovalSubjectType(c.Params("type"))
+	subjectID := c.Params("id")
+
+	requests, err := services.Workflow.History(subjectType, subjectID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao buscar histórico de aprovação",
+		})
+	}
+
+	// Apenas o solicitante, quem participou do fluxo ou administradores
+	if c.Locals("role") != "admin" && !approvalParticipant(requests, userID) {
+		return c.Status(404).JSON(fiber.Map{
+			"success": false,
+			"message": "Histórico não encontrado",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"requests": requests,
+	})
+}
+
+func approvalParticipant(requests []models.ApprovalRequest, userID string) bool {
+	for _, r := range requests {
+		if r.RequesterID == userID {
+			return true
+		}
+		for _, t := range r.Tasks {
+			if (t.AssigneeID != nil && *t.AssigneeID == userID) || (t.DecidedBy != nil && *t.DecidedBy == userID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ==================== DELEGAÇÕES ====================
+
+// GetMyApprovalDelegations retorna as delegações feitas e recebidas pelo usuário
+func GetMyApprovalDelegations(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var given, received []models.ApprovalDelegation
+	config.DB.Preload("Delegate").Where("delegator_id = ?", userID).Order("start_date DESC").Find(&given)
+	config.DB.Preload("Delegator").Where("delegate_id = ?", userID).Order("start_date DESC").Find(&received)
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"given":    given,
+		"received": received,
+	})
+}
+
+// CreateApprovalDelegation delega as aprovações do usuário durante um período (ex: férias)
+func CreateApprovalDelegation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req struct {
+		DelegateID  string                     `json:"delegate_id"`
+		StartDate   string                     `json:"start_date"`
+		EndDate     string                     `json:"end_date"`
+		SubjectType models.ApprovalSubjectType `json:"subject_type"`
+		Reason      string                     `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Dados inválidos",
+		})
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Data de início inválida",
+		})
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil || endDate.Before(startDate) {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Data de término inválida",
+		})
+	}
+
+	if req.DelegateID == "" || req.DelegateID == userID {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Informe outro colaborador como substituto",
+		})
+	}
+	var delegate models.User
+	if err := config.DB.Where("id = ?", req.DelegateID).First(&delegate).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"success": false,
+			"message": "Substituto não encontrado",
+		})
+	}
+
+	delegation := models.ApprovalDelegation{
+		DelegatorID: userID,
+		DelegateID:  req.DelegateID,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		SubjectType: req.SubjectType,
+		Reason:      req.Reason,
+	}
+	if err := config.DB.Create(&delegation).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao criar delegação",
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success":    true,
+		"message":    "Aprovações delegadas para " + delegate.Name,
+		"delegation": delegation,
+	})
+}
+
+// DeleteApprovalDelegation encerra uma delegação do usuário
+func DeleteApprovalDelegation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	delegationID := c.Params("id")
+
+	result := config.DB.Where("id = ? AND delegator_id = ?", delegationID, userID).Delete(&models.ApprovalDelegation{})
+	if result.RowsAffected == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"success": false,
+			"message": "Delegação não encontrada",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Delegação removida",
+	})
+}
+
+// ==================== CADEIAS DE APROVAÇÃO (ADMIN) ====================
+
+// AdminGetWorkflowChains retorna as cadeias de aprovação de cada tipo de item
+func AdminGetWorkflowChains(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+		"chains":  services.Workflow.Chains(),
+	})
+}
+
+// AdminUpdateWorkflowChain substitui as etapas da cadeia de um tipo de item
+func AdminUpdateWorkflowChain(c *fiber.Ctx) error {
+	var chain models.WorkflowChain
+	if err := c.BodyParser(&chain); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Dados inválidos",
+		})
+	}
+	chain.SubjectType = models.ApprovalSubjectType(c.Params("type"))
+
+	saved, err := services.Workflow.SaveChain(chain)
+	if err != nil {
+		return c.Status(approvalErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Cadeia de aprovação atualizada",
+		"chain":   saved,
+	})
+}
+
+// AdminGetAllDelegations lista todas as delegações de aprovação
+func AdminGetAllDelegations(c *fiber.Ctx) error {
+	var delegations []models.ApprovalDelegation
+	config.DB.Preload("Delegator").Preload("Delegate").Order("start_date DESC").Find(&delegations)
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"delegations": delegations,
+	})
+}
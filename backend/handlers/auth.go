@@ -7,6 +7,7 @@ import (
 
 	"github.com/frappyou/backend/config"
 	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -141,8 +142,8 @@ func Signup(c *fiber.Ctx) error {
 		println("Erro ao salvar respostas:", result.Error.Error())
 	}
 
-	// Gera o token JWT
-	token, err := config.GenerateToken(user.ID, user.Role, false)
+	// Abre a sessão e gera os tokens
+	tokens, err := services.Sessions.Create(&user, false, sessionClient(c))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -151,9 +152,11 @@ func Signup(c *fiber.Ctx) error {
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"success": true,
-		"token":   token,
-		"user":    user.ToResponse(),
+		"success":       true,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+		"user":          user.ToResponse(),
 	})
 }
 
@@ -398,6 +401,15 @@ func ActivateAccount(c *fiber.Ctx) error {
 		})
 	}
 
+	// Papéis com 2FA obrigatório cadastram o autenticador no primeiro login
+	if services.TwoFactor.Required(user.Role) {
+		return c.JSON(fiber.Map{
+			"success":                   true,
+			"message":                   "Conta criada com sucesso! No primeiro login você precisará configurar a verificação em dois fatores.",
+			"two_factor_setup_required": true,
+		})
+	}
+
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Conta criada com sucesso! Faça login para continuar.",
@@ -442,8 +454,27 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
-	// Gera o token JWT
-	token, err := config.GenerateToken(user.ID, user.Role, req.RememberMe)
+	// Segundo fator: com 2FA ativo (ou exigido para o papel) a sessão só é aberta
+	// em /auth/2fa/verify, com o código do autenticador
+	challenge, err := services.TwoFactor.LoginChallenge(&user, req.RememberMe)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao iniciar verificação em dois fatores",
+		})
+	}
+	if challenge != nil {
+		return c.JSON(fiber.Map{
+			"success":             true,
+			"two_factor_required": true,
+			"challenge_token":     challenge.ChallengeToken,
+			"setup_required":      challenge.SetupRequired,
+			"enrollment":          challenge.Enrollment,
+		})
+	}
+
+	// Abre a sessão e gera os tokens
+	tokens, err := services.Sessions.Create(&user, req.RememberMe, sessionClient(c))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -452,8 +483,10 @@ func Login(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(fiber.Map{
-		"success": true,
-		"token":   token,
-		"user":    user.ToResponse(),
+		"success":       true,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+		"user":          user.ToResponse(),
 	})
 }
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// requestAccess retorna a abrangência resolvida por middleware.RequirePermission.
+// Sem o middleware na rota, restringe aos dados do próprio usuário.
+func requestAccess(c *fiber.Ctx) *services.Access {
+	if access, ok := c.Locals("access").(*services.Access); ok {
+		return access
+	}
+	userID, _ := c.Locals("user_id").(string)
+	return services.OwnAccess(userID)
+}
+
+// GetMyPermissions retorna o papel efetivo do usuário e suas permissões com abrangência
+func GetMyPermissions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	role, err := services.Authz.RoleOf(userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Usuário não encontrado",
+		})
+	}
+	role = services.Authz.EffectiveRole(userID, role)
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"role":        role,
+		"permissions": services.Permissions(role),
+	})
+}
@@ -1,14 +1,8 @@
 package handlers
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/json"
-	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -18,70 +12,6 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-// ==================== Azure OpenAI Types ====================
-
-type AzureMessage struct {
-	Role         string            `json:"role"`
-	Content      string            `json:"content,omitempty"`
-	FunctionCall *AzureFunctionCall `json:"function_call,omitempty"`
-	Name         string            `json:"name,omitempty"` // Para respostas de função
-}
-
-type AzureFunctionCall struct {
-	Name      string `json:"name"`
-	Arguments string `json:"arguments"`
-}
-
-type AzureFunction struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	Parameters  map[string]interface{} `json:"parameters"`
-}
-
-type AzureChatRequest struct {
-	Messages            []AzureMessage  `json:"messages"`
-	MaxCompletionTokens int             `json:"max_completion_tokens,omitempty"`
-	Stream              bool            `json:"stream,omitempty"`
-	Functions           []AzureFunction `json:"functions,omitempty"`
-	FunctionCall        interface{}     `json:"function_call,omitempty"` // "auto", "none", ou {"name": "func_name"}
-}
-
-type AzureChoice struct {
-	Index        int          `json:"index"`
-	Message      AzureMessage `json:"message"`
-	FinishReason string       `json:"finish_reason"`
-}
-
-type AzureUsage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
-}
-
-type AzureChatResponse struct {
-	ID      string        `json:"id"`
-	Choices []AzureChoice `json:"choices"`
-	Usage   AzureUsage    `json:"usage"`
-}
-
-// StreamChoice para streaming
-type StreamChoice struct {
-	Delta        StreamDelta `json:"delta"`
-	Index        int         `json:"index"`
-	FinishReason *string     `json:"finish_reason"`
-}
-
-type StreamDelta struct {
-	Role         string            `json:"role,omitempty"`
-	Content      string            `json:"content,omitempty"`
-	FunctionCall *AzureFunctionCall `json:"function_call,omitempty"`
-}
-
-type StreamResponse struct {
-	ID      string         `json:"id"`
-	Choices []StreamChoice `json:"choices"`
-}
-
 // ==================== System Prompts ====================
 
 // getSystemPromptWithContext busca contexto completo do usuário e monta prompt enriquecido
@@ -295,29 +225,26 @@ func SendMessage(c *fiber.Ctx) error {
 	}
 	config.DB.Create(&userMessage)
 
-	// Busca histórico recente (últimas 10 mensagens)
-	var recentMessages []models.ChatMessage
-	config.DB.Where("session_id = ?", session.ID).
-		Order("created_at DESC").
-		Limit(10).
-		Find(&recentMessages)
-
-	// Inverte para ordem cronológica
-	for i, j := 0, len(recentMessages)-1; i < j; i, j = i+1, j-1 {
-		recentMessages[i], recentMessages[j] = recentMessages[j], recentMessages[i]
-	}
+	// Busca histórico recente
+	recentMessages := loadRecentMessages(session.ID)
 
-	// Chama Azure OpenAI com contexto enriquecido
-	response, tokens, err := callAzureOpenAI(recentMessages, session.Context, userID)
+	// Chama o modelo com contexto enriquecido; as ferramentas pedidas rodam até a resposta final
+	request := buildLLMRequest(recentMessages, session.Context, userID)
+	ctx := services.WithChatSession(c.UserContext(), session.ID)
+	turn, err := services.ChatAssistant.Run(ctx, services.LLM, userID, request, nil)
+	saveToolMessages(session.ID, userID, turn)
 	if err != nil {
-		log.Printf("Erro Azure OpenAI: %v", err)
+		log.Printf("Erro no provedor de IA: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Desculpe, estou com dificuldades técnicas. Tente novamente em instantes.",
+			"error": chatTechnicalError,
 		})
 	}
+	response, tokens := turn.Content, turn.Usage.TotalTokens
 
-	// Cacheia a resposta se for pergunta comum
-	go cache.SetCachedResponse(req.Message, response, session.Context)
+	// Cacheia a resposta se for pergunta comum (respostas com dados do colaborador não)
+	if turn.ToolCalls == 0 {
+		go cache.SetCachedResponse(req.Message, response, session.Context)
+	}
 
 	// Salva resposta do assistente
 	assistantMessage := models.ChatMessage{
@@ -336,6 +263,7 @@ func SendMessage(c *fiber.Ctx) error {
 		Message:   response,
 		SessionID: session.ID,
 		Tokens:    tokens,
+		Actions:   turn.Actions,
 	})
 }
 
@@ -396,15 +324,7 @@ func SendMessageStream(c *fiber.Ctx) error {
 	config.DB.Create(&userMessage)
 
 	// Busca histórico
-	var recentMessages []models.ChatMessage
-	config.DB.Where("session_id = ?", session.ID).
-		Order("created_at DESC").
-		Limit(10).
-		Find(&recentMessages)
-
-	for i, j := 0, len(recentMessages)-1; i < j; i, j = i+1, j-1 {
-		recentMessages[i], recentMessages[j] = recentMessages[j], recentMessages[i]
-	}
+	recentMessages := loadRecentMessages(session.ID)
 
 	// Configura SSE
 	c.Set("Content-Type", "text/event-stream")
@@ -412,23 +332,26 @@ func SendMessageStream(c *fiber.Ctx) error {
 	c.Set("Connection", "keep-alive")
 	c.Set("Transfer-Encoding", "chunked")
 
-	// Stream da resposta com contexto enriquecido
-	fullResponse, err := streamAzureOpenAI(c, recentMessages, session.Context, userID, session.ID)
+	// Stream da resposta com contexto enriquecido (mesmo loop de ferramentas do SendMessage)
+	request := buildLLMRequest(recentMessages, session.Context, userID)
+	turn, err := streamChatTurn(c, request, userID, session.ID)
+	saveToolMessages(session.ID, userID, turn)
 	if err != nil {
 		log.Printf("Erro streaming: %v", err)
 		return nil
 	}
 
 	// Salva resposta completa
-	if fullResponse != "" {
+	if turn.Content != "" {
 		assistantMessage := models.ChatMessage{
 			SessionID: session.ID,
 			UserID:    userID,
 			Role:      "assistant",
-			Content:   fullResponse,
+			Content:   turn.Content,
+			Tokens:    turn.Usage.TotalTokens,
 		}
 		config.DB.Create(&assistantMessage)
-		updateChatUsageStats(userID, 0)
+		updateChatUsageStats(userID, turn.Usage.TotalTokens)
 	}
 
 	return nil
@@ -470,16 +393,28 @@ func GetChatHistory(c *fiber.Ctx) error {
 		})
 	}
 
-	var messages []models.ChatMessage
+	var history []models.ChatMessage
 	config.DB.Where("session_id = ?", sessionID).
 		Order("created_at ASC").
-		Find(&messages)
+		Find(&history)
+
+	// Pedidos e resultados de ferramentas ficam só para o modelo
+	messages := make([]models.ChatMessage, 0, len(history))
+	for _, msg := range history {
+		if !msg.IsToolExchange() {
+			messages = append(messages, msg)
+		}
+	}
+
+	// Cartões das ações propostas nesta conversa
+	actions, _ := services.ChatActions.List(userID, sessionID)
 
 	return c.JSON(models.ChatSessionResponse{
 		ID:        session.ID,
 		Title:     session.Title,
 		Context:   session.Context,
 		Messages:  messages,
+		Actions:   actions,
 		CreatedAt: session.CreatedAt,
 	})
 }
@@ -586,402 +521,114 @@ func GetChatSuggestions(c *fiber.Ctx) error {
 	return c.JSON(suggestions)
 }
 
-// ==================== Azure OpenAI Functions ====================
+// ==================== Chamada ao modelo ====================
 
-// getAzureFunctions converte nossas funções para o formato Azure
-func getAzureFunctions() []AzureFunction {
-	functionDefs := services.GetAvailableFunctions()
-	azureFunctions := make([]AzureFunction, len(functionDefs))
+// chatMaxTokens limite de tokens de cada resposta do modelo
+const chatMaxTokens = 1000
 
-	for i, fn := range functionDefs {
-		azureFunctions[i] = AzureFunction{
-			Name:        fn.Name,
-			Description: fn.Description,
-			Parameters:  fn.Parameters,
-		}
-	}
-
-	return azureFunctions
-}
+// chatHistoryLimit mensagens recentes enviadas ao modelo (inclui pedidos e resultados de ferramentas)
+const chatHistoryLimit = 20
 
-// processFunctionCall executa uma função chamada pela IA e retorna o resultado
-func processFunctionCall(userID string, functionCall *AzureFunctionCall) (string, error) {
-	cache := services.NewChatCache()
+// chatTechnicalError mensagem ao usuário quando o provedor falha
+const chatTechnicalError = "Desculpe, estou com dificuldades técnicas. Tente novamente em instantes."
 
-	// Tenta buscar do cache primeiro
-	if cached, _ := cache.GetFunctionResult(functionCall.Name, userID, functionCall.Arguments); cached != nil {
-		log.Printf("✅ Cache HIT: função %s", functionCall.Name)
-		resultJSON, _ := json.Marshal(cached.Result)
-		return string(resultJSON), nil
-	}
+// loadRecentMessages histórico recente da sessão em ordem cronológica
+func loadRecentMessages(sessionID string) []models.ChatMessage {
+	var recentMessages []models.ChatMessage
+	config.DB.Where("session_id = ?", sessionID).
+		Order("created_at DESC").
+		Limit(chatHistoryLimit).
+		Find(&recentMessages)
 
-	// Executa a função
-	log.Printf("🔧 Executando função: %s com args: %s", functionCall.Name, functionCall.Arguments)
-	result, err := services.ExecuteFunction(userID, functionCall.Name, functionCall.Arguments)
-	if err != nil {
-		return "", err
+	// Inverte para ordem cronológica
+	for i, j := 0, len(recentMessages)-1; i < j; i, j = i+1, j-1 {
+		recentMessages[i], recentMessages[j] = recentMessages[j], recentMessages[i]
 	}
-
-	// Cacheia o resultado
-	go cache.SetFunctionResult(functionCall.Name, userID, functionCall.Arguments, result)
-
-	resultJSON, _ := json.Marshal(result)
-	return string(resultJSON), nil
+	return recentMessages
 }
 
-func callAzureOpenAI(messages []models.ChatMessage, context string, userID string) (string, int, error) {
-	endpoint := os.Getenv("AZURE_OPENAI_ENDPOINT")
-	apiKey := os.Getenv("AZURE_OPENAI_KEY")
-	deployment := os.Getenv("AZURE_OPENAI_DEPLOYMENT")
-	apiVersion := os.Getenv("AZURE_OPENAI_API_VERSION")
-
-	if endpoint == "" || apiKey == "" || deployment == "" {
-		return "", 0, fmt.Errorf("Azure OpenAI não configurado")
-	}
-
-	if apiVersion == "" {
-		apiVersion = "2024-02-15-preview"
-	}
-
+// buildLLMRequest monta o pedido com o contexto do usuário, a base de conhecimento (RAG),
+// o histórico e as ferramentas disponíveis
+func buildLLMRequest(messages []models.ChatMessage, chatContext string, userID string) services.LLMRequest {
 	// Extrai a última mensagem do usuário para busca RAG
 	userQuery := ""
-	if len(messages) > 0 {
-		userQuery = messages[len(messages)-1].Content
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			userQuery = messages[i].Content
+			break
+		}
 	}
 
-	// Monta mensagens para a API com contexto enriquecido + RAG
-	systemPrompt := getSystemPromptWithContextAndRAG(userID, context, userQuery)
-	azureMessages := []AzureMessage{
+	systemPrompt := getSystemPromptWithContextAndRAG(userID, chatContext, userQuery)
+	llmMessages := []services.LLMMessage{
 		{Role: "system", Content: systemPrompt},
 	}
-
 	for _, msg := range messages {
-		azureMessages = append(azureMessages, AzureMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
-	}
-
-	// Inclui funções disponíveis
-	functions := getAzureFunctions()
-
-	reqBody := AzureChatRequest{
-		Messages:            azureMessages,
-		MaxCompletionTokens: 1000,
-		Functions:           functions,
-		FunctionCall:        "auto", // Deixa a IA decidir quando chamar funções
-	}
-
-	jsonBody, _ := json.Marshal(reqBody)
-
-	url := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
-		strings.TrimSuffix(endpoint, "/"), deployment, apiVersion)
-
-	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("api-key", apiKey)
-
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", 0, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", 0, fmt.Errorf("Azure OpenAI error: %s - %s", resp.Status, string(body))
-	}
-
-	var azureResp AzureChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&azureResp); err != nil {
-		return "", 0, err
-	}
-
-	if len(azureResp.Choices) == 0 {
-		return "", 0, fmt.Errorf("resposta vazia do Azure OpenAI")
-	}
-
-	totalTokens := azureResp.Usage.TotalTokens
-	choice := azureResp.Choices[0]
-
-	// Verifica se a IA quer chamar uma função
-	if choice.FinishReason == "function_call" && choice.Message.FunctionCall != nil {
-		log.Printf("🔧 IA solicitou função: %s", choice.Message.FunctionCall.Name)
-
-		// Executa a função
-		functionResult, err := processFunctionCall(userID, choice.Message.FunctionCall)
-		if err != nil {
-			log.Printf("❌ Erro ao executar função: %v", err)
-			return "Desculpe, não consegui processar sua solicitação. Tente novamente.", totalTokens, nil
+		llmMessage := services.LLMMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCallID: msg.ToolCallID,
+			Name:       msg.ToolName,
 		}
-
-		// Adiciona a chamada da função e o resultado às mensagens
-		azureMessages = append(azureMessages, AzureMessage{
-			Role:         "assistant",
-			FunctionCall: choice.Message.FunctionCall,
-		})
-		azureMessages = append(azureMessages, AzureMessage{
-			Role:    "function",
-			Name:    choice.Message.FunctionCall.Name,
-			Content: functionResult,
-		})
-
-		// Segunda chamada para a IA processar o resultado da função
-		reqBody2 := AzureChatRequest{
-			Messages:            azureMessages,
-			MaxCompletionTokens: 1000,
+		if msg.ToolCalls != "" {
+			json.Unmarshal([]byte(msg.ToolCalls), &llmMessage.ToolCalls)
 		}
+		llmMessages = append(llmMessages, llmMessage)
+	}
 
-		jsonBody2, _ := json.Marshal(reqBody2)
-		req2, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody2))
-		req2.Header.Set("Content-Type", "application/json")
-		req2.Header.Set("api-key", apiKey)
-
-		resp2, err := client.Do(req2)
-		if err != nil {
-			return "", totalTokens, err
-		}
-		defer resp2.Body.Close()
-
-		if resp2.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp2.Body)
-			return "", totalTokens, fmt.Errorf("Azure OpenAI error (function response): %s - %s", resp2.Status, string(body))
-		}
+	return services.LLMRequest{
+		Messages:  services.CompleteToolExchanges(llmMessages),
+		Tools:     services.ChatTools.Available(userID),
+		MaxTokens: chatMaxTokens,
+	}
+}
 
-		var azureResp2 AzureChatResponse
-		if err := json.NewDecoder(resp2.Body).Decode(&azureResp2); err != nil {
-			return "", totalTokens, err
+// saveToolMessages grava no histórico os pedidos de ferramenta e os resultados da rodada
+func saveToolMessages(sessionID string, userID string, turn *services.ChatTurn) {
+	createdAt := time.Now()
+	for i, msg := range turn.Messages {
+		row := models.ChatMessage{
+			SessionID:  sessionID,
+			UserID:     userID,
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCallID: msg.ToolCallID,
+			ToolName:   msg.Name,
+			// Mesma ordem do loop, mesmo com várias mensagens no mesmo instante
+			CreatedAt: createdAt.Add(time.Duration(i) * time.Microsecond),
 		}
-
-		if len(azureResp2.Choices) == 0 {
-			return "", totalTokens, fmt.Errorf("resposta vazia do Azure OpenAI após função")
+		if len(msg.ToolCalls) > 0 {
+			toolCalls, _ := json.Marshal(msg.ToolCalls)
+			row.ToolCalls = string(toolCalls)
 		}
-
-		totalTokens += azureResp2.Usage.TotalTokens
-		return azureResp2.Choices[0].Message.Content, totalTokens, nil
+		config.DB.Create(&row)
 	}
-
-	return choice.Message.Content, totalTokens, nil
 }
 
-func streamAzureOpenAI(c *fiber.Ctx, messages []models.ChatMessage, context string, userID string, sessionID string) (string, error) {
-	endpoint := os.Getenv("AZURE_OPENAI_ENDPOINT")
-	apiKey := os.Getenv("AZURE_OPENAI_KEY")
-	deployment := os.Getenv("AZURE_OPENAI_DEPLOYMENT")
-	apiVersion := os.Getenv("AZURE_OPENAI_API_VERSION")
-
-	log.Printf("Azure OpenAI - Endpoint: %s, Deployment: %s, API Version: %s", endpoint, deployment, apiVersion)
-
-	if endpoint == "" || apiKey == "" || deployment == "" {
-		errMsg := "Azure OpenAI não configurado"
-		log.Printf("Erro: %s", errMsg)
-		c.WriteString(fmt.Sprintf("data: {\"error\": \"%s\"}\n\n", errMsg))
-		c.WriteString("data: [DONE]\n\n")
-		return "", fmt.Errorf(errMsg)
-	}
-
-	if apiVersion == "" {
-		apiVersion = "2024-02-15-preview"
-	}
-
-	// Extrai a última mensagem do usuário para busca RAG
-	userQuery := ""
-	if len(messages) > 0 {
-		userQuery = messages[len(messages)-1].Content
-	}
-
-	// Monta mensagens com contexto enriquecido do usuário + RAG
-	systemPrompt := getSystemPromptWithContextAndRAG(userID, context, userQuery)
-	azureMessages := []AzureMessage{
-		{Role: "system", Content: systemPrompt},
-	}
-
-	for _, msg := range messages {
-		azureMessages = append(azureMessages, AzureMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
-	}
-
-	// Inclui funções disponíveis
-	functions := getAzureFunctions()
-
-	reqBody := AzureChatRequest{
-		Messages:            azureMessages,
-		MaxCompletionTokens: 1000,
-		Stream:              true,
-		Functions:           functions,
-		FunctionCall:        "auto",
-	}
-
-	jsonBody, _ := json.Marshal(reqBody)
-
-	url := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
-		strings.TrimSuffix(endpoint, "/"), deployment, apiVersion)
-
-	log.Printf("Azure OpenAI URL: %s", url)
-
-	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("api-key", apiKey)
-
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("Erro ao chamar Azure OpenAI: %v", err)
-		c.WriteString(fmt.Sprintf("data: {\"error\": \"%s\"}\n\n", err.Error()))
-		c.WriteString("data: [DONE]\n\n")
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	log.Printf("Azure OpenAI Response Status: %d", resp.StatusCode)
-
-	// Verifica se houve erro na resposta
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg := fmt.Sprintf("Azure OpenAI error: %s - %s", resp.Status, string(body))
-		log.Printf("Erro Azure OpenAI: %s", errMsg)
-		c.WriteString(fmt.Sprintf("data: {\"error\": \"%s\"}\n\n", resp.Status))
-		c.WriteString("data: [DONE]\n\n")
-		return "", fmt.Errorf(errMsg)
-	}
+// writeStreamEvent envia um evento SSE com o payload em JSON
+func writeStreamEvent(c *fiber.Ctx, payload fiber.Map) {
+	data, _ := json.Marshal(payload)
+	c.WriteString("data: " + string(data) + "\n\n")
+}
 
+// streamChatTurn executa o loop do assistente enviando o texto em SSE conforme chega;
+// as ações que aguardam confirmação seguem como eventos "action" no final
+func streamChatTurn(c *fiber.Ctx, req services.LLMRequest, userID string, sessionID string) (*services.ChatTurn, error) {
 	// Envia session_id primeiro
-	c.WriteString(fmt.Sprintf("data: {\"session_id\": \"%s\"}\n\n", sessionID))
-
-	var fullResponse strings.Builder
-	var functionName strings.Builder
-	var functionArgs strings.Builder
-	var isFunctionCall bool
-	var finishReason string
-
-	scanner := bufio.NewScanner(resp.Body)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if strings.HasPrefix(line, "data: ") {
-			data := strings.TrimPrefix(line, "data: ")
-
-			if data == "[DONE]" {
-				// Verifica se era uma function_call
-				if isFunctionCall && functionName.Len() > 0 {
-					// Executa a função
-					log.Printf("🔧 Streaming: Executando função %s", functionName.String())
-
-					funcCall := &AzureFunctionCall{
-						Name:      functionName.String(),
-						Arguments: functionArgs.String(),
-					}
-
-					functionResult, err := processFunctionCall(userID, funcCall)
-					if err != nil {
-						log.Printf("❌ Erro ao executar função: %v", err)
-						c.WriteString("data: {\"content\": \"Desculpe, não consegui processar sua solicitação.\"}\n\n")
-						c.WriteString("data: [DONE]\n\n")
-						return "Erro ao executar função", nil
-					}
-
-					// Segunda chamada para processar o resultado (sem streaming para simplificar)
-					azureMessages = append(azureMessages, AzureMessage{
-						Role:         "assistant",
-						FunctionCall: funcCall,
-					})
-					azureMessages = append(azureMessages, AzureMessage{
-						Role:    "function",
-						Name:    funcCall.Name,
-						Content: functionResult,
-					})
-
-					// Faz segunda chamada em streaming
-					reqBody2 := AzureChatRequest{
-						Messages:            azureMessages,
-						MaxCompletionTokens: 1000,
-						Stream:              true,
-					}
-
-					jsonBody2, _ := json.Marshal(reqBody2)
-					req2, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody2))
-					req2.Header.Set("Content-Type", "application/json")
-					req2.Header.Set("api-key", apiKey)
-
-					resp2, err := client.Do(req2)
-					if err != nil {
-						log.Printf("Erro na segunda chamada: %v", err)
-						c.WriteString("data: [DONE]\n\n")
-						return fullResponse.String(), nil
-					}
-					defer resp2.Body.Close()
-
-					// Processa segunda resposta
-					scanner2 := bufio.NewScanner(resp2.Body)
-					for scanner2.Scan() {
-						line2 := scanner2.Text()
-						if strings.HasPrefix(line2, "data: ") {
-							data2 := strings.TrimPrefix(line2, "data: ")
-							if data2 == "[DONE]" {
-								continue
-							}
-							var streamResp2 StreamResponse
-							if err := json.Unmarshal([]byte(data2), &streamResp2); err == nil {
-								if len(streamResp2.Choices) > 0 {
-									content := streamResp2.Choices[0].Delta.Content
-									if content != "" {
-										fullResponse.WriteString(content)
-										escaped, _ := json.Marshal(content)
-										c.WriteString(fmt.Sprintf("data: {\"content\": %s}\n\n", string(escaped)))
-									}
-								}
-							}
-						}
-					}
-				}
-				c.WriteString("data: [DONE]\n\n")
-				continue
-			}
+	writeStreamEvent(c, fiber.Map{"session_id": sessionID})
 
-			var streamResp StreamResponse
-			if err := json.Unmarshal([]byte(data), &streamResp); err == nil {
-				if len(streamResp.Choices) > 0 {
-					choice := streamResp.Choices[0]
-
-					// Verifica finish_reason
-					if choice.FinishReason != nil {
-						finishReason = *choice.FinishReason
-						if finishReason == "function_call" {
-							isFunctionCall = true
-						}
-					}
-
-					// Conteúdo normal
-					if choice.Delta.Content != "" {
-						fullResponse.WriteString(choice.Delta.Content)
-						escaped, _ := json.Marshal(choice.Delta.Content)
-						c.WriteString(fmt.Sprintf("data: {\"content\": %s}\n\n", string(escaped)))
-					}
-
-					// Function call
-					if choice.Delta.FunctionCall != nil {
-						isFunctionCall = true
-						if choice.Delta.FunctionCall.Name != "" {
-							functionName.WriteString(choice.Delta.FunctionCall.Name)
-						}
-						if choice.Delta.FunctionCall.Arguments != "" {
-							functionArgs.WriteString(choice.Delta.FunctionCall.Arguments)
-						}
-					}
-				}
-			}
-		}
+	ctx := services.WithChatSession(c.UserContext(), sessionID)
+	turn, err := services.ChatAssistant.Run(ctx, services.LLM, userID, req, func(content string) {
+		writeStreamEvent(c, fiber.Map{"content": content})
+	})
+	for _, action := range turn.Actions {
+		writeStreamEvent(c, fiber.Map{"action": action})
 	}
-
-	if err := scanner.Err(); err != nil {
-		log.Printf("Erro ao ler stream: %v", err)
+	if err != nil {
+		writeStreamEvent(c, fiber.Map{"error": chatTechnicalError})
 	}
-
-	return fullResponse.String(), nil
+	c.WriteString("data: [DONE]\n\n")
+	return turn, err
 }
 
 // ==================== Helper Functions ====================
@@ -1038,6 +685,24 @@ func GetChatCacheStats(c *fiber.Ctx) error {
 	return c.JSON(stats)
 }
 
+// GetChatProviderStats retorna o provedor de IA em uso e o consumo de tokens por provedor
+// @Summary Consumo dos provedores de IA
+// @Tags Chat Admin
+// @Produce json
+// @Success 200 {array} services.LLMProviderStats
+// @Router /api/admin/chat/providers [get]
+func GetChatProviderStats(c *fiber.Ctx) error {
+	active := ""
+	if services.LLM != nil {
+		active = services.LLM.Name()
+	}
+
+	return c.JSON(fiber.Map{
+		"active":    active,
+		"providers": services.LLMStats(),
+	})
+}
+
 // ClearChatCache limpa todo o cache do chat
 // @Summary Limpar cache do chat
 // @Tags Chat Admin
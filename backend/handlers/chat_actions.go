@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ==================== Ações propostas pelo assistente ====================
+
+// ListChatActions lista as ações propostas pelo assistente ao colaborador
+// @Summary Listar ações do assistente
+// @Tags Chat
+// @Produce json
+// @Param session_id query string false "ID da sessão"
+// @Success 200 {array} models.ChatPendingAction
+// @Router /api/chat/actions [get]
+func ListChatActions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	actions, err := services.ChatActions.List(userID, c.Query("session_id"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Erro ao buscar ações",
+		})
+	}
+	return c.JSON(actions)
+}
+
+// ConfirmChatAction executa a ação proposta após a confirmação do colaborador.
+// O cabeçalho Idempotency-Key é obrigatório: repetir a requisição com a mesma chave
+// devolve o resultado da primeira execução.
+// @Summary Confirmar ação do assistente
+// @Tags Chat
+// @Produce json
+// @Param id path string true "ID da ação"
+// @Param Idempotency-Key header string true "Chave de idempotência"
+// @Success 200 {object} models.ChatPendingAction
+// @Router /api/chat/actions/{id}/confirm [post]
+func ConfirmChatAction(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	action, replayed, err := services.ChatActions.Confirm(userID, c.Params("id"), c.Get("Idempotency-Key"), c.IP())
+	if err != nil {
+		return chatActionError(c, action, err)
+	}
+
+	if !replayed {
+		auditChatAction(c, userID, models.ActionChatActionConfirm, action, "Confirmou ação proposta pelo assistente: "+action.Summary)
+		saveChatActionOutcome(action)
+	}
+	return c.JSON(action)
+}
+
+// RejectChatAction recusa a ação proposta pelo assistente
+// @Summary Recusar ação do assistente
+// @Tags Chat
+// @Produce json
+// @Param id path string true "ID da ação"
+// @Success 200 {object} models.ChatPendingAction
+// @Router /api/chat/actions/{id}/reject [post]
+func RejectChatAction(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	action, replayed, err := services.ChatActions.Reject(userID, c.Params("id"), c.IP())
+	if err != nil {
+		return chatActionError(c, action, err)
+	}
+	if !replayed {
+		auditChatAction(c, userID, models.ActionChatActionReject, action, "Recusou ação proposta pelo assistente: "+action.Summary)
+	}
+	return c.JSON(action)
+}
+
+// GetChatActionPolicies tabela de funções que exigem confirmação do colaborador
+// @Summary Políticas de confirmação das ações do assistente
+// @Tags Chat
+// @Produce json
+// @Success 200 {array} services.ChatActionPolicy
+// @Router /api/admin/chat/actions/policies [get]
+func GetChatActionPolicies(c *fiber.Ctx) error {
+	return c.JSON(services.ChatActionPolicies())
+}
+
+// chatActionError converte os erros do serviço em respostas HTTP
+func chatActionError(c *fiber.Ctx, action *models.ChatPendingAction, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrChatActionKeyRequired):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, services.ErrChatActionNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, services.ErrChatActionDecided):
+		status = fiber.StatusConflict
+	case errors.Is(err, services.ErrChatActionExpired):
+		status = fiber.StatusGone
+	default:
+		log.Printf("Erro ao decidir ação do chat: %v", err)
+		return c.Status(status).JSON(fiber.Map{"error": "Erro ao processar a ação"})
+	}
+
+	response := fiber.Map{"error": err.Error()}
+	if action != nil {
+		response["action"] = action
+	}
+	return c.Status(status).JSON(response)
+}
+
+// auditChatAction registra quem decidiu a ação que a IA propôs: os argumentos propostos
+// ficam no valor anterior e o resultado no novo
+func auditChatAction(c *fiber.Ctx, userID, auditAction string, action *models.ChatPendingAction, description string) {
+	var user models.User
+	config.DB.Select("id", "name", "email").First(&user, "id = ?", userID)
+
+	CreateAuditLog(userID, user.Name, user.Email, auditAction, models.EntityChatAction, action.ID, action.FunctionName,
+		"arguments", action.Arguments, action.Result, description, c.IP(), c.Get("User-Agent"))
+}
+
+// saveChatActionOutcome registra o resultado na conversa, para o colaborador e o
+// assistente saberem o que aconteceu depois da confirmação
+func saveChatActionOutcome(action *models.ChatPendingAction) {
+	if action.SessionID == "" {
+		return
+	}
+
+	var result services.FunctionResult
+	json.Unmarshal([]byte(action.Result), &result)
+	content := fmt.Sprintf("✅ %s: %s", action.Title, action.Summary)
+	if result.Message != "" {
+		content += "\n" + result.Message
+	}
+	if action.Status == models.ChatActionFailed {
+		content = fmt.Sprintf("❌ Não foi possível concluir \"%s\": %s", action.Summary, result.Error)
+	}
+
+	config.DB.Create(&models.ChatMessage{
+		SessionID: action.SessionID,
+		UserID:    action.UserID,
+		Role:      "assistant",
+		Content:   content,
+	})
+}
@@ -1,16 +1,20 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"mime/multipart"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/frappyou/backend/config"
 	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // Tipos de documentos permitidos
@@ -105,27 +109,18 @@ func UploadDocument(c *fiber.Ctx) error {
 		})
 	}
 
-	// Cria diretório de uploads se não existir
-	uploadDir := "./uploads/documents/" + userID
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	// Lê o conteúdo enviado (gravado cifrado no armazenamento)
+	content, err := readFormFile(file)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
-			"error":   "Erro ao criar diretório de upload",
+			"error":   "Erro ao ler arquivo",
 		})
 	}
 
-	// Gera nome único para o arquivo
+	// Gera nome único para o arquivo (chave no armazenamento)
 	fileID := uuid.New().String()
-	fileName := fmt.Sprintf("%s_%s%s", docType, fileID, ext)
-	filePath := filepath.Join(uploadDir, fileName)
-
-	// Salva o arquivo
-	if err := c.SaveFile(file, filePath); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Erro ao salvar arquivo",
-		})
-	}
+	filePath := fmt.Sprintf("documents/%s/%s_%s%s", userID, docType, fileID, ext)
 
 	// Determina o mime type
 	mimeType := file.Header.Get("Content-Type")
@@ -144,22 +139,34 @@ func UploadDocument(c *fiber.Ctx) error {
 		Path:         filePath,
 		Description:  description,
 		IsPublic:     false,
-		Status:       models.DocumentStatusPending, // Inicia como pendente
+		Status:       models.DocumentStatusQuarantined, // Bloqueado até a verificação do arquivo
+		ScanStatus:   models.DocumentScanPending,
+	}
+
+	// Salva o arquivo
+	if err := services.WriteDocumentFile(&document, content); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao salvar arquivo",
+		})
 	}
 
 	if err := config.DB.Create(&document).Error; err != nil {
 		// Remove arquivo se falhar ao salvar no banco
-		os.Remove(filePath)
+		services.DeleteDocumentFile(&document)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Erro ao registrar documento",
 		})
 	}
 
+	// Verificação do arquivo; liberado, segue para a aprovação do RH
+	services.DocumentPipeline.Enqueue(document.ID)
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success":  true,
 		"document": document,
-		"message":  "Documento enviado com sucesso!",
+		"message":  "Documento enviado com sucesso! Ele fica disponível após a verificação de segurança.",
 	})
 }
 
@@ -176,15 +183,40 @@ func DownloadDocument(c *fiber.Ctx) error {
 		})
 	}
 
-	// Verifica se o arquivo existe
-	if _, err := os.Stat(document.Path); os.IsNotExist(err) {
+	return sendDocumentFile(c, &document)
+}
+
+// GetDocumentLink gera um link temporário para baixar o documento sem o token de acesso
+// (abrir em nova aba, visualizador de PDF do navegador)
+func GetDocumentLink(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	docID := c.Params("id")
+
+	var document models.Document
+	if err := config.DB.Select("id").Where("id = ? AND user_id = ?", docID, userID).First(&document).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
-			"error":   "Arquivo não encontrado no servidor",
+			"error":   "Documento não encontrado",
+		})
+	}
+
+	return sendDocumentLink(c, &document)
+}
+
+// GetDocumentThumbnail retorna a miniatura (JPEG) de um documento de imagem
+func GetDocumentThumbnail(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	docID := c.Params("id")
+
+	var document models.Document
+	if err := config.DB.Where("id = ? AND user_id = ?", docID, userID).First(&document).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Documento não encontrado",
 		})
 	}
 
-	return c.Download(document.Path, document.OriginalName)
+	return sendDocumentThumbnail(c, &document)
 }
 
 // DeleteDocument deleta um documento
@@ -200,11 +232,17 @@ func DeleteDocument(c *fiber.Ctx) error {
 		})
 	}
 
-	// Remove o arquivo físico
-	os.Remove(document.Path)
+	// Remove o arquivo do armazenamento
+	services.DeleteDocumentFile(&document)
 
-	// Remove do banco (soft delete)
-	if err := config.DB.Delete(&document).Error; err != nil {
+	// Remove do banco (soft delete) junto com o fluxo de aprovação pendente
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := services.Workflow.Cancel(tx, models.ApprovalSubjectDocument, document.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&document).Error
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Erro ao deletar documento",
@@ -271,7 +309,7 @@ func AdminGetAllDocuments(c *fiber.Ctx) error {
 	status := c.Query("status") // pending, approved, rejected
 	userID := c.Query("user_id")
 
-	query := config.DB.Preload("User").Order("created_at DESC")
+	query := requestAccess(c).Apply(config.DB, "user_id").Preload("User").Order("created_at DESC")
 
 	if status != "" {
 		query = query.Where("status = ?", status)
@@ -297,7 +335,7 @@ func AdminGetAllDocuments(c *fiber.Ctx) error {
 // AdminGetPendingDocuments retorna documentos pendentes de aprovação
 func AdminGetPendingDocuments(c *fiber.Ctx) error {
 	var documents []models.Document
-	if err := config.DB.Preload("User").
+	if err := requestAccess(c).Apply(config.DB, "user_id").Preload("User").
 		Where("status = ?", models.DocumentStatusPending).
 		Order("created_at ASC").
 		Find(&documents).Error; err != nil {
@@ -313,103 +351,121 @@ func AdminGetPendingDocuments(c *fiber.Ctx) error {
 	})
 }
 
-// AdminApproveDocument aprova um documento
+// AdminApproveDocument registra a aprovação na etapa atual do documento
 func AdminApproveDocument(c *fiber.Ctx) error {
-	adminID := c.Locals("user_id").(string)
-	docID := c.Params("id")
+	return decideDocument(c, services.ApprovalApprove, "", "Documento aprovado com sucesso!")
+}
 
-	var document models.Document
-	if err := config.DB.First(&document, "id = ?", docID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+// AdminRejectDocument rejeita um documento
+func AdminRejectDocument(c *fiber.Ctx) error {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
-			"error":   "Documento não encontrado",
+			"error":   "Dados inválidos",
 		})
 	}
 
-	now := time.Now()
-	document.Status = models.DocumentStatusApproved
-	document.ReviewedBy = &adminID
-	document.ReviewedAt = &now
-	document.RejectReason = ""
+	return decideDocument(c, services.ApprovalReject, req.Reason, "Documento rejeitado")
+}
+
+func decideDocument(c *fiber.Ctx, decision services.ApprovalDecision, reason, finalMsg string) error {
+	userID := c.Locals("user_id").(string)
+	docID := c.Params("id")
 
-	if err := config.DB.Save(&document).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	outcome, err := services.Workflow.Decide(models.ApprovalSubjectDocument, docID, userID, decision, reason)
+	if err != nil {
+		return c.Status(approvalErrorStatus(err)).JSON(fiber.Map{
 			"success": false,
-			"error":   "Erro ao aprovar documento",
+			"error":   err.Error(),
 		})
 	}
 
+	var document models.Document
+	config.DB.First(&document, "id = ?", docID)
+
 	return c.JSON(fiber.Map{
 		"success":  true,
-		"message":  "Documento aprovado com sucesso!",
+		"message":  approvalMessage(outcome, finalMsg),
 		"document": document,
 	})
 }
 
-// AdminRejectDocument rejeita um documento
-func AdminRejectDocument(c *fiber.Ctx) error {
-	adminID := c.Locals("user_id").(string)
+// AdminDownloadDocument permite admin baixar qualquer documento
+func AdminDownloadDocument(c *fiber.Ctx) error {
 	docID := c.Params("id")
 
-	var req struct {
-		Reason string `json:"reason"`
-	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+	var document models.Document
+	if err := requestAccess(c).Apply(config.DB, "user_id").First(&document, "id = ?", docID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
-			"error":   "Dados inválidos",
+			"error":   "Documento não encontrado",
 		})
 	}
 
+	return sendDocumentFile(c, &document)
+}
+
+// AdminGetDocumentLink gera um link temporário para o RH/admin baixar o documento
+func AdminGetDocumentLink(c *fiber.Ctx) error {
+	docID := c.Params("id")
+
 	var document models.Document
-	if err := config.DB.First(&document, "id = ?", docID).Error; err != nil {
+	if err := requestAccess(c).Apply(config.DB.Select("id"), "user_id").First(&document, "id = ?", docID).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
 			"error":   "Documento não encontrado",
 		})
 	}
 
-	now := time.Now()
-	document.Status = models.DocumentStatusRejected
-	document.ReviewedBy = &adminID
-	document.ReviewedAt = &now
-	document.RejectReason = req.Reason
+	return sendDocumentLink(c, &document)
+}
 
-	if err := config.DB.Save(&document).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+// AdminGetDocumentThumbnail retorna a miniatura de um documento para o RH/admin
+func AdminGetDocumentThumbnail(c *fiber.Ctx) error {
+	docID := c.Params("id")
+
+	var document models.Document
+	if err := requestAccess(c).Apply(config.DB, "user_id").First(&document, "id = ?", docID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
-			"error":   "Erro ao rejeitar documento",
+			"error":   "Documento não encontrado",
 		})
 	}
 
-	return c.JSON(fiber.Map{
-		"success":  true,
-		"message":  "Documento rejeitado",
-		"document": document,
-	})
+	return sendDocumentThumbnail(c, &document)
 }
 
-// AdminDownloadDocument permite admin baixar qualquer documento
-func AdminDownloadDocument(c *fiber.Ctx) error {
+// AdminRescanDocument verifica novamente um documento em quarentena (ex.: antivírus
+// estava indisponível)
+func AdminRescanDocument(c *fiber.Ctx) error {
 	docID := c.Params("id")
 
 	var document models.Document
-	if err := config.DB.First(&document, "id = ?", docID).Error; err != nil {
+	if err := requestAccess(c).Apply(config.DB.Select("id"), "user_id").First(&document, "id = ?", docID).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
 			"error":   "Documento não encontrado",
 		})
 	}
 
-	// Verifica se o arquivo existe
-	if _, err := os.Stat(document.Path); os.IsNotExist(err) {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+	if err := services.DocumentPipeline.Rescan(document.ID); err != nil {
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, services.ErrDocumentNotQuarantined) {
+			status = fiber.StatusConflict
+		}
+		return c.Status(status).JSON(fiber.Map{
 			"success": false,
-			"error":   "Arquivo não encontrado no servidor",
+			"error":   err.Error(),
 		})
 	}
 
-	return c.Download(document.Path, document.OriginalName)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Documento enviado para nova verificação",
+	})
 }
 
 // AdminDeleteDocument permite admin deletar qualquer documento
@@ -417,18 +473,24 @@ func AdminDeleteDocument(c *fiber.Ctx) error {
 	docID := c.Params("id")
 
 	var document models.Document
-	if err := config.DB.First(&document, "id = ?", docID).Error; err != nil {
+	if err := requestAccess(c).Apply(config.DB, "user_id").First(&document, "id = ?", docID).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
 			"error":   "Documento não encontrado",
 		})
 	}
 
-	// Remove o arquivo físico
-	os.Remove(document.Path)
+	// Remove o arquivo do armazenamento
+	services.DeleteDocumentFile(&document)
 
-	// Remove do banco (soft delete)
-	if err := config.DB.Delete(&document).Error; err != nil {
+	// Remove do banco (soft delete) junto com o fluxo de aprovação pendente
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := services.Workflow.Cancel(tx, models.ApprovalSubjectDocument, document.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&document).Error
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Erro ao deletar documento",
@@ -443,19 +505,133 @@ func AdminDeleteDocument(c *fiber.Ctx) error {
 
 // AdminGetDocumentStats retorna estatísticas de documentos
 func AdminGetDocumentStats(c *fiber.Ctx) error {
-	var pending, approved, rejected int64
+	var quarantined, pending, approved, rejected int64
 
-	config.DB.Model(&models.Document{}).Where("status = ?", models.DocumentStatusPending).Count(&pending)
-	config.DB.Model(&models.Document{}).Where("status = ?", models.DocumentStatusApproved).Count(&approved)
-	config.DB.Model(&models.Document{}).Where("status = ?", models.DocumentStatusRejected).Count(&rejected)
+	access := requestAccess(c)
+	scoped := func() *gorm.DB { return access.Apply(config.DB.Model(&models.Document{}), "user_id") }
+	scoped().Where("status = ?", models.DocumentStatusQuarantined).Count(&quarantined)
+	scoped().Where("status = ?", models.DocumentStatusPending).Count(&pending)
+	scoped().Where("status = ?", models.DocumentStatusApproved).Count(&approved)
+	scoped().Where("status = ?", models.DocumentStatusRejected).Count(&rejected)
 
 	return c.JSON(fiber.Map{
 		"success": true,
 		"stats": fiber.Map{
-			"pending":  pending,
-			"approved": approved,
-			"rejected": rejected,
-			"total":    pending + approved + rejected,
+			"quarantined": quarantined,
+			"pending":     pending,
+			"approved":    approved,
+			"rejected":    rejected,
+			"total":       quarantined + pending + approved + rejected,
 		},
 	})
 }
+
+// readFormFile lê o conteúdo de um arquivo enviado por formulário
+func readFormFile(file *multipart.FileHeader) ([]byte, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// ServeDocumentFile entrega o documento de um link gerado por GetDocumentLink ou
+// AdminGetDocumentLink. A assinatura do link substitui o token de acesso.
+func ServeDocumentFile(c *fiber.Ctx) error {
+	docID := c.Params("id")
+	if err := services.VerifyDocumentURL(docID, c.Query("expires"), c.Query("signature")); err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	var document models.Document
+	if err := config.DB.First(&document, "id = ?", docID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Documento não encontrado",
+		})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return sendDocumentFile(c, &document)
+}
+
+// sendDocumentLink responde com o link temporário do documento
+func sendDocumentLink(c *fiber.Ctx, document *models.Document) error {
+	if document.Status == models.DocumentStatusQuarantined {
+		return quarantinedDocument(c, document)
+	}
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"url":        services.SignedDocumentURL(document.ID, services.DocumentURLTTL),
+		"expires_in": int(services.DocumentURLTTL.Seconds()),
+	})
+}
+
+// sendDocumentFile envia o arquivo do documento já decifrado
+func sendDocumentFile(c *fiber.Ctx, document *models.Document) error {
+	if document.Status == models.DocumentStatusQuarantined {
+		return quarantinedDocument(c, document)
+	}
+
+	content, err := services.ReadDocumentFile(document)
+	if errors.Is(err, services.ErrObjectNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Arquivo não encontrado no servidor",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao ler arquivo",
+		})
+	}
+
+	c.Attachment(document.OriginalName)
+	if document.MimeType != "" {
+		c.Set(fiber.HeaderContentType, document.MimeType)
+	}
+	return c.Send(content)
+}
+
+// sendDocumentThumbnail envia a miniatura do documento
+func sendDocumentThumbnail(c *fiber.Ctx, document *models.Document) error {
+	if document.Status == models.DocumentStatusQuarantined {
+		return quarantinedDocument(c, document)
+	}
+
+	thumbnail, err := services.ReadDocumentThumbnail(document)
+	if errors.Is(err, services.ErrObjectNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Documento sem miniatura",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao ler miniatura",
+		})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "private, max-age=3600")
+	c.Set(fiber.HeaderContentType, "image/jpeg")
+	return c.Send(thumbnail)
+}
+
+// quarantinedDocument download bloqueado até a verificação liberar o arquivo
+func quarantinedDocument(c *fiber.Ctx, document *models.Document) error {
+	message := "Documento em verificação de segurança. Tente novamente em instantes."
+	if document.ScanStatus == models.DocumentScanInfected || document.ScanStatus == models.DocumentScanInvalid {
+		message = "Documento bloqueado pela verificação de segurança: " + document.ScanResult
+	}
+	return c.Status(fiber.StatusLocked).JSON(fiber.Map{
+		"success":     false,
+		"error":       message,
+		"scan_status": document.ScanStatus,
+	})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ==================== VÍNCULO DE HOLERITES COM O COLABORADOR ====================
+
+// AdminListUnlinkedPayslips holerites sem vínculo (sem colaborador encontrado ou ambíguos)
+func AdminListUnlinkedPayslips(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	payslips, total, err := services.EmployeeIdentities.Pending(c.Query("status", ""), page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao buscar holerites sem vínculo",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"payslips": payslips,
+		"counts":   services.EmployeeIdentities.PendingCounts(),
+		"pagination": fiber.Map{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// AdminBackfillPayslipIdentities vincula os holerites ainda não processados ou sem colaborador
+func AdminBackfillPayslipIdentities(c *fiber.Ctx) error {
+	result, err := services.EmployeeIdentities.Backfill()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao vincular holerites",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": fmt.Sprintf("%d holerites processados, %d vinculados", result.Processed, result.Linked),
+		"result":  result,
+	})
+}
+
+// AdminLinkPayslipIdentity vincula holerites ao colaborador do CPF informado
+func AdminLinkPayslipIdentity(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+
+	var req struct {
+		PayslipIDs []string `json:"payslip_ids"`
+		CPF        string   `json:"cpf"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Dados inválidos",
+		})
+	}
+
+	identity, count, err := services.EmployeeIdentities.LinkManually(req.PayslipIDs, req.CPF)
+	if err != nil {
+		return identityError(c, err)
+	}
+
+	var admin models.User
+	config.DB.First(&admin, "id = ?", adminID)
+	CreateAuditLog(adminID, admin.Name, admin.Email, models.ActionUpdate, models.EntitySystem, identity.ID, identity.Name, "payslip_identity", "", identity.ID, fmt.Sprintf("Vinculou manualmente %d holerites ao colaborador %s", count, identity.Name), c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"message":  fmt.Sprintf("%d holerites vinculados a %s", count, identity.Name),
+		"count":    count,
+		"identity": identity,
+	})
+}
+
+// identityError converte os erros de identidade do colaborador em respostas HTTP
+func identityError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrIdentityNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, services.ErrIdentityConflict):
+		status = fiber.StatusConflict
+	case errors.Is(err, services.ErrIdentityInvalidCPF),
+		errors.Is(err, services.ErrIdentityNoPayslips):
+		status = fiber.StatusBadRequest
+	}
+	if status == fiber.StatusInternalServerError {
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao consultar o cadastro do colaborador",
+		})
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
@@ -11,25 +16,41 @@ import (
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/frappyou/backend/config"
 	"github.com/frappyou/backend/graph"
+	"github.com/frappyou/backend/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/adaptor"
-)
-
-// contextKey is a custom type for context keys
-type contextKey string
-
-const (
-	userIDKey contextKey = "user_id"
-	roleKey   contextKey = "role"
+	"github.com/gorilla/websocket"
 )
 
 // graphqlServer is the singleton GraphQL server
 var graphqlServer *handler.Server
 
+// webSocketSessionCheck is how often open subscriptions re-check that their session is still active
+const webSocketSessionCheck = 30 * time.Second
+
+// webSocketCancelKey holds the cancel func of an authenticated WebSocket connection
+type webSocketCancelKey struct{}
+
 func init() {
 	resolver := graph.NewResolver()
-	graphqlServer = handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: resolver}))
+	graphqlServer = handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: resolver,
+		Directives: graph.DirectiveRoot{
+			HasPermission: graph.HasPermission, // Enforces @hasPermission on the fields that declare it
+		},
+	}))
 
+	// WebSocket must come first: it is selected by the Upgrade header
+	graphqlServer.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		Upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     checkWebSocketOrigin,
+		},
+		InitFunc:  webSocketInit,
+		CloseFunc: webSocketClose,
+	})
 	graphqlServer.AddTransport(transport.Options{})
 	graphqlServer.AddTransport(transport.GET{})
 	graphqlServer.AddTransport(transport.POST{})
@@ -42,22 +63,40 @@ func init() {
 
 // GraphQLHandler returns the GraphQL handler for Fiber
 func GraphQLHandler() fiber.Handler {
-	return adaptor.HTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	httpHandler := adaptor.HTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Extract user info from JWT if present
-		ctx := r.Context()
+		ctx := graph.WithClient(r.Context(), clientIP(r), r.UserAgent())
 		authHeader := r.Header.Get("Authorization")
 
 		if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
 			token := strings.TrimPrefix(authHeader, "Bearer ")
 			claims, err := config.ValidateToken(token)
-			if err == nil {
-				ctx = context.WithValue(ctx, userIDKey, claims.UserID)
-				ctx = context.WithValue(ctx, roleKey, claims.Role)
+			if err == nil && services.Sessions.IsActive(claims.SessionID) {
+				ctx = graph.WithUser(ctx, claims.UserID, claims.Role)
+				ctx = graph.WithSession(ctx, claims.SessionID)
 			}
 		}
 
 		graphqlServer.ServeHTTP(w, r.WithContext(ctx))
 	})
+
+	return func(c *fiber.Ctx) error {
+		if isWebSocketUpgrade(c) {
+			return serveGraphQLWebSocket(c)
+		}
+		return httpHandler(c)
+	}
+}
+
+// clientIP returns the caller's address, honoring the proxy headers
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
 }
 
 // PlaygroundHandler returns the GraphQL Playground handler
@@ -65,3 +104,143 @@ func PlaygroundHandler() fiber.Handler {
 	h := playground.Handler("FrappYou GraphQL Playground", "/graphql")
 	return adaptor.HTTPHandler(h)
 }
+
+// ============== WEBSOCKET (SUBSCRIPTIONS) ==============
+
+// isWebSocketUpgrade checks whether the request asks for a WebSocket upgrade
+func isWebSocketUpgrade(c *fiber.Ctx) bool {
+	return c.Method() == fiber.MethodGet &&
+		strings.EqualFold(c.Get(fiber.HeaderUpgrade), "websocket") &&
+		strings.Contains(strings.ToLower(c.Get(fiber.HeaderConnection)), "upgrade")
+}
+
+// serveGraphQLWebSocket hijacks the fasthttp connection and hands it to the
+// gqlgen WebSocket transport, which speaks graphql-ws and graphql-transport-ws
+func serveGraphQLWebSocket(c *fiber.Ctx) error {
+	req, err := adaptor.ConvertRequest(c, true)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Requisição WebSocket inválida",
+		})
+	}
+
+	// The upgrade handshake is written by gorilla/websocket on the raw connection
+	c.Context().HijackSetNoResponse(true)
+	c.Context().Hijack(func(conn net.Conn) {
+		graphqlServer.ServeHTTP(newHijackedResponseWriter(conn), req)
+	})
+
+	return nil
+}
+
+// webSocketInit authenticates the subscription using the token sent in the
+// connection_init payload, since browsers cannot set headers on WebSocket requests
+func webSocketInit(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+	token := payload.Authorization()
+	if token == "" {
+		token = payload.GetString("authToken")
+	}
+	token = strings.TrimPrefix(token, "Bearer ")
+
+	if token == "" {
+		return ctx, nil, errors.New("token não fornecido")
+	}
+
+	claims, err := config.ValidateToken(token)
+	if err != nil {
+		return ctx, nil, errors.New("token inválido ou expirado")
+	}
+	if !services.Sessions.IsActive(claims.SessionID) {
+		return ctx, nil, errors.New("sessão encerrada")
+	}
+
+	ctx = graph.WithUser(ctx, claims.UserID, claims.Role)
+	ctx = graph.WithSession(ctx, claims.SessionID)
+
+	// The socket outlives the checks above: it is closed when the access token expires
+	// (the client reconnects with a refreshed one) or when the session is revoked
+	ctx = transport.AppendCloseReason(ctx, "sessão expirada ou encerrada")
+	expiresAt := time.Now().Add(config.AccessTokenTTL)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	ctx, cancel := context.WithDeadline(ctx, expiresAt)
+	ctx = context.WithValue(ctx, webSocketCancelKey{}, cancel)
+	go watchWebSocketSession(ctx, cancel, claims.SessionID, webSocketSessionCheck, services.Sessions.IsActive)
+
+	return ctx, nil, nil
+}
+
+// webSocketClose stops the session watcher once the connection is closed
+func webSocketClose(ctx context.Context, closeCode int) {
+	if cancel, ok := ctx.Value(webSocketCancelKey{}).(context.CancelFunc); ok {
+		cancel()
+	}
+}
+
+// watchWebSocketSession cancels the connection context, which makes gqlgen close the
+// socket, as soon as the session is no longer active
+func watchWebSocketSession(ctx context.Context, cancel context.CancelFunc, sessionID string, interval time.Duration, isActive func(sessionID string) bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !isActive(sessionID) {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// checkWebSocketOrigin applies the same allowed origins used by CORS. Requests
+// without an Origin header are refused: browsers always send it on WebSocket upgrades
+func checkWebSocketOrigin(r *http.Request) bool {
+	return config.IsAllowedOrigin(r.Header.Get("Origin"))
+}
+
+// hijackedResponseWriter adapts a hijacked fasthttp connection to the
+// http.ResponseWriter + http.Hijacker pair expected by gorilla/websocket
+type hijackedResponseWriter struct {
+	conn        net.Conn
+	header      http.Header
+	wroteHeader bool
+}
+
+func newHijackedResponseWriter(conn net.Conn) *hijackedResponseWriter {
+	return &hijackedResponseWriter{conn: conn, header: make(http.Header)}
+}
+
+func (w *hijackedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader is only used when the upgrade fails, to report the error to the client
+func (w *hijackedResponseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	fmt.Fprintf(w.conn, "HTTP/1.1 %d %s\r\n", statusCode, http.StatusText(statusCode))
+	w.header.Set("Connection", "close")
+	w.header.Write(w.conn)
+	fmt.Fprint(w.conn, "\r\n")
+}
+
+func (w *hijackedResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.conn.Write(b)
+}
+
+func (w *hijackedResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.wroteHeader = true
+	return w.conn, bufio.NewReadWriter(bufio.NewReader(w.conn), bufio.NewWriter(w.conn)), nil
+}
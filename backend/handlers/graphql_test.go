@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchWebSocketSession(t *testing.T) {
+	var active atomic.Bool
+	active.Store(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		watchWebSocketSession(ctx, cancel, "sessao", time.Millisecond, func(string) bool { return active.Load() })
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, ctx.Err(), "active session keeps the socket open")
+
+	// Revoked session: the connection context is cancelled and the watcher stops
+	active.Store(false)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watcher did not stop after the session was revoked")
+	}
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
+
+func TestCheckWebSocketOrigin(t *testing.T) {
+	t.Setenv("ALLOWED_ORIGINS", "https://frappyou.app")
+
+	req := httptest.NewRequest("GET", "/graphql", nil)
+	assert.False(t, checkWebSocketOrigin(req), "missing Origin is refused")
+
+	req.Header.Set("Origin", "https://frappyou.app")
+	assert.True(t, checkWebSocketOrigin(req))
+
+	req.Header.Set("Origin", "https://evil.example")
+	assert.False(t, checkWebSocketOrigin(req))
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ==================== CALENDÁRIO DE DIAS ÚTEIS ====================
+
+// GetMyHolidays retorna os feriados que se aplicam ao colaborador no ano
+func GetMyHolidays(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	year, err := strconv.Atoi(c.Query("year", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Ano inválido",
+		})
+	}
+
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	wc := services.LoadWorkCalendar(userID, start, end)
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"filial":   wc.Filial,
+		"state":    wc.State,
+		"city":     wc.City,
+		"holidays": wc.Holidays(start, end),
+	})
+}
+
+// PreviewVacationDays simula o cálculo e a validação de uma solicitação sem criá-la
+func PreviewVacationDays(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	startDate, err := time.Parse("2006-01-02", c.Query("start_date"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Data de início inválida",
+		})
+	}
+
+	endDate, err := time.Parse("2006-01-02", c.Query("end_date"))
+	if err != nil || endDate.Before(startDate) {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Data de término inválida",
+		})
+	}
+
+	vacationType := models.VacationType(c.Query("type", string(models.VacationTypeFerias)))
+
+	period, err := services.NewVacationPolicy().Validate(services.VacationPolicyRequest{
+		UserID:    userID,
+		Type:      vacationType,
+		StartDate: startDate,
+		EndDate:   endDate,
+	})
+	if err != nil {
+		return vacationPolicyErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"period":  period,
+	})
+}
+
+// ==================== FERIADOS (ADMIN) ====================
+
+// AdminGetHolidays lista os feriados cadastrados (admin)
+func AdminGetHolidays(c *fiber.Ctx) error {
+	query := config.DB.Model(&models.Holiday{})
+
+	if scope := c.Query("scope"); scope != "" {
+		query = query.Where("scope = ?", scope)
+	}
+	if state := c.Query("state"); state != "" {
+		query = query.Where("state = ?", strings.ToUpper(state))
+	}
+
+	var holidays []models.Holiday
+	query.Order("date ASC").Find(&holidays)
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"holidays": holidays,
+	})
+}
+
+type holidayRequest struct {
+	Name      string              `json:"name"`
+	Date      string              `json:"date"`
+	Recurring *bool               `json:"recurring"`
+	Scope     models.HolidayScope `json:"scope"`
+	State     string              `json:"state"`
+	City      string              `json:"city"`
+}
+
+// apply valida o payload e copia os dados para o feriado
+func (req holidayRequest) apply(holiday *models.Holiday) string {
+	if req.Name != "" {
+		holiday.Name = req.Name
+	}
+	if req.Date != "" {
+		date, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			return "Data inválida"
+		}
+		holiday.Date = date
+	}
+	if req.Recurring != nil {
+		holiday.Recurring = *req.Recurring
+	}
+	if req.Scope != "" {
+		holiday.Scope = req.Scope
+	}
+	if req.State != "" {
+		holiday.State = strings.ToUpper(req.State)
+	}
+	if req.City != "" {
+		holiday.City = req.City
+	}
+
+	if holiday.Name == "" || holiday.Date.IsZero() {
+		return "Nome e data são obrigatórios"
+	}
+
+	switch holiday.Scope {
+	case models.HolidayScopeNacional:
+		holiday.State, holiday.City = "", ""
+	case models.HolidayScopeEstadual:
+		if holiday.State == "" {
+			return "UF é obrigatória para feriados estaduais"
+		}
+		holiday.City = ""
+	case models.HolidayScopeMunicipal:
+		if holiday.State == "" || holiday.City == "" {
+			return "UF e município são obrigatórios para feriados municipais"
+		}
+	default:
+		return "Abrangência inválida (nacional, estadual ou municipal)"
+	}
+
+	return ""
+}
+
+// AdminCreateHoliday cadastra um feriado (admin)
+func AdminCreateHoliday(c *fiber.Ctx) error {
+	var req holidayRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Dados inválidos",
+		})
+	}
+
+	holiday := models.Holiday{Recurring: true}
+	if msg := req.apply(&holiday); msg != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": msg,
+		})
+	}
+
+	if err := config.DB.Create(&holiday).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao cadastrar feriado",
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Feriado cadastrado com sucesso",
+		"holiday": holiday,
+	})
+}
+
+// AdminUpdateHoliday atualiza um feriado (admin)
+func AdminUpdateHoliday(c *fiber.Ctx) error {
+	var holiday models.Holiday
+	if err := config.DB.Where("id = ?", c.Params("id")).First(&holiday).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"success": false,
+			"message": "Feriado não encontrado",
+		})
+	}
+
+	var req holidayRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Dados inválidos",
+		})
+	}
+
+	if msg := req.apply(&holiday); msg != "" {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": msg,
+		})
+	}
+
+	config.DB.Save(&holiday)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Feriado atualizado com sucesso",
+		"holiday": holiday,
+	})
+}
+
+// AdminDeleteHoliday remove um feriado (admin)
+func AdminDeleteHoliday(c *fiber.Ctx) error {
+	var holiday models.Holiday
+	if err := config.DB.Where("id = ?", c.Params("id")).First(&holiday).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"success": false,
+			"message": "Feriado não encontrado",
+		})
+	}
+
+	config.DB.Delete(&holiday)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Feriado removido com sucesso",
+	})
+}
+
+// ==================== LOCALIZAÇÃO DAS FILIAIS (ADMIN) ====================
+
+// AdminGetFilialLocations lista as filiais com UF/município configurados (admin)
+func AdminGetFilialLocations(c *fiber.Ctx) error {
+	var locations []models.FilialLocation
+	config.DB.Order("filial ASC").Find(&locations)
+
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"locations": locations,
+	})
+}
+
+// AdminUpsertFilialLocation define a UF e o município de uma filial (admin)
+func AdminUpsertFilialLocation(c *fiber.Ctx) error {
+	var req struct {
+		Filial string `json:"filial"`
+		State  string `json:"state"`
+		City   string `json:"city"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Dados inválidos",
+		})
+	}
+
+	req.Filial = strings.TrimSpace(req.Filial)
+	req.State = strings.ToUpper(strings.TrimSpace(req.State))
+	if req.Filial == "" || len(req.State) != 2 || req.City == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Filial, UF (2 letras) e município são obrigatórios",
+		})
+	}
+
+	var location models.FilialLocation
+	if config.DB.Where("filial = ?", req.Filial).First(&location).Error != nil {
+		location = models.FilialLocation{Filial: req.Filial}
+	}
+	location.State = req.State
+	location.City = req.City
+
+	if err := config.DB.Save(&location).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao salvar localização da filial",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"message":  "Localização da filial salva com sucesso",
+		"location": location,
+	})
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ==================== INFORME DE RENDIMENTOS ====================
+
+// GetMyIncomeStatementYears anos-calendário com holerites publicados do colaborador
+func GetMyIncomeStatementYears(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Usuário não encontrado",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"years":   services.IncomeStatements.AvailableYears(user),
+	})
+}
+
+// GetMyIncomeStatement comprovante de rendimentos do colaborador logado (JSON)
+func GetMyIncomeStatement(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Usuário não encontrado",
+		})
+	}
+
+	year, _ := strconv.Atoi(c.Params("year"))
+	data, err := services.IncomeStatements.ForEmployee(user, year)
+	if err != nil {
+		return incomeStatementError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"statement": data,
+	})
+}
+
+// DownloadMyIncomeStatementPDF PDF do comprovante de rendimentos do colaborador logado
+func DownloadMyIncomeStatementPDF(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Usuário não encontrado",
+		})
+	}
+
+	year, _ := strconv.Atoi(c.Params("year"))
+	data, err := services.IncomeStatements.ForEmployee(user, year)
+	if err != nil {
+		return incomeStatementError(c, err)
+	}
+	return sendIncomeStatementPDF(c, data)
+}
+
+// AdminListIncomeStatements comprovantes gravados de um ano
+func AdminListIncomeStatements(c *fiber.Ctx) error {
+	year, _ := strconv.Atoi(c.Query("year", strconv.Itoa(time.Now().Year()-1)))
+
+	statements, err := services.IncomeStatements.List(year)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao buscar informes de rendimentos",
+		})
+	}
+
+	var lastBatch *models.IncomeStatementBatch
+	var batch models.IncomeStatementBatch
+	if config.DB.Where("year = ?", year).Order("created_at DESC").First(&batch).Error == nil {
+		lastBatch = &batch
+	}
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"year":       year,
+		"statements": statements,
+		"total":      len(statements),
+		"last_batch": lastBatch,
+	})
+}
+
+// AdminStartIncomeStatementBatch inicia a geração em lote dos comprovantes de um ano
+func AdminStartIncomeStatementBatch(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+
+	var req struct {
+		Year int `json:"year"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Dados inválidos",
+		})
+	}
+
+	batch, err := services.IncomeStatements.StartBatch(req.Year, adminID)
+	if err != nil {
+		return incomeStatementError(c, err)
+	}
+
+	var admin models.User
+	config.DB.First(&admin, "id = ?", adminID)
+	CreateAuditLog(adminID, admin.Name, admin.Email, models.ActionCreate, models.EntitySystem, batch.ID, strconv.Itoa(batch.Year), "income_statement_batch", "", string(batch.Status), fmt.Sprintf("Iniciou a geração dos informes de rendimentos do ano-calendário %d", batch.Year), c.IP(), c.Get("User-Agent"))
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"message": "Geração dos informes iniciada",
+		"batch":   batch,
+	})
+}
+
+// AdminGetIncomeStatementBatch acompanhamento de uma geração em lote
+func AdminGetIncomeStatementBatch(c *fiber.Ctx) error {
+	batch, err := services.IncomeStatements.Batch(c.Params("id"))
+	if err != nil {
+		return incomeStatementError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"batch":   batch,
+	})
+}
+
+// AdminGetIncomeStatement comprovante de um colaborador por CPF (JSON)
+func AdminGetIncomeStatement(c *fiber.Ctx) error {
+	year, _ := strconv.Atoi(c.Params("year"))
+	data, err := services.IncomeStatements.ForCPF(year, c.Params("cpf"))
+	if err != nil {
+		return incomeStatementError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"statement": data,
+	})
+}
+
+// AdminDownloadIncomeStatementPDF PDF do comprovante de um colaborador por CPF
+func AdminDownloadIncomeStatementPDF(c *fiber.Ctx) error {
+	year, _ := strconv.Atoi(c.Params("year"))
+	data, err := services.IncomeStatements.ForCPF(year, c.Params("cpf"))
+	if err != nil {
+		return incomeStatementError(c, err)
+	}
+	return sendIncomeStatementPDF(c, data)
+}
+
+// sendIncomeStatementPDF envia o comprovante renderizado como anexo
+func sendIncomeStatementPDF(c *fiber.Ctx, data *services.IncomeStatementData) error {
+	pdf := services.RenderIncomeStatementPDF(data)
+	filename := fmt.Sprintf("informe-rendimentos-%d.pdf", data.Year)
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	return c.Send(pdf)
+}
+
+// incomeStatementError converte os erros do informe de rendimentos em respostas HTTP
+func incomeStatementError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrIncomeStatementNotFound),
+		errors.Is(err, services.ErrIncomeBatchNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, services.ErrIncomeStatementYear),
+		errors.Is(err, services.ErrIncomeBatchOpenYear):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, services.ErrIncomeBatchRunning):
+		status = fiber.StatusConflict
+	}
+	if status == fiber.StatusInternalServerError {
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao gerar informe de rendimentos",
+		})
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
@@ -276,6 +276,9 @@ func CreateKnowledgeArticle(c *fiber.Ctx) error {
 		})
 	}
 
+	// Indexa para a busca semântica
+	go services.KnowledgeIndex.Refresh(article.ID)
+
 	return c.Status(fiber.StatusCreated).JSON(article)
 }
 
@@ -345,6 +348,9 @@ func UpdateKnowledgeArticle(c *fiber.Ctx) error {
 		})
 	}
 
+	// Reindexa só os trechos alterados
+	go services.KnowledgeIndex.Refresh(article.ID)
+
 	return c.JSON(article)
 }
 
@@ -364,6 +370,8 @@ func DeleteKnowledgeArticle(c *fiber.Ctx) error {
 		})
 	}
 
+	go services.KnowledgeIndex.Refresh(articleID)
+
 	return c.JSON(fiber.Map{
 		"message": "Artigo deletado com sucesso",
 	})
@@ -387,6 +395,7 @@ func ToggleKnowledgePublish(c *fiber.Ctx) error {
 
 	article.IsPublished = !article.IsPublished
 	config.DB.Save(&article)
+	go services.KnowledgeIndex.Refresh(article.ID)
 
 	status := "despublicado"
 	if article.IsPublished {
@@ -3,13 +3,15 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+	"mime"
+	"mime/multipart"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/frappyou/backend/config"
 	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -41,6 +43,7 @@ func GetCourses(c *fiber.Ctx) error {
 
 	var courses []models.Course
 	query.Order("featured DESC, enrollment_count DESC, created_at DESC").Find(&courses)
+	signCoursesMedia(courses)
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -82,6 +85,7 @@ func GetCourseByID(c *fiber.Ctx) error {
 			}
 		}
 	}
+	signCourseMedia(&course)
 
 	return c.JSON(fiber.Map{
 		"success":    true,
@@ -137,6 +141,9 @@ func GetMyEnrollments(c *fiber.Ctx) error {
 
 	var enrollments []models.Enrollment
 	config.DB.Preload("Course").Where("user_id = ?", userID).Order("created_at DESC").Find(&enrollments)
+	for i := range enrollments {
+		signCourseMedia(&enrollments[i].Course)
+	}
 
 	return c.JSON(fiber.Map{
 		"success":     true,
@@ -181,6 +188,7 @@ func GetLessonContent(c *fiber.Ctx) error {
 		}
 		config.DB.Create(&progress)
 	}
+	lesson.VideoURL = services.SignMediaURL(lesson.VideoURL)
 
 	return c.JSON(fiber.Map{
 		"success":  true,
@@ -513,6 +521,7 @@ func RateCourse(c *fiber.Ctx) error {
 func AdminGetAllCourses(c *fiber.Ctx) error {
 	var courses []models.Course
 	config.DB.Order("created_at DESC").Find(&courses)
+	signCoursesMedia(courses)
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -533,7 +542,9 @@ func AdminCreateCourse(c *fiber.Ctx) error {
 	}
 
 	course.InstructorID = adminID
+	course.IntroVideoURL = services.UploadRef(course.IntroVideoURL)
 	config.DB.Create(&course)
+	signCourseMedia(&course)
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -566,7 +577,7 @@ func AdminUpdateCourse(c *fiber.Ctx) error {
 		"title":           updates.Title,
 		"description":     updates.Description,
 		"thumbnail":       updates.Thumbnail,
-		"intro_video_url": updates.IntroVideoURL,
+		"intro_video_url": services.UploadRef(updates.IntroVideoURL),
 		"category":        updates.Category,
 		"level":           updates.Level,
 		"requirements":    updates.Requirements,
@@ -577,6 +588,7 @@ func AdminUpdateCourse(c *fiber.Ctx) error {
 	})
 
 	config.DB.First(&course, "id = ?", courseID)
+	signCourseMedia(&course)
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -724,6 +736,7 @@ func AdminCreateLesson(c *fiber.Ctx) error {
 	}
 
 	lesson.ModuleID = moduleID
+	lesson.VideoURL = services.UploadRef(lesson.VideoURL)
 
 	// Definir ordem automaticamente
 	var maxOrder int
@@ -731,6 +744,7 @@ func AdminCreateLesson(c *fiber.Ctx) error {
 	lesson.SortOrder = maxOrder + 1
 
 	config.DB.Create(&lesson)
+	lesson.VideoURL = services.SignMediaURL(lesson.VideoURL)
 
 	// Atualizar duração do módulo e curso
 	updateCourseDuration(moduleID)
@@ -767,12 +781,14 @@ func AdminUpdateLesson(c *fiber.Ctx) error {
 		"description": updates.Description,
 		"type":        updates.Type,
 		"content":     updates.Content,
-		"video_url":   updates.VideoURL,
+		"video_url":   services.UploadRef(updates.VideoURL),
 		"duration":    updates.Duration,
 		"sort_order":  updates.SortOrder,
 		"is_free":     updates.IsFree,
 	})
 
+	lesson.VideoURL = services.SignMediaURL(lesson.VideoURL)
+
 	// Atualizar duração
 	updateCourseDuration(lesson.ModuleID)
 
@@ -844,30 +860,21 @@ func AdminUploadImage(c *fiber.Ctx) error {
 		})
 	}
 
-	// Criar diretório de uploads se não existir
-	uploadDir := "./uploads/images"
-	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"message": "Erro ao criar diretório de uploads",
-		})
-	}
-
 	// Gerar nome único para o arquivo
-	ext := filepath.Ext(file.Filename)
+	ext := strings.ToLower(filepath.Ext(file.Filename))
 	filename := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), ext)
-	filePath := filepath.Join(uploadDir, filename)
+	key := "images/" + filename
 
-	// Salvar arquivo
-	if err := c.SaveFile(file, filePath); err != nil {
+	// Salvar arquivo no armazenamento
+	if err := saveUploadedMedia(c, file, key); err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"success": false,
 			"message": "Erro ao salvar arquivo",
 		})
 	}
 
-	// Gerar URL pública
-	imageURL := fmt.Sprintf("/uploads/images/%s", filename)
+	// Imagens são públicas (capas dos cursos)
+	imageURL := services.UploadRef(key)
 
 	return c.JSON(fiber.Map{
 		"success":   true,
@@ -924,29 +931,20 @@ func AdminUploadVideo(c *fiber.Ctx) error {
 		})
 	}
 
-	// Criar diretório de uploads se não existir
-	uploadDir := "./uploads/videos"
-	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"message": "Erro ao criar diretório de uploads",
-		})
-	}
-
 	// Gerar nome único para o arquivo (ext já foi declarado acima na validação)
 	filename := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), ext)
-	filePath := filepath.Join(uploadDir, filename)
+	key := "videos/" + filename
 
-	// Salvar arquivo
-	if err := c.SaveFile(file, filePath); err != nil {
+	// Salvar arquivo no armazenamento
+	if err := saveUploadedMedia(c, file, key); err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"success": false,
 			"message": "Erro ao salvar arquivo",
 		})
 	}
 
-	// Gerar URL pública
-	videoURL := fmt.Sprintf("/uploads/videos/%s", filename)
+	// URL assinada para a pré-visualização; ao salvar a lição a assinatura é removida
+	videoURL := services.SignMediaURL(services.UploadRef(key))
 
 	return c.JSON(fiber.Map{
 		"success":   true,
@@ -957,6 +955,39 @@ func AdminUploadVideo(c *fiber.Ctx) error {
 	})
 }
 
+// signCourseMedia troca os vídeos enviados do curso e das lições por URLs assinadas
+// (os vídeos não são públicos; ver ServeUpload)
+func signCourseMedia(course *models.Course) {
+	course.IntroVideoURL = services.SignMediaURL(course.IntroVideoURL)
+	for i := range course.Modules {
+		for j := range course.Modules[i].Lessons {
+			lesson := &course.Modules[i].Lessons[j]
+			lesson.VideoURL = services.SignMediaURL(lesson.VideoURL)
+		}
+	}
+}
+
+func signCoursesMedia(courses []models.Course) {
+	for i := range courses {
+		signCourseMedia(&courses[i])
+	}
+}
+
+// saveUploadedMedia grava o arquivo enviado no armazenamento
+func saveUploadedMedia(c *fiber.Ctx, file *multipart.FileHeader, key string) error {
+	f, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	contentType := file.Header.Get("Content-Type")
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = mime.TypeByExtension(filepath.Ext(key))
+	}
+	return services.Storage.Put(c.UserContext(), key, f, file.Size, contentType)
+}
+
 // updateCourseDuration atualiza a duração total do curso
 func updateCourseDuration(moduleID string) {
 	var module models.Module
@@ -1136,6 +1167,8 @@ func AdminGetCourseDetails(c *fiber.Ctx) error {
 		})
 	}
 
+	signCourseMedia(&course)
+
 	// Estatísticas do curso
 	var enrollmentCount int64
 	config.DB.Model(&models.Enrollment{}).Where("course_id = ?", courseID).Count(&enrollmentCount)
@@ -6,6 +6,7 @@ import (
 
 	"github.com/frappyou/backend/config"
 	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -419,6 +420,7 @@ func AdminCreateNotification(c *fiber.Ctx) error {
 		}
 
 		if err := config.DB.Create(&notification).Error; err == nil {
+			publishNotification(notification)
 			created++
 		}
 	}
@@ -510,7 +512,12 @@ func CreateNotification(userID, title, message string, notifType models.Notifica
 		Link:     link,
 	}
 
-	return config.DB.Create(&notification).Error
+	if err := config.DB.Create(&notification).Error; err != nil {
+		return err
+	}
+
+	publishNotification(notification)
+	return nil
 }
 
 // CreateNotificationForAdmins cria notificação para todos os admins
@@ -529,8 +536,15 @@ func CreateNotificationForAdmins(title, message string, notifType models.Notific
 			Category: category,
 			Link:     link,
 		}
-		config.DB.Create(&notification)
+		if config.DB.Create(&notification).Error == nil {
+			publishNotification(notification)
+		}
 	}
 
 	return nil
 }
+
+// publishNotification envia a notificação em tempo real (subscription newNotification)
+func publishNotification(notification models.Notification) {
+	services.Events.Publish(services.EventNotificationCreated, notification.UserID, notification)
+}
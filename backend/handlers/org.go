@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ==================== ORGANOGRAMA ====================
+
+// GetMyOrgPosition retorna a lotação vigente do usuário (departamento, cargo e gestor)
+func GetMyOrgPosition(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	assignment, err := services.Org.CurrentAssignment(userID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"success": false,
+			"message": "Lotação não encontrada no organograma",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":        true,
+		"assignment":     assignment,
+		"manager_chain":  services.Org.ManagerChain(userID),
+		"direct_reports": len(services.Org.DirectReports(userID)),
+	})
+}
+
+// GetMyReports retorna os subordinados do usuário (scope=direct ou all)
+func GetMyReports(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var ids []string
+	if c.Query("scope", "direct") == "all" {
+		ids = services.Org.AllReports(userID)
+	} else {
+		ids = services.Org.DirectReports(userID)
+	}
+
+	users := []models.User{}
+	if len(ids) > 0 {
+		config.DB.Where("id IN ?", ids).Order("name ASC").Find(&users)
+	}
+
+	reports := make([]models.UserResponse, 0, len(users))
+	for _, u := range users {
+		reports = append(reports, u.ToResponse())
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"reports": reports,
+		"total":   len(reports),
+	})
+}
+
+// GetOrgChart exporta o organograma (format=json ou csv). Gestores veem a própria
+// subárvore; administradores podem exportar o organograma inteiro ou a partir de root.
+func GetOrgChart(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	at := time.Now()
+	if c.Query("at") != "" {
+		parsed, err := time.Parse("2006-01-02", c.Query("at"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"message": "Data inválida",
+			})
+		}
+		at = parsed
+	}
+
+	root := userID
+	if c.Locals("role") == "admin" {
+		root = c.Query("root", "")
+	}
+
+	chart, err := services.Org.Chart(at, root)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao montar organograma",
+		})
+	}
+
+	if c.Query("format") == "csv" {
+		data, err := services.ChartCSV(chart)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"message": "Erro ao exportar organograma",
+			})
+		}
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="organograma-`+at.Format("2006-01-02")+`.csv"`)
+		return c.Send(data)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"date":    at.Format("2006-01-02"),
+		"chart":   chart,
+	})
+}
+
+// ==================== ORGANOGRAMA (ADMIN) ====================
+
+// AdminGetDepartments lista os departamentos
+func AdminGetDepartments(c *fiber.Ctx) error {
+	var departments []models.OrgDepartment
+	config.DB.Preload("HeadUser").Order("name ASC").Find(&departments)
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"departments": departments,
+	})
+}
+
+// AdminSaveDepartment cria ou atualiza um departamento
+func AdminSaveDepartment(c *fiber.Ctx) error {
+	var req models.OrgDepartment
+	if err := c.BodyParser(&req); err != nil || req.Name == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Informe o nome do departamento",
+		})
+	}
+
+	department := models.OrgDepartment{Active: true}
+	if id := c.Params("id"); id != "" {
+		if err := config.DB.Where("id = ?", id).First(&department).Error; err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"message": "Departamento não encontrado",
+			})
+		}
+		department.Active = req.Active
+	}
+	if req.ParentID != nil && *req.ParentID == department.ID {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Um departamento não pode ser subordinado a si mesmo",
+		})
+	}
+
+	department.Name = req.Name
+	department.Filial = req.Filial
+	department.ParentID = req.ParentID
+	department.HeadUserID = req.HeadUserID
+
+	if err := config.DB.Save(&department).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao salvar departamento",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"department": department,
+	})
+}
+
+// AdminGetPositions lista os cargos
+func AdminGetPositions(c *fiber.Ctx) error {
+	var positions []models.OrgPosition
+	config.DB.Order("level DESC, title ASC").Find(&positions)
+
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"positions": positions,
+	})
+}
+
+// AdminSavePosition cria ou atualiza um cargo
+func AdminSavePosition(c *fiber.Ctx) error {
+	var req models.OrgPosition
+	if err := c.BodyParser(&req); err != nil || req.Title == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Informe o título do cargo",
+		})
+	}
+
+	var position models.OrgPosition
+	if id := c.Params("id"); id != "" {
+		if err := config.DB.Where("id = ?", id).First(&position).Error; err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"message": "Cargo não encontrado",
+			})
+		}
+	}
+
+	position.Title = req.Title
+	position.Level = req.Level
+	position.IsManagerial = req.IsManagerial
+
+	if err := config.DB.Save(&position).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao salvar cargo",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"position": position,
+	})
+}
+
+// AdminChangeAssignment registra uma mudança de lotação (departamento, cargo ou gestor) com vigência
+func AdminChangeAssignment(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+	userID := c.Params("user_id")
+
+	var req struct {
+		DepartmentID  *string `json:"department_id"`
+		PositionID    *string `json:"position_id"`
+		ManagerID     *string `json:"manager_id"`
+		ClearManager  bool    `json:"clear_manager"`
+		EffectiveFrom string  `json:"effective_from"`
+		Reason        string  `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Dados inválidos",
+		})
+	}
+
+	change := services.OrgAssignmentChange{
+		DepartmentID: req.DepartmentID,
+		PositionID:   req.PositionID,
+		ManagerID:    req.ManagerID,
+		ClearManager: req.ClearManager,
+		Reason:       req.Reason,
+	}
+	if req.EffectiveFrom != "" {
+		effectiveFrom, err := time.Parse("2006-01-02", req.EffectiveFrom)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"message": "Data de vigência inválida",
+			})
+		}
+		change.EffectiveFrom = effectiveFrom
+	}
+
+	assignment, err := services.Org.ChangeAssignment(userID, change, adminID)
+	if err != nil {
+		status := 500
+		switch {
+		case errors.Is(err, services.ErrOrgUserNotFound):
+			status = 404
+		case errors.Is(err, services.ErrOrgCycle), errors.Is(err, services.ErrOrgSelfManager), errors.Is(err, services.ErrOrgInvalidChange):
+			status = 400
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"message":    "Lotação atualizada",
+		"assignment": assignment,
+	})
+}
+
+// AdminGetAssignmentHistory retorna o histórico de lotações de um colaborador
+func AdminGetAssignmentHistory(c *fiber.Ctx) error {
+	history, err := services.Org.History(c.Params("user_id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao buscar histórico",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"history": history,
+	})
+}
+
+// AdminSeedOrgChart importa a lotação dos colaboradores de dbo.ColaboradoresFradema
+func AdminSeedOrgChart(c *fiber.Ctx) error {
+	result, err := services.Org.SeedFromColaboradores()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao importar organograma: " + err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"result":  result,
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+
+	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// ==================== ESQUECI MINHA SENHA ====================
+
+// forgotPasswordMessage resposta única para não revelar quais CPFs têm conta
+const forgotPasswordMessage = "Se o CPF estiver cadastrado, enviaremos um link de redefinição para o e-mail da conta."
+
+// ForgotPassword gera um link de redefinição de senha e envia para o e-mail do usuário do CPF.
+// O processamento (busca, token, e-mail e auditoria) roda em segundo plano: a resposta
+// sai no mesmo tempo exista ou não conta para o CPF.
+func ForgotPassword(c *fiber.Ctx) error {
+	var req struct {
+		CPF string `json:"cpf"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Dados inválidos",
+		})
+	}
+
+	cpfClean := cleanCPF(req.CPF)
+	if len(cpfClean) != 11 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "CPF inválido",
+		})
+	}
+
+	// Cópias: os valores do contexto do Fiber não sobrevivem à requisição
+	ip := utils.CopyString(c.IP())
+	userAgent := utils.CopyString(c.Get("User-Agent"))
+	go processPasswordResetRequest(cpfClean, ip, userAgent)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": forgotPasswordMessage,
+	})
+}
+
+// processPasswordResetRequest emite o link e registra a solicitação na auditoria
+func processPasswordResetRequest(cpf, ip, userAgent string) {
+	user, err := services.PasswordResets.RequestReset(cpf, ip)
+	if user != nil {
+		description := "Solicitou redefinição de senha"
+		if err != nil {
+			description += " (link não enviado: " + err.Error() + ")"
+		}
+		CreateAuditLog(user.ID, user.Name, user.Email, models.ActionPasswordResetRequest, models.EntityUser, user.ID, user.Name, "password", "", "", description, ip, userAgent)
+	}
+	if err != nil && !errors.Is(err, services.ErrResetNoEmail) {
+		log.Printf("Erro ao processar redefinição de senha: %v", err)
+	}
+}
+
+// ResetPassword define a nova senha a partir do token recebido por e-mail
+func ResetPassword(c *fiber.Ctx) error {
+	var req struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Dados inválidos",
+		})
+	}
+
+	user, err := services.PasswordResets.ResetPassword(req.Token, req.NewPassword)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPasswordTooShort):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "A senha deve ter pelo menos 6 caracteres",
+			})
+		case errors.Is(err, services.ErrResetTokenInvalid):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Link de redefinição inválido ou expirado. Solicite um novo.",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao redefinir senha",
+		})
+	}
+
+	CreateAuditLog(user.ID, user.Name, user.Email, models.ActionPasswordReset, models.EntityUser, user.ID, user.Name, "password", "***", "***", "Redefiniu a senha pelo link de recuperação", c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Senha redefinida com sucesso! Faça login com a nova senha.",
+	})
+}
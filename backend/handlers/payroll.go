@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ==================== CÁLCULO DA FOLHA (ADMIN) ====================
+
+// AdminCalculatePayroll simula o cálculo de um holerite sem gravar nada
+func AdminCalculatePayroll(c *fiber.Ctx) error {
+	var input services.PayrollInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Dados inválidos",
+		})
+	}
+
+	result, err := services.Payroll.Calculate(input)
+	if err != nil {
+		return payrollError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"result":  result,
+	})
+}
+
+// AdminListPayrollTaxTables lista as tabelas de INSS, IRRF e FGTS com suas vigências
+func AdminListPayrollTaxTables(c *fiber.Ctx) error {
+	tables, err := services.Payroll.ListTables()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao buscar tabelas",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"tables":  tables,
+	})
+}
+
+// AdminCreatePayrollTaxTable cadastra uma nova vigência de tabela.
+// Tabelas não são editadas: holerites já calculados continuam reproduzíveis.
+func AdminCreatePayrollTaxTable(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+
+	var table models.PayrollTaxTable
+	if err := c.BodyParser(&table); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Dados inválidos",
+		})
+	}
+	table.ID = ""
+	table.CreatedBy = adminID
+
+	if err := services.Payroll.SaveTable(&table); err != nil {
+		if errors.Is(err, services.ErrPayrollInvalidTaxTable) {
+			return payrollError(c, err)
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Já existe uma tabela deste tipo com a mesma vigência",
+		})
+	}
+
+	var admin models.User
+	config.DB.First(&admin, "id = ?", adminID)
+	validFrom := table.ValidFrom.Format("02/01/2006")
+	CreateAuditLog(adminID, admin.Name, admin.Email, models.ActionCreate, models.EntitySettings, table.ID, string(table.Kind), "payroll_tax_table", "", validFrom, fmt.Sprintf("Cadastrou tabela de %s vigente a partir de %s", string(table.Kind), validFrom), c.IP(), c.Get("User-Agent"))
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Tabela cadastrada com sucesso",
+		"table":   table,
+	})
+}
+
+// payrollError converte os erros do cálculo da folha em respostas HTTP
+func payrollError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrPayrollInvalidInput),
+		errors.Is(err, services.ErrPayrollUnsupportedType),
+		errors.Is(err, services.ErrPayrollInvalidTaxTable):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	case errors.Is(err, services.ErrPayrollTableNotFound):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"error":   "Erro no cálculo da folha",
+	})
+}
+
+// AdminGetPayrollVariance compara duas competências por colaborador e por rubrica,
+// com as anomalias para conferência antes da publicação (format=csv exporta)
+func AdminGetPayrollVariance(c *fiber.Ctx) error {
+	baseMonth, _ := strconv.Atoi(c.Query("base_month", "0"))
+	baseYear, _ := strconv.Atoi(c.Query("base_year", "0"))
+	compareMonth, _ := strconv.Atoi(c.Query("compare_month", "0"))
+	compareYear, _ := strconv.Atoi(c.Query("compare_year", "0"))
+	thresholdPct, _ := strconv.ParseFloat(c.Query("threshold_pct", "0"), 64)
+	thresholdAmount, _ := strconv.ParseFloat(c.Query("threshold_amount", "0"), 64)
+
+	report, err := services.PayrollVariance.Report(services.PayrollVarianceOptions{
+		BaseMonth:       baseMonth,
+		BaseYear:        baseYear,
+		CompareMonth:    compareMonth,
+		CompareYear:     compareYear,
+		Branch:          c.Query("branch", ""),
+		PayslipType:     c.Query("payslip_type", ""),
+		ThresholdPct:    thresholdPct,
+		ThresholdAmount: thresholdAmount,
+	})
+	if errors.Is(err, services.ErrVariancePeriod) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao comparar competências",
+		})
+	}
+
+	if c.Query("format") == "csv" {
+		data, err := services.VarianceCSV(report, c.Query("only_anomalies") == "true")
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"success": false,
+				"error":   "Erro ao exportar relatório",
+			})
+		}
+		filename := fmt.Sprintf("variacao-folha-%d-%02d-x-%d-%02d.csv",
+			report.Options.BaseYear, report.Options.BaseMonth, report.Options.CompareYear, report.Options.CompareMonth)
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+		return c.Send(data)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"report":  report,
+	})
+}
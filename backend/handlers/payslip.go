@@ -1,13 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/frappyou/backend/config"
 	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -22,32 +23,23 @@ func GetMyPayslips(c *fiber.Ctx) error {
 	}
 	userID := userIDInterface.(string)
 
-	// Busca o usuário e seu CPF
-	var user models.User
-	if err := config.DB.Where("id = ?", userID).First(&user).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"error":   "Usuário não encontrado",
+	// Identidade do colaborador: única referência usada para autorizar o acesso
+	identity, err := services.EmployeeIdentities.ForUserID(userID)
+	if errors.Is(err, services.ErrIdentityNotFound) {
+		return c.JSON(fiber.Map{
+			"success":  true,
+			"payslips": []models.PayslipSummary{},
+			"years":    []int{},
 		})
 	}
-
-	// Busca o colaborador_id pelo CPF
-	var colaboradorID int
-	cpfLimpo := user.CPF
-	for _, char := range []string{".", "-", " "} {
-		cpfLimpo = strings.ReplaceAll(cpfLimpo, char, "")
+	if err != nil {
+		return identityError(c, err)
 	}
-	config.DB.Raw(`
-		SELECT c.Id FROM dbo.ColaboradoresFradema c
-		INNER JOIN dbo.PessoasFisicasFradema p ON c.PessoaFisicaId = p.Id
-		WHERE REPLACE(REPLACE(REPLACE(p.Cpf, '.', ''), '-', ''), ' ', '') = ?
-	`, cpfLimpo).Scan(&colaboradorID)
 
 	// Parâmetros de filtro
 	year := c.Query("year", "")
 	
-	// Query base - busca por user_id, cpf ou colaborador_id
-	query := config.DB.Model(&models.Payslip{}).Where("user_id = ? OR employee_cpf = ? OR colaborador_id = ?", userID, user.CPF, colaboradorID)
+	query := config.DB.Model(&models.Payslip{}).Scopes(services.PublishedPayslips, services.EmployeePayslips(identity.ID))
 	
 	if year != "" {
 		yearInt, _ := strconv.Atoi(year)
@@ -56,7 +48,7 @@ func GetMyPayslips(c *fiber.Ctx) error {
 
 	// Busca os holerites (resumo)
 	var payslips []models.PayslipSummary
-	err := query.Select("id, reference_month, reference_year, payslip_type, gross_total, deduction_total, net_total, payment_date, status").
+	err = query.Select(models.PayslipSummaryColumns).
 		Order("reference_year DESC, reference_month DESC, created_at DESC").
 		Find(&payslips).Error
 
@@ -69,8 +61,7 @@ func GetMyPayslips(c *fiber.Ctx) error {
 
 	// Anos disponíveis
 	var years []int
-	config.DB.Model(&models.Payslip{}).
-		Where("user_id = ? OR employee_cpf = ? OR colaborador_id = ?", userID, user.CPF, colaboradorID).
+	config.DB.Model(&models.Payslip{}).Scopes(services.PublishedPayslips, services.EmployeePayslips(identity.ID)).
 		Distinct("reference_year").
 		Order("reference_year DESC").
 		Pluck("reference_year", &years)
@@ -94,33 +85,8 @@ func GetPayslipByID(c *fiber.Ctx) error {
 	userID := userIDInterface.(string)
 	payslipID := c.Params("id")
 
-	// Busca o usuário e seu CPF
-	var user models.User
-	if err := config.DB.Where("id = ?", userID).First(&user).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"error":   "Usuário não encontrado",
-		})
-	}
-
-	// Busca o colaborador_id pelo CPF
-	var colaboradorID int
-	cpfLimpo := user.CPF
-	for _, char := range []string{".", "-", " "} {
-		cpfLimpo = strings.ReplaceAll(cpfLimpo, char, "")
-	}
-	config.DB.Raw(`
-		SELECT c.Id FROM dbo.ColaboradoresFradema c
-		INNER JOIN dbo.PessoasFisicasFradema p ON c.PessoaFisicaId = p.Id
-		WHERE REPLACE(REPLACE(REPLACE(p.Cpf, '.', ''), '-', ''), ' ', '') = ?
-	`, cpfLimpo).Scan(&colaboradorID)
-
 	// Busca o holerite com os itens
-	var payslip models.Payslip
-	err := config.DB.Preload("Items").
-		Where("id = ? AND (user_id = ? OR employee_cpf = ? OR colaborador_id = ?)", payslipID, userID, user.CPF, colaboradorID).
-		First(&payslip).Error
-
+	payslip, err := findOwnPayslip(userID, payslipID)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
@@ -157,6 +123,7 @@ func AdminGetAllPayslips(c *fiber.Ctx) error {
 	month := c.Query("month", "")
 	colaboradorID := c.Query("colaborador_id", "")
 	search := c.Query("search", "")
+	status := c.Query("status", "")
 
 	if page < 1 {
 		page = 1
@@ -180,7 +147,15 @@ func AdminGetAllPayslips(c *fiber.Ctx) error {
 		query = query.Where("colaborador_id = ?", colaboradorID)
 	}
 	if search != "" {
-		query = query.Where("employee_name LIKE ? OR employee_cpf LIKE ?", "%"+search+"%", "%"+search+"%")
+		// CPF cifrado: só a busca pelo CPF completo usa o índice
+		if services.IsValidCPF(search) {
+			query = query.Where(config.DB.Where("employee_name LIKE ?", "%"+search+"%").Or(services.PayslipsByCPF(search)(config.DB)))
+		} else {
+			query = query.Where("employee_name LIKE ?", "%"+search+"%")
+		}
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
 	}
 
 	var total int64
@@ -232,13 +207,16 @@ func AdminCreatePayslip(c *fiber.Ctx) error {
 		IRRFBase       float64   `json:"irrf_base"`
 		FGTSBase       float64   `json:"fgts_base"`
 		FGTSAmount     float64   `json:"fgts_amount"`
-		Items          []struct {
-			Type        string  `json:"type"`
-			Code        string  `json:"code"`
-			Description string  `json:"description"`
-			Reference   float64 `json:"reference"`
-			Amount      float64 `json:"amount"`
-		} `json:"items"`
+		Items          []services.PayrollRubrica `json:"items"`
+
+		// Cálculo automático: gera salário, INSS, IRRF e FGTS a partir do salário
+		// base (os itens informados entram como rubricas adicionais). Sem ele, os
+		// itens são gravados como vieram e conferidos contra o cálculo.
+		Calculate    bool     `json:"calculate"`
+		Dependents   int      `json:"dependents"`
+		VacationDays int      `json:"vacation_days"`
+		MonthsWorked int      `json:"months_worked"`
+		AdvancePaid  *float64 `json:"advance_paid"`
 	}
 
 	if err := c.BodyParser(&input); err != nil {
@@ -247,6 +225,31 @@ func AdminCreatePayslip(c *fiber.Ctx) error {
 			"error":   "Dados inválidos",
 		})
 	}
+	if input.PayslipType == "" {
+		input.PayslipType = "mensal"
+	}
+
+	payrollInput := services.PayrollInput{
+		PayslipType:    input.PayslipType,
+		ReferenceMonth: input.ReferenceMonth,
+		ReferenceYear:  input.ReferenceYear,
+		PaymentDate:    input.PaymentDate,
+		BaseSalary:     input.BaseSalary,
+		WorkedDays:     input.WorkedDays,
+		Dependents:     input.Dependents,
+		VacationDays:   input.VacationDays,
+		MonthsWorked:   input.MonthsWorked,
+		AdvancePaid:    input.AdvancePaid,
+	}
+	var calc *services.PayrollResult
+	if input.Calculate {
+		payrollInput.Rubricas = input.Items
+		result, err := services.Payroll.Calculate(payrollInput)
+		if err != nil {
+			return payrollError(c, err)
+		}
+		calc = result
+	}
 
 	// Calcula totais
 	var grossTotal, deductionTotal float64
@@ -282,37 +285,53 @@ func AdminCreatePayslip(c *fiber.Ctx) error {
 		IRRFBase:       input.IRRFBase,
 		FGTSBase:       input.FGTSBase,
 		FGTSAmount:     input.FGTSAmount,
-		Status:         "disponivel",
-	}
-
-	if payslip.PayslipType == "" {
-		payslip.PayslipType = "mensal"
-	}
-
-	if err := config.DB.Create(&payslip).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Erro ao criar holerite: " + err.Error(),
-		})
+		Status:         models.PayslipStatusDraft, // Visível ao colaborador só após revisão e publicação
 	}
 
-	// Cria os itens
+	// Itens criados junto com o holerite (mesma transação): falha em um item desfaz tudo
 	for _, item := range input.Items {
-		payslipItem := models.PayslipItem{
-			PayslipID:   payslip.ID,
+		payslip.Items = append(payslip.Items, models.PayslipItem{
 			Type:        item.Type,
 			Code:        item.Code,
 			Description: item.Description,
 			Reference:   item.Reference,
 			Amount:      item.Amount,
+		})
+	}
+
+	var warnings []string
+	if calc != nil {
+		payslip.Items = calc.Items
+		payslip.GrossTotal, payslip.DeductionTotal, payslip.NetTotal = calc.GrossTotal, calc.DeductionTotal, calc.NetTotal
+		payslip.INSSBase, payslip.IRRFBase = calc.INSSBase, calc.IRRFBase
+		payslip.FGTSBase, payslip.FGTSAmount = calc.FGTSBase, calc.FGTSAmount
+	} else if len(payslip.Items) > 0 {
+		// Divergências não impedem o cadastro: o holerite nasce como rascunho para revisão
+		if issues, err := services.Payroll.CheckPayslip(&payslip, input.Dependents); err == nil {
+			warnings = issues
 		}
-		config.DB.Create(&payslipItem)
+	}
+
+	// Vínculo com a identidade do colaborador (pendências ficam para resolução do admin)
+	if err := services.EmployeeIdentities.LinkPayslip(&payslip); err != nil {
+		return identityError(c, err)
+	}
+	if payslip.EmployeeID == "" {
+		warnings = append(warnings, "Holerite sem vínculo com colaborador: "+payslip.LinkNote)
+	}
+
+	if err := config.DB.Create(&payslip).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao criar holerite: " + err.Error(),
+		})
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"success": true,
-		"payslip": payslip,
-		"message": "Holerite criado com sucesso",
+		"success":  true,
+		"payslip":  payslip,
+		"warnings": warnings,
+		"message":  "Holerite criado com sucesso",
 	})
 }
 
@@ -360,20 +379,8 @@ func AdminImportPayslips(c *fiber.Ctx) error {
 	errors := []string{}
 
 	for i, p := range input.Payslips {
-		// Tenta encontrar o user_id pelo CPF
-		var userID string
-		var user models.User
-		cpfLimpo := p.EmployeeCPF
-		for _, char := range []string{".", "-", " "} {
-			cpfLimpo = strings.ReplaceAll(cpfLimpo, char, "")
-		}
-		if err := config.DB.Where("REPLACE(REPLACE(REPLACE(cpf, '.', ''), '-', ''), ' ', '') = ?", cpfLimpo).First(&user).Error; err == nil {
-			userID = user.ID
-		}
-
 		payslip := models.Payslip{
 			ColaboradorID:  p.ColaboradorID,
-			UserID:         userID,
 			EmployeeName:   p.EmployeeName,
 			EmployeeCPF:    p.EmployeeCPF,
 			Position:       p.Position,
@@ -392,29 +399,33 @@ func AdminImportPayslips(c *fiber.Ctx) error {
 			IRRFBase:       p.IRRFBase,
 			FGTSBase:       p.FGTSBase,
 			FGTSAmount:     p.FGTSAmount,
-			Status:         "disponivel",
+			Status:         models.PayslipStatusDraft, // Visível ao colaborador só após revisão e publicação
 		}
 
 		if payslip.PayslipType == "" {
 			payslip.PayslipType = "mensal"
 		}
 
-		if err := config.DB.Create(&payslip).Error; err != nil {
-			errors = append(errors, fmt.Sprintf("Linha %d: %s", i+1, err.Error()))
-			continue
-		}
-
-		// Cria os itens
+		// Itens criados junto com o holerite: erro em um item é reportado e desfaz a linha
 		for _, item := range p.Items {
-			payslipItem := models.PayslipItem{
-				PayslipID:   payslip.ID,
+			payslip.Items = append(payslip.Items, models.PayslipItem{
 				Type:        item.Type,
 				Code:        item.Code,
 				Description: item.Description,
 				Reference:   item.Reference,
 				Amount:      item.Amount,
-			}
-			config.DB.Create(&payslipItem)
+			})
+		}
+
+		// Usuário e colaborador vêm da identidade do CPF; sem vínculo, fica pendente para o admin
+		if err := services.EmployeeIdentities.LinkPayslip(&payslip); err != nil {
+			errors = append(errors, fmt.Sprintf("Linha %d: %s", i+1, err.Error()))
+			continue
+		}
+
+		if err := config.DB.Create(&payslip).Error; err != nil {
+			errors = append(errors, fmt.Sprintf("Linha %d: %s", i+1, err.Error()))
+			continue
 		}
 
 		created++
@@ -455,24 +466,14 @@ func AdminGetPayslipStats(c *fiber.Ctx) error {
 	year := c.Query("year", strconv.Itoa(time.Now().Year()))
 	yearInt, _ := strconv.Atoi(year)
 
-	var totalPayslips int64
-	config.DB.Model(&models.Payslip{}).Where("reference_year = ?", yearInt).Count(&totalPayslips)
-
-	var totalGross, totalDeductions, totalNet float64
-	config.DB.Model(&models.Payslip{}).
-		Where("reference_year = ?", yearInt).
-		Select("COALESCE(SUM(gross_total), 0)").
-		Scan(&totalGross)
-
-	config.DB.Model(&models.Payslip{}).
-		Where("reference_year = ?", yearInt).
-		Select("COALESCE(SUM(deduction_total), 0)").
-		Scan(&totalDeductions)
-
-	config.DB.Model(&models.Payslip{}).
-		Where("reference_year = ?", yearInt).
-		Select("COALESCE(SUM(net_total), 0)").
-		Scan(&totalNet)
+	// Somas feitas na aplicação: os valores ficam cifrados no banco
+	totals, byMonth, err := services.SumPayslips(config.DB.Model(&models.Payslip{}).Where("reference_year = ?", yearInt))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao calcular estatísticas",
+		})
+	}
 
 	// Por mês
 	type MonthStat struct {
@@ -480,13 +481,12 @@ func AdminGetPayslipStats(c *fiber.Ctx) error {
 		Count int64   `json:"count"`
 		Total float64 `json:"total"`
 	}
-	var monthlyStats []MonthStat
-	config.DB.Model(&models.Payslip{}).
-		Where("reference_year = ?", yearInt).
-		Select("reference_month as month, COUNT(*) as count, COALESCE(SUM(net_total), 0) as total").
-		Group("reference_month").
-		Order("reference_month").
-		Scan(&monthlyStats)
+	monthlyStats := []MonthStat{}
+	for month := 1; month <= 12; month++ {
+		if stat, ok := byMonth[month]; ok {
+			monthlyStats = append(monthlyStats, MonthStat{Month: month, Count: stat.Count, Total: stat.NetTotal})
+		}
+	}
 
 	// Anos disponíveis
 	var years []int
@@ -499,10 +499,10 @@ func AdminGetPayslipStats(c *fiber.Ctx) error {
 		"success": true,
 		"stats": fiber.Map{
 			"year":            yearInt,
-			"total_payslips":  totalPayslips,
-			"total_gross":     totalGross,
-			"total_deductions": totalDeductions,
-			"total_net":       totalNet,
+			"total_payslips":  totals.Count,
+			"total_gross":     totals.GrossTotal,
+			"total_deductions": totals.DeductionTotal,
+			"total_net":       totals.NetTotal,
 			"by_month":        monthlyStats,
 		},
 		"years": years,
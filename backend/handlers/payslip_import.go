@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ==================== IMPORTAÇÃO DE HOLERITES POR ARQUIVO (ADMIN) ====================
+
+// maxPayslipImportSize tamanho máximo do arquivo de folha
+const maxPayslipImportSize = 20 * 1024 * 1024
+
+// AdminListImportTemplates lista os templates de mapeamento salvos
+func AdminListImportTemplates(c *fiber.Ctx) error {
+	var templates []models.PayslipImportTemplate
+	if err := config.DB.Order("name ASC").Find(&templates).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao buscar templates",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"templates": templates,
+		"defaults": fiber.Map{
+			"tabular_columns": services.DefaultImportConfig(models.PayslipImportCSV).Columns,
+			"fixed_layout":    services.DefaultFixedWidthLayout(),
+		},
+	})
+}
+
+// AdminSaveImportTemplate cria (sem :id) ou atualiza um template de mapeamento
+func AdminSaveImportTemplate(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+
+	var input models.PayslipImportTemplate
+	if err := c.BodyParser(&input); err != nil || input.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Nome e formato do template são obrigatórios",
+		})
+	}
+	if _, err := services.ImportConfigFromTemplate(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	template := input
+	action := models.ActionCreate
+	if id := c.Params("id"); id != "" {
+		var existing models.PayslipImportTemplate
+		if err := config.DB.First(&existing, "id = ?", id).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Template não encontrado",
+			})
+		}
+		template.ID = existing.ID
+		template.CreatedAt = existing.CreatedAt
+		template.CreatedBy = existing.CreatedBy
+		action = models.ActionUpdate
+	} else {
+		template.ID = ""
+		template.CreatedBy = adminID
+	}
+
+	if err := config.DB.Save(&template).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao salvar template (o nome já pode estar em uso)",
+		})
+	}
+
+	var admin models.User
+	config.DB.First(&admin, "id = ?", adminID)
+	CreateAuditLog(adminID, admin.Name, admin.Email, action, models.EntitySettings, template.ID, template.Name, "payslip_import_template", "", string(template.Format), "Salvou template de importação de holerites", c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"message":  "Template salvo com sucesso",
+		"template": template,
+	})
+}
+
+// AdminDeleteImportTemplate exclui um template de mapeamento
+func AdminDeleteImportTemplate(c *fiber.Ctx) error {
+	result := config.DB.Where("id = ?", c.Params("id")).Delete(&models.PayslipImportTemplate{})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao excluir template",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Template não encontrado",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Template excluído com sucesso",
+	})
+}
+
+// AdminPreviewPayslipImport recebe o arquivo (campo "file"), interpreta pelo template
+// (template_id) ou pelo modelo padrão do formato e retorna o relatório da simulação.
+// Nada é gravado em holerites até a confirmação do lote.
+func AdminPreviewPayslipImport(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Envie o arquivo no campo \"file\"",
+		})
+	}
+	if file.Size > maxPayslipImportSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"success": false,
+			"error":   "Arquivo muito grande (máximo 20MB)",
+		})
+	}
+
+	var cfg services.PayslipImportConfig
+	templateID := c.FormValue("template_id")
+	if templateID != "" {
+		var template models.PayslipImportTemplate
+		if err := config.DB.First(&template, "id = ?", templateID).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Template não encontrado",
+			})
+		}
+		if cfg, err = services.ImportConfigFromTemplate(&template); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+	} else {
+		format := models.PayslipImportFormat(c.FormValue("format"))
+		if format == "" {
+			if format, err = services.DetectImportFormat(file.Filename); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"success": false,
+					"error":   err.Error(),
+				})
+			}
+		}
+		cfg = services.DefaultImportConfig(format)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Não foi possível ler o arquivo",
+		})
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxPayslipImportSize))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Não foi possível ler o arquivo",
+		})
+	}
+
+	replaceExisting, _ := strconv.ParseBool(c.FormValue("replace_existing"))
+	batch, report, err := services.PayslipImports.Preview(data, file.Filename, cfg, templateID, replaceExisting, adminID)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		message := "Erro ao processar arquivo"
+		if errors.Is(err, services.ErrImportUnknownFormat) || errors.Is(err, services.ErrInvalidXLSX) {
+			status, message = fiber.StatusBadRequest, err.Error()
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   message,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"batch":   batch,
+		"report":  report,
+	})
+}
+
+// AdminGetPayslipImportBatch retorna um lote com o relatório da simulação
+func AdminGetPayslipImportBatch(c *fiber.Ctx) error {
+	batch, report, err := services.PayslipImports.Batch(c.Params("id"))
+	if err != nil {
+		return payslipImportError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"batch":   batch,
+		"report":  report,
+	})
+}
+
+// AdminCommitPayslipImport confirma o lote: grava todos os holerites em uma única transação
+func AdminCommitPayslipImport(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+
+	batch, report, err := services.PayslipImports.Commit(c.Params("id"), adminID)
+	if errors.Is(err, services.ErrImportHasErrors) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"success": false,
+			"error":   "O lote possui erros e não foi importado. Corrija o arquivo e envie novamente.",
+			"report":  report,
+		})
+	}
+	if err != nil {
+		return payslipImportError(c, err)
+	}
+
+	var admin models.User
+	config.DB.First(&admin, "id = ?", adminID)
+	CreateAuditLog(adminID, admin.Name, admin.Email, models.ActionCreate, models.EntitySystem, batch.ID, batch.FileName, "payslip_import", "", strconv.Itoa(report.Payslips), fmt.Sprintf("Importou %d holerites do arquivo %s", report.Payslips, batch.FileName), c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": fmt.Sprintf("%d holerites importados com sucesso", report.Payslips),
+		"batch":   batch,
+		"report":  report,
+	})
+}
+
+// AdminDiscardPayslipImport descarta um lote ainda não confirmado
+func AdminDiscardPayslipImport(c *fiber.Ctx) error {
+	if err := services.PayslipImports.Discard(c.Params("id")); err != nil {
+		return payslipImportError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Lote descartado",
+	})
+}
+
+// payslipImportError converte os erros do importador em respostas HTTP
+func payslipImportError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrImportBatchNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	case errors.Is(err, services.ErrImportBatchClosed), errors.Is(err, services.ErrImportReplacesPublished):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"error":   "Erro ao importar holerites: " + err.Error(),
+	})
+}
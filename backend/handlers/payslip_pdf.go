@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ==================== PDF DO HOLERITE ====================
+
+// maxVerifyUploadSize tamanho máximo do PDF enviado para verificação
+const maxVerifyUploadSize = 5 * 1024 * 1024
+
+// findOwnPayslip busca um holerite publicado do colaborador com os itens. A
+// propriedade é decidida só pelo vínculo com a identidade do colaborador.
+func findOwnPayslip(userID, payslipID string) (*models.Payslip, error) {
+	identity, err := services.EmployeeIdentities.ForUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var payslip models.Payslip
+	err = config.DB.Preload("Items").Scopes(services.PublishedPayslips, services.EmployeePayslips(identity.ID)).
+		Where("id = ?", payslipID).
+		First(&payslip).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payslip, nil
+}
+
+// DownloadPayslipPDF gera o PDF do holerite do colaborador logado com código de verificação
+func DownloadPayslipPDF(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	payslipID := c.Params("id")
+
+	payslip, err := findOwnPayslip(userID, payslipID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Holerite não encontrado",
+		})
+	}
+
+	pdf, issuance, err := services.PayslipDocs.Issue(payslip, userID, c.IP())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao gerar PDF do holerite",
+		})
+	}
+
+	filename := fmt.Sprintf("holerite-%d-%02d-%s.pdf", payslip.ReferenceYear, payslip.ReferenceMonth, payslip.PayslipType)
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	c.Set("X-Verification-Code", issuance.VerificationCode)
+	return c.Send(pdf)
+}
+
+// verificationResponse resposta pública da verificação de holerite
+func verificationResponse(c *fiber.Ctx, result *services.PayslipVerification, err error) error {
+	if errors.Is(err, services.ErrIssuanceNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"valid":   false,
+			"error":   "Documento não encontrado. O arquivo pode ter sido alterado ou o código é inválido.",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao verificar documento",
+		})
+	}
+
+	message := "Documento autêntico"
+	switch {
+	case !result.Valid:
+		message = "Assinatura inválida: o registro de emissão não confere"
+	case !result.DataIntact:
+		message = "Documento emitido pelo sistema, mas o holerite foi alterado ou removido depois da emissão"
+	}
+
+	return c.JSON(fiber.Map{
+		"success":      true,
+		"valid":        result.Valid,
+		"message":      message,
+		"verification": result,
+	})
+}
+
+// VerifyPayslipCode confirma um código de verificação impresso no holerite (público)
+func VerifyPayslipCode(c *fiber.Ctx) error {
+	result, err := services.PayslipDocs.VerifyCode(c.Params("code"))
+	return verificationResponse(c, result, err)
+}
+
+// VerifyPayslipDocument confirma que um PDF (campo "file") ou um hash SHA-256
+// (campo "hash") corresponde exatamente a um holerite emitido (público)
+func VerifyPayslipDocument(c *fiber.Ctx) error {
+	if file, err := c.FormFile("file"); err == nil {
+		if file.Size > maxVerifyUploadSize {
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+				"success": false,
+				"error":   "Arquivo muito grande",
+			})
+		}
+		f, err := file.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Não foi possível ler o arquivo",
+			})
+		}
+		defer f.Close()
+		content, err := io.ReadAll(io.LimitReader(f, maxVerifyUploadSize))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Não foi possível ler o arquivo",
+			})
+		}
+		result, err := services.PayslipDocs.VerifyDocument(content)
+		return verificationResponse(c, result, err)
+	}
+
+	var req struct {
+		Hash string `json:"hash" form:"hash"`
+	}
+	if err := c.BodyParser(&req); err != nil || len(strings.TrimSpace(req.Hash)) != 64 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Envie o PDF no campo \"file\" ou o hash SHA-256 no campo \"hash\"",
+		})
+	}
+	result, err := services.PayslipDocs.VerifyHash(req.Hash)
+	return verificationResponse(c, result, err)
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ==================== PUBLICAÇÃO E CIÊNCIA DE HOLERITES ====================
+
+// AcknowledgePayslip registra a ciência do colaborador ("confirmo o recebimento")
+func AcknowledgePayslip(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	payslip, err := findOwnPayslip(userID, c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Holerite não encontrado",
+		})
+	}
+
+	ack, err := services.PayslipPublications.Acknowledge(payslip, userID, c.IP(), c.Get("User-Agent"))
+	if err != nil {
+		return payslipPublicationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success":         true,
+		"message":         "Recebimento confirmado",
+		"acknowledged_at": ack.AcknowledgedAt,
+	})
+}
+
+// AdminReviewPayslips marca como revisados os rascunhos (por ids ou competência/filial)
+func AdminReviewPayslips(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+
+	var sel services.PayslipSelection
+	if err := c.BodyParser(&sel); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Dados inválidos",
+		})
+	}
+
+	count, err := services.PayslipPublications.Review(sel, adminID)
+	if err != nil {
+		return payslipPublicationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": fmt.Sprintf("%d holerites revisados", count),
+		"count":   count,
+	})
+}
+
+// AdminReturnPayslipsToDraft devolve holerites revisados para rascunho
+func AdminReturnPayslipsToDraft(c *fiber.Ctx) error {
+	var sel services.PayslipSelection
+	if err := c.BodyParser(&sel); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Dados inválidos",
+		})
+	}
+
+	count, err := services.PayslipPublications.ReturnToDraft(sel)
+	if err != nil {
+		return payslipPublicationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": fmt.Sprintf("%d holerites devolvidos para rascunho", count),
+		"count":   count,
+	})
+}
+
+// AdminListPayslipPublications lista os lotes de publicação
+func AdminListPayslipPublications(c *fiber.Ctx) error {
+	year, _ := strconv.Atoi(c.Query("year", ""))
+
+	publications, err := services.PayslipPublications.List(year)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao buscar publicações",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":      true,
+		"publications": publications,
+	})
+}
+
+// AdminSchedulePayslipPublication agenda (ou publica imediatamente, sem data)
+// os holerites revisados de uma competência e filial
+func AdminSchedulePayslipPublication(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+
+	var req struct {
+		services.PayslipSelection
+		ScheduledFor *time.Time `json:"scheduled_for"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Dados inválidos",
+		})
+	}
+
+	var scheduledFor time.Time
+	if req.ScheduledFor != nil {
+		scheduledFor = *req.ScheduledFor
+	}
+	publication, err := services.PayslipPublications.Schedule(req.PayslipSelection, scheduledFor, adminID)
+	if err != nil {
+		return payslipPublicationError(c, err)
+	}
+
+	var admin models.User
+	config.DB.First(&admin, "id = ?", adminID)
+	period := fmt.Sprintf("%02d/%d", publication.ReferenceMonth, publication.ReferenceYear)
+	CreateAuditLog(adminID, admin.Name, admin.Email, models.ActionCreate, models.EntitySystem, publication.ID, period, "payslip_publication", "", string(publication.Status), fmt.Sprintf("Agendou publicação de %d holerites (%s) para %s", publication.PayslipCount, period, publication.ScheduledFor.Format("02/01/2006 15:04")), c.IP(), c.Get("User-Agent"))
+
+	message := "Publicação agendada com sucesso"
+	if publication.Status == models.PayslipPublicationPublished {
+		message = fmt.Sprintf("%d holerites publicados", publication.PayslipCount)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success":     true,
+		"message":     message,
+		"publication": publication,
+	})
+}
+
+// AdminCancelPayslipPublication cancela uma publicação ainda não executada
+func AdminCancelPayslipPublication(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+
+	publication, err := services.PayslipPublications.Cancel(c.Params("id"), adminID)
+	if err != nil {
+		return payslipPublicationError(c, err)
+	}
+
+	var admin models.User
+	config.DB.First(&admin, "id = ?", adminID)
+	period := fmt.Sprintf("%02d/%d", publication.ReferenceMonth, publication.ReferenceYear)
+	CreateAuditLog(adminID, admin.Name, admin.Email, models.ActionUpdate, models.EntitySystem, publication.ID, period, "payslip_publication", string(models.PayslipPublicationScheduled), string(publication.Status), "Cancelou publicação agendada de holerites", c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"message":     "Publicação cancelada",
+		"publication": publication,
+	})
+}
+
+// AdminGetPayslipAcknowledgements relatório de ciência por competência (e filial)
+func AdminGetPayslipAcknowledgements(c *fiber.Ctx) error {
+	month, _ := strconv.Atoi(c.Query("month", ""))
+	year, _ := strconv.Atoi(c.Query("year", ""))
+	sel := services.PayslipSelection{
+		ReferenceMonth: month,
+		ReferenceYear:  year,
+		Branch:         c.Query("branch", ""),
+		PayslipType:    c.Query("payslip_type", ""),
+	}
+
+	rows, err := services.PayslipPublications.AcknowledgementReport(sel)
+	if err != nil {
+		return payslipPublicationError(c, err)
+	}
+
+	acknowledged := 0
+	for _, row := range rows {
+		if row.AcknowledgedAt != nil {
+			acknowledged++
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success":      true,
+		"rows":         rows,
+		"total":        len(rows),
+		"acknowledged": acknowledged,
+		"pending":      len(rows) - acknowledged,
+	})
+}
+
+// payslipPublicationError converte os erros do ciclo de publicação em respostas HTTP
+func payslipPublicationError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrPublicationNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, services.ErrPublicationClosed),
+		errors.Is(err, services.ErrPayslipAlreadyAcked),
+		errors.Is(err, services.ErrPayslipNotPublished):
+		status = fiber.StatusConflict
+	case errors.Is(err, services.ErrPublicationEmpty),
+		errors.Is(err, services.ErrPublicationPeriod),
+		errors.Is(err, services.ErrPayslipReviewSelector):
+		status = fiber.StatusBadRequest
+	}
+	if status == fiber.StatusInternalServerError {
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao processar publicação de holerites",
+		})
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
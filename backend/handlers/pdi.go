@@ -6,6 +6,7 @@ import (
 
 	"github.com/frappyou/backend/config"
 	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -130,6 +131,15 @@ func CreatePDI(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Formato de data inválido para período final"})
 	}
 
+	// Sem gestor informado, usa o gestor direto do organograma
+	if input.ManagerID == nil || *input.ManagerID == "" {
+		if managerID := services.Org.ManagerOf(userID); managerID != "" {
+			input.ManagerID = &managerID
+		} else {
+			input.ManagerID = nil
+		}
+	}
+
 	pdi := models.PDI{
 		UserID:      userID,
 		Title:       input.Title,
@@ -230,10 +240,21 @@ func SubmitPDI(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Adicione pelo menos uma meta antes de enviar"})
 	}
 
+	// Envia o PDI e inicia a cadeia de aprovação (gestor do PDI) na mesma transação
 	pdi.Status = models.PDIStatusPending
-	if err := config.DB.Save(&pdi).Error; err != nil {
+	var approval *models.ApprovalRequest
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&pdi).Error; err != nil {
+			return err
+		}
+		var err error
+		approval, err = services.Workflow.Submit(tx, models.ApprovalSubjectPDI, pdi.ID)
+		return err
+	})
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Erro ao enviar PDI"})
 	}
+	services.Workflow.NotifySubmitted(approval)
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -633,16 +654,24 @@ func AddCheckin(c *fiber.Ctx) error {
 
 // ==================== ADMIN/GESTOR ====================
 
+// managedPDIs filtra os PDIs que o usuário acompanha: indicados a ele ou de colaboradores
+// no seu escopo (subordinados diretos e indiretos, filial ou todos)
+func managedPDIs(db *gorm.DB, access *services.Access) *gorm.DB {
+	if access.All() {
+		return db
+	}
+	return db.Where("(manager_id = ? OR user_id IN ?)", access.UserID, access.UserIDs())
+}
+
 // AdminGetTeamPDIs retorna os PDIs da equipe (para gestores)
 func AdminGetTeamPDIs(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(string)
 	status := c.Query("status")
 	page := c.QueryInt("page", 1)
 	limit := c.QueryInt("limit", 20)
 
 	offset := (page - 1) * limit
 
-	query := config.DB.Model(&models.PDI{}).Where("manager_id = ?", userID)
+	query := managedPDIs(config.DB.Model(&models.PDI{}), requestAccess(c))
 
 	if status != "" {
 		query = query.Where("status = ?", status)
@@ -699,7 +728,6 @@ func AdminGetTeamPDIs(c *fiber.Ctx) error {
 
 // AdminGetPDIByID retorna detalhes de um PDI (para gestor)
 func AdminGetPDIByID(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(string)
 	pdiID := c.Params("id")
 
 	var pdi models.PDI
@@ -713,7 +741,8 @@ func AdminGetPDIByID(c *fiber.Ctx) error {
 			return db.Order("checkin_date DESC")
 		}).
 		Preload("Checkins.Author").
-		Where("id = ? AND manager_id = ?", pdiID, userID).
+		Scopes(func(db *gorm.DB) *gorm.DB { return managedPDIs(db, requestAccess(c)) }).
+		Where("id = ?", pdiID).
 		First(&pdi).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{"error": "PDI não encontrado"})
 	}
@@ -724,77 +753,33 @@ func AdminGetPDIByID(c *fiber.Ctx) error {
 	})
 }
 
-// AdminApprovePDI aprova um PDI
+// AdminApprovePDI registra a aprovação na etapa atual do PDI
 func AdminApprovePDI(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(string)
-	pdiID := c.Params("id")
-
-	var pdi models.PDI
-	if err := config.DB.Where("id = ? AND manager_id = ?", pdiID, userID).First(&pdi).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "PDI não encontrado"})
-	}
-
-	if pdi.Status != models.PDIStatusPending {
-		return c.Status(400).JSON(fiber.Map{"error": "PDI não está pendente de aprovação"})
-	}
-
-	var input struct {
-		Feedback string `json:"feedback"`
-	}
-	c.BodyParser(&input)
-
-	now := time.Now()
-	updates := map[string]interface{}{
-		"status":      models.PDIStatusApproved,
-		"approved_at": &now,
-	}
-	if input.Feedback != "" {
-		updates["manager_feedback"] = input.Feedback
-	}
-
-	if err := config.DB.Model(&pdi).Updates(updates).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Erro ao aprovar PDI"})
-	}
-
-	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "PDI aprovado com sucesso",
-	})
+	return decidePDI(c, services.ApprovalApprove, "PDI aprovado com sucesso")
 }
 
-// AdminRejectPDI rejeita um PDI
+// AdminRejectPDI rejeita um PDI, devolvendo-o para revisão
 func AdminRejectPDI(c *fiber.Ctx) error {
+	return decidePDI(c, services.ApprovalReject, "PDI retornado para revisão")
+}
+
+func decidePDI(c *fiber.Ctx, decision services.ApprovalDecision, finalMsg string) error {
 	userID := c.Locals("user_id").(string)
 	pdiID := c.Params("id")
 
-	var pdi models.PDI
-	if err := config.DB.Where("id = ? AND manager_id = ?", pdiID, userID).First(&pdi).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "PDI não encontrado"})
-	}
-
-	if pdi.Status != models.PDIStatusPending {
-		return c.Status(400).JSON(fiber.Map{"error": "PDI não está pendente de aprovação"})
-	}
-
 	var input struct {
 		Feedback string `json:"feedback"`
 	}
 	c.BodyParser(&input)
 
-	updates := map[string]interface{}{
-		"status": models.PDIStatusDraft,
-	}
-	if input.Feedback != "" {
-		updates["manager_feedback"] = input.Feedback
-	}
-
-	if err := config.DB.Model(&pdi).Updates(updates).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Erro ao rejeitar PDI"})
+	outcome, err := services.Workflow.Decide(models.ApprovalSubjectPDI, pdiID, userID, decision, input.Feedback)
+	if err != nil {
+		return c.Status(approvalErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
-		"message": "PDI retornado para revisão",
+		"message": approvalMessage(outcome, finalMsg),
 	})
 }
 
@@ -804,7 +789,7 @@ func AdminAddCheckin(c *fiber.Ctx) error {
 	pdiID := c.Params("id")
 
 	var pdi models.PDI
-	if err := config.DB.Where("id = ? AND manager_id = ?", pdiID, userID).First(&pdi).Error; err != nil {
+	if err := managedPDIs(config.DB, requestAccess(c)).Where("id = ?", pdiID).First(&pdi).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{"error": "PDI não encontrado"})
 	}
 
@@ -7,6 +7,7 @@ import (
 
 	"github.com/frappyou/backend/config"
 	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -478,7 +479,7 @@ func UpdateUserProfile(c *fiber.Ctx) error {
 	})
 }
 
-// GetTeamMembers retorna membros da equipe/departamento
+// GetTeamMembers retorna a equipe do colaborador conforme o organograma
 func GetTeamMembers(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(string)
 
@@ -487,25 +488,46 @@ func GetTeamMembers(c *fiber.Ctx) error {
 		return c.Status(404).JSON(fiber.Map{"error": "Usuário não encontrado"})
 	}
 
+	managerID := services.Org.ManagerOf(userID)
+	direct := make(map[string]bool)
+	for _, id := range services.Org.DirectReports(userID) {
+		direct[id] = true
+	}
+	reports := make(map[string]bool)
+	for _, id := range services.Org.AllReports(userID) {
+		reports[id] = true
+	}
+
 	var teamMembers []models.User
-	if currentUser.Department != "" {
-		config.DB.Where("department = ? AND id != ?", currentUser.Department, userID).Find(&teamMembers)
+	if team := services.Org.TeamOf(userID); len(team) > 0 {
+		config.DB.Where("id IN ?", team).Order("name ASC").Find(&teamMembers)
 	}
 
 	var members []fiber.Map
 	for _, member := range teamMembers {
+		relation := "peer"
+		if member.ID == managerID {
+			relation = "manager"
+		} else if direct[member.ID] {
+			relation = "direct_report"
+		} else if reports[member.ID] {
+			relation = "indirect_report"
+		}
+
 		members = append(members, fiber.Map{
 			"id":         member.ID,
 			"name":       member.Name,
 			"position":   member.Position,
 			"department": member.Department,
 			"avatar_url": member.AvatarURL,
+			"relation":   relation,
 		})
 	}
 
 	return c.JSON(fiber.Map{
-		"success":     true,
-		"department":  currentUser.Department,
+		"success":      true,
+		"department":   currentUser.Department,
+		"manager_id":   managerID,
 		"team_members": members,
 	})
 }
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ==================== SESSÕES ====================
+
+// sessionClient extrai IP e user agent da requisição para registrar na sessão
+func sessionClient(c *fiber.Ctx) services.SessionClient {
+	return services.SessionClient{IP: c.IP(), UserAgent: c.Get("User-Agent")}
+}
+
+// sessionIDFromCtx retorna a sessão do access token (definida pelo AuthMiddleware)
+func sessionIDFromCtx(c *fiber.Ctx) string {
+	sessionID, _ := c.Locals("session_id").(string)
+	return sessionID
+}
+
+// RefreshToken troca o refresh token por um novo par de tokens (rotação)
+func RefreshToken(c *fiber.Ctx) error {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Refresh token não fornecido",
+		})
+	}
+
+	tokens, err := services.Sessions.Refresh(req.RefreshToken, sessionClient(c))
+	if err != nil {
+		if errors.Is(err, services.ErrSessionInvalid) || errors.Is(err, services.ErrRefreshTokenReused) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao renovar sessão",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":       true,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// Logout encerra a sessão atual
+func Logout(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := services.Sessions.Revoke(userID, sessionIDFromCtx(c), models.SessionRevokedLogout); err != nil && !errors.Is(err, services.ErrSessionInvalid) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao encerrar sessão",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Sessão encerrada",
+	})
+}
+
+// LogoutAllDevices encerra todas as sessões do usuário (inclusive a atual)
+func LogoutAllDevices(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	revoked, err := services.Sessions.RevokeAll(userID, models.SessionRevokedLogoutAll, "")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao encerrar sessões",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": fmt.Sprintf("%d sessões encerradas", revoked),
+		"revoked": revoked,
+	})
+}
+
+// GetMySessions lista as sessões ativas do usuário, indicando a atual
+func GetMySessions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	sessions, err := services.Sessions.ActiveSessions(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao buscar sessões",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":         true,
+		"sessions":        sessions,
+		"current_session": sessionIDFromCtx(c),
+	})
+}
+
+// RevokeMySession encerra uma sessão do usuário (ex: dispositivo perdido)
+func RevokeMySession(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := services.Sessions.Revoke(userID, c.Params("id"), models.SessionRevokedByUser); err != nil {
+		if errors.Is(err, services.ErrSessionInvalid) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "Sessão não encontrada",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao encerrar sessão",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Sessão encerrada",
+	})
+}
+
+// ==================== SESSÕES (ADMIN) ====================
+
+// AdminGetUserSessions lista as sessões ativas de um usuário
+func AdminGetUserSessions(c *fiber.Ctx) error {
+	sessions, err := services.Sessions.ActiveSessions(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao buscar sessões",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"sessions": sessions,
+	})
+}
+
+// AdminRevokeUserSessions encerra todas as sessões de um usuário
+func AdminRevokeUserSessions(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+	targetUserID := c.Params("id")
+
+	var admin, targetUser models.User
+	config.DB.First(&admin, "id = ?", adminID)
+	if err := config.DB.First(&targetUser, "id = ?", targetUserID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Usuário não encontrado",
+		})
+	}
+
+	revoked, err := services.Sessions.RevokeAll(targetUserID, models.SessionRevokedByAdmin, "")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao encerrar sessões",
+		})
+	}
+
+	CreateAuditLog(adminID, admin.Name, admin.Email, models.ActionLogout, models.EntityUser, targetUserID, targetUser.Name, "sessions", "", "", fmt.Sprintf("Encerrou %d sessões do usuário", revoked), c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": fmt.Sprintf("%d sessões encerradas", revoked),
+		"revoked": revoked,
+	})
+}
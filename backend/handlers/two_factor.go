@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ==================== AUTENTICAÇÃO EM DOIS FATORES ====================
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// twoFactorError converte os erros do serviço de 2FA em respostas HTTP
+func twoFactorError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrTwoFactorInvalidCode):
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "Código de verificação inválido",
+		})
+	case errors.Is(err, services.ErrTwoFactorLocked):
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"success": false,
+			"error":   "Muitas tentativas inválidas. Tente novamente mais tarde.",
+		})
+	case errors.Is(err, services.ErrSessionInvalid):
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "Verificação expirada. Faça login novamente.",
+		})
+	case errors.Is(err, services.ErrTwoFactorNotEnabled),
+		errors.Is(err, services.ErrTwoFactorAlreadyEnabled),
+		errors.Is(err, services.ErrTwoFactorMandatory):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"error":   "Erro na verificação em dois fatores",
+	})
+}
+
+// currentUser carrega o usuário autenticado
+func currentUser(c *fiber.Ctx) (*models.User, error) {
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", c.Locals("user_id").(string)).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// VerifyTwoFactorLogin conclui o login com o código TOTP (ou de recuperação)
+func VerifyTwoFactorLogin(c *fiber.Ctx) error {
+	var req struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Código de verificação é obrigatório",
+		})
+	}
+
+	user, tokens, recoveryCodes, err := services.TwoFactor.CompleteLogin(req.ChallengeToken, req.Code, sessionClient(c))
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+
+	response := fiber.Map{
+		"success":       true,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+		"user":          user.ToResponse(),
+	}
+	if len(recoveryCodes) > 0 {
+		// Cadastro obrigatório concluído no login: códigos exibidos uma única vez
+		response["recovery_codes"] = recoveryCodes
+		CreateAuditLog(user.ID, user.Name, user.Email, models.ActionUpdate, models.EntityUser, user.ID, user.Name, "two_factor", "", "enabled", "Ativou a verificação em dois fatores", c.IP(), c.Get("User-Agent"))
+	}
+	return c.JSON(response)
+}
+
+// GetTwoFactorStatus retorna a situação do 2FA do usuário
+func GetTwoFactorStatus(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Usuário não encontrado",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"two_factor": services.TwoFactor.Status(user),
+	})
+}
+
+// BeginTwoFactorSetup gera o segredo para o aplicativo autenticador
+func BeginTwoFactorSetup(c *fiber.Ctx) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Usuário não encontrado",
+		})
+	}
+
+	enrollment, err := services.TwoFactor.BeginEnrollment(user)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"enrollment": enrollment,
+	})
+}
+
+// ConfirmTwoFactorSetup ativa o 2FA com o primeiro código gerado pelo autenticador
+func ConfirmTwoFactorSetup(c *fiber.Ctx) error {
+	var req twoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Código de verificação é obrigatório",
+		})
+	}
+
+	user, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Usuário não encontrado",
+		})
+	}
+
+	recoveryCodes, err := services.TwoFactor.ConfirmEnrollment(user.ID, req.Code)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+
+	CreateAuditLog(user.ID, user.Name, user.Email, models.ActionUpdate, models.EntityUser, user.ID, user.Name, "two_factor", "", "enabled", "Ativou a verificação em dois fatores", c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"success":        true,
+		"message":        "Verificação em dois fatores ativada. Guarde os códigos de recuperação em local seguro.",
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// DisableTwoFactor desativa o 2FA (não permitido quando a política exige)
+func DisableTwoFactor(c *fiber.Ctx) error {
+	var req twoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Código de verificação é obrigatório",
+		})
+	}
+
+	user, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Usuário não encontrado",
+		})
+	}
+
+	if err := services.TwoFactor.Disable(user, req.Code); err != nil {
+		return twoFactorError(c, err)
+	}
+
+	CreateAuditLog(user.ID, user.Name, user.Email, models.ActionUpdate, models.EntityUser, user.ID, user.Name, "two_factor", "enabled", "disabled", "Desativou a verificação em dois fatores", c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Verificação em dois fatores desativada",
+	})
+}
+
+// RegenerateRecoveryCodes gera novos códigos de recuperação (os anteriores deixam de valer)
+func RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	var req twoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Código de verificação é obrigatório",
+		})
+	}
+
+	recoveryCodes, err := services.TwoFactor.RegenerateRecoveryCodes(c.Locals("user_id").(string), req.Code)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success":        true,
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// ==================== 2FA (ADMIN) ====================
+
+// AdminResetTwoFactor remove o 2FA de um usuário (ex: perda do celular).
+// As sessões são encerradas; no próximo login o cadastro recomeça se for obrigatório.
+func AdminResetTwoFactor(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+	targetUserID := c.Params("id")
+
+	var admin, targetUser models.User
+	config.DB.First(&admin, "id = ?", adminID)
+	if err := config.DB.First(&targetUser, "id = ?", targetUserID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Usuário não encontrado",
+		})
+	}
+
+	if err := services.TwoFactor.Reset(targetUserID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao redefinir verificação em dois fatores",
+		})
+	}
+	services.Sessions.RevokeAll(targetUserID, models.SessionRevokedByAdmin, "")
+
+	CreateAuditLog(adminID, admin.Name, admin.Email, models.ActionUpdate, models.EntityUser, targetUserID, targetUser.Name, "two_factor", "enabled", "reset", "Redefiniu a verificação em dois fatores do usuário", c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Verificação em dois fatores redefinida",
+	})
+}
+
+// GetSecuritySettings retorna a política de segurança
+func GetSecuritySettings(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"settings": services.LoadSecuritySettings(),
+	})
+}
+
+// UpdateSecuritySettings atualiza a política de segurança (admin)
+func UpdateSecuritySettings(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+
+	var req models.SecuritySettings
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Dados inválidos",
+		})
+	}
+
+	var settings models.SecuritySettings
+	if err := config.DB.First(&settings).Error; err != nil {
+		settings = services.DefaultSecuritySettings()
+	}
+	oldValue := settings.RequireTwoFactorPrivileged
+	settings.RequireTwoFactorPrivileged = req.RequireTwoFactorPrivileged
+
+	if err := config.DB.Save(&settings).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao salvar configurações",
+		})
+	}
+
+	var admin models.User
+	config.DB.First(&admin, "id = ?", adminID)
+	CreateAuditLog(adminID, admin.Name, admin.Email, models.ActionUpdate, models.EntitySettings, settings.ID, "Segurança", "require_two_factor_privileged", strconv.FormatBool(oldValue), strconv.FormatBool(settings.RequireTwoFactorPrivileged), "Alterou a exigência de 2FA para admin e folha de pagamento", c.IP(), c.Get("User-Agent"))
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"message":  "Configurações atualizadas com sucesso",
+		"settings": settings,
+	})
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ServeUpload entrega os arquivos enviados (GET /uploads/*). Imagens dos cursos são
+// públicas; vídeos exigem o link assinado gerado pela API (services.SignMediaURL).
+// Documentos nunca são entregues aqui: o download passa por /api/files/documents/:id,
+// que confere o link e decifra o arquivo.
+func ServeUpload(c *fiber.Ctx) error {
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil || key == "" || strings.HasPrefix(key, "documents/") {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	public := strings.HasPrefix(key, "images/")
+	if !public {
+		if err := services.VerifyUploadURL(key, c.Query("expires"), c.Query("signature")); err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	// Bucket S3: o navegador baixa direto do bucket (com Range para o seek dos vídeos)
+	ttl := services.MediaURLTTL
+	if public {
+		ttl = time.Hour
+	}
+	presigned, err := services.Storage.PresignGet(key, ttl)
+	if err != nil {
+		return uploadError(c, err)
+	}
+	if presigned != "" {
+		return c.Redirect(presigned, fiber.StatusFound)
+	}
+
+	local, ok := services.Storage.(*services.LocalStorage)
+	if !ok {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+	path, err := local.Path(key)
+	if err != nil {
+		return uploadError(c, err)
+	}
+	if public {
+		c.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+	} else {
+		c.Set(fiber.HeaderCacheControl, "private, max-age=3600")
+	}
+	// SendFile atende Range Requests (seek nos vídeos); só as imagens são comprimidas
+	return c.SendFile(path, public)
+}
+
+func uploadError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrInvalidObjectKey) || errors.Is(err, services.ErrObjectNotFound) {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"error":   "Erro ao ler arquivo",
+	})
+}
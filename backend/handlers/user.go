@@ -3,6 +3,7 @@ package handlers
 import (
 	"github.com/frappyou/backend/config"
 	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -75,6 +76,8 @@ func GetFullProfile(c *fiber.Ctx) error {
 			"error":   "Erro ao buscar dados: " + err.Error(),
 		})
 	}
+	pessoaFisica.Rg = services.OpenPersonDocument(pessoaFisica.Rg)
+	pessoaFisica.Cnh = services.OpenPersonDocument(pessoaFisica.Cnh)
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -129,8 +132,20 @@ func UpdateFullProfile(c *fiber.Ctx) error {
 		updateData.DataNascimento = nil
 	}
 
+	// RG e CNH cifrados quando ENCRYPTION_PERSON_DOCUMENTS está ligada
+	var err error
+	if updateData.Rg, err = services.SealPersonDocument(updateData.Rg); err == nil {
+		updateData.Cnh, err = services.SealPersonDocument(updateData.Cnh)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao proteger documentos",
+		})
+	}
+
 	// Atualiza os campos permitidos na tabela PessoasFisicasFradema
-	err := config.DB.Exec(`
+	err = config.DB.Exec(`
 		UPDATE dbo.PessoasFisicasFradema
 		SET Nome = CASE WHEN ? IS NOT NULL THEN ? ELSE Nome END,
 			Codinome = COALESCE(?, Codinome),
@@ -197,6 +212,8 @@ func UpdateFullProfile(c *fiber.Ctx) error {
 		FROM dbo.PessoasFisicasFradema
 		WHERE REPLACE(REPLACE(REPLACE(Cpf, '.', ''), '-', ''), ' ', '') = ?
 	`, user.CPF).Scan(&pessoaFisica)
+	pessoaFisica.Rg = services.OpenPersonDocument(pessoaFisica.Rg)
+	pessoaFisica.Cnh = services.OpenPersonDocument(pessoaFisica.Cnh)
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -7,56 +7,25 @@ import (
 
 	"github.com/frappyou/backend/config"
 	"github.com/frappyou/backend/models"
+	"github.com/frappyou/backend/services"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
+// vacationLedger extrato de férias; os saldos são derivados dos lançamentos
+var vacationLedger = services.NewVacationLedger()
+
 // GetVacationBalance retorna o saldo de férias do usuário
 func GetVacationBalance(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(string)
 
-	var balance models.VacationBalance
-	result := config.DB.Where("user_id = ?", userID).First(&balance)
-
-	// Se não existe, cria um saldo inicial baseado na data de admissão
-	if result.Error != nil {
-		// Buscar data de admissão do usuário
-		var user models.User
-		config.DB.First(&user, "id = ?", userID)
-
-		var periodStart time.Time
-		if user.HireDate != nil && !user.HireDate.IsZero() {
-			periodStart = *user.HireDate
-		} else {
-			periodStart = user.CreatedAt
-		}
-
-		// Calcular período aquisitivo atual (cada ano a partir da admissão)
-		now := time.Now()
-		yearsWorked := now.Year() - periodStart.Year()
-		if now.Month() < periodStart.Month() ||
-			(now.Month() == periodStart.Month() && now.Day() < periodStart.Day()) {
-			yearsWorked--
-		}
-
-		// Período aquisitivo atual
-		currentPeriodStart := periodStart.AddDate(yearsWorked, 0, 0)
-		currentPeriodEnd := currentPeriodStart.AddDate(1, 0, 0)
-
-		balance = models.VacationBalance{
-			UserID:        userID,
-			TotalDays:     30,
-			UsedDays:      0,
-			PendingDays:   0,
-			AvailableDays: 30,
-			AbonoDays:     0, // Abono definido pelo RH/DP caso a caso
-			UsedAbono:     0,
-			PeriodStart:   currentPeriodStart,
-			PeriodEnd:     currentPeriodEnd,
-		}
-		config.DB.Create(&balance)
-	} else {
-		// Verificar se precisa renovar o período aquisitivo
-		balance = checkAndRenewVacationPeriod(balance)
+	// Saldo derivado do extrato; a leitura não grava (o worker do extrato abre/encerra os períodos)
+	balance, err := vacationLedger.Balance(config.DB, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao calcular saldo de férias",
+		})
 	}
 
 	return c.JSON(fiber.Map{
@@ -65,35 +34,22 @@ func GetVacationBalance(c *fiber.Ctx) error {
 	})
 }
 
-// checkAndRenewVacationPeriod verifica se o período aquisitivo expirou e renova
-func checkAndRenewVacationPeriod(balance models.VacationBalance) models.VacationBalance {
-	now := time.Now()
-
-	// Se o período atual já terminou, renovar
-	if now.After(balance.PeriodEnd) {
-		// Calcular quantos períodos passaram
-		daysSinceEnd := now.Sub(balance.PeriodEnd).Hours() / 24
-		periodsElapsed := int(daysSinceEnd/365) + 1
-
-		// Novo período aquisitivo
-		newPeriodStart := balance.PeriodEnd
-		newPeriodEnd := newPeriodStart.AddDate(periodsElapsed, 0, 0)
-
-		// Renovar com 30 dias (os dias não usados do período anterior são perdidos conforme a CLT)
-		balance.TotalDays = 30
-		balance.UsedDays = 0
-		balance.PendingDays = 0
-		balance.AvailableDays = 30
-		balance.AbonoDays = 0 // Abono definido pelo RH/DP caso a caso
-		balance.UsedAbono = 0
-		balance.PeriodStart = newPeriodStart
-		balance.PeriodEnd = newPeriodEnd
+// GetMyVacationLedger retorna o extrato de férias do usuário (períodos e lançamentos)
+func GetMyVacationLedger(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
 
-		// Atualizar no banco
-		config.DB.Save(&balance)
+	statement, err := vacationLedger.Statement(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao carregar extrato de férias",
+		})
 	}
 
-	return balance
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"statement": statement,
+	})
 }
 
 // CreateVacation cria uma nova solicitação de férias/ausência
@@ -125,90 +81,52 @@ func CreateVacation(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validações
-	if endDate.Before(startDate) {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"message": "Data de término deve ser posterior à data de início",
-		})
-	}
-
-	if startDate.Before(time.Now().Truncate(24 * time.Hour)) {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"message": "Não é possível solicitar férias para datas passadas",
-		})
-	}
-
-	// Calcula total de dias (incluindo fins de semana por simplicidade)
-	totalDays := int(endDate.Sub(startDate).Hours()/24) + 1
-
-	// Verifica saldo para férias
-	if req.Type == models.VacationTypeFerias {
-		var balance models.VacationBalance
-		config.DB.Where("user_id = ?", userID).First(&balance)
-
-		if balance.AvailableDays < totalDays {
-			return c.Status(400).JSON(fiber.Map{
-				"success": false,
-				"message": "Saldo insuficiente de férias",
-			})
-		}
-	}
-
-	// Verifica saldo para abono
-	if req.Type == models.VacationTypeAbono {
-		var balance models.VacationBalance
-		config.DB.Where("user_id = ?", userID).First(&balance)
-
-		remainingAbono := balance.AbonoDays - balance.UsedAbono
-		if remainingAbono < totalDays {
-			return c.Status(400).JSON(fiber.Map{
-				"success": false,
-				"message": "Saldo insuficiente de abono",
-			})
-		}
-	}
-
-	// Verifica conflitos de datas
-	var existingVacation models.Vacation
-	conflictResult := config.DB.Where(
-		"user_id = ? AND status IN ('pending', 'approved') AND ((start_date <= ? AND end_date >= ?) OR (start_date <= ? AND end_date >= ?) OR (start_date >= ? AND end_date <= ?))",
-		userID, endDate, startDate, startDate, startDate, startDate, endDate,
-	).First(&existingVacation)
-
-	if conflictResult.Error == nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"message": "Já existe uma solicitação para este período",
-		})
-	}
-
-	// Cria a solicitação
-	vacation := models.Vacation{
+	// Valida a solicitação contra as configurações de férias e a CLT
+	period, err := services.NewVacationPolicy().Validate(services.VacationPolicyRequest{
 		UserID:    userID,
 		Type:      req.Type,
 		StartDate: startDate,
 		EndDate:   endDate,
-		TotalDays: totalDays,
-		Reason:    req.Reason,
-		Notes:     req.Notes,
-		Status:    models.VacationStatusPending,
+	})
+	if err != nil {
+		return vacationPolicyErrorResponse(c, err)
 	}
+	totalDays := period.TotalDays
 
-	if err := config.DB.Create(&vacation).Error; err != nil {
+	// Cria a solicitação
+	vacation := models.Vacation{
+		UserID:       userID,
+		Type:         req.Type,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		TotalDays:    totalDays,
+		BusinessDays: period.BusinessDays,
+		Reason:       req.Reason,
+		Notes:        req.Notes,
+		Status:       models.VacationStatusPending,
+	}
+
+	// Grava a solicitação, reserva os dias no extrato de férias e inicia a aprovação na mesma transação
+	var approval *models.ApprovalRequest
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&vacation).Error; err != nil {
+			return err
+		}
+		if _, err := vacationLedger.SyncVacation(tx, vacation, userID); err != nil {
+			return err
+		}
+		// Inicia a cadeia de aprovação (gestor direto → RH)
+		var err error
+		approval, err = services.Workflow.Submit(tx, models.ApprovalSubjectVacation, vacation.ID)
+		return err
+	})
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"success": false,
 			"message": "Erro ao criar solicitação",
 		})
 	}
-
-	// Atualiza dias pendentes no saldo
-	if req.Type == models.VacationTypeFerias {
-		config.DB.Model(&models.VacationBalance{}).
-			Where("user_id = ?", userID).
-			Update("pending_days", config.DB.Raw("pending_days + ?", totalDays))
-	}
+	services.Workflow.NotifySubmitted(approval)
 
 	// Carrega dados do usuário
 	config.DB.Preload("User").Where("id = ?", vacation.ID).First(&vacation)
@@ -220,6 +138,19 @@ func CreateVacation(c *fiber.Ctx) error {
 	})
 }
 
+// vacationPolicyErrorResponse responde com as violações de política estruturadas
+func vacationPolicyErrorResponse(c *fiber.Ctx, err error) error {
+	response := fiber.Map{
+		"success": false,
+		"message": err.Error(),
+	}
+	if policyErr, ok := services.AsVacationPolicyError(err); ok {
+		response["message"] = policyErr.Violations[0].Message
+		response["violations"] = policyErr.Violations
+	}
+	return c.Status(400).JSON(response)
+}
+
 // GetMyVacations retorna as férias do usuário logado
 func GetMyVacations(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(string)
@@ -314,24 +245,16 @@ func CancelVacation(c *fiber.Ctx) error {
 		})
 	}
 
-	previousStatus := vacation.Status
 	vacation.Status = models.VacationStatusCanceled
-	config.DB.Save(&vacation)
-
-	// Atualiza saldo de dias
-	if vacation.Type == models.VacationTypeFerias {
-		if previousStatus == models.VacationStatusPending {
-			// Se estava pendente, diminui dias pendentes
-			config.DB.Model(&models.VacationBalance{}).
-				Where("user_id = ?", userID).
-				Update("pending_days", config.DB.Raw("pending_days - ?", vacation.TotalDays))
-		} else if previousStatus == models.VacationStatusApproved {
-			// Se estava aprovada, devolve os dias usados
-			config.DB.Model(&models.VacationBalance{}).
-				Where("user_id = ?", userID).
-				Update("used_days", config.DB.Raw("used_days - ?", vacation.TotalDays))
-		}
+
+	// Libera a reserva ou estorna os dias no extrato junto com o cancelamento
+	if err := saveVacationWithLedger(&vacation, userID, true); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao cancelar solicitação",
+		})
 	}
+	services.Events.Publish(services.EventVacationUpdated, vacation.UserID, vacation)
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -357,7 +280,8 @@ func InterruptVacation(c *fiber.Ctx) error {
 	}
 
 	var vacation models.Vacation
-	result := config.DB.Where("id = ? AND status = ?", vacationID, models.VacationStatusApproved).First(&vacation)
+	result := requestAccess(c).Apply(config.DB, "user_id").
+		Where("id = ? AND status = ?", vacationID, models.VacationStatusApproved).First(&vacation)
 
 	if result.Error != nil {
 		return c.Status(404).JSON(fiber.Map{
@@ -392,14 +316,15 @@ func InterruptVacation(c *fiber.Ctx) error {
 	vacation.InterruptedBy = &adminID
 	vacation.ActualDays = actualDays
 	vacation.InterruptReason = req.Reason
-	config.DB.Save(&vacation)
 
-	// Devolve os dias não usados ao saldo
-	if vacation.Type == models.VacationTypeFerias && daysToReturn > 0 {
-		config.DB.Model(&models.VacationBalance{}).
-			Where("user_id = ?", vacation.UserID).
-			Update("used_days", config.DB.Raw("used_days - ?", daysToReturn))
+	// Devolve os dias não usados ao saldo (estorno no extrato)
+	if err := saveVacationWithLedger(&vacation, adminID, false); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao interromper férias",
+		})
 	}
+	services.Events.Publish(services.EventVacationUpdated, vacation.UserID, vacation)
 
 	return c.JSON(fiber.Map{
 		"success":       true,
@@ -409,10 +334,10 @@ func InterruptVacation(c *fiber.Ctx) error {
 	})
 }
 
-// GetTeamVacations retorna as férias da equipe (para gestores)
+// GetTeamVacations retorna as férias da equipe conforme o organograma
+// (gestor, colegas e subordinados); administradores veem todas
 func GetTeamVacations(c *fiber.Ctx) error {
-	// Por enquanto, retorna todas as férias aprovadas/pendentes
-	// No futuro, filtrar por equipe do gestor
+	userID := c.Locals("user_id").(string)
 
 	month := c.Query("month", "")
 	year := c.Query("year", "")
@@ -420,6 +345,10 @@ func GetTeamVacations(c *fiber.Ctx) error {
 	query := config.DB.Model(&models.Vacation{}).
 		Where("status IN ('pending', 'approved')")
 
+	if c.Locals("role") != "admin" {
+		query = query.Where("user_id IN ?", append(services.Org.TeamOf(userID), userID))
+	}
+
 	if month != "" && year != "" {
 		monthInt, _ := strconv.Atoi(month)
 		yearInt, _ := strconv.Atoi(year)
@@ -454,13 +383,32 @@ func GetTeamVacations(c *fiber.Ctx) error {
 	})
 }
 
-// GetPendingApprovals retorna solicitações pendentes para aprovação (gestores/admin)
+// GetPendingApprovals retorna as férias pendentes na caixa de aprovações do usuário
 func GetPendingApprovals(c *fiber.Ctx) error {
-	var vacations []models.Vacation
-	config.DB.Preload("User").
-		Where("status = ?", models.VacationStatusPending).
-		Order("created_at ASC").
-		Find(&vacations)
+	userID := c.Locals("user_id").(string)
+
+	tasks, err := services.Workflow.Inbox(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao buscar aprovações pendentes",
+		})
+	}
+
+	var vacationIDs []string
+	for _, task := range tasks {
+		if task.Request.SubjectType == models.ApprovalSubjectVacation {
+			vacationIDs = append(vacationIDs, task.Request.SubjectID)
+		}
+	}
+
+	vacations := []models.Vacation{}
+	if len(vacationIDs) > 0 {
+		config.DB.Preload("User").
+			Where("id IN ? AND status = ?", vacationIDs, models.VacationStatusPending).
+			Order("created_at ASC").
+			Find(&vacations)
+	}
 
 	return c.JSON(fiber.Map{
 		"success":   true,
@@ -468,7 +416,7 @@ func GetPendingApprovals(c *fiber.Ctx) error {
 	})
 }
 
-// ApproveOrRejectVacation aprova ou rejeita uma solicitação
+// ApproveOrRejectVacation registra a decisão na etapa atual da cadeia de aprovação
 func ApproveOrRejectVacation(c *fiber.Ctx) error {
 	approverID := c.Locals("user_id").(string)
 	vacationID := c.Params("id")
@@ -481,59 +429,24 @@ func ApproveOrRejectVacation(c *fiber.Ctx) error {
 		})
 	}
 
-	var vacation models.Vacation
-	result := config.DB.Where("id = ? AND status = ?", vacationID, models.VacationStatusPending).First(&vacation)
-
-	if result.Error != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"message": "Solicitação não encontrada ou já processada",
-		})
-	}
-
-	now := time.Now()
-	vacation.Status = req.Status
-	vacation.ApprovedBy = &approverID
-	vacation.ApprovedAt = &now
-
-	if req.Status == models.VacationStatusRejected {
-		vacation.RejectReason = req.RejectReason
-	}
-
-	config.DB.Save(&vacation)
-
-	// Atualiza saldo de férias
-	if vacation.Type == models.VacationTypeFerias {
-		var balance models.VacationBalance
-		config.DB.Where("user_id = ?", vacation.UserID).First(&balance)
-
-		if req.Status == models.VacationStatusApproved {
-			// Move de pendente para usado
-			balance.PendingDays -= vacation.TotalDays
-			balance.UsedDays += vacation.TotalDays
-			balance.AvailableDays = balance.TotalDays - balance.UsedDays - balance.PendingDays
-		} else {
-			// Apenas remove de pendente
-			balance.PendingDays -= vacation.TotalDays
-		}
-		config.DB.Save(&balance)
-	}
-
-	// Atualiza saldo de abono
-	if vacation.Type == models.VacationTypeAbono && req.Status == models.VacationStatusApproved {
-		config.DB.Model(&models.VacationBalance{}).
-			Where("user_id = ?", vacation.UserID).
-			Update("used_abono", config.DB.Raw("used_abono + ?", vacation.TotalDays))
-	}
-
+	decision := services.ApprovalApprove
 	statusMsg := "aprovada"
 	if req.Status == models.VacationStatusRejected {
+		decision = services.ApprovalReject
 		statusMsg = "rejeitada"
 	}
 
+	outcome, err := services.Workflow.Decide(models.ApprovalSubjectVacation, vacationID, approverID, decision, req.RejectReason)
+	if err != nil {
+		return c.Status(approvalErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
 	return c.JSON(fiber.Map{
 		"success": true,
-		"message": "Solicitação " + statusMsg + " com sucesso",
+		"message": approvalMessage(outcome, "Solicitação "+statusMsg+" com sucesso"),
 	})
 }
 
@@ -585,31 +498,46 @@ func AdminCreateVacation(c *fiber.Ctx) error {
 		})
 	}
 
-	// Verifica se usuário existe
+	// Verifica se usuário existe e está no escopo de quem está criando
 	var user models.User
-	if err := config.DB.Where("id = ?", req.UserID).First(&user).Error; err != nil {
+	if err := requestAccess(c).Apply(config.DB, "id").Where("id = ?", req.UserID).First(&user).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"success": false,
 			"message": "Usuário não encontrado",
 		})
 	}
 
-	startDate, _ := time.Parse("2006-01-02", req.StartDate)
-	endDate, _ := time.Parse("2006-01-02", req.EndDate)
-	totalDays := int(endDate.Sub(startDate).Hours()/24) + 1
+	startDate, errStart := time.Parse("2006-01-02", req.StartDate)
+	endDate, errEnd := time.Parse("2006-01-02", req.EndDate)
+	if errStart != nil || errEnd != nil || endDate.Before(startDate) {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Período inválido",
+		})
+	}
+
+	period, err := services.CalculateVacationPeriod(req.UserID, req.Type, startDate, endDate, services.LoadVacationSettings())
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+	totalDays := period.TotalDays
 
 	adminID := c.Locals("user_id").(string)
 	now := time.Now()
 
 	vacation := models.Vacation{
-		UserID:    req.UserID,
-		Type:      req.Type,
-		StartDate: startDate,
-		EndDate:   endDate,
-		TotalDays: totalDays,
-		Reason:    req.Reason,
-		Notes:     req.Notes,
-		Status:    req.Status,
+		UserID:       req.UserID,
+		Type:         req.Type,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		TotalDays:    totalDays,
+		BusinessDays: period.BusinessDays,
+		Reason:       req.Reason,
+		Notes:        req.Notes,
+		Status:       req.Status,
 	}
 
 	// Se já aprovado, marca quem aprovou
@@ -618,31 +546,29 @@ func AdminCreateVacation(c *fiber.Ctx) error {
 		vacation.ApprovedAt = &now
 	}
 
-	if err := config.DB.Create(&vacation).Error; err != nil {
+	// Lança a reserva/consumo no extrato conforme o status informado, junto com a solicitação
+	var approval *models.ApprovalRequest
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&vacation).Error; err != nil {
+			return err
+		}
+		if _, err := vacationLedger.SyncVacation(tx, vacation, adminID); err != nil {
+			return err
+		}
+		if vacation.Status != models.VacationStatusPending {
+			return nil
+		}
+		var err error
+		approval, err = services.Workflow.Submit(tx, models.ApprovalSubjectVacation, vacation.ID)
+		return err
+	})
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"success": false,
 			"message": "Erro ao criar solicitação",
 		})
 	}
-
-	// Atualiza saldo se aprovado
-	if req.Status == models.VacationStatusApproved && req.Type == models.VacationTypeFerias {
-		var balance models.VacationBalance
-		if config.DB.Where("user_id = ?", req.UserID).First(&balance).Error != nil {
-			balance = models.VacationBalance{
-				UserID:        req.UserID,
-				TotalDays:     30,
-				UsedDays:      totalDays,
-				AvailableDays: 30 - totalDays,
-				AbonoDays:     3,
-			}
-			config.DB.Create(&balance)
-		} else {
-			balance.UsedDays += totalDays
-			balance.AvailableDays = balance.TotalDays - balance.UsedDays - balance.PendingDays
-			config.DB.Save(&balance)
-		}
-	}
+	services.Workflow.NotifySubmitted(approval)
 
 	config.DB.Preload("User").Where("id = ?", vacation.ID).First(&vacation)
 
@@ -658,7 +584,7 @@ func AdminUpdateVacation(c *fiber.Ctx) error {
 	vacationID := c.Params("id")
 
 	var vacation models.Vacation
-	if err := config.DB.Where("id = ?", vacationID).First(&vacation).Error; err != nil {
+	if err := requestAccess(c).Apply(config.DB, "user_id").Where("id = ?", vacationID).First(&vacation).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"success": false,
 			"message": "Solicitação não encontrada",
@@ -682,7 +608,6 @@ func AdminUpdateVacation(c *fiber.Ctx) error {
 	}
 
 	oldStatus := vacation.Status
-	oldTotalDays := vacation.TotalDays
 
 	if req.StartDate != "" && req.EndDate != "" {
 		startDate, _ := time.Parse("2006-01-02", req.StartDate)
@@ -707,23 +632,14 @@ func AdminUpdateVacation(c *fiber.Ctx) error {
 	vacation.Reason = req.Reason
 	vacation.Notes = req.Notes
 
-	config.DB.Save(&vacation)
-
-	// Atualiza saldo se mudou status ou dias
-	if vacation.Type == models.VacationTypeFerias {
-		var balance models.VacationBalance
-		config.DB.Where("user_id = ?", vacation.UserID).First(&balance)
-
-		// Remove dias antigos se estava aprovado
-		if oldStatus == models.VacationStatusApproved {
-			balance.UsedDays -= oldTotalDays
-		}
-		// Adiciona novos dias se está aprovado
-		if vacation.Status == models.VacationStatusApproved {
-			balance.UsedDays += vacation.TotalDays
-		}
-		balance.AvailableDays = balance.TotalDays - balance.UsedDays - balance.PendingDays
-		config.DB.Save(&balance)
+	// Ajusta o extrato à nova duração/status (estornos e lançamentos de diferença). Decisão
+	// direta do admin encerra o fluxo de aprovação em andamento.
+	decided := oldStatus == models.VacationStatusPending && vacation.Status != models.VacationStatusPending
+	if err := saveVacationWithLedger(&vacation, c.Locals("user_id").(string), decided); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao atualizar solicitação",
+		})
 	}
 
 	return c.JSON(fiber.Map{
@@ -738,39 +654,44 @@ func AdminDeleteVacation(c *fiber.Ctx) error {
 	vacationID := c.Params("id")
 
 	var vacation models.Vacation
-	if err := config.DB.Where("id = ?", vacationID).First(&vacation).Error; err != nil {
+	if err := requestAccess(c).Apply(config.DB, "user_id").Where("id = ?", vacationID).First(&vacation).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"success": false,
 			"message": "Solicitação não encontrada",
 		})
 	}
 
-	// Restaura saldo se estava aprovado
-	if vacation.Status == models.VacationStatusApproved && vacation.Type == models.VacationTypeFerias {
-		var balance models.VacationBalance
-		if config.DB.Where("user_id = ?", vacation.UserID).First(&balance).Error == nil {
-			balance.UsedDays -= vacation.TotalDays
-			balance.AvailableDays = balance.TotalDays - balance.UsedDays - balance.PendingDays
-			config.DB.Save(&balance)
+	// Estorna os lançamentos da solicitação no extrato e remove na mesma transação
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := vacationLedger.ClearVacation(tx, vacation, c.Locals("user_id").(string)); err != nil {
+			return err
 		}
+		if err := services.Workflow.Cancel(tx, models.ApprovalSubjectVacation, vacation.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&vacation).Error
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao remover solicitação",
+		})
 	}
 
-	config.DB.Delete(&vacation)
-
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Solicitação removida com sucesso",
 	})
 }
 
-// AdminGetAllVacations retorna todas as férias/ausências
+// AdminGetAllVacations retorna as férias/ausências dentro do escopo do usuário (equipe, filial ou todas)
 func AdminGetAllVacations(c *fiber.Ctx) error {
 	month := c.Query("month", "")
 	year := c.Query("year", "")
 	userID := c.Query("user_id", "")
 	status := c.Query("status", "")
 
-	query := config.DB.Model(&models.Vacation{})
+	query := requestAccess(c).Apply(config.DB.Model(&models.Vacation{}), "user_id")
 
 	if userID != "" {
 		query = query.Where("user_id = ?", userID)
@@ -798,10 +719,10 @@ func AdminGetAllVacations(c *fiber.Ctx) error {
 	})
 }
 
-// GetAllUsersForAdmin retorna lista de usuários para seleção
+// GetAllUsersForAdmin retorna lista de usuários para seleção (dentro do escopo do usuário)
 func GetAllUsersForAdmin(c *fiber.Ctx) error {
 	var users []models.User
-	config.DB.Select("id", "name", "email", "cpf").Order("name ASC").Find(&users)
+	requestAccess(c).Apply(config.DB, "id").Select("id", "name", "email", "cpf").Order("name ASC").Find(&users)
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -992,8 +913,8 @@ func CreateVacationSellRequest(c *fiber.Ctx) error {
 	}
 
 	// Verifica saldo disponível
-	var balance models.VacationBalance
-	if err := config.DB.Where("user_id = ?", userID).First(&balance).Error; err != nil {
+	balance, err := vacationLedger.Balance(config.DB, userID)
+	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"success": false,
 			"message": "Saldo de férias não encontrado",
@@ -1027,30 +948,26 @@ func CreateVacationSellRequest(c *fiber.Ctx) error {
 		PeriodYear: time.Now().Year(),
 	}
 
-	if err := config.DB.Create(&sellRequest).Error; err != nil {
+	// Grava a solicitação e inicia a cadeia de aprovação; os aprovadores são notificados pelo workflow
+	var approval *models.ApprovalRequest
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&sellRequest).Error; err != nil {
+			return err
+		}
+		var err error
+		approval, err = services.Workflow.Submit(tx, models.ApprovalSubjectVacationSell, sellRequest.ID)
+		return err
+	})
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"success": false,
 			"message": "Erro ao criar solicitação",
 		})
 	}
+	services.Workflow.NotifySubmitted(approval)
 
-	// Carrega dados do usuário para notificação
 	config.DB.Preload("User").Where("id = ?", sellRequest.ID).First(&sellRequest)
 
-	// Cria notificação para administradores
-	var admins []models.User
-	config.DB.Where("role = ?", "admin").Find(&admins)
-
-	for _, admin := range admins {
-		notification := models.Notification{
-			UserID:  admin.ID,
-			Title:   "Nova Solicitação de Venda de Férias",
-			Message: sellRequest.User.Name + " solicitou vender " + strconv.Itoa(req.DaysToSell) + " dias de férias",
-			Type:    "vacation_sell",
-		}
-		config.DB.Create(&notification)
-	}
-
 	return c.Status(201).JSON(fiber.Map{
 		"success": true,
 		"message": "Solicitação de venda de férias enviada com sucesso",
@@ -1077,7 +994,7 @@ func GetMyVacationSellRequests(c *fiber.Ctx) error {
 // GetPendingVacationSellRequests retorna solicitações pendentes (admin)
 func GetPendingVacationSellRequests(c *fiber.Ctx) error {
 	var requests []models.VacationSellRequest
-	config.DB.Preload("User").
+	requestAccess(c).Apply(config.DB, "user_id").Preload("User").
 		Where("status = ?", models.VacationSellStatusPending).
 		Order("created_at ASC").
 		Find(&requests)
@@ -1092,7 +1009,7 @@ func GetPendingVacationSellRequests(c *fiber.Ctx) error {
 func GetAllVacationSellRequests(c *fiber.Ctx) error {
 	userID := c.Query("user_id", "")
 
-	query := config.DB.Model(&models.VacationSellRequest{}).Preload("User").Preload("Approver")
+	query := requestAccess(c).Apply(config.DB.Model(&models.VacationSellRequest{}), "user_id").Preload("User").Preload("Approver")
 
 	if userID != "" {
 		query = query.Where("user_id = ?", userID)
@@ -1107,7 +1024,7 @@ func GetAllVacationSellRequests(c *fiber.Ctx) error {
 	})
 }
 
-// ApproveOrRejectVacationSell aprova ou rejeita venda de férias
+// ApproveOrRejectVacationSell registra a decisão na etapa atual da venda de férias
 func ApproveOrRejectVacationSell(c *fiber.Ctx) error {
 	adminID := c.Locals("user_id").(string)
 	requestID := c.Params("id")
@@ -1124,53 +1041,24 @@ func ApproveOrRejectVacationSell(c *fiber.Ctx) error {
 		})
 	}
 
-	var sellRequest models.VacationSellRequest
-	if err := config.DB.Preload("User").Where("id = ? AND status = ?",
-		requestID, models.VacationSellStatusPending).First(&sellRequest).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"message": "Solicitação não encontrada ou já processada",
-		})
-	}
-
-	now := time.Now()
-	sellRequest.Status = req.Status
-	sellRequest.ApprovedBy = &adminID
-	sellRequest.ApprovedAt = &now
-
-	if req.Status == models.VacationSellStatusRejected {
-		sellRequest.RejectReason = req.RejectReason
-	}
-
-	config.DB.Save(&sellRequest)
-
-	// Se aprovado, atualiza o saldo de férias
-	if req.Status == models.VacationSellStatusApproved {
-		var balance models.VacationBalance
-		if config.DB.Where("user_id = ?", sellRequest.UserID).First(&balance).Error == nil {
-			balance.TotalDays -= sellRequest.DaysToSell
-			balance.AvailableDays = balance.TotalDays - balance.UsedDays - balance.PendingDays
-			config.DB.Save(&balance)
-		}
-	}
-
-	// Notifica o usuário
+	decision := services.ApprovalApprove
 	statusMsg := "aprovada"
 	if req.Status == models.VacationSellStatusRejected {
+		decision = services.ApprovalReject
 		statusMsg = "rejeitada"
 	}
 
-	notification := models.Notification{
-		UserID:  sellRequest.UserID,
-		Title:   "Venda de Férias " + statusMsg,
-		Message: "Sua solicitação de venda de " + strconv.Itoa(sellRequest.DaysToSell) + " dias de férias foi " + statusMsg,
-		Type:    "vacation_sell",
+	outcome, err := services.Workflow.Decide(models.ApprovalSubjectVacationSell, requestID, adminID, decision, req.RejectReason)
+	if err != nil {
+		return c.Status(approvalErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
 	}
-	config.DB.Create(&notification)
 
 	return c.JSON(fiber.Map{
 		"success": true,
-		"message": "Solicitação " + statusMsg + " com sucesso",
+		"message": approvalMessage(outcome, "Solicitação "+statusMsg+" com sucesso"),
 	})
 }
 
@@ -1197,7 +1085,7 @@ func AdminUpdateVacationSell(c *fiber.Ctx) error {
 	}
 
 	var sellRequest models.VacationSellRequest
-	if err := config.DB.Preload("User").Where("id = ?", requestID).First(&sellRequest).Error; err != nil {
+	if err := requestAccess(c).Apply(config.DB, "user_id").Preload("User").Where("id = ?", requestID).First(&sellRequest).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"success": false,
 			"message": "Solicitação não encontrada",
@@ -1206,7 +1094,21 @@ func AdminUpdateVacationSell(c *fiber.Ctx) error {
 
 	// Atualiza a quantidade de dias
 	sellRequest.DaysToSell = req.DaysToSell
-	config.DB.Save(&sellRequest)
+
+	// Vendas já aprovadas têm a diferença lançada no extrato
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&sellRequest).Error; err != nil {
+			return err
+		}
+		_, err := vacationLedger.SyncSale(tx, sellRequest, c.Locals("user_id").(string))
+		return err
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao atualizar solicitação",
+		})
+	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -1225,6 +1127,8 @@ func AdminGetAllVacationBalances(c *fiber.Ctx) error {
 		UserEmail string `json:"user_email"`
 	}
 
+	access := requestAccess(c)
+
 	var balances []BalanceWithUser
 	config.DB.Raw(`
 		SELECT
@@ -1234,8 +1138,9 @@ func AdminGetAllVacationBalances(c *fiber.Ctx) error {
 		FROM vacation_balances vb
 		JOIN users u ON vb.user_id = u.id
 		WHERE u.deleted_at IS NULL
+		AND (? = 1 OR u.id IN ?)
 		ORDER BY u.name
-	`).Scan(&balances)
+	`, access.All(), append(access.UserIDs(), "")).Scan(&balances)
 
 	return c.JSON(fiber.Map{
 		"success":  true,
@@ -1247,20 +1152,28 @@ func AdminGetAllVacationBalances(c *fiber.Ctx) error {
 func AdminGetVacationBalance(c *fiber.Ctx) error {
 	userID := c.Params("user_id")
 
-	var balance models.VacationBalance
-	if err := config.DB.Where("user_id = ?", userID).First(&balance).Error; err != nil {
+	var user models.User
+	if err := requestAccess(c).Apply(config.DB, "id").First(&user, "id = ?", userID).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"success": false,
-			"message": "Saldo não encontrado para este usuário",
+			"message": "Usuário não encontrado",
 		})
 	}
 
-	var user models.User
-	config.DB.First(&user, "id = ?", userID)
+	statement, err := vacationLedger.Statement(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao calcular saldo de férias",
+		})
+	}
+	balance := statement.Balance
 
 	return c.JSON(fiber.Map{
 		"success": true,
 		"balance": balance,
+		"periods": statement.Periods,
+		"entries": statement.Entries,
 		"user": fiber.Map{
 			"id":    user.ID,
 			"name":  user.Name,
@@ -1269,8 +1182,10 @@ func AdminGetVacationBalance(c *fiber.Ctx) error {
 	})
 }
 
-// AdminUpdateVacationBalance atualiza saldo de férias de um usuário (admin)
+// AdminUpdateVacationBalance ajusta o saldo de férias de um usuário (admin).
+// Os valores informados geram lançamentos de ajuste no extrato; o saldo não é sobrescrito.
 func AdminUpdateVacationBalance(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
 	userID := c.Params("user_id")
 
 	var req struct {
@@ -1282,6 +1197,7 @@ func AdminUpdateVacationBalance(c *fiber.Ctx) error {
 		UsedAbono     *int    `json:"used_abono"`
 		PeriodStart   *string `json:"period_start"`
 		PeriodEnd     *string `json:"period_end"`
+		Reason        string  `json:"reason"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -1291,76 +1207,43 @@ func AdminUpdateVacationBalance(c *fiber.Ctx) error {
 		})
 	}
 
-	var balance models.VacationBalance
-	result := config.DB.Where("user_id = ?", userID).First(&balance)
-
-	// Se não existe, cria
-	if result.Error != nil {
-		balance = models.VacationBalance{
-			UserID:        userID,
-			TotalDays:     30,
-			UsedDays:      0,
-			PendingDays:   0,
-			AvailableDays: 30,
-			AbonoDays:     0,
-			UsedAbono:     0,
-			PeriodStart:   time.Now().AddDate(-1, 0, 0),
-			PeriodEnd:     time.Now(),
-		}
+	var user models.User
+	if err := requestAccess(c).Apply(config.DB, "id").First(&user, "id = ?", userID).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"success": false,
+			"message": "Usuário não encontrado",
+		})
 	}
 
-	// Atualiza campos fornecidos
-	if req.TotalDays != nil {
-		balance.TotalDays = *req.TotalDays
-	}
-	if req.UsedDays != nil {
-		balance.UsedDays = *req.UsedDays
-	}
-	if req.PendingDays != nil {
-		balance.PendingDays = *req.PendingDays
+	adjustment := services.BalanceAdjustment{
+		TotalDays:     req.TotalDays,
+		UsedDays:      req.UsedDays,
+		PendingDays:   req.PendingDays,
+		AvailableDays: req.AvailableDays,
+		AbonoDays:     req.AbonoDays,
+		UsedAbono:     req.UsedAbono,
+		Reason:        req.Reason,
 	}
-	if req.AvailableDays != nil {
-		balance.AvailableDays = *req.AvailableDays
-	}
-	if req.AbonoDays != nil {
-		balance.AbonoDays = *req.AbonoDays
-	}
-	if req.UsedAbono != nil {
-		balance.UsedAbono = *req.UsedAbono
-	}
-	// Parse period_start como string para time.Time
+	// Parse period_start/period_end como string para time.Time
 	if req.PeriodStart != nil && *req.PeriodStart != "" {
-		parsedDate, err := time.Parse("2006-01-02", *req.PeriodStart)
-		if err == nil {
-			balance.PeriodStart = parsedDate
+		if parsedDate, err := time.Parse("2006-01-02", *req.PeriodStart); err == nil {
+			adjustment.PeriodStart = &parsedDate
 		}
 	}
-	// Parse period_end como string para time.Time
 	if req.PeriodEnd != nil && *req.PeriodEnd != "" {
-		parsedDate, err := time.Parse("2006-01-02", *req.PeriodEnd)
-		if err == nil {
-			balance.PeriodEnd = parsedDate
+		if parsedDate, err := time.Parse("2006-01-02", *req.PeriodEnd); err == nil {
+			adjustment.PeriodEnd = &parsedDate
 		}
 	}
 
-	// Recalcular available_days se não foi fornecido explicitamente
-	if req.AvailableDays == nil {
-		balance.AvailableDays = balance.TotalDays - balance.UsedDays - balance.PendingDays
-		if balance.AvailableDays < 0 {
-			balance.AvailableDays = 0
-		}
-	}
-
-	if result.Error != nil {
-		config.DB.Create(&balance)
-	} else {
-		config.DB.Save(&balance)
+	balance, err := vacationLedger.Adjust(config.DB, userID, adjustment, adminID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"message": "Erro ao ajustar saldo de férias",
+		})
 	}
 
-	// Buscar nome do usuário para log
-	var user models.User
-	config.DB.First(&user, "id = ?", userID)
-
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Saldo de férias atualizado com sucesso",
@@ -1382,7 +1265,7 @@ func AdminSearchUsersForVacation(c *fiber.Ctx) error {
 		Email string `json:"email"`
 	}
 
-	query := config.DB.Table("users").Select("id, name, email").Where("deleted_at IS NULL")
+	query := requestAccess(c).Apply(config.DB.Table("users"), "id").Select("id, name, email").Where("deleted_at IS NULL")
 
 	if search != "" {
 		query = query.Where("name LIKE ? OR email LIKE ?", "%"+search+"%", "%"+search+"%")
@@ -1405,25 +1288,7 @@ func GetVacationSettings(c *fiber.Ctx) error {
 
 	// Se não existe, cria configurações padrão
 	if result.Error != nil {
-		settings = models.VacationSettings{
-			TotalDaysPerYear:      30,
-			MinDaysPerRequest:     5,
-			MaxDaysPerRequest:     30,
-			MaxSplits:             3,
-			MinAdvanceDays:        30,
-			AllowWeekendStart:     false,
-			AllowSellVacation:     true,
-			MaxSellDays:           10,
-			MinSellDays:           1,
-			AllowAbono:            true,
-			MaxAbonoDays:          3,
-			AbonoRequiresApproval: true,
-			PeriodMonths:          12,
-			AllowCarryOver:        false,
-			MaxCarryOverDays:      10,
-			WelcomeMessage:        "Bem-vindo ao sistema de férias! Aqui você pode solicitar, acompanhar e gerenciar suas férias.",
-			RulesMessage:          "• Mínimo de 5 dias por solicitação\n• Máximo de 3 fracionamentos por período\n• Antecedência mínima de 30 dias\n• Férias devem ser tiradas dentro do período aquisitivo",
-		}
+		settings = services.DefaultVacationSettings()
 		config.DB.Create(&settings)
 	}
 
@@ -1480,3 +1345,20 @@ func UpdateVacationSettings(c *fiber.Ctx) error {
 		"settings": settings,
 	})
 }
+
+// saveVacationWithLedger grava a solicitação e ajusta o extrato de férias na mesma transação;
+// com cancelFlow também encerra o fluxo de aprovação pendente
+func saveVacationWithLedger(vacation *models.Vacation, actorID string, cancelFlow bool) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(vacation).Error; err != nil {
+			return err
+		}
+		if _, err := vacationLedger.SyncVacation(tx, *vacation, actorID); err != nil {
+			return err
+		}
+		if !cancelFlow {
+			return nil
+		}
+		return services.Workflow.Cancel(tx, models.ApprovalSubjectVacation, vacation.ID)
+	})
+}
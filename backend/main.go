@@ -2,12 +2,16 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/encryption"
 	"github.com/frappyou/backend/handlers"
 	"github.com/frappyou/backend/routes"
+	"github.com/frappyou/backend/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -22,13 +26,79 @@ func main() {
 		log.Fatal("Falha ao conectar ao banco de dados:", err)
 	}
 
+	// Rotação de chaves: ./frappyou-api rotate-keys [-all]
+	if len(os.Args) > 1 && os.Args[1] == "rotate-keys" {
+		os.Exit(runKeyRotation(os.Args[2:]))
+	}
+	// Migração dos arquivos para o armazenamento configurado: ./frappyou-api migrate-storage [-source ./uploads] [-dry-run]
+	if len(os.Args) > 1 && os.Args[1] == "migrate-storage" {
+		os.Exit(runStorageMigration(os.Args[2:]))
+	}
+	if err := services.CheckMailConfig(); err != nil {
+		log.Fatal("Falha na configuração de e-mail:", err)
+	}
+	if !encryption.Enabled() {
+		log.Println("⚠️ Criptografia: ENCRYPTION_KEYS não configurada, holerites e documentos serão gravados em claro")
+	}
+
 	// Conecta ao Redis (opcional - continua funcionando sem)
 	config.ConnectRedis()
 	defer config.CloseRedis()
 
+	// Replica eventos em tempo real (subscriptions GraphQL) entre instâncias
+	services.Events.StartRedisBridge(context.Background())
+
+	// Escalação de aprovações com prazo (SLA) vencido
+	services.Workflow.StartEscalationWorker(context.Background(), 5*time.Minute)
+
+	// Publicação dos lotes de holerites agendados
+	services.PayslipPublications.StartScheduler(context.Background(), time.Minute)
+
+	// Verificação dos documentos enviados (quarentena até o antivírus liberar)
+	if services.DocumentPipeline.ScannerName() == "" {
+		log.Println("⚠️ Documentos: CLAMAV_ADDRESS não configurado, documentos verificados sem antivírus")
+	}
+	services.DocumentPipeline.Start(context.Background(), 2, time.Minute)
+
+	// Abertura/encerramento diário dos períodos aquisitivos de férias (as leituras de saldo não gravam)
+	services.NewVacationLedger().StartWorker(context.Background(), 24*time.Hour)
+
+	// Limpeza de sessões expiradas/revogadas (mantidas 30 dias para consulta)
+	services.Sessions.StartCleanupWorker(context.Background(), 6*time.Hour, 30*24*time.Hour)
+
 	// Seed de dados iniciais
 	config.SeedDatabase()
 	handlers.SeedDefaultBadges()
+	services.Payroll.SeedDefaultTables()
+
+	// Organograma inicial a partir de dbo.ColaboradoresFradema (ignora quem já tem lotação)
+	if result, err := services.Org.SeedFromColaboradores(); err != nil {
+		log.Printf("⚠️ Organograma: erro na carga inicial: %v", err)
+	} else if result.Assignments > 0 {
+		log.Printf("🏢 Organograma: %d lotações importadas (%d departamentos, %d cargos)",
+			result.Assignments, result.Departments, result.Positions)
+	}
+
+	// Índice da busca semântica da base de conhecimento (só reprocessa o que mudou)
+	go func() {
+		result, err := services.KnowledgeIndex.Rebuild(context.Background())
+		if err != nil {
+			log.Printf("⚠️ Base de conhecimento: erro ao indexar: %v", err)
+		} else if result.Updated > 0 || result.Removed > 0 || result.Failed > 0 {
+			log.Printf("🔎 Base de conhecimento: %d artigos indexados (%s), %d falhas, %d trechos removidos",
+				result.Updated, services.KnowledgeIndex.EmbeddingModel(), result.Failed, result.Removed)
+		}
+	}()
+
+	// Vínculo dos holerites com a identidade do colaborador (novos e sem colaborador encontrado)
+	go func() {
+		if result, err := services.EmployeeIdentities.Backfill(); err != nil {
+			log.Printf("⚠️ Holerites: erro ao vincular identidades: %v", err)
+		} else if result.Processed > 0 {
+			log.Printf("🪪 Holerites: %d vinculados, %d sem colaborador, %d ambíguos",
+				result.Linked, result.Unmatched, result.Ambiguous)
+		}
+	}()
 
 	// Cria a aplicação Fiber
 	app := fiber.New(fiber.Config{
@@ -41,28 +111,17 @@ func main() {
 	app.Use(logger.New())
 	app.Use(recover.New())
 
-	// CORS - configurável via variável de ambiente
-	allowedOrigins := os.Getenv("ALLOWED_ORIGINS")
-	if allowedOrigins == "" {
-		// Inclui localhost para desenvolvimento e domínios de produção
-		allowedOrigins = "http://localhost:3000, http://127.0.0.1:3000, https://www.frappyou.app, https://frappyou.app"
-	}
+	// CORS - configurável via variável de ambiente (ALLOWED_ORIGINS)
 	app.Use(cors.New(cors.Config{
-		AllowOrigins:     allowedOrigins,
+		AllowOrigins:     config.AllowedOrigins(),
 		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
 		AllowMethods:     "GET, POST, PUT, DELETE, OPTIONS",
 		AllowCredentials: true,
 	}))
 
-	// Servir arquivos estáticos (uploads de imagens)
-	app.Static("/uploads/images", "./uploads/images", fiber.Static{
-		Compress: true,
-	})
-
-	// Servir arquivos de vídeo com suporte a Range Requests (permite seek)
-	app.Static("/uploads/videos", "./uploads/videos", fiber.Static{
-		ByteRange: true, // Habilita suporte a Range Requests para seek em vídeos
-	})
+	// Arquivos enviados (armazenamento local ou S3). Imagens são públicas; vídeos exigem
+	// link assinado. Range Requests habilitados para o seek nos vídeos.
+	app.Get("/uploads/*", handlers.ServeUpload)
 
 	// Rotas
 	routes.SetupRoutes(app)
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/services"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -38,10 +39,19 @@ func AuthMiddleware(c *fiber.Ctx) error {
 		})
 	}
 
+	// Verifica se a sessão não foi encerrada (logout, revogação pelo admin, troca de senha)
+	if !services.Sessions.IsActive(claims.SessionID) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "Sessão encerrada. Faça login novamente",
+		})
+	}
+
 	// Adiciona os dados do usuário ao contexto
 	c.Locals("user_id", claims.UserID)
 	c.Locals("email", claims.Email)
 	c.Locals("role", claims.Role)
+	c.Locals("session_id", claims.SessionID)
 
 	return c.Next()
 }
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/frappyou/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission verifica se o papel do usuário concede a permissão e guarda
+// a abrangência resolvida (own, team, filial ou all) em c.Locals("access")
+func RequirePermission(resource, action string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("user_id").(string)
+		if !ok || userID == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"error":   "Não autorizado",
+			})
+		}
+
+		access, err := services.Authz.Resolve(userID, resource, action)
+		if err != nil {
+			if errors.Is(err, services.ErrAccessDenied) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"success": false,
+					"error":   "Acesso negado. Seu perfil não tem permissão para acessar este recurso.",
+				})
+			}
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"error":   "Usuário não encontrado",
+			})
+		}
+
+		c.Locals("role", access.Role)
+		c.Locals("access", access)
+
+		return c.Next()
+	}
+}
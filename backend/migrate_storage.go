@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/frappyou/backend/services"
+)
+
+// runStorageMigration copia os arquivos do disco local para o armazenamento configurado
+// (STORAGE_BACKEND). Retorna o código de saída do processo.
+func runStorageMigration(args []string) int {
+	flags := flag.NewFlagSet("migrate-storage", flag.ContinueOnError)
+	source := flags.String("source", "./uploads", "diretório local com os arquivos enviados")
+	dryRun := flags.Bool("dry-run", false, "só lista o que seria copiado, sem gravar")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	log.Printf("📦 Armazenamento: migrando %s para o backend %s", *source, services.Storage.Name())
+	result, err := services.StorageMigration.Run(context.Background(), *source, *dryRun)
+	if result != nil {
+		log.Printf("📦 Armazenamento: %d arquivos copiados, %d já existentes, %d documentos atualizados; %d falhas",
+			result.Copied, result.Skipped, result.Documents, result.Failed)
+		for _, problem := range result.Errors {
+			log.Printf("   - %s", problem)
+		}
+	}
+	if err != nil {
+		log.Printf("❌ Armazenamento: migração interrompida: %v", err)
+		return 1
+	}
+	if result.Failed > 0 {
+		return 1
+	}
+	return 0
+}
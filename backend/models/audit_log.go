@@ -80,13 +80,20 @@ const (
 	ActionRoleChange = "ROLE_CHANGE"
 	ActionLogin      = "LOGIN"
 	ActionLogout     = "LOGOUT"
+
+	ActionPasswordResetRequest = "PASSWORD_RESET_REQUEST"
+	ActionPasswordReset        = "PASSWORD_RESET"
+
+	ActionChatActionConfirm = "CHAT_ACTION_CONFIRM"
+	ActionChatActionReject  = "CHAT_ACTION_REJECT"
 )
 
 // Constantes para tipos de entidade
 const (
-	EntityUser     = "USER"
-	EntityProfile  = "PROFILE"
-	EntitySettings = "SETTINGS"
-	EntitySystem   = "SYSTEM"
+	EntityUser       = "USER"
+	EntityProfile    = "PROFILE"
+	EntitySettings   = "SETTINGS"
+	EntitySystem     = "SYSTEM"
+	EntityChatAction = "CHAT_ACTION"
 )
 
@@ -12,10 +12,21 @@ type ChatMessage struct {
 	ID        string    `json:"id" gorm:"type:nvarchar(36);primaryKey"`
 	SessionID string    `json:"session_id" gorm:"type:nvarchar(36);not null;index"`
 	UserID    string    `json:"user_id" gorm:"type:nvarchar(36);not null;index"`
-	Role      string    `json:"role" gorm:"type:nvarchar(20);not null"` // user, assistant, system
+	Role      string    `json:"role" gorm:"type:nvarchar(20);not null"` // user, assistant, system, tool
 	Content   string    `json:"content" gorm:"type:nvarchar(max);not null"`
 	Tokens    int       `json:"tokens" gorm:"default:0"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// Ferramentas (tools) do assistente: pedidos em JSON na mensagem do assistant e, nas
+	// mensagens tool, a chamada respondida e a ferramenta executada
+	ToolCalls  string `json:"-" gorm:"type:nvarchar(max)"`
+	ToolCallID string `json:"-" gorm:"type:nvarchar(100)"`
+	ToolName   string `json:"-" gorm:"type:nvarchar(100)"`
+}
+
+// IsToolExchange pedido ou resultado de ferramenta (não é exibido ao colaborador)
+func (c ChatMessage) IsToolExchange() bool {
+	return c.Role == "tool" || (c.ToolCalls != "" && c.Content == "")
 }
 
 // ChatSession representa uma sessão de conversa
@@ -79,15 +90,18 @@ type ChatResponse struct {
 	Message   string `json:"message"`
 	SessionID string `json:"session_id"`
 	Tokens    int    `json:"tokens,omitempty"`
+
+	Actions []ChatPendingAction `json:"actions,omitempty"` // Ações propostas aguardando confirmação
 }
 
 // ChatSessionResponse resposta com dados da sessão
 type ChatSessionResponse struct {
-	ID        string        `json:"id"`
-	Title     string        `json:"title"`
-	Context   string        `json:"context"`
-	Messages  []ChatMessage `json:"messages"`
-	CreatedAt time.Time     `json:"created_at"`
+	ID        string              `json:"id"`
+	Title     string              `json:"title"`
+	Context   string              `json:"context"`
+	Messages  []ChatMessage       `json:"messages"`
+	Actions   []ChatPendingAction `json:"actions,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
 }
 
 // QuickAction ação rápida sugerida pelo assistente
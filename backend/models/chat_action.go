@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Status de uma ação proposta pelo assistente
+const (
+	ChatActionPending   = "pending"   // Aguardando confirmação do colaborador
+	ChatActionExecuted  = "executed"  // Confirmada e executada com sucesso
+	ChatActionFailed    = "failed"    // Confirmada, mas a execução falhou
+	ChatActionRejected  = "rejected"  // Recusada pelo colaborador
+	ChatActionExpired   = "expired"   // Não confirmada dentro do prazo
+	ChatActionConfirmed = "confirmed" // Confirmada, em execução
+)
+
+// ChatPendingAction ação de escrita proposta pelo assistente (ex.: solicitar férias).
+// Só é executada quando o próprio colaborador confirma pelo cartão no chat; o registro
+// guarda o que a IA propôs, quem confirmou e o resultado.
+type ChatPendingAction struct {
+	ID        string    `json:"id" gorm:"type:nvarchar(36);primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID       string `json:"user_id" gorm:"type:nvarchar(36);not null;index"`
+	SessionID    string `json:"session_id,omitempty" gorm:"type:nvarchar(36);index"`
+	ToolCallID   string `json:"-" gorm:"type:nvarchar(100)"`
+	FunctionName string `json:"function_name" gorm:"type:nvarchar(100);not null;index"`
+	Arguments    string `json:"arguments" gorm:"type:nvarchar(max)"` // JSON proposto pelo modelo
+	Title        string `json:"title" gorm:"type:nvarchar(255)"`
+	Summary      string `json:"summary" gorm:"type:nvarchar(500)"`
+
+	Status    string    `json:"status" gorm:"type:nvarchar(20);not null;default:'pending';index"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+
+	// Decisão do colaborador
+	DecidedAt      *time.Time `json:"decided_at,omitempty"`
+	DecidedIP      string     `json:"-" gorm:"type:nvarchar(64)"`
+	IdempotencyKey string     `json:"-" gorm:"type:nvarchar(100)"` // Chave da confirmação; repetir devolve o mesmo resultado
+	Result         string     `json:"result,omitempty" gorm:"type:nvarchar(max)"`
+}
+
+// TableName nome da tabela
+func (ChatPendingAction) TableName() string {
+	return "chat_pending_actions"
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (a *ChatPendingAction) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Confirmable indica se a ação ainda pode ser confirmada na data informada
+func (a *ChatPendingAction) Confirmable(at time.Time) bool {
+	return a.Status == ChatActionPending && at.Before(a.ExpiresAt)
+}
@@ -11,9 +11,22 @@ import (
 type DocumentStatus string
 
 const (
-	DocumentStatusPending  DocumentStatus = "pending"  // Aguardando aprovação
-	DocumentStatusApproved DocumentStatus = "approved" // Aprovado
-	DocumentStatusRejected DocumentStatus = "rejected" // Rejeitado
+	DocumentStatusQuarantined DocumentStatus = "quarantined" // Em verificação (antivírus/conteúdo); download bloqueado
+	DocumentStatusPending     DocumentStatus = "pending"     // Aguardando aprovação
+	DocumentStatusApproved    DocumentStatus = "approved"    // Aprovado
+	DocumentStatusRejected    DocumentStatus = "rejected"    // Rejeitado
+)
+
+// DocumentScanStatus situação da verificação do arquivo enviado
+type DocumentScanStatus string
+
+const (
+	DocumentScanPending  DocumentScanStatus = "pending"  // Na fila
+	DocumentScanRunning  DocumentScanStatus = "running"  // Em processamento
+	DocumentScanClean    DocumentScanStatus = "clean"    // Liberado
+	DocumentScanInfected DocumentScanStatus = "infected" // Antivírus encontrou ameaça
+	DocumentScanInvalid  DocumentScanStatus = "invalid"  // Conteúdo não confere com o tipo ou arquivo corrompido
+	DocumentScanFailed   DocumentScanStatus = "failed"   // Erro ao verificar (nova tentativa automática)
 )
 
 // Document representa um documento do sistema
@@ -31,6 +44,23 @@ type Document struct {
 	Description  string  `gorm:"type:nvarchar(500)" json:"description,omitempty"`
 	IsPublic     bool    `gorm:"default:false" json:"is_public"`
 
+	// Arquivo cifrado em disco: chave mestra e DEK cifrada (vazios = arquivo em claro, legado)
+	EncKeyID   string `gorm:"type:nvarchar(50);index" json:"-"`
+	EncDataKey string `gorm:"type:nvarchar(200)" json:"-"`
+
+	// Verificação do arquivo (ver services.DocumentPipeline)
+	ScanStatus   DocumentScanStatus `gorm:"type:nvarchar(20);index" json:"scan_status,omitempty"`
+	ScanResult   string             `gorm:"type:nvarchar(500)" json:"scan_result,omitempty"` // Ameaça encontrada ou motivo da recusa
+	ScanAttempts int                `gorm:"default:0" json:"-"`
+	ScanStarted  *time.Time         `json:"-"`
+	ScannedAt    *time.Time         `json:"scanned_at,omitempty"`
+
+	// Miniatura (imagens), cifrada como o arquivo
+	ThumbnailPath    string `gorm:"type:nvarchar(500)" json:"-"`
+	ThumbnailKeyID   string `gorm:"type:nvarchar(50)" json:"-"`
+	ThumbnailDataKey string `gorm:"type:nvarchar(200)" json:"-"`
+	HasThumbnail     bool   `gorm:"-" json:"has_thumbnail"`
+
 	// Campos de aprovação
 	Status       DocumentStatus `gorm:"type:nvarchar(20);default:'pending'" json:"status"`
 	ReviewedBy   *string        `gorm:"type:nvarchar(36)" json:"reviewed_by,omitempty"`
@@ -55,6 +85,12 @@ func (d *Document) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// AfterFind indica se há miniatura sem expor o caminho
+func (d *Document) AfterFind(tx *gorm.DB) error {
+	d.HasThumbnail = d.ThumbnailPath != ""
+	return nil
+}
+
 // DocumentUploadResponse representa a resposta de upload
 type DocumentUploadResponse struct {
 	Success  bool     `json:"success"`
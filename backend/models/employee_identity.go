@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmployeeIdentity chave canônica do colaborador: liga o usuário do portal, o
+// cadastro de dbo.ColaboradoresFradema e os holerites por um único ID estável
+type EmployeeIdentity struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	CPF           string `gorm:"type:nvarchar(11);not null;uniqueIndex" json:"cpf"` // Somente dígitos
+	Name          string `gorm:"type:nvarchar(255)" json:"name"`
+	UserID        string `gorm:"type:nvarchar(36);index" json:"user_id,omitempty"` // Conta no portal (vazio = ainda não ativada)
+	ColaboradorID int    `gorm:"index" json:"colaborador_id,omitempty"`            // dbo.ColaboradoresFradema.Id
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (e *EmployeeIdentity) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Situação do vínculo do holerite com a identidade do colaborador
+const (
+	PayslipLinkLinked    = "linked"    // Vinculado automaticamente
+	PayslipLinkManual    = "manual"    // Vinculado pelo admin
+	PayslipLinkUnmatched = "unmatched" // Nenhum colaborador encontrado
+	PayslipLinkAmbiguous = "ambiguous" // CPF, usuário e colaborador apontam para pessoas diferentes
+)
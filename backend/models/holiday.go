@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// HolidayScope representa a abrangência do feriado
+type HolidayScope string
+
+const (
+	HolidayScopeNacional  HolidayScope = "nacional"
+	HolidayScopeEstadual  HolidayScope = "estadual"
+	HolidayScopeMunicipal HolidayScope = "municipal"
+)
+
+// Holiday representa um feriado nacional, estadual ou municipal
+type Holiday struct {
+	ID        string         `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Name      string       `gorm:"type:nvarchar(255);not null" json:"name"`
+	Date      time.Time    `gorm:"type:date;not null;index" json:"date"`
+	Recurring bool         `gorm:"default:true" json:"recurring"` // Repete todo ano no mesmo dia/mês
+	Scope     HolidayScope `gorm:"type:nvarchar(20);not null;index" json:"scope"`
+	State     string       `gorm:"type:nvarchar(2);index" json:"state,omitempty"` // UF (estadual/municipal)
+	City      string       `gorm:"type:nvarchar(100)" json:"city,omitempty"`      // Município (municipal)
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (h *Holiday) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == "" {
+		h.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// FilialLocation associa uma filial à UF e ao município, para resolver
+// os feriados estaduais e municipais dos colaboradores lotados nela
+type FilialLocation struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Filial string `gorm:"type:nvarchar(255);uniqueIndex;not null" json:"filial"` // Nome da filial (dbo.Filiais)
+	State  string `gorm:"type:nvarchar(2);not null" json:"state"`
+	City   string `gorm:"type:nvarchar(100);not null" json:"city"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (fl *FilialLocation) BeforeCreate(tx *gorm.DB) error {
+	if fl.ID == "" {
+		fl.ID = uuid.New().String()
+	}
+	return nil
+}
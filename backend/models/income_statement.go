@@ -0,0 +1,127 @@
+package models
+
+import (
+	"time"
+
+	"github.com/frappyou/backend/encryption"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IncomeStatement Comprovante de Rendimentos Pagos e de Imposto sobre a Renda
+// Retido na Fonte de um colaborador (por CPF) em um ano-calendário
+type IncomeStatement struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Year         int    `gorm:"not null;index:idx_income_statement_year_cpf_hash" json:"year"`       // Ano-calendário
+	CPF          string `gorm:"type:nvarchar(11)" json:"cpf"`                                        // Vazio no banco com a criptografia ativa
+	CPFHash      string `gorm:"type:nvarchar(64);index:idx_income_statement_year_cpf_hash" json:"-"` // Índice cego do CPF
+	UserID       string `gorm:"type:nvarchar(36);index" json:"user_id,omitempty"`
+	EmployeeName string `gorm:"type:nvarchar(255)" json:"employee_name"`
+
+	// Totais para listagem (o comprovante completo fica em Data)
+	TaxableIncome float64 `gorm:"type:decimal(14,2)" json:"taxable_income"`
+	WithheldTax   float64 `gorm:"type:decimal(14,2)" json:"withheld_tax"`
+	Thirteenth    float64 `gorm:"type:decimal(14,2)" json:"thirteenth"`
+	PayslipCount  int     `json:"payslip_count"`
+
+	Data        string    `gorm:"type:nvarchar(max)" json:"-"` // JSON do comprovante
+	BatchID     string    `gorm:"type:nvarchar(36);index" json:"batch_id,omitempty"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	// CPF, totais e comprovante cifrados (ver incomeStatementSecrets)
+	Sealed
+}
+
+// incomeStatementSecrets valores do comprovante gravados apenas no envelope cifrado
+type incomeStatementSecrets struct {
+	CPF           string  `json:"cpf"`
+	TaxableIncome float64 `json:"taxable_income"`
+	WithheldTax   float64 `json:"withheld_tax"`
+	Thirteenth    float64 `json:"thirteenth"`
+	Data          string  `json:"data"`
+}
+
+// IncomeStatementSealedColumns colunas regravadas ao cifrar (ou recifrar) um comprovante
+var IncomeStatementSealedColumns = append([]string{"cpf", "cpf_hash", "taxable_income", "withheld_tax", "thirteenth", "data"}, SealedColumns...)
+
+// BeforeSave atualiza o índice do CPF e, com a criptografia ativa, move CPF, valores e
+// comprovante para o envelope
+func (s *IncomeStatement) BeforeSave(tx *gorm.DB) error {
+	if !sealTarget(tx) {
+		return nil
+	}
+	s.CPFHash = encryption.BlindIndex(s.CPF)
+	if !encryption.Enabled() {
+		return nil
+	}
+	secrets := incomeStatementSecrets{CPF: s.CPF, TaxableIncome: s.TaxableIncome, WithheldTax: s.WithheldTax, Thirteenth: s.Thirteenth, Data: s.Data}
+	if err := s.Sealed.seal(secrets); err != nil {
+		return err
+	}
+	s.CPF, s.TaxableIncome, s.WithheldTax, s.Thirteenth, s.Data = "", 0, 0, 0, ""
+	return nil
+}
+
+// AfterSave devolve ao struct os valores zerados no BeforeSave
+func (s *IncomeStatement) AfterSave(tx *gorm.DB) error {
+	if !sealTarget(tx) {
+		return nil
+	}
+	return s.AfterFind(tx)
+}
+
+// AfterFind decifra CPF, valores e comprovante
+func (s *IncomeStatement) AfterFind(tx *gorm.DB) error {
+	var secrets incomeStatementSecrets
+	if ok, err := s.Sealed.open(&secrets); !ok || err != nil {
+		return err
+	}
+	s.CPF = secrets.CPF
+	s.TaxableIncome, s.WithheldTax, s.Thirteenth, s.Data = secrets.TaxableIncome, secrets.WithheldTax, secrets.Thirteenth, secrets.Data
+	return nil
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (s *IncomeStatement) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// IncomeStatementBatchStatus situação da geração em lote
+type IncomeStatementBatchStatus string
+
+const (
+	IncomeStatementBatchRunning   IncomeStatementBatchStatus = "running"
+	IncomeStatementBatchCompleted IncomeStatementBatchStatus = "completed"
+	IncomeStatementBatchFailed    IncomeStatementBatchStatus = "failed"
+)
+
+// IncomeStatementBatch geração dos comprovantes de todos os colaboradores de um ano
+type IncomeStatementBatch struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Year      int                        `gorm:"not null;index" json:"year"`
+	Status    IncomeStatementBatchStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Total     int                        `json:"total"`     // CPFs encontrados nos holerites do ano
+	Generated int                        `json:"generated"` // Comprovantes gravados
+	Failed    int                        `json:"failed"`
+	Errors    string                     `gorm:"type:nvarchar(max)" json:"errors,omitempty"` // Uma ocorrência por linha
+
+	StartedBy  string     `gorm:"type:nvarchar(36)" json:"started_by"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (b *IncomeStatementBatch) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return nil
+}
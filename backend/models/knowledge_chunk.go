@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// KnowledgeChunk trecho de um artigo da base de conhecimento no índice de busca
+// (termos para o BM25 e embedding para a similaridade semântica)
+type KnowledgeChunk struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ArticleID string `gorm:"type:nvarchar(36);not null;index" json:"article_id"`
+	Position  int    `gorm:"not null" json:"position"`          // Ordem do trecho no artigo
+	Heading   string `gorm:"type:nvarchar(255)" json:"heading"` // Seção (título Markdown) do trecho
+	Content   string `gorm:"type:nvarchar(max);not null" json:"content"`
+
+	// Termos normalizados (sem acentos e stopwords) separados por espaço
+	Terms     string `gorm:"type:nvarchar(max)" json:"-"`
+	TermCount int    `json:"-"`
+
+	// Embedding em float32 little-endian. ContentHash identifica o texto embutido,
+	// permitindo reaproveitar o vetor quando o trecho não muda entre versões.
+	ContentHash    string `gorm:"type:nvarchar(64);index" json:"-"`
+	Embedding      []byte `gorm:"type:varbinary(max)" json:"-"`
+	EmbeddingModel string `gorm:"type:nvarchar(100)" json:"embedding_model"`
+}
+
+// BeforeCreate gera UUID
+func (kc *KnowledgeChunk) BeforeCreate(tx *gorm.DB) error {
+	if kc.ID == "" {
+		kc.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName define o nome da tabela
+func (KnowledgeChunk) TableName() string {
+	return "knowledge_chunks"
+}
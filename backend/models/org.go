@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrgDepartment departamento/área do organograma
+type OrgDepartment struct {
+	ID        string         `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Name       string  `gorm:"type:nvarchar(150);not null;uniqueIndex" json:"name"`
+	Filial     string  `gorm:"type:nvarchar(100)" json:"filial,omitempty"`
+	ParentID   *string `gorm:"type:nvarchar(36);index" json:"parent_id,omitempty"`
+	HeadUserID *string `gorm:"type:nvarchar(36)" json:"head_user_id,omitempty"` // Gestor responsável pela área
+	HeadUser   *User   `gorm:"foreignKey:HeadUserID" json:"head_user,omitempty"`
+	Active     bool    `gorm:"default:true" json:"active"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (d *OrgDepartment) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// OrgPosition cargo do organograma
+type OrgPosition struct {
+	ID        string         `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Title        string `gorm:"type:nvarchar(150);not null;uniqueIndex" json:"title"`
+	Level        int    `gorm:"default:0" json:"level"`             // 0 = operacional ... 4 = diretoria
+	IsManagerial bool   `gorm:"default:false" json:"is_managerial"` // Cargo de gestão (pode ter subordinados)
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (p *OrgPosition) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// OrgAssignment lotação do colaborador (departamento, cargo e gestor) com vigência.
+// Mudanças criam um novo registro e encerram o anterior, preservando o histórico.
+type OrgAssignment struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID        string `gorm:"type:nvarchar(36);not null;index" json:"user_id"`
+	User          *User  `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	ColaboradorID *int   `json:"colaborador_id,omitempty"` // dbo.ColaboradoresFradema.Id
+
+	DepartmentID *string        `gorm:"type:nvarchar(36);index" json:"department_id,omitempty"`
+	Department   *OrgDepartment `gorm:"foreignKey:DepartmentID" json:"department,omitempty"`
+	PositionID   *string        `gorm:"type:nvarchar(36)" json:"position_id,omitempty"`
+	Position     *OrgPosition   `gorm:"foreignKey:PositionID" json:"position,omitempty"`
+	ManagerID    *string        `gorm:"type:nvarchar(36);index" json:"manager_id,omitempty"` // Gestor direto (usuário)
+	Manager      *User          `gorm:"foreignKey:ManagerID" json:"manager,omitempty"`
+
+	EffectiveFrom time.Time  `gorm:"type:date;not null;index" json:"effective_from"`
+	EffectiveTo   *time.Time `gorm:"type:date" json:"effective_to,omitempty"` // Exclusivo; nulo = vigente
+
+	Reason    string  `gorm:"type:nvarchar(255)" json:"reason,omitempty"`
+	CreatedBy *string `gorm:"type:nvarchar(36)" json:"created_by,omitempty"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (a *OrgAssignment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
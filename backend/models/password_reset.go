@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetToken token de uso único para redefinição de senha (esqueci minha senha).
+// Apenas o hash SHA-256 do token é armazenado.
+type PasswordResetToken struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID    string     `gorm:"type:nvarchar(36);not null;index" json:"user_id"`
+	User      *User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	TokenHash string     `gorm:"type:nvarchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RequestIP string     `gorm:"type:nvarchar(64)" json:"request_ip,omitempty"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (t *PasswordResetToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Usable indica se o token ainda pode ser usado na data informada
+func (t *PasswordResetToken) Usable(at time.Time) bool {
+	return t.UsedAt == nil && at.Before(t.ExpiresAt)
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PayrollTaxKind tipo de tabela usada no cálculo da folha
+type PayrollTaxKind string
+
+const (
+	PayrollTaxINSS PayrollTaxKind = "inss" // Contribuição previdenciária progressiva
+	PayrollTaxIRRF PayrollTaxKind = "irrf" // Imposto de renda retido na fonte
+	PayrollTaxFGTS PayrollTaxKind = "fgts" // Depósito do FGTS (alíquota única)
+)
+
+// PayrollTaxTable tabela de incidência com vigência. O cálculo usa a tabela de
+// maior ValidFrom que não seja posterior à competência do holerite.
+type PayrollTaxTable struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Kind        PayrollTaxKind `gorm:"type:varchar(10);not null;uniqueIndex:idx_payroll_tax_validity" json:"kind"`
+	ValidFrom   time.Time      `gorm:"type:date;not null;uniqueIndex:idx_payroll_tax_validity" json:"valid_from"`
+	Description string         `gorm:"type:nvarchar(255)" json:"description"`
+
+	// JSON: [{"up_to": 1518.00, "rate": 7.5, "deduction": 0}]. up_to 0 = sem limite.
+	// INSS: faixas progressivas (a última define o teto). IRRF: alíquota e parcela a deduzir.
+	// FGTS: uma faixa com a alíquota.
+	Brackets string `gorm:"type:nvarchar(max);not null" json:"brackets"`
+
+	DependentDeduction float64 `gorm:"type:decimal(12,2)" json:"dependent_deduction"` // IRRF: dedução por dependente
+	SimplifiedDiscount float64 `gorm:"type:decimal(12,2)" json:"simplified_discount"` // IRRF: desconto simplificado mensal
+
+	// IRRF: redução mensal do imposto (Lei nº 15.270/2025), calculada sobre os rendimentos
+	// tributáveis do mês. Até ReductionFullUpTo reduz até ReductionMax; até ReductionUpTo
+	// reduz ReductionConstant - rendimentos × ReductionRate%; acima disso não há redução.
+	// ReductionUpTo 0 = tabela sem redução.
+	ReductionFullUpTo float64 `gorm:"type:decimal(12,2)" json:"reduction_full_up_to"`
+	ReductionMax      float64 `gorm:"type:decimal(12,2)" json:"reduction_max"`
+	ReductionUpTo     float64 `gorm:"type:decimal(12,2)" json:"reduction_up_to"`
+	ReductionConstant float64 `gorm:"type:decimal(12,2)" json:"reduction_constant"`
+	ReductionRate     float64 `gorm:"type:decimal(9,6)" json:"reduction_rate"`
+
+	CreatedBy string `gorm:"type:nvarchar(36)" json:"created_by"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (t *PayrollTaxTable) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
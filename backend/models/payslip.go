@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/frappyou/backend/encryption"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -17,6 +18,7 @@ type PayslipItem struct {
 	Reference   float64 `gorm:"type:decimal(10,2)" json:"reference"` // Quantidade/Referência (horas, dias, %)
 	Amount      float64 `gorm:"type:decimal(12,2);not null" json:"amount"`
 	CreatedAt   time.Time `json:"created_at"`
+	Sealed
 }
 
 // BeforeCreate gera UUID antes de criar
@@ -32,10 +34,16 @@ type Payslip struct {
 	ID              string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
 	UserID          string    `gorm:"type:nvarchar(36);index" json:"user_id"`
 	ColaboradorID   int       `gorm:"index" json:"colaborador_id"`
-	
+
+	// Vínculo com a identidade do colaborador (única referência usada na autorização)
+	EmployeeID      string    `gorm:"type:nvarchar(36);index" json:"employee_id,omitempty"`
+	LinkStatus      string    `gorm:"type:nvarchar(20);index" json:"link_status"` // linked, manual, unmatched, ambiguous (vazio = não processado)
+	LinkNote        string    `gorm:"type:nvarchar(500)" json:"link_note,omitempty"`
+
 	// Dados do colaborador no momento do holerite
 	EmployeeName    string    `gorm:"type:nvarchar(255)" json:"employee_name"`
 	EmployeeCPF     string    `gorm:"type:nvarchar(20)" json:"employee_cpf"`
+	EmployeeCPFHash string    `gorm:"type:nvarchar(64);index" json:"-"` // Índice cego do CPF (a coluna em claro fica vazia com a criptografia ativa)
 	Position        string    `gorm:"type:nvarchar(255)" json:"position"`
 	Department      string    `gorm:"type:nvarchar(255)" json:"department"`
 	Branch          string    `gorm:"type:nvarchar(255)" json:"branch"` // Filial
@@ -65,15 +73,44 @@ type Payslip struct {
 	BaseSalary      float64   `gorm:"type:decimal(12,2)" json:"base_salary"`
 	
 	// Status
-	Status          string    `gorm:"type:nvarchar(20);default:'disponivel'" json:"status"` // disponivel, bloqueado
+	Status          string    `gorm:"type:nvarchar(20);default:'disponivel'" json:"status"` // rascunho, revisado, agendado, publicado, ciente, bloqueado (disponivel = legado, já publicado)
+	
+	// Publicação e ciência do colaborador
+	PublicationID   string     `gorm:"type:nvarchar(36);index" json:"publication_id,omitempty"` // Lote de publicação (agendado/publicado)
+	ReviewedBy      string     `gorm:"type:nvarchar(36)" json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewed_at"`
+	PublishedAt     *time.Time `json:"published_at"`
+	AcknowledgedAt  *time.Time `json:"acknowledged_at"`
 	
 	// Itens do holerite
 	Items           []PayslipItem `gorm:"foreignKey:PayslipID" json:"items"`
 	
+	// CPF e valores cifrados (ver payslipSecrets)
+	Sealed
+	
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
 
+// Ciclo de publicação do holerite: rascunho → revisado → agendado → publicado → ciente
+const (
+	PayslipStatusDraft        = "rascunho"
+	PayslipStatusReviewed     = "revisado"
+	PayslipStatusScheduled    = "agendado"
+	PayslipStatusPublished    = "publicado"
+	PayslipStatusAcknowledged = "ciente"
+	PayslipStatusBlocked      = "bloqueado"
+	PayslipStatusLegacy       = "disponivel" // Holerites anteriores ao ciclo de publicação (tratados como publicados)
+)
+
+// PayslipPublishedStatuses status visíveis para o colaborador. Qualquer outro status
+// (inclusive bloqueado ou desconhecido) fica oculto.
+var PayslipPublishedStatuses = []string{PayslipStatusPublished, PayslipStatusAcknowledged, PayslipStatusLegacy}
+
+// PayslipUnpublishedStatuses status do ciclo anteriores à publicação; só estes podem ser
+// substituídos por uma nova importação
+var PayslipUnpublishedStatuses = []string{PayslipStatusDraft, PayslipStatusReviewed, PayslipStatusScheduled}
+
 // BeforeCreate gera UUID antes de criar
 func (p *Payslip) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == "" {
@@ -103,4 +140,140 @@ type PayslipSummary struct {
 	NetTotal       float64   `json:"net_total"`
 	PaymentDate    *time.Time `json:"payment_date"`
 	Status         string    `json:"status"`
+	PublishedAt    *time.Time `json:"published_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	Sealed
+}
+
+// PayslipSummaryColumns colunas lidas na listagem de holerites
+var PayslipSummaryColumns = append([]string{"id", "reference_month", "reference_year", "payslip_type",
+	"gross_total", "deduction_total", "net_total", "payment_date", "status", "published_at", "acknowledged_at"}, SealedColumns...)
+
+// AfterFind decifra os totais do holerite
+func (s *PayslipSummary) AfterFind(tx *gorm.DB) error {
+	var secrets payslipSecrets
+	if ok, err := s.Sealed.open(&secrets); !ok || err != nil {
+		return err
+	}
+	s.GrossTotal, s.DeductionTotal, s.NetTotal = secrets.GrossTotal, secrets.DeductionTotal, secrets.NetTotal
+	return nil
+}
+
+// payslipSecrets campos do holerite gravados apenas no envelope cifrado
+type payslipSecrets struct {
+	EmployeeCPF    string  `json:"cpf"`
+	BaseSalary     float64 `json:"base_salary"`
+	GrossTotal     float64 `json:"gross_total"`
+	DeductionTotal float64 `json:"deduction_total"`
+	NetTotal       float64 `json:"net_total"`
+	INSSBase       float64 `json:"inss_base"`
+	IRRFBase       float64 `json:"irrf_base"`
+	FGTSBase       float64 `json:"fgts_base"`
+	FGTSAmount     float64 `json:"fgts_amount"`
+}
+
+// PayslipSealedColumns colunas regravadas ao cifrar (ou recifrar) um holerite
+var PayslipSealedColumns = append([]string{"employee_cpf", "employee_cpf_hash", "base_salary", "gross_total",
+	"deduction_total", "net_total", "inss_base", "irrf_base", "fgts_base", "fgts_amount"}, SealedColumns...)
+
+func (p *Payslip) secrets() payslipSecrets {
+	return payslipSecrets{
+		EmployeeCPF:    p.EmployeeCPF,
+		BaseSalary:     p.BaseSalary,
+		GrossTotal:     p.GrossTotal,
+		DeductionTotal: p.DeductionTotal,
+		NetTotal:       p.NetTotal,
+		INSSBase:       p.INSSBase,
+		IRRFBase:       p.IRRFBase,
+		FGTSBase:       p.FGTSBase,
+		FGTSAmount:     p.FGTSAmount,
+	}
+}
+
+func (p *Payslip) setSecrets(s payslipSecrets) {
+	p.EmployeeCPF = s.EmployeeCPF
+	p.BaseSalary = s.BaseSalary
+	p.GrossTotal, p.DeductionTotal, p.NetTotal = s.GrossTotal, s.DeductionTotal, s.NetTotal
+	p.INSSBase, p.IRRFBase, p.FGTSBase, p.FGTSAmount = s.INSSBase, s.IRRFBase, s.FGTSBase, s.FGTSAmount
+}
+
+// BeforeSave atualiza o índice do CPF e, com a criptografia ativa, move CPF e valores para o envelope
+func (p *Payslip) BeforeSave(tx *gorm.DB) error {
+	if !sealTarget(tx) {
+		return nil
+	}
+	p.EmployeeCPFHash = encryption.BlindIndex(p.EmployeeCPF)
+	if !encryption.Enabled() {
+		return nil
+	}
+	if err := p.Sealed.seal(p.secrets()); err != nil {
+		return err
+	}
+	p.setSecrets(payslipSecrets{})
+	return nil
+}
+
+// AfterSave devolve ao struct os valores zerados no BeforeSave
+func (p *Payslip) AfterSave(tx *gorm.DB) error {
+	if !sealTarget(tx) {
+		return nil
+	}
+	return p.AfterFind(tx)
+}
+
+// AfterFind decifra CPF e valores do holerite
+func (p *Payslip) AfterFind(tx *gorm.DB) error {
+	var secrets payslipSecrets
+	if ok, err := p.Sealed.open(&secrets); !ok || err != nil {
+		return err
+	}
+	p.setSecrets(secrets)
+	return nil
+}
+
+// OpenPayslipCPF CPF de um holerite lido sem o modelo (Scan em structs de relatório)
+func OpenPayslipCPF(sealed Sealed, clear string) (string, error) {
+	var secrets payslipSecrets
+	if ok, err := sealed.open(&secrets); !ok || err != nil {
+		return clear, err
+	}
+	return secrets.EmployeeCPF, nil
+}
+
+// payslipItemSecrets valor do item gravado apenas no envelope cifrado
+type payslipItemSecrets struct {
+	Amount float64 `json:"amount"`
+}
+
+// PayslipItemSealedColumns colunas regravadas ao cifrar (ou recifrar) um item
+var PayslipItemSealedColumns = append([]string{"amount"}, SealedColumns...)
+
+// BeforeSave move o valor do item para o envelope com a criptografia ativa
+func (pi *PayslipItem) BeforeSave(tx *gorm.DB) error {
+	if !sealTarget(tx) || !encryption.Enabled() {
+		return nil
+	}
+	if err := pi.Sealed.seal(payslipItemSecrets{Amount: pi.Amount}); err != nil {
+		return err
+	}
+	pi.Amount = 0
+	return nil
+}
+
+// AfterSave devolve ao struct o valor zerado no BeforeSave
+func (pi *PayslipItem) AfterSave(tx *gorm.DB) error {
+	if !sealTarget(tx) {
+		return nil
+	}
+	return pi.AfterFind(tx)
+}
+
+// AfterFind decifra o valor do item
+func (pi *PayslipItem) AfterFind(tx *gorm.DB) error {
+	var secrets payslipItemSecrets
+	if ok, err := pi.Sealed.open(&secrets); !ok || err != nil {
+		return err
+	}
+	pi.Amount = secrets.Amount
+	return nil
 }
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PayslipImportFormat formato do arquivo de importação de holerites
+type PayslipImportFormat string
+
+const (
+	PayslipImportCSV   PayslipImportFormat = "csv"
+	PayslipImportXLSX  PayslipImportFormat = "xlsx"
+	PayslipImportFixed PayslipImportFormat = "fixed" // TXT de largura fixa exportado pelo sistema de folha
+)
+
+// PayslipImportTemplate mapeamento salvo entre as colunas do arquivo e os campos do holerite
+type PayslipImportTemplate struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name   string              `gorm:"type:nvarchar(100);not null;uniqueIndex" json:"name"`
+	Format PayslipImportFormat `gorm:"type:varchar(10);not null" json:"format"`
+
+	Delimiter       string `gorm:"type:nvarchar(5)" json:"delimiter"`          // CSV (vazio = detecta ; ou ,)
+	HeaderRow       int    `gorm:"default:1" json:"header_row"`                // Linha do cabeçalho (0 = sem cabeçalho, colunas por número)
+	DecimalComma    bool   `gorm:"default:true" json:"decimal_comma"`          // Valores no formato 1.234,56
+	ImpliedDecimals int    `gorm:"default:0" json:"implied_decimals"`          // TXT: casas decimais implícitas (000000123456 = 1234,56)
+	DateFormat      string `gorm:"type:nvarchar(20)" json:"date_format"`       // Layout Go (padrão 02/01/2006)
+	Columns         string `gorm:"type:nvarchar(max)" json:"columns"`          // JSON: campo -> coluna (nome do cabeçalho ou número)
+	Layout          string `gorm:"type:nvarchar(max)" json:"layout,omitempty"` // JSON: registros e posições do TXT
+	RubricaTypes    string `gorm:"type:nvarchar(max)" json:"rubrica_types"`    // JSON: código da rubrica -> earning/deduction
+	CreatedBy       string `gorm:"type:nvarchar(36)" json:"created_by"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (t *PayslipImportTemplate) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// PayslipImportStatus situação de um lote de importação
+type PayslipImportStatus string
+
+const (
+	PayslipImportValidated PayslipImportStatus = "validated" // Simulação concluída, aguardando confirmação
+	PayslipImportCommitted PayslipImportStatus = "committed"
+	PayslipImportDiscarded PayslipImportStatus = "discarded"
+)
+
+// PayslipImportBatch lote importado de um arquivo: guarda o resultado da simulação
+// (dry-run) até a confirmação, que grava tudo em uma única transação
+type PayslipImportBatch struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	FileName        string              `gorm:"type:nvarchar(255)" json:"file_name"`
+	Format          PayslipImportFormat `gorm:"type:varchar(10);not null" json:"format"`
+	TemplateID      string              `gorm:"type:nvarchar(36)" json:"template_id,omitempty"`
+	ReplaceExisting bool                `json:"replace_existing"` // Substitui holerites já existentes da mesma competência/tipo
+	Status          PayslipImportStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+
+	PayslipCount int    `json:"payslip_count"`
+	ErrorCount   int    `json:"error_count"`
+	WarningCount int    `json:"warning_count"`
+	Report       string `gorm:"type:nvarchar(max)" json:"-"` // JSON do relatório de validação
+	Payload      string `gorm:"type:nvarchar(max)" json:"-"` // JSON dos holerites interpretados
+
+	CreatedBy   string     `gorm:"type:nvarchar(36)" json:"created_by"`
+	CommittedBy string     `gorm:"type:nvarchar(36)" json:"committed_by,omitempty"`
+	CommittedAt *time.Time `json:"committed_at,omitempty"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (b *PayslipImportBatch) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PayslipIssuance registro de cada PDF de holerite emitido.
+// Guarda o hash do arquivo entregue e a assinatura (HMAC) do servidor para que
+// terceiros possam confirmar a autenticidade pelo código de verificação.
+type PayslipIssuance struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	PayslipID        string    `gorm:"type:nvarchar(36);not null;index" json:"payslip_id"`
+	IssuedTo         string    `gorm:"type:nvarchar(36);index" json:"issued_to"` // Usuário que baixou o PDF
+	VerificationCode string    `gorm:"type:nvarchar(32);not null;uniqueIndex" json:"verification_code"`
+	DocumentHash     string    `gorm:"type:nvarchar(64);not null;index" json:"document_hash"` // SHA-256 do PDF
+	DataHash         string    `gorm:"type:nvarchar(64);not null" json:"-"`                   // SHA-256 dos dados do holerite na emissão
+	Signature        string    `gorm:"type:nvarchar(64);not null" json:"-"`
+	IssuedAt         time.Time `gorm:"not null" json:"issued_at"`
+	RequestIP        string    `gorm:"type:nvarchar(64)" json:"-"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (i *PayslipIssuance) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PayslipPublicationStatus situação de um lote de publicação de holerites
+type PayslipPublicationStatus string
+
+const (
+	PayslipPublicationScheduled PayslipPublicationStatus = "scheduled"
+	PayslipPublicationPublished PayslipPublicationStatus = "published"
+	PayslipPublicationCanceled  PayslipPublicationStatus = "canceled"
+)
+
+// PayslipPublication publicação em lote dos holerites revisados de uma competência
+// (opcionalmente de uma filial e/ou tipo) em uma data agendada
+type PayslipPublication struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ReferenceMonth int    `gorm:"not null;index:idx_payslip_publication_period" json:"reference_month"`
+	ReferenceYear  int    `gorm:"not null;index:idx_payslip_publication_period" json:"reference_year"`
+	Branch         string `gorm:"type:nvarchar(255)" json:"branch"`      // Filial (vazio = todas)
+	PayslipType    string `gorm:"type:nvarchar(50)" json:"payslip_type"` // Tipo (vazio = todos)
+
+	ScheduledFor time.Time                `gorm:"not null;index" json:"scheduled_for"`
+	Status       PayslipPublicationStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+
+	PayslipCount  int        `json:"payslip_count"`
+	NotifiedCount int        `json:"notified_count"` // Colaboradores notificados (com conta no portal)
+	PublishedAt   *time.Time `json:"published_at"`
+	CreatedBy     string     `gorm:"type:nvarchar(36)" json:"created_by"`
+	CanceledBy    string     `gorm:"type:nvarchar(36)" json:"canceled_by,omitempty"`
+	CanceledAt    *time.Time `json:"canceled_at,omitempty"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (p *PayslipPublication) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// PayslipAcknowledgement registro de ciência do colaborador ("confirmo o recebimento"),
+// mantido como evidência para fins trabalhistas
+type PayslipAcknowledgement struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	PayslipID      string    `gorm:"type:nvarchar(36);not null;uniqueIndex" json:"payslip_id"`
+	UserID         string    `gorm:"type:nvarchar(36);not null;index" json:"user_id"`
+	AcknowledgedAt time.Time `gorm:"not null" json:"acknowledged_at"`
+	IPAddress      string    `gorm:"type:nvarchar(64)" json:"ip_address"`
+	UserAgent      string    `gorm:"type:nvarchar(500)" json:"user_agent"`
+	DataHash       string    `gorm:"type:nvarchar(64);not null" json:"data_hash"` // SHA-256 dos dados do holerite no momento da ciência
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (a *PayslipAcknowledgement) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"github.com/frappyou/backend/encryption"
+	"gorm.io/gorm"
+)
+
+// Sealed campos do envelope com os dados sensíveis do registro (ver pacote encryption).
+// Com a criptografia ativa, as colunas sensíveis ficam zeradas no banco e os valores
+// são decifrados nos hooks AfterFind do modelo.
+type Sealed struct {
+	EncKeyID   string `gorm:"type:nvarchar(50);index" json:"-"` // Chave mestra que protege a DEK
+	EncDataKey string `gorm:"type:nvarchar(200)" json:"-"`      // DEK cifrada
+	EncData    string `gorm:"type:nvarchar(max)" json:"-"`      // Campos sensíveis cifrados (JSON)
+}
+
+// SealedColumns colunas do envelope, para consultas com Select
+var SealedColumns = []string{"enc_key_id", "enc_data_key", "enc_data"}
+
+func (s Sealed) envelope() encryption.Envelope {
+	return encryption.Envelope{KeyID: s.EncKeyID, DataKey: s.EncDataKey, Data: s.EncData}
+}
+
+// seal cifra os campos sensíveis com uma DEK nova da chave ativa
+func (s *Sealed) seal(secrets interface{}) error {
+	envelope, err := encryption.SealJSON(secrets)
+	if err != nil {
+		return err
+	}
+	s.EncKeyID, s.EncDataKey, s.EncData = envelope.KeyID, envelope.DataKey, envelope.Data
+	return nil
+}
+
+// open decifra os campos sensíveis; false quando o registro ainda está em claro
+func (s Sealed) open(secrets interface{}) (bool, error) {
+	envelope := s.envelope()
+	if envelope.Empty() || envelope.Data == "" {
+		return false, nil
+	}
+	return true, encryption.OpenJSON(envelope, secrets)
+}
+
+// sealTarget indica gravação do próprio registro (Create/Save). Atualizações por mapa
+// de colunas (Update/Updates com map) não tocam nos campos sensíveis e não são cifradas.
+func sealTarget(tx *gorm.DB) bool {
+	switch tx.Statement.Dest.(type) {
+	case map[string]interface{}, []map[string]interface{}:
+		return false
+	}
+	return true
+}
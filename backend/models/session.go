@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Motivos de revogação de sessão
+const (
+	SessionRevokedLogout        = "logout"
+	SessionRevokedLogoutAll     = "logout_all"
+	SessionRevokedByUser        = "revoked_by_user"
+	SessionRevokedByAdmin       = "revoked_by_admin"
+	SessionRevokedPasswordReset = "password_reset"
+	SessionRevokedUserDeleted   = "user_deleted"
+	SessionRevokedTokenReuse    = "refresh_token_reuse"
+)
+
+// UserSession sessão de login (um dispositivo/navegador). O refresh token é
+// rotacionado a cada renovação; apenas o hash SHA-256 é armazenado.
+type UserSession struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID            string `gorm:"type:nvarchar(36);not null;index" json:"user_id"`
+	User              *User  `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	RefreshTokenHash  string `gorm:"type:nvarchar(64);not null;uniqueIndex" json:"-"`
+	PreviousTokenHash string `gorm:"type:nvarchar(64);index" json:"-"` // Detecta reuso de refresh token já rotacionado
+	RememberMe        bool   `gorm:"default:false" json:"remember_me"`
+
+	UserAgent  string    `gorm:"type:nvarchar(500)" json:"user_agent,omitempty"`
+	IPAddress  string    `gorm:"type:nvarchar(64)" json:"ip_address,omitempty"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `gorm:"not null;index" json:"expires_at"`
+
+	RevokedAt     *time.Time `gorm:"index" json:"revoked_at,omitempty"`
+	RevokedReason string     `gorm:"type:nvarchar(50)" json:"revoked_reason,omitempty"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (s *UserSession) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Active indica se a sessão pode ser usada na data informada
+func (s *UserSession) Active(at time.Time) bool {
+	return s.RevokedAt == nil && at.Before(s.ExpiresAt)
+}
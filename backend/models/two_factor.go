@@ -0,0 +1,114 @@
+package models
+
+import (
+	"time"
+
+	"github.com/frappyou/backend/encryption"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserTwoFactor configuração de TOTP (RFC 6238) do usuário. Enquanto Enabled
+// for falso o cadastro está pendente de confirmação com o primeiro código.
+type UserTwoFactor struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID       string     `gorm:"type:nvarchar(36);not null;uniqueIndex" json:"user_id"`
+	Secret       string     `gorm:"type:nvarchar(64);not null" json:"-"` // Base32 (vazio no banco com a criptografia ativa)
+	Enabled      bool       `gorm:"default:false" json:"enabled"`
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+	LastUsedStep int64      `gorm:"default:0" json:"-"` // Último intervalo TOTP aceito (impede reuso do código)
+
+	// Limite de tentativas
+	FailedAttempts    int        `gorm:"default:0" json:"-"`         // Códigos inválidos seguidos, em qualquer verificação
+	LockedUntil       *time.Time `json:"-"`                          // Verificações recusadas até esta data
+	ChallengeID       string     `gorm:"type:nvarchar(36)" json:"-"` // Desafio de login em aberto (só o mais recente vale)
+	ChallengeFailures int        `gorm:"default:0" json:"-"`         // Códigos inválidos no desafio em aberto
+
+	// Segredo TOTP cifrado (ver twoFactorSecrets)
+	Sealed
+}
+
+// twoFactorSecrets segredo gravado apenas no envelope cifrado
+type twoFactorSecrets struct {
+	Secret string `json:"secret"`
+}
+
+// UserTwoFactorSealedColumns colunas regravadas ao cifrar (ou recifrar) o segredo
+var UserTwoFactorSealedColumns = append([]string{"secret"}, SealedColumns...)
+
+// BeforeSave move o segredo para o envelope com a criptografia ativa
+func (t *UserTwoFactor) BeforeSave(tx *gorm.DB) error {
+	if !sealTarget(tx) || !encryption.Enabled() {
+		return nil
+	}
+	if err := t.Sealed.seal(twoFactorSecrets{Secret: t.Secret}); err != nil {
+		return err
+	}
+	t.Secret = ""
+	return nil
+}
+
+// AfterSave devolve ao struct o segredo zerado no BeforeSave
+func (t *UserTwoFactor) AfterSave(tx *gorm.DB) error {
+	if !sealTarget(tx) {
+		return nil
+	}
+	return t.AfterFind(tx)
+}
+
+// AfterFind decifra o segredo
+func (t *UserTwoFactor) AfterFind(tx *gorm.DB) error {
+	var secrets twoFactorSecrets
+	if ok, err := t.Sealed.open(&secrets); !ok || err != nil {
+		return err
+	}
+	t.Secret = secrets.Secret
+	return nil
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (t *UserTwoFactor) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TwoFactorRecoveryCode código de recuperação de uso único (apenas o hash é armazenado)
+type TwoFactorRecoveryCode struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID   string     `gorm:"type:nvarchar(36);not null;index" json:"user_id"`
+	CodeHash string     `gorm:"type:nvarchar(64);not null;index" json:"-"`
+	UsedAt   *time.Time `json:"used_at,omitempty"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (r *TwoFactorRecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// SecuritySettings política de segurança da conta (editável pelo admin)
+type SecuritySettings struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Exige 2FA para os papéis com acesso amplo (admin e folha de pagamento)
+	RequireTwoFactorPrivileged bool `gorm:"default:false" json:"require_two_factor_privileged"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (s *SecuritySettings) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
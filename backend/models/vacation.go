@@ -46,6 +46,7 @@ type Vacation struct {
 	StartDate   time.Time      `gorm:"not null" json:"start_date"`
 	EndDate     time.Time      `gorm:"not null" json:"end_date"`
 	TotalDays   int            `gorm:"not null" json:"total_days"`
+	BusinessDays int           `gorm:"default:0" json:"business_days"` // Dias úteis no período (descontados feriados e fins de semana)
 	Reason      string         `gorm:"type:nvarchar(max)" json:"reason"`
 
 	// Status e aprovação
@@ -74,7 +75,8 @@ func (v *Vacation) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-// VacationBalance representa o saldo de férias do colaborador
+// VacationBalance representa o saldo de férias do colaborador.
+// Os saldos de dias são um retrato derivado do extrato (VacationLedgerEntry) e não devem ser alterados diretamente.
 type VacationBalance struct {
 	ID            string         `gorm:"type:nvarchar(36);primaryKey" json:"id"`
 	CreatedAt     time.Time      `json:"created_at"`
@@ -96,7 +98,7 @@ type VacationBalance struct {
 
 	// Abonos
 	AbonoDays     int            `gorm:"default:3" json:"abono_days"`
-	UsedAbono     int            `gorm:"default:0" json:"used_abono"`
+	UsedAbono     int            `gorm:"default:0" json:"used_abono"` // Derivado dos lançamentos de abono do período atual
 }
 
 // BeforeCreate gera o UUID antes de criar
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// VacationAcquisitionPeriod representa um período aquisitivo de férias do colaborador.
+// Os dias do período podem ser gozados até o fim do período concessivo (ConcessiveEnd),
+// por isso dois períodos podem estar abertos ao mesmo tempo.
+type VacationAcquisitionPeriod struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Um período por data de início: duas sincronizações simultâneas não duplicam o período
+	UserID string `gorm:"type:nvarchar(36);not null;uniqueIndex:idx_vacation_period_user_start" json:"user_id"`
+
+	PeriodStart   time.Time  `gorm:"type:date;not null;uniqueIndex:idx_vacation_period_user_start" json:"period_start"`
+	PeriodEnd     time.Time  `gorm:"type:date;not null" json:"period_end"`
+	ConcessiveEnd time.Time  `gorm:"type:date;not null" json:"concessive_end"` // Prazo limite para gozo
+	ClosedAt      *time.Time `json:"closed_at,omitempty"`                      // Encerrado após o prazo (saldo expirado/transferido)
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (p *VacationAcquisitionPeriod) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// VacationLedgerKind tipo de lançamento no extrato de férias
+type VacationLedgerKind string
+
+const (
+	VacationLedgerAccrual     VacationLedgerKind = "accrual"     // Direito adquirido no período
+	VacationLedgerAdjustment  VacationLedgerKind = "adjustment"  // Ajuste manual do direito (RH/DP)
+	VacationLedgerReservation VacationLedgerKind = "reservation" // Dias reservados por solicitação pendente (negativo libera)
+	VacationLedgerConsumption VacationLedgerKind = "consumption" // Dias gozados (negativo estorna)
+	VacationLedgerSale        VacationLedgerKind = "sale"        // Abono pecuniário (venda de férias)
+	VacationLedgerExpiry      VacationLedgerKind = "expiry"      // Dias perdidos ao fim do período concessivo
+	VacationLedgerCarryOver   VacationLedgerKind = "carry_over"  // Transferência de saldo entre períodos
+	VacationLedgerAbono       VacationLedgerKind = "abono"       // Abono (folga abonada) aprovado; negativo estorna. Não afeta o saldo de férias
+)
+
+// VacationLedgerEntry lançamento imutável do extrato de férias.
+// Os saldos (direito, pendente, usado e disponível) são sempre derivados da soma dos lançamentos.
+type VacationLedgerEntry struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	UserID   string                     `gorm:"type:nvarchar(36);not null;index" json:"user_id"`
+	PeriodID string                     `gorm:"type:nvarchar(36);not null;index" json:"period_id"`
+	Period   *VacationAcquisitionPeriod `gorm:"foreignKey:PeriodID" json:"period,omitempty"`
+
+	Kind VacationLedgerKind `gorm:"type:varchar(20);not null" json:"kind"`
+	Days int                `gorm:"not null" json:"days"` // Positivo ou negativo
+
+	// Origem do lançamento
+	VacationID    *string `gorm:"type:nvarchar(36);index" json:"vacation_id,omitempty"`
+	SellRequestID *string `gorm:"type:nvarchar(36)" json:"sell_request_id,omitempty"`
+	CreatedBy     *string `gorm:"type:nvarchar(36)" json:"created_by,omitempty"` // Nulo para lançamentos automáticos
+	Description   string  `gorm:"type:nvarchar(500)" json:"description"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (e *VacationLedgerEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
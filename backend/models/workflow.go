@@ -0,0 +1,184 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ApprovalSubjectType tipo de item que passa pelo fluxo de aprovação
+type ApprovalSubjectType string
+
+const (
+	ApprovalSubjectVacation     ApprovalSubjectType = "vacation"
+	ApprovalSubjectVacationSell ApprovalSubjectType = "vacation_sell"
+	ApprovalSubjectDocument     ApprovalSubjectType = "document"
+	ApprovalSubjectPDI          ApprovalSubjectType = "pdi"
+)
+
+// ApproverType define como o aprovador de uma etapa é resolvido
+type ApproverType string
+
+const (
+	ApproverDirectManager ApproverType = "manager" // Gestor direto do solicitante
+	ApproverRole          ApproverType = "role"    // Qualquer usuário com o papel informado (ex: admin = RH)
+	ApproverUser          ApproverType = "user"    // Usuário específico (ex: diretor)
+)
+
+// WorkflowChain cadeia de aprovação configurada para um tipo de item
+type WorkflowChain struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SubjectType ApprovalSubjectType `gorm:"type:varchar(20);not null;uniqueIndex" json:"subject_type"`
+	Name        string              `gorm:"type:nvarchar(100);not null" json:"name"`
+	Active      bool                `gorm:"default:true" json:"active"`
+
+	Steps []WorkflowChainStep `gorm:"foreignKey:ChainID" json:"steps"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (w *WorkflowChain) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// WorkflowChainStep etapa de uma cadeia de aprovação (executadas em ordem crescente)
+type WorkflowChainStep struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ChainID   string `gorm:"type:nvarchar(36);not null;index" json:"chain_id"`
+	StepOrder int    `gorm:"not null" json:"step_order"`
+	Name      string `gorm:"type:nvarchar(100);not null" json:"name"`
+
+	ApproverType  ApproverType `gorm:"type:varchar(20);not null" json:"approver_type"`
+	ApproverValue string       `gorm:"type:nvarchar(100)" json:"approver_value"` // Papel ou ID do usuário
+
+	SLAHours int `gorm:"default:48" json:"sla_hours"` // Prazo antes da escalação (0 = sem prazo)
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (s *WorkflowChainStep) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// ApprovalRequestStatus status de uma solicitação de aprovação
+type ApprovalRequestStatus string
+
+const (
+	ApprovalRequestPending  ApprovalRequestStatus = "pending"
+	ApprovalRequestApproved ApprovalRequestStatus = "approved"
+	ApprovalRequestRejected ApprovalRequestStatus = "rejected"
+	ApprovalRequestCanceled ApprovalRequestStatus = "canceled"
+)
+
+// ApprovalRequest instância do fluxo de aprovação para um item
+type ApprovalRequest struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SubjectType ApprovalSubjectType `gorm:"type:varchar(20);not null;index:idx_approval_subject,priority:1" json:"subject_type"`
+	SubjectID   string              `gorm:"type:nvarchar(36);not null;index:idx_approval_subject,priority:2" json:"subject_id"`
+	Summary     string              `gorm:"type:nvarchar(500)" json:"summary"`
+
+	RequesterID string `gorm:"type:nvarchar(36);not null;index" json:"requester_id"`
+	Requester   *User  `gorm:"foreignKey:RequesterID" json:"requester,omitempty"`
+
+	ChainID     *string               `gorm:"type:nvarchar(36)" json:"chain_id,omitempty"`
+	CurrentStep int                   `json:"current_step"`
+	Status      ApprovalRequestStatus `gorm:"type:varchar(20);default:'pending';index" json:"status"`
+	CompletedAt *time.Time            `json:"completed_at,omitempty"`
+
+	Tasks []ApprovalTask `gorm:"foreignKey:RequestID" json:"tasks,omitempty"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (r *ApprovalRequest) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// ApprovalTaskStatus status de uma tarefa de aprovação
+type ApprovalTaskStatus string
+
+const (
+	ApprovalTaskPending   ApprovalTaskStatus = "pending"
+	ApprovalTaskApproved  ApprovalTaskStatus = "approved"
+	ApprovalTaskRejected  ApprovalTaskStatus = "rejected"
+	ApprovalTaskEscalated ApprovalTaskStatus = "escalated" // Prazo estourado, tarefa repassada
+	ApprovalTaskCanceled  ApprovalTaskStatus = "canceled"
+)
+
+// ApprovalTask tarefa de aprovação atribuída a um usuário ou a um papel
+type ApprovalTask struct {
+	ID        string    `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	RequestID string           `gorm:"type:nvarchar(36);not null;index" json:"request_id"`
+	Request   *ApprovalRequest `gorm:"foreignKey:RequestID" json:"request,omitempty"`
+	StepOrder int              `gorm:"not null" json:"step_order"`
+	StepName  string           `gorm:"type:nvarchar(100)" json:"step_name"`
+
+	// Atribuição: um usuário (gestor, diretor, delegado) ou um papel inteiro
+	AssigneeID         *string `gorm:"type:nvarchar(36);index" json:"assignee_id,omitempty"`
+	Assignee           *User   `gorm:"foreignKey:AssigneeID" json:"assignee,omitempty"`
+	AssigneeRole       string  `gorm:"type:varchar(20);index" json:"assignee_role,omitempty"`
+	OriginalAssigneeID *string `gorm:"type:nvarchar(36)" json:"original_assignee_id,omitempty"` // Preenchido quando houve delegação
+	DelegationReason   string  `gorm:"type:nvarchar(255)" json:"delegation_reason,omitempty"`
+
+	Status      ApprovalTaskStatus `gorm:"type:varchar(20);default:'pending';index" json:"status"`
+	DueAt       *time.Time         `gorm:"index" json:"due_at,omitempty"`
+	EscalatedTo *string            `gorm:"type:nvarchar(36)" json:"escalated_to,omitempty"` // Tarefa criada na escalação
+	DecidedBy   *string            `gorm:"type:nvarchar(36)" json:"decided_by,omitempty"`
+	DecidedAt   *time.Time         `json:"decided_at,omitempty"`
+	Comment     string             `gorm:"type:nvarchar(max)" json:"comment,omitempty"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (t *ApprovalTask) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// ApprovalDelegation delegação temporária das aprovações de um usuário (ex: durante as férias)
+type ApprovalDelegation struct {
+	ID        string         `gorm:"type:nvarchar(36);primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	DelegatorID string `gorm:"type:nvarchar(36);not null;index" json:"delegator_id"`
+	Delegator   *User  `gorm:"foreignKey:DelegatorID" json:"delegator,omitempty"`
+	DelegateID  string `gorm:"type:nvarchar(36);not null;index" json:"delegate_id"`
+	Delegate    *User  `gorm:"foreignKey:DelegateID" json:"delegate,omitempty"`
+
+	StartDate time.Time `gorm:"type:date;not null" json:"start_date"`
+	EndDate   time.Time `gorm:"type:date;not null" json:"end_date"`
+
+	// Vazio = todas as aprovações
+	SubjectType ApprovalSubjectType `gorm:"type:varchar(20)" json:"subject_type,omitempty"`
+	Reason      string              `gorm:"type:nvarchar(255)" json:"reason,omitempty"`
+}
+
+// BeforeCreate gera o UUID antes de criar
+func (d *ApprovalDelegation) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/frappyou/backend/encryption"
+	"github.com/frappyou/backend/services"
+)
+
+// runKeyRotation recifra os dados sensíveis com a chave ativa (ENCRYPTION_ACTIVE_KEY).
+// Retorna o código de saída do processo.
+func runKeyRotation(args []string) int {
+	flags := flag.NewFlagSet("rotate-keys", flag.ContinueOnError)
+	all := flags.Bool("all", false, "recifra todos os registros, inclusive os que já estão na chave ativa")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	log.Printf("🔐 Criptografia: recifrando com a chave %s (chaves disponíveis: %v)", encryption.ActiveKeyID(), encryption.KeyIDs())
+	result, err := services.KeyRotation.Run(*all)
+	if result != nil {
+		log.Printf("🔐 Criptografia: %d holerites, %d itens, %d informes, %d documentos e %d cadastros recifrados; %d falhas",
+			result.Payslips, result.PayslipItems, result.IncomeStatements, result.Documents, result.PersonDocuments, result.Failed)
+		for _, problem := range result.Errors {
+			log.Printf("   - %s", problem)
+		}
+	}
+	if err != nil {
+		log.Printf("❌ Criptografia: rotação interrompida: %v", err)
+		return 1
+	}
+	if result.Failed > 0 {
+		return 1
+	}
+	return 0
+}
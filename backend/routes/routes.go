@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/frappyou/backend/handlers"
 	"github.com/frappyou/backend/middleware"
+	"github.com/frappyou/backend/services"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -24,6 +25,12 @@ func SetupRoutes(app *fiber.App) {
 	auth.Post("/login", middleware.AuthRateLimiter(), handlers.Login)
 	auth.Post("/activate", middleware.AuthRateLimiter(), handlers.ActivateAccount)
 	auth.Post("/validate-cpf", middleware.AuthRateLimiter(), handlers.ValidateCPF)
+	auth.Post("/refresh", middleware.AuthRateLimiter(), handlers.RefreshToken)
+	auth.Post("/forgot-password", middleware.AuthRateLimiter(), handlers.ForgotPassword)
+	auth.Post("/reset-password", middleware.AuthRateLimiter(), handlers.ResetPassword)
+	auth.Post("/2fa/verify", middleware.AuthRateLimiter(), handlers.VerifyTwoFactorLogin)
+	auth.Post("/logout", middleware.AuthMiddleware, handlers.Logout)
+	auth.Post("/logout-all", middleware.AuthMiddleware, handlers.LogoutAllDevices)
 	auth.Get("/debug-yasmin", handlers.DebugYasmin) // TEMPORÁRIO - debug Yasmin
 
 	// Rotas do questionário (públicas)
@@ -41,13 +48,23 @@ func SetupRoutes(app *fiber.App) {
 	user.Get("/profile/full", handlers.GetFullProfile)
 	user.Put("/profile", handlers.UpdateProfile)
 	user.Put("/profile/full", handlers.UpdateFullProfile)
+	user.Get("/permissions", handlers.GetMyPermissions)
+	user.Get("/sessions", handlers.GetMySessions)
+	user.Delete("/sessions/:id", handlers.RevokeMySession)
+	user.Get("/2fa", handlers.GetTwoFactorStatus)
+	user.Post("/2fa/setup", handlers.BeginTwoFactorSetup)
+	user.Post("/2fa/confirm", middleware.AuthRateLimiter(), handlers.ConfirmTwoFactorSetup)
+	user.Post("/2fa/disable", middleware.AuthRateLimiter(), handlers.DisableTwoFactor)
+	user.Post("/2fa/recovery-codes", middleware.AuthRateLimiter(), handlers.RegenerateRecoveryCodes)
 
 	// Rotas de Férias e Ausências (protegidas)
 	vacation := api.Group("/vacation", middleware.AuthMiddleware)
 	vacation.Get("/balance", handlers.GetVacationBalance)
+	vacation.Get("/ledger", handlers.GetMyVacationLedger)
 	vacation.Get("/stats", handlers.GetVacationStats)
 	vacation.Get("/my", handlers.GetMyVacations)
 	vacation.Get("/team", handlers.GetTeamVacations)
+	vacation.Get("/working-days", handlers.PreviewVacationDays)
 	vacation.Get("/:id", handlers.GetVacationByID)
 	vacation.Post("/", handlers.CreateVacation)
 	vacation.Put("/:id/cancel", handlers.CancelVacation)
@@ -56,42 +73,89 @@ func SetupRoutes(app *fiber.App) {
 	vacation.Post("/sell", handlers.CreateVacationSellRequest)
 	vacation.Get("/sell/my", handlers.GetMyVacationSellRequests)
 
-	// Rotas de aprovação e gestão (gestores/admin)
-	vacationAdmin := api.Group("/vacation/admin", middleware.AuthMiddleware, middleware.AdminMiddleware)
+	// Rotas de aprovação e gestão: gestores veem a equipe, RH a filial e admin todos.
+	// Alterações em nome de terceiros exigem a permissão de gestão (RH/admin).
+	vacationAdmin := api.Group("/vacation/admin", middleware.AuthMiddleware, middleware.RequirePermission(services.ResourceVacation, services.ActionApprove))
+	vacationManage := middleware.RequirePermission(services.ResourceVacation, services.ActionManage)
 	vacationAdmin.Get("/pending", handlers.GetPendingApprovals)
 	vacationAdmin.Get("/all", handlers.AdminGetAllVacations)
 	vacationAdmin.Get("/users", handlers.GetAllUsersForAdmin)
-	vacationAdmin.Post("/", handlers.AdminCreateVacation)
-	vacationAdmin.Put("/:id", handlers.AdminUpdateVacation)
+	vacationAdmin.Post("/", vacationManage, handlers.AdminCreateVacation)
+	vacationAdmin.Put("/:id", vacationManage, handlers.AdminUpdateVacation)
 	vacationAdmin.Put("/:id/approve", handlers.ApproveOrRejectVacation)
-	vacationAdmin.Put("/:id/interrupt", handlers.InterruptVacation)
-	vacationAdmin.Delete("/:id", handlers.AdminDeleteVacation)
+	vacationAdmin.Put("/:id/interrupt", vacationManage, handlers.InterruptVacation)
+	vacationAdmin.Delete("/:id", vacationManage, handlers.AdminDeleteVacation)
 
-	// Venda de férias (admin)
+	// Venda de férias
 	vacationAdmin.Get("/sell/pending", handlers.GetPendingVacationSellRequests)
 	vacationAdmin.Get("/sell/all", handlers.GetAllVacationSellRequests)
 	vacationAdmin.Put("/sell/:id/approve", handlers.ApproveOrRejectVacationSell)
-	vacationAdmin.Put("/sell/:id", handlers.AdminUpdateVacationSell)
+	vacationAdmin.Put("/sell/:id", vacationManage, handlers.AdminUpdateVacationSell)
 
-	// Gerenciamento de saldos de férias (admin)
+	// Saldos de férias
 	vacationAdmin.Get("/balances", handlers.AdminGetAllVacationBalances)
 	vacationAdmin.Get("/balance/:user_id", handlers.AdminGetVacationBalance)
-	vacationAdmin.Put("/balance/:user_id", handlers.AdminUpdateVacationBalance)
+	vacationAdmin.Put("/balance/:user_id", vacationManage, handlers.AdminUpdateVacationBalance)
 	vacationAdmin.Get("/users/search", handlers.AdminSearchUsersForVacation)
 
 	// Configurações de férias (admin)
 	vacationAdmin.Get("/settings", handlers.GetVacationSettings)
-	vacationAdmin.Put("/settings", handlers.UpdateVacationSettings)
+	vacationAdmin.Put("/settings", middleware.RequirePermission(services.ResourceSettings, services.ActionManage), handlers.UpdateVacationSettings)
 
 	// Rotas de eventos do calendário (admin)
 	calendar := api.Group("/calendar", middleware.AuthMiddleware)
 	calendar.Get("/events", handlers.GetCalendarEvents)
+	calendar.Get("/holidays", handlers.GetMyHolidays)
 
 	calendarAdmin := api.Group("/calendar/admin", middleware.AuthMiddleware, middleware.AdminMiddleware)
 	calendarAdmin.Post("/", handlers.CreateCalendarEvent)
 	calendarAdmin.Put("/:id", handlers.UpdateCalendarEvent)
 	calendarAdmin.Delete("/:id", handlers.DeleteCalendarEvent)
 
+	// Feriados nacionais/estaduais/municipais e localização das filiais (admin)
+	calendarAdmin.Get("/holidays", handlers.AdminGetHolidays)
+	calendarAdmin.Post("/holidays", handlers.AdminCreateHoliday)
+	calendarAdmin.Put("/holidays/:id", handlers.AdminUpdateHoliday)
+	calendarAdmin.Delete("/holidays/:id", handlers.AdminDeleteHoliday)
+	calendarAdmin.Get("/filiais", handlers.AdminGetFilialLocations)
+	calendarAdmin.Put("/filiais", handlers.AdminUpsertFilialLocation)
+
+	// Organograma (RH/admin): departamentos, cargos e lotações com vigência
+	orgAdmin := api.Group("/org/admin", middleware.AuthMiddleware, middleware.RequirePermission(services.ResourceOrg, services.ActionManage))
+	orgAdmin.Get("/departments", handlers.AdminGetDepartments)
+	orgAdmin.Post("/departments", handlers.AdminSaveDepartment)
+	orgAdmin.Put("/departments/:id", handlers.AdminSaveDepartment)
+	orgAdmin.Get("/positions", handlers.AdminGetPositions)
+	orgAdmin.Post("/positions", handlers.AdminSavePosition)
+	orgAdmin.Put("/positions/:id", handlers.AdminSavePosition)
+	orgAdmin.Get("/assignments/:user_id", handlers.AdminGetAssignmentHistory)
+	orgAdmin.Put("/assignments/:user_id", handlers.AdminChangeAssignment)
+	orgAdmin.Post("/seed", handlers.AdminSeedOrgChart)
+
+	// Organograma: lotação, subordinados e exportação
+	org := api.Group("/org", middleware.AuthMiddleware)
+	org.Get("/me", handlers.GetMyOrgPosition)
+	org.Get("/reports", handlers.GetMyReports)
+	org.Get("/chart", handlers.GetOrgChart)
+
+	// Cadeias de aprovação e delegações (admin)
+	approvalsAdmin := api.Group("/approvals/admin", middleware.AuthMiddleware, middleware.AdminMiddleware)
+	approvalsAdmin.Get("/chains", handlers.AdminGetWorkflowChains)
+	approvalsAdmin.Put("/chains/:type", handlers.AdminUpdateWorkflowChain)
+	approvalsAdmin.Get("/delegations", handlers.AdminGetAllDelegations)
+
+	// Caixa unificada de aprovações (gestores, RH e delegados)
+	approvals := api.Group("/approvals", middleware.AuthMiddleware)
+	approvals.Get("/inbox", handlers.GetMyApprovalInbox)
+	approvals.Put("/tasks/:id", handlers.DecideApprovalTask)
+	approvals.Get("/history/:type/:id", handlers.GetApprovalHistory)
+	approvals.Get("/delegations", handlers.GetMyApprovalDelegations)
+	approvals.Post("/delegations", handlers.CreateApprovalDelegation)
+	approvals.Delete("/delegations/:id", handlers.DeleteApprovalDelegation)
+
+	// Download de documento por link temporário (a assinatura substitui o token)
+	api.Get("/files/documents/:id", handlers.ServeDocumentFile)
+
 	// Rotas de Documentos (protegidas) - Upload com rate limiting específico
 	documents := api.Group("/documents", middleware.AuthMiddleware)
 	documents.Get("/", handlers.GetMyDocuments)
@@ -101,15 +165,20 @@ func SetupRoutes(app *fiber.App) {
 	documents.Post("", middleware.UploadRateLimiter(), handlers.UploadDocument)
 	documents.Post("/upload", middleware.UploadRateLimiter(), handlers.UploadDocument)
 	documents.Get("/:id/download", handlers.DownloadDocument)
+	documents.Get("/:id/link", handlers.GetDocumentLink)
+	documents.Get("/:id/thumbnail", handlers.GetDocumentThumbnail)
 	documents.Delete("/:id", handlers.DeleteDocument)
 
-	// Rotas de Documentos para Admin
-	documentsAdmin := api.Group("/documents/admin", middleware.AuthMiddleware, middleware.AdminMiddleware)
+	// Rotas de Documentos para RH (filial) e Admin
+	documentsAdmin := api.Group("/documents/admin", middleware.AuthMiddleware, middleware.RequirePermission(services.ResourceDocument, services.ActionApprove))
 	documentsAdmin.Get("/", handlers.AdminGetAllDocuments)
 	documentsAdmin.Get("", handlers.AdminGetAllDocuments)
 	documentsAdmin.Get("/pending", handlers.AdminGetPendingDocuments)
 	documentsAdmin.Get("/stats", handlers.AdminGetDocumentStats)
 	documentsAdmin.Get("/:id/download", handlers.AdminDownloadDocument)
+	documentsAdmin.Get("/:id/link", handlers.AdminGetDocumentLink)
+	documentsAdmin.Get("/:id/thumbnail", handlers.AdminGetDocumentThumbnail)
+	documentsAdmin.Post("/:id/rescan", handlers.AdminRescanDocument)
 	documentsAdmin.Put("/:id/approve", handlers.AdminApproveDocument)
 	documentsAdmin.Put("/:id/reject", handlers.AdminRejectDocument)
 	documentsAdmin.Delete("/:id", handlers.AdminDeleteDocument)
@@ -129,10 +198,19 @@ func SetupRoutes(app *fiber.App) {
 	admin.Put("/users/:id/profile", handlers.UpdateProfileByAdmin)
 	admin.Put("/users/:id/password", middleware.StrictAuthRateLimiter(), handlers.AdminResetPassword) // Rate limit restritivo
 	admin.Delete("/users/:id", handlers.DeleteUser)
+	admin.Get("/users/:id/sessions", handlers.AdminGetUserSessions)
+	admin.Delete("/users/:id/sessions", handlers.AdminRevokeUserSessions)
+	admin.Delete("/users/:id/2fa", handlers.AdminResetTwoFactor)
+	admin.Get("/security-settings", handlers.GetSecuritySettings)
+	admin.Put("/security-settings", middleware.RequirePermission(services.ResourceSettings, services.ActionManage), handlers.UpdateSecuritySettings)
 	admin.Get("/logs", handlers.GetAuditLogs)
 
-	// Rotas de Analytics (admin)
-	analytics := api.Group("/analytics", middleware.AuthMiddleware, middleware.AdminMiddleware)
+	// Trilha de auditoria (admin e auditores)
+	audit := api.Group("/audit", middleware.AuthMiddleware, middleware.RequirePermission(services.ResourceAudit, services.ActionRead))
+	audit.Get("/logs", handlers.GetAuditLogs)
+
+	// Rotas de Analytics (RH, admin e auditores)
+	analytics := api.Group("/analytics", middleware.AuthMiddleware, middleware.RequirePermission(services.ResourceAnalytics, services.ActionRead))
 	analytics.Get("/overview", handlers.GetOverviewAnalytics)
 	analytics.Get("/hr", handlers.GetHRAnalytics)
 	analytics.Get("/people", handlers.GetPeopleAnalytics)
@@ -214,27 +292,63 @@ func SetupRoutes(app *fiber.App) {
 
 	// ==================== HOLERITE/CONTRACHEQUE ====================
 
-	// Rotas Admin de Holerite (DEVE vir antes das rotas com :id)
-	payslipAdmin := api.Group("/payslip/admin", middleware.AuthMiddleware, middleware.AdminMiddleware)
+	// Rotas de Folha/Admin de Holerite (DEVE vir antes das rotas com :id)
+	payslipAdmin := api.Group("/payslip/admin", middleware.AuthMiddleware, middleware.RequirePermission(services.ResourcePayslip, services.ActionManage))
 	payslipAdmin.Get("/", handlers.AdminGetAllPayslips)
 	payslipAdmin.Get("/stats", handlers.AdminGetPayslipStats)
 	payslipAdmin.Post("/", handlers.AdminCreatePayslip)
 	payslipAdmin.Post("/import", handlers.AdminImportPayslips)
+	payslipAdmin.Get("/import/templates", handlers.AdminListImportTemplates)
+	payslipAdmin.Post("/import/templates", handlers.AdminSaveImportTemplate)
+	payslipAdmin.Put("/import/templates/:id", handlers.AdminSaveImportTemplate)
+	payslipAdmin.Delete("/import/templates/:id", handlers.AdminDeleteImportTemplate)
+	payslipAdmin.Post("/import/file", handlers.AdminPreviewPayslipImport)
+	payslipAdmin.Get("/import/batches/:id", handlers.AdminGetPayslipImportBatch)
+	payslipAdmin.Post("/import/batches/:id/commit", handlers.AdminCommitPayslipImport)
+	payslipAdmin.Delete("/import/batches/:id", handlers.AdminDiscardPayslipImport)
+	payslipAdmin.Post("/review", handlers.AdminReviewPayslips)
+	payslipAdmin.Post("/review/undo", handlers.AdminReturnPayslipsToDraft)
+	payslipAdmin.Get("/publications", handlers.AdminListPayslipPublications)
+	payslipAdmin.Post("/publications", handlers.AdminSchedulePayslipPublication)
+	payslipAdmin.Delete("/publications/:id", handlers.AdminCancelPayslipPublication)
+	payslipAdmin.Get("/acknowledgements", handlers.AdminGetPayslipAcknowledgements)
+	payslipAdmin.Post("/calculate", handlers.AdminCalculatePayroll)
+	payslipAdmin.Get("/tax-tables", handlers.AdminListPayrollTaxTables)
+	payslipAdmin.Post("/tax-tables", handlers.AdminCreatePayrollTaxTable)
+	payslipAdmin.Get("/variance", handlers.AdminGetPayrollVariance)
+	payslipAdmin.Get("/income-statements", handlers.AdminListIncomeStatements)
+	payslipAdmin.Post("/income-statements/batches", handlers.AdminStartIncomeStatementBatch)
+	payslipAdmin.Get("/income-statements/batches/:id", handlers.AdminGetIncomeStatementBatch)
+	payslipAdmin.Get("/income-statements/:year/:cpf", handlers.AdminGetIncomeStatement)
+	payslipAdmin.Get("/income-statements/:year/:cpf/pdf", handlers.AdminDownloadIncomeStatementPDF)
+	payslipAdmin.Get("/identity/pending", handlers.AdminListUnlinkedPayslips)
+	payslipAdmin.Post("/identity/backfill", handlers.AdminBackfillPayslipIdentities)
+	payslipAdmin.Post("/identity/link", handlers.AdminLinkPayslipIdentity)
 	payslipAdmin.Delete("/:id", handlers.AdminDeletePayslip)
 
 	// Rotas de Holerite (Colaboradores)
 	payslip := api.Group("/payslip", middleware.AuthMiddleware)
 	payslip.Get("/", handlers.GetMyPayslips)
+	payslip.Get("/income-statements", handlers.GetMyIncomeStatementYears)
+	payslip.Get("/income-statements/:year", handlers.GetMyIncomeStatement)
+	payslip.Get("/income-statements/:year/pdf", handlers.DownloadMyIncomeStatementPDF)
 	payslip.Get("/:id", handlers.GetPayslipByID)
+	payslip.Get("/:id/pdf", handlers.DownloadPayslipPDF)
+	payslip.Post("/:id/acknowledge", handlers.AcknowledgePayslip)
+
+	// Verificação pública de autenticidade do PDF (sem login)
+	payslipVerify := api.Group("/public/payslip/verify", middleware.APIRateLimiter())
+	payslipVerify.Get("/:code", handlers.VerifyPayslipCode)
+	payslipVerify.Post("/", handlers.VerifyPayslipDocument)
 
 	// ==================== PDI (PLANO DE DESENVOLVIMENTO INDIVIDUAL) ====================
 
-	// Rotas Admin de PDI (DEVE vir antes das rotas com :id)
-	pdiAdmin := api.Group("/pdi/admin", middleware.AuthMiddleware, middleware.AdminMiddleware)
+	// Rotas de RH/Admin de PDI (DEVE vir antes das rotas com :id)
+	pdiAdmin := api.Group("/pdi/admin", middleware.AuthMiddleware, middleware.RequirePermission(services.ResourcePDI, services.ActionManage))
 	pdiAdmin.Get("/", handlers.AdminGetAllPDIs)
 
-	// Rotas de Gestor para PDI
-	pdiManager := api.Group("/pdi/manager", middleware.AuthMiddleware)
+	// Rotas de Gestor para PDI (equipe no organograma; RH filial; admin todos)
+	pdiManager := api.Group("/pdi/manager", middleware.AuthMiddleware, middleware.RequirePermission(services.ResourcePDI, services.ActionApprove))
 	pdiManager.Get("/team", handlers.AdminGetTeamPDIs)
 	pdiManager.Get("/:id", handlers.AdminGetPDIByID)
 	pdiManager.Put("/:id/approve", handlers.AdminApprovePDI)
@@ -287,10 +401,15 @@ func SetupRoutes(app *fiber.App) {
 	chat.Get("/sessions/:id", handlers.GetChatHistory)
 	chat.Delete("/sessions/:id", handlers.DeleteChatSession)
 	chat.Get("/suggestions", handlers.GetChatSuggestions)
+	chat.Get("/actions", handlers.ListChatActions)
+	chat.Post("/actions/:id/confirm", handlers.ConfirmChatAction)
+	chat.Post("/actions/:id/reject", handlers.RejectChatAction)
 
 	// Rotas Admin de Chat (Cache)
 	chatAdmin := api.Group("/admin/chat", middleware.AuthMiddleware, middleware.AdminMiddleware)
 	chatAdmin.Get("/cache/stats", handlers.GetChatCacheStats)
+	chatAdmin.Get("/providers", handlers.GetChatProviderStats)
+	chatAdmin.Get("/actions/policies", handlers.GetChatActionPolicies)
 	chatAdmin.Post("/cache/clear", handlers.ClearChatCache)
 	chatAdmin.Delete("/cache/user/:id", handlers.InvalidateUserCache)
 
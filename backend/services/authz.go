@@ -0,0 +1,315 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== Papéis e permissões ====================
+
+// Papéis do sistema. RoleEmployee mantém o valor legado "user" gravado em users.role.
+const (
+	RoleEmployee = "user"
+	RoleManager  = "manager"
+	RoleHR       = "hr"
+	RolePayroll  = "payroll"
+	RoleAdmin    = "admin"
+	RoleAuditor  = "auditor"
+)
+
+// Recursos protegidos
+const (
+	ResourceVacation  = "vacation"
+	ResourceDocument  = "document"
+	ResourcePayslip   = "payslip"
+	ResourcePDI       = "pdi"
+	ResourceUser      = "user"
+	ResourceOrg       = "org"
+	ResourceAnalytics = "analytics"
+	ResourceAudit     = "audit"
+	ResourceSettings  = "settings"
+)
+
+// Ações sobre os recursos
+const (
+	ActionRead    = "read"    // Consultar dados
+	ActionApprove = "approve" // Decidir solicitações
+	ActionManage  = "manage"  // Criar, alterar e excluir em nome de terceiros
+)
+
+// AccessScope abrangência de uma permissão
+type AccessScope string
+
+const (
+	ScopeNone   AccessScope = ""
+	ScopeOwn    AccessScope = "own"    // Apenas os próprios dados
+	ScopeTeam   AccessScope = "team"   // Próprios dados e subordinados (diretos e indiretos)
+	ScopeFilial AccessScope = "filial" // Colaboradores da mesma filial
+	ScopeAll    AccessScope = "all"    // Toda a empresa
+)
+
+var (
+	ErrAccessDenied = errors.New("acesso negado")
+	ErrInvalidRole  = errors.New("papel inválido")
+)
+
+// Permission recurso + ação
+type Permission struct {
+	Resource string
+	Action   string
+}
+
+// permissionPolicy abrangência concedida a cada papel por permissão; papéis ausentes não têm acesso
+// A abrangência de ActionApprove limita tanto as listagens quanto as decisões do workflow.
+var permissionPolicy = map[Permission]map[string]AccessScope{
+	{ResourceVacation, ActionRead}: {
+		RoleEmployee: ScopeOwn, RoleManager: ScopeTeam, RoleHR: ScopeFilial,
+		RolePayroll: ScopeAll, RoleAdmin: ScopeAll, RoleAuditor: ScopeAll,
+	},
+	{ResourceVacation, ActionApprove}: {RoleManager: ScopeTeam, RoleHR: ScopeFilial, RoleAdmin: ScopeAll},
+	{ResourceVacation, ActionManage}:  {RoleHR: ScopeFilial, RoleAdmin: ScopeAll},
+
+	{ResourceDocument, ActionRead}: {
+		RoleEmployee: ScopeOwn, RoleManager: ScopeOwn, RoleHR: ScopeFilial,
+		RoleAdmin: ScopeAll, RoleAuditor: ScopeAll,
+	},
+	{ResourceDocument, ActionApprove}: {RoleHR: ScopeFilial, RoleAdmin: ScopeAll},
+	{ResourceDocument, ActionManage}:  {RoleHR: ScopeFilial, RoleAdmin: ScopeAll},
+
+	{ResourcePayslip, ActionRead}: {
+		RoleEmployee: ScopeOwn, RoleManager: ScopeOwn, RoleHR: ScopeOwn,
+		RolePayroll: ScopeAll, RoleAdmin: ScopeAll, RoleAuditor: ScopeAll,
+	},
+	{ResourcePayslip, ActionManage}: {RolePayroll: ScopeAll, RoleAdmin: ScopeAll},
+
+	{ResourcePDI, ActionRead}: {
+		RoleEmployee: ScopeOwn, RoleManager: ScopeTeam, RoleHR: ScopeFilial,
+		RoleAdmin: ScopeAll, RoleAuditor: ScopeAll,
+	},
+	{ResourcePDI, ActionApprove}: {RoleManager: ScopeTeam, RoleHR: ScopeFilial, RoleAdmin: ScopeAll},
+	{ResourcePDI, ActionManage}:  {RoleHR: ScopeAll, RoleAdmin: ScopeAll},
+
+	{ResourceUser, ActionRead}: {
+		RoleEmployee: ScopeOwn, RoleManager: ScopeTeam, RoleHR: ScopeFilial,
+		RolePayroll: ScopeAll, RoleAdmin: ScopeAll, RoleAuditor: ScopeAll,
+	},
+	{ResourceUser, ActionManage}: {RoleHR: ScopeFilial, RoleAdmin: ScopeAll},
+
+	{ResourceOrg, ActionRead}:   {RoleManager: ScopeTeam, RoleHR: ScopeAll, RoleAdmin: ScopeAll, RoleAuditor: ScopeAll},
+	{ResourceOrg, ActionManage}: {RoleHR: ScopeAll, RoleAdmin: ScopeAll},
+
+	{ResourceAnalytics, ActionRead}:  {RoleHR: ScopeAll, RoleAdmin: ScopeAll, RoleAuditor: ScopeAll},
+	{ResourceAudit, ActionRead}:      {RoleAdmin: ScopeAll, RoleAuditor: ScopeAll},
+	{ResourceSettings, ActionManage}: {RoleAdmin: ScopeAll},
+}
+
+// Roles papéis válidos, na ordem exibida na administração
+func Roles() []string {
+	return []string{RoleEmployee, RoleManager, RoleHR, RolePayroll, RoleAdmin, RoleAuditor}
+}
+
+// NormalizeRole converte o papel informado para o valor gravado ("employee" e vazio viram "user")
+func NormalizeRole(role string) (string, error) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	if role == "" || role == "employee" {
+		return RoleEmployee, nil
+	}
+	for _, r := range Roles() {
+		if r == role {
+			return r, nil
+		}
+	}
+	return "", ErrInvalidRole
+}
+
+// PolicyScope retorna a abrangência que o papel tem na permissão (ScopeNone quando não tem)
+func PolicyScope(role, resource, action string) AccessScope {
+	role, err := NormalizeRole(role)
+	if err != nil {
+		return ScopeNone
+	}
+	return permissionPolicy[Permission{resource, action}][role]
+}
+
+// Permissions lista as permissões do papel com a respectiva abrangência ("recurso:ação" -> escopo)
+func Permissions(role string) map[string]AccessScope {
+	perms := map[string]AccessScope{}
+	for perm := range permissionPolicy {
+		if scope := PolicyScope(role, perm.Resource, perm.Action); scope != ScopeNone {
+			perms[perm.Resource+":"+perm.Action] = scope
+		}
+	}
+	return perms
+}
+
+// Access permissão resolvida para um usuário. Para own/team/filial guarda os
+// usuários alcançados (incluindo o próprio); para all não há restrição.
+type Access struct {
+	UserID   string      `json:"user_id"`
+	Role     string      `json:"role"`
+	Resource string      `json:"resource"`
+	Action   string      `json:"action"`
+	Scope    AccessScope `json:"scope"`
+	userIDs  []string
+}
+
+// OwnAccess acesso restrito aos dados do próprio usuário
+func OwnAccess(userID string) *Access {
+	return &Access{UserID: userID, Scope: ScopeOwn, userIDs: []string{userID}}
+}
+
+// All indica acesso irrestrito
+func (a *Access) All() bool {
+	return a.Scope == ScopeAll
+}
+
+// UserIDs retorna os usuários alcançados (nil quando o acesso é irrestrito)
+func (a *Access) UserIDs() []string {
+	if a.All() {
+		return nil
+	}
+	return a.userIDs
+}
+
+// Allows verifica se o acesso alcança os dados do usuário informado
+func (a *Access) Allows(userID string) bool {
+	if a.All() {
+		return true
+	}
+	for _, id := range a.userIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply restringe a consulta à coluna de usuário conforme a abrangência
+func (a *Access) Apply(db *gorm.DB, column string) *gorm.DB {
+	if a.All() {
+		return db
+	}
+	return db.Where(column+" IN ?", a.userIDs)
+}
+
+// AuthzService resolve papéis e abrangências usando o organograma e a filial do colaborador
+type AuthzService struct {
+	RoleOf        func(userID string) (string, error)
+	IsManager     func(userID string) bool
+	ReportsOf     func(userID string) []string
+	FilialMembers func(userID string) []string
+}
+
+// Authz autorização compartilhada pelo middleware REST e pela diretiva GraphQL
+var Authz = NewAuthzService()
+
+// NewAuthzService cria o serviço de autorização
+func NewAuthzService() *AuthzService {
+	return &AuthzService{
+		RoleOf:        userRole,
+		IsManager:     func(userID string) bool { return Org.IsManager(userID) },
+		ReportsOf:     func(userID string) []string { return Org.AllReports(userID) },
+		FilialMembers: filialMembers,
+	}
+}
+
+// EffectiveRole retorna o papel usado na autorização: colaboradores com
+// subordinados no organograma recebem as permissões de gestor
+func (s *AuthzService) EffectiveRole(userID, role string) string {
+	role, err := NormalizeRole(role)
+	if err != nil {
+		return ""
+	}
+	if role == RoleEmployee && s.IsManager(userID) {
+		return RoleManager
+	}
+	return role
+}
+
+// Resolve carrega o papel atual do usuário e resolve a permissão
+func (s *AuthzService) Resolve(userID, resource, action string) (*Access, error) {
+	role, err := s.RoleOf(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.ResolveRole(userID, role, resource, action)
+}
+
+// ResolveRole resolve a permissão para um papel já conhecido
+func (s *AuthzService) ResolveRole(userID, role, resource, action string) (*Access, error) {
+	role = s.EffectiveRole(userID, role)
+	access := &Access{
+		UserID:   userID,
+		Role:     role,
+		Resource: resource,
+		Action:   action,
+		Scope:    PolicyScope(role, resource, action),
+	}
+
+	switch access.Scope {
+	case ScopeNone:
+		return nil, ErrAccessDenied
+	case ScopeOwn:
+		access.userIDs = []string{userID}
+	case ScopeTeam:
+		access.userIDs = appendUnique([]string{userID}, s.ReportsOf(userID)...)
+	case ScopeFilial:
+		access.userIDs = appendUnique([]string{userID}, s.FilialMembers(userID)...)
+	}
+	return access, nil
+}
+
+// Can verifica se o usuário tem a permissão sobre os dados de outro usuário
+func (s *AuthzService) Can(userID, resource, action, targetUserID string) bool {
+	access, err := s.Resolve(userID, resource, action)
+	return err == nil && access.Allows(targetUserID)
+}
+
+func appendUnique(ids []string, more ...string) []string {
+	seen := make(map[string]bool, len(ids)+len(more))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	for _, id := range more {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// userRole busca o papel atual no banco (o token pode estar desatualizado)
+func userRole(userID string) (string, error) {
+	var user models.User
+	if err := config.DB.Select("id", "role").First(&user, "id = ?", userID).Error; err != nil {
+		return "", err
+	}
+	return user.Role, nil
+}
+
+// filialMembers retorna os usuários ativos lotados na mesma filial do colaborador
+func filialMembers(userID string) []string {
+	filial := GetUserFilial(userID)
+	if filial == "" {
+		return nil
+	}
+
+	var ids []string
+	config.DB.Raw(`
+		SELECT u.id
+		FROM users u
+		WHERE u.deleted_at IS NULL
+		AND REPLACE(REPLACE(REPLACE(u.cpf, '.', ''), '-', ''), ' ', '') IN (
+			SELECT REPLACE(REPLACE(REPLACE(p.Cpf, '.', ''), '-', ''), ' ', '')
+			FROM dbo.ColaboradoresFradema c
+			INNER JOIN dbo.PessoasFisicasFradema p ON c.PessoaFisicaId = p.Id
+			WHERE c.Ativo = 1 AND LTRIM(RTRIM(c.Filial)) = ?
+		)
+	`, filial).Scan(&ids)
+	return ids
+}
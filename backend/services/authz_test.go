@@ -0,0 +1,84 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAuthz() *AuthzService {
+	return &AuthzService{
+		RoleOf:    func(userID string) (string, error) { return RoleEmployee, nil },
+		IsManager: func(userID string) bool { return userID == "gestor" },
+		ReportsOf: func(userID string) []string {
+			if userID == "gestor" {
+				return []string{"analista", "assistente"}
+			}
+			return nil
+		},
+		FilialMembers: func(userID string) []string { return []string{"rh", "analista", "operador"} },
+	}
+}
+
+func TestNormalizeRole(t *testing.T) {
+	for input, expected := range map[string]string{"": RoleEmployee, "employee": RoleEmployee, "user": RoleEmployee, " HR ": RoleHR, "auditor": RoleAuditor} {
+		role, err := NormalizeRole(input)
+		require.NoError(t, err)
+		assert.Equal(t, expected, role)
+	}
+
+	_, err := NormalizeRole("superuser")
+	assert.ErrorIs(t, err, ErrInvalidRole)
+}
+
+func TestPolicyScope(t *testing.T) {
+	assert.Equal(t, ScopeOwn, PolicyScope("employee", ResourceVacation, ActionRead))
+	assert.Equal(t, ScopeTeam, PolicyScope(RoleManager, ResourceVacation, ActionApprove))
+	assert.Equal(t, ScopeFilial, PolicyScope(RoleHR, ResourceDocument, ActionApprove))
+	assert.Equal(t, ScopeAll, PolicyScope(RolePayroll, ResourcePayslip, ActionManage))
+
+	// Auditor só consulta; gestor não vê holerites da equipe
+	assert.Equal(t, ScopeAll, PolicyScope(RoleAuditor, ResourceAudit, ActionRead))
+	assert.Equal(t, ScopeNone, PolicyScope(RoleAuditor, ResourceVacation, ActionApprove))
+	assert.Equal(t, ScopeOwn, PolicyScope(RoleManager, ResourcePayslip, ActionRead))
+	assert.Equal(t, ScopeNone, PolicyScope(RoleEmployee, ResourcePDI, ActionApprove))
+	assert.Equal(t, ScopeNone, PolicyScope("superuser", ResourceVacation, ActionRead))
+}
+
+func TestResolveRoleScopes(t *testing.T) {
+	authz := newTestAuthz()
+
+	// Colaborador com subordinados no organograma recebe as permissões de gestor
+	access, err := authz.ResolveRole("gestor", RoleEmployee, ResourcePDI, ActionApprove)
+	require.NoError(t, err)
+	assert.Equal(t, RoleManager, access.Role)
+	assert.Equal(t, ScopeTeam, access.Scope)
+	assert.ElementsMatch(t, []string{"gestor", "analista", "assistente"}, access.UserIDs())
+	assert.True(t, access.Allows("assistente"))
+	assert.False(t, access.Allows("operador"))
+
+	_, err = authz.ResolveRole("analista", RoleEmployee, ResourcePDI, ActionApprove)
+	assert.ErrorIs(t, err, ErrAccessDenied)
+
+	access, err = authz.ResolveRole("rh", RoleHR, ResourceVacation, ActionManage)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"rh", "analista", "operador"}, access.UserIDs())
+
+	access, err = authz.ResolveRole("admin", RoleAdmin, ResourceVacation, ActionManage)
+	require.NoError(t, err)
+	assert.True(t, access.All())
+	assert.Nil(t, access.UserIDs())
+	assert.True(t, access.Allows("qualquer"))
+
+	own := OwnAccess("analista")
+	assert.True(t, own.Allows("analista"))
+	assert.False(t, own.Allows("gestor"))
+}
+
+func TestPermissionsList(t *testing.T) {
+	perms := Permissions(RoleAuditor)
+	assert.Equal(t, ScopeAll, perms["audit:read"])
+	assert.NotContains(t, perms, "vacation:manage")
+	assert.NotContains(t, Permissions(RoleEmployee), "analytics:read")
+}
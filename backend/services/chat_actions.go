@@ -0,0 +1,377 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== CONFIRMAÇÃO DAS AÇÕES DO ASSISTENTE ====================
+
+var (
+	ErrChatActionNotFound    = errors.New("ação não encontrada")
+	ErrChatActionExpired     = errors.New("o prazo para confirmar esta ação terminou; peça novamente ao assistente")
+	ErrChatActionDecided     = errors.New("esta ação já foi confirmada ou recusada")
+	ErrChatActionKeyRequired = errors.New("cabeçalho Idempotency-Key obrigatório")
+)
+
+// chatActionTTL prazo padrão para o colaborador confirmar uma ação proposta
+const chatActionTTL = 15 * time.Minute
+
+// ChatActionPolicy regra de uma função de escrita do assistente
+type ChatActionPolicy struct {
+	Function             string        `json:"function"`
+	Title                string        `json:"title"`
+	RequiresConfirmation bool          `json:"requires_confirmation"`
+	TTL                  time.Duration `json:"-"`
+	TTLMinutes           int           `json:"ttl_minutes"`
+
+	describe func(arguments string) string // Resumo do cartão a partir dos argumentos do modelo
+}
+
+// chatActionPolicies funções que alteram dados e se precisam da confirmação do colaborador.
+// Funções de escrita sem política aqui também exigem confirmação (ver chatActionPolicy).
+var chatActionPolicies = map[string]ChatActionPolicy{
+	"request_vacation": {
+		Title:                "Solicitar férias",
+		RequiresConfirmation: true,
+		describe: func(arguments string) string {
+			var params struct {
+				StartDate string `json:"start_date"`
+				EndDate   string `json:"end_date"`
+				Reason    string `json:"reason"`
+			}
+			json.Unmarshal([]byte(arguments), &params)
+			summary := fmt.Sprintf("Férias de %s a %s", formatISODate(params.StartDate), formatISODate(params.EndDate))
+			if params.Reason != "" {
+				summary += " — " + params.Reason
+			}
+			return summary
+		},
+	},
+	"sell_vacation_days": {
+		Title:                "Vender dias de férias",
+		RequiresConfirmation: true,
+		describe: func(arguments string) string {
+			var params struct {
+				Days int `json:"days"`
+			}
+			json.Unmarshal([]byte(arguments), &params)
+			return fmt.Sprintf("Vender %d dias de férias (abono pecuniário)", params.Days)
+		},
+	},
+	"cancel_vacation": {
+		Title:                "Cancelar férias",
+		RequiresConfirmation: true,
+		describe: func(arguments string) string {
+			var params struct {
+				VacationID string `json:"vacation_id"`
+			}
+			json.Unmarshal([]byte(arguments), &params)
+			return "Cancelar a solicitação de férias " + shortID(params.VacationID)
+		},
+	},
+	"enroll_in_course": {
+		Title:                "Inscrever-se em curso",
+		RequiresConfirmation: true,
+		TTL:                  time.Hour,
+		describe: func(arguments string) string {
+			var params struct {
+				CourseID string `json:"course_id"`
+			}
+			json.Unmarshal([]byte(arguments), &params)
+			return "Inscrição no curso " + shortID(params.CourseID)
+		},
+	},
+	"approve_vacation": {
+		Title:                "Decidir solicitação de férias",
+		RequiresConfirmation: true,
+		TTL:                  10 * time.Minute,
+		describe: func(arguments string) string {
+			var params struct {
+				VacationID string `json:"vacation_id"`
+				Action     string `json:"action"`
+				Comment    string `json:"comment"`
+			}
+			json.Unmarshal([]byte(arguments), &params)
+			verb := "Aprovar"
+			if params.Action == "reject" {
+				verb = "Reprovar"
+			}
+			summary := fmt.Sprintf("%s a solicitação de férias %s", verb, shortID(params.VacationID))
+			if params.Comment != "" {
+				summary += " — " + params.Comment
+			}
+			return summary
+		},
+	},
+}
+
+// chatActionPolicy política da função; escrita sem política cadastrada exige confirmação
+func chatActionPolicy(functionName string) ChatActionPolicy {
+	policy, ok := chatActionPolicies[functionName]
+	if !ok {
+		policy = ChatActionPolicy{Title: functionName, RequiresConfirmation: ChatTools.IsWrite(functionName)}
+	}
+	policy.Function = functionName
+	if policy.TTL == 0 {
+		policy.TTL = chatActionTTL
+	}
+	policy.TTLMinutes = int(policy.TTL.Minutes())
+	return policy
+}
+
+// RequiresConfirmation indica se a função só roda após a confirmação do colaborador
+func RequiresConfirmation(functionName string) bool {
+	return chatActionPolicy(functionName).RequiresConfirmation
+}
+
+// ChatActionPolicies tabela de políticas, em ordem alfabética
+func ChatActionPolicies() []ChatActionPolicy {
+	policies := make([]ChatActionPolicy, 0, len(chatActionPolicies))
+	for name := range chatActionPolicies {
+		policies = append(policies, chatActionPolicy(name))
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Function < policies[j].Function })
+	return policies
+}
+
+func formatISODate(value string) string {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t.Format("02/01/2006")
+	}
+	return value
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// ==================== Sessão do chat no contexto ====================
+
+type chatSessionKey struct{}
+
+// WithChatSession associa a sessão do chat ao contexto, para as ações propostas
+// aparecerem no histórico da conversa
+func WithChatSession(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, chatSessionKey{}, sessionID)
+}
+
+func chatSessionFrom(ctx context.Context) string {
+	sessionID, _ := ctx.Value(chatSessionKey{}).(string)
+	return sessionID
+}
+
+// ==================== Serviço ====================
+
+// ChatActionService registra as ações propostas pelo assistente e as executa quando o
+// colaborador confirma
+type ChatActionService struct {
+	now     func() time.Time
+	execute func(userID, functionName, arguments string) (*FunctionResult, error)
+}
+
+// ChatActions instância usada pelo chat
+var ChatActions = NewChatActionService()
+
+// NewChatActionService cria o serviço de ações do chat
+func NewChatActionService() *ChatActionService {
+	return &ChatActionService{now: time.Now, execute: ChatTools.Execute}
+}
+
+// newProposal monta a ação pendente a partir da chamada de ferramenta do modelo
+func (s *ChatActionService) newProposal(userID, sessionID string, call LLMToolCall) models.ChatPendingAction {
+	policy := chatActionPolicy(call.Function.Name)
+	arguments := call.Function.Arguments
+	if !json.Valid([]byte(arguments)) {
+		arguments = "{}"
+	}
+	summary := policy.Title
+	if policy.describe != nil {
+		summary = policy.describe(arguments)
+	}
+	return models.ChatPendingAction{
+		UserID:       userID,
+		SessionID:    sessionID,
+		ToolCallID:   call.ID,
+		FunctionName: call.Function.Name,
+		Arguments:    arguments,
+		Title:        policy.Title,
+		Summary:      truncateText(summary, 500),
+		Status:       models.ChatActionPending,
+		ExpiresAt:    s.now().Add(policy.TTL),
+	}
+}
+
+// Propose grava a ação pendente em vez de executá-la. Ferramentas que o usuário não
+// pode usar não viram cartão.
+func (s *ChatActionService) Propose(ctx context.Context, userID string, call LLMToolCall) (*models.ChatPendingAction, error) {
+	if _, err := ChatTools.Authorize(userID, call.Function.Name); err != nil {
+		return nil, err
+	}
+	action := s.newProposal(userID, chatSessionFrom(ctx), call)
+	if err := config.DB.WithContext(ctx).Create(&action).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("📝 Chat: ação %s proposta (%s) aguardando confirmação", action.FunctionName, shortID(action.ID))
+	return &action, nil
+}
+
+// proposalResult resultado devolvido ao modelo: a ação não foi executada
+func proposalResult(action *models.ChatPendingAction) string {
+	data, _ := json.Marshal(FunctionResult{
+		Success: true,
+		Data: map[string]interface{}{
+			"action_id":  action.ID,
+			"status":     "awaiting_confirmation",
+			"summary":    action.Summary,
+			"expires_at": action.ExpiresAt,
+		},
+		Message: "A ação ainda NÃO foi executada. O colaborador precisa confirmar no cartão exibido no chat. " +
+			"Resuma o que será feito e peça a confirmação; não diga que foi concluída.",
+	})
+	return string(data)
+}
+
+// find busca a ação do colaborador
+func (s *ChatActionService) find(userID, actionID string) (*models.ChatPendingAction, error) {
+	var action models.ChatPendingAction
+	if err := config.DB.Where("id = ? AND user_id = ?", actionID, userID).First(&action).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChatActionNotFound
+		}
+		return nil, err
+	}
+	return &action, nil
+}
+
+// replayOf indica se a ação já decidida foi confirmada com a mesma chave (repetição do
+// mesmo pedido, que devolve o resultado gravado)
+func replayOf(action *models.ChatPendingAction, idempotencyKey string) bool {
+	switch action.Status {
+	case models.ChatActionConfirmed, models.ChatActionExecuted, models.ChatActionFailed:
+		return action.IdempotencyKey == idempotencyKey
+	}
+	return false
+}
+
+// Confirm executa a ação proposta. Repetir a confirmação com a mesma Idempotency-Key
+// devolve o resultado gravado sem executar de novo (replayed = true).
+func (s *ChatActionService) Confirm(userID, actionID, idempotencyKey, ip string) (action *models.ChatPendingAction, replayed bool, err error) {
+	if idempotencyKey == "" {
+		return nil, false, ErrChatActionKeyRequired
+	}
+	action, err = s.find(userID, actionID)
+	if err != nil {
+		return nil, false, err
+	}
+	if replayOf(action, idempotencyKey) {
+		return action, true, nil
+	}
+	if action.Status != models.ChatActionPending {
+		return action, false, ErrChatActionDecided
+	}
+
+	now := s.now()
+	if !action.Confirmable(now) {
+		config.DB.Model(action).Where("status = ?", models.ChatActionPending).Update("status", models.ChatActionExpired)
+		action.Status = models.ChatActionExpired
+		return action, false, ErrChatActionExpired
+	}
+
+	// Reserva a ação: com cliques simultâneos só uma confirmação executa
+	res := config.DB.Model(&models.ChatPendingAction{}).
+		Where("id = ? AND status = ?", action.ID, models.ChatActionPending).
+		Updates(map[string]interface{}{
+			"status":          models.ChatActionConfirmed,
+			"decided_at":      now,
+			"decided_ip":      ip,
+			"idempotency_key": idempotencyKey,
+		})
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		if err := config.DB.First(action, "id = ?", action.ID).Error; err != nil {
+			return nil, false, err
+		}
+		if replayOf(action, idempotencyKey) {
+			return action, true, nil
+		}
+		return action, false, ErrChatActionDecided
+	}
+	action.Status, action.DecidedAt, action.DecidedIP, action.IdempotencyKey = models.ChatActionConfirmed, &now, ip, idempotencyKey
+
+	result, execErr := s.execute(userID, action.FunctionName, action.Arguments)
+	if execErr != nil {
+		log.Printf("❌ Chat: erro ao executar ação %s: %v", action.FunctionName, execErr)
+		result = &FunctionResult{Success: false, Error: execErr.Error()}
+	}
+	action.Status = models.ChatActionExecuted
+	if !result.Success {
+		action.Status = models.ChatActionFailed
+	}
+	data, _ := json.Marshal(result)
+	action.Result = string(data)
+	if err := config.DB.Model(action).Updates(map[string]interface{}{"status": action.Status, "result": action.Result}).Error; err != nil {
+		log.Printf("❌ Chat: erro ao gravar resultado da ação %s: %v", action.ID, err)
+	}
+
+	// Saldos e listas consultados pelo assistente mudaram
+	go NewChatCache().InvalidateFunctionCache(userID)
+	return action, false, nil
+}
+
+// Reject recusa a ação proposta; recusar de novo não é erro (replayed = true)
+func (s *ChatActionService) Reject(userID, actionID, ip string) (action *models.ChatPendingAction, replayed bool, err error) {
+	action, err = s.find(userID, actionID)
+	if err != nil {
+		return nil, false, err
+	}
+	if action.Status == models.ChatActionRejected {
+		return action, true, nil
+	}
+	if action.Status != models.ChatActionPending {
+		return action, false, ErrChatActionDecided
+	}
+
+	now := s.now()
+	res := config.DB.Model(&models.ChatPendingAction{}).
+		Where("id = ? AND status = ?", action.ID, models.ChatActionPending).
+		Updates(map[string]interface{}{"status": models.ChatActionRejected, "decided_at": now, "decided_ip": ip})
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return action, false, ErrChatActionDecided
+	}
+	action.Status, action.DecidedAt, action.DecidedIP = models.ChatActionRejected, &now, ip
+	return action, false, nil
+}
+
+// List ações do colaborador (opcionalmente de uma sessão), mais recentes primeiro.
+// Pendentes vencidas são marcadas como expiradas.
+func (s *ChatActionService) List(userID, sessionID string) ([]models.ChatPendingAction, error) {
+	config.DB.Model(&models.ChatPendingAction{}).
+		Where("user_id = ? AND status = ? AND expires_at <= ?", userID, models.ChatActionPending, s.now()).
+		Update("status", models.ChatActionExpired)
+
+	query := config.DB.Where("user_id = ?", userID)
+	if sessionID != "" {
+		query = query.Where("session_id = ?", sessionID)
+	}
+	var actions []models.ChatPendingAction
+	err := query.Order("created_at DESC").Limit(50).Find(&actions).Error
+	return actions, err
+}
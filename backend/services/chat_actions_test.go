@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatActionPolicies(t *testing.T) {
+	for _, name := range []string{"request_vacation", "sell_vacation_days", "cancel_vacation", "enroll_in_course", "approve_vacation"} {
+		assert.True(t, RequiresConfirmation(name), name)
+	}
+	assert.False(t, RequiresConfirmation("get_vacation_balance"))
+	assert.False(t, RequiresConfirmation("clock_punch"), "função não registrada")
+
+	policies := ChatActionPolicies()
+	require.Len(t, policies, 5)
+	assert.Equal(t, "approve_vacation", policies[0].Function)
+	assert.Equal(t, 10, policies[0].TTLMinutes)
+}
+
+func TestChatActionProposal(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	service := &ChatActionService{now: func() time.Time { return now }}
+	ctx := WithChatSession(context.Background(), "sessao-1")
+
+	action := service.newProposal("user-1", chatSessionFrom(ctx), toolCall("call_1", "request_vacation",
+		`{"start_date":"2026-11-03","end_date":"2026-11-17","reason":"Viagem"}`))
+	assert.Equal(t, "sessao-1", action.SessionID)
+	assert.Equal(t, "Solicitar férias", action.Title)
+	assert.Equal(t, "Férias de 03/11/2026 a 17/11/2026 — Viagem", action.Summary)
+	assert.Equal(t, models.ChatActionPending, action.Status)
+	assert.Equal(t, now.Add(chatActionTTL), action.ExpiresAt)
+	assert.True(t, action.Confirmable(now))
+	assert.False(t, action.Confirmable(now.Add(chatActionTTL)))
+
+	action = service.newProposal("user-1", "", toolCall("call_2", "approve_vacation", `{"vacation_id":"0123456789abcdef","action":"reject"}`))
+	assert.Equal(t, "Reprovar a solicitação de férias 01234567", action.Summary)
+	assert.Equal(t, now.Add(10*time.Minute), action.ExpiresAt)
+
+	action = service.newProposal("user-1", "", toolCall("call_3", "sell_vacation_days", `{"days":`))
+	assert.Equal(t, "{}", action.Arguments, "argumentos inválidos não são gravados")
+}
+
+func TestChatActionReplay(t *testing.T) {
+	action := &models.ChatPendingAction{Status: models.ChatActionExecuted, IdempotencyKey: "chave-1"}
+	assert.True(t, replayOf(action, "chave-1"))
+	assert.False(t, replayOf(action, "chave-2"), "outra confirmação da mesma ação")
+
+	action.Status = models.ChatActionRejected
+	assert.False(t, replayOf(action, "chave-1"))
+}
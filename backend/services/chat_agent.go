@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/frappyou/backend/models"
+)
+
+// ==================== LOOP DO ASSISTENTE (TOOLS) ====================
+
+// ChatTurn resultado de uma pergunta ao assistente
+type ChatTurn struct {
+	Content   string
+	Usage     LLMUsage
+	Steps     int                        // Chamadas ao modelo
+	ToolCalls int                        // Ferramentas executadas
+	Messages  []LLMMessage               // Pedidos de ferramenta e resultados, na ordem, para o histórico
+	Actions   []models.ChatPendingAction // Ações de escrita aguardando a confirmação do colaborador
+}
+
+// ChatAgent executa o loop do assistente: o modelo pede ferramentas, elas rodam (as de
+// consulta em paralelo) e os resultados voltam ao modelo até a resposta final. Depois
+// de maxSteps rodadas o modelo é chamado sem ferramentas e precisa responder.
+// Funções que exigem confirmação (RequiresConfirmation) não rodam: propose grava a ação
+// pendente e o modelo é avisado de que o colaborador precisa confirmar.
+type ChatAgent struct {
+	maxSteps    int
+	maxParallel int
+	execute     func(ctx context.Context, userID string, call LLMToolCall) string
+	propose     func(ctx context.Context, userID string, call LLMToolCall) (*models.ChatPendingAction, error)
+}
+
+// ChatAssistant instância global do loop do chat
+var ChatAssistant = &ChatAgent{maxSteps: 4, maxParallel: 4, execute: executeToolCall, propose: ChatActions.Propose}
+
+// chatIncompleteAnswer resposta quando o modelo encerra sem texto após usar ferramentas
+const chatIncompleteAnswer = "Desculpe, não consegui concluir sua solicitação. Tente novamente."
+
+// Run responde ao pedido. Com onContent o texto é enviado em streaming conforme chega.
+// Em caso de erro o ChatTurn parcial traz as ferramentas já executadas.
+func (a *ChatAgent) Run(ctx context.Context, llm LLMClient, userID string, req LLMRequest, onContent func(string)) (*ChatTurn, error) {
+	turn := &ChatTurn{}
+	if llm == nil {
+		return turn, ErrLLMNotConfigured
+	}
+
+	messages := append([]LLMMessage(nil), req.Messages...)
+	for {
+		step := req
+		step.Messages = messages
+		if turn.Steps >= a.maxSteps {
+			step.Tools = nil
+		}
+
+		var resp *LLMResponse
+		var err error
+		if onContent != nil {
+			resp, err = llm.Stream(ctx, step, onContent)
+		} else {
+			resp, err = llm.Complete(ctx, step)
+		}
+		if err != nil {
+			return turn, err
+		}
+		turn.Steps++
+		turn.Usage.Add(resp.Usage)
+
+		if len(resp.ToolCalls) == 0 || len(step.Tools) == 0 {
+			turn.Content = resp.Content
+			if turn.Content == "" && turn.ToolCalls > 0 {
+				turn.Content = chatIncompleteAnswer
+				if onContent != nil {
+					onContent(turn.Content)
+				}
+			}
+			return turn, nil
+		}
+
+		calls := resp.ToolCalls
+		for i := range calls {
+			if calls[i].ID == "" {
+				calls[i].ID = fmt.Sprintf("call_%d_%d", turn.Steps, i) // Servidores que não geram id
+			}
+			calls[i].Type = "function"
+		}
+		request := LLMMessage{Role: "assistant", Content: resp.Content, ToolCalls: calls}
+		results, actions := a.runTools(ctx, userID, calls)
+		turn.ToolCalls += len(calls)
+		turn.Actions = append(turn.Actions, actions...)
+		turn.Messages = append(turn.Messages, request)
+		turn.Messages = append(turn.Messages, results...)
+		messages = append(messages, request)
+		messages = append(messages, results...)
+	}
+}
+
+// runTools executa as chamadas de uma rodada: consultas em paralelo, escritas uma por
+// vez (ex.: duas solicitações de férias não concorrem). Os resultados seguem a ordem
+// das chamadas. Escritas que exigem confirmação viram ações pendentes.
+func (a *ChatAgent) runTools(ctx context.Context, userID string, calls []LLMToolCall) ([]LLMMessage, []models.ChatPendingAction) {
+	results := make([]LLMMessage, len(calls))
+	var actions []models.ChatPendingAction
+	run := func(i int) {
+		call := calls[i]
+		results[i] = LLMMessage{Role: "tool", ToolCallID: call.ID, Name: call.Function.Name, Content: a.safeExecute(ctx, userID, call)}
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, max(1, a.maxParallel))
+	for i, call := range calls {
+		if ChatTools.IsWrite(call.Function.Name) {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			run(i)
+		}(i)
+	}
+	for i, call := range calls {
+		switch {
+		case a.propose != nil && RequiresConfirmation(call.Function.Name):
+			content := toolError("não foi possível registrar a ação para confirmação")
+			if action, err := a.propose(ctx, userID, call); errors.Is(err, ErrChatToolDenied) {
+				content = toolError(err.Error())
+			} else if err != nil {
+				log.Printf("❌ Chat: erro ao propor ação %s: %v", call.Function.Name, err)
+			} else {
+				content = proposalResult(action)
+				actions = append(actions, *action)
+			}
+			results[i] = LLMMessage{Role: "tool", ToolCallID: call.ID, Name: call.Function.Name, Content: content}
+		case ChatTools.IsWrite(call.Function.Name):
+			run(i)
+		}
+	}
+	wg.Wait()
+	return results, actions
+}
+
+// safeExecute executa a ferramenta; pânico vira erro para o modelo em vez de derrubar o servidor
+func (a *ChatAgent) safeExecute(ctx context.Context, userID string, call LLMToolCall) (result string) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ Chat: pânico na ferramenta %s: %v", call.Function.Name, r)
+			result = toolError("erro interno ao executar a ferramenta")
+		}
+	}()
+	if err := ctx.Err(); err != nil {
+		return toolError(err.Error())
+	}
+	return a.execute(ctx, userID, call)
+}
+
+// executeToolCall executa a função (com o cache das consultas) e devolve o resultado em
+// JSON. Erros voltam ao modelo para ele explicar ao colaborador.
+func executeToolCall(ctx context.Context, userID string, call LLMToolCall) string {
+	cache := NewChatCache()
+	name, arguments := call.Function.Name, call.Function.Arguments
+
+	// A permissão vale também para o cache: um resultado guardado antes de o papel mudar
+	// não pode ser devolvido a quem perdeu o acesso
+	if _, refusal := ChatTools.authorizeCall(userID, name); refusal != nil {
+		resultJSON, _ := json.Marshal(refusal)
+		return string(resultJSON)
+	}
+
+	// Tenta buscar do cache primeiro
+	if cached, _ := cache.GetFunctionResult(name, userID, arguments); cached != nil {
+		log.Printf("✅ Cache HIT: função %s", name)
+		resultJSON, _ := json.Marshal(cached.Result)
+		return string(resultJSON)
+	}
+
+	log.Printf("🔧 Executando função: %s com args: %s", name, arguments)
+	result, err := ChatTools.Execute(userID, name, arguments)
+	if err != nil {
+		log.Printf("❌ Erro ao executar função %s: %v", name, err)
+		return toolError(err.Error())
+	}
+
+	// Cacheia o resultado
+	go cache.SetFunctionResult(name, userID, arguments, result)
+
+	resultJSON, _ := json.Marshal(result)
+	return string(resultJSON)
+}
+
+func toolError(message string) string {
+	data, _ := json.Marshal(FunctionResult{Success: false, Error: message})
+	return string(data)
+}
+
+// CompleteToolExchanges prepara o histórico salvo para o modelo: o corte nas últimas
+// mensagens pode separar um pedido de ferramenta dos resultados, e a API recusa pedidos
+// sem resultado e resultados sem pedido
+func CompleteToolExchanges(messages []LLMMessage) []LLMMessage {
+	var out []LLMMessage
+	for i := 0; i < len(messages); i++ {
+		msg := messages[i]
+		if msg.Role == "tool" {
+			continue // Resultado cujo pedido ficou fora do histórico
+		}
+		if len(msg.ToolCalls) == 0 {
+			out = append(out, msg)
+			continue
+		}
+
+		pending := map[string]bool{}
+		for _, call := range msg.ToolCalls {
+			pending[call.ID] = true
+		}
+		var results []LLMMessage
+		j := i + 1
+		for ; j < len(messages) && messages[j].Role == "tool"; j++ {
+			if pending[messages[j].ToolCallID] {
+				delete(pending, messages[j].ToolCallID)
+				results = append(results, messages[j])
+			}
+		}
+		switch {
+		case len(pending) == 0:
+			out = append(out, msg)
+			out = append(out, results...)
+		case msg.Content != "":
+			msg.ToolCalls = nil
+			out = append(out, msg)
+		}
+		i = j - 1
+	}
+	return out
+}
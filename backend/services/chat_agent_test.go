@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func toolCall(id, name, arguments string) LLMToolCall {
+	return LLMToolCall{ID: id, Type: "function", Function: LLMFunctionCall{Name: name, Arguments: arguments}}
+}
+
+func agentRequest() LLMRequest {
+	return LLMRequest{
+		Messages: []LLMMessage{{Role: "system", Content: "sistema"}, {Role: "user", Content: "Qual meu saldo e meu último holerite?"}},
+		Tools:    []FunctionDefinition{{Name: "get_vacation_balance"}, {Name: "get_last_payslip"}},
+	}
+}
+
+func TestChatAgentRunsParallelToolCalls(t *testing.T) {
+	// As duas consultas só terminam se rodarem ao mesmo tempo
+	var started sync.WaitGroup
+	started.Add(2)
+	agent := &ChatAgent{maxSteps: 4, maxParallel: 4, execute: func(ctx context.Context, userID string, call LLMToolCall) string {
+		started.Done()
+		started.Wait()
+		if call.Function.Name == "get_last_payslip" {
+			time.Sleep(10 * time.Millisecond) // Termina depois, mas o resultado mantém a ordem
+		}
+		return `{"tool":"` + call.Function.Name + `"}`
+	}}
+	llm := &ScriptedLLM{Replies: []ScriptedReply{
+		{Response: LLMResponse{ToolCalls: []LLMToolCall{
+			toolCall("call_1", "get_last_payslip", "{}"),
+			toolCall("call_2", "get_vacation_balance", "{}"),
+		}}},
+		{Response: LLMResponse{Content: "Você tem 20 dias e o holerite de setembro."}},
+	}}
+
+	turn, err := agent.Run(context.Background(), llm, "user-1", agentRequest(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Você tem 20 dias e o holerite de setembro.", turn.Content)
+	assert.Equal(t, 2, turn.Steps)
+	assert.Equal(t, 2, turn.ToolCalls)
+
+	require.Len(t, turn.Messages, 3)
+	assert.Equal(t, "assistant", turn.Messages[0].Role)
+	assert.Len(t, turn.Messages[0].ToolCalls, 2)
+	assert.Equal(t, LLMMessage{Role: "tool", ToolCallID: "call_1", Name: "get_last_payslip", Content: `{"tool":"get_last_payslip"}`}, turn.Messages[1])
+	assert.Equal(t, "call_2", turn.Messages[2].ToolCallID)
+
+	// A segunda chamada ao modelo recebe os pedidos e os resultados
+	requests := llm.Requests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, append(agentRequest().Messages, turn.Messages...), requests[1].Messages)
+}
+
+func TestChatAgentRunsWritesSequentially(t *testing.T) {
+	var running, overlaps int32
+	agent := &ChatAgent{maxSteps: 4, maxParallel: 4, execute: func(ctx context.Context, userID string, call LLMToolCall) string {
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return "{}"
+	}}
+
+	results, _ := agent.runTools(context.Background(), "user-1", []LLMToolCall{
+		toolCall("a", "request_vacation", "{}"),
+		toolCall("b", "sell_vacation_days", "{}"),
+		toolCall("c", "cancel_vacation", "{}"),
+	})
+	assert.Len(t, results, 3)
+	assert.Zero(t, atomic.LoadInt32(&overlaps), "escritas não concorrem")
+}
+
+func TestChatAgentBoundsSteps(t *testing.T) {
+	calls := 0
+	agent := &ChatAgent{maxSteps: 2, maxParallel: 1, execute: func(ctx context.Context, userID string, call LLMToolCall) string {
+		calls++
+		return "{}"
+	}}
+	loop := LLMResponse{ToolCalls: []LLMToolCall{toolCall("", "get_vacation_balance", "{}")}}
+	llm := &ScriptedLLM{Replies: []ScriptedReply{{Response: loop}, {Response: loop}, {Response: LLMResponse{Content: "Resposta final"}}}}
+
+	turn, err := agent.Run(context.Background(), llm, "user-1", agentRequest(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Resposta final", turn.Content)
+	assert.Equal(t, 3, turn.Steps)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "call_1_0", turn.Messages[0].ToolCalls[0].ID, "id gerado quando o servidor não envia")
+
+	// Esgotadas as rodadas o modelo é chamado sem ferramentas
+	requests := llm.Requests()
+	require.Len(t, requests, 3)
+	assert.NotEmpty(t, requests[1].Tools)
+	assert.Empty(t, requests[2].Tools)
+}
+
+func TestChatAgentToolErrorsGoBackToModel(t *testing.T) {
+	agent := &ChatAgent{maxSteps: 4, maxParallel: 1, execute: func(ctx context.Context, userID string, call LLMToolCall) string {
+		panic("falha inesperada")
+	}}
+	llm := &ScriptedLLM{Replies: []ScriptedReply{
+		{Response: LLMResponse{ToolCalls: []LLMToolCall{toolCall("call_1", "get_vacation_balance", "{}")}}},
+		{Response: LLMResponse{}},
+	}}
+
+	turn, err := agent.Run(context.Background(), llm, "user-1", agentRequest(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, chatIncompleteAnswer, turn.Content)
+
+	var result FunctionResult
+	require.NoError(t, json.Unmarshal([]byte(turn.Messages[1].Content), &result))
+	assert.False(t, result.Success)
+	assert.NotEmpty(t, result.Error)
+}
+
+func TestChatAgentStream(t *testing.T) {
+	agent := &ChatAgent{maxSteps: 4, maxParallel: 1, execute: func(ctx context.Context, userID string, call LLMToolCall) string {
+		return `{"saldo":20}`
+	}}
+	llm := &ScriptedLLM{Replies: []ScriptedReply{
+		{Response: LLMResponse{ToolCalls: []LLMToolCall{toolCall("call_1", "get_vacation_balance", "{}")}}},
+		{Response: LLMResponse{Content: "Você tem 20 dias."}},
+	}}
+
+	var streamed strings.Builder
+	turn, err := agent.Run(context.Background(), llm, "user-1", agentRequest(), func(s string) { streamed.WriteString(s) })
+	require.NoError(t, err)
+	assert.Equal(t, "Você tem 20 dias.", streamed.String())
+	assert.Equal(t, "Você tem 20 dias.", turn.Content)
+	assert.Equal(t, 1, turn.ToolCalls)
+}
+
+func TestCompleteToolExchanges(t *testing.T) {
+	messages := []LLMMessage{
+		{Role: "tool", ToolCallID: "antigo", Content: "{}"}, // Pedido ficou fora do histórico
+		{Role: "user", Content: "saldo?"},
+		{Role: "assistant", ToolCalls: []LLMToolCall{toolCall("a", "get_vacation_balance", "{}")}},
+		{Role: "tool", ToolCallID: "a", Content: "{}"},
+		{Role: "assistant", Content: "20 dias"},
+		{Role: "user", Content: "e o holerite?"},
+		{Role: "assistant", Content: "Vou verificar.", ToolCalls: []LLMToolCall{toolCall("b", "get_last_payslip", "{}"), toolCall("c", "get_ytd_earnings", "{}")}},
+		{Role: "tool", ToolCallID: "b", Content: "{}"}, // "c" sem resultado (falha ao gravar)
+		{Role: "assistant", ToolCalls: []LLMToolCall{toolCall("d", "get_pdi_status", "{}")}},
+	}
+
+	out := CompleteToolExchanges(messages)
+	require.Len(t, out, 6)
+	assert.Equal(t, "saldo?", out[0].Content)
+	assert.Equal(t, "a", out[1].ToolCalls[0].ID)
+	assert.Equal(t, "a", out[2].ToolCallID)
+	assert.Equal(t, "20 dias", out[3].Content)
+	assert.Equal(t, LLMMessage{Role: "assistant", Content: "Vou verificar."}, out[5], "mantém o texto sem o pedido incompleto")
+}
+
+func TestChatAgentProposesWriteActions(t *testing.T) {
+	var executed []string
+	var proposed []string
+	agent := &ChatAgent{maxSteps: 4, maxParallel: 2,
+		execute: func(ctx context.Context, userID string, call LLMToolCall) string {
+			executed = append(executed, call.Function.Name)
+			return `{"saldo":20}`
+		},
+		propose: func(ctx context.Context, userID string, call LLMToolCall) (*models.ChatPendingAction, error) {
+			proposed = append(proposed, call.Function.Name)
+			return &models.ChatPendingAction{ID: "acao-1", FunctionName: call.Function.Name, Summary: "Férias de 03/11/2026 a 17/11/2026"}, nil
+		},
+	}
+	llm := &ScriptedLLM{Replies: []ScriptedReply{
+		{Response: LLMResponse{ToolCalls: []LLMToolCall{
+			toolCall("call_1", "get_vacation_balance", "{}"),
+			toolCall("call_2", "request_vacation", `{"start_date":"2026-11-03","end_date":"2026-11-17"}`),
+		}}},
+		{Response: LLMResponse{Content: "Confirme a solicitação no cartão abaixo."}},
+	}}
+
+	turn, err := agent.Run(context.Background(), llm, "user-1", agentRequest(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"get_vacation_balance"}, executed, "escrita não executa sem confirmação")
+	assert.Equal(t, []string{"request_vacation"}, proposed)
+	require.Len(t, turn.Actions, 1)
+	assert.Equal(t, "acao-1", turn.Actions[0].ID)
+
+	var result FunctionResult
+	require.NoError(t, json.Unmarshal([]byte(turn.Messages[2].Content), &result))
+	assert.True(t, result.Success)
+	assert.Contains(t, result.Message, "NÃO foi executada")
+	assert.Equal(t, "awaiting_confirmation", result.Data.(map[string]interface{})["status"])
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/frappyou/backend/config"
@@ -274,8 +275,8 @@ func (c *ChatCache) GetFunctionResult(functionName, userID string, arguments str
 		return nil, nil
 	}
 
-	// Funções de escrita não são cacheadas
-	if isWriteFunction(functionName) {
+	// Funções de escrita (e as sem cache declarado) não são cacheadas
+	if tool, ok := ChatTools.Lookup(functionName); !ok || !tool.Cacheable() {
 		return nil, nil
 	}
 
@@ -297,14 +298,16 @@ func (c *ChatCache) GetFunctionResult(functionName, userID string, arguments str
 	return &cached, nil
 }
 
-// SetFunctionResult salva resultado de função no cache
-func (c *ChatCache) SetFunctionResult(functionName, userID string, arguments string, result interface{}) error {
-	if !c.IsAvailable() {
+// SetFunctionResult salva resultado de função no cache. Só resultados com sucesso são
+// guardados: recusas e falhas temporárias precisam ser reavaliadas na próxima chamada.
+func (c *ChatCache) SetFunctionResult(functionName, userID string, arguments string, result *FunctionResult) error {
+	if !c.IsAvailable() || result == nil || !result.Success {
 		return nil
 	}
 
-	// Funções de escrita não são cacheadas
-	if isWriteFunction(functionName) {
+	// Funções de escrita (e as sem cache declarado) não são cacheadas
+	tool, ok := ChatTools.Lookup(functionName)
+	if !ok || !tool.Cacheable() {
 		return nil
 	}
 
@@ -324,7 +327,7 @@ func (c *ChatCache) SetFunctionResult(functionName, userID string, arguments str
 	}
 
 	key := PrefixFunction + hashFunctionCall(functionName, userID, arguments)
-	ttl := getFunctionCacheTTL(functionName)
+	ttl := tool.CacheTTL
 
 	log.Printf("💾 Cacheando resultado da função %s", functionName)
 	return config.RedisClient.Set(ctx, key, data, ttl).Err()
@@ -361,70 +364,32 @@ func hashFunctionCall(functionName, userID, arguments string) string {
 	return hex.EncodeToString(hash[:12])
 }
 
-// getFunctionCacheTTL retorna TTL baseado no tipo de função
-func getFunctionCacheTTL(functionName string) time.Duration {
-	// Funções com dados que mudam pouco: cache longo
-	longCache := map[string]bool{
-		"list_available_courses": true,
-		"get_birthdays":          true,
-		"get_team_members":       true,
-	}
-
-	// Funções com dados que mudam frequentemente: cache curto
-	shortCache := map[string]bool{
-		"get_today_clock_entries": true,
-		"get_pending_approvals":   true,
-		"get_hour_bank":           true,
-	}
-
-	if longCache[functionName] {
-		return 30 * time.Minute
-	}
-
-	if shortCache[functionName] {
-		return 1 * time.Minute
-	}
-
-	return FunctionResultTTL // 5 minutos padrão
-}
-
-// isWriteFunction verifica se é uma função de escrita (não cachear)
-func isWriteFunction(functionName string) bool {
-	writeFunctions := map[string]bool{
-		"request_vacation":    true,
-		"cancel_vacation":     true,
-		"sell_vacation_days":  true,
-		"clock_punch":         true,
-		"justify_absence":     true,
-		"enroll_in_course":    true,
-		"approve_vacation":    true,
-		"reject_vacation":     true,
-	}
-	return writeFunctions[functionName]
-}
-
 // ==================== Rate Limiting ====================
 
-// CheckRateLimit verifica limite de requisições do usuário
+// CheckRateLimit verifica limite de requisições do usuário. Sem Redis (ou com erro no
+// Redis) o contador fica na memória da instância: o limite nunca deixa de valer.
 func (c *ChatCache) CheckRateLimit(userID string, maxRequests int, window time.Duration) (bool, int, error) {
-	if !c.IsAvailable() {
-		return true, 0, nil // Sem cache, permite tudo
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-
 	key := PrefixRateLimit + userID
 
-	// Incrementa contador
-	count, err := config.RedisClient.Incr(ctx, key).Result()
-	if err != nil {
-		return true, 0, err
+	var count int64
+	if c.IsAvailable() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		// Incrementa contador
+		n, err := config.RedisClient.Incr(ctx, key).Result()
+		if err == nil {
+			count = n
+			// Se é primeira requisição, define TTL
+			if count == 1 {
+				config.RedisClient.Expire(ctx, key, window)
+			}
+		} else {
+			log.Printf("⚠️ Rate limit: Redis indisponível, usando contador local: %v", err)
+		}
 	}
-
-	// Se é primeira requisição, define TTL
-	if count == 1 {
-		config.RedisClient.Expire(ctx, key, window)
+	if count == 0 {
+		count = int64(localRateLimits.hit(key, window, time.Now()))
 	}
 
 	remaining := maxRequests - int(count)
@@ -436,6 +401,40 @@ func (c *ChatCache) CheckRateLimit(userID string, maxRequests int, window time.D
 	return allowed, remaining, nil
 }
 
+// localRateLimits contadores usados quando o Redis não responde (valem por instância)
+var localRateLimits = &localRateLimiter{windows: map[string]*rateWindow{}}
+
+// localRateLimiter janela fixa em memória, com a mesma semântica do INCR + EXPIRE do Redis
+type localRateLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+}
+
+type rateWindow struct {
+	count   int
+	resetAt time.Time
+}
+
+// hit conta uma requisição na janela da chave e devolve o total da janela
+func (l *localRateLimiter) hit(key string, window time.Duration, now time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.windows[key]
+	if !ok || !now.Before(w.resetAt) {
+		// Descarta as janelas vencidas antes de abrir uma nova
+		for k, old := range l.windows {
+			if !now.Before(old.resetAt) {
+				delete(l.windows, k)
+			}
+		}
+		w = &rateWindow{resetAt: now.Add(window)}
+		l.windows[key] = w
+	}
+	w.count++
+	return w.count
+}
+
 // ==================== Cache Stats ====================
 
 // CacheStats estatísticas do cache
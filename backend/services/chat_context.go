@@ -250,10 +250,11 @@ func getLearningContext(userID string) *LearningContext {
 // getPayslipContext busca dados de holerite
 func getPayslipContext(userID string) *PayslipContext {
 	ctx := &PayslipContext{}
+	own := EmployeeIdentities.OwnPayslips(userID)
 
 	// Busca último holerite
 	var lastPayslip models.Payslip
-	if err := config.DB.Where("user_id = ?", userID).
+	if err := config.DB.Scopes(PublishedPayslips, own).
 		Order("reference_year DESC, reference_month DESC").
 		First(&lastPayslip).Error; err == nil {
 		ctx.LastPayslip = &PayslipInfo{
@@ -268,17 +269,14 @@ func getPayslipContext(userID string) *PayslipContext {
 
 	// Calcula YTD (Year to Date)
 	currentYear := time.Now().Year()
-	var ytdGross, ytdNet float64
-	config.DB.Model(&models.Payslip{}).
-		Where("user_id = ? AND reference_year = ?", userID, currentYear).
-		Select("COALESCE(SUM(gross_total), 0), COALESCE(SUM(net_total), 0)").
-		Row().Scan(&ytdGross, &ytdNet)
-	ctx.YTDGross = ytdGross
-	ctx.YTDNet = ytdNet
+	ytd, _, _ := SumPayslips(config.DB.Model(&models.Payslip{}).Scopes(PublishedPayslips, own).
+		Where("reference_year = ?", currentYear))
+	ctx.YTDGross = ytd.GrossTotal
+	ctx.YTDNet = ytd.NetTotal
 
 	// Conta holerites disponíveis
 	var count int64
-	config.DB.Model(&models.Payslip{}).Where("user_id = ?", userID).Count(&count)
+	config.DB.Model(&models.Payslip{}).Scopes(PublishedPayslips, own).Count(&count)
 	ctx.AvailableCount = int(count)
 
 	return ctx
@@ -373,6 +371,7 @@ Ajudar colaboradores com dúvidas sobre RH, benefícios, políticas da empresa e
 - Nunca revele informações de outros colaboradores
 - Para assuntos críticos (demissão, assédio, etc), direcione ao RH humano
 - Proteja dados sensíveis
+- Ações que alteram dados (solicitar, vender ou cancelar férias, inscrição em cursos, aprovações) só são executadas depois que o colaborador confirma no cartão exibido no chat; nunca diga que foram concluídas antes disso
 
 `
 
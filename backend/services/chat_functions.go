@@ -3,11 +3,11 @@ package services
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/frappyou/backend/config"
 	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
 )
 
 // ==================== Function Definitions ====================
@@ -19,11 +19,12 @@ type FunctionDefinition struct {
 	Parameters  map[string]interface{} `json:"parameters"`
 }
 
-// GetAvailableFunctions retorna as funções disponíveis para function calling
-func GetAvailableFunctions() []FunctionDefinition {
-	return []FunctionDefinition{
-		// 1. Consultar saldo de férias
-		{
+// init registra as ferramentas nativas do assistente. Cada uma declara o JSON schema,
+// quem pode usá-la, se é de escrita, o cache do resultado e o limite de uso.
+func init() {
+	// 1. Consultar saldo de férias
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "get_vacation_balance",
 			Description: "Consulta o saldo de férias atualizado do colaborador, incluindo período aquisitivo e prazo para uso",
 			Parameters: map[string]interface{}{
@@ -31,9 +32,15 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"properties": map[string]interface{}{},
 			},
 		},
+		CacheTTL: FunctionResultTTL,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			return executeGetVacationBalance(userID)
+		},
+	})
 
-		// 2. Listar cursos disponíveis
-		{
+	// 2. Listar cursos disponíveis
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "list_available_courses",
 			Description: "Lista cursos disponíveis na plataforma de e-learning, opcionalmente filtrados por categoria",
 			Parameters: map[string]interface{}{
@@ -50,9 +57,23 @@ func GetAvailableFunctions() []FunctionDefinition {
 				},
 			},
 		},
+		CacheTTL: 30 * time.Minute,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			var params struct {
+				Category string `json:"category"`
+				Limit    int    `json:"limit"`
+			}
+			json.Unmarshal([]byte(arguments), &params)
+			if params.Limit == 0 {
+				params.Limit = 5
+			}
+			return executeListCourses(params.Category, params.Limit)
+		},
+	})
 
-		// 3. Consultar progresso em cursos
-		{
+	// 3. Consultar progresso em cursos
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "get_my_courses",
 			Description: "Consulta os cursos em que o colaborador está matriculado e seu progresso",
 			Parameters: map[string]interface{}{
@@ -60,9 +81,15 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"properties": map[string]interface{}{},
 			},
 		},
+		CacheTTL: FunctionResultTTL,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			return executeGetMyCourses(userID)
+		},
+	})
 
-		// 4. Consultar último holerite
-		{
+	// 4. Consultar último holerite
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "get_last_payslip",
 			Description: "Consulta informações do último holerite disponível do colaborador",
 			Parameters: map[string]interface{}{
@@ -70,9 +97,15 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"properties": map[string]interface{}{},
 			},
 		},
+		CacheTTL: FunctionResultTTL,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			return executeGetLastPayslip(userID)
+		},
+	})
 
-		// 5. Consultar PDI
-		{
+	// 5. Consultar PDI
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "get_pdi_status",
 			Description: "Consulta o status do PDI (Plano de Desenvolvimento Individual) do colaborador",
 			Parameters: map[string]interface{}{
@@ -80,9 +113,15 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"properties": map[string]interface{}{},
 			},
 		},
+		CacheTTL: FunctionResultTTL,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			return executeGetPDIStatus(userID)
+		},
+	})
 
-		// 6. Solicitar férias
-		{
+	// 6. Solicitar férias
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "request_vacation",
 			Description: "Cria uma solicitação de férias para o colaborador",
 			Parameters: map[string]interface{}{
@@ -104,9 +143,22 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"required": []string{"start_date", "end_date"},
 			},
 		},
+		Write:     true,
+		RateLimit: 10,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			var params struct {
+				StartDate string `json:"start_date"`
+				EndDate   string `json:"end_date"`
+				Reason    string `json:"reason"`
+			}
+			json.Unmarshal([]byte(arguments), &params)
+			return executeRequestVacation(userID, params.StartDate, params.EndDate, params.Reason)
+		},
+	})
 
-		// 7. Vender férias
-		{
+	// 7. Vender férias
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "sell_vacation_days",
 			Description: "Solicita a venda de dias de férias (abono pecuniário). Máximo: 10 dias ou 1/3 das férias",
 			Parameters: map[string]interface{}{
@@ -124,9 +176,21 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"required": []string{"days"},
 			},
 		},
+		Write:     true,
+		RateLimit: 5,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			var params struct {
+				Days   int    `json:"days"`
+				Reason string `json:"reason"`
+			}
+			json.Unmarshal([]byte(arguments), &params)
+			return executeSellVacation(userID, params.Days, params.Reason)
+		},
+	})
 
-		// 8. Matricular em curso
-		{
+	// 8. Matricular em curso
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "enroll_in_course",
 			Description: "Matricula o colaborador em um curso da plataforma",
 			Parameters: map[string]interface{}{
@@ -140,9 +204,20 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"required": []string{"course_id"},
 			},
 		},
+		Write:     true,
+		RateLimit: 20,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			var params struct {
+				CourseID string `json:"course_id"`
+			}
+			json.Unmarshal([]byte(arguments), &params)
+			return executeEnrollInCourse(userID, params.CourseID)
+		},
+	})
 
-		// 9. Consultar badges/conquistas
-		{
+	// 9. Consultar badges/conquistas
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "get_my_badges",
 			Description: "Consulta os badges e conquistas do colaborador",
 			Parameters: map[string]interface{}{
@@ -150,9 +225,15 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"properties": map[string]interface{}{},
 			},
 		},
+		CacheTTL: FunctionResultTTL,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			return executeGetMyBadges(userID)
+		},
+	})
 
-		// 10. Consultar aniversariantes
-		{
+	// 10. Consultar aniversariantes
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "get_birthdays",
 			Description: "Consulta aniversariantes do mês atual na empresa",
 			Parameters: map[string]interface{}{
@@ -160,9 +241,15 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"properties": map[string]interface{}{},
 			},
 		},
+		CacheTTL: 30 * time.Minute,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			return executeGetBirthdays()
+		},
+	})
 
-		// 11. Histórico de férias
-		{
+	// 11. Histórico de férias
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "get_vacation_history",
 			Description: "Consulta o histórico de férias do colaborador nos últimos 2 anos",
 			Parameters: map[string]interface{}{
@@ -170,9 +257,15 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"properties": map[string]interface{}{},
 			},
 		},
+		CacheTTL: FunctionResultTTL,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			return executeGetVacationHistory(userID)
+		},
+	})
 
-		// 12. Cancelar férias
-		{
+	// 12. Cancelar férias
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "cancel_vacation",
 			Description: "Cancela uma solicitação de férias pendente",
 			Parameters: map[string]interface{}{
@@ -186,9 +279,20 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"required": []string{"vacation_id"},
 			},
 		},
+		Write:     true,
+		RateLimit: 10,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			var params struct {
+				VacationID string `json:"vacation_id"`
+			}
+			json.Unmarshal([]byte(arguments), &params)
+			return executeCancelVacation(userID, params.VacationID)
+		},
+	})
 
-		// 13. Membros da equipe (gestores)
-		{
+	// 13. Membros da equipe (gestores)
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "get_team_members",
 			Description: "Lista os membros da equipe do gestor. Apenas gestores podem usar esta função",
 			Parameters: map[string]interface{}{
@@ -196,21 +300,34 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"properties": map[string]interface{}{},
 			},
 		},
+		Access:   ChatToolAccess{Resource: ResourceOrg, Action: ActionRead, Scope: ScopeTeam},
+		CacheTTL: 30 * time.Minute,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			return executeGetTeamMembers(userID)
+		},
+	})
 
-		// 14. Aprovações pendentes (gestores)
-		{
+	// 14. Aprovações pendentes (gestores)
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "get_pending_approvals",
-			Description: "Lista todas as aprovações pendentes (férias, justificativas). Apenas gestores podem usar esta função",
+			Description: "Lista as aprovações pendentes do usuário (férias, venda de férias, documentos e PDI), incluindo as delegadas a ele",
 			Parameters: map[string]interface{}{
 				"type":       "object",
 				"properties": map[string]interface{}{},
 			},
 		},
+		CacheTTL: time.Minute,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			return executeGetPendingApprovals(userID)
+		},
+	})
 
-		// 15. Aprovar/Rejeitar férias (gestores)
-		{
+	// 15. Aprovar/Rejeitar férias (gestores)
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "approve_vacation",
-			Description: "Aprova ou rejeita uma solicitação de férias. Apenas gestores podem usar esta função",
+			Description: "Aprova ou rejeita a etapa atual de uma solicitação de férias. Apenas o aprovador da etapa (gestor, RH ou delegado) pode usar esta função",
 			Parameters: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -231,9 +348,23 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"required": []string{"vacation_id", "action"},
 			},
 		},
+		Access:    ChatToolAccess{Resource: ResourceVacation, Action: ActionApprove, Scope: ScopeTeam},
+		Write:     true,
+		RateLimit: 60,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			var params struct {
+				VacationID string `json:"vacation_id"`
+				Action     string `json:"action"`
+				Comment    string `json:"comment"`
+			}
+			json.Unmarshal([]byte(arguments), &params)
+			return executeApproveVacation(userID, params.VacationID, params.Action, params.Comment)
+		},
+	})
 
-		// 16. Histórico de holerites
-		{
+	// 16. Histórico de holerites
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "get_payroll_history",
 			Description: "Consulta o histórico de holerites do colaborador",
 			Parameters: map[string]interface{}{
@@ -246,9 +377,22 @@ func GetAvailableFunctions() []FunctionDefinition {
 				},
 			},
 		},
+		CacheTTL: FunctionResultTTL,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			var params struct {
+				Months int `json:"months"`
+			}
+			json.Unmarshal([]byte(arguments), &params)
+			if params.Months == 0 {
+				params.Months = 6
+			}
+			return executeGetPayrollHistory(userID, params.Months)
+		},
+	})
 
-		// 17. Ganhos no ano (YTD)
-		{
+	// 17. Ganhos no ano (YTD)
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "get_ytd_earnings",
 			Description: "Consulta o total de ganhos no ano atual (Year-to-Date)",
 			Parameters: map[string]interface{}{
@@ -256,9 +400,15 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"properties": map[string]interface{}{},
 			},
 		},
+		CacheTTL: FunctionResultTTL,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			return executeGetYTDEarnings(userID)
+		},
+	})
 
-		// 18. Buscar políticas
-		{
+	// 18. Buscar políticas
+	RegisterChatTool(ChatTool{
+		Definition: FunctionDefinition{
 			Name:        "search_policies",
 			Description: "Busca políticas e documentos da empresa sobre um tema específico",
 			Parameters: map[string]interface{}{
@@ -272,7 +422,16 @@ func GetAvailableFunctions() []FunctionDefinition {
 				"required": []string{"topic"},
 			},
 		},
-	}
+		CacheTTL:  FunctionResultTTL,
+		RateLimit: 60,
+		Execute: func(userID string, arguments string) (*FunctionResult, error) {
+			var params struct {
+				Topic string `json:"topic"`
+			}
+			json.Unmarshal([]byte(arguments), &params)
+			return executeSearchPolicies(params.Topic)
+		},
+	})
 }
 
 // ==================== Function Execution ====================
@@ -285,125 +444,26 @@ type FunctionResult struct {
 	Error   string      `json:"error,omitempty"`
 }
 
-// ExecuteFunction executa uma função chamada pela IA
-func ExecuteFunction(userID string, functionName string, arguments string) (*FunctionResult, error) {
-	switch functionName {
-	case "get_vacation_balance":
-		return executeGetVacationBalance(userID)
-
-	case "list_available_courses":
-		var params struct {
-			Category string `json:"category"`
-			Limit    int    `json:"limit"`
-		}
-		json.Unmarshal([]byte(arguments), &params)
-		if params.Limit == 0 {
-			params.Limit = 5
-		}
-		return executeListCourses(params.Category, params.Limit)
-
-	case "get_my_courses":
-		return executeGetMyCourses(userID)
-
-	case "get_last_payslip":
-		return executeGetLastPayslip(userID)
-
-	case "get_pdi_status":
-		return executeGetPDIStatus(userID)
-
-	case "request_vacation":
-		var params struct {
-			StartDate string `json:"start_date"`
-			EndDate   string `json:"end_date"`
-			Reason    string `json:"reason"`
-		}
-		json.Unmarshal([]byte(arguments), &params)
-		return executeRequestVacation(userID, params.StartDate, params.EndDate, params.Reason)
-
-	case "sell_vacation_days":
-		var params struct {
-			Days   int    `json:"days"`
-			Reason string `json:"reason"`
-		}
-		json.Unmarshal([]byte(arguments), &params)
-		return executeSellVacation(userID, params.Days, params.Reason)
-
-	case "enroll_in_course":
-		var params struct {
-			CourseID string `json:"course_id"`
-		}
-		json.Unmarshal([]byte(arguments), &params)
-		return executeEnrollInCourse(userID, params.CourseID)
-
-	case "get_my_badges":
-		return executeGetMyBadges(userID)
-
-	case "get_birthdays":
-		return executeGetBirthdays()
-
-	case "get_vacation_history":
-		return executeGetVacationHistory(userID)
-
-	case "cancel_vacation":
-		var params struct {
-			VacationID string `json:"vacation_id"`
-		}
-		json.Unmarshal([]byte(arguments), &params)
-		return executeCancelVacation(userID, params.VacationID)
-
-	case "get_team_members":
-		return executeGetTeamMembers(userID)
-
-	case "get_pending_approvals":
-		return executeGetPendingApprovals(userID)
-
-	case "approve_vacation":
-		var params struct {
-			VacationID string `json:"vacation_id"`
-			Action     string `json:"action"`
-			Comment    string `json:"comment"`
-		}
-		json.Unmarshal([]byte(arguments), &params)
-		return executeApproveVacation(userID, params.VacationID, params.Action, params.Comment)
-
-	case "get_payroll_history":
-		var params struct {
-			Months int `json:"months"`
-		}
-		json.Unmarshal([]byte(arguments), &params)
-		if params.Months == 0 {
-			params.Months = 6
-		}
-		return executeGetPayrollHistory(userID, params.Months)
-
-	case "get_ytd_earnings":
-		return executeGetYTDEarnings(userID)
-
-	case "search_policies":
-		var params struct {
-			Topic string `json:"topic"`
-		}
-		json.Unmarshal([]byte(arguments), &params)
-		return executeSearchPolicies(params.Topic)
-
-	default:
-		return &FunctionResult{
-			Success: false,
-			Error:   fmt.Sprintf("Função não encontrada: %s", functionName),
-		}, nil
-	}
-}
-
 // ==================== Function Implementations ====================
 
 func executeGetVacationBalance(userID string) (*FunctionResult, error) {
-	var balance models.VacationBalance
-	if err := config.DB.Where("user_id = ?", userID).First(&balance).Error; err != nil {
+	statement, err := NewVacationLedger().Statement(userID)
+	if err != nil {
 		return &FunctionResult{
 			Success: false,
 			Error:   "Saldo de férias não encontrado",
 		}, nil
 	}
+	balance := statement.Balance
+
+	// Prazo mais próximo entre os períodos com saldo disponível
+	deadline := balance.PeriodEnd.AddDate(1, 0, 0)
+	for _, p := range statement.Periods {
+		if p.Period.ClosedAt == nil && p.Available > 0 {
+			deadline = p.Period.ConcessiveEnd
+			break
+		}
+	}
 
 	// Busca próximas férias
 	var nextVacation models.Vacation
@@ -419,7 +479,7 @@ func executeGetVacationBalance(userID string) (*FunctionResult, error) {
 		"total_days":        balance.TotalDays,
 		"period_start":      balance.PeriodStart.Format("02/01/2006"),
 		"period_end":        balance.PeriodEnd.Format("02/01/2006"),
-		"deadline_to_use":   balance.PeriodEnd.AddDate(1, 0, 0).Format("02/01/2006"),
+		"deadline_to_use":   deadline.Format("02/01/2006"),
 		"has_next_vacation": hasNext,
 	}
 
@@ -499,8 +559,7 @@ func executeGetMyCourses(userID string) (*FunctionResult, error) {
 
 func executeGetLastPayslip(userID string) (*FunctionResult, error) {
 	var payslip models.Payslip
-	if err := config.DB.Preload("Items").
-		Where("user_id = ?", userID).
+	if err := config.DB.Preload("Items").Scopes(PublishedPayslips, EmployeeIdentities.OwnPayslips(userID)).
 		Order("reference_year DESC, reference_month DESC").
 		First(&payslip).Error; err != nil {
 		return &FunctionResult{
@@ -624,68 +683,67 @@ func executeRequestVacation(userID, startDate, endDate, reason string) (*Functio
 		}, nil
 	}
 
-	if start.After(end) {
-		return &FunctionResult{
-			Success: false,
-			Error:   "Data de início não pode ser posterior à data de fim",
-		}, nil
-	}
-
-	if start.Before(time.Now()) {
-		return &FunctionResult{
-			Success: false,
-			Error:   "Não é possível solicitar férias para datas passadas",
-		}, nil
-	}
-
-	days := int(end.Sub(start).Hours()/24) + 1
-
-	// Verifica saldo
-	var balance models.VacationBalance
-	if err := config.DB.Where("user_id = ?", userID).First(&balance).Error; err != nil {
-		return &FunctionResult{
-			Success: false,
-			Error:   "Saldo de férias não encontrado",
-		}, nil
-	}
-
-	if days > balance.AvailableDays {
-		return &FunctionResult{
+	// Valida a solicitação contra as configurações de férias e a CLT
+	period, err := NewVacationPolicy().Validate(VacationPolicyRequest{
+		UserID:    userID,
+		Type:      models.VacationTypeFerias,
+		StartDate: start,
+		EndDate:   end,
+	})
+	if err != nil {
+		result := &FunctionResult{
 			Success: false,
-			Error: fmt.Sprintf("Saldo insuficiente. Você tem %d dias disponíveis e solicitou %d dias",
-				balance.AvailableDays, days),
-		}, nil
+			Error:   err.Error(),
+		}
+		if policyErr, ok := AsVacationPolicyError(err); ok {
+			result.Data = map[string]interface{}{"violations": policyErr.Violations}
+		}
+		return result, nil
 	}
+	days := period.TotalDays
 
 	// Cria solicitação
 	vacation := models.Vacation{
-		UserID:    userID,
-		Type:      models.VacationTypeFerias,
-		StartDate: start,
-		EndDate:   end,
-		TotalDays: days,
-		Reason:    reason,
-		Status:    models.VacationStatusPending,
+		UserID:       userID,
+		Type:         models.VacationTypeFerias,
+		StartDate:    start,
+		EndDate:      end,
+		TotalDays:    days,
+		BusinessDays: period.BusinessDays,
+		Reason:       reason,
+		Status:       models.VacationStatusPending,
 	}
 
-	if err := config.DB.Create(&vacation).Error; err != nil {
+	// Grava a solicitação, reserva os dias no extrato de férias e inicia a cadeia de aprovação
+	var approval *models.ApprovalRequest
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&vacation).Error; err != nil {
+			return err
+		}
+		if _, err := NewVacationLedger().SyncVacation(tx, vacation, userID); err != nil {
+			return err
+		}
+		var err error
+		approval, err = Workflow.Submit(tx, models.ApprovalSubjectVacation, vacation.ID)
+		return err
+	})
+	if err != nil {
 		return &FunctionResult{
 			Success: false,
 			Error:   "Erro ao criar solicitação de férias",
 		}, nil
 	}
-
-	// Atualiza saldo pendente
-	config.DB.Model(&balance).Update("pending_days", balance.PendingDays+days)
+	Workflow.NotifySubmitted(approval)
 
 	return &FunctionResult{
 		Success: true,
 		Data: map[string]interface{}{
-			"vacation_id": vacation.ID,
-			"start_date":  start.Format("02/01/2006"),
-			"end_date":    end.Format("02/01/2006"),
-			"days":        days,
-			"status":      "pending",
+			"vacation_id":   vacation.ID,
+			"start_date":    start.Format("02/01/2006"),
+			"end_date":      end.Format("02/01/2006"),
+			"days":          days,
+			"business_days": period.BusinessDays,
+			"status":        "pending",
 		},
 		Message: fmt.Sprintf("✅ Férias solicitadas com sucesso! %d dias de %s a %s. Aguarde aprovação do gestor.",
 			days, start.Format("02/01/2006"), end.Format("02/01/2006")),
@@ -701,8 +759,8 @@ func executeSellVacation(userID string, days int, reason string) (*FunctionResul
 	}
 
 	// Verifica saldo
-	var balance models.VacationBalance
-	if err := config.DB.Where("user_id = ?", userID).First(&balance).Error; err != nil {
+	balance, err := NewVacationLedger().Balance(config.DB, userID)
+	if err != nil {
 		return &FunctionResult{
 			Success: false,
 			Error:   "Saldo de férias não encontrado",
@@ -738,12 +796,22 @@ func executeSellVacation(userID string, days int, reason string) (*FunctionResul
 		PeriodYear: time.Now().Year(),
 	}
 
-	if err := config.DB.Create(&sellRequest).Error; err != nil {
+	var approval *models.ApprovalRequest
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&sellRequest).Error; err != nil {
+			return err
+		}
+		var err error
+		approval, err = Workflow.Submit(tx, models.ApprovalSubjectVacationSell, sellRequest.ID)
+		return err
+	})
+	if err != nil {
 		return &FunctionResult{
 			Success: false,
 			Error:   "Erro ao criar solicitação de venda",
 		}, nil
 	}
+	Workflow.NotifySubmitted(approval)
 
 	return &FunctionResult{
 		Success: true,
@@ -918,18 +986,22 @@ func executeCancelVacation(userID, vacationID string) (*FunctionResult, error) {
 		}, nil
 	}
 
-	// Atualiza status
+	// Atualiza status e libera a reserva no extrato de férias
 	vacation.Status = models.VacationStatusCanceled
-	config.DB.Save(&vacation)
-
-	// Devolve dias ao saldo pendente
-	var balance models.VacationBalance
-	if config.DB.Where("user_id = ?", userID).First(&balance).Error == nil {
-		newPending := balance.PendingDays - vacation.TotalDays
-		if newPending < 0 {
-			newPending = 0
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&vacation).Error; err != nil {
+			return err
 		}
-		config.DB.Model(&balance).Update("pending_days", newPending)
+		if _, err := NewVacationLedger().SyncVacation(tx, vacation, userID); err != nil {
+			return err
+		}
+		return Workflow.Cancel(tx, models.ApprovalSubjectVacation, vacation.ID)
+	})
+	if err != nil {
+		return &FunctionResult{
+			Success: false,
+			Error:   "Erro ao cancelar solicitação de férias",
+		}, nil
 	}
 
 	return &FunctionResult{
@@ -943,25 +1015,16 @@ func executeCancelVacation(userID, vacationID string) (*FunctionResult, error) {
 }
 
 func executeGetTeamMembers(userID string) (*FunctionResult, error) {
-	// Verifica se é gestor
-	if !isManager(userID) {
-		return &FunctionResult{
-			Success: false,
-			Error:   "Esta função está disponível apenas para gestores",
-		}, nil
-	}
-
-	var manager models.User
-	if err := config.DB.Where("id = ?", userID).First(&manager).Error; err != nil {
-		return &FunctionResult{
-			Success: false,
-			Error:   "Usuário não encontrado",
-		}, nil
+	// Subordinados diretos e indiretos conforme o organograma
+	direct := make(map[string]bool)
+	for _, id := range Org.DirectReports(userID) {
+		direct[id] = true
 	}
 
-	// Busca membros do mesmo departamento
 	var members []models.User
-	config.DB.Where("department = ? AND id != ?", manager.Department, userID).Find(&members)
+	if reports := Org.AllReports(userID); len(reports) > 0 {
+		config.DB.Where("id IN ?", reports).Order("name ASC").Find(&members)
+	}
 
 	var teamList []map[string]interface{}
 	for _, m := range members {
@@ -971,91 +1034,69 @@ func executeGetTeamMembers(userID string) (*FunctionResult, error) {
 			"position":   m.Position,
 			"email":      m.Email,
 			"department": m.Department,
+			"direct":     direct[m.ID],
 		})
 	}
 
 	return &FunctionResult{
 		Success: true,
 		Data: map[string]interface{}{
-			"department": manager.Department,
-			"members":    teamList,
-			"count":      len(teamList),
+			"members":        teamList,
+			"count":          len(teamList),
+			"direct_reports": len(direct),
 		},
-		Message: fmt.Sprintf("Sua equipe tem %d membros no departamento %s", len(teamList), manager.Department),
+		Message: fmt.Sprintf("Sua equipe tem %d membros (%d subordinados diretos)", len(teamList), len(direct)),
 	}, nil
 }
 
 func executeGetPendingApprovals(userID string) (*FunctionResult, error) {
-	if !isManager(userID) {
+	tasks, err := Workflow.Inbox(userID)
+	if err != nil {
 		return &FunctionResult{
 			Success: false,
-			Error:   "Esta função está disponível apenas para gestores",
+			Error:   "Erro ao buscar aprovações pendentes",
 		}, nil
 	}
 
-	var manager models.User
-	config.DB.Where("id = ?", userID).First(&manager)
-
-	// Busca férias pendentes do departamento
-	var pendingVacations []models.Vacation
-	config.DB.Joins("JOIN users ON users.id = vacations.user_id").
-		Where("users.department = ? AND vacations.status = ?", manager.Department, models.VacationStatusPending).
-		Preload("User").
-		Find(&pendingVacations)
-
-	var vacationList []map[string]interface{}
-	for _, v := range pendingVacations {
-		vacationList = append(vacationList, map[string]interface{}{
-			"id":           v.ID,
-			"employee":     v.User.Name,
-			"type":         v.Type,
-			"start_date":   v.StartDate.Format("02/01/2006"),
-			"end_date":     v.EndDate.Format("02/01/2006"),
-			"days":         v.TotalDays,
-			"requested_at": v.CreatedAt.Format("02/01/2006"),
-		})
-	}
-
-	// Busca vendas de férias pendentes
-	var pendingSells []models.VacationSellRequest
-	config.DB.Joins("JOIN users ON users.id = vacation_sell_requests.user_id").
-		Where("users.department = ? AND vacation_sell_requests.status = ?", manager.Department, models.VacationSellStatusPending).
-		Preload("User").
-		Find(&pendingSells)
-
-	var sellList []map[string]interface{}
-	for _, s := range pendingSells {
-		sellList = append(sellList, map[string]interface{}{
-			"id":           s.ID,
-			"employee":     s.User.Name,
-			"days":         s.DaysToSell,
-			"reason":       s.Reason,
-			"requested_at": s.CreatedAt.Format("02/01/2006"),
-		})
+	var approvals []map[string]interface{}
+	byType := make(map[models.ApprovalSubjectType]int)
+	for _, t := range tasks {
+		item := map[string]interface{}{
+			"task_id":      t.ID,
+			"type":         t.Request.SubjectType,
+			"subject_id":   t.Request.SubjectID,
+			"summary":      t.Request.Summary,
+			"step":         t.StepName,
+			"requested_at": t.Request.CreatedAt.Format("02/01/2006"),
+		}
+		if t.Request.Requester != nil {
+			item["employee"] = t.Request.Requester.Name
+		}
+		if t.DueAt != nil {
+			item["due_at"] = t.DueAt.Format("02/01/2006 15:04")
+		}
+		if t.OriginalAssigneeID != nil {
+			item["delegation_reason"] = t.DelegationReason
+		}
+		approvals = append(approvals, item)
+		byType[t.Request.SubjectType]++
 	}
 
 	return &FunctionResult{
 		Success: true,
 		Data: map[string]interface{}{
-			"vacations":       vacationList,
-			"vacation_sells":  sellList,
-			"total_vacations": len(vacationList),
-			"total_sells":     len(sellList),
-			"total":           len(vacationList) + len(sellList),
+			"approvals":       approvals,
+			"total_vacations": byType[models.ApprovalSubjectVacation],
+			"total_sells":     byType[models.ApprovalSubjectVacationSell],
+			"total_documents": byType[models.ApprovalSubjectDocument],
+			"total_pdis":      byType[models.ApprovalSubjectPDI],
+			"total":           len(approvals),
 		},
-		Message: fmt.Sprintf("Você tem %d férias e %d vendas de férias pendentes de aprovação",
-			len(vacationList), len(sellList)),
+		Message: fmt.Sprintf("Você tem %d aprovações pendentes", len(approvals)),
 	}, nil
 }
 
 func executeApproveVacation(managerID, vacationID, action, comment string) (*FunctionResult, error) {
-	if !isManager(managerID) {
-		return &FunctionResult{
-			Success: false,
-			Error:   "Esta função está disponível apenas para gestores",
-		}, nil
-	}
-
 	var vacation models.Vacation
 	if err := config.DB.Where("id = ?", vacationID).Preload("User").First(&vacation).Error; err != nil {
 		return &FunctionResult{
@@ -1078,39 +1119,35 @@ func executeApproveVacation(managerID, vacationID, action, comment string) (*Fun
 		}, nil
 	}
 
-	now := time.Now()
-	if action == "approve" {
-		vacation.Status = models.VacationStatusApproved
-		vacation.ApprovedBy = &managerID
-		vacation.ApprovedAt = &now
-
-		// Atualiza saldo
-		var balance models.VacationBalance
-		if config.DB.Where("user_id = ?", vacation.UserID).First(&balance).Error == nil {
-			config.DB.Model(&balance).Updates(map[string]interface{}{
-				"used_days":      balance.UsedDays + vacation.TotalDays,
-				"available_days": balance.AvailableDays - vacation.TotalDays,
-				"pending_days":   balance.PendingDays - vacation.TotalDays,
-			})
-		}
-	} else {
-		vacation.Status = models.VacationStatusRejected
-		vacation.RejectReason = comment
-		vacation.ApprovedBy = &managerID
-		vacation.ApprovedAt = &now
-
-		// Devolve dias pendentes
-		var balance models.VacationBalance
-		if config.DB.Where("user_id = ?", vacation.UserID).First(&balance).Error == nil {
-			newPending := balance.PendingDays - vacation.TotalDays
-			if newPending < 0 {
-				newPending = 0
-			}
-			config.DB.Model(&balance).Update("pending_days", newPending)
-		}
+	decision := ApprovalApprove
+	if action == "reject" {
+		decision = ApprovalReject
+	}
+
+	outcome, err := Workflow.Decide(models.ApprovalSubjectVacation, vacationID, managerID, decision, comment)
+	if err != nil {
+		return &FunctionResult{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	data := map[string]interface{}{
+		"vacation_id": vacationID,
+		"employee":    vacation.User.Name,
+		"action":      action,
+		"days":        vacation.TotalDays,
+		"final":       outcome.Final,
 	}
 
-	config.DB.Save(&vacation)
+	if !outcome.Final {
+		data["next_step"] = outcome.NextTask.StepName
+		return &FunctionResult{
+			Success: true,
+			Data:    data,
+			Message: fmt.Sprintf("✅ Aprovação registrada. As férias de %s seguem para a etapa: %s", vacation.User.Name, outcome.NextTask.StepName),
+		}, nil
+	}
 
 	actionText := "aprovadas"
 	if action == "reject" {
@@ -1119,12 +1156,7 @@ func executeApproveVacation(managerID, vacationID, action, comment string) (*Fun
 
 	return &FunctionResult{
 		Success: true,
-		Data: map[string]interface{}{
-			"vacation_id": vacationID,
-			"employee":    vacation.User.Name,
-			"action":      action,
-			"days":        vacation.TotalDays,
-		},
+		Data:    data,
 		Message: fmt.Sprintf("✅ Férias de %s %s com sucesso! (%d dias)", vacation.User.Name, actionText, vacation.TotalDays),
 	}, nil
 }
@@ -1133,7 +1165,7 @@ func executeGetPayrollHistory(userID string, months int) (*FunctionResult, error
 	startDate := time.Now().AddDate(0, -months, 0)
 
 	var payslips []models.Payslip
-	config.DB.Where("user_id = ? AND created_at >= ?", userID, startDate).
+	config.DB.Scopes(PublishedPayslips, EmployeeIdentities.OwnPayslips(userID)).Where("created_at >= ?", startDate).
 		Order("reference_year DESC, reference_month DESC").
 		Find(&payslips)
 
@@ -1163,7 +1195,7 @@ func executeGetYTDEarnings(userID string) (*FunctionResult, error) {
 	currentYear := time.Now().Year()
 
 	var payslips []models.Payslip
-	config.DB.Where("user_id = ? AND reference_year = ?", userID, currentYear).Find(&payslips)
+	config.DB.Scopes(PublishedPayslips, EmployeeIdentities.OwnPayslips(userID)).Where("reference_year = ?", currentYear).Find(&payslips)
 
 	var grossTotal, netTotal, deductionTotal float64
 	for _, p := range payslips {
@@ -1217,26 +1249,6 @@ func executeSearchPolicies(topic string) (*FunctionResult, error) {
 	}, nil
 }
 
-// isManager verifica se o usuário é gestor
-func isManager(userID string) bool {
-	var user models.User
-	if err := config.DB.Where("id = ?", userID).First(&user).Error; err != nil {
-		return false
-	}
-	// Considera gestor se role é admin ou manager, ou se cargo contém "gerente", "diretor", "coordenador", etc.
-	if user.Role == "admin" || user.Role == "manager" {
-		return true
-	}
-	position := strings.ToLower(user.Position)
-	managerKeywords := []string{"gerente", "diretor", "coordenador", "supervisor", "líder", "head", "manager"}
-	for _, keyword := range managerKeywords {
-		if strings.Contains(position, keyword) {
-			return true
-		}
-	}
-	return false
-}
-
 func truncateText(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
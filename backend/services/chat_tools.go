@@ -0,0 +1,222 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ==================== REGISTRO DE FERRAMENTAS DO ASSISTENTE ====================
+
+var (
+	ErrChatToolNotFound    = errors.New("função não encontrada")
+	ErrChatToolDenied      = errors.New("você não tem permissão para usar esta função")
+	ErrChatToolRateLimited = errors.New("limite de uso desta função atingido; tente novamente mais tarde")
+)
+
+// chatToolRateWindow janela do limite de uso de cada ferramenta
+const chatToolRateWindow = time.Hour
+
+// ChatToolAccess quem pode usar a ferramenta. Com Roles o papel efetivo precisa estar na
+// lista; com Resource/Action o papel precisa da permissão no Authz com abrangência de pelo
+// menos Scope (own quando vazio). Sem restrições, qualquer colaborador autenticado usa
+// (ferramentas que só consultam os próprios dados).
+type ChatToolAccess struct {
+	Roles    []string    `json:"roles,omitempty"`
+	Resource string      `json:"resource,omitempty"`
+	Action   string      `json:"action,omitempty"`
+	Scope    AccessScope `json:"scope,omitempty"`
+}
+
+// ChatTool ferramenta que o assistente pode chamar
+type ChatTool struct {
+	Definition FunctionDefinition // Nome, descrição e JSON schema dos parâmetros
+	Access     ChatToolAccess
+	Write      bool          // Altera dados: roda em série, não é cacheada e passa pela confirmação
+	CacheTTL   time.Duration // Tempo do resultado no cache; zero não cacheia
+	RateLimit  int           // Execuções por usuário por hora; zero sem limite
+	Execute    func(userID string, arguments string) (*FunctionResult, error)
+}
+
+// Name nome da ferramenta
+func (t *ChatTool) Name() string {
+	return t.Definition.Name
+}
+
+// Cacheable indica se o resultado pode ser reaproveitado
+func (t *ChatTool) Cacheable() bool {
+	return !t.Write && t.CacheTTL > 0
+}
+
+// ChatToolRegistry ferramentas disponíveis ao assistente, na ordem de registro
+type ChatToolRegistry struct {
+	mu    sync.RWMutex
+	tools map[string]*ChatTool
+	order []string
+
+	roleOf func(userID string) (string, error) // Papel efetivo do usuário
+	allow  func(key string, limit int, window time.Duration) bool
+}
+
+// ChatTools registro global; outros pacotes adicionam ferramentas com RegisterChatTool
+var ChatTools = NewChatToolRegistry()
+
+// NewChatToolRegistry cria um registro vazio que usa o Authz e o rate limit do Redis
+func NewChatToolRegistry() *ChatToolRegistry {
+	return &ChatToolRegistry{
+		tools:  map[string]*ChatTool{},
+		roleOf: effectiveRoleOf,
+		allow: func(key string, limit int, window time.Duration) bool {
+			allowed, _, _ := NewChatCache().CheckRateLimit(key, limit, window)
+			return allowed
+		},
+	}
+}
+
+func effectiveRoleOf(userID string) (string, error) {
+	role, err := Authz.RoleOf(userID)
+	if err != nil {
+		return "", err
+	}
+	return Authz.EffectiveRole(userID, role), nil
+}
+
+// RegisterChatTool registra a ferramenta no registro global (normalmente no init do
+// pacote). Nome vazio, repetido ou sem Execute é erro de programação e gera pânico.
+func RegisterChatTool(tool ChatTool) {
+	if err := ChatTools.Register(tool); err != nil {
+		panic(err)
+	}
+}
+
+// Register adiciona a ferramenta
+func (r *ChatToolRegistry) Register(tool ChatTool) error {
+	name := tool.Name()
+	if name == "" || tool.Execute == nil {
+		return fmt.Errorf("ferramenta do chat inválida: %q", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[name]; ok {
+		return fmt.Errorf("ferramenta do chat já registrada: %s", name)
+	}
+	r.tools[name] = &tool
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Lookup busca a ferramenta pelo nome
+func (r *ChatToolRegistry) Lookup(name string) (*ChatTool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// IsWrite indica se a ferramenta altera dados
+func (r *ChatToolRegistry) IsWrite(name string) bool {
+	tool, ok := r.Lookup(name)
+	return ok && tool.Write
+}
+
+// Tools todas as ferramentas, na ordem de registro
+func (r *ChatToolRegistry) Tools() []*ChatTool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tools := make([]*ChatTool, 0, len(r.order))
+	for _, name := range r.order {
+		tools = append(tools, r.tools[name])
+	}
+	return tools
+}
+
+// scopeRank ordena as abrangências para comparar com o mínimo exigido
+var scopeRank = map[AccessScope]int{ScopeOwn: 1, ScopeTeam: 2, ScopeFilial: 3, ScopeAll: 4}
+
+// allowed verifica o acesso da ferramenta para o papel efetivo
+func (a ChatToolAccess) allowed(role string) bool {
+	if len(a.Roles) > 0 {
+		found := false
+		for _, r := range a.Roles {
+			found = found || r == role
+		}
+		if !found {
+			return false
+		}
+	}
+	if a.Resource != "" {
+		minScope := a.Scope
+		if minScope == ScopeNone {
+			minScope = ScopeOwn
+		}
+		if scopeRank[PolicyScope(role, a.Resource, a.Action)] < scopeRank[minScope] {
+			return false
+		}
+	}
+	return true
+}
+
+// restricted indica se a ferramenta depende do papel do usuário
+func (a ChatToolAccess) restricted() bool {
+	return len(a.Roles) > 0 || a.Resource != ""
+}
+
+// Authorize verifica se o usuário pode usar a ferramenta
+func (r *ChatToolRegistry) Authorize(userID, name string) (*ChatTool, error) {
+	tool, ok := r.Lookup(name)
+	if !ok {
+		return nil, ErrChatToolNotFound
+	}
+	if !tool.Access.restricted() {
+		return tool, nil
+	}
+	role, err := r.roleOf(userID)
+	if err != nil || !tool.Access.allowed(role) {
+		return nil, ErrChatToolDenied
+	}
+	return tool, nil
+}
+
+// Available definições enviadas ao modelo: só as ferramentas que o usuário pode usar
+func (r *ChatToolRegistry) Available(userID string) []FunctionDefinition {
+	role, err := r.roleOf(userID)
+	if err != nil {
+		role = ""
+	}
+	var definitions []FunctionDefinition
+	for _, tool := range r.Tools() {
+		if tool.Access.restricted() && (role == "" || !tool.Access.allowed(role)) {
+			continue
+		}
+		definitions = append(definitions, tool.Definition)
+	}
+	return definitions
+}
+
+// authorizeCall verifica a permissão e, na recusa, devolve o resultado para o modelo
+func (r *ChatToolRegistry) authorizeCall(userID, name string) (*ChatTool, *FunctionResult) {
+	tool, err := r.Authorize(userID, name)
+	if err != nil {
+		if errors.Is(err, ErrChatToolNotFound) {
+			err = fmt.Errorf("%w: %s", err, name)
+		}
+		log.Printf("⚠️ Chat: ferramenta %s recusada para %s: %v", name, shortID(userID), err)
+		return nil, &FunctionResult{Success: false, Error: err.Error()}
+	}
+	return tool, nil
+}
+
+// Execute executa a ferramenta após verificar permissão e limite de uso. Recusas viram
+// FunctionResult sem sucesso, para o modelo explicar ao colaborador.
+func (r *ChatToolRegistry) Execute(userID, name, arguments string) (*FunctionResult, error) {
+	tool, refusal := r.authorizeCall(userID, name)
+	if refusal != nil {
+		return refusal, nil
+	}
+	if tool.RateLimit > 0 && !r.allow("tool:"+name+":"+userID, tool.RateLimit, chatToolRateWindow) {
+		return &FunctionResult{Success: false, Error: ErrChatToolRateLimited.Error()}, nil
+	}
+	return tool.Execute(userID, arguments)
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noopTool(userID string, arguments string) (*FunctionResult, error) {
+	return &FunctionResult{Success: true, Message: "ok"}, nil
+}
+
+// testToolRegistry registro com papéis fixos por usuário
+func testToolRegistry(roles map[string]string) *ChatToolRegistry {
+	registry := NewChatToolRegistry()
+	registry.roleOf = func(userID string) (string, error) { return roles[userID], nil }
+	registry.allow = func(key string, limit int, window time.Duration) bool { return true }
+	return registry
+}
+
+func TestChatToolRegistryRegister(t *testing.T) {
+	registry := testToolRegistry(nil)
+	require.NoError(t, registry.Register(ChatTool{Definition: FunctionDefinition{Name: "get_weather"}, Execute: noopTool}))
+	assert.Error(t, registry.Register(ChatTool{Definition: FunctionDefinition{Name: "get_weather"}, Execute: noopTool}), "nome repetido")
+	assert.Error(t, registry.Register(ChatTool{Definition: FunctionDefinition{Name: ""}, Execute: noopTool}))
+	assert.Error(t, registry.Register(ChatTool{Definition: FunctionDefinition{Name: "sem_execute"}}))
+	assert.Len(t, registry.Tools(), 1)
+}
+
+func TestChatToolRegistryAvailableByRole(t *testing.T) {
+	registry := testToolRegistry(map[string]string{"ana": RoleEmployee, "bia": RoleManager, "caio": RoleHR})
+	registry.Register(ChatTool{Definition: FunctionDefinition{Name: "get_vacation_balance"}, Execute: noopTool})
+	registry.Register(ChatTool{Definition: FunctionDefinition{Name: "get_team_members"},
+		Access: ChatToolAccess{Resource: ResourceOrg, Action: ActionRead, Scope: ScopeTeam}, Execute: noopTool})
+	registry.Register(ChatTool{Definition: FunctionDefinition{Name: "approve_vacation"},
+		Access: ChatToolAccess{Roles: []string{RoleManager}}, Execute: noopTool})
+
+	names := func(userID string) []string {
+		var out []string
+		for _, def := range registry.Available(userID) {
+			out = append(out, def.Name)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"get_vacation_balance"}, names("ana"))
+	assert.Equal(t, []string{"get_vacation_balance", "get_team_members", "approve_vacation"}, names("bia"))
+	assert.Equal(t, []string{"get_vacation_balance", "get_team_members"}, names("caio"))
+	assert.Equal(t, []string{"get_vacation_balance"}, names("desconhecido"), "sem papel só as ferramentas abertas")
+
+	// O executor também recusa: o modelo pode inventar uma ferramenta que não recebeu
+	result, err := registry.Execute("ana", "approve_vacation", "{}")
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, ErrChatToolDenied.Error(), result.Error)
+
+	result, _ = registry.Execute("ana", "clock_punch", "{}")
+	assert.Contains(t, result.Error, "clock_punch")
+
+	result, _ = registry.Execute("bia", "approve_vacation", "{}")
+	assert.True(t, result.Success)
+}
+
+func TestChatToolRegistryRateLimit(t *testing.T) {
+	registry := testToolRegistry(nil)
+	var keys []string
+	registry.allow = func(key string, limit int, window time.Duration) bool {
+		keys = append(keys, key)
+		return len(keys) <= limit
+	}
+	registry.Register(ChatTool{Definition: FunctionDefinition{Name: "request_vacation"}, Write: true, RateLimit: 1, Execute: noopTool})
+	registry.Register(ChatTool{Definition: FunctionDefinition{Name: "get_my_badges"}, Execute: noopTool})
+
+	result, _ := registry.Execute("ana", "request_vacation", "{}")
+	assert.True(t, result.Success)
+	result, _ = registry.Execute("ana", "request_vacation", "{}")
+	assert.Equal(t, ErrChatToolRateLimited.Error(), result.Error)
+	assert.Equal(t, "tool:request_vacation:ana", keys[0])
+
+	registry.Execute("ana", "get_my_badges", "{}")
+	assert.Len(t, keys, 2, "sem limite não consulta o contador")
+}
+
+func TestBuiltinChatTools(t *testing.T) {
+	tools := ChatTools.Tools()
+	require.GreaterOrEqual(t, len(tools), 18)
+
+	for _, tool := range tools {
+		if tool.Write {
+			assert.False(t, tool.Cacheable(), tool.Name())
+			assert.True(t, RequiresConfirmation(tool.Name()), "%s altera dados e precisa de confirmação", tool.Name())
+		}
+		assert.Equal(t, "object", tool.Definition.Parameters["type"], tool.Name())
+	}
+
+	balance, ok := ChatTools.Lookup("get_vacation_balance")
+	require.True(t, ok)
+	assert.True(t, balance.Cacheable())
+	approve, _ := ChatTools.Lookup("approve_vacation")
+	assert.Equal(t, ResourceVacation, approve.Access.Resource)
+}
+
+func TestLocalRateLimiterWindow(t *testing.T) {
+	limiter := &localRateLimiter{windows: map[string]*rateWindow{}}
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 1, limiter.hit("tool:request_vacation:ana", time.Hour, now))
+	assert.Equal(t, 2, limiter.hit("tool:request_vacation:ana", time.Hour, now.Add(30*time.Minute)))
+	assert.Equal(t, 1, limiter.hit("tool:request_vacation:bia", time.Hour, now), "contador por chave")
+
+	// Janela vencida recomeça a contagem
+	assert.Equal(t, 1, limiter.hit("tool:request_vacation:ana", time.Hour, now.Add(time.Hour)))
+	assert.Len(t, limiter.windows, 1, "janelas vencidas são descartadas")
+}
+
+func TestCheckRateLimitWithoutRedis(t *testing.T) {
+	cache := NewChatCache()
+	require.False(t, cache.IsAvailable())
+
+	key := "teste-sem-redis-" + time.Now().Format(time.RFC3339Nano)
+	allowed, remaining, err := cache.CheckRateLimit(key, 2, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, remaining)
+
+	cache.CheckRateLimit(key, 2, time.Hour)
+	allowed, _, _ = cache.CheckRateLimit(key, 2, time.Hour)
+	assert.False(t, allowed, "sem Redis o limite continua valendo")
+}
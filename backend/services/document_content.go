@@ -0,0 +1,215 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	_ "image/gif" // Decodificadores usados por image.Decode
+	_ "image/png"
+)
+
+// ==================== CONTEÚDO DOS DOCUMENTOS ====================
+
+// DocumentContentError arquivo recusado na verificação de conteúdo
+type DocumentContentError struct {
+	Reason string
+}
+
+func (e *DocumentContentError) Error() string { return e.Reason }
+
+func contentError(reason string) error {
+	return &DocumentContentError{Reason: reason}
+}
+
+const (
+	mimePDF  = "application/pdf"
+	mimeDOC  = "application/msword"
+	mimeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+	maxImagePixels    = 40_000_000 // Evita imagens "bomba" que estouram a memória ao decodificar
+	thumbnailMaxSide  = 320
+	thumbnailQuality  = 80
+	pdfTrailerWindow  = 2048
+	oleMagic          = "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1"
+	docxContentTypes  = "[Content_Types].xml"
+	docxMacroProjects = "vbaProject.bin"
+)
+
+// documentMimeByExt tipos aceitos para cada extensão permitida no upload
+var documentMimeByExt = map[string]string{
+	".pdf":  mimePDF,
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".doc":  mimeDOC,
+	".docx": mimeDOCX,
+}
+
+// DetectDocumentMime tipo do arquivo pelos bytes iniciais, ignorando extensão e o
+// Content-Type informado pelo navegador
+func DetectDocumentMime(content []byte) string {
+	if bytes.HasPrefix(content, []byte(oleMagic)) {
+		return mimeDOC
+	}
+	detected := http.DetectContentType(content)
+	if detected == "application/zip" && isDOCX(content) {
+		return mimeDOCX
+	}
+	if i := strings.IndexByte(detected, ';'); i >= 0 {
+		detected = detected[:i]
+	}
+	return detected
+}
+
+// isDOCX pacote OOXML do Word
+func isDOCX(content []byte) bool {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return false
+	}
+	hasTypes, hasWord := false, false
+	for _, f := range archive.File {
+		hasTypes = hasTypes || f.Name == docxContentTypes
+		hasWord = hasWord || strings.HasPrefix(f.Name, "word/")
+	}
+	return hasTypes && hasWord
+}
+
+// DocumentInspection resultado da verificação de conteúdo
+type DocumentInspection struct {
+	MimeType  string
+	Thumbnail []byte // JPEG; só para imagens
+}
+
+// InspectDocument confere o conteúdo contra a extensão e faz as verificações do formato
+// (PDF íntegro e sem conteúdo ativo, imagem decodificável, DOCX sem macros). Arquivos
+// recusados retornam *DocumentContentError.
+func InspectDocument(ext string, content []byte) (*DocumentInspection, error) {
+	if len(content) == 0 {
+		return nil, contentError("arquivo vazio")
+	}
+	expected, ok := documentMimeByExt[strings.ToLower(ext)]
+	if !ok {
+		return nil, contentError("tipo de arquivo não permitido")
+	}
+	detected := DetectDocumentMime(content)
+	if detected != expected {
+		return nil, contentError("conteúdo (" + detected + ") não corresponde à extensão " + strings.ToLower(ext))
+	}
+
+	inspection := &DocumentInspection{MimeType: detected}
+	switch detected {
+	case mimePDF:
+		return inspection, checkPDF(content)
+	case mimeDOCX:
+		return inspection, checkDOCX(content)
+	case "image/jpeg", "image/png", "image/gif":
+		thumb, err := imageThumbnail(content)
+		inspection.Thumbnail = thumb
+		return inspection, err
+	}
+	return inspection, nil
+}
+
+// pdfActiveContent ações que executam código ou abrem outros arquivos
+var pdfActiveContent = regexp.MustCompile(`/(JavaScript|JS|Launch|EmbeddedFiles?|RichMedia)\b`)
+
+// pdfNameEscape caracteres escritos como #xx em nomes PDF (/J#61vaScript)
+var pdfNameEscape = regexp.MustCompile(`#[0-9A-Fa-f]{2}`)
+
+func checkPDF(content []byte) error {
+	tail := content
+	if len(tail) > pdfTrailerWindow {
+		tail = tail[len(tail)-pdfTrailerWindow:]
+	}
+	if !bytes.Contains(tail, []byte("%%EOF")) {
+		return contentError("PDF incompleto ou corrompido")
+	}
+
+	names := pdfNameEscape.ReplaceAllFunc(content, func(m []byte) []byte {
+		b, _ := strconv.ParseUint(string(m[1:]), 16, 8)
+		return []byte{byte(b)}
+	})
+	if match := pdfActiveContent.Find(names); match != nil {
+		return contentError("PDF com conteúdo ativo (" + string(match) + ")")
+	}
+	return nil
+}
+
+func checkDOCX(content []byte) error {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return contentError("documento Word corrompido")
+	}
+	for _, f := range archive.File {
+		if strings.HasSuffix(f.Name, docxMacroProjects) {
+			return contentError("documento Word com macros")
+		}
+	}
+	return nil
+}
+
+// imageThumbnail confere a imagem e gera a miniatura em JPEG
+func imageThumbnail(content []byte) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, contentError("imagem corrompida")
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, contentError("dimensões da imagem fora do limite")
+	}
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, contentError("imagem corrompida")
+	}
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, scaleDown(img, thumbnailMaxSide), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// scaleDown reduz a imagem para caber em maxSide (média dos pixels de cada bloco),
+// sobre fundo branco para imagens com transparência
+func scaleDown(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > maxSide || h > maxSide {
+		if w >= h {
+			w, h = maxSide, max(1, h*maxSide/b.Dx())
+		} else {
+			w, h = max(1, w*maxSide/b.Dy()), maxSide
+		}
+	}
+
+	flat := image.NewRGBA(b)
+	draw.Draw(flat, b, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, b, src, b.Min, draw.Over)
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := b.Min.Y+y*b.Dy()/h, b.Min.Y+max((y+1)*b.Dy()/h, y*b.Dy()/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := b.Min.X+x*b.Dx()/w, b.Min.X+max((x+1)*b.Dx()/w, x*b.Dx()/w+1)
+			var r, g, bl, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := flat.RGBAAt(sx, sy)
+					r, g, bl, n = r+uint32(c.R), g+uint32(c.G), bl+uint32(c.B), n+1
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(bl / n), 255})
+		}
+	}
+	return dst
+}
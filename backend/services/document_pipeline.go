@@ -0,0 +1,250 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== VERIFICAÇÃO DOS DOCUMENTOS ENVIADOS ====================
+
+// Documentos enviados entram em quarentena (download bloqueado) até passarem pela
+// verificação: tipo real pelos bytes iniciais, integridade do formato, antivírus e
+// miniatura. Liberados, seguem para a aprovação do RH; recusados ficam em quarentena
+// com o motivo em ScanResult.
+
+// DocumentProcessor fila de verificação dos documentos
+type DocumentProcessor struct {
+	scanner     VirusScanner
+	queue       chan string
+	maxAttempts int           // Tentativas quando o antivírus está indisponível
+	staleAfter  time.Duration // Verificação presa (instância reiniciada no meio)
+	now         func() time.Time
+	submit      func(tx *gorm.DB, documentID string) (*models.ApprovalRequest, error) // Inicia a aprovação do documento liberado
+	startOnce   sync.Once
+}
+
+// DocumentPipeline instância global da verificação de documentos
+var DocumentPipeline = &DocumentProcessor{
+	scanner:     newScannerFromEnv(),
+	queue:       make(chan string, 256),
+	maxAttempts: 5,
+	staleAfter:  15 * time.Minute,
+	now:         time.Now,
+	submit: func(tx *gorm.DB, documentID string) (*models.ApprovalRequest, error) {
+		return Workflow.Submit(tx, models.ApprovalSubjectDocument, documentID)
+	},
+}
+
+// ScannerName antivírus configurado ("" sem antivírus)
+func (p *DocumentProcessor) ScannerName() string {
+	if p.scanner == nil {
+		return ""
+	}
+	return p.scanner.Name()
+}
+
+// Enqueue agenda a verificação. Com a fila cheia o documento é pego na próxima varredura.
+func (p *DocumentProcessor) Enqueue(documentID string) {
+	select {
+	case p.queue <- documentID:
+	default:
+	}
+}
+
+// Start inicia os workers e a varredura periódica dos documentos pendentes (reinícios,
+// fila cheia, documentos enviados por outra instância e novas tentativas)
+func (p *DocumentProcessor) Start(ctx context.Context, workers int, sweepInterval time.Duration) {
+	if config.DB == nil {
+		return
+	}
+	p.startOnce.Do(func() {
+		for i := 0; i < workers; i++ {
+			go func() {
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case id := <-p.queue:
+						if err := p.Process(ctx, id); err != nil {
+							log.Printf("⚠️ Documentos: erro ao verificar %s: %v", id, err)
+						}
+					}
+				}
+			}()
+		}
+
+		go func() {
+			ticker := time.NewTicker(sweepInterval)
+			defer ticker.Stop()
+			for {
+				if err := p.sweep(); err != nil {
+					log.Printf("⚠️ Documentos: erro na varredura da quarentena: %v", err)
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	})
+}
+
+// sweep devolve à fila as verificações presas e as falhas com tentativas restantes
+func (p *DocumentProcessor) sweep() error {
+	stale := p.now().Add(-p.staleAfter)
+	if err := config.DB.Model(&models.Document{}).
+		Where("scan_status = ? AND scan_started < ?", models.DocumentScanRunning, stale).
+		UpdateColumn("scan_status", models.DocumentScanPending).Error; err != nil {
+		return err
+	}
+	if err := config.DB.Model(&models.Document{}).
+		Where("scan_status = ? AND scan_attempts < ?", models.DocumentScanFailed, p.maxAttempts).
+		UpdateColumn("scan_status", models.DocumentScanPending).Error; err != nil {
+		return err
+	}
+
+	var ids []string
+	if err := config.DB.Model(&models.Document{}).
+		Where("status = ? AND scan_status = ?", models.DocumentStatusQuarantined, models.DocumentScanPending).
+		Order("created_at").Limit(cap(p.queue)).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		p.Enqueue(id)
+	}
+	return nil
+}
+
+// Rescan agenda nova verificação de um documento em quarentena (após ajustar o antivírus)
+func (p *DocumentProcessor) Rescan(documentID string) error {
+	result := config.DB.Model(&models.Document{}).
+		Where("id = ? AND status = ? AND scan_status <> ?", documentID, models.DocumentStatusQuarantined, models.DocumentScanRunning).
+		UpdateColumns(map[string]interface{}{"scan_status": models.DocumentScanPending, "scan_attempts": 0, "scan_result": ""})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDocumentNotQuarantined
+	}
+	p.Enqueue(documentID)
+	return nil
+}
+
+// ErrDocumentNotQuarantined documento liberado ou já em verificação
+var ErrDocumentNotQuarantined = errors.New("documento não está em quarentena")
+
+// Process verifica um documento. A verificação é reservada com UPDATE condicional, então
+// várias instâncias podem processar a mesma fila sem repetir o trabalho.
+func (p *DocumentProcessor) Process(ctx context.Context, documentID string) error {
+	now := p.now()
+	claim := config.DB.Model(&models.Document{}).
+		Where("id = ? AND status = ? AND scan_status = ?", documentID, models.DocumentStatusQuarantined, models.DocumentScanPending).
+		UpdateColumns(map[string]interface{}{
+			"scan_status":   models.DocumentScanRunning,
+			"scan_started":  now,
+			"scan_attempts": gorm.Expr("scan_attempts + 1"),
+		})
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		return claim.Error
+	}
+
+	var doc models.Document
+	if err := config.DB.First(&doc, "id = ?", documentID).Error; err != nil {
+		return err
+	}
+
+	outcome := p.check(ctx, &doc)
+	if outcome.err != nil {
+		log.Printf("⚠️ Documentos: verificação de %s falhou (tentativa %d): %v", doc.ID, doc.ScanAttempts, outcome.err)
+	}
+	if outcome.thumbnail != nil {
+		if err := WriteDocumentThumbnail(&doc, outcome.thumbnail); err != nil {
+			log.Printf("⚠️ Documentos: erro ao gravar miniatura de %s: %v", doc.ID, err)
+		}
+	}
+
+	scannedAt := p.now()
+	doc.ScanStatus, doc.ScanResult, doc.ScannedAt = outcome.status, outcome.result, &scannedAt
+	if outcome.mimeType != "" {
+		doc.MimeType = outcome.mimeType
+	}
+	if outcome.status == models.DocumentScanClean {
+		doc.Status = models.DocumentStatusPending
+	}
+	// O documento liberado só sai da quarentena junto com o início da aprovação
+	var approval *models.ApprovalRequest
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&doc).Select("status", "scan_status", "scan_result", "scanned_at", "mime_type",
+			"thumbnail_path", "thumbnail_key_id", "thumbnail_data_key").Updates(&doc).Error; err != nil {
+			return err
+		}
+		if outcome.status == models.DocumentScanClean {
+			var err error
+			approval, err = p.submit(tx, doc.ID)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	Workflow.NotifySubmitted(approval)
+
+	switch outcome.status {
+	case models.DocumentScanInfected, models.DocumentScanInvalid:
+		log.Printf("🛑 Documentos: %s mantido em quarentena: %s", doc.ID, outcome.result)
+	}
+	Events.Publish(EventDocumentUpdated, doc.UserID, doc)
+	return nil
+}
+
+// scanOutcome resultado da verificação de um documento
+type scanOutcome struct {
+	status    models.DocumentScanStatus
+	result    string
+	mimeType  string
+	thumbnail []byte
+	err       error // Falha técnica (nova tentativa)
+}
+
+// check lê o arquivo e faz as verificações de conteúdo e o antivírus
+func (p *DocumentProcessor) check(ctx context.Context, doc *models.Document) scanOutcome {
+	content, err := ReadDocumentFile(doc)
+	if err != nil {
+		return scanOutcome{status: models.DocumentScanFailed, result: "Erro ao ler o arquivo", err: err}
+	}
+	return p.inspect(ctx, path.Ext(UploadKey(doc.Path)), content)
+}
+
+// inspect verifica o conteúdo já decifrado
+func (p *DocumentProcessor) inspect(ctx context.Context, ext string, content []byte) scanOutcome {
+	inspection, err := InspectDocument(ext, content)
+	var contentErr *DocumentContentError
+	if errors.As(err, &contentErr) {
+		return scanOutcome{status: models.DocumentScanInvalid, result: "Arquivo recusado: " + contentErr.Reason}
+	}
+	if err != nil {
+		return scanOutcome{status: models.DocumentScanFailed, result: "Erro ao verificar o conteúdo", err: err}
+	}
+
+	if p.scanner != nil {
+		verdict, err := p.scanner.Scan(ctx, bytes.NewReader(content))
+		if err != nil {
+			return scanOutcome{status: models.DocumentScanFailed, result: "Antivírus indisponível", mimeType: inspection.MimeType, err: err}
+		}
+		if verdict.Infected {
+			return scanOutcome{status: models.DocumentScanInfected, result: "Ameaça encontrada: " + verdict.Threat, mimeType: inspection.MimeType}
+		}
+	}
+	return scanOutcome{status: models.DocumentScanClean, mimeType: inspection.MimeType, thumbnail: inspection.Thumbnail}
+}
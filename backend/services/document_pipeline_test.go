@@ -0,0 +1,256 @@
+package services
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eicar arquivo de teste padrão dos antivírus
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+const samplePDF = "%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\ntrailer << /Root 1 0 R >>\n%%EOF\n"
+
+// fakeScanner antivírus de teste: acusa o EICAR ou devolve o erro configurado
+type fakeScanner struct {
+	err     error
+	scanned int
+}
+
+func (f *fakeScanner) Name() string { return "fake" }
+
+func (f *fakeScanner) Scan(ctx context.Context, content io.Reader) (ScanVerdict, error) {
+	f.scanned++
+	if f.err != nil {
+		return ScanVerdict{}, f.err
+	}
+	data, _ := io.ReadAll(content)
+	if bytes.Contains(data, []byte("EICAR-STANDARD-ANTIVIRUS-TEST-FILE")) {
+		return ScanVerdict{Infected: true, Threat: "Eicar-Test-Signature"}, nil
+	}
+	return ScanVerdict{}, nil
+}
+
+func samplePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, h/2, color.RGBA{255, 0, 0, 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func sampleDOCX(t *testing.T, extra ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, name := range append([]string{docxContentTypes, "word/document.xml"}, extra...) {
+		f, err := archive.Create(name)
+		require.NoError(t, err)
+		f.Write([]byte("<xml/>"))
+	}
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func TestDetectDocumentMime(t *testing.T) {
+	assert.Equal(t, mimePDF, DetectDocumentMime([]byte(samplePDF)))
+	assert.Equal(t, "image/png", DetectDocumentMime(samplePNG(t, 2, 2)))
+	assert.Equal(t, mimeDOCX, DetectDocumentMime(sampleDOCX(t)))
+	assert.Equal(t, mimeDOC, DetectDocumentMime([]byte(oleMagic+"resto")))
+	assert.Equal(t, "application/octet-stream", DetectDocumentMime([]byte("MZ\x90\x00\x03\x00\x00\x00")))
+}
+
+func TestInspectDocumentRejectsDisguisedFiles(t *testing.T) {
+	// Executável renomeado para .pdf e PNG renomeado para .jpg
+	_, err := InspectDocument(".pdf", []byte("MZ\x90\x00\x03\x00\x00\x00 programa"))
+	var contentErr *DocumentContentError
+	require.ErrorAs(t, err, &contentErr)
+	assert.Contains(t, contentErr.Reason, "não corresponde à extensão .pdf")
+
+	_, err = InspectDocument(".jpg", samplePNG(t, 2, 2))
+	assert.ErrorAs(t, err, &contentErr)
+
+	_, err = InspectDocument(".exe", []byte("MZ"))
+	assert.ErrorAs(t, err, &contentErr)
+}
+
+func TestInspectDocumentPDF(t *testing.T) {
+	inspection, err := InspectDocument(".PDF", []byte(samplePDF))
+	require.NoError(t, err)
+	assert.Equal(t, mimePDF, inspection.MimeType)
+	assert.Nil(t, inspection.Thumbnail)
+
+	_, err = InspectDocument(".pdf", []byte(samplePDF[:40]))
+	assert.ErrorContains(t, err, "PDF incompleto")
+
+	active := strings.Replace(samplePDF, "/Type /Catalog", "/Type /Catalog /OpenAction << /S /JavaScript /JS (app.alert(1)) >>", 1)
+	_, err = InspectDocument(".pdf", []byte(active))
+	assert.ErrorContains(t, err, "conteúdo ativo")
+
+	// Nome ofuscado com #xx
+	escaped := strings.Replace(samplePDF, "/Type /Catalog", "/Type /Catalog /OpenAction << /S /L#61unch >>", 1)
+	_, err = InspectDocument(".pdf", []byte(escaped))
+	assert.ErrorContains(t, err, "/Launch")
+}
+
+func TestInspectDocumentImageThumbnail(t *testing.T) {
+	inspection, err := InspectDocument(".png", samplePNG(t, 1200, 600))
+	require.NoError(t, err)
+	require.NotNil(t, inspection.Thumbnail)
+
+	thumb, err := jpeg.Decode(bytes.NewReader(inspection.Thumbnail))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 320, 160), thumb.Bounds())
+
+	// Imagem pequena mantém o tamanho
+	inspection, err = InspectDocument(".png", samplePNG(t, 40, 30))
+	require.NoError(t, err)
+	thumb, err = jpeg.Decode(bytes.NewReader(inspection.Thumbnail))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 40, 30), thumb.Bounds())
+
+	corrupted := samplePNG(t, 10, 10)[:40]
+	_, err = InspectDocument(".png", corrupted)
+	assert.ErrorContains(t, err, "imagem corrompida")
+}
+
+func TestInspectDocumentDOCXMacros(t *testing.T) {
+	_, err := InspectDocument(".docx", sampleDOCX(t))
+	assert.NoError(t, err)
+	_, err = InspectDocument(".docx", sampleDOCX(t, "word/vbaProject.bin"))
+	assert.ErrorContains(t, err, "macros")
+}
+
+// fakeClamd daemon que implementa o INSTREAM e acusa o EICAR
+func fakeClamd(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				command, err := r.ReadString(0)
+				if err != nil || command != "zINSTREAM\x00" {
+					io.WriteString(conn, "UNKNOWN COMMAND\x00")
+					return
+				}
+				var stream bytes.Buffer
+				for {
+					var size uint32
+					if binary.Read(r, binary.BigEndian, &size) != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					io.CopyN(&stream, r, int64(size))
+				}
+				if bytes.Contains(stream.Bytes(), []byte("EICAR")) {
+					io.WriteString(conn, "stream: Eicar-Test-Signature FOUND\x00")
+				} else {
+					io.WriteString(conn, "stream: OK\x00")
+				}
+			}(conn)
+		}
+	}()
+	return "tcp://" + listener.Addr().String()
+}
+
+func TestClamAVScanner(t *testing.T) {
+	scanner, err := NewClamAVScanner(fakeClamd(t), 5*time.Second)
+	require.NoError(t, err)
+	scanner.chunkSize = 16 // Vários blocos no INSTREAM
+
+	verdict, err := scanner.Scan(context.Background(), strings.NewReader(samplePDF))
+	require.NoError(t, err)
+	assert.False(t, verdict.Infected)
+
+	verdict, err = scanner.Scan(context.Background(), strings.NewReader(eicar))
+	require.NoError(t, err)
+	assert.True(t, verdict.Infected)
+	assert.Equal(t, "Eicar-Test-Signature", verdict.Threat)
+
+	_, err = parseClamAVReply("INSTREAM size limit exceeded. ERROR\x00")
+	assert.ErrorContains(t, err, "size limit")
+
+	_, err = NewClamAVScanner("clamav", 5*time.Second)
+	assert.Error(t, err, "porta obrigatória")
+}
+
+func TestDocumentProcessorInspect(t *testing.T) {
+	scanner := &fakeScanner{}
+	p := &DocumentProcessor{scanner: scanner}
+	ctx := context.Background()
+
+	outcome := p.inspect(ctx, ".pdf", []byte(samplePDF))
+	assert.Equal(t, models.DocumentScanClean, outcome.status)
+	assert.Equal(t, mimePDF, outcome.mimeType)
+
+	// EICAR dentro de um PDF válido
+	infected := strings.Replace(samplePDF, "trailer", eicar+"\ntrailer", 1)
+	outcome = p.inspect(ctx, ".pdf", []byte(infected))
+	assert.Equal(t, models.DocumentScanInfected, outcome.status)
+	assert.Equal(t, "Ameaça encontrada: Eicar-Test-Signature", outcome.result)
+
+	// Conteúdo recusado não chega ao antivírus
+	scanned := scanner.scanned
+	outcome = p.inspect(ctx, ".pdf", []byte("não é pdf"))
+	assert.Equal(t, models.DocumentScanInvalid, outcome.status)
+	assert.Equal(t, scanned, scanner.scanned)
+
+	// Antivírus fora do ar: falha técnica, com nova tentativa
+	scanner.err = errors.New("connection refused")
+	outcome = p.inspect(ctx, ".png", samplePNG(t, 4, 4))
+	assert.Equal(t, models.DocumentScanFailed, outcome.status)
+	assert.Error(t, outcome.err)
+	assert.Nil(t, outcome.thumbnail)
+
+	// Sem antivírus configurado: só a verificação de conteúdo
+	outcome = (&DocumentProcessor{}).inspect(ctx, ".png", samplePNG(t, 4, 4))
+	assert.Equal(t, models.DocumentScanClean, outcome.status)
+	assert.NotNil(t, outcome.thumbnail)
+}
+
+func TestDocumentThumbnailSealedInStorage(t *testing.T) {
+	withEncryption(t, "k1:{a}", "")
+	withStorage(t, NewLocalStorage(t.TempDir()))
+
+	doc := &models.Document{UserID: "u1", Path: DocumentKey("u1", ".png")}
+	require.NoError(t, WriteDocumentThumbnail(doc, []byte("jpeg")))
+	assert.True(t, doc.HasThumbnail)
+	assert.Equal(t, "k1", doc.ThumbnailKeyID)
+
+	thumb, err := ReadDocumentThumbnail(doc)
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", string(thumb))
+
+	_, err = ReadDocumentThumbnail(&models.Document{})
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==================== ANTIVÍRUS ====================
+
+// ScanVerdict resultado da verificação de um arquivo
+type ScanVerdict struct {
+	Infected bool
+	Threat   string // Nome da ameaça encontrada
+}
+
+// VirusScanner antivírus usado na verificação dos documentos enviados
+type VirusScanner interface {
+	Scan(ctx context.Context, content io.Reader) (ScanVerdict, error)
+	Name() string
+}
+
+// newScannerFromEnv ClamAV em CLAMAV_ADDRESS ("tcp://clamav:3310", "clamav:3310" ou
+// "unix:///var/run/clamav/clamd.ctl"); nil quando não configurado
+func newScannerFromEnv() VirusScanner {
+	address := os.Getenv("CLAMAV_ADDRESS")
+	if address == "" {
+		return nil
+	}
+	timeout := 60 * time.Second
+	if seconds, err := strconv.Atoi(os.Getenv("CLAMAV_TIMEOUT")); err == nil && seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+	scanner, err := NewClamAVScanner(address, timeout)
+	if err != nil {
+		panic("ERRO CRÍTICO: CLAMAV_ADDRESS inválido: " + err.Error())
+	}
+	return scanner
+}
+
+// ClamAVScanner cliente do clamd (comando INSTREAM)
+type ClamAVScanner struct {
+	network   string
+	address   string
+	timeout   time.Duration
+	chunkSize int
+}
+
+// NewClamAVScanner cliente do daemon no endereço informado
+func NewClamAVScanner(address string, timeout time.Duration) (*ClamAVScanner, error) {
+	network := "tcp"
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		network, address = "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "tcp://"):
+		address = strings.TrimPrefix(address, "tcp://")
+	}
+	if address == "" {
+		return nil, errors.New("endereço vazio")
+	}
+	if network == "tcp" {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return nil, err
+		}
+	}
+	return &ClamAVScanner{network: network, address: address, timeout: timeout, chunkSize: 64 * 1024}, nil
+}
+
+func (s *ClamAVScanner) Name() string { return "clamav" }
+
+// Scan envia o conteúdo em blocos (tamanho em 4 bytes big-endian, bloco vazio no fim) e
+// lê a resposta: "stream: OK", "stream: <ameaça> FOUND" ou "<motivo> ERROR"
+func (s *ClamAVScanner) Scan(ctx context.Context, content io.Reader) (ScanVerdict, error) {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return ScanVerdict{}, fmt.Errorf("clamav: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return ScanVerdict{}, fmt.Errorf("clamav: %w", err)
+	}
+	buf := make([]byte, 4+s.chunkSize)
+	for {
+		n, readErr := content.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				// O clamd fecha a conexão ao passar do StreamMaxLength: a resposta explica
+				break
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return ScanVerdict{}, readErr
+		}
+	}
+	conn.Write([]byte{0, 0, 0, 0})
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return ScanVerdict{}, fmt.Errorf("clamav: sem resposta: %w", err)
+	}
+	return parseClamAVReply(reply)
+}
+
+func parseClamAVReply(reply string) (ScanVerdict, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	result := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case result == "OK":
+		return ScanVerdict{}, nil
+	case strings.HasSuffix(result, " FOUND"):
+		return ScanVerdict{Infected: true, Threat: strings.TrimSuffix(result, " FOUND")}, nil
+	default:
+		return ScanVerdict{}, fmt.Errorf("clamav: %s", reply)
+	}
+}
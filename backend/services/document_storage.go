@@ -0,0 +1,133 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/encryption"
+	"github.com/frappyou/backend/models"
+	"github.com/google/uuid"
+)
+
+// ==================== ARQUIVOS DE DOCUMENTOS ====================
+
+// DocumentKey chave de armazenamento de um novo documento do usuário
+func DocumentKey(userID, ext string) string {
+	return "documents/" + userID + "/" + uuid.New().String() + strings.ToLower(ext)
+}
+
+// WriteDocumentFile grava o arquivo do documento no armazenamento (doc.Path é a chave),
+// cifrado quando a criptografia está ativa. A chave usada fica no próprio documento
+// (EncKeyID/EncDataKey).
+func WriteDocumentFile(doc *models.Document, content []byte) error {
+	contentType := mime.TypeByExtension(path.Ext(doc.Path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	keyID, dataKey, err := sealObject(UploadKey(doc.Path), content, contentType)
+	if err != nil {
+		return err
+	}
+	doc.EncKeyID, doc.EncDataKey = keyID, dataKey
+	return nil
+}
+
+// ReadDocumentFile lê o arquivo do documento, decifrando quando necessário
+func ReadDocumentFile(doc *models.Document) ([]byte, error) {
+	data, err := ReadObject(context.Background(), UploadKey(doc.Path))
+	if err != nil {
+		return nil, err
+	}
+	if doc.EncKeyID == "" {
+		return data, nil // Arquivo enviado antes da criptografia
+	}
+	return encryption.OpenBytes(doc.EncKeyID, doc.EncDataKey, data)
+}
+
+// DeleteDocumentFile remove o arquivo do documento (e a miniatura) do armazenamento
+func DeleteDocumentFile(doc *models.Document) error {
+	deleteDocumentThumbnail(doc)
+	return Storage.Delete(context.Background(), UploadKey(doc.Path))
+}
+
+// sealObject grava o conteúdo cifrado (quando a criptografia está ativa) e retorna a
+// chave mestra e a DEK usadas
+func sealObject(key string, content []byte, contentType string) (keyID, dataKey string, err error) {
+	data := content
+	if encryption.Enabled() {
+		if keyID, dataKey, data, err = encryption.SealBytes(content); err != nil {
+			return "", "", err
+		}
+		contentType = "application/octet-stream"
+	}
+	return keyID, dataKey, Storage.Put(context.Background(), key, bytes.NewReader(data), int64(len(data)), contentType)
+}
+
+// WriteDocumentThumbnail grava a miniatura (JPEG) do documento, cifrada como o arquivo
+func WriteDocumentThumbnail(doc *models.Document, thumbnail []byte) error {
+	thumbPath := "documents/" + doc.UserID + "/thumb_" + uuid.New().String() + ".jpg"
+	keyID, dataKey, err := sealObject(thumbPath, thumbnail, "image/jpeg")
+	if err != nil {
+		return err
+	}
+	doc.ThumbnailPath, doc.ThumbnailKeyID, doc.ThumbnailDataKey = thumbPath, keyID, dataKey
+	doc.HasThumbnail = true
+	return nil
+}
+
+// ReadDocumentThumbnail lê a miniatura do documento (ErrObjectNotFound se não houver)
+func ReadDocumentThumbnail(doc *models.Document) ([]byte, error) {
+	if doc.ThumbnailPath == "" {
+		return nil, ErrObjectNotFound
+	}
+	data, err := ReadObject(context.Background(), doc.ThumbnailPath)
+	if err != nil || doc.ThumbnailKeyID == "" {
+		return data, err
+	}
+	return encryption.OpenBytes(doc.ThumbnailKeyID, doc.ThumbnailDataKey, data)
+}
+
+// deleteDocumentThumbnail remove a miniatura do armazenamento
+func deleteDocumentThumbnail(doc *models.Document) {
+	if doc.ThumbnailPath != "" {
+		Storage.Delete(context.Background(), doc.ThumbnailPath)
+	}
+}
+
+// ReencryptDocumentFile recifra o arquivo com a chave ativa. O conteúdo vai para um
+// arquivo novo; o documento só passa a apontar para ele depois de gravado e o antigo
+// é removido no fim, então uma falha no meio não deixa o documento ilegível.
+func ReencryptDocumentFile(doc *models.Document) error {
+	content, err := ReadDocumentFile(doc)
+	if err != nil {
+		return err
+	}
+	thumbnail, err := ReadDocumentThumbnail(doc)
+	if err != nil && !errors.Is(err, ErrObjectNotFound) {
+		return err
+	}
+
+	updated := *doc
+	updated.Path = DocumentKey(doc.UserID, path.Ext(UploadKey(doc.Path)))
+	if err := WriteDocumentFile(&updated, content); err != nil {
+		return err
+	}
+	if thumbnail != nil {
+		if err := WriteDocumentThumbnail(&updated, thumbnail); err != nil {
+			Storage.Delete(context.Background(), updated.Path)
+			return err
+		}
+	}
+	if err := config.DB.Model(doc).Select("path", "enc_key_id", "enc_data_key", "thumbnail_path", "thumbnail_key_id", "thumbnail_data_key").Updates(&updated).Error; err != nil {
+		DeleteDocumentFile(&updated)
+		return err
+	}
+	DeleteDocumentFile(doc)
+	*doc = updated
+	return nil
+}
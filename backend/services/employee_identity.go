@@ -0,0 +1,422 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== Identidade do colaborador ====================
+//
+// Cada colaborador tem uma EmployeeIdentity (chave canônica pelo CPF) que liga a
+// conta do portal, o cadastro de dbo.ColaboradoresFradema e os holerites. A
+// autorização dos holerites usa apenas payslips.employee_id: CPF, user_id e
+// colaborador_id gravados no holerite servem só para estabelecer o vínculo, que
+// fica pendente para o admin quando não há candidato ou quando eles divergem.
+
+var (
+	ErrIdentityNotFound   = errors.New("colaborador não encontrado no cadastro")
+	ErrIdentityConflict   = errors.New("o CPF do usuário já está vinculado a outra conta")
+	ErrIdentityInvalidCPF = errors.New("CPF inválido")
+	ErrIdentityNoPayslips = errors.New("informe os holerites a vincular")
+)
+
+// IdentityLinker resolve as identidades e grava o vínculo dos holerites
+type IdentityLinker struct {
+	userCPF        func(userID string) string
+	colaboradorCPF func(colaboradorID int) string
+	ensure         func(cpf string) (*models.EmployeeIdentity, error)
+}
+
+// EmployeeIdentities instância usada pelos handlers, importação e publicação
+var EmployeeIdentities = NewIdentityLinker()
+
+// NewIdentityLinker cria o linker com as consultas ao banco
+func NewIdentityLinker() *IdentityLinker {
+	return &IdentityLinker{
+		userCPF:        lookupUserCPF,
+		colaboradorCPF: lookupColaboradorCPF,
+		ensure:         ensureEmployeeIdentity,
+	}
+}
+
+// normalizedCPFColumn compara a coluna de CPF (gravada com ou sem máscara) com dígitos
+func normalizedCPFColumn(column string) string {
+	return fmt.Sprintf("REPLACE(REPLACE(REPLACE(%s, '.', ''), '-', ''), ' ', '') = ?", column)
+}
+
+func lookupUserCPF(userID string) string {
+	var user models.User
+	if config.DB.Select("id, cpf").First(&user, "id = ?", userID).Error != nil {
+		return ""
+	}
+	return cleanDigits(user.CPF)
+}
+
+func lookupColaboradorCPF(colaboradorID int) string {
+	var cpf string
+	config.DB.Raw(`
+		SELECT p.Cpf FROM dbo.ColaboradoresFradema c
+		INNER JOIN dbo.PessoasFisicasFradema p ON c.PessoaFisicaId = p.Id
+		WHERE c.Id = ?
+	`, colaboradorID).Scan(&cpf)
+	return cleanDigits(cpf)
+}
+
+// ensureEmployeeIdentity retorna a identidade do CPF, criando-a quando o CPF
+// existe nos usuários ou no cadastro de colaboradores (nil quando não existe)
+func ensureEmployeeIdentity(cpf string) (*models.EmployeeIdentity, error) {
+	var identity models.EmployeeIdentity
+	err := config.DB.Where("cpf = ?", cpf).First(&identity).Error
+	if err == nil {
+		// Conta ativada depois da criação da identidade
+		if identity.UserID == "" {
+			var user models.User
+			if config.DB.Select("id").Where(normalizedCPFColumn("cpf"), cpf).First(&user).Error == nil {
+				identity.UserID = user.ID
+				config.DB.Model(&identity).Update("user_id", user.ID)
+			}
+		}
+		return &identity, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	identity = models.EmployeeIdentity{CPF: cpf}
+	var user models.User
+	err = config.DB.Select("id, name").Where(normalizedCPFColumn("cpf"), cpf).First(&user).Error
+	if err == nil {
+		identity.UserID, identity.Name = user.ID, user.Name
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var colaborador struct {
+		ID   int
+		Nome string
+	}
+	config.DB.Raw(`
+		SELECT TOP 1 c.Id AS id, p.Nome AS nome FROM dbo.ColaboradoresFradema c
+		INNER JOIN dbo.PessoasFisicasFradema p ON c.PessoaFisicaId = p.Id
+		WHERE `+normalizedCPFColumn("p.Cpf")+`
+		ORDER BY c.Ativo DESC, c.Id DESC
+	`, cpf).Scan(&colaborador)
+	identity.ColaboradorID = colaborador.ID
+	if identity.Name == "" {
+		identity.Name = colaborador.Nome
+	}
+
+	if identity.UserID == "" && identity.ColaboradorID == 0 {
+		return nil, nil
+	}
+	if err := config.DB.Create(&identity).Error; err != nil {
+		// Criada em paralelo por outra requisição (índice único no CPF)
+		var existing models.EmployeeIdentity
+		if config.DB.Where("cpf = ?", cpf).First(&existing).Error == nil {
+			return &existing, nil
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// ForCPF identidade de um CPF do cadastro
+func (l *IdentityLinker) ForCPF(cpf string) (*models.EmployeeIdentity, error) {
+	cpf = cleanDigits(cpf)
+	if !IsValidCPF(cpf) {
+		return nil, ErrIdentityInvalidCPF
+	}
+	identity, err := l.ensure(cpf)
+	if err != nil {
+		return nil, err
+	}
+	if identity == nil {
+		return nil, ErrIdentityNotFound
+	}
+	return identity, nil
+}
+
+// ForUser identidade do usuário do portal, vinculando a conta pelo CPF no primeiro acesso
+func (l *IdentityLinker) ForUser(user *models.User) (*models.EmployeeIdentity, error) {
+	var identity models.EmployeeIdentity
+	err := config.DB.Where("user_id = ?", user.ID).First(&identity).Error
+	if err == nil {
+		return &identity, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	found, err := l.ForCPF(user.CPF)
+	if errors.Is(err, ErrIdentityInvalidCPF) {
+		return nil, ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if found.UserID != "" && found.UserID != user.ID {
+		return nil, ErrIdentityConflict
+	}
+	if found.UserID == "" {
+		found.UserID = user.ID
+		if err := config.DB.Model(found).Update("user_id", user.ID).Error; err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// ForUserID identidade do usuário pelo ID
+func (l *IdentityLinker) ForUserID(userID string) (*models.EmployeeIdentity, error) {
+	var user models.User
+	if err := config.DB.Select("id, cpf").First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIdentityNotFound
+		}
+		return nil, err
+	}
+	return l.ForUser(&user)
+}
+
+// EmployeePayslips escopo dos holerites vinculados à identidade
+func EmployeePayslips(employeeID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("employee_id = ?", employeeID)
+	}
+}
+
+// OwnPayslips escopo dos holerites do usuário (nenhum, se ele não tiver identidade)
+func (l *IdentityLinker) OwnPayslips(userID string) func(*gorm.DB) *gorm.DB {
+	identity, err := l.ForUserID(userID)
+	if err != nil {
+		return func(db *gorm.DB) *gorm.DB {
+			return db.Where("1 = 0")
+		}
+	}
+	return EmployeePayslips(identity.ID)
+}
+
+// linkCandidate CPF apontado por uma das referências do holerite
+type linkCandidate struct {
+	source string
+	cpf    string
+}
+
+// decideLinkCPF escolhe o CPF do vínculo: todas as referências precisam concordar
+func decideLinkCPF(candidates []linkCandidate) (cpf string, status string, note string) {
+	if len(candidates) == 0 {
+		return "", models.PayslipLinkUnmatched, "Holerite sem CPF válido, usuário ou colaborador de referência"
+	}
+	distinct := map[string]bool{}
+	for _, c := range candidates {
+		distinct[c.cpf] = true
+	}
+	if len(distinct) > 1 {
+		parts := make([]string, 0, len(candidates))
+		for _, c := range candidates {
+			parts = append(parts, fmt.Sprintf("%s: %s", c.source, maskCPF(c.cpf)))
+		}
+		return "", models.PayslipLinkAmbiguous, "Referências apontam para CPFs diferentes (" + strings.Join(parts, "; ") + ")"
+	}
+	return candidates[0].cpf, models.PayslipLinkLinked, ""
+}
+
+// LinkPayslip define employee_id, link_status e link_note do holerite (sem gravar).
+// Vínculos manuais são mantidos.
+func (l *IdentityLinker) LinkPayslip(p *models.Payslip) error {
+	if p.LinkStatus == models.PayslipLinkManual && p.EmployeeID != "" {
+		return nil
+	}
+
+	var candidates []linkCandidate
+	if cpf := cleanDigits(p.EmployeeCPF); IsValidCPF(cpf) {
+		candidates = append(candidates, linkCandidate{"CPF do holerite", cpf})
+	}
+	if p.UserID != "" {
+		if cpf := l.userCPF(p.UserID); IsValidCPF(cpf) {
+			candidates = append(candidates, linkCandidate{"usuário", cpf})
+		}
+	}
+	if p.ColaboradorID > 0 {
+		if cpf := l.colaboradorCPF(p.ColaboradorID); IsValidCPF(cpf) {
+			candidates = append(candidates, linkCandidate{"colaborador", cpf})
+		}
+	}
+
+	cpf, status, note := decideLinkCPF(candidates)
+	p.EmployeeID, p.LinkStatus, p.LinkNote = "", status, note
+	if cpf == "" {
+		return nil
+	}
+
+	identity, err := l.ensure(cpf)
+	if err != nil {
+		return err
+	}
+	if identity == nil {
+		p.LinkStatus = models.PayslipLinkUnmatched
+		p.LinkNote = fmt.Sprintf("CPF %s não encontrado nos usuários nem no cadastro de colaboradores", maskCPF(cpf))
+		return nil
+	}
+	p.EmployeeID = identity.ID
+	if p.UserID == "" {
+		p.UserID = identity.UserID
+	}
+	if p.ColaboradorID == 0 {
+		p.ColaboradorID = identity.ColaboradorID
+	}
+	return nil
+}
+
+// cached cópia do linker que memoriza as consultas (vinculação de muitos holerites)
+func (l *IdentityLinker) cached() *IdentityLinker {
+	users := map[string]string{}
+	colaboradores := map[int]string{}
+	identities := map[string]*models.EmployeeIdentity{}
+	return &IdentityLinker{
+		userCPF: func(userID string) string {
+			if cpf, ok := users[userID]; ok {
+				return cpf
+			}
+			users[userID] = l.userCPF(userID)
+			return users[userID]
+		},
+		colaboradorCPF: func(colaboradorID int) string {
+			if cpf, ok := colaboradores[colaboradorID]; ok {
+				return cpf
+			}
+			colaboradores[colaboradorID] = l.colaboradorCPF(colaboradorID)
+			return colaboradores[colaboradorID]
+		},
+		ensure: func(cpf string) (*models.EmployeeIdentity, error) {
+			if identity, ok := identities[cpf]; ok {
+				return identity, nil
+			}
+			identity, err := l.ensure(cpf)
+			if err != nil {
+				return nil, err
+			}
+			identities[cpf] = identity
+			return identity, nil
+		},
+	}
+}
+
+// IdentityBackfillResult resumo da vinculação em lote
+type IdentityBackfillResult struct {
+	Processed int `json:"processed"`
+	Linked    int `json:"linked"`
+	Unmatched int `json:"unmatched"`
+	Ambiguous int `json:"ambiguous"`
+}
+
+// Backfill vincula os holerites ainda não processados e os sem colaborador
+// encontrado (um cadastro novo pode resolvê-los). Ambíguos ficam para o admin.
+func (l *IdentityLinker) Backfill() (*IdentityBackfillResult, error) {
+	result := &IdentityBackfillResult{}
+	linker := l.cached()
+
+	var chunk []models.Payslip
+	err := config.DB.Select(append([]string{"id", "user_id", "employee_cpf", "colaborador_id", "employee_id", "link_status"}, models.SealedColumns...)).
+		Where("link_status IS NULL OR link_status IN ?", []string{"", models.PayslipLinkUnmatched}).
+		FindInBatches(&chunk, 500, func(tx *gorm.DB, _ int) error {
+			for i := range chunk {
+				p := &chunk[i]
+				if err := linker.LinkPayslip(p); err != nil {
+					return err
+				}
+				err := config.DB.Model(&models.Payslip{}).Where("id = ?", p.ID).Updates(map[string]interface{}{
+					"employee_id":    p.EmployeeID,
+					"link_status":    p.LinkStatus,
+					"link_note":      p.LinkNote,
+					"user_id":        p.UserID,
+					"colaborador_id": p.ColaboradorID,
+				}).Error
+				if err != nil {
+					return err
+				}
+
+				result.Processed++
+				switch p.LinkStatus {
+				case models.PayslipLinkLinked:
+					result.Linked++
+				case models.PayslipLinkAmbiguous:
+					result.Ambiguous++
+				default:
+					result.Unmatched++
+				}
+			}
+			return nil
+		}).Error
+	return result, err
+}
+
+// Pending holerites sem vínculo (sem colaborador ou ambíguos), mais recentes primeiro
+func (l *IdentityLinker) Pending(status string, page, limit int) ([]models.Payslip, int64, error) {
+	statuses := []string{models.PayslipLinkUnmatched, models.PayslipLinkAmbiguous}
+	if status == models.PayslipLinkUnmatched || status == models.PayslipLinkAmbiguous {
+		statuses = []string{status}
+	}
+
+	query := config.DB.Model(&models.Payslip{}).Where("link_status IN ?", statuses)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var payslips []models.Payslip
+	err := query.Order("reference_year DESC, reference_month DESC, employee_name ASC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&payslips).Error
+	return payslips, total, err
+}
+
+// PendingCounts quantidade de holerites por situação de vínculo pendente
+func (l *IdentityLinker) PendingCounts() map[string]int64 {
+	var rows []struct {
+		LinkStatus string
+		Count      int64
+	}
+	config.DB.Model(&models.Payslip{}).
+		Select("link_status, COUNT(*) AS count").
+		Where("link_status IN ?", []string{models.PayslipLinkUnmatched, models.PayslipLinkAmbiguous}).
+		Group("link_status").
+		Scan(&rows)
+
+	counts := map[string]int64{models.PayslipLinkUnmatched: 0, models.PayslipLinkAmbiguous: 0}
+	for _, row := range rows {
+		counts[row.LinkStatus] = row.Count
+	}
+	return counts
+}
+
+// LinkManually vincula os holerites ao colaborador do CPF informado (resolução pelo admin)
+func (l *IdentityLinker) LinkManually(payslipIDs []string, cpf string) (*models.EmployeeIdentity, int64, error) {
+	if len(payslipIDs) == 0 {
+		return nil, 0, ErrIdentityNoPayslips
+	}
+	identity, err := l.ForCPF(cpf)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	updates := map[string]interface{}{
+		"employee_id": identity.ID,
+		"link_status": models.PayslipLinkManual,
+		"link_note":   "",
+	}
+	if identity.UserID != "" {
+		updates["user_id"] = identity.UserID
+	}
+	if identity.ColaboradorID > 0 {
+		updates["colaborador_id"] = identity.ColaboradorID
+	}
+	result := config.DB.Model(&models.Payslip{}).Where("id IN ?", payslipIDs).Updates(updates)
+	return identity, result.RowsAffected, result.Error
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLinker linker com usuários, colaboradores e identidades em memória
+func testLinker(ensureCalls *int) *IdentityLinker {
+	identities := map[string]*models.EmployeeIdentity{
+		cpfAna:   {ID: "e-ana", CPF: cpfAna, UserID: "u-ana", ColaboradorID: 10, Name: "Ana"},
+		cpfBruno: {ID: "e-bruno", CPF: cpfBruno, ColaboradorID: 11, Name: "Bruno"},
+	}
+	return &IdentityLinker{
+		userCPF: func(userID string) string {
+			return map[string]string{"u-ana": cpfAna}[userID]
+		},
+		colaboradorCPF: func(colaboradorID int) string {
+			return map[int]string{10: cpfAna, 11: cpfBruno}[colaboradorID]
+		},
+		ensure: func(cpf string) (*models.EmployeeIdentity, error) {
+			if ensureCalls != nil {
+				*ensureCalls++
+			}
+			return identities[cpf], nil
+		},
+	}
+}
+
+func TestLinkPayslipByCPF(t *testing.T) {
+	p := &models.Payslip{EmployeeCPF: "529.982.247-25"}
+	require.NoError(t, testLinker(nil).LinkPayslip(p))
+
+	assert.Equal(t, "e-ana", p.EmployeeID)
+	assert.Equal(t, models.PayslipLinkLinked, p.LinkStatus)
+	assert.Empty(t, p.LinkNote)
+	// Referências legadas completadas a partir da identidade
+	assert.Equal(t, "u-ana", p.UserID)
+	assert.Equal(t, 10, p.ColaboradorID)
+}
+
+func TestLinkPayslipAgreeingReferences(t *testing.T) {
+	p := &models.Payslip{EmployeeCPF: cpfAna, UserID: "u-ana", ColaboradorID: 10}
+	require.NoError(t, testLinker(nil).LinkPayslip(p))
+	assert.Equal(t, "e-ana", p.EmployeeID)
+
+	// Sem CPF no holerite, o colaborador basta
+	p = &models.Payslip{ColaboradorID: 11}
+	require.NoError(t, testLinker(nil).LinkPayslip(p))
+	assert.Equal(t, "e-bruno", p.EmployeeID)
+	assert.Empty(t, p.UserID)
+}
+
+func TestLinkPayslipAmbiguous(t *testing.T) {
+	p := &models.Payslip{EmployeeCPF: cpfAna, ColaboradorID: 11, EmployeeID: "antigo"}
+	require.NoError(t, testLinker(nil).LinkPayslip(p))
+
+	assert.Empty(t, p.EmployeeID)
+	assert.Equal(t, models.PayslipLinkAmbiguous, p.LinkStatus)
+	assert.Contains(t, p.LinkNote, "CPF do holerite")
+	assert.Contains(t, p.LinkNote, "colaborador")
+	assert.NotContains(t, p.LinkNote, cpfBruno)
+}
+
+func TestLinkPayslipUnmatched(t *testing.T) {
+	p := &models.Payslip{EmployeeCPF: "123"}
+	require.NoError(t, testLinker(nil).LinkPayslip(p))
+	assert.Equal(t, models.PayslipLinkUnmatched, p.LinkStatus)
+	assert.Empty(t, p.EmployeeID)
+
+	// CPF válido sem usuário nem colaborador
+	p = &models.Payslip{EmployeeCPF: "39053344705"}
+	require.NoError(t, testLinker(nil).LinkPayslip(p))
+	assert.Equal(t, models.PayslipLinkUnmatched, p.LinkStatus)
+	assert.Contains(t, p.LinkNote, "não encontrado")
+}
+
+func TestLinkPayslipKeepsManualLink(t *testing.T) {
+	p := &models.Payslip{EmployeeCPF: cpfAna, EmployeeID: "e-bruno", LinkStatus: models.PayslipLinkManual}
+	require.NoError(t, testLinker(nil).LinkPayslip(p))
+	assert.Equal(t, "e-bruno", p.EmployeeID)
+	assert.Equal(t, models.PayslipLinkManual, p.LinkStatus)
+}
+
+func TestCachedLinkerMemoizesLookups(t *testing.T) {
+	calls := 0
+	linker := testLinker(&calls).cached()
+	for i := 0; i < 3; i++ {
+		p := &models.Payslip{EmployeeCPF: cpfAna}
+		require.NoError(t, linker.LinkPayslip(p))
+		assert.Equal(t, "e-ana", p.EmployeeID)
+	}
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"github.com/frappyou/backend/encryption"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== CONSULTAS SOBRE DADOS CIFRADOS ====================
+
+// PayslipsByCPF holerites do CPF pelo índice cego. Holerites gravados antes do índice
+// (ainda não processados pela rotação de chaves) ou sem ENCRYPTION_INDEX_KEY configurada
+// são encontrados pela coluna em claro.
+func PayslipsByCPF(cpf string) func(*gorm.DB) *gorm.DB {
+	cpf = cleanDigits(cpf)
+	return byBlindIndex("employee_cpf_hash", normalizedCPFColumn("employee_cpf"), cpf)
+}
+
+// IncomeStatementsByCPF comprovantes do CPF pelo índice cego, com o mesmo fallback para
+// a coluna em claro dos comprovantes gravados sem o índice
+func IncomeStatementsByCPF(cpf string) func(*gorm.DB) *gorm.DB {
+	cpf = cleanDigits(cpf)
+	return byBlindIndex("cpf_hash", "cpf = ?", cpf)
+}
+
+// byBlindIndex filtra pelo índice cego ou, nos registros sem índice, pela condição em claro.
+// Sem chave do índice nenhum registro é indexado e vale só a condição em claro.
+func byBlindIndex(hashColumn, plainCondition, value string) func(*gorm.DB) *gorm.DB {
+	hash := encryption.BlindIndex(value)
+	unindexed := "(" + hashColumn + " IS NULL OR " + hashColumn + " = '') AND " + plainCondition
+	return func(db *gorm.DB) *gorm.DB {
+		if hash == "" {
+			return db.Where(unindexed, value)
+		}
+		return db.Where("("+hashColumn+" = ? OR ("+unindexed+"))", hash, value)
+	}
+}
+
+// PayslipTotals somas dos totais de um conjunto de holerites
+type PayslipTotals struct {
+	Count          int64   `json:"count"`
+	GrossTotal     float64 `json:"gross_total"`
+	DeductionTotal float64 `json:"deduction_total"`
+	NetTotal       float64 `json:"net_total"`
+}
+
+func (t *PayslipTotals) add(p models.Payslip) {
+	t.Count++
+	t.GrossTotal = roundCents(t.GrossTotal + p.GrossTotal)
+	t.DeductionTotal = roundCents(t.DeductionTotal + p.DeductionTotal)
+	t.NetTotal = roundCents(t.NetTotal + p.NetTotal)
+}
+
+// payslipTotalsColumns colunas necessárias para somar os holerites
+var payslipTotalsColumns = append([]string{"id", "reference_month", "reference_year",
+	"gross_total", "deduction_total", "net_total"}, models.SealedColumns...)
+
+// SumPayslips soma os totais dos holerites da consulta. Com os valores cifrados não há
+// SUM no banco: os holerites são lidos (e decifrados) em lotes e somados aqui.
+func SumPayslips(query *gorm.DB) (PayslipTotals, map[int]*PayslipTotals, error) {
+	var total PayslipTotals
+	byMonth := map[int]*PayslipTotals{}
+	var chunk []models.Payslip
+	err := query.Select(payslipTotalsColumns).FindInBatches(&chunk, 500, func(tx *gorm.DB, _ int) error {
+		for _, p := range chunk {
+			total.add(p)
+			if byMonth[p.ReferenceMonth] == nil {
+				byMonth[p.ReferenceMonth] = &PayslipTotals{}
+			}
+			byMonth[p.ReferenceMonth].add(p)
+		}
+		return nil
+	}).Error
+	return total, byMonth, err
+}
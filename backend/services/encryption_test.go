@@ -0,0 +1,148 @@
+package services
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/frappyou/backend/encryption"
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// withEncryption ativa a criptografia com a chave informada durante o teste
+func withEncryption(t *testing.T, spec, active string) {
+	t.Helper()
+	key := func(b string) string { return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(b, 32))) }
+	spec = strings.NewReplacer("{a}", key("a"), "{b}", key("b")).Replace(spec)
+	keyring, err := encryption.NewKeyring(spec, active, key("i"))
+	require.NoError(t, err)
+	encryption.SetKeyring(keyring)
+	t.Cleanup(func() {
+		empty, _ := encryption.NewKeyring("", "", "")
+		encryption.SetKeyring(empty)
+	})
+}
+
+// saveTx simula a gravação do próprio registro (Create/Save) nos hooks
+func saveTx(dest interface{}) *gorm.DB {
+	return &gorm.DB{Statement: &gorm.Statement{Dest: dest}}
+}
+
+func TestPayslipSealedAtRest(t *testing.T) {
+	withEncryption(t, "k1:{a}", "")
+	p := &models.Payslip{EmployeeCPF: "529.982.247-25", GrossTotal: 5000, DeductionTotal: 800, NetTotal: 4200, BaseSalary: 5000, FGTSAmount: 400}
+
+	require.NoError(t, p.BeforeSave(saveTx(p)))
+	// O que vai para o banco: valores zerados, envelope e índice do CPF
+	assert.Empty(t, p.EmployeeCPF)
+	assert.Zero(t, p.NetTotal)
+	assert.Zero(t, p.BaseSalary)
+	assert.Equal(t, "k1", p.EncKeyID)
+	assert.NotContains(t, p.EncData, "4200")
+	assert.Equal(t, encryption.BlindIndex(cpfAna), p.EmployeeCPFHash)
+
+	// Depois de gravar, o struct volta a ter os valores
+	require.NoError(t, p.AfterSave(saveTx(p)))
+	assert.Equal(t, "529.982.247-25", p.EmployeeCPF)
+	assert.Equal(t, 4200.0, p.NetTotal)
+
+	// Lido do banco (só as colunas gravadas)
+	stored := &models.Payslip{Sealed: p.Sealed, EmployeeCPFHash: p.EmployeeCPFHash}
+	require.NoError(t, stored.AfterFind(nil))
+	assert.Equal(t, 5000.0, stored.GrossTotal)
+	assert.Equal(t, 400.0, stored.FGTSAmount)
+
+	summary := &models.PayslipSummary{Sealed: p.Sealed}
+	require.NoError(t, summary.AfterFind(nil))
+	assert.Equal(t, 800.0, summary.DeductionTotal)
+
+	cpf, err := models.OpenPayslipCPF(p.Sealed, "")
+	require.NoError(t, err)
+	assert.Equal(t, "529.982.247-25", cpf)
+}
+
+func TestPayslipMapUpdateNotSealed(t *testing.T) {
+	withEncryption(t, "k1:{a}", "")
+	p := &models.Payslip{}
+	require.NoError(t, p.BeforeSave(saveTx(map[string]interface{}{"status": models.PayslipStatusPublished})))
+	assert.Empty(t, p.EncKeyID)
+}
+
+func TestPayslipLegacyRowsStayReadable(t *testing.T) {
+	// Sem criptografia nem chave do índice: CPF e valores ficam em claro, sem índice
+	// (a busca usa a coluna em claro até a rotação indexar o registro)
+	p := &models.Payslip{EmployeeCPF: cpfAna, NetTotal: 100}
+	require.NoError(t, p.BeforeSave(saveTx(p)))
+	assert.Equal(t, 100.0, p.NetTotal)
+	assert.Equal(t, cpfAna, p.EmployeeCPF)
+	assert.Empty(t, p.EncKeyID)
+	assert.Empty(t, p.EmployeeCPFHash)
+
+	withEncryption(t, "k1:{a}", "")
+	require.NoError(t, p.AfterFind(nil))
+	assert.Equal(t, 100.0, p.NetTotal)
+}
+
+func TestIncomeStatementSealedAtRest(t *testing.T) {
+	withEncryption(t, "k1:{a}", "")
+	s := &models.IncomeStatement{Year: 2025, CPF: cpfAna, TaxableIncome: 60000, Data: `{"year":2025}`}
+
+	require.NoError(t, s.BeforeSave(saveTx(s)))
+	assert.Empty(t, s.CPF)
+	assert.Empty(t, s.Data)
+	assert.Zero(t, s.TaxableIncome)
+	assert.Equal(t, encryption.BlindIndex(cpfAna), s.CPFHash)
+
+	stored := &models.IncomeStatement{Sealed: s.Sealed, CPFHash: s.CPFHash}
+	require.NoError(t, stored.AfterFind(nil))
+	assert.Equal(t, cpfAna, stored.CPF)
+	assert.Equal(t, 60000.0, stored.TaxableIncome)
+}
+
+func TestPayslipItemSealedAtRest(t *testing.T) {
+	withEncryption(t, "k1:{a},k2:{b}", "k2")
+	item := &models.PayslipItem{Description: "Salário", Amount: 3210.5}
+	require.NoError(t, item.BeforeSave(saveTx(item)))
+	assert.Zero(t, item.Amount)
+	assert.Equal(t, "k2", item.EncKeyID)
+
+	stored := &models.PayslipItem{Sealed: item.Sealed}
+	require.NoError(t, stored.AfterFind(nil))
+	assert.Equal(t, 3210.5, stored.Amount)
+}
+
+func TestResealPersonDocument(t *testing.T) {
+	withEncryption(t, "k1:{a},k2:{b}", "k1")
+	t.Setenv("ENCRYPTION_PERSON_DOCUMENTS", "true")
+	rg := "12.345.678-9"
+
+	sealed, err := SealPersonDocument(&rg)
+	require.NoError(t, err)
+	assert.Equal(t, "k1", encryption.TokenKeyID(*sealed))
+	assert.Equal(t, rg, *OpenPersonDocument(sealed))
+
+	// Já na chave ativa: nada a fazer
+	_, changed, err := resealPersonDocument(sealed, false)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// Nova chave ativa: recifra
+	withEncryption(t, "k1:{a},k2:{b}", "k2")
+	resealed, changed, err := resealPersonDocument(sealed, false)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "k2", encryption.TokenKeyID(*resealed))
+	assert.Equal(t, rg, *OpenPersonDocument(resealed))
+
+	// Desligada: volta a ficar em claro
+	t.Setenv("ENCRYPTION_PERSON_DOCUMENTS", "false")
+	plain, changed, err := resealPersonDocument(resealed, false)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, rg, *plain)
+	_, changed, _ = resealPersonDocument(&rg, false)
+	assert.False(t, changed)
+}
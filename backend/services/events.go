@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/google/uuid"
+)
+
+// ==================== Event Bus (Pub/Sub) ====================
+
+// EventType identifica o tipo de evento publicado no barramento
+type EventType string
+
+const (
+	EventVacationUpdated     EventType = "vacation.updated"
+	EventDocumentUpdated     EventType = "document.updated"
+	EventNotificationCreated EventType = "notification.created"
+)
+
+const (
+	// Canal Redis usado para replicar eventos entre instâncias da API
+	RedisEventsChannel = "frappyou:events"
+
+	// Buffer de cada assinante; eventos excedentes são descartados
+	subscriberBufferSize = 16
+)
+
+// Event representa um evento em tempo real
+type Event struct {
+	Type    EventType       `json:"type"`
+	UserID  string          `json:"user_id"` // Dono do recurso / destinatário
+	Payload json.RawMessage `json:"payload"`
+	Origin  string          `json:"origin"` // Instância que publicou o evento
+}
+
+// EventBus barramento de eventos em memória, opcionalmente replicado via Redis
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[EventType]map[int]chan Event
+	nextID      int
+	instanceID  string
+}
+
+// Events barramento compartilhado pelos handlers REST e resolvers GraphQL
+var Events = NewEventBus()
+
+// NewEventBus cria um novo barramento de eventos
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[EventType]map[int]chan Event),
+		instanceID:  uuid.New().String(),
+	}
+}
+
+// Subscribe registra um assinante para um tipo de evento.
+// Retorna o canal de eventos e uma função para cancelar a assinatura.
+func (b *EventBus) Subscribe(eventType EventType) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBufferSize)
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	if b.subscribers[eventType] == nil {
+		b.subscribers[eventType] = make(map[int]chan Event)
+	}
+	b.subscribers[eventType][id] = ch
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[eventType], id)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish publica um evento para os assinantes locais e, se o Redis estiver
+// disponível, para as demais instâncias da API
+func (b *EventBus) Publish(eventType EventType, userID string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("⚠️ Erro ao serializar evento %s: %v", eventType, err)
+		return
+	}
+
+	evt := Event{
+		Type:    eventType,
+		UserID:  userID,
+		Payload: data,
+		Origin:  b.instanceID,
+	}
+
+	b.deliver(evt)
+
+	if config.IsRedisAvailable() {
+		msg, err := json.Marshal(evt)
+		if err != nil {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		if err := config.RedisClient.Publish(ctx, RedisEventsChannel, msg).Err(); err != nil {
+			log.Printf("⚠️ Erro ao publicar evento %s no Redis: %v", eventType, err)
+		}
+	}
+}
+
+// deliver entrega o evento aos assinantes locais sem bloquear o publicador
+func (b *EventBus) deliver(evt Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, ch := range b.subscribers[evt.Type] {
+		select {
+		case ch <- evt:
+		default:
+			log.Printf("⚠️ Assinante lento: evento %s descartado", evt.Type)
+		}
+	}
+}
+
+// StartRedisBridge passa a receber eventos publicados por outras instâncias.
+// Sem Redis, o barramento continua funcionando apenas em memória.
+func (b *EventBus) StartRedisBridge(ctx context.Context) {
+	if !config.IsRedisAvailable() {
+		log.Println("⚠️ Redis indisponível - eventos em tempo real restritos a esta instância")
+		return
+	}
+
+	sub := config.RedisClient.Subscribe(ctx, RedisEventsChannel)
+
+	go func() {
+		defer sub.Close()
+
+		for msg := range sub.Channel() {
+			var evt Event
+			if err := json.Unmarshal([]byte(msg.Payload), &evt); err != nil {
+				continue
+			}
+
+			// Eventos desta instância já foram entregues localmente
+			if evt.Origin == b.instanceID {
+				continue
+			}
+
+			b.deliver(evt)
+		}
+	}()
+
+	log.Println("✅ Eventos em tempo real replicados via Redis")
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBusDeliversToSubscribers(t *testing.T) {
+	bus := NewEventBus()
+
+	events, unsubscribe := bus.Subscribe(EventVacationUpdated)
+	defer unsubscribe()
+
+	bus.Publish(EventVacationUpdated, "user-123", map[string]string{"status": "approved"})
+
+	select {
+	case evt := <-events:
+		assert.Equal(t, EventVacationUpdated, evt.Type)
+		assert.Equal(t, "user-123", evt.UserID)
+
+		var payload map[string]string
+		assert.NoError(t, json.Unmarshal(evt.Payload, &payload))
+		assert.Equal(t, "approved", payload["status"])
+	case <-time.After(time.Second):
+		t.Fatal("evento não entregue")
+	}
+}
+
+func TestEventBusFiltersByType(t *testing.T) {
+	bus := NewEventBus()
+
+	events, unsubscribe := bus.Subscribe(EventDocumentUpdated)
+	defer unsubscribe()
+
+	bus.Publish(EventNotificationCreated, "user-123", map[string]string{})
+
+	select {
+	case evt := <-events:
+		t.Fatalf("evento inesperado: %s", evt.Type)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestEventBusUnsubscribe(t *testing.T) {
+	bus := NewEventBus()
+
+	events, unsubscribe := bus.Subscribe(EventNotificationCreated)
+	unsubscribe()
+	unsubscribe() // Idempotente
+
+	_, ok := <-events
+	assert.False(t, ok, "canal deve ser fechado ao cancelar a assinatura")
+
+	// Publicar sem assinantes não deve bloquear nem causar panic
+	bus.Publish(EventNotificationCreated, "user-123", map[string]string{})
+}
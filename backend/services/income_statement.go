@@ -0,0 +1,585 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== Informe de Rendimentos ====================
+//
+// Comprovante de Rendimentos Pagos e de Imposto sobre a Renda Retido na Fonte
+// (modelo da Receita Federal) montado a partir dos holerites publicados do
+// ano-calendário. Os holerites entram pelo regime de caixa: data de pagamento
+// dentro do ano (ou, sem data, a competência do ano).
+
+var (
+	ErrIncomeStatementNotFound = errors.New("nenhum rendimento encontrado para o ano informado")
+	ErrIncomeStatementYear     = errors.New("ano-calendário inválido")
+	ErrIncomeBatchRunning      = errors.New("já existe uma geração em andamento para este ano")
+	ErrIncomeBatchNotFound     = errors.New("geração não encontrada")
+	ErrIncomeBatchOpenYear     = errors.New("o ano-calendário ainda não terminou; a geração em lote fica disponível a partir de janeiro do ano seguinte")
+)
+
+// IncomeStatementParty fonte pagadora ou beneficiário
+type IncomeStatementParty struct {
+	Name     string `json:"name"`
+	Document string `json:"document"` // CNPJ da fonte pagadora ou CPF do beneficiário
+}
+
+// IncomeStatementTaxable quadro 3 - rendimentos tributáveis, deduções e imposto retido
+type IncomeStatementTaxable struct {
+	TotalIncome     float64 `json:"total_income"`     // 01 Total dos rendimentos (inclusive férias)
+	OfficialPension float64 `json:"official_pension"` // 02 Contribuição previdenciária oficial
+	PrivatePension  float64 `json:"private_pension"`  // 03 Previdência complementar
+	Alimony         float64 `json:"alimony"`          // 04 Pensão alimentícia
+	WithheldTax     float64 `json:"withheld_tax"`     // 05 Imposto sobre a renda retido na fonte
+}
+
+// IncomeStatementExempt quadro 4 - rendimentos isentos e não tributáveis
+type IncomeStatementExempt struct {
+	Allowances   float64               `json:"allowances"`  // 03 Diárias e ajudas de custo
+	Indemnities  float64               `json:"indemnities"` // 07 Indenizações por rescisão, inclusive PDV
+	Other        float64               `json:"other"`       // 09 Outros
+	OtherDetails []IncomeStatementNote `json:"other_details,omitempty"`
+}
+
+// IncomeStatementExclusive quadro 5 - rendimentos sujeitos à tributação exclusiva
+type IncomeStatementExclusive struct {
+	Thirteenth            float64 `json:"thirteenth"`              // 01 Décimo terceiro salário (líquido do INSS)
+	ThirteenthWithheldTax float64 `json:"thirteenth_withheld_tax"` // 02 IRRF sobre o 13º salário
+}
+
+// IncomeStatementNote linha descritiva (isentos "outros" e informações complementares)
+type IncomeStatementNote struct {
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+}
+
+// IncomeStatementMonth resumo mensal (mês de pagamento)
+type IncomeStatementMonth struct {
+	Month         int     `json:"month"`
+	TaxableIncome float64 `json:"taxable_income"`
+	INSS          float64 `json:"inss"`
+	WithheldTax   float64 `json:"withheld_tax"`
+}
+
+// IncomeStatementData comprovante completo
+type IncomeStatementData struct {
+	Year          int                      `json:"year"`          // Ano-calendário
+	ExerciseYear  int                      `json:"exercise_year"` // Exercício da declaração
+	Payer         IncomeStatementParty     `json:"payer"`
+	Beneficiary   IncomeStatementParty     `json:"beneficiary"`
+	Nature        string                   `json:"nature"`
+	Taxable       IncomeStatementTaxable   `json:"taxable"`
+	Exempt        IncomeStatementExempt    `json:"exempt"`
+	Exclusive     IncomeStatementExclusive `json:"exclusive"`
+	Complementary []IncomeStatementNote    `json:"complementary,omitempty"` // Quadro 7 (ex: plano de saúde)
+	Monthly       []IncomeStatementMonth   `json:"monthly"`
+	PayslipCount  int                      `json:"payslip_count"`
+	Preliminary   bool                     `json:"preliminary"` // Ano ainda em curso
+	Responsible   string                   `json:"responsible"`
+	GeneratedAt   time.Time                `json:"generated_at"`
+}
+
+// ==================== Classificação das rubricas ====================
+
+type incomeCategory int
+
+const (
+	incomeTaxable incomeCategory = iota
+	incomeThirteenth
+	incomeExemptAllowance
+	incomeExemptIndemnity
+	incomeExemptOther
+	deductionINSS
+	deductionIRRF
+	deductionPrivatePension
+	deductionAlimony
+	deductionReducer // Faltas, atrasos e adiantamentos: reduzem o rendimento pago
+	deductionHealthPlan
+	deductionOther
+)
+
+var thirteenthPattern = regexp.MustCompile(`(^|[^0-9])13([^0-9]|$)|decimo terceiro`)
+
+// foldIncomeText minúsculas e sem acentos, para comparar descrições de rubricas
+func foldIncomeText(s string) string {
+	return strings.NewReplacer("_", " ", "-", " ").Replace(normalizeImportKey(s))
+}
+
+func containsAny(s string, terms ...string) bool {
+	for _, term := range terms {
+		if strings.Contains(s, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyIncomeItem enquadra a rubrica no quadro do comprovante pela descrição.
+// Em holerites de 13º todas as rubricas pertencem à tributação exclusiva.
+func classifyIncomeItem(payslipType string, item models.PayslipItem) (incomeCategory, bool) {
+	text := foldIncomeText(item.Description)
+	thirteenth := strings.HasPrefix(payslipType, "13_") || thirteenthPattern.MatchString(text)
+
+	if item.Type == "earning" {
+		switch {
+		case containsAny(text, "indeniz", "pdv", "demissao voluntaria"):
+			return incomeExemptIndemnity, thirteenth
+		case containsAny(text, "diaria", "ajuda de custo"):
+			return incomeExemptAllowance, thirteenth
+		case containsAny(text, "salario familia", "abono pecuniario"):
+			return incomeExemptOther, thirteenth
+		case thirteenth:
+			return incomeThirteenth, true
+		}
+		return incomeTaxable, false
+	}
+
+	switch {
+	case IsPayrollTaxItem(item, PayrollCodeINSS):
+		return deductionINSS, thirteenth
+	case IsPayrollTaxItem(item, PayrollCodeIRRF):
+		return deductionIRRF, thirteenth
+	case containsAny(text, "previdencia privada", "previdencia complementar", "fundo de pensao"):
+		return deductionPrivatePension, thirteenth
+	case strings.Contains(text, "pensao"):
+		return deductionAlimony, thirteenth
+	case containsAny(text, "plano de saude", "assistencia medica", "odonto"):
+		return deductionHealthPlan, thirteenth
+	case containsAny(text, "falta", "atraso", "adiantamento"):
+		return deductionReducer, thirteenth
+	}
+	return deductionOther, thirteenth
+}
+
+// incomePaymentMonth mês de pagamento do holerite (ou da competência, sem data)
+func incomePaymentMonth(p *models.Payslip) int {
+	if p.PaymentDate != nil {
+		return int(p.PaymentDate.Month())
+	}
+	return p.ReferenceMonth
+}
+
+// BuildIncomeStatement soma os holerites do ano nos quadros do comprovante
+func BuildIncomeStatement(year int, beneficiary IncomeStatementParty, payer IncomeStatementParty, payslips []models.Payslip) *IncomeStatementData {
+	data := &IncomeStatementData{
+		Year:         year,
+		ExerciseYear: year + 1,
+		Payer:        payer,
+		Beneficiary:  beneficiary,
+		Nature:       "Rendimentos do trabalho assalariado (0561)",
+		PayslipCount: len(payslips),
+	}
+
+	monthly := make([]IncomeStatementMonth, 12)
+	for i := range monthly {
+		monthly[i].Month = i + 1
+	}
+	exemptOther := map[string]float64{}
+	health := map[string]float64{}
+	var thirteenthINSS float64
+
+	for i := range payslips {
+		p := &payslips[i]
+		month := &monthly[0]
+		if m := incomePaymentMonth(p); m >= 1 && m <= 12 {
+			month = &monthly[m-1]
+		}
+
+		for _, item := range p.Items {
+			category, thirteenth := classifyIncomeItem(p.PayslipType, item)
+			amount := item.Amount
+
+			switch category {
+			case incomeTaxable:
+				data.Taxable.TotalIncome += amount
+				month.TaxableIncome += amount
+			case incomeThirteenth:
+				data.Exclusive.Thirteenth += amount
+			case incomeExemptAllowance:
+				data.Exempt.Allowances += amount
+			case incomeExemptIndemnity:
+				data.Exempt.Indemnities += amount
+			case incomeExemptOther:
+				data.Exempt.Other += amount
+				exemptOther[item.Description] += amount
+			case deductionINSS:
+				if thirteenth {
+					thirteenthINSS += amount
+				} else {
+					data.Taxable.OfficialPension += amount
+					month.INSS += amount
+				}
+			case deductionIRRF:
+				if thirteenth {
+					data.Exclusive.ThirteenthWithheldTax += amount
+				} else {
+					data.Taxable.WithheldTax += amount
+					month.WithheldTax += amount
+				}
+			case deductionPrivatePension:
+				data.Taxable.PrivatePension += amount
+			case deductionAlimony:
+				data.Taxable.Alimony += amount
+			case deductionHealthPlan:
+				health[item.Description] += amount
+			case deductionReducer:
+				if thirteenth {
+					data.Exclusive.Thirteenth -= amount
+				} else {
+					data.Taxable.TotalIncome -= amount
+					month.TaxableIncome -= amount
+				}
+			}
+		}
+	}
+
+	// 13º informado pelo valor líquido da contribuição previdenciária
+	data.Exclusive.Thirteenth -= thirteenthINSS
+
+	data.Taxable = IncomeStatementTaxable{
+		TotalIncome:     roundCents(maxZero(data.Taxable.TotalIncome)),
+		OfficialPension: roundCents(data.Taxable.OfficialPension),
+		PrivatePension:  roundCents(data.Taxable.PrivatePension),
+		Alimony:         roundCents(data.Taxable.Alimony),
+		WithheldTax:     roundCents(data.Taxable.WithheldTax),
+	}
+	data.Exempt.Allowances = roundCents(data.Exempt.Allowances)
+	data.Exempt.Indemnities = roundCents(data.Exempt.Indemnities)
+	data.Exempt.Other = roundCents(data.Exempt.Other)
+	data.Exempt.OtherDetails = incomeNotes(exemptOther)
+	data.Exclusive.Thirteenth = roundCents(maxZero(data.Exclusive.Thirteenth))
+	data.Exclusive.ThirteenthWithheldTax = roundCents(data.Exclusive.ThirteenthWithheldTax)
+	data.Complementary = incomeNotes(health)
+
+	for _, m := range monthly {
+		m.TaxableIncome = roundCents(maxZero(m.TaxableIncome))
+		m.INSS = roundCents(m.INSS)
+		m.WithheldTax = roundCents(m.WithheldTax)
+		if m.TaxableIncome != 0 || m.INSS != 0 || m.WithheldTax != 0 {
+			data.Monthly = append(data.Monthly, m)
+		}
+	}
+	return data
+}
+
+func maxZero(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// incomeNotes agrupa por descrição, em ordem alfabética
+func incomeNotes(values map[string]float64) []IncomeStatementNote {
+	notes := make([]IncomeStatementNote, 0, len(values))
+	for description, amount := range values {
+		if amount = roundCents(amount); amount > 0 {
+			notes = append(notes, IncomeStatementNote{Description: description, Amount: amount})
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Description < notes[j].Description })
+	if len(notes) == 0 {
+		return nil
+	}
+	return notes
+}
+
+// FormatCPF formata 11 dígitos como 000.000.000-00
+func FormatCPF(cpf string) string {
+	d := cleanDigits(cpf)
+	if len(d) != 11 {
+		return cpf
+	}
+	return d[0:3] + "." + d[3:6] + "." + d[6:9] + "-" + d[9:]
+}
+
+// FormatCNPJ formata 14 dígitos como 00.000.000/0000-00
+func FormatCNPJ(cnpj string) string {
+	d := cleanDigits(cnpj)
+	if len(d) != 14 {
+		return cnpj
+	}
+	return d[0:2] + "." + d[2:5] + "." + d[5:8] + "/" + d[8:12] + "-" + d[12:]
+}
+
+// ==================== Geração e armazenamento ====================
+
+// IncomeStatementGenerator monta, grava e gera em lote os comprovantes
+type IncomeStatementGenerator struct {
+	now func() time.Time
+}
+
+// IncomeStatements instância usada pelos handlers
+var IncomeStatements = NewIncomeStatementGenerator()
+
+// NewIncomeStatementGenerator cria o gerador
+func NewIncomeStatementGenerator() *IncomeStatementGenerator {
+	return &IncomeStatementGenerator{now: time.Now}
+}
+
+// incomeStatementPayer fonte pagadora (PAYSLIP_COMPANY_NAME / PAYSLIP_COMPANY_CNPJ)
+func incomeStatementPayer() IncomeStatementParty {
+	return IncomeStatementParty{
+		Name:     envOrDefault("PAYSLIP_COMPANY_NAME", "Fradema"),
+		Document: FormatCNPJ(os.Getenv("PAYSLIP_COMPANY_CNPJ")),
+	}
+}
+
+// IncomeYearPayslips escopo dos holerites publicados pagos no ano-calendário
+func IncomeYearPayslips(year int) func(*gorm.DB) *gorm.DB {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(1, 0, 0)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(PublishedPayslips).
+			Where("(payment_date >= ? AND payment_date < ?) OR (payment_date IS NULL AND reference_year = ?)", start, end, year)
+	}
+}
+
+func (g *IncomeStatementGenerator) validYear(year int) bool {
+	return year >= 2000 && year <= g.now().Year()
+}
+
+// closedYear indica se o ano-calendário já terminou (holerites pagos no ano não mudam mais)
+func (g *IncomeStatementGenerator) closedYear(year int) bool {
+	return year < g.now().Year()
+}
+
+// build monta o comprovante com os dados da emissão
+func (g *IncomeStatementGenerator) build(year int, cpf, name string, payslips []models.Payslip) *IncomeStatementData {
+	data := BuildIncomeStatement(year, IncomeStatementParty{Name: name, Document: FormatCPF(cpf)}, incomeStatementPayer(), payslips)
+	data.Preliminary = year >= g.now().Year()
+	data.Responsible = os.Getenv("INCOME_STATEMENT_RESPONSIBLE")
+	data.GeneratedAt = g.now().Truncate(time.Second)
+	return data
+}
+
+// stored comprovante gravado pela geração em lote. Só vale para anos encerrados: no ano
+// corrente o comprovante é sempre calculado com os holerites atuais.
+func (g *IncomeStatementGenerator) stored(year int, cpf string) (*IncomeStatementData, bool) {
+	if !g.closedYear(year) {
+		return nil, false
+	}
+	var record models.IncomeStatement
+	if config.DB.Where("year = ?", year).Scopes(IncomeStatementsByCPF(cpf)).First(&record).Error != nil {
+		return nil, false
+	}
+	var data IncomeStatementData
+	// Prévias gravadas com o ano ainda aberto não valem como comprovante definitivo
+	if json.Unmarshal([]byte(record.Data), &data) != nil || data.Preliminary {
+		return nil, false
+	}
+	return &data, true
+}
+
+// ForEmployee comprovante do colaborador: o gravado em lote ou, sem ele, calculado na hora
+func (g *IncomeStatementGenerator) ForEmployee(user *models.User, year int) (*IncomeStatementData, error) {
+	if !g.validYear(year) {
+		return nil, ErrIncomeStatementYear
+	}
+	identity, err := EmployeeIdentities.ForUser(user)
+	if errors.Is(err, ErrIdentityNotFound) {
+		return nil, ErrIncomeStatementNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data, ok := g.stored(year, identity.CPF); ok {
+		return data, nil
+	}
+
+	var payslips []models.Payslip
+	err = config.DB.Preload("Items").Scopes(IncomeYearPayslips(year), EmployeePayslips(identity.ID)).
+		Find(&payslips).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(payslips) == 0 {
+		return nil, ErrIncomeStatementNotFound
+	}
+	return g.build(year, identity.CPF, user.Name, payslips), nil
+}
+
+// ForCPF comprovante de qualquer colaborador (admin)
+func (g *IncomeStatementGenerator) ForCPF(year int, cpf string) (*IncomeStatementData, error) {
+	if !g.validYear(year) {
+		return nil, ErrIncomeStatementYear
+	}
+	cpf = cleanDigits(cpf)
+	if data, ok := g.stored(year, cpf); ok {
+		return data, nil
+	}
+
+	var payslips []models.Payslip
+	err := config.DB.Preload("Items").Scopes(IncomeYearPayslips(year), PayslipsByCPF(cpf)).
+		Find(&payslips).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(payslips) == 0 {
+		return nil, ErrIncomeStatementNotFound
+	}
+	return g.build(year, cpf, payslips[len(payslips)-1].EmployeeName, payslips), nil
+}
+
+// AvailableYears anos com holerites publicados do colaborador
+func (g *IncomeStatementGenerator) AvailableYears(user *models.User) []int {
+	var years []int
+	config.DB.Model(&models.Payslip{}).Scopes(PublishedPayslips, EmployeeIdentities.OwnPayslips(user.ID)).
+		Distinct("reference_year").
+		Order("reference_year DESC").
+		Pluck("reference_year", &years)
+	return years
+}
+
+// List comprovantes gravados de um ano
+func (g *IncomeStatementGenerator) List(year int) ([]models.IncomeStatement, error) {
+	var statements []models.IncomeStatement
+	err := config.DB.Where("year = ?", year).Order("employee_name ASC").Find(&statements).Error
+	return statements, err
+}
+
+// Batch retorna uma geração em lote
+func (g *IncomeStatementGenerator) Batch(id string) (*models.IncomeStatementBatch, error) {
+	var batch models.IncomeStatementBatch
+	if err := config.DB.First(&batch, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIncomeBatchNotFound
+		}
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// StartBatch inicia em segundo plano a geração dos comprovantes de todos os colaboradores
+// do ano. Somente anos encerrados: no ano corrente os holerites ainda mudam.
+func (g *IncomeStatementGenerator) StartBatch(year int, userID string) (*models.IncomeStatementBatch, error) {
+	if !g.validYear(year) {
+		return nil, ErrIncomeStatementYear
+	}
+	if !g.closedYear(year) {
+		return nil, ErrIncomeBatchOpenYear
+	}
+	var running int64
+	config.DB.Model(&models.IncomeStatementBatch{}).
+		Where("year = ? AND status = ?", year, models.IncomeStatementBatchRunning).
+		Count(&running)
+	if running > 0 {
+		return nil, ErrIncomeBatchRunning
+	}
+
+	batch := models.IncomeStatementBatch{
+		Year:      year,
+		Status:    models.IncomeStatementBatchRunning,
+		StartedBy: userID,
+	}
+	if err := config.DB.Create(&batch).Error; err != nil {
+		return nil, err
+	}
+
+	go func(batch models.IncomeStatementBatch) {
+		if err := g.RunBatch(&batch); err != nil {
+			log.Printf("⚠️ Informe de rendimentos %d: erro na geração: %v", batch.Year, err)
+		}
+	}(batch)
+	return &batch, nil
+}
+
+// RunBatch agrupa os holerites do ano por CPF e grava um comprovante por colaborador
+func (g *IncomeStatementGenerator) RunBatch(batch *models.IncomeStatementBatch) (err error) {
+	var problems []string
+	defer func() {
+		now := g.now()
+		batch.FinishedAt = &now
+		batch.Status = models.IncomeStatementBatchCompleted
+		if err != nil {
+			batch.Status = models.IncomeStatementBatchFailed
+			problems = append(problems, err.Error())
+		}
+		batch.Errors = strings.Join(problems, "\n")
+		config.DB.Save(batch)
+	}()
+
+	groups := map[string][]models.Payslip{}
+	var order []string
+	var chunk []models.Payslip
+	// FindInBatches pagina pela chave primária; a ordem por competência é feita por CPF
+	err = config.DB.Preload("Items").Scopes(IncomeYearPayslips(batch.Year)).
+		FindInBatches(&chunk, 500, func(tx *gorm.DB, _ int) error {
+			for _, p := range chunk {
+				cpf := cleanDigits(p.EmployeeCPF)
+				if !IsValidCPF(cpf) {
+					problems = append(problems, fmt.Sprintf("Holerite %s (%s) sem CPF válido", p.ID, p.EmployeeName))
+					continue
+				}
+				if _, ok := groups[cpf]; !ok {
+					order = append(order, cpf)
+				}
+				groups[cpf] = append(groups[cpf], p)
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	batch.Total = len(order)
+	for _, cpf := range order {
+		payslips := groups[cpf]
+		sort.SliceStable(payslips, func(i, j int) bool {
+			if payslips[i].ReferenceYear != payslips[j].ReferenceYear {
+				return payslips[i].ReferenceYear < payslips[j].ReferenceYear
+			}
+			return payslips[i].ReferenceMonth < payslips[j].ReferenceMonth
+		})
+		last := payslips[len(payslips)-1]
+		data := g.build(batch.Year, cpf, last.EmployeeName, payslips)
+		if err := g.save(batch.ID, cpf, last.UserID, data); err != nil {
+			batch.Failed++
+			problems = append(problems, fmt.Sprintf("CPF %s: %v", maskCPF(cpf), err))
+			continue
+		}
+		batch.Generated++
+	}
+	return nil
+}
+
+// save grava (ou substitui) o comprovante do CPF no ano
+func (g *IncomeStatementGenerator) save(batchID, cpf, userID string, data *IncomeStatementData) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	record := models.IncomeStatement{
+		Year:          data.Year,
+		CPF:           cpf,
+		UserID:        userID,
+		EmployeeName:  data.Beneficiary.Name,
+		TaxableIncome: data.Taxable.TotalIncome,
+		WithheldTax:   data.Taxable.WithheldTax,
+		Thirteenth:    data.Exclusive.Thirteenth,
+		PayslipCount:  data.PayslipCount,
+		Data:          string(payload),
+		BatchID:       batchID,
+		GeneratedAt:   data.GeneratedAt,
+	}
+	var existing models.IncomeStatement
+	if config.DB.Select("id, created_at").Where("year = ?", data.Year).Scopes(IncomeStatementsByCPF(cpf)).First(&existing).Error == nil {
+		record.ID, record.CreatedAt = existing.ID, existing.CreatedAt
+		return config.DB.Save(&record).Error
+	}
+	return config.DB.Create(&record).Error
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"fmt"
+)
+
+// ==================== PDF do Informe de Rendimentos ====================
+
+// Layout do comprovante (pontos, a partir do topo da página)
+const (
+	incomeMarginX   = 30.0
+	incomeRight     = PDFPageWidth - incomeMarginX
+	incomeRowHeight = 13.0
+	incomeValueCol  = 470.0 // Início da coluna "Valores em reais"
+)
+
+type incomePDFRow struct {
+	label string
+	value float64
+}
+
+// RenderIncomeStatementPDF gera o comprovante no layout da Receita Federal (quadros 1 a 8)
+func RenderIncomeStatementPDF(data *IncomeStatementData) []byte {
+	title := fmt.Sprintf("Comprovante de Rendimentos %d - %s", data.Year, data.Beneficiary.Name)
+	doc := NewPDFDocument(title, data.GeneratedAt)
+	doc.AddPage()
+	width := incomeRight - incomeMarginX
+
+	// Cabeçalho
+	doc.Rect(incomeMarginX, 30, width, 58, 0.8)
+	doc.Line(290, 30, 290, 88, 0.5)
+	doc.Text(incomeMarginX+8, 44, 8, true, "MINISTÉRIO DA FAZENDA")
+	doc.Text(incomeMarginX+8, 55, 7.5, false, "Secretaria Especial da Receita Federal do Brasil")
+	doc.Text(incomeMarginX+8, 66, 7.5, false, "Imposto sobre a Renda da Pessoa Física")
+	doc.Text(incomeMarginX+8, 80, 8, true, fmt.Sprintf("Exercício de %d", data.ExerciseYear))
+	doc.TextCenter(427, 46, 8.5, true, "Comprovante de Rendimentos Pagos e de")
+	doc.TextCenter(427, 57, 8.5, true, "Imposto sobre a Renda Retido na Fonte")
+	doc.TextCenter(427, 74, 9, true, fmt.Sprintf("Ano-calendário de %d", data.Year))
+	if data.Preliminary {
+		doc.TextCenter(427, 84, 7, false, "Prévia - ano-calendário em curso")
+	}
+
+	y := 96.0
+
+	// 1 e 2: identificação
+	y = incomeSection(doc, y, "1. Fonte Pagadora Pessoa Jurídica")
+	y = incomeFields(doc, y, "CNPJ", data.Payer.Document, "Nome Empresarial", data.Payer.Name)
+	y = incomeSection(doc, y+6, "2. Pessoa Física Beneficiária dos Rendimentos")
+	y = incomeFields(doc, y, "CPF", data.Beneficiary.Document, "Nome Completo", data.Beneficiary.Name)
+	doc.Rect(incomeMarginX, y, width, 24, 0.5)
+	doc.Text(incomeMarginX+4, y+8, 6.5, false, "Natureza do Rendimento")
+	doc.Text(incomeMarginX+4, y+19, 8.5, true, data.Nature)
+	y += 24
+
+	// 3: tributáveis
+	y = incomeTable(doc, y+6, "3. Rendimentos Tributáveis, Deduções e Imposto sobre a Renda Retido na Fonte", []incomePDFRow{
+		{"01. Total dos rendimentos (inclusive férias)", data.Taxable.TotalIncome},
+		{"02. Contribuição previdenciária oficial", data.Taxable.OfficialPension},
+		{"03. Contribuições a entidades de previdência complementar e a fundos de aposentadoria programada individual (Fapi)", data.Taxable.PrivatePension},
+		{"04. Pensão alimentícia (preencher também o quadro 7)", data.Taxable.Alimony},
+		{"05. Imposto sobre a renda retido na fonte", data.Taxable.WithheldTax},
+	})
+
+	// 4: isentos
+	y = incomeTable(doc, y+6, "4. Rendimentos Isentos e Não Tributáveis", []incomePDFRow{
+		{"01. Parcela isenta dos proventos de aposentadoria, reserva remunerada, reforma e pensão (65 anos ou mais)", 0},
+		{"02. Parcela isenta do 13º salário de aposentadoria, reserva remunerada, reforma e pensão (65 anos ou mais)", 0},
+		{"03. Diárias e ajudas de custo", data.Exempt.Allowances},
+		{"04. Pensão e proventos de aposentadoria ou reforma por moléstia grave ou acidente em serviço", 0},
+		{"05. Lucros e dividendos apurados a partir de 1996", 0},
+		{"06. Valores pagos ao titular ou sócio de microempresa ou empresa de pequeno porte", 0},
+		{"07. Indenizações por rescisão de contrato de trabalho, inclusive a título de PDV, e por acidente de trabalho", data.Exempt.Indemnities},
+		{"08. Juros de mora recebidos, devidos pelo atraso no pagamento de remuneração", 0},
+		{"09. Outros (especificar no quadro 7)", data.Exempt.Other},
+	})
+
+	// 5: tributação exclusiva
+	y = incomeTable(doc, y+6, "5. Rendimentos Sujeitos à Tributação Exclusiva (rendimento líquido)", []incomePDFRow{
+		{"01. Décimo terceiro salário", data.Exclusive.Thirteenth},
+		{"02. Imposto sobre a renda retido na fonte sobre 13º salário", data.Exclusive.ThirteenthWithheldTax},
+		{"03. Outros", 0},
+	})
+
+	// 6: rendimentos recebidos acumuladamente (não gerados pela folha)
+	y = incomeSection(doc, y+6, "6. Rendimentos Recebidos Acumuladamente - Art. 12-A da Lei nº 7.713, de 1988 (sujeitos à tributação exclusiva)")
+	doc.Rect(incomeMarginX, y, width, incomeRowHeight, 0.5)
+	doc.Text(incomeMarginX+4, y+9.5, 7.5, false, "Não houve rendimentos recebidos acumuladamente no ano-calendário.")
+	y += incomeRowHeight
+
+	// 7: informações complementares
+	y = incomeSection(doc, y+6, "7. Informações Complementares")
+	var notes []string
+	for _, n := range data.Complementary {
+		notes = append(notes, fmt.Sprintf("%s: R$ %s", n.Description, FormatBRL(n.Amount)))
+	}
+	for _, n := range data.Exempt.OtherDetails {
+		notes = append(notes, fmt.Sprintf("Isento (quadro 4, linha 09) - %s: R$ %s", n.Description, FormatBRL(n.Amount)))
+	}
+	if data.Taxable.Alimony > 0 {
+		notes = append(notes, fmt.Sprintf("Pensão alimentícia descontada em folha: R$ %s", FormatBRL(data.Taxable.Alimony)))
+	}
+	if len(notes) == 0 {
+		notes = []string{"-"}
+	}
+	// Espaço restante da página, reservando o quadro 8
+	maxNotes := int((780 - 36 - y - 8) / 10)
+	if len(notes) > maxNotes {
+		notes = append(notes[:maxNotes-1], fmt.Sprintf("... e mais %d informações", len(notes)-maxNotes+1))
+	}
+	boxHeight := float64(len(notes))*10 + 6
+	doc.Rect(incomeMarginX, y, width, boxHeight, 0.5)
+	for i, note := range notes {
+		doc.Text(incomeMarginX+4, y+11+float64(i)*10, 7.5, false, PDFFitText(note, 7.5, false, width-8))
+	}
+	y += boxHeight
+
+	// 8: responsável
+	y = incomeSection(doc, y+6, "8. Responsável pelas Informações")
+	doc.Rect(incomeMarginX, y, width, 26, 0.5)
+	doc.Line(330, y, 330, y+26, 0.5)
+	doc.Line(420, y, 420, y+26, 0.5)
+	doc.Text(incomeMarginX+4, y+8, 6.5, false, "Nome")
+	doc.Text(incomeMarginX+4, y+20, 8.5, true, PDFFitText(data.Responsible, 8.5, true, 290))
+	doc.Text(334, y+8, 6.5, false, "Data")
+	doc.Text(334, y+20, 8.5, true, data.GeneratedAt.Format("02/01/2006"))
+	doc.Text(424, y+8, 6.5, false, "Assinatura")
+	doc.Text(424, y+20, 7, false, "Dispensada (documento eletrônico)")
+
+	doc.Text(incomeMarginX, 810, 6.5, false, "Aprovado pela Instrução Normativa RFB nº 2.060, de 13 de dezembro de 2021. Gerado a partir dos holerites publicados no portal.")
+	return doc.Bytes()
+}
+
+// incomeSection faixa cinza com o título do quadro
+func incomeSection(doc *PDFDocument, y float64, title string) float64 {
+	width := incomeRight - incomeMarginX
+	doc.FillRect(incomeMarginX, y, width, 13, 0.88)
+	doc.Rect(incomeMarginX, y, width, 13, 0.5)
+	doc.Text(incomeMarginX+4, y+9.5, 7.5, true, PDFFitText(title, 7.5, true, width-8))
+	return y + 13
+}
+
+// incomeFields linha com dois campos (documento e nome)
+func incomeFields(doc *PDFDocument, y float64, label1, value1, label2, value2 string) float64 {
+	width := incomeRight - incomeMarginX
+	doc.Rect(incomeMarginX, y, width, 24, 0.5)
+	doc.Line(150, y, 150, y+24, 0.5)
+	doc.Text(incomeMarginX+4, y+8, 6.5, false, label1)
+	doc.Text(incomeMarginX+4, y+19, 8.5, true, value1)
+	doc.Text(154, y+8, 6.5, false, label2)
+	doc.Text(154, y+19, 8.5, true, PDFFitText(value2, 8.5, true, incomeRight-158))
+	return y + 24
+}
+
+// incomeTable quadro de valores com a coluna "Valores em reais"
+func incomeTable(doc *PDFDocument, y float64, title string, rows []incomePDFRow) float64 {
+	width := incomeRight - incomeMarginX
+	y = incomeSection(doc, y, title)
+	doc.TextRight(incomeRight-4, y-3.5, 6.5, false, "Valores em reais")
+
+	height := float64(len(rows)) * incomeRowHeight
+	doc.Rect(incomeMarginX, y, width, height, 0.5)
+	doc.Line(incomeValueCol, y, incomeValueCol, y+height, 0.5)
+	for i, row := range rows {
+		rowY := y + float64(i)*incomeRowHeight
+		if i > 0 {
+			doc.Line(incomeMarginX, rowY, incomeRight, rowY, 0.3)
+		}
+		doc.Text(incomeMarginX+4, rowY+9.5, 7.5, false, PDFFitText(row.label, 7.5, false, incomeValueCol-incomeMarginX-8))
+		doc.TextRight(incomeRight-4, rowY+9.5, 8, true, FormatBRL(row.value))
+	}
+	return y + height
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func incomePayslip(payslipType string, month int, paid time.Time, items ...models.PayslipItem) models.Payslip {
+	return models.Payslip{
+		EmployeeName:   "Ana Souza",
+		EmployeeCPF:    "123.456.789-09",
+		ReferenceMonth: month,
+		ReferenceYear:  2025,
+		PaymentDate:    &paid,
+		PayslipType:    payslipType,
+		Items:          items,
+	}
+}
+
+func earning(description string, amount float64) models.PayslipItem {
+	return models.PayslipItem{Type: "earning", Description: description, Amount: amount}
+}
+
+func deduction(code, description string, amount float64) models.PayslipItem {
+	return models.PayslipItem{Type: "deduction", Code: code, Description: description, Amount: amount}
+}
+
+func sampleIncomePayslips() []models.Payslip {
+	return []models.Payslip{
+		incomePayslip("mensal", 1, time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC),
+			earning("Salário Base", 5000),
+			earning("Ajuda de custo", 150),
+			earning("Salário Família", 60),
+			deduction("INSS", "INSS", 500),
+			deduction("IRRF", "IRRF", 300),
+			deduction("", "Plano de Saúde", 200),
+			deduction("", "Faltas", 100),
+		),
+		incomePayslip("13_primeira", 11, time.Date(2025, 11, 28, 0, 0, 0, 0, time.UTC),
+			earning("13º Salário 1ª parcela", 2500),
+		),
+		incomePayslip("13_segunda", 12, time.Date(2025, 12, 20, 0, 0, 0, 0, time.UTC),
+			earning("13º Salário", 5000),
+			deduction("13DESC", "Adiantamento 13º", 2500),
+			deduction("INSS", "INSS 13º", 450),
+			deduction("IRRF", "IRRF 13º", 200),
+		),
+		incomePayslip("rescisao", 12, time.Date(2025, 12, 30, 0, 0, 0, 0, time.UTC),
+			earning("Saldo de salário", 1000),
+			earning("Aviso prévio indenizado", 3000),
+			earning("Férias indenizadas", 2000),
+		),
+	}
+}
+
+func TestBuildIncomeStatement(t *testing.T) {
+	data := BuildIncomeStatement(2025,
+		IncomeStatementParty{Name: "Ana Souza", Document: "123.456.789-09"},
+		IncomeStatementParty{Name: "Fradema", Document: "12.345.678/0001-95"},
+		sampleIncomePayslips())
+
+	assert.Equal(t, 2026, data.ExerciseYear)
+	assert.Equal(t, 4, data.PayslipCount)
+
+	// Quadro 3: salário menos faltas, mais saldo da rescisão
+	assert.Equal(t, 5900.0, data.Taxable.TotalIncome)
+	assert.Equal(t, 500.0, data.Taxable.OfficialPension)
+	assert.Equal(t, 300.0, data.Taxable.WithheldTax)
+
+	// Quadro 4
+	assert.Equal(t, 150.0, data.Exempt.Allowances)
+	assert.Equal(t, 5000.0, data.Exempt.Indemnities)
+	assert.Equal(t, 60.0, data.Exempt.Other)
+	require.Len(t, data.Exempt.OtherDetails, 1)
+	assert.Equal(t, "Salário Família", data.Exempt.OtherDetails[0].Description)
+
+	// Quadro 5: 13º líquido do adiantamento já pago e do INSS
+	assert.Equal(t, 4550.0, data.Exclusive.Thirteenth)
+	assert.Equal(t, 200.0, data.Exclusive.ThirteenthWithheldTax)
+
+	// Quadro 7
+	require.Len(t, data.Complementary, 1)
+	assert.Equal(t, IncomeStatementNote{Description: "Plano de Saúde", Amount: 200}, data.Complementary[0])
+
+	// Resumo pelo mês de pagamento
+	require.Len(t, data.Monthly, 2)
+	assert.Equal(t, IncomeStatementMonth{Month: 2, TaxableIncome: 4900, INSS: 500, WithheldTax: 300}, data.Monthly[0])
+	assert.Equal(t, IncomeStatementMonth{Month: 12, TaxableIncome: 1000}, data.Monthly[1])
+}
+
+func TestClassifyIncomeItemThirteenthOutsideThirteenthPayslip(t *testing.T) {
+	category, thirteenth := classifyIncomeItem("rescisao", earning("13º Salário proporcional", 800))
+	assert.Equal(t, incomeThirteenth, category)
+	assert.True(t, thirteenth)
+
+	category, thirteenth = classifyIncomeItem("mensal", deduction("", "Previdência Privada", 100))
+	assert.Equal(t, deductionPrivatePension, category)
+	assert.False(t, thirteenth)
+
+	category, _ = classifyIncomeItem("mensal", deduction("", "Pensão Alimentícia", 100))
+	assert.Equal(t, deductionAlimony, category)
+}
+
+func TestFormatDocuments(t *testing.T) {
+	assert.Equal(t, "123.456.789-09", FormatCPF("12345678909"))
+	assert.Equal(t, "123.456.789-09", FormatCPF("123.456.789-09"))
+	assert.Equal(t, "123", FormatCPF("123"))
+	assert.Equal(t, "12.345.678/0001-95", FormatCNPJ("12345678000195"))
+	assert.Equal(t, "", FormatCNPJ(""))
+}
+
+func TestRenderIncomeStatementPDF(t *testing.T) {
+	data := BuildIncomeStatement(2025,
+		IncomeStatementParty{Name: "Ana Souza", Document: "123.456.789-09"},
+		IncomeStatementParty{Name: "Fradema", Document: "12.345.678/0001-95"},
+		sampleIncomePayslips())
+	data.GeneratedAt = time.Date(2026, 2, 10, 9, 0, 0, 0, time.UTC)
+
+	pdf := RenderIncomeStatementPDF(data)
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4")))
+	assert.Contains(t, string(pdf), "/Count 1")
+	assert.Contains(t, string(pdf), "(123.456.789-09)")
+	assert.Contains(t, string(pdf), "(12.345.678/0001-95)")
+	assert.Contains(t, string(pdf), "(5.900,00)")
+	assert.Contains(t, string(pdf), "(4.550,00)")
+	assert.Contains(t, string(pdf), "(10/02/2026)")
+}
+
+func TestIncomeStatementBatchRequiresClosedYear(t *testing.T) {
+	g := &IncomeStatementGenerator{now: func() time.Time { return time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC) }}
+
+	_, err := g.StartBatch(2026, "admin")
+	assert.ErrorIs(t, err, ErrIncomeBatchOpenYear)
+	_, err = g.StartBatch(2027, "admin")
+	assert.ErrorIs(t, err, ErrIncomeStatementYear)
+
+	// No ano corrente o comprovante gravado nunca é usado
+	_, ok := g.stored(2026, cpfAna)
+	assert.False(t, ok)
+	assert.True(t, g.closedYear(2025))
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/encryption"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== ROTAÇÃO DE CHAVES ====================
+
+// maxRotationErrors limita as ocorrências guardadas no resultado (as demais só contam)
+const maxRotationErrors = 50
+
+// KeyRotationResult registros recifrados por tabela
+type KeyRotationResult struct {
+	ActiveKey        string   `json:"active_key"`
+	Payslips         int      `json:"payslips"`
+	PayslipItems     int      `json:"payslip_items"`
+	IncomeStatements int      `json:"income_statements"`
+	TwoFactorSecrets int      `json:"two_factor_secrets"`
+	Documents        int      `json:"documents"`
+	PersonDocuments  int      `json:"person_documents"`
+	Failed           int      `json:"failed"`
+	Errors           []string `json:"errors,omitempty"`
+}
+
+func (r *KeyRotationResult) fail(format string, args ...interface{}) {
+	r.Failed++
+	if len(r.Errors) < maxRotationErrors {
+		r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+	}
+}
+
+// KeyRotator recifra em lotes os dados sensíveis com a chave mestra ativa
+type KeyRotator struct {
+	batchSize int
+}
+
+// KeyRotation instância global da rotação de chaves
+var KeyRotation = &KeyRotator{batchSize: 200}
+
+// Run recifra com a chave ativa os registros gravados com outra chave ou ainda em claro.
+// Com all, recifra todos (necessário após trocar a ENCRYPTION_INDEX_KEY, para refazer o
+// índice cego do CPF). As chaves antigas precisam continuar no chaveiro até o fim.
+func (r *KeyRotator) Run(all bool) (*KeyRotationResult, error) {
+	if !encryption.Enabled() {
+		return nil, encryption.ErrDisabled
+	}
+	result := &KeyRotationResult{ActiveKey: encryption.ActiveKeyID()}
+
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"holerites", func() error {
+			return rotateSealed[models.Payslip](r, all, models.PayslipSealedColumns, result, &result.Payslips)
+		}},
+		{"itens de holerite", func() error {
+			return rotateSealed[models.PayslipItem](r, all, models.PayslipItemSealedColumns, result, &result.PayslipItems)
+		}},
+		{"informes de rendimentos", func() error {
+			return rotateSealed[models.IncomeStatement](r, all, models.IncomeStatementSealedColumns, result, &result.IncomeStatements)
+		}},
+		{"segredos de 2FA", func() error {
+			return rotateSealed[models.UserTwoFactor](r, all, models.UserTwoFactorSealedColumns, result, &result.TwoFactorSecrets)
+		}},
+		{"documentos", func() error { return r.rotateDocuments(all, result) }},
+		{"RG/CNH", func() error { return r.rotatePersonDocuments(all, result) }},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			return result, fmt.Errorf("%s: %w", step.name, err)
+		}
+		log.Printf("🔐 Criptografia: %s processados", step.name)
+	}
+	return result, nil
+}
+
+// pendingKey escopo dos registros que não estão na chave ativa
+func pendingKey(all bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if all {
+			return db
+		}
+		return db.Where("enc_key_id IS NULL OR enc_key_id <> ?", encryption.ActiveKeyID())
+	}
+}
+
+// rotateSealed relê (decifrando no AfterFind) e regrava (cifrando no BeforeSave) só as
+// colunas do envelope, sem alterar updated_at nem os demais campos
+func rotateSealed[T any](r *KeyRotator, all bool, columns []string, result *KeyRotationResult, count *int) error {
+	var chunk []T
+	return config.DB.Model(new(T)).Scopes(pendingKey(all)).
+		FindInBatches(&chunk, r.batchSize, func(tx *gorm.DB, _ int) error {
+			for i := range chunk {
+				record := &chunk[i]
+				if err := config.DB.Model(record).Select(columns).Updates(record).Error; err != nil {
+					result.fail("%T: %v", record, err)
+					continue
+				}
+				*count++
+			}
+			return nil
+		}).Error
+}
+
+// rotateDocuments recifra os arquivos dos documentos
+func (r *KeyRotator) rotateDocuments(all bool, result *KeyRotationResult) error {
+	var chunk []models.Document
+	return config.DB.Scopes(pendingKey(all)).
+		FindInBatches(&chunk, r.batchSize, func(tx *gorm.DB, _ int) error {
+			for i := range chunk {
+				if err := ReencryptDocumentFile(&chunk[i]); err != nil {
+					result.fail("Documento %s: %v", chunk[i].ID, err)
+					continue
+				}
+				result.Documents++
+			}
+			return nil
+		}).Error
+}
+
+// personDocumentRow RG/CNH de uma pessoa em dbo.PessoasFisicasFradema
+type personDocumentRow struct {
+	Id  int
+	Rg  *string
+	Cnh *string
+}
+
+// rotatePersonDocuments recifra RG/CNH. Com ENCRYPTION_PERSON_DOCUMENTS desligada,
+// os tokens existentes voltam a ser gravados em claro.
+func (r *KeyRotator) rotatePersonDocuments(all bool, result *KeyRotationResult) error {
+	filter := "(Rg LIKE 'enc:%' OR Cnh LIKE 'enc:%')"
+	if personDocumentsEnabled() {
+		filter = "(ISNULL(Rg, '') <> '' OR ISNULL(Cnh, '') <> '')"
+	}
+
+	lastID := 0
+	for {
+		var rows []personDocumentRow
+		err := config.DB.Raw(`
+			SELECT TOP (?) Id, Rg, Cnh FROM dbo.PessoasFisicasFradema
+			WHERE Id > ? AND `+filter+`
+			ORDER BY Id
+		`, r.batchSize, lastID).Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			lastID = row.Id
+			rg, rgChanged, err := resealPersonDocument(row.Rg, all)
+			if err != nil {
+				result.fail("Pessoa %d: %v", row.Id, err)
+				continue
+			}
+			cnh, cnhChanged, err := resealPersonDocument(row.Cnh, all)
+			if err != nil {
+				result.fail("Pessoa %d: %v", row.Id, err)
+				continue
+			}
+			if !rgChanged && !cnhChanged {
+				continue
+			}
+			if err := config.DB.Exec(`UPDATE dbo.PessoasFisicasFradema SET Rg = ?, Cnh = ? WHERE Id = ?`, rg, cnh, row.Id).Error; err != nil {
+				result.fail("Pessoa %d: %v", row.Id, err)
+				continue
+			}
+			result.PersonDocuments++
+		}
+
+		if len(rows) < r.batchSize {
+			return nil
+		}
+	}
+}
+
+// resealPersonDocument valor de RG/CNH na forma em que deve ficar gravado e se mudou
+func resealPersonDocument(value *string, all bool) (*string, bool, error) {
+	if value == nil || *value == "" {
+		return value, false, nil
+	}
+
+	plain := *value
+	if encryption.IsToken(plain) {
+		if !all && personDocumentsEnabled() && encryption.TokenKeyID(plain) == encryption.ActiveKeyID() {
+			return value, false, nil
+		}
+		var err error
+		if plain, err = encryption.OpenString(plain); err != nil {
+			return nil, false, err
+		}
+		if !personDocumentsEnabled() {
+			return &plain, true, nil
+		}
+	} else if !personDocumentsEnabled() {
+		return value, false, nil
+	}
+
+	sealed, err := SealPersonDocument(&plain)
+	return sealed, err == nil, err
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ==================== EMBEDDINGS DA BASE DE CONHECIMENTO ====================
+
+// EmbeddingProvider gera os vetores usados na busca semântica
+type EmbeddingProvider interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Name identifica o modelo; vetores de modelos diferentes não são comparáveis
+	Name() string
+}
+
+// newEmbeddingsFromEnv Azure OpenAI quando AZURE_OPENAI_EMBEDDING_DEPLOYMENT estiver
+// configurado; sem ele, o embedder local (só aproxima grafias, não sinônimos)
+func newEmbeddingsFromEnv() EmbeddingProvider {
+	endpoint := os.Getenv("AZURE_OPENAI_ENDPOINT")
+	apiKey := os.Getenv("AZURE_OPENAI_KEY")
+	deployment := os.Getenv("AZURE_OPENAI_EMBEDDING_DEPLOYMENT")
+	if endpoint == "" || apiKey == "" || deployment == "" {
+		return NewLocalEmbedder(localEmbeddingDimensions)
+	}
+	return &AzureEmbeddings{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		apiKey:     apiKey,
+		deployment: deployment,
+		apiVersion: envOrDefault("AZURE_OPENAI_EMBEDDING_API_VERSION", "2024-02-01"),
+		batchSize:  16,
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// AzureEmbeddings deployment de embeddings do Azure OpenAI (text-embedding-3-small etc.)
+type AzureEmbeddings struct {
+	endpoint   string
+	apiKey     string
+	deployment string
+	apiVersion string
+	batchSize  int
+	client     *http.Client
+}
+
+func (a *AzureEmbeddings) Name() string { return "azure:" + a.deployment }
+
+// Embed envia os textos em lotes e devolve os vetores na ordem recebida
+func (a *AzureEmbeddings) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += a.batchSize {
+		batch := texts[start:min(start+a.batchSize, len(texts))]
+		embedded, err := a.embedBatch(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, embedded...)
+	}
+	return vectors, nil
+}
+
+func (a *AzureEmbeddings) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	body, _ := json.Marshal(map[string]interface{}{"input": texts})
+	url := fmt.Sprintf("%s/openai/deployments/%s/embeddings?api-version=%s", a.endpoint, a.deployment, a.apiVersion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("api-key", a.apiKey)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("Azure OpenAI embeddings: %s - %s", resp.Status, string(detail))
+	}
+
+	var parsed struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(texts))
+	for _, item := range parsed.Data {
+		if item.Index >= 0 && item.Index < len(vectors) {
+			vectors[item.Index] = item.Embedding
+		}
+	}
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("Azure OpenAI embeddings: vetor ausente para o texto %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+const localEmbeddingDimensions = 384
+
+// LocalEmbedder embedding determinístico sem serviço externo: termos normalizados e
+// trigramas de caracteres espalhados por hashing. Aproxima plurais, flexões e erros de
+// digitação; usado nos testes e quando o Azure OpenAI não está configurado.
+type LocalEmbedder struct {
+	dimensions int
+	text       *RAGService
+}
+
+// NewLocalEmbedder embedder local com o número de dimensões informado
+func NewLocalEmbedder(dimensions int) *LocalEmbedder {
+	return &LocalEmbedder{dimensions: dimensions, text: &RAGService{}}
+}
+
+func (l *LocalEmbedder) Name() string { return fmt.Sprintf("local-hash-%d", l.dimensions) }
+
+func (l *LocalEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = l.embed(text)
+	}
+	return vectors, nil
+}
+
+func (l *LocalEmbedder) embed(text string) []float32 {
+	vector := make([]float32, l.dimensions)
+	add := func(feature string, weight float32) {
+		h := fnv.New32a()
+		h.Write([]byte(feature))
+		sum := h.Sum32()
+		if sum&1 == 1 {
+			weight = -weight
+		}
+		vector[(sum>>1)%uint32(l.dimensions)] += weight
+	}
+	for _, token := range l.text.tokenize(text) {
+		add("w:"+token, 1)
+		padded := []rune(" " + token + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			add("c:"+string(padded[i:i+3]), 0.5)
+		}
+	}
+	normalize(vector)
+	return vector
+}
+
+// ==================== Vetores ====================
+
+// normalize deixa o vetor com norma 1 (o cosseno vira produto interno)
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+}
+
+// cosine similaridade do cosseno entre dois vetores (0 com dimensões diferentes)
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// encodeVector serializa o vetor para a coluna varbinary
+func encodeVector(v []float32) []byte {
+	out := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(out[4*i:], math.Float32bits(x))
+	}
+	return out
+}
+
+func decodeVector(data []byte) []float32 {
+	v := make([]float32, len(data)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return v
+}
@@ -0,0 +1,604 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== ÍNDICE DA BASE DE CONHECIMENTO ====================
+
+// Os artigos publicados são divididos em trechos (seções Markdown de até maxChunkRunes),
+// gravados em knowledge_chunks com os termos normalizados e o embedding. A busca combina
+// BM25 sobre os termos com a similaridade do cosseno dos embeddings, de modo que
+// sinônimos e paráfrases também encontram o artigo.
+
+const (
+	maxChunkRunes     = 1200
+	chunkOverlapRunes = 240 // Último parágrafo repetido no trecho seguinte da mesma seção
+	bm25K1            = 1.2
+	bm25B             = 0.75
+	passagesPerResult = 2
+	knowledgeScale    = 10 // Score final na escala da busca por palavras (título = 10)
+)
+
+// ErrKnowledgeIndexEmpty índice ainda não construído (usa-se a busca por palavras)
+var ErrKnowledgeIndexEmpty = errors.New("índice da base de conhecimento vazio")
+
+// KnowledgeIndexer mantém e consulta o índice híbrido dos artigos
+type KnowledgeIndexer struct {
+	embedder      EmbeddingProvider
+	text          *RAGService
+	minSimilarity float64       // Cosseno mínimo para um trecho contar como semelhante
+	vectorWeight  float64       // Peso do cosseno no score (o restante é do BM25)
+	cacheTTL      time.Duration // Recarrega o índice gravado por outras instâncias
+	now           func() time.Time
+
+	writeMu  sync.Mutex
+	mu       sync.Mutex
+	snapshot *knowledgeSnapshot
+}
+
+// KnowledgeIndex instância global do índice
+var KnowledgeIndex = newKnowledgeIndexFromEnv()
+
+// newKnowledgeIndexFromEnv ajustes em RAG_MIN_SIMILARITY e RAG_VECTOR_WEIGHT
+func newKnowledgeIndexFromEnv() *KnowledgeIndexer {
+	embedder := newEmbeddingsFromEnv()
+	minSimilarity := 0.35
+	if _, local := embedder.(*LocalEmbedder); local {
+		minSimilarity = 0.3
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("RAG_MIN_SIMILARITY"), 64); err == nil && v > 0 && v < 1 {
+		minSimilarity = v
+	}
+	vectorWeight := 0.6
+	if v, err := strconv.ParseFloat(os.Getenv("RAG_VECTOR_WEIGHT"), 64); err == nil && v >= 0 && v <= 1 {
+		vectorWeight = v
+	}
+	return NewKnowledgeIndexer(embedder, minSimilarity, vectorWeight)
+}
+
+// NewKnowledgeIndexer índice com o provedor de embeddings informado
+func NewKnowledgeIndexer(embedder EmbeddingProvider, minSimilarity, vectorWeight float64) *KnowledgeIndexer {
+	return &KnowledgeIndexer{
+		embedder:      embedder,
+		text:          &RAGService{},
+		minSimilarity: minSimilarity,
+		vectorWeight:  vectorWeight,
+		cacheTTL:      5 * time.Minute,
+		now:           time.Now,
+	}
+}
+
+// EmbeddingModel modelo usado nos embeddings
+func (k *KnowledgeIndexer) EmbeddingModel() string {
+	return k.embedder.Name()
+}
+
+// ==================== Trechos ====================
+
+// articleChunk trecho de um artigo antes de virar KnowledgeChunk
+type articleChunk struct {
+	Heading string
+	Content string
+}
+
+// chunkArticle divide o artigo: resumo, depois as seções (títulos Markdown) em blocos de
+// parágrafos de até maxChunkRunes. Parágrafos longos são quebrados em frases.
+func chunkArticle(article models.KnowledgeArticle) []articleChunk {
+	var chunks []articleChunk
+	if summary := strings.TrimSpace(article.Summary); summary != "" {
+		chunks = append(chunks, articleChunk{Heading: "Resumo", Content: summary})
+	}
+
+	heading := ""
+	var paragraphs []string
+	var current strings.Builder
+	flushParagraph := func() {
+		if p := strings.TrimSpace(current.String()); p != "" {
+			paragraphs = append(paragraphs, splitLongText(p, maxChunkRunes)...)
+		}
+		current.Reset()
+	}
+	flushSection := func() {
+		flushParagraph()
+		chunks = append(chunks, packParagraphs(heading, paragraphs)...)
+		paragraphs = nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(article.Content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			flushSection()
+			heading = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+		case trimmed == "":
+			flushParagraph()
+		default:
+			if current.Len() > 0 {
+				current.WriteByte('\n')
+			}
+			current.WriteString(trimmed)
+		}
+	}
+	flushSection()
+
+	if len(chunks) == 0 {
+		chunks = append(chunks, articleChunk{Content: strings.TrimSpace(article.Title)})
+	}
+	return chunks
+}
+
+// packParagraphs agrupa os parágrafos de uma seção, repetindo o último parágrafo curto
+// no trecho seguinte para não perder o contexto na fronteira
+func packParagraphs(heading string, paragraphs []string) []articleChunk {
+	var chunks []articleChunk
+	var group []string
+	size := 0
+	for _, p := range paragraphs {
+		n := len([]rune(p))
+		if size > 0 && size+n > maxChunkRunes {
+			chunks = append(chunks, articleChunk{Heading: heading, Content: strings.Join(group, "\n\n")})
+			last := group[len(group)-1]
+			group, size = nil, 0
+			if l := len([]rune(last)); l <= chunkOverlapRunes && l+n <= maxChunkRunes {
+				group, size = []string{last}, l
+			}
+		}
+		group = append(group, p)
+		size += n
+	}
+	if len(group) > 0 {
+		chunks = append(chunks, articleChunk{Heading: heading, Content: strings.Join(group, "\n\n")})
+	}
+	return chunks
+}
+
+// splitLongText quebra o texto em partes de até limit runas, de preferência no fim de
+// uma frase, senão no último espaço
+func splitLongText(text string, limit int) []string {
+	var parts []string
+	runes := []rune(text)
+	for len(runes) > limit {
+		cut := -1
+		for i := limit - 1; i > limit/2; i-- {
+			if (runes[i] == '.' || runes[i] == '!' || runes[i] == '?') && (i+1 == len(runes) || runes[i+1] == ' ' || runes[i+1] == '\n') {
+				cut = i + 1
+				break
+			}
+		}
+		if cut < 0 {
+			for i := limit - 1; i > 0; i-- {
+				if runes[i] == ' ' || runes[i] == '\n' {
+					cut = i
+					break
+				}
+			}
+		}
+		if cut <= 0 {
+			cut = limit
+		}
+		parts = append(parts, strings.TrimSpace(string(runes[:cut])))
+		runes = []rune(strings.TrimSpace(string(runes[cut:])))
+	}
+	if len(runes) > 0 {
+		parts = append(parts, string(runes))
+	}
+	return parts
+}
+
+// embeddingText texto enviado ao provedor: o título e a seção dão contexto ao trecho
+func embeddingText(article models.KnowledgeArticle, chunk articleChunk) string {
+	parts := []string{article.Title}
+	if chunk.Heading != "" {
+		parts = append(parts, chunk.Heading)
+	}
+	return strings.Join(append(parts, chunk.Content), "\n")
+}
+
+// prepareChunks monta os trechos do artigo reaproveitando os embeddings dos trechos que
+// não mudaram. changed=false quando o índice gravado já está atualizado.
+func (k *KnowledgeIndexer) prepareChunks(ctx context.Context, article models.KnowledgeArticle, existing []models.KnowledgeChunk) ([]models.KnowledgeChunk, bool, error) {
+	model := k.embedder.Name()
+	reusable := make(map[string][]byte, len(existing))
+	for _, chunk := range existing {
+		if chunk.EmbeddingModel == model && len(chunk.Embedding) > 0 {
+			reusable[chunk.ContentHash] = chunk.Embedding
+		}
+	}
+
+	metadata := article.Title + " " + article.Tags + " " + article.Keywords
+	var rows []models.KnowledgeChunk
+	var missing []int
+	var texts []string
+	for i, chunk := range chunkArticle(article) {
+		text := embeddingText(article, chunk)
+		sum := sha256.Sum256([]byte(text))
+		terms := k.text.tokenize(metadata + " " + chunk.Heading + " " + chunk.Content)
+		row := models.KnowledgeChunk{
+			ArticleID:      article.ID,
+			Position:       i,
+			Heading:        truncateRunes(chunk.Heading, 255),
+			Content:        chunk.Content,
+			Terms:          strings.Join(terms, " "),
+			TermCount:      len(terms),
+			ContentHash:    hex.EncodeToString(sum[:]),
+			EmbeddingModel: model,
+		}
+		if embedding, ok := reusable[row.ContentHash]; ok {
+			row.Embedding = embedding
+		} else {
+			missing = append(missing, i)
+			texts = append(texts, text)
+		}
+		rows = append(rows, row)
+	}
+
+	changed := len(missing) > 0 || len(rows) != len(existing)
+	for i := 0; !changed && i < len(rows); i++ {
+		old := existing[i]
+		changed = old.ContentHash != rows[i].ContentHash || old.Terms != rows[i].Terms || old.Position != i
+	}
+	if !changed {
+		return rows, false, nil
+	}
+
+	if len(texts) > 0 {
+		vectors, err := k.embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(vectors) != len(texts) {
+			return nil, false, errors.New("provedor de embeddings retornou quantidade diferente de vetores")
+		}
+		for j, i := range missing {
+			rows[i].Embedding = encodeVector(vectors[j])
+		}
+	}
+	return rows, true, nil
+}
+
+func truncateRunes(s string, limit int) string {
+	if r := []rune(s); len(r) > limit {
+		return string(r[:limit])
+	}
+	return s
+}
+
+// ==================== Indexação ====================
+
+// IndexArticle atualiza os trechos do artigo; artigos despublicados saem do índice
+func (k *KnowledgeIndexer) IndexArticle(ctx context.Context, article models.KnowledgeArticle) (bool, error) {
+	if !article.IsPublished || article.DeletedAt.Valid {
+		return k.RemoveArticle(article.ID)
+	}
+
+	k.writeMu.Lock()
+	defer k.writeMu.Unlock()
+
+	var existing []models.KnowledgeChunk
+	if err := config.DB.Where("article_id = ?", article.ID).Order("position").Find(&existing).Error; err != nil {
+		return false, err
+	}
+	rows, changed, err := k.prepareChunks(ctx, article, existing)
+	if err != nil || !changed {
+		return false, err
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("article_id = ?", article.ID).Delete(&models.KnowledgeChunk{}).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(rows, 50).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	k.invalidate()
+	return true, nil
+}
+
+// RemoveArticle tira o artigo do índice
+func (k *KnowledgeIndexer) RemoveArticle(articleID string) (bool, error) {
+	k.writeMu.Lock()
+	defer k.writeMu.Unlock()
+
+	result := config.DB.Where("article_id = ?", articleID).Delete(&models.KnowledgeChunk{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		k.invalidate()
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Refresh reindexa o artigo após criação, edição, publicação ou exclusão
+func (k *KnowledgeIndexer) Refresh(articleID string) {
+	if config.DB == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	var article models.KnowledgeArticle
+	err := config.DB.First(&article, "id = ?", articleID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		_, err = k.RemoveArticle(articleID)
+	} else if err == nil {
+		_, err = k.IndexArticle(ctx, article)
+	}
+	if err != nil {
+		log.Printf("⚠️ Base de conhecimento: erro ao indexar o artigo %s: %v", articleID, err)
+	}
+	// Categoria e demais dados do artigo também estão no índice em memória
+	k.invalidate()
+}
+
+// KnowledgeReindexResult resultado da reindexação completa
+type KnowledgeReindexResult struct {
+	Articles int
+	Updated  int
+	Removed  int64
+	Failed   int
+}
+
+// Rebuild confere todos os artigos publicados (só reprocessa o que mudou, inclusive a
+// troca do modelo de embeddings) e remove os trechos de artigos despublicados
+func (k *KnowledgeIndexer) Rebuild(ctx context.Context) (KnowledgeReindexResult, error) {
+	var result KnowledgeReindexResult
+	published := config.DB.Model(&models.KnowledgeArticle{}).Where("is_published = ?", true).Select("id")
+	removed := config.DB.Where("article_id NOT IN (?)", published).Delete(&models.KnowledgeChunk{})
+	if removed.Error != nil {
+		return result, removed.Error
+	}
+	result.Removed = removed.RowsAffected
+	if result.Removed > 0 {
+		k.invalidate()
+	}
+
+	var articles []models.KnowledgeArticle
+	if err := config.DB.Where("is_published = ?", true).Find(&articles).Error; err != nil {
+		return result, err
+	}
+	for _, article := range articles {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		result.Articles++
+		updated, err := k.IndexArticle(ctx, article)
+		if err != nil {
+			result.Failed++
+			log.Printf("⚠️ Base de conhecimento: erro ao indexar o artigo %s: %v", article.ID, err)
+			continue
+		}
+		if updated {
+			result.Updated++
+		}
+	}
+	return result, nil
+}
+
+// ==================== Consulta ====================
+
+// indexedChunk trecho carregado em memória para a busca
+type indexedChunk struct {
+	articleID string
+	heading   string
+	content   string
+	terms     map[string]int
+	length    int
+	vector    []float32
+}
+
+// knowledgeSnapshot índice em memória com as estatísticas do BM25
+type knowledgeSnapshot struct {
+	loadedAt time.Time
+	articles map[string]models.KnowledgeArticle
+	chunks   []indexedChunk
+	docFreq  map[string]int
+	avgLen   float64
+}
+
+// buildSnapshot monta o índice em memória; trechos de artigos fora da lista são ignorados
+// e vetores de outro modelo ficam de fora da parte semântica
+func buildSnapshot(articles []models.KnowledgeArticle, chunks []models.KnowledgeChunk, model string, loadedAt time.Time) *knowledgeSnapshot {
+	snap := &knowledgeSnapshot{
+		loadedAt: loadedAt,
+		articles: make(map[string]models.KnowledgeArticle, len(articles)),
+		docFreq:  map[string]int{},
+	}
+	for _, article := range articles {
+		snap.articles[article.ID] = article
+	}
+	total := 0
+	for _, chunk := range chunks {
+		if _, ok := snap.articles[chunk.ArticleID]; !ok {
+			continue
+		}
+		entry := indexedChunk{articleID: chunk.ArticleID, heading: chunk.Heading, content: chunk.Content, terms: map[string]int{}}
+		for _, term := range strings.Fields(chunk.Terms) {
+			entry.terms[term]++
+			entry.length++
+		}
+		for term := range entry.terms {
+			snap.docFreq[term]++
+		}
+		if chunk.EmbeddingModel == model && len(chunk.Embedding) > 0 {
+			entry.vector = decodeVector(chunk.Embedding)
+		}
+		total += entry.length
+		snap.chunks = append(snap.chunks, entry)
+	}
+	if len(snap.chunks) > 0 {
+		snap.avgLen = float64(total) / float64(len(snap.chunks))
+	}
+	return snap
+}
+
+func (k *KnowledgeIndexer) invalidate() {
+	k.mu.Lock()
+	k.snapshot = nil
+	k.mu.Unlock()
+}
+
+// current índice em memória, recarregado após alterações ou depois de cacheTTL
+func (k *KnowledgeIndexer) current() (*knowledgeSnapshot, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.snapshot != nil && k.now().Sub(k.snapshot.loadedAt) < k.cacheTTL {
+		return k.snapshot, nil
+	}
+
+	var articles []models.KnowledgeArticle
+	if err := config.DB.Where("is_published = ?", true).Find(&articles).Error; err != nil {
+		return nil, err
+	}
+	var chunks []models.KnowledgeChunk
+	if err := config.DB.Order("article_id, position").Find(&chunks).Error; err != nil {
+		return nil, err
+	}
+	k.snapshot = buildSnapshot(articles, chunks, k.embedder.Name(), k.now())
+	return k.snapshot, nil
+}
+
+// Search busca híbrida nos artigos publicados
+func (k *KnowledgeIndexer) Search(ctx context.Context, query string, category string, limit int) ([]SearchResult, error) {
+	if config.DB == nil {
+		return nil, ErrKnowledgeIndexEmpty
+	}
+	snap, err := k.current()
+	if err != nil {
+		return nil, err
+	}
+	return k.search(ctx, snap, query, category, limit)
+}
+
+// chunkHit trecho candidato com os scores de cada parte
+type chunkHit struct {
+	chunk  *indexedChunk
+	bm25   float64
+	cosine float64
+	score  float64
+}
+
+func (k *KnowledgeIndexer) search(ctx context.Context, snap *knowledgeSnapshot, query string, category string, limit int) ([]SearchResult, error) {
+	if len(snap.chunks) == 0 {
+		return nil, ErrKnowledgeIndexEmpty
+	}
+	if limit <= 0 {
+		limit = 5
+	}
+	queryTerms := uniqueStrings(k.text.tokenize(query))
+	if len(queryTerms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	// Sem o vetor da pergunta (provedor fora do ar) a busca segue só com o BM25
+	var queryVector []float32
+	embedCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	vectors, err := k.embedder.Embed(embedCtx, []string{query})
+	cancel()
+	if err != nil || len(vectors) != 1 {
+		log.Printf("⚠️ Base de conhecimento: busca sem embeddings: %v", err)
+	} else {
+		queryVector = vectors[0]
+	}
+
+	var hits []chunkHit
+	maxBM25 := 0.0
+	n := float64(len(snap.chunks))
+	for i := range snap.chunks {
+		chunk := &snap.chunks[i]
+		if category != "" && string(snap.articles[chunk.articleID].Category) != category {
+			continue
+		}
+		hit := chunkHit{chunk: chunk}
+		for _, term := range queryTerms {
+			tf := float64(chunk.terms[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(snap.docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			hit.bm25 += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(chunk.length)/snap.avgLen))
+		}
+		if queryVector != nil && chunk.vector != nil {
+			hit.cosine = cosine(queryVector, chunk.vector)
+		}
+		if hit.bm25 > 0 || hit.cosine >= k.minSimilarity {
+			hits = append(hits, hit)
+			maxBM25 = math.Max(maxBM25, hit.bm25)
+		}
+	}
+
+	for i := range hits {
+		lexical := 0.0
+		if maxBM25 > 0 {
+			lexical = hits[i].bm25 / maxBM25
+		}
+		if queryVector == nil {
+			hits[i].score = lexical
+			continue
+		}
+		semantic := 0.0
+		if hits[i].cosine >= k.minSimilarity {
+			semantic = hits[i].cosine
+		}
+		hits[i].score = k.vectorWeight*semantic + (1-k.vectorWeight)*lexical
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+
+	// Agrupa por artigo: vale o melhor trecho, e os melhores trechos vão para o contexto
+	var results []SearchResult
+	byArticle := map[string]int{}
+	for _, hit := range hits {
+		idx, seen := byArticle[hit.chunk.articleID]
+		if !seen {
+			if len(results) == limit {
+				continue
+			}
+			byArticle[hit.chunk.articleID] = len(results)
+			results = append(results, SearchResult{
+				Article:  snap.articles[hit.chunk.articleID],
+				Score:    hit.score * knowledgeScale,
+				Snippets: []string{k.text.extractSnippet(hit.chunk.content, query)},
+				Passages: []string{hit.chunk.content},
+			})
+			continue
+		}
+		if len(results[idx].Passages) < passagesPerResult {
+			results[idx].Passages = append(results[idx].Passages, hit.chunk.content)
+		}
+	}
+	if results == nil {
+		results = []SearchResult{}
+	}
+	return results, nil
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var out []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
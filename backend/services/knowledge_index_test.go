@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEmbedder embedder local que conta os textos enviados
+type countingEmbedder struct {
+	*LocalEmbedder
+	embedded int
+	err      error
+}
+
+func (c *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.embedded += len(texts)
+	return c.LocalEmbedder.Embed(ctx, texts)
+}
+
+var knowledgeFixtures = []models.KnowledgeArticle{
+	{
+		ID: "ferias", Title: "Política de Férias", Category: models.KnowledgeCategoryVacation, IsPublished: true,
+		Summary: "Regras para solicitar e parcelar as férias.",
+		Content: "## Parcelamento\nAs férias podem ser divididas em até três períodos, sendo um deles de no mínimo 14 dias.\n\n" +
+			"## Abono pecuniário\nO colaborador pode vender um terço das férias, solicitando até 15 dias antes do fim do período aquisitivo.",
+	},
+	{
+		ID: "vale", Title: "Vale-refeição e vale-alimentação", Category: models.KnowledgeCategoryBenefits, IsPublished: true,
+		Content: "O crédito do vale-refeição é feito todo dia 25 no cartão do benefício.\n\nEm caso de perda do cartão, solicite a segunda via ao RH.",
+	},
+	{
+		ID: "holerite", Title: "Como ler o holerite", Category: models.KnowledgeCategoryPayroll, IsPublished: true,
+		Content: "O holerite mostra os proventos, os descontos de INSS e IRRF e o salário líquido depositado.",
+	},
+}
+
+// indexFixtures monta o índice em memória com os artigos de exemplo
+func indexFixtures(t *testing.T, k *KnowledgeIndexer) *knowledgeSnapshot {
+	t.Helper()
+	var chunks []models.KnowledgeChunk
+	for _, article := range knowledgeFixtures {
+		rows, changed, err := k.prepareChunks(context.Background(), article, nil)
+		require.NoError(t, err)
+		require.True(t, changed)
+		chunks = append(chunks, rows...)
+	}
+	return buildSnapshot(knowledgeFixtures, chunks, k.EmbeddingModel(), time.Now())
+}
+
+func TestChunkArticle(t *testing.T) {
+	chunks := chunkArticle(knowledgeFixtures[0])
+	require.Len(t, chunks, 3)
+	assert.Equal(t, articleChunk{Heading: "Resumo", Content: "Regras para solicitar e parcelar as férias."}, chunks[0])
+	assert.Equal(t, "Parcelamento", chunks[1].Heading)
+	assert.Equal(t, "Abono pecuniário", chunks[2].Heading)
+
+	// Seção longa: trechos dentro do limite, com o parágrafo curto repetido na fronteira
+	paragraph := strings.Repeat("Texto do regulamento interno sobre jornada. ", 10)
+	long := models.KnowledgeArticle{Title: "Jornada", Content: strings.Repeat(paragraph+"\n\n", 8) + strings.Repeat("palavra ", 400)}
+	chunks = chunkArticle(long)
+	require.Greater(t, len(chunks), 3)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len([]rune(chunk.Content)), maxChunkRunes)
+	}
+	assert.True(t, strings.HasPrefix(chunks[1].Content, strings.TrimSpace(paragraph)))
+
+	// Artigo sem conteúdo vira um trecho com o título
+	assert.Equal(t, []articleChunk{{Content: "Aviso"}}, chunkArticle(models.KnowledgeArticle{Title: "Aviso"}))
+}
+
+func TestPrepareChunksReusesEmbeddings(t *testing.T) {
+	embedder := &countingEmbedder{LocalEmbedder: NewLocalEmbedder(64)}
+	k := NewKnowledgeIndexer(embedder, 0.3, 0.6)
+	ctx := context.Background()
+	article := knowledgeFixtures[0]
+
+	first, changed, err := k.prepareChunks(ctx, article, nil)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 3, embedder.embedded)
+	for _, row := range first {
+		assert.Len(t, decodeVector(row.Embedding), 64)
+		assert.Equal(t, "local-hash-64", row.EmbeddingModel)
+	}
+
+	// Sem alteração: nada a gravar nem a embutir
+	_, changed, err = k.prepareChunks(ctx, article, first)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, 3, embedder.embedded)
+
+	// Só a seção editada volta ao provedor
+	edited := article
+	edited.Content = strings.Replace(article.Content, "14 dias", "catorze dias", 1)
+	second, changed, err := k.prepareChunks(ctx, edited, first)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 4, embedder.embedded)
+	assert.Equal(t, first[2].Embedding, second[2].Embedding)
+
+	// Tags novas mudam só os termos do BM25
+	tagged := article
+	tagged.Tags = "descanso"
+	third, changed, err := k.prepareChunks(ctx, tagged, first)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 4, embedder.embedded)
+	assert.Contains(t, third[1].Terms, "descanso")
+
+	// Troca de modelo reprocessa tudo
+	other := NewKnowledgeIndexer(NewLocalEmbedder(32), 0.3, 0.6)
+	_, changed, err = other.prepareChunks(ctx, article, first)
+	require.NoError(t, err)
+	assert.True(t, changed)
+}
+
+func TestKnowledgeHybridSearch(t *testing.T) {
+	k := NewKnowledgeIndexer(NewLocalEmbedder(localEmbeddingDimensions), 0.3, 0.6)
+	snap := indexFixtures(t, k)
+	ctx := context.Background()
+
+	results, err := k.search(ctx, snap, "posso vender parte das minhas férias?", "", 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "ferias", results[0].Article.ID)
+	assert.Contains(t, results[0].Passages[0], "vender um terço")
+	assert.LessOrEqual(t, results[0].Score, float64(knowledgeScale))
+
+	// Erro de digitação sem nenhum termo em comum: só a parte semântica encontra
+	results, err = k.search(ctx, snap, "holeritte", "", 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "holerite", results[0].Article.ID)
+
+	// Filtro de categoria
+	results, err = k.search(ctx, snap, "cartão do benefício", string(models.KnowledgeCategoryVacation), 3)
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.Equal(t, models.KnowledgeCategoryVacation, result.Article.Category)
+	}
+
+	// Índice vazio: o RAGService volta para a busca por palavras
+	_, err = k.search(ctx, buildSnapshot(nil, nil, k.EmbeddingModel(), time.Now()), "férias", "", 3)
+	assert.ErrorIs(t, err, ErrKnowledgeIndexEmpty)
+}
+
+func TestKnowledgeSearchWithoutEmbeddings(t *testing.T) {
+	embedder := &countingEmbedder{LocalEmbedder: NewLocalEmbedder(localEmbeddingDimensions)}
+	k := NewKnowledgeIndexer(embedder, 0.3, 0.6)
+	snap := indexFixtures(t, k)
+
+	// Provedor fora do ar: segue só com o BM25
+	embedder.err = errors.New("429 Too Many Requests")
+	results, err := k.search(context.Background(), snap, "segunda via do cartão", "", 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "vale", results[0].Article.ID)
+	assert.Equal(t, float64(knowledgeScale), results[0].Score)
+}
+
+func TestAzureEmbeddings(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/openai/deployments/embed-small/embeddings", r.URL.Path)
+		assert.Equal(t, "2024-02-01", r.URL.Query().Get("api-version"))
+		assert.Equal(t, "chave", r.Header.Get("api-key"))
+
+		var body struct {
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		// Resposta fora de ordem: o índice de cada item define a posição
+		var data []item
+		for i := len(body.Input) - 1; i >= 0; i-- {
+			data = append(data, item{Index: i, Embedding: []float32{float32(len(body.Input[i])), 1}})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer server.Close()
+
+	t.Setenv("AZURE_OPENAI_ENDPOINT", server.URL+"/")
+	t.Setenv("AZURE_OPENAI_KEY", "chave")
+	t.Setenv("AZURE_OPENAI_EMBEDDING_DEPLOYMENT", "embed-small")
+	embedder, ok := newEmbeddingsFromEnv().(*AzureEmbeddings)
+	require.True(t, ok)
+	embedder.batchSize = 2
+	assert.Equal(t, "azure:embed-small", embedder.Name())
+
+	vectors, err := embedder.Embed(context.Background(), []string{"a", "bb", "ccc"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, [][]float32{{1, 1}, {2, 1}, {3, 1}}, vectors)
+
+	t.Setenv("AZURE_OPENAI_EMBEDDING_DEPLOYMENT", "")
+	assert.IsType(t, &LocalEmbedder{}, newEmbeddingsFromEnv())
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ==================== PROVEDORES DE LLM (ASSISTENTE) ====================
+
+// LLMMessage mensagem no formato de chat (system, user, assistant, tool)
+type LLMMessage struct {
+	Role       string        `json:"role"`
+	Content    string        `json:"content,omitempty"`
+	ToolCalls  []LLMToolCall `json:"tool_calls,omitempty"`   // Ferramentas pedidas pelo modelo (assistant)
+	ToolCallID string        `json:"tool_call_id,omitempty"` // Chamada respondida (tool)
+	Name       string        `json:"-"`                      // Ferramenta que produziu o resultado (tool)
+}
+
+// LLMToolCall ferramenta pedida pelo modelo; várias podem vir na mesma resposta
+type LLMToolCall struct {
+	ID       string          `json:"id"`
+	Type     string          `json:"type"` // "function"
+	Function LLMFunctionCall `json:"function"`
+}
+
+// LLMFunctionCall função e argumentos (JSON) de uma chamada de ferramenta
+type LLMFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// LLMRequest pedido de resposta ao modelo
+type LLMRequest struct {
+	Messages  []LLMMessage
+	Tools     []FunctionDefinition // Ferramentas que o modelo pode chamar
+	MaxTokens int
+}
+
+// LLMUsage tokens consumidos. Estimated quando o provedor não informou (streaming).
+type LLMUsage struct {
+	PromptTokens     int  `json:"prompt_tokens"`
+	CompletionTokens int  `json:"completion_tokens"`
+	TotalTokens      int  `json:"total_tokens"`
+	Estimated        bool `json:"estimated,omitempty"`
+}
+
+// Add soma o consumo de outra chamada
+func (u *LLMUsage) Add(other LLMUsage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.Estimated = u.Estimated || other.Estimated
+}
+
+// LLMResponse resposta do modelo: texto e/ou chamadas de ferramenta
+type LLMResponse struct {
+	Content      string
+	ToolCalls    []LLMToolCall
+	FinishReason string
+	Usage        LLMUsage
+	Provider     string // Provedor que respondeu (o secundário, em caso de fallback)
+}
+
+// LLMClient provedor de modelo de linguagem usado pelo chat
+type LLMClient interface {
+	Name() string
+	Complete(ctx context.Context, req LLMRequest) (*LLMResponse, error)
+	// Stream entrega o texto em partes via onContent e devolve a resposta completa
+	Stream(ctx context.Context, req LLMRequest, onContent func(string)) (*LLMResponse, error)
+}
+
+// ErrLLMNotConfigured nenhum provedor configurado
+var ErrLLMNotConfigured = errors.New("assistente de IA não configurado")
+
+// LLM provedor global do chat (LLM_PROVIDER, com LLM_FALLBACK_PROVIDER opcional);
+// nil quando não configurado
+var LLM = newLLMFromEnv()
+
+// newLLMFromEnv provedores "azure" (default), "openai" (API compatível: OpenAI, vLLM,
+// Ollama, LiteLLM...) e "fake" (respostas simuladas para desenvolvimento)
+func newLLMFromEnv() LLMClient {
+	primary := llmProviderFromEnv(envOrDefault("LLM_PROVIDER", "azure"))
+	name := os.Getenv("LLM_FALLBACK_PROVIDER")
+	if name == "" {
+		return primary
+	}
+	secondary := llmProviderFromEnv(name)
+	switch {
+	case primary == nil:
+		return secondary
+	case secondary == nil:
+		return primary
+	}
+	return &FallbackLLM{Primary: primary, Secondary: secondary}
+}
+
+func llmProviderFromEnv(name string) LLMClient {
+	switch name {
+	case "azure":
+		cfg := AzureOpenAIConfig{
+			Endpoint:   os.Getenv("AZURE_OPENAI_ENDPOINT"),
+			APIKey:     os.Getenv("AZURE_OPENAI_KEY"),
+			Deployment: os.Getenv("AZURE_OPENAI_DEPLOYMENT"),
+			APIVersion: envOrDefault("AZURE_OPENAI_API_VERSION", "2024-02-15-preview"),
+			Timeout:    envSeconds("AZURE_OPENAI_TIMEOUT", 60*time.Second),
+			MaxRetries: envRetries("AZURE_OPENAI_MAX_RETRIES", 2),
+		}
+		if cfg.Endpoint == "" || cfg.APIKey == "" || cfg.Deployment == "" {
+			return nil
+		}
+		return NewAzureOpenAIClient(cfg)
+	case "openai":
+		cfg := OpenAICompatibleConfig{
+			BaseURL:    envOrDefault("OPENAI_BASE_URL", "https://api.openai.com/v1"),
+			APIKey:     os.Getenv("OPENAI_API_KEY"),
+			Model:      os.Getenv("OPENAI_MODEL"),
+			Timeout:    envSeconds("OPENAI_TIMEOUT", 60*time.Second),
+			MaxRetries: envRetries("OPENAI_MAX_RETRIES", 2),
+		}
+		if cfg.Model == "" {
+			log.Println("⚠️ Chat: OPENAI_MODEL não configurado, provedor openai ignorado")
+			return nil
+		}
+		return NewOpenAICompatibleClient(cfg)
+	case "fake":
+		return &ScriptedLLM{}
+	}
+	log.Printf("⚠️ Chat: provedor de LLM desconhecido: %q", name)
+	return nil
+}
+
+func envSeconds(key string, fallback time.Duration) time.Duration {
+	if seconds, err := strconv.Atoi(os.Getenv(key)); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return fallback
+}
+
+func envRetries(key string, fallback int) int {
+	if retries, err := strconv.Atoi(os.Getenv(key)); err == nil && retries >= 0 {
+		return retries
+	}
+	return fallback
+}
+
+// ==================== Fallback ====================
+
+// FallbackLLM usa o secundário quando o primário falha (após as novas tentativas). No
+// streaming só há troca se o primário falhar antes de enviar texto ao usuário.
+type FallbackLLM struct {
+	Primary   LLMClient
+	Secondary LLMClient
+}
+
+func (f *FallbackLLM) Name() string { return f.Primary.Name() }
+
+func (f *FallbackLLM) Complete(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	resp, err := f.Primary.Complete(ctx, req)
+	if err == nil || ctx.Err() != nil {
+		return resp, err
+	}
+	f.logFallback(err)
+	return f.Secondary.Complete(ctx, req)
+}
+
+func (f *FallbackLLM) Stream(ctx context.Context, req LLMRequest, onContent func(string)) (*LLMResponse, error) {
+	emitted := false
+	resp, err := f.Primary.Stream(ctx, req, func(content string) {
+		emitted = true
+		onContent(content)
+	})
+	if err == nil || emitted || ctx.Err() != nil {
+		return resp, err
+	}
+	f.logFallback(err)
+	return f.Secondary.Stream(ctx, req, onContent)
+}
+
+func (f *FallbackLLM) logFallback(err error) {
+	log.Printf("⚠️ Chat: %s falhou, usando %s: %v", f.Primary.Name(), f.Secondary.Name(), err)
+	llmStats.record(f.Primary.Name(), func(s *LLMProviderStats) { s.Fallbacks++ })
+}
+
+// ==================== Consumo ====================
+
+// LLMProviderStats consumo de um provedor desde o início do processo
+type LLMProviderStats struct {
+	Provider         string `json:"provider"`
+	Requests         int64  `json:"requests"`
+	Failures         int64  `json:"failures"`
+	Retries          int64  `json:"retries"`
+	Fallbacks        int64  `json:"fallbacks"` // Vezes em que o secundário assumiu
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	EstimatedTokens  int64  `json:"estimated_tokens"` // Parte dos tokens estimada localmente
+}
+
+type llmStatsRegistry struct {
+	mu        sync.Mutex
+	providers map[string]*LLMProviderStats
+}
+
+var llmStats = &llmStatsRegistry{providers: map[string]*LLMProviderStats{}}
+
+func (r *llmStatsRegistry) record(provider string, update func(*LLMProviderStats)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats, ok := r.providers[provider]
+	if !ok {
+		stats = &LLMProviderStats{Provider: provider}
+		r.providers[provider] = stats
+	}
+	update(stats)
+}
+
+// recordUsage contabiliza uma chamada concluída
+func (r *llmStatsRegistry) recordUsage(provider string, usage LLMUsage) {
+	r.record(provider, func(s *LLMProviderStats) {
+		s.Requests++
+		s.PromptTokens += int64(usage.PromptTokens)
+		s.CompletionTokens += int64(usage.CompletionTokens)
+		if usage.Estimated {
+			s.EstimatedTokens += int64(usage.TotalTokens)
+		}
+	})
+}
+
+func (r *llmStatsRegistry) recordFailure(provider string) {
+	r.record(provider, func(s *LLMProviderStats) {
+		s.Requests++
+		s.Failures++
+	})
+}
+
+// LLMStats consumo por provedor, em ordem alfabética
+func LLMStats() []LLMProviderStats {
+	llmStats.mu.Lock()
+	defer llmStats.mu.Unlock()
+	out := make([]LLMProviderStats, 0, len(llmStats.providers))
+	for _, stats := range llmStats.providers {
+		out = append(out, *stats)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out
+}
+
+// estimateUsage estimativa (~4 caracteres por token) quando o provedor não informa o consumo
+func estimateUsage(req LLMRequest, completion string) LLMUsage {
+	prompt := 0
+	for _, msg := range req.Messages {
+		prompt += estimateTokens(msg.Content) + 4 // Papel e separadores
+		for _, call := range msg.ToolCalls {
+			prompt += estimateTokens(call.Function.Name + call.Function.Arguments)
+		}
+	}
+	completionTokens := estimateTokens(completion)
+	return LLMUsage{PromptTokens: prompt, CompletionTokens: completionTokens, TotalTokens: prompt + completionTokens, Estimated: true}
+}
+
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// ScriptedReply resposta (ou erro) programada do ScriptedLLM
+type ScriptedReply struct {
+	Response LLMResponse
+	Err      error
+}
+
+// ScriptedLLM provedor simulado: devolve as respostas programadas em ordem e registra os
+// pedidos recebidos. Sem roteiro (ou com ele esgotado) responde um texto fixo; é o
+// provedor "fake" do LLM_PROVIDER, para desenvolvimento sem chave de API.
+type ScriptedLLM struct {
+	ProviderName string
+	Replies      []ScriptedReply
+
+	mu       sync.Mutex
+	requests []LLMRequest
+}
+
+// fakeLLMAnswer resposta padrão do provedor simulado
+const fakeLLMAnswer = "Esta é uma resposta simulada do assistente."
+
+func (s *ScriptedLLM) Name() string {
+	if s.ProviderName != "" {
+		return s.ProviderName
+	}
+	return "fake"
+}
+
+// Requests pedidos recebidos até agora
+func (s *ScriptedLLM) Requests() []LLMRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]LLMRequest(nil), s.requests...)
+}
+
+func (s *ScriptedLLM) next(req LLMRequest) (*LLMResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+
+	reply := ScriptedReply{Response: LLMResponse{Content: fakeLLMAnswer, FinishReason: "stop"}}
+	if len(s.Replies) > 0 {
+		reply, s.Replies = s.Replies[0], s.Replies[1:]
+	}
+	if reply.Err != nil {
+		llmStats.recordFailure(s.Name())
+		return nil, reply.Err
+	}
+	resp := reply.Response
+	resp.Provider = s.Name()
+	if resp.Usage.TotalTokens == 0 {
+		resp.Usage = estimateUsage(req, resp.Content)
+	}
+	llmStats.recordUsage(s.Name(), resp.Usage)
+	return &resp, nil
+}
+
+func (s *ScriptedLLM) Complete(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.next(req)
+}
+
+// Stream entrega o texto palavra a palavra
+func (s *ScriptedLLM) Stream(ctx context.Context, req LLMRequest, onContent func(string)) (*LLMResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	resp, err := s.next(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, part := range strings.SplitAfter(resp.Content, " ") {
+		if part != "" {
+			onContent(part)
+		}
+	}
+	return resp, nil
+}
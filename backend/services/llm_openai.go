@@ -0,0 +1,362 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==================== API DE CHAT NO FORMATO DA OPENAI ====================
+
+// AzureOpenAIConfig deployment do Azure OpenAI
+type AzureOpenAIConfig struct {
+	Endpoint   string
+	APIKey     string
+	Deployment string
+	APIVersion string
+	Timeout    time.Duration // Por tentativa (streaming: a resposta inteira)
+	MaxRetries int
+}
+
+// OpenAICompatibleConfig API compatível com a OpenAI (OpenAI, vLLM, Ollama, LiteLLM...)
+type OpenAICompatibleConfig struct {
+	BaseURL    string // Ex.: https://api.openai.com/v1, http://ollama:11434/v1
+	APIKey     string // Opcional em servidores próprios
+	Model      string
+	Timeout    time.Duration
+	MaxRetries int
+}
+
+// OpenAIClient cliente de /chat/completions; Azure e os compatíveis só diferem na URL,
+// na autenticação e no campo model
+type OpenAIClient struct {
+	name        string
+	url         string
+	headers     map[string]string
+	model       string
+	streamUsage bool // Pede o consumo no fim do streaming (stream_options)
+	timeout     time.Duration
+	maxRetries  int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	client      *http.Client
+	sleep       func(ctx context.Context, d time.Duration) error
+}
+
+// NewAzureOpenAIClient cliente do deployment informado
+func NewAzureOpenAIClient(cfg AzureOpenAIConfig) *OpenAIClient {
+	c := newOpenAIClient("azure:"+cfg.Deployment, cfg.Timeout, cfg.MaxRetries)
+	c.url = fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		strings.TrimSuffix(cfg.Endpoint, "/"), cfg.Deployment, cfg.APIVersion)
+	c.headers["api-key"] = cfg.APIKey
+	// stream_options só é aceito a partir da versão 2024-09-01 da API
+	c.streamUsage = cfg.APIVersion >= "2024-09-01"
+	return c
+}
+
+// NewOpenAICompatibleClient cliente de uma API compatível com a OpenAI
+func NewOpenAICompatibleClient(cfg OpenAICompatibleConfig) *OpenAIClient {
+	c := newOpenAIClient("openai:"+cfg.Model, cfg.Timeout, cfg.MaxRetries)
+	c.url = strings.TrimSuffix(cfg.BaseURL, "/") + "/chat/completions"
+	c.model = cfg.Model
+	c.streamUsage = true
+	if cfg.APIKey != "" {
+		c.headers["Authorization"] = "Bearer " + cfg.APIKey
+	}
+	return c
+}
+
+func newOpenAIClient(name string, timeout time.Duration, maxRetries int) *OpenAIClient {
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	return &OpenAIClient{
+		name:       name,
+		headers:    map[string]string{"Content-Type": "application/json"},
+		timeout:    timeout,
+		maxRetries: maxRetries,
+		baseDelay:  500 * time.Millisecond,
+		maxDelay:   8 * time.Second,
+		client:     &http.Client{}, // Prazo pelo contexto: o streaming pode passar do timeout do cliente
+		sleep:      sleepContext,
+	}
+}
+
+func (c *OpenAIClient) Name() string { return c.name }
+
+// LLMError resposta de erro do provedor
+type LLMError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *LLMError) Error() string {
+	return fmt.Sprintf("%s: %d %s - %s", e.Provider, e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// openAIRequest corpo do /chat/completions
+type openAIRequest struct {
+	Model               string               `json:"model,omitempty"`
+	Messages            []LLMMessage         `json:"messages"`
+	MaxCompletionTokens int                  `json:"max_completion_tokens,omitempty"`
+	Stream              bool                 `json:"stream,omitempty"`
+	StreamOptions       *openAIStreamOptions `json:"stream_options,omitempty"`
+	Tools               []openAITool         `json:"tools,omitempty"`
+	ToolChoice          string               `json:"tool_choice,omitempty"`
+}
+
+type openAITool struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message      LLMMessage  `json:"message"`
+		Delta        openAIDelta `json:"delta"`
+		FinishReason *string     `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *LLMUsage `json:"usage"`
+}
+
+// openAIDelta parte da resposta no streaming; cada chamada de ferramenta chega em
+// pedaços identificados pelo index (id e nome no primeiro, argumentos em seguida)
+type openAIDelta struct {
+	Content   string `json:"content"`
+	ToolCalls []struct {
+		Index    int             `json:"index"`
+		ID       string          `json:"id"`
+		Type     string          `json:"type"`
+		Function LLMFunctionCall `json:"function"`
+	} `json:"tool_calls"`
+}
+
+func (c *OpenAIClient) body(req LLMRequest, stream bool) []byte {
+	payload := openAIRequest{
+		Model:               c.model,
+		Messages:            req.Messages,
+		MaxCompletionTokens: req.MaxTokens,
+		Stream:              stream,
+	}
+	for _, tool := range req.Tools {
+		payload.Tools = append(payload.Tools, openAITool{Type: "function", Function: tool})
+	}
+	if len(payload.Tools) > 0 {
+		payload.ToolChoice = "auto" // Deixa a IA decidir quando chamar ferramentas
+	}
+	if stream && c.streamUsage {
+		payload.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+	body, _ := json.Marshal(payload)
+	return body
+}
+
+// Complete resposta inteira
+func (c *OpenAIClient) Complete(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	body := c.body(req, false)
+	var parsed openAIResponse
+	err := c.withRetry(ctx, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+		resp, err := c.post(ctx, body)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		parsed = openAIResponse{}
+		return json.NewDecoder(resp.Body).Decode(&parsed)
+	})
+	if err != nil {
+		llmStats.recordFailure(c.name)
+		return nil, err
+	}
+	if len(parsed.Choices) == 0 {
+		llmStats.recordFailure(c.name)
+		return nil, fmt.Errorf("%s: resposta sem choices", c.name)
+	}
+
+	choice := parsed.Choices[0]
+	out := &LLMResponse{Content: choice.Message.Content, ToolCalls: choice.Message.ToolCalls, Provider: c.name}
+	if choice.FinishReason != nil {
+		out.FinishReason = *choice.FinishReason
+	}
+	if parsed.Usage != nil {
+		out.Usage = *parsed.Usage
+	} else {
+		out.Usage = estimateUsage(req, out.Content)
+	}
+	llmStats.recordUsage(c.name, out.Usage)
+	return out, nil
+}
+
+// Stream resposta em partes (SSE). Novas tentativas só antes de chegar o primeiro evento.
+func (c *OpenAIClient) Stream(ctx context.Context, req LLMRequest, onContent func(string)) (*LLMResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var resp *http.Response
+	err := c.withRetry(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.post(ctx, c.body(req, true))
+		return err
+	})
+	if err != nil {
+		llmStats.recordFailure(c.name)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	out := &LLMResponse{Provider: c.name}
+	var content strings.Builder
+	var usage *LLMUsage
+	calls := map[int]*LLMToolCall{}
+	var order []int
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+		var event openAIResponse
+		if json.Unmarshal([]byte(data), &event) != nil {
+			continue
+		}
+		if event.Usage != nil {
+			usage = event.Usage
+		}
+		if len(event.Choices) == 0 {
+			continue
+		}
+		choice := event.Choices[0]
+		if choice.FinishReason != nil {
+			out.FinishReason = *choice.FinishReason
+		}
+		if choice.Delta.Content != "" {
+			content.WriteString(choice.Delta.Content)
+			onContent(choice.Delta.Content)
+		}
+		for _, part := range choice.Delta.ToolCalls {
+			call, ok := calls[part.Index]
+			if !ok {
+				call = &LLMToolCall{Type: "function"}
+				calls[part.Index] = call
+				order = append(order, part.Index)
+			}
+			if part.ID != "" {
+				call.ID = part.ID
+			}
+			call.Function.Name += part.Function.Name
+			call.Function.Arguments += part.Function.Arguments
+		}
+	}
+	for _, index := range order {
+		out.ToolCalls = append(out.ToolCalls, *calls[index])
+	}
+	if err := scanner.Err(); err != nil {
+		llmStats.recordFailure(c.name)
+		return nil, fmt.Errorf("%s: erro ao ler o streaming: %w", c.name, err)
+	}
+
+	out.Content = content.String()
+	if usage != nil {
+		out.Usage = *usage
+	} else {
+		completion := out.Content
+		for _, call := range out.ToolCalls {
+			completion += call.Function.Name + call.Function.Arguments
+		}
+		out.Usage = estimateUsage(req, completion)
+	}
+	llmStats.recordUsage(c.name, out.Usage)
+	return out, nil
+}
+
+// post envia o pedido; respostas diferentes de 200 viram *LLMError
+func (c *OpenAIClient) post(ctx context.Context, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.name, err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+	llmErr := &LLMError{Provider: c.name, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(detail))}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		llmErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return nil, llmErr
+}
+
+// withRetry repete falhas de rede, 408, 429 e 5xx com backoff exponencial e jitter,
+// respeitando o Retry-After do provedor
+func (c *OpenAIClient) withRetry(ctx context.Context, attempt func(ctx context.Context) error) error {
+	for n := 0; ; n++ {
+		err := attempt(ctx)
+		if err == nil || n >= c.maxRetries || ctx.Err() != nil || !retryableLLMError(err) {
+			return err
+		}
+		delay := c.baseDelay << n
+		if delay > c.maxDelay {
+			delay = c.maxDelay
+		}
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		var llmErr *LLMError
+		if errors.As(err, &llmErr) && llmErr.RetryAfter > 0 && llmErr.RetryAfter <= 30*time.Second {
+			delay = llmErr.RetryAfter
+		}
+		llmStats.record(c.name, func(s *LLMProviderStats) { s.Retries++ })
+		if err := c.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func retryableLLMError(err error) bool {
+	var llmErr *LLMError
+	if errors.As(err, &llmErr) {
+		return llmErr.StatusCode == http.StatusTooManyRequests || llmErr.StatusCode == http.StatusRequestTimeout ||
+			llmErr.StatusCode >= 500
+	}
+	// Resposta malformada não melhora com nova tentativa
+	var syntaxErr *json.SyntaxError
+	return !errors.As(err, &syntaxErr)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
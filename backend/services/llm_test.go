@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noSleep registra as esperas do backoff sem esperar
+func noSleep(c *OpenAIClient) *[]time.Duration {
+	var delays []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	return &delays
+}
+
+func TestAzureOpenAIClientRetriesRateLimit(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "/openai/deployments/gpt/chat/completions", r.URL.Path)
+		assert.Equal(t, "2024-02-15-preview", r.URL.Query().Get("api-version"))
+		assert.Equal(t, "chave", r.Header.Get("api-key"))
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.NotContains(t, body, "model", "o deployment define o modelo")
+		assert.Equal(t, "auto", body["tool_choice"])
+		tools := body["tools"].([]interface{})
+		require.Len(t, tools, 1)
+		assert.Equal(t, "function", tools[0].(map[string]interface{})["type"])
+		assert.NotContains(t, body, "functions", "campo obsoleto")
+
+		if calls == 1 {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"Olá!"},"finish_reason":"stop"}],
+			"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`)
+	}))
+	defer server.Close()
+
+	client := NewAzureOpenAIClient(AzureOpenAIConfig{Endpoint: server.URL + "/", APIKey: "chave", Deployment: "gpt", APIVersion: "2024-02-15-preview", MaxRetries: 2})
+	delays := noSleep(client)
+
+	resp, err := client.Complete(context.Background(), LLMRequest{
+		Messages: []LLMMessage{{Role: "user", Content: "oi"}},
+		Tools:    []FunctionDefinition{{Name: "get_vacation_balance", Parameters: map[string]interface{}{"type": "object"}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Olá!", resp.Content)
+	assert.Equal(t, "azure:gpt", resp.Provider)
+	assert.Equal(t, LLMUsage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15}, resp.Usage)
+	assert.Equal(t, []time.Duration{3 * time.Second}, *delays, "respeita o Retry-After")
+}
+
+func TestOpenAICompatibleClientDoesNotRetryBadRequest(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer sk-teste", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"error":{"message":"context length exceeded"}}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenAICompatibleClient(OpenAICompatibleConfig{BaseURL: server.URL + "/v1", APIKey: "sk-teste", Model: "llama3", MaxRetries: 3})
+	noSleep(client)
+
+	_, err := client.Complete(context.Background(), LLMRequest{Messages: []LLMMessage{{Role: "user", Content: "oi"}}})
+	var llmErr *LLMError
+	require.ErrorAs(t, err, &llmErr)
+	assert.Equal(t, http.StatusBadRequest, llmErr.StatusCode)
+	assert.Contains(t, llmErr.Body, "context length")
+	assert.Equal(t, 1, calls)
+}
+
+func TestOpenAIClientBackoffGivesUp(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewOpenAICompatibleClient(OpenAICompatibleConfig{BaseURL: server.URL, Model: "m", MaxRetries: 2})
+	delays := noSleep(client)
+
+	_, err := client.Complete(context.Background(), LLMRequest{Messages: []LLMMessage{{Role: "user", Content: "oi"}}})
+	assert.Error(t, err)
+	assert.Equal(t, 3, calls)
+	require.Len(t, *delays, 2)
+	assert.True(t, (*delays)[1] >= (*delays)[0]/2 && (*delays)[1] <= client.maxDelay, "backoff exponencial com jitter")
+}
+
+func TestOpenAIClientStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, true, body["stream"])
+		assert.Equal(t, "m", body["model"])
+		assert.Equal(t, map[string]interface{}{"include_usage": true}, body["stream_options"])
+
+		for _, event := range []string{
+			`{"choices":[{"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"get_vacation_balance","arguments":""}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_b","type":"function","function":{"name":"get_payslips","arguments":"{}"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"ano\":"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"2026}"}}]},"finish_reason":"tool_calls"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":40,"completion_tokens":9,"total_tokens":49}}`,
+			`[DONE]`,
+		} {
+			io.WriteString(w, "data: "+event+"\n\n")
+		}
+	}))
+	defer server.Close()
+
+	client := NewOpenAICompatibleClient(OpenAICompatibleConfig{BaseURL: server.URL, Model: "m"})
+	resp, err := client.Stream(context.Background(), LLMRequest{Messages: []LLMMessage{{Role: "user", Content: "saldo"}}}, func(string) {
+		t.Error("chamada de ferramenta não envia texto")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []LLMToolCall{
+		{ID: "call_a", Type: "function", Function: LLMFunctionCall{Name: "get_vacation_balance", Arguments: `{"ano":2026}`}},
+		{ID: "call_b", Type: "function", Function: LLMFunctionCall{Name: "get_payslips", Arguments: "{}"}},
+	}, resp.ToolCalls)
+	assert.Equal(t, "tool_calls", resp.FinishReason)
+	assert.Equal(t, 49, resp.Usage.TotalTokens)
+}
+
+func TestAzureStreamEstimatesUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.NotContains(t, body, "stream_options", "não suportado na versão antiga da API")
+		io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Seu saldo \"}}]}\n\n")
+		io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"é de 30 dias.\"},\"finish_reason\":\"stop\"}]}\n\n")
+		io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := NewAzureOpenAIClient(AzureOpenAIConfig{Endpoint: server.URL, APIKey: "k", Deployment: "gpt", APIVersion: "2024-02-15-preview"})
+	var parts []string
+	resp, err := client.Stream(context.Background(), LLMRequest{Messages: []LLMMessage{{Role: "user", Content: "Qual meu saldo de férias?"}}}, func(s string) {
+		parts = append(parts, s)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Seu saldo ", "é de 30 dias."}, parts)
+	assert.Equal(t, "Seu saldo é de 30 dias.", resp.Content)
+	assert.True(t, resp.Usage.Estimated)
+	assert.Equal(t, 6, resp.Usage.CompletionTokens)
+	assert.Equal(t, resp.Usage.PromptTokens+resp.Usage.CompletionTokens, resp.Usage.TotalTokens)
+}
+
+func TestFallbackLLM(t *testing.T) {
+	primary := &ScriptedLLM{ProviderName: "primario", Replies: []ScriptedReply{{Err: errors.New("503")}}}
+	secondary := &ScriptedLLM{ProviderName: "secundario", Replies: []ScriptedReply{{Response: LLMResponse{Content: "resposta reserva"}}}}
+	llm := &FallbackLLM{Primary: primary, Secondary: secondary}
+	before := providerStats("primario").Fallbacks
+
+	resp, err := llm.Complete(context.Background(), LLMRequest{Messages: []LLMMessage{{Role: "user", Content: "oi"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "resposta reserva", resp.Content)
+	assert.Equal(t, "secundario", resp.Provider)
+	assert.Len(t, secondary.Requests(), 1)
+	assert.Equal(t, before+1, providerStats("primario").Fallbacks)
+
+	// Primário saudável: o secundário não é chamado
+	var streamed strings.Builder
+	resp, err = llm.Stream(context.Background(), LLMRequest{}, func(s string) { streamed.WriteString(s) })
+	require.NoError(t, err)
+	assert.Equal(t, fakeLLMAnswer, streamed.String())
+	assert.Equal(t, "primario", resp.Provider)
+	assert.Len(t, secondary.Requests(), 1)
+}
+
+// failingStream envia parte do texto e falha
+type failingStream struct{ ScriptedLLM }
+
+func (f *failingStream) Stream(ctx context.Context, req LLMRequest, onContent func(string)) (*LLMResponse, error) {
+	onContent("Seu saldo")
+	return nil, errors.New("conexão interrompida")
+}
+
+func TestFallbackLLMStreamAfterContent(t *testing.T) {
+	secondary := &ScriptedLLM{ProviderName: "secundario"}
+	llm := &FallbackLLM{Primary: &failingStream{}, Secondary: secondary}
+
+	// Texto já enviado ao usuário: trocar de provedor duplicaria a resposta
+	_, err := llm.Stream(context.Background(), LLMRequest{}, func(string) {})
+	assert.Error(t, err)
+	assert.Empty(t, secondary.Requests())
+}
+
+func TestNewLLMFromEnv(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "openai")
+	t.Setenv("OPENAI_MODEL", "llama3")
+	t.Setenv("OPENAI_BASE_URL", "http://ollama:11434/v1")
+	t.Setenv("OPENAI_TIMEOUT", "5")
+	t.Setenv("LLM_FALLBACK_PROVIDER", "fake")
+
+	llm, ok := newLLMFromEnv().(*FallbackLLM)
+	require.True(t, ok)
+	primary := llm.Primary.(*OpenAIClient)
+	assert.Equal(t, "openai:llama3", primary.Name())
+	assert.Equal(t, "http://ollama:11434/v1/chat/completions", primary.url)
+	assert.Equal(t, 5*time.Second, primary.timeout)
+	assert.Equal(t, "fake", llm.Secondary.Name())
+
+	// Sem o primário configurado fica só o secundário
+	t.Setenv("OPENAI_MODEL", "")
+	assert.Equal(t, "fake", newLLMFromEnv().Name())
+}
+
+func providerStats(name string) LLMProviderStats {
+	for _, stats := range LLMStats() {
+		if stats.Provider == name {
+			return stats
+		}
+	}
+	return LLMProviderStats{}
+}
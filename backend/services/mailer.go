@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ==================== Envio de e-mails ====================
+
+// MailMessage e-mail em texto simples
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer envia e-mails; a implementação é escolhida por MAIL_DRIVER
+type Mailer interface {
+	Send(msg MailMessage) error
+}
+
+// ErrMailNotConfigured MAIL_DRIVER ausente (ou desconhecido) fora de desenvolvimento
+var ErrMailNotConfigured = errors.New("MAIL_DRIVER não configurado: defina smtp, file ou log (o padrão file só vale com APP_ENV=development)")
+
+// Mail remetente usado pelos fluxos de conta (redefinição de senha etc.)
+var Mail Mailer = NewMailerFromEnv()
+
+// CheckMailConfig valida MAIL_DRIVER na inicialização
+func CheckMailConfig() error {
+	_, err := mailDriver(os.Getenv("MAIL_DRIVER"), os.Getenv("APP_ENV"))
+	return err
+}
+
+// mailDriver driver efetivo: sem MAIL_DRIVER, usa file apenas em desenvolvimento
+func mailDriver(driver, appEnv string) (string, error) {
+	switch driver = strings.ToLower(strings.TrimSpace(driver)); driver {
+	case "smtp", "file", "log":
+		return driver, nil
+	case "":
+		if strings.EqualFold(appEnv, "development") {
+			return "file", nil
+		}
+	}
+	return "", ErrMailNotConfigured
+}
+
+// NewMailerFromEnv cria o remetente configurado:
+//   - MAIL_DRIVER=smtp: SMTP_HOST, SMTP_PORT, SMTP_USER, SMTP_PASSWORD, MAIL_FROM
+//   - MAIL_DRIVER=file (padrão com APP_ENV=development): grava os e-mails em MAIL_DIR (padrão ./tmp/mail)
+//   - MAIL_DRIVER=log: registra destinatário e assunto no log, nunca o corpo
+//
+// Fora de desenvolvimento MAIL_DRIVER é obrigatório; sem ele, o envio falha.
+func NewMailerFromEnv() Mailer {
+	from := envOrDefault("MAIL_FROM", "no-reply@fradema.com.br")
+
+	driver, err := mailDriver(os.Getenv("MAIL_DRIVER"), os.Getenv("APP_ENV"))
+	switch driver {
+	case "smtp":
+		return &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     envOrDefault("SMTP_PORT", "587"),
+			Username: os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	case "log":
+		return &LogMailer{From: from}
+	case "file":
+		return &FileMailer{Dir: envOrDefault("MAIL_DIR", filepath.Join("tmp", "mail")), From: from}
+	default:
+		return unconfiguredMailer{err: err}
+	}
+}
+
+// unconfiguredMailer recusa todos os envios (MAIL_DRIVER ausente)
+type unconfiguredMailer struct {
+	err error
+}
+
+// Send devolve o erro de configuração
+func (m unconfiguredMailer) Send(MailMessage) error {
+	return m.err
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// formatMail monta a mensagem RFC 5322 (texto simples, UTF-8)
+func formatMail(from string, msg MailMessage, date time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// SMTPMailer envia pelo servidor SMTP (STARTTLS quando suportado)
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send envia o e-mail via SMTP
+func (m *SMTPMailer) Send(msg MailMessage) error {
+	if m.Host == "" {
+		return fmt.Errorf("SMTP_HOST não configurado")
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{msg.To}, formatMail(m.From, msg, time.Now()))
+}
+
+// FileMailer grava cada e-mail como arquivo .eml (testes e ambientes sem SMTP)
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// Send grava o e-mail em Dir
+func (m *FileMailer) Send(msg MailMessage) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102-150405"), uuid.New().String()[:8])
+	path := filepath.Join(m.Dir, name)
+	if err := os.WriteFile(path, formatMail(m.From, msg, now), 0o600); err != nil {
+		return err
+	}
+
+	log.Printf("📧 E-mail para %s gravado em %s", msg.To, path)
+	return nil
+}
+
+// LogMailer apenas registra o envio no log. O corpo nunca é registrado, pois pode
+// conter links de redefinição de senha e outros segredos.
+type LogMailer struct {
+	From string
+}
+
+// Send registra remetente, destinatário e assunto
+func (m *LogMailer) Send(msg MailMessage) error {
+	log.Printf("📧 E-mail de %s para %s: %s (corpo omitido)", m.From, msg.To, msg.Subject)
+	return nil
+}
@@ -0,0 +1,767 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== Organograma ====================
+
+var (
+	ErrOrgSelfManager   = errors.New("o colaborador não pode ser gestor de si mesmo")
+	ErrOrgCycle         = errors.New("o gestor informado é subordinado do colaborador")
+	ErrOrgUserNotFound  = errors.New("colaborador não encontrado")
+	ErrOrgInvalidChange = errors.New("informe ao menos uma alteração de lotação")
+)
+
+// OrgService resolve a hierarquia (gestor direto, subordinados e equipe) a partir das lotações vigentes
+type OrgService struct {
+	now func() time.Time
+}
+
+// Org organograma compartilhado por férias, PDI, workflow e chat
+var Org = NewOrgService()
+
+// NewOrgService cria o serviço de organograma
+func NewOrgService() *OrgService {
+	return &OrgService{now: time.Now}
+}
+
+// activeAt filtra as lotações vigentes na data
+func activeAt(db *gorm.DB, at time.Time) *gorm.DB {
+	day := truncateDay(at)
+	return db.Where("effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", day, day)
+}
+
+// CurrentAssignment retorna a lotação vigente do colaborador
+func (o *OrgService) CurrentAssignment(userID string) (*models.OrgAssignment, error) {
+	var assignment models.OrgAssignment
+	err := activeAt(config.DB, o.now()).
+		Preload("Department").Preload("Position").Preload("Manager").
+		Where("user_id = ?", userID).
+		Order("effective_from DESC").
+		First(&assignment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// ManagerOf retorna o gestor direto vigente ("" quando não há)
+func (o *OrgService) ManagerOf(userID string) string {
+	var managerIDs []string
+	activeAt(config.DB.Model(&models.OrgAssignment{}), o.now()).
+		Where("user_id = ? AND manager_id IS NOT NULL", userID).
+		Order("effective_from DESC").
+		Limit(1).
+		Pluck("manager_id", &managerIDs)
+	if len(managerIDs) == 0 {
+		return ""
+	}
+	return managerIDs[0]
+}
+
+// ManagerChain retorna a linha hierárquica acima do colaborador (gestor, gestor do gestor...)
+func (o *OrgService) ManagerChain(userID string) []string {
+	var chain []string
+	seen := map[string]bool{userID: true}
+	for current := o.ManagerOf(userID); current != "" && !seen[current]; current = o.ManagerOf(current) {
+		seen[current] = true
+		chain = append(chain, current)
+	}
+	return chain
+}
+
+// reportLines carrega os pares gestor → subordinado vigentes
+func (o *OrgService) reportLines() map[string][]string {
+	var lines []struct {
+		UserID    string
+		ManagerID string
+	}
+	activeAt(config.DB.Model(&models.OrgAssignment{}), o.now()).
+		Select("user_id, manager_id").
+		Where("manager_id IS NOT NULL").
+		Scan(&lines)
+
+	index := make(map[string][]string)
+	for _, l := range lines {
+		index[l.ManagerID] = append(index[l.ManagerID], l.UserID)
+	}
+	return index
+}
+
+// DirectReports retorna os subordinados diretos
+func (o *OrgService) DirectReports(managerID string) []string {
+	return o.reportLines()[managerID]
+}
+
+// AllReports retorna os subordinados diretos e indiretos
+func (o *OrgService) AllReports(managerID string) []string {
+	return collectReports(o.reportLines(), managerID)
+}
+
+// IsManager indica se o colaborador tem subordinados ou é responsável por um departamento
+func (o *OrgService) IsManager(userID string) bool {
+	if len(o.DirectReports(userID)) > 0 {
+		return true
+	}
+	var heads int64
+	config.DB.Model(&models.OrgDepartment{}).Where("head_user_id = ? AND active = ?", userID, true).Count(&heads)
+	return heads > 0
+}
+
+// Manages indica se o gestor está acima do colaborador na hierarquia (em qualquer nível)
+func (o *OrgService) Manages(managerID, userID string) bool {
+	for _, id := range o.ManagerChain(userID) {
+		if id == managerID {
+			return true
+		}
+	}
+	return false
+}
+
+// TeamOf retorna a equipe do colaborador: gestor, colegas com o mesmo gestor e subordinados
+func (o *OrgService) TeamOf(userID string) []string {
+	index := o.reportLines()
+	managerID := o.ManagerOf(userID)
+
+	seen := map[string]bool{userID: true}
+	var team []string
+	add := func(ids ...string) {
+		for _, id := range ids {
+			if id != "" && !seen[id] {
+				seen[id] = true
+				team = append(team, id)
+			}
+		}
+	}
+
+	add(managerID)
+	if managerID != "" {
+		add(index[managerID]...)
+	}
+	add(collectReports(index, userID)...)
+	return team
+}
+
+// collectReports percorre a árvore de subordinados a partir do gestor (protegido contra ciclos)
+func collectReports(index map[string][]string, managerID string) []string {
+	seen := map[string]bool{managerID: true}
+	var reports []string
+	queue := []string{managerID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, id := range index[current] {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			reports = append(reports, id)
+			queue = append(queue, id)
+		}
+	}
+	return reports
+}
+
+// wouldCreateCycle indica se tornar managerID gestor de userID cria um ciclo
+func wouldCreateCycle(index map[string][]string, userID, managerID string) bool {
+	if userID == managerID {
+		return true
+	}
+	for _, id := range collectReports(index, userID) {
+		if id == managerID {
+			return true
+		}
+	}
+	return false
+}
+
+// orgReportLine relação gestor → subordinado com vigência
+type orgReportLine struct {
+	UserID        string
+	ManagerID     string
+	EffectiveFrom time.Time
+	EffectiveTo   *time.Time
+}
+
+func (l orgReportLine) activeAt(day time.Time) bool {
+	return !l.EffectiveFrom.After(day) && (l.EffectiveTo == nil || l.EffectiveTo.After(day))
+}
+
+// reportLinesFrom relações vigentes na data ou agendadas para depois dela
+func (o *OrgService) reportLinesFrom(from time.Time) ([]orgReportLine, error) {
+	var lines []orgReportLine
+	err := config.DB.Model(&models.OrgAssignment{}).
+		Select("user_id, manager_id, effective_from, effective_to").
+		Where("manager_id IS NOT NULL AND (effective_to IS NULL OR effective_to > ?)", truncateDay(from)).
+		Scan(&lines).Error
+	return lines, err
+}
+
+// wouldCreateCycleFrom indica se tornar managerID gestor de userID a partir de from cria
+// um ciclo em alguma data: na própria vigência ou quando entra (ou termina) uma lotação
+// agendada de outro colaborador. As lotações do próprio userID a partir de from são
+// substituídas pela nova e não entram na verificação.
+func wouldCreateCycleFrom(lines []orgReportLine, userID, managerID string, from time.Time) bool {
+	from = truncateDay(from)
+	checkpoints := []time.Time{from}
+	for _, l := range lines {
+		if l.UserID == userID {
+			continue
+		}
+		if l.EffectiveFrom.After(from) {
+			checkpoints = append(checkpoints, l.EffectiveFrom)
+		}
+		if l.EffectiveTo != nil && l.EffectiveTo.After(from) {
+			checkpoints = append(checkpoints, *l.EffectiveTo)
+		}
+	}
+
+	for _, day := range checkpoints {
+		index := make(map[string][]string)
+		for _, l := range lines {
+			if l.UserID != userID && l.activeAt(day) {
+				index[l.ManagerID] = append(index[l.ManagerID], l.UserID)
+			}
+		}
+		if wouldCreateCycle(index, userID, managerID) {
+			return true
+		}
+	}
+	return false
+}
+
+// ==================== MUDANÇAS DE LOTAÇÃO ====================
+
+// OrgAssignmentChange mudança de lotação. Campos nulos mantêm o valor vigente.
+type OrgAssignmentChange struct {
+	DepartmentID  *string   `json:"department_id"`
+	PositionID    *string   `json:"position_id"`
+	ManagerID     *string   `json:"manager_id"`
+	ClearManager  bool      `json:"clear_manager"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	Reason        string    `json:"reason"`
+}
+
+// ChangeAssignment registra uma nova lotação com vigência a partir da data informada.
+// A lotação anterior é encerrada na mesma data e lotações futuras substituídas.
+func (o *OrgService) ChangeAssignment(userID string, change OrgAssignmentChange, actorID string) (*models.OrgAssignment, error) {
+	if change.DepartmentID == nil && change.PositionID == nil && change.ManagerID == nil && !change.ClearManager {
+		return nil, ErrOrgInvalidChange
+	}
+
+	var user models.User
+	if err := config.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, ErrOrgUserNotFound
+	}
+
+	effectiveFrom := truncateDay(change.EffectiveFrom)
+	if change.EffectiveFrom.IsZero() {
+		effectiveFrom = truncateDay(o.now())
+	}
+
+	if change.ManagerID != nil && *change.ManagerID != "" {
+		if *change.ManagerID == userID {
+			return nil, ErrOrgSelfManager
+		}
+		lines, err := o.reportLinesFrom(effectiveFrom)
+		if err != nil {
+			return nil, err
+		}
+		if wouldCreateCycleFrom(lines, userID, *change.ManagerID, effectiveFrom) {
+			return nil, ErrOrgCycle
+		}
+	}
+
+	assignment := models.OrgAssignment{
+		UserID:        userID,
+		EffectiveFrom: effectiveFrom,
+		Reason:        change.Reason,
+	}
+	if actorID != "" {
+		assignment.CreatedBy = &actorID
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Herda os campos não alterados da lotação vigente na data
+		var previous models.OrgAssignment
+		if err := activeAt(tx, effectiveFrom).Where("user_id = ?", userID).
+			Order("effective_from DESC").First(&previous).Error; err == nil {
+			assignment.ColaboradorID = previous.ColaboradorID
+			assignment.DepartmentID = previous.DepartmentID
+			assignment.PositionID = previous.PositionID
+			assignment.ManagerID = previous.ManagerID
+		}
+
+		if change.DepartmentID != nil {
+			assignment.DepartmentID = emptyToNil(*change.DepartmentID)
+		}
+		if change.PositionID != nil {
+			assignment.PositionID = emptyToNil(*change.PositionID)
+		}
+		if change.ManagerID != nil {
+			assignment.ManagerID = emptyToNil(*change.ManagerID)
+		}
+		if change.ClearManager {
+			assignment.ManagerID = nil
+		}
+
+		// Substitui mudanças futuras já agendadas e encerra a vigente
+		if err := tx.Where("user_id = ? AND effective_from >= ?", userID, effectiveFrom).
+			Delete(&models.OrgAssignment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.OrgAssignment{}).
+			Where("user_id = ? AND effective_from < ? AND (effective_to IS NULL OR effective_to > ?)", userID, effectiveFrom, effectiveFrom).
+			Update("effective_to", effectiveFrom).Error; err != nil {
+			return err
+		}
+		return tx.Create(&assignment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !effectiveFrom.After(truncateDay(o.now())) {
+		o.syncUserProfile(userID)
+	}
+	return &assignment, nil
+}
+
+// syncUserProfile replica departamento e cargo vigentes no cadastro do usuário
+func (o *OrgService) syncUserProfile(userID string) {
+	current, err := o.CurrentAssignment(userID)
+	if err != nil {
+		return
+	}
+	updates := map[string]interface{}{}
+	if current.Department != nil {
+		updates["department"] = current.Department.Name
+	}
+	if current.Position != nil {
+		updates["position"] = current.Position.Title
+	}
+	if len(updates) > 0 {
+		config.DB.Model(&models.User{}).Where("id = ?", userID).Updates(updates)
+	}
+}
+
+// History retorna todas as lotações do colaborador, da mais recente para a mais antiga
+func (o *OrgService) History(userID string) ([]models.OrgAssignment, error) {
+	var assignments []models.OrgAssignment
+	err := config.DB.Preload("Department").Preload("Position").Preload("Manager").
+		Where("user_id = ?", userID).
+		Order("effective_from DESC").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+func emptyToNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// ==================== ORGANOGRAMA (EXPORTAÇÃO) ====================
+
+// OrgNode colaborador no organograma
+type OrgNode struct {
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name"`
+	Email      string     `json:"email"`
+	Department string     `json:"department"`
+	Position   string     `json:"position"`
+	ManagerID  string     `json:"manager_id,omitempty"`
+	Level      int        `json:"level"` // Profundidade na árvore (0 = topo)
+	Reports    []*OrgNode `json:"reports,omitempty"`
+}
+
+// Chart monta o organograma vigente na data. Com rootUserID, retorna apenas a subárvore do gestor.
+func (o *OrgService) Chart(at time.Time, rootUserID string) ([]*OrgNode, error) {
+	var assignments []models.OrgAssignment
+	if err := activeAt(config.DB, at).
+		Preload("User").Preload("Department").Preload("Position").
+		Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*OrgNode, 0, len(assignments))
+	for _, a := range assignments {
+		if a.User == nil {
+			continue
+		}
+		node := &OrgNode{UserID: a.UserID, Name: a.User.Name, Email: a.User.Email}
+		if a.Department != nil {
+			node.Department = a.Department.Name
+		}
+		if a.Position != nil {
+			node.Position = a.Position.Title
+		}
+		if a.ManagerID != nil {
+			node.ManagerID = *a.ManagerID
+		}
+		nodes = append(nodes, node)
+	}
+
+	roots := buildOrgTree(nodes)
+	if rootUserID == "" {
+		return roots, nil
+	}
+	if node := findOrgNode(roots, rootUserID); node != nil {
+		return []*OrgNode{node}, nil
+	}
+	return []*OrgNode{}, nil
+}
+
+// buildOrgTree liga os nós aos gestores. Colaboradores sem gestor (ou com gestor fora do
+// organograma) viram raízes; ciclos são quebrados no primeiro nó revisitado.
+func buildOrgTree(nodes []*OrgNode) []*OrgNode {
+	byID := make(map[string]*OrgNode, len(nodes))
+	for _, n := range nodes {
+		n.Reports = nil
+		byID[n.UserID] = n
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+
+	var roots []*OrgNode
+	for _, n := range nodes {
+		manager, ok := byID[n.ManagerID]
+		if n.ManagerID == "" || !ok || reachesNode(byID, n.ManagerID, n.UserID) {
+			roots = append(roots, n)
+			continue
+		}
+		manager.Reports = append(manager.Reports, n)
+	}
+
+	var setLevel func(n *OrgNode, level int)
+	setLevel = func(n *OrgNode, level int) {
+		n.Level = level
+		for _, r := range n.Reports {
+			setLevel(r, level+1)
+		}
+	}
+	for _, r := range roots {
+		setLevel(r, 0)
+	}
+	return roots
+}
+
+// reachesNode indica se subir a partir de fromID chega a targetID (ciclo)
+func reachesNode(byID map[string]*OrgNode, fromID, targetID string) bool {
+	seen := make(map[string]bool)
+	for current := fromID; current != "" && !seen[current]; {
+		if current == targetID {
+			return true
+		}
+		seen[current] = true
+		node, ok := byID[current]
+		if !ok {
+			return false
+		}
+		current = node.ManagerID
+	}
+	return false
+}
+
+func findOrgNode(nodes []*OrgNode, userID string) *OrgNode {
+	for _, n := range nodes {
+		if n.UserID == userID {
+			return n
+		}
+		if found := findOrgNode(n.Reports, userID); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// ChartCSV exporta o organograma em CSV (uma linha por colaborador, em ordem hierárquica)
+func ChartCSV(roots []*OrgNode) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = ';'
+
+	names := make(map[string]string)
+	var collect func(nodes []*OrgNode)
+	collect = func(nodes []*OrgNode) {
+		for _, n := range nodes {
+			names[n.UserID] = n.Name
+			collect(n.Reports)
+		}
+	}
+	collect(roots)
+
+	w.Write([]string{"nivel", "colaborador", "email", "departamento", "cargo", "gestor", "user_id", "gestor_id"})
+	var write func(nodes []*OrgNode)
+	write = func(nodes []*OrgNode) {
+		for _, n := range nodes {
+			w.Write([]string{strconv.Itoa(n.Level), n.Name, n.Email, n.Department, n.Position, names[n.ManagerID], n.UserID, n.ManagerID})
+			write(n.Reports)
+		}
+	}
+	write(roots)
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// ==================== CARGA A PARTIR DE COLABORADORESFRADEMA ====================
+
+// OrgSeedResult resumo da carga inicial do organograma
+type OrgSeedResult struct {
+	Departments int `json:"departments"`
+	Positions   int `json:"positions"`
+	Assignments int `json:"assignments"`
+	Managers    int `json:"managers"`
+	Unmatched   int `json:"unmatched"` // Colaboradores sem usuário no sistema
+}
+
+// orgSeedMember colaborador ativo de dbo.ColaboradoresFradema vinculado a um usuário
+type orgSeedMember struct {
+	UserID        string
+	ColaboradorID int
+	Department    string
+	Position      string
+	Level         int
+	Managerial    bool
+	ManagerID     string // Gestor já cadastrado (Employee.ManagerID)
+	HireDate      time.Time
+}
+
+// managerialKeywords cargos de gestão reconhecidos na carga inicial, do nível mais alto ao mais baixo
+var managerialKeywords = []struct {
+	keyword string
+	level   int
+}{
+	{"diretor", 4}, {"director", 4}, {"head", 4},
+	{"gerente", 3}, {"manager", 3},
+	{"coordenador", 2}, {"supervisor", 2},
+	{"líder", 1}, {"lider", 1},
+}
+
+// inferPositionLevel estima o nível de um cargo pelo título (ajustável depois pelo RH)
+func inferPositionLevel(title string) (int, bool) {
+	lower := strings.ToLower(title)
+	for _, k := range managerialKeywords {
+		if strings.Contains(lower, k.keyword) {
+			return k.level, true
+		}
+	}
+	return 0, false
+}
+
+// pickDepartmentHead escolhe o responsável da área: o cargo de gestão de maior nível
+// (desempate pelo colaborador mais antigo)
+func pickDepartmentHead(members []orgSeedMember) string {
+	var head *orgSeedMember
+	for i := range members {
+		m := &members[i]
+		if !m.Managerial {
+			continue
+		}
+		if head == nil || m.Level > head.Level ||
+			(m.Level == head.Level && !m.HireDate.IsZero() && (head.HireDate.IsZero() || m.HireDate.Before(head.HireDate))) {
+			head = m
+		}
+	}
+	if head == nil {
+		return ""
+	}
+	return head.UserID
+}
+
+// SeedFromColaboradores monta o organograma inicial a partir de dbo.ColaboradoresFradema:
+// departamento (ou filial), cargo e gestor. Colaboradores que já têm lotação são ignorados.
+func (o *OrgService) SeedFromColaboradores() (*OrgSeedResult, error) {
+	var rows []struct {
+		ColaboradorID int
+		Cpf           string
+		Cargo         string
+		Filial        string
+		DataAdmissao  *time.Time
+	}
+	if err := config.DB.Raw(`
+		SELECT c.Id as ColaboradorId, p.Cpf, ISNULL(c.Cargo, '') as Cargo, ISNULL(c.Filial, '') as Filial, c.DataAdmissao
+		FROM dbo.ColaboradoresFradema c
+		INNER JOIN dbo.PessoasFisicasFradema p ON c.PessoaFisicaId = p.Id
+		WHERE c.Ativo = 1
+	`).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var users []models.User
+	config.DB.Select("id, cpf, department, position, hire_date").Where("cpf IS NOT NULL AND cpf <> ''").Find(&users)
+	usersByCPF := make(map[string]models.User, len(users))
+	for _, u := range users {
+		usersByCPF[cleanCPF(u.CPF)] = u
+	}
+
+	var assigned []string
+	config.DB.Model(&models.OrgAssignment{}).Distinct("user_id").Pluck("user_id", &assigned)
+	hasAssignment := make(map[string]bool, len(assigned))
+	for _, id := range assigned {
+		hasAssignment[id] = true
+	}
+
+	// Gestores já informados no cadastro de funcionários
+	var employees []models.Employee
+	config.DB.Preload("Manager").Where("manager_id IS NOT NULL").Find(&employees)
+	employeeManager := make(map[string]string, len(employees))
+	for _, e := range employees {
+		if e.Manager != nil {
+			employeeManager[e.UserID] = e.Manager.UserID
+		}
+	}
+
+	result := &OrgSeedResult{}
+	byDepartment := make(map[string][]orgSeedMember)
+	filialOf := make(map[string]string)
+	for _, row := range rows {
+		user, ok := usersByCPF[cleanCPF(row.Cpf)]
+		if !ok {
+			result.Unmatched++
+			continue
+		}
+		if hasAssignment[user.ID] {
+			continue
+		}
+
+		member := orgSeedMember{
+			UserID:        user.ID,
+			ColaboradorID: row.ColaboradorID,
+			Department:    strings.TrimSpace(user.Department),
+			Position:      strings.TrimSpace(row.Cargo),
+			ManagerID:     employeeManager[user.ID],
+		}
+		if member.Department == "" {
+			member.Department = strings.TrimSpace(row.Filial)
+		}
+		if member.Position == "" {
+			member.Position = strings.TrimSpace(user.Position)
+		}
+		if row.DataAdmissao != nil {
+			member.HireDate = *row.DataAdmissao
+		} else if user.HireDate != nil {
+			member.HireDate = *user.HireDate
+		}
+		member.Level, member.Managerial = inferPositionLevel(member.Position)
+
+		byDepartment[member.Department] = append(byDepartment[member.Department], member)
+		if _, ok := filialOf[member.Department]; !ok {
+			filialOf[member.Department] = strings.TrimSpace(row.Filial)
+		}
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		positions := make(map[string]string)
+		for deptName, members := range byDepartment {
+			var departmentID *string
+			headID := pickDepartmentHead(members)
+			if deptName != "" {
+				dept, created, err := firstOrCreateDepartment(tx, deptName, filialOf[deptName], headID)
+				if err != nil {
+					return err
+				}
+				if created {
+					result.Departments++
+				}
+				departmentID = &dept.ID
+				if dept.HeadUserID != nil {
+					headID = *dept.HeadUserID
+				}
+			}
+
+			for _, m := range members {
+				assignment := models.OrgAssignment{
+					UserID:        m.UserID,
+					DepartmentID:  departmentID,
+					EffectiveFrom: truncateDay(o.now()),
+					Reason:        "Carga inicial (ColaboradoresFradema)",
+				}
+				colaboradorID := m.ColaboradorID
+				assignment.ColaboradorID = &colaboradorID
+				if !m.HireDate.IsZero() {
+					assignment.EffectiveFrom = truncateDay(m.HireDate)
+				}
+
+				if m.Position != "" {
+					positionID, ok := positions[m.Position]
+					if !ok {
+						pos, created, err := firstOrCreatePosition(tx, m.Position, m.Level, m.Managerial)
+						if err != nil {
+							return err
+						}
+						if created {
+							result.Positions++
+						}
+						positionID = pos.ID
+						positions[m.Position] = positionID
+					}
+					assignment.PositionID = &positionID
+				}
+
+				managerID := m.ManagerID
+				if managerID == "" && headID != m.UserID {
+					managerID = headID
+				}
+				if managerID != "" && managerID != m.UserID {
+					assignment.ManagerID = &managerID
+					result.Managers++
+				}
+
+				if err := tx.Create(&assignment).Error; err != nil {
+					return err
+				}
+				result.Assignments++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func firstOrCreateDepartment(tx *gorm.DB, name, filial, headID string) (*models.OrgDepartment, bool, error) {
+	var dept models.OrgDepartment
+	if err := tx.Where("name = ?", name).First(&dept).Error; err == nil {
+		return &dept, false, nil
+	}
+	dept = models.OrgDepartment{Name: name, Filial: filial, Active: true}
+	if headID != "" {
+		dept.HeadUserID = &headID
+	}
+	if err := tx.Create(&dept).Error; err != nil {
+		return nil, false, err
+	}
+	return &dept, true, nil
+}
+
+func firstOrCreatePosition(tx *gorm.DB, title string, level int, managerial bool) (*models.OrgPosition, bool, error) {
+	var pos models.OrgPosition
+	if err := tx.Where("title = ?", title).First(&pos).Error; err == nil {
+		return &pos, false, nil
+	}
+	pos = models.OrgPosition{Title: title, Level: level, IsManagerial: managerial}
+	if err := tx.Create(&pos).Error; err != nil {
+		return nil, false, err
+	}
+	return &pos, true, nil
+}
+
+func cleanCPF(cpf string) string {
+	return strings.NewReplacer(".", "", "-", "", " ", "").Replace(cpf)
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectReportsAndCycles(t *testing.T) {
+	index := map[string][]string{
+		"diretor":     {"gerente"},
+		"gerente":     {"analista", "coordenador"},
+		"coordenador": {"assistente"},
+	}
+
+	assert.ElementsMatch(t, []string{"gerente", "analista", "coordenador", "assistente"}, collectReports(index, "diretor"))
+	assert.ElementsMatch(t, []string{"assistente"}, collectReports(index, "coordenador"))
+	assert.Empty(t, collectReports(index, "analista"))
+
+	// O diretor não pode passar a reportar a alguém abaixo dele
+	assert.True(t, wouldCreateCycle(index, "diretor", "assistente"))
+	assert.True(t, wouldCreateCycle(index, "gerente", "gerente"))
+	assert.False(t, wouldCreateCycle(index, "assistente", "gerente"))
+
+	// Ciclo já existente nos dados não trava a busca
+	index["assistente"] = []string{"diretor"}
+	assert.Len(t, collectReports(index, "diretor"), 4)
+}
+
+func TestCycleCheckUsesEffectiveDateAndScheduledChanges(t *testing.T) {
+	day := func(m, d int) time.Time { return time.Date(2026, time.Month(m), d, 0, 0, 0, 0, time.UTC) }
+	end := day(6, 1)
+	lines := []orgReportLine{
+		{UserID: "gerente", ManagerID: "diretor", EffectiveFrom: day(1, 1)},
+		// Analista reporta ao gerente só até junho
+		{UserID: "analista", ManagerID: "gerente", EffectiveFrom: day(1, 1), EffectiveTo: &end},
+		// Coordenador passa a reportar ao diretor em agosto (agendado)
+		{UserID: "coordenador", ManagerID: "diretor", EffectiveFrom: day(8, 1)},
+	}
+
+	// Vigente hoje e na data da mudança
+	assert.True(t, wouldCreateCycleFrom(lines, "diretor", "analista", day(3, 1)))
+	// Depois de junho o analista não está mais abaixo do diretor
+	assert.False(t, wouldCreateCycleFrom(lines, "diretor", "analista", day(7, 1)))
+	// A lotação agendada do coordenador cria o ciclo a partir de agosto
+	assert.True(t, wouldCreateCycleFrom(lines, "diretor", "coordenador", day(3, 1)))
+	assert.True(t, wouldCreateCycleFrom(lines, "diretor", "coordenador", day(9, 1)))
+	// As lotações do próprio colaborador são substituídas pela nova
+	assert.False(t, wouldCreateCycleFrom(lines, "gerente", "coordenador", day(3, 1)))
+	assert.False(t, wouldCreateCycleFrom(lines, "analista", "diretor", day(3, 1)))
+}
+
+func TestInferPositionLevel(t *testing.T) {
+	level, managerial := inferPositionLevel("Diretora Comercial")
+	assert.Equal(t, 4, level)
+	assert.True(t, managerial)
+
+	level, managerial = inferPositionLevel("Coordenador de Logística")
+	assert.Equal(t, 2, level)
+	assert.True(t, managerial)
+
+	_, managerial = inferPositionLevel("Analista de RH")
+	assert.False(t, managerial)
+}
+
+func TestPickDepartmentHead(t *testing.T) {
+	members := []orgSeedMember{
+		{UserID: "analista"},
+		{UserID: "coord-novo", Level: 2, Managerial: true, HireDate: date(2022, time.March, 1)},
+		{UserID: "coord-antigo", Level: 2, Managerial: true, HireDate: date(2015, time.March, 1)},
+	}
+	assert.Equal(t, "coord-antigo", pickDepartmentHead(members))
+
+	members = append(members, orgSeedMember{UserID: "gerente", Level: 3, Managerial: true})
+	assert.Equal(t, "gerente", pickDepartmentHead(members))
+
+	assert.Empty(t, pickDepartmentHead([]orgSeedMember{{UserID: "analista"}}))
+}
+
+func TestBuildOrgTree(t *testing.T) {
+	nodes := []*OrgNode{
+		{UserID: "d", Name: "Diretor"},
+		{UserID: "g", Name: "Gerente", ManagerID: "d"},
+		{UserID: "a", Name: "Analista", ManagerID: "g"},
+		{UserID: "x", Name: "Externo", ManagerID: "desligado"},
+		// Ciclo nos dados de origem
+		{UserID: "c1", Name: "Ciclo 1", ManagerID: "c2"},
+		{UserID: "c2", Name: "Ciclo 2", ManagerID: "c1"},
+	}
+
+	roots := buildOrgTree(nodes)
+	names := make([]string, 0, len(roots))
+	for _, r := range roots {
+		names = append(names, r.Name)
+	}
+	assert.Contains(t, names, "Diretor")
+	assert.Contains(t, names, "Externo")
+
+	director := findOrgNode(roots, "d")
+	assert.Len(t, director.Reports, 1)
+	assert.Equal(t, 2, findOrgNode(roots, "a").Level)
+	assert.NotNil(t, findOrgNode(roots, "c1"))
+	assert.NotNil(t, findOrgNode(roots, "c2"))
+
+	csv, err := ChartCSV([]*OrgNode{director})
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(csv)), "\n")
+	assert.Len(t, lines, 4)
+	assert.Contains(t, lines[3], "Analista;")
+	assert.Contains(t, lines[3], ";Gerente;")
+}
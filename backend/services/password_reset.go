@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ==================== Redefinição de senha ====================
+
+var (
+	ErrResetTokenInvalid = errors.New("link de redefinição inválido ou expirado")
+	ErrResetNoEmail      = errors.New("usuário sem e-mail cadastrado para recuperação")
+	ErrPasswordTooShort  = errors.New("a senha deve ter pelo menos 6 caracteres")
+)
+
+const (
+	PasswordResetTTL  = 30 * time.Minute
+	MinPasswordLength = 6
+
+	// placeholderEmailDomain domínio dos e-mails gerados na ativação de contas sem e-mail
+	placeholderEmailDomain = "@placeholder.local"
+)
+
+// PasswordResetService emite e consome os tokens de "esqueci minha senha"
+type PasswordResetService struct {
+	now func() time.Time
+}
+
+// PasswordResets instância usada pelos handlers de autenticação
+var PasswordResets = NewPasswordResetService()
+
+// NewPasswordResetService cria o serviço de redefinição de senha
+func NewPasswordResetService() *PasswordResetService {
+	return &PasswordResetService{now: time.Now}
+}
+
+// hasDeliverableEmail indica se o e-mail do usuário pode receber o link
+func hasDeliverableEmail(email string) bool {
+	return strings.Contains(email, "@") && !strings.HasSuffix(strings.ToLower(email), placeholderEmailDomain)
+}
+
+// resetLink monta o link do frontend (FRONTEND_URL) com o token
+func resetLink(token string) string {
+	base := strings.TrimRight(envOrDefault("FRONTEND_URL", "http://localhost:3000"), "/")
+	return base + "/reset-password?token=" + url.QueryEscape(token)
+}
+
+// resetMessage e-mail enviado ao colaborador
+func resetMessage(user *models.User, token string, ttl time.Duration) MailMessage {
+	return MailMessage{
+		To:      user.Email,
+		Subject: "Redefinição de senha - FrappYOU",
+		Body: fmt.Sprintf(`Olá, %s.
+
+Recebemos uma solicitação para redefinir a sua senha. Para criar uma nova senha, acesse:
+
+%s
+
+O link é válido por %d minutos e só pode ser usado uma vez.
+Se você não fez esta solicitação, ignore este e-mail; sua senha continua a mesma.
+`, user.Name, resetLink(token), int(ttl.Minutes())),
+	}
+}
+
+// RequestReset gera um token para o usuário do CPF e envia o link por e-mail.
+// Retorna (nil, nil) quando o CPF não tem conta, para o handler responder de
+// forma genérica sem revelar quais CPFs estão cadastrados.
+func (s *PasswordResetService) RequestReset(cpf, requestIP string) (*models.User, error) {
+	var user models.User
+	if err := config.DB.Where("cpf = ?", cpf).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !hasDeliverableEmail(user.Email) {
+		return &user, ErrResetNoEmail
+	}
+
+	token, err := newRefreshToken()
+	if err != nil {
+		return &user, err
+	}
+
+	now := s.now()
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Um único link válido por vez: invalida os anteriores
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: hashToken(token),
+			ExpiresAt: now.Add(PasswordResetTTL),
+			RequestIP: truncateString(requestIP, 64),
+		}).Error
+	})
+	if err != nil {
+		return &user, err
+	}
+
+	return &user, Mail.Send(resetMessage(&user, token, PasswordResetTTL))
+}
+
+// ResetPassword consome o token e grava a nova senha. Todas as sessões abertas
+// são encerradas, pois podem ter sido obtidas com a senha antiga.
+func (s *PasswordResetService) ResetPassword(token, newPassword string) (*models.User, error) {
+	if len(newPassword) < MinPasswordLength {
+		return nil, ErrPasswordTooShort
+	}
+	if token == "" {
+		return nil, ErrResetTokenInvalid
+	}
+
+	now := s.now()
+	var resetToken models.PasswordResetToken
+	if err := config.DB.Where("token_hash = ?", hashToken(token)).First(&resetToken).Error; err != nil {
+		return nil, ErrResetTokenInvalid
+	}
+	if !resetToken.Usable(now) {
+		return nil, ErrResetTokenInvalid
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", resetToken.UserID).Error; err != nil {
+		return nil, ErrResetTokenInvalid
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Atualização condicional: o mesmo token não pode ser usado duas vezes
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", resetToken.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrResetTokenInvalid
+		}
+		return tx.Model(&user).Update("password", string(hashedPassword)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	Sessions.RevokeAll(user.ID, models.SessionRevokedPasswordReset, "")
+	return &user, nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasDeliverableEmail(t *testing.T) {
+	assert.True(t, hasDeliverableEmail("ana@fradema.com.br"))
+	assert.False(t, hasDeliverableEmail("12345678901@placeholder.local"))
+	assert.False(t, hasDeliverableEmail(""))
+}
+
+func TestResetMessage(t *testing.T) {
+	t.Setenv("FRONTEND_URL", "https://rh.fradema.com.br/")
+
+	msg := resetMessage(&models.User{Name: "Ana", Email: "ana@fradema.com.br"}, "abc+def", PasswordResetTTL)
+	assert.Equal(t, "ana@fradema.com.br", msg.To)
+	assert.Contains(t, msg.Body, "https://rh.fradema.com.br/reset-password?token=abc%2Bdef")
+	assert.Contains(t, msg.Body, "30 minutos")
+}
+
+func TestPasswordResetTokenUsable(t *testing.T) {
+	now := time.Now()
+	token := models.PasswordResetToken{ExpiresAt: now.Add(time.Minute)}
+	assert.True(t, token.Usable(now))
+	assert.False(t, token.Usable(now.Add(2*time.Minute)))
+
+	token.UsedAt = &now
+	assert.False(t, token.Usable(now))
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer := &FileMailer{Dir: dir, From: "no-reply@fradema.com.br"}
+
+	require.NoError(t, mailer.Send(MailMessage{To: "ana@fradema.com.br", Subject: "Teste", Body: "linha 1\nlinha 2"}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "To: ana@fradema.com.br\r\n")
+	assert.Contains(t, string(content), "Subject: Teste\r\n")
+	assert.Contains(t, string(content), "linha 1\r\nlinha 2")
+}
+
+func TestMailDriver(t *testing.T) {
+	driver, err := mailDriver("SMTP", "")
+	require.NoError(t, err)
+	assert.Equal(t, "smtp", driver)
+
+	driver, err = mailDriver("", "development")
+	require.NoError(t, err)
+	assert.Equal(t, "file", driver)
+
+	_, err = mailDriver("", "")
+	assert.ErrorIs(t, err, ErrMailNotConfigured)
+	_, err = mailDriver("", "production")
+	assert.ErrorIs(t, err, ErrMailNotConfigured)
+	_, err = mailDriver("sendgrid", "development")
+	assert.ErrorIs(t, err, ErrMailNotConfigured)
+}
+
+func TestUnconfiguredMailerRefusesToSend(t *testing.T) {
+	t.Setenv("MAIL_DRIVER", "")
+	t.Setenv("APP_ENV", "")
+	assert.ErrorIs(t, NewMailerFromEnv().Send(MailMessage{To: "ana@fradema.com.br"}), ErrMailNotConfigured)
+}
@@ -0,0 +1,687 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== Cálculo da folha (INSS, IRRF e FGTS) ====================
+//
+// Gera os itens do holerite a partir do salário base, dias trabalhados e rubricas
+// informadas, usando as tabelas de incidência vigentes gravadas no banco:
+//   - INSS progressivo por faixas, limitado ao teto;
+//   - IRRF com dedução por dependente ou desconto simplificado (o que for mais vantajoso)
+//     e, a partir de 2026, a redução mensal da Lei nº 15.270/2025 (exceto no 13º);
+//   - FGTS (informativo, não é desconto);
+//   - 13º salário (1ª e 2ª parcelas) e férias com 1/3 constitucional.
+//
+// Cada holerite é calculado isoladamente: férias e 13º têm tributação própria.
+
+var (
+	ErrPayrollInvalidInput    = errors.New("dados insuficientes para o cálculo da folha")
+	ErrPayrollUnsupportedType = errors.New("tipo de holerite sem cálculo automático")
+	ErrPayrollTableNotFound   = errors.New("nenhuma tabela de incidência vigente para a competência")
+	ErrPayrollInvalidTaxTable = errors.New("tabela de incidência inválida")
+)
+
+// payrollDefaultMonthsWorked avos do 13º quando não informados (ano completo)
+const payrollDefaultMonthsWorked = 12
+
+// Códigos das rubricas geradas pelo cálculo
+const (
+	PayrollCodeSalary        = "SAL"
+	PayrollCodeVacation      = "FER"
+	PayrollCodeVacationThird = "FER13"
+	PayrollCodeThirteenthAdv = "13ADT"
+	PayrollCodeThirteenth    = "13SAL"
+	PayrollCodeThirteenthDed = "13DESC"
+	PayrollCodeINSS          = "INSS"
+	PayrollCodeIRRF          = "IRRF"
+)
+
+// PayrollBracket faixa de uma tabela de incidência (alíquota em %)
+type PayrollBracket struct {
+	UpTo      float64 `json:"up_to"` // 0 = sem limite (somente a última faixa)
+	Rate      float64 `json:"rate"`
+	Deduction float64 `json:"deduction"` // IRRF: parcela a deduzir
+}
+
+// PayrollTaxRule tabela interpretada, pronta para o cálculo
+type PayrollTaxRule struct {
+	Kind               models.PayrollTaxKind `json:"kind"`
+	ValidFrom          time.Time             `json:"valid_from"`
+	Brackets           []PayrollBracket      `json:"brackets"`
+	DependentDeduction float64               `json:"dependent_deduction"`
+	SimplifiedDiscount float64               `json:"simplified_discount"`
+	Reduction          IRRFReduction         `json:"reduction"`
+}
+
+// IRRFReduction redução mensal do IRRF (ver models.PayrollTaxTable)
+type IRRFReduction struct {
+	FullUpTo float64 `json:"full_up_to"`
+	Max      float64 `json:"max"`
+	UpTo     float64 `json:"up_to"`
+	Constant float64 `json:"constant"`
+	Rate     float64 `json:"rate"` // %
+}
+
+// Amount redução aplicável aos rendimentos tributáveis do mês, limitada ao imposto
+func (r IRRFReduction) Amount(income, tax float64) float64 {
+	var amount float64
+	switch {
+	case r.UpTo == 0 || income > r.UpTo:
+		return 0
+	case income <= r.FullUpTo:
+		amount = r.Max
+	default:
+		amount = r.Constant - income*r.Rate/100
+	}
+	return roundCents(math.Min(tax, math.Max(0, amount)))
+}
+
+// ParsePayrollTaxTable valida e interpreta uma tabela gravada
+func ParsePayrollTaxTable(t *models.PayrollTaxTable) (*PayrollTaxRule, error) {
+	rule := &PayrollTaxRule{
+		Kind:               t.Kind,
+		ValidFrom:          t.ValidFrom,
+		DependentDeduction: t.DependentDeduction,
+		SimplifiedDiscount: t.SimplifiedDiscount,
+		Reduction: IRRFReduction{
+			FullUpTo: t.ReductionFullUpTo,
+			Max:      t.ReductionMax,
+			UpTo:     t.ReductionUpTo,
+			Constant: t.ReductionConstant,
+			Rate:     t.ReductionRate,
+		},
+	}
+	if err := json.Unmarshal([]byte(t.Brackets), &rule.Brackets); err != nil {
+		return nil, fmt.Errorf("%w: faixas em formato inválido", ErrPayrollInvalidTaxTable)
+	}
+
+	switch t.Kind {
+	case models.PayrollTaxINSS, models.PayrollTaxIRRF, models.PayrollTaxFGTS:
+	default:
+		return nil, fmt.Errorf("%w: tipo %q desconhecido", ErrPayrollInvalidTaxTable, t.Kind)
+	}
+	if len(rule.Brackets) == 0 {
+		return nil, fmt.Errorf("%w: informe ao menos uma faixa", ErrPayrollInvalidTaxTable)
+	}
+	if t.Kind == models.PayrollTaxFGTS && len(rule.Brackets) != 1 {
+		return nil, fmt.Errorf("%w: o FGTS tem alíquota única", ErrPayrollInvalidTaxTable)
+	}
+	if t.DependentDeduction < 0 || t.SimplifiedDiscount < 0 {
+		return nil, fmt.Errorf("%w: deduções não podem ser negativas", ErrPayrollInvalidTaxTable)
+	}
+	if err := validateIRRFReduction(t.Kind, rule.Reduction); err != nil {
+		return nil, err
+	}
+	prev := 0.0
+	for i, b := range rule.Brackets {
+		if b.Rate < 0 || b.Rate > 100 || b.Deduction < 0 {
+			return nil, fmt.Errorf("%w: faixa %d com alíquota ou dedução inválida", ErrPayrollInvalidTaxTable, i+1)
+		}
+		last := i == len(rule.Brackets)-1
+		if b.UpTo == 0 && !last {
+			return nil, fmt.Errorf("%w: somente a última faixa pode ser sem limite", ErrPayrollInvalidTaxTable)
+		}
+		if b.UpTo != 0 && b.UpTo <= prev {
+			return nil, fmt.Errorf("%w: faixas devem estar em ordem crescente", ErrPayrollInvalidTaxTable)
+		}
+		prev = b.UpTo
+	}
+	return rule, nil
+}
+
+// validateIRRFReduction a redução só existe no IRRF, com faixas em ordem
+func validateIRRFReduction(kind models.PayrollTaxKind, r IRRFReduction) error {
+	if r == (IRRFReduction{}) {
+		return nil
+	}
+	switch {
+	case kind != models.PayrollTaxIRRF:
+		return fmt.Errorf("%w: redução mensal só se aplica ao IRRF", ErrPayrollInvalidTaxTable)
+	case r.FullUpTo < 0 || r.Max < 0 || r.Constant < 0 || r.Rate < 0 || r.Rate > 100:
+		return fmt.Errorf("%w: redução mensal com valores inválidos", ErrPayrollInvalidTaxTable)
+	case r.UpTo <= 0 || r.UpTo < r.FullUpTo:
+		return fmt.Errorf("%w: limite da redução mensal deve ser maior que a faixa de redução integral", ErrPayrollInvalidTaxTable)
+	}
+	return nil
+}
+
+// Progressive contribuição progressiva (INSS): cada faixa incide só sobre a
+// parcela da base dentro dela; acima da última faixa não há incidência (teto)
+func (r *PayrollTaxRule) Progressive(base float64) float64 {
+	total, lower := 0.0, 0.0
+	for _, b := range r.Brackets {
+		if base <= lower {
+			break
+		}
+		upper := base
+		if b.UpTo > 0 && b.UpTo < upper {
+			upper = b.UpTo
+		}
+		total += (upper - lower) * b.Rate / 100
+		lower = b.UpTo
+		if b.UpTo == 0 {
+			break
+		}
+	}
+	return roundCents(total)
+}
+
+// Bracket retorna a faixa em que a base se enquadra
+func (r *PayrollTaxRule) Bracket(base float64) PayrollBracket {
+	for _, b := range r.Brackets {
+		if b.UpTo == 0 || base <= b.UpTo {
+			return b
+		}
+	}
+	return r.Brackets[len(r.Brackets)-1]
+}
+
+// Flat alíquota única (FGTS)
+func (r *PayrollTaxRule) Flat(base float64) float64 {
+	return roundCents(base * r.Brackets[0].Rate / 100)
+}
+
+// PayrollTables tabelas vigentes usadas em um cálculo
+type PayrollTables struct {
+	INSS *PayrollTaxRule `json:"inss"`
+	IRRF *PayrollTaxRule `json:"irrf"`
+	FGTS *PayrollTaxRule `json:"fgts"`
+}
+
+// IRRFResult imposto de renda calculado
+type IRRFResult struct {
+	Base       float64 `json:"base"`       // Base de cálculo (rendimentos - deduções)
+	Deductions float64 `json:"deductions"` // Deduções aplicadas
+	Simplified bool    `json:"simplified"` // Usou o desconto simplificado
+	Rate       float64 `json:"rate"`
+	Reduction  float64 `json:"reduction"` // Redução mensal (Lei nº 15.270/2025)
+	Tax        float64 `json:"tax"`
+}
+
+// CalculateIRRF aplica a tabela mensal. As deduções legais (INSS, dependentes e
+// outras, como pensão alimentícia) são comparadas ao desconto simplificado e
+// prevalece o maior. A redução mensal da tabela, quando houver, é calculada sobre
+// os rendimentos (income) e abatida do imposto.
+func CalculateIRRF(income, inss float64, dependents int, otherDeductions float64, rule *PayrollTaxRule) IRRFResult {
+	return calculateIRRF(income, inss, dependents, otherDeductions, rule, true)
+}
+
+// CalculateThirteenthIRRF IRRF do 13º salário: tributação exclusiva, sem a redução mensal
+func CalculateThirteenthIRRF(income, inss float64, dependents int, otherDeductions float64, rule *PayrollTaxRule) IRRFResult {
+	return calculateIRRF(income, inss, dependents, otherDeductions, rule, false)
+}
+
+func calculateIRRF(income, inss float64, dependents int, otherDeductions float64, rule *PayrollTaxRule, monthly bool) IRRFResult {
+	legal := inss + float64(dependents)*rule.DependentDeduction + otherDeductions
+	result := IRRFResult{Deductions: legal}
+	if rule.SimplifiedDiscount > legal {
+		result.Deductions, result.Simplified = rule.SimplifiedDiscount, true
+	}
+	result.Deductions = roundCents(result.Deductions)
+
+	result.Base = roundCents(math.Max(0, income-result.Deductions))
+	bracket := rule.Bracket(result.Base)
+	result.Rate = bracket.Rate
+	result.Tax = roundCents(math.Max(0, result.Base*bracket.Rate/100-bracket.Deduction))
+	if monthly {
+		result.Reduction = rule.Reduction.Amount(income, result.Tax)
+		result.Tax = roundCents(result.Tax - result.Reduction)
+	}
+	if result.Tax == 0 {
+		result.Rate = 0
+	}
+	return result
+}
+
+// PayrollRubrica rubrica adicional informada para o cálculo (horas extras, faltas, benefícios...)
+type PayrollRubrica struct {
+	Type        string  `json:"type"` // earning ou deduction
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	Reference   float64 `json:"reference"`
+	Amount      float64 `json:"amount"`
+
+	// Proventos incidem em INSS, IRRF e FGTS, salvo indicação em contrário
+	NoINSS bool `json:"no_inss"`
+	NoIRRF bool `json:"no_irrf"`
+	NoFGTS bool `json:"no_fgts"`
+
+	// Descontos: ReducesBases abate das bases (faltas, atrasos);
+	// IRRFDeductible é dedução legal do IR (pensão alimentícia)
+	ReducesBases   bool `json:"reduces_bases"`
+	IRRFDeductible bool `json:"irrf_deductible"`
+}
+
+// PayrollInput dados de entrada do cálculo
+type PayrollInput struct {
+	PayslipType    string     `json:"payslip_type"` // mensal, ferias, 13_primeira, 13_segunda
+	ReferenceMonth int        `json:"reference_month"`
+	ReferenceYear  int        `json:"reference_year"`
+	PaymentDate    *time.Time `json:"payment_date"` // IRRF segue a data de pagamento (regime de caixa)
+
+	BaseSalary float64 `json:"base_salary"`
+	WorkedDays int     `json:"worked_days"` // Mensal: dias trabalhados no mês comercial (padrão 30)
+	Dependents int     `json:"dependents"`
+
+	VacationDays int              `json:"vacation_days"` // Férias: dias de gozo
+	MonthsWorked int              `json:"months_worked"` // 13º: avos (padrão 12)
+	AdvancePaid  *float64         `json:"advance_paid"`  // 13º 2ª parcela: adiantamento pago (padrão metade)
+	Rubricas     []PayrollRubrica `json:"rubricas"`
+}
+
+// PayrollResult resultado do cálculo, pronto para compor o holerite
+type PayrollResult struct {
+	Items          []models.PayslipItem `json:"items"`
+	GrossTotal     float64              `json:"gross_total"`
+	DeductionTotal float64              `json:"deduction_total"`
+	NetTotal       float64              `json:"net_total"`
+	INSSBase       float64              `json:"inss_base"`
+	IRRFBase       float64              `json:"irrf_base"`
+	FGTSBase       float64              `json:"fgts_base"`
+	FGTSAmount     float64              `json:"fgts_amount"`
+	INSS           float64              `json:"inss"`
+	IRRF           IRRFResult           `json:"irrf"`
+	Tables         PayrollTables        `json:"tables"`
+}
+
+func (r *PayrollResult) add(itemType, code, description string, reference, amount float64) {
+	amount = roundCents(amount)
+	if amount <= 0 {
+		return
+	}
+	r.Items = append(r.Items, models.PayslipItem{
+		Type:        itemType,
+		Code:        code,
+		Description: description,
+		Reference:   roundCents(reference),
+		Amount:      amount,
+	})
+	if itemType == "earning" {
+		r.GrossTotal += amount
+	} else {
+		r.DeductionTotal += amount
+	}
+}
+
+// CalculatePayroll calcula o holerite com as tabelas informadas
+func CalculatePayroll(in PayrollInput, tables PayrollTables) (*PayrollResult, error) {
+	if in.BaseSalary <= 0 || tables.INSS == nil || tables.IRRF == nil || tables.FGTS == nil {
+		return nil, ErrPayrollInvalidInput
+	}
+	if in.Dependents < 0 || in.WorkedDays < 0 || in.WorkedDays > 30 || in.MonthsWorked < 0 || in.MonthsWorked > 12 {
+		return nil, ErrPayrollInvalidInput
+	}
+
+	result := &PayrollResult{Tables: tables}
+	var inssBase, irrfBase, fgtsBase float64
+	taxed := true // 1ª parcela do 13º não tem INSS nem IRRF
+
+	months := in.MonthsWorked
+	if months == 0 {
+		months = payrollDefaultMonthsWorked
+	}
+
+	switch in.PayslipType {
+	case "", "mensal":
+		days := in.WorkedDays
+		if days == 0 {
+			days = 30
+		}
+		salary := roundCents(in.BaseSalary * float64(days) / 30)
+		result.add("earning", PayrollCodeSalary, "Salário", float64(days), salary)
+		inssBase, irrfBase, fgtsBase = salary, salary, salary
+
+	case "ferias":
+		if in.VacationDays <= 0 || in.VacationDays > 30 {
+			return nil, ErrPayrollInvalidInput
+		}
+		vacation := roundCents(in.BaseSalary * float64(in.VacationDays) / 30)
+		third := roundCents(vacation / 3)
+		result.add("earning", PayrollCodeVacation, "Férias", float64(in.VacationDays), vacation)
+		result.add("earning", PayrollCodeVacationThird, "1/3 Constitucional de Férias", 0, third)
+		inssBase = vacation + third
+		irrfBase, fgtsBase = inssBase, inssBase
+
+	case "13_primeira":
+		advance := roundCents(in.BaseSalary * float64(months) / 12 / 2)
+		result.add("earning", PayrollCodeThirteenthAdv, "13º Salário - 1ª Parcela", float64(months), advance)
+		fgtsBase = advance
+		taxed = false
+
+	case "13_segunda":
+		full := roundCents(in.BaseSalary * float64(months) / 12)
+		advance := roundCents(full / 2)
+		if in.AdvancePaid != nil {
+			advance = roundCents(*in.AdvancePaid)
+		}
+		if advance < 0 || advance > full {
+			return nil, ErrPayrollInvalidInput
+		}
+		result.add("earning", PayrollCodeThirteenth, "13º Salário", float64(months), full)
+		result.add("deduction", PayrollCodeThirteenthDed, "Adiantamento 13º Salário", 0, advance)
+		// INSS e IRRF incidem sobre o valor integral; o FGTS da 1ª parcela já foi depositado
+		inssBase, irrfBase, fgtsBase = full, full, full-advance
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPayrollUnsupportedType, in.PayslipType)
+	}
+
+	otherIRRFDeductions := 0.0
+	for _, rub := range in.Rubricas {
+		if rub.Amount <= 0 {
+			continue
+		}
+		amount := roundCents(rub.Amount)
+		if rub.Type == "earning" {
+			result.add("earning", rub.Code, rub.Description, rub.Reference, amount)
+			if !rub.NoINSS {
+				inssBase += amount
+			}
+			if !rub.NoIRRF {
+				irrfBase += amount
+			}
+			if !rub.NoFGTS {
+				fgtsBase += amount
+			}
+			continue
+		}
+		result.add("deduction", rub.Code, rub.Description, rub.Reference, amount)
+		if rub.ReducesBases {
+			inssBase -= amount
+			irrfBase -= amount
+			fgtsBase -= amount
+		}
+		if rub.IRRFDeductible {
+			otherIRRFDeductions += amount
+		}
+	}
+
+	result.FGTSBase = roundCents(math.Max(0, fgtsBase))
+	result.FGTSAmount = tables.FGTS.Flat(result.FGTSBase)
+
+	if taxed {
+		result.INSSBase = roundCents(math.Max(0, inssBase))
+		result.INSS = tables.INSS.Progressive(result.INSSBase)
+		if result.INSSBase > 0 {
+			rate := result.INSS / math.Min(result.INSSBase, inssCeiling(tables.INSS)) * 100
+			result.add("deduction", PayrollCodeINSS, "INSS", rate, result.INSS)
+		}
+
+		irrf := CalculateIRRF
+		if in.PayslipType == "13_segunda" {
+			irrf = CalculateThirteenthIRRF
+		}
+		result.IRRF = irrf(math.Max(0, irrfBase), result.INSS, in.Dependents, otherIRRFDeductions, tables.IRRF)
+		result.IRRFBase = result.IRRF.Base
+		result.add("deduction", PayrollCodeIRRF, "IRRF", result.IRRF.Rate, result.IRRF.Tax)
+	}
+
+	result.GrossTotal = roundCents(result.GrossTotal)
+	result.DeductionTotal = roundCents(result.DeductionTotal)
+	result.NetTotal = roundCents(result.GrossTotal - result.DeductionTotal)
+	return result, nil
+}
+
+// inssCeiling teto de contribuição (limite da última faixa)
+func inssCeiling(rule *PayrollTaxRule) float64 {
+	if last := rule.Brackets[len(rule.Brackets)-1]; last.UpTo > 0 {
+		return last.UpTo
+	}
+	return math.MaxFloat64
+}
+
+// CrossCheckPayslip confere os descontos de INSS e IRRF e o FGTS de um holerite
+// informado manualmente contra as tabelas vigentes. A base do INSS é a informada
+// no holerite (ou o total de proventos); o IRRF incide sobre essa base menos o
+// INSS calculado. Retorna a descrição das divergências encontradas.
+func CrossCheckPayslip(p *models.Payslip, tables PayrollTables, dependents int) []string {
+	switch p.PayslipType {
+	case "", "mensal", "ferias", "13_primeira", "13_segunda":
+	default:
+		return nil // Rescisão e adiantamento têm regras próprias
+	}
+
+	var issues []string
+	check := func(label string, informed, expected float64) {
+		if !centsEqual(informed, expected) {
+			issues = append(issues, fmt.Sprintf("%s informado %s difere do calculado %s", label, FormatBRL(informed), FormatBRL(expected)))
+		}
+	}
+
+	gross := 0.0
+	for _, item := range p.Items {
+		if item.Type == "earning" {
+			gross += item.Amount
+		}
+	}
+	base := p.INSSBase
+	if base == 0 {
+		base = roundCents(gross)
+	}
+
+	var expectedINSS, expectedIRRF float64
+	if p.PayslipType != "13_primeira" {
+		expectedINSS = tables.INSS.Progressive(base)
+		irrf := CalculateIRRF
+		if p.PayslipType == "13_segunda" {
+			irrf = CalculateThirteenthIRRF
+		}
+		expectedIRRF = irrf(base, expectedINSS, dependents, 0, tables.IRRF).Tax
+	}
+	for _, tax := range []struct {
+		code     string
+		expected float64
+	}{{PayrollCodeINSS, expectedINSS}, {PayrollCodeIRRF, expectedIRRF}} {
+		informed, found := payrollTaxItemAmount(p.Items, tax.code)
+		switch {
+		case !found && tax.expected > 0:
+			issues = append(issues, fmt.Sprintf("Desconto de %s não informado (calculado %s)", tax.code, FormatBRL(tax.expected)))
+		case found:
+			check(tax.code, informed, tax.expected)
+		}
+	}
+
+	if p.FGTSAmount != 0 {
+		fgtsBase := p.FGTSBase
+		if fgtsBase == 0 {
+			fgtsBase = base
+		}
+		check("FGTS", p.FGTSAmount, tables.FGTS.Flat(fgtsBase))
+	}
+	return issues
+}
+
+// payrollTaxItemAmount soma os descontos de INSS/IRRF identificados pelo código ou descrição
+func payrollTaxItemAmount(items []models.PayslipItem, code string) (float64, bool) {
+	total, found := 0.0, false
+	for _, item := range items {
+		if item.Type != "deduction" {
+			continue
+		}
+		if IsPayrollTaxItem(item, code) {
+			total += item.Amount
+			found = true
+		}
+	}
+	return roundCents(total), found
+}
+
+// IsPayrollTaxItem indica se o item é o desconto de INSS ou IRRF (code = PayrollCodeINSS/PayrollCodeIRRF)
+func IsPayrollTaxItem(item models.PayslipItem, code string) bool {
+	if strings.EqualFold(strings.TrimSpace(item.Code), code) {
+		return true
+	}
+	desc := strings.ToUpper(item.Description)
+	if code == PayrollCodeIRRF && strings.Contains(desc, "IMPOSTO DE RENDA") {
+		return true
+	}
+	// "INSS S/ FÉRIAS", "IRRF 13º"... mas não "BASE INSS"
+	return strings.HasPrefix(desc, code)
+}
+
+// ==================== Tabelas vigentes ====================
+
+// PayrollEngine carrega as tabelas vigentes e executa o cálculo
+type PayrollEngine struct {
+	loadTable func(kind models.PayrollTaxKind, at time.Time) (*models.PayrollTaxTable, error)
+}
+
+// Payroll instância usada pelos handlers de holerite
+var Payroll = NewPayrollEngine()
+
+// NewPayrollEngine cria o motor de cálculo com as tabelas do banco
+func NewPayrollEngine() *PayrollEngine {
+	return &PayrollEngine{loadTable: loadPayrollTaxTable}
+}
+
+func loadPayrollTaxTable(kind models.PayrollTaxKind, at time.Time) (*models.PayrollTaxTable, error) {
+	var table models.PayrollTaxTable
+	err := config.DB.Where("kind = ? AND valid_from <= ?", kind, at).
+		Order("valid_from DESC").
+		First(&table).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w (%s em %s)", ErrPayrollTableNotFound, strings.ToUpper(string(kind)), at.Format("01/2006"))
+	}
+	return &table, err
+}
+
+// Tables retorna as tabelas vigentes: INSS e FGTS pela competência, IRRF pela data de pagamento
+func (e *PayrollEngine) Tables(competence, payment time.Time) (PayrollTables, error) {
+	var tables PayrollTables
+	for _, t := range []struct {
+		kind models.PayrollTaxKind
+		at   time.Time
+		dst  **PayrollTaxRule
+	}{
+		{models.PayrollTaxINSS, competence, &tables.INSS},
+		{models.PayrollTaxIRRF, payment, &tables.IRRF},
+		{models.PayrollTaxFGTS, competence, &tables.FGTS},
+	} {
+		table, err := e.loadTable(t.kind, t.at)
+		if err != nil {
+			return tables, err
+		}
+		rule, err := ParsePayrollTaxTable(table)
+		if err != nil {
+			return tables, err
+		}
+		*t.dst = rule
+	}
+	return tables, nil
+}
+
+// TablesFor retorna as tabelas vigentes para a competência e a data de pagamento (opcional)
+func (e *PayrollEngine) TablesFor(month, year int, paymentDate *time.Time) (PayrollTables, error) {
+	if month < 1 || month > 12 || year < 1900 {
+		return PayrollTables{}, ErrPayrollInvalidInput
+	}
+	competence := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	payment := competence
+	if paymentDate != nil {
+		payment = *paymentDate
+	}
+	return e.Tables(competence, payment)
+}
+
+// Calculate calcula o holerite com as tabelas vigentes na competência
+func (e *PayrollEngine) Calculate(in PayrollInput) (*PayrollResult, error) {
+	tables, err := e.TablesFor(in.ReferenceMonth, in.ReferenceYear, in.PaymentDate)
+	if err != nil {
+		return nil, err
+	}
+	return CalculatePayroll(in, tables)
+}
+
+// CheckPayslip confere um holerite informado manualmente com as tabelas vigentes
+func (e *PayrollEngine) CheckPayslip(p *models.Payslip, dependents int) ([]string, error) {
+	tables, err := e.TablesFor(p.ReferenceMonth, p.ReferenceYear, p.PaymentDate)
+	if err != nil {
+		return nil, err
+	}
+	return CrossCheckPayslip(p, tables, dependents), nil
+}
+
+// DefaultPayrollTaxTables tabelas oficiais usadas na carga inicial. Novas vigências
+// são cadastradas pelo admin sem alterar as anteriores.
+func DefaultPayrollTaxTables() []models.PayrollTaxTable {
+	return []models.PayrollTaxTable{
+		{
+			Kind:        models.PayrollTaxINSS,
+			ValidFrom:   time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+			Description: "INSS 2025 (Portaria Interministerial MPS/MF nº 6/2025)",
+			Brackets:    `[{"up_to":1518.00,"rate":7.5},{"up_to":2793.88,"rate":9},{"up_to":4190.83,"rate":12},{"up_to":8157.41,"rate":14}]`,
+		},
+		{
+			Kind:               models.PayrollTaxIRRF,
+			ValidFrom:          time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC),
+			Description:        "IRRF a partir de maio/2025 (MP nº 1.294/2025)",
+			Brackets:           `[{"up_to":2428.80,"rate":0},{"up_to":2826.65,"rate":7.5,"deduction":182.16},{"up_to":3751.05,"rate":15,"deduction":394.16},{"up_to":4664.68,"rate":22.5,"deduction":675.49},{"up_to":0,"rate":27.5,"deduction":908.73}]`,
+			DependentDeduction: 189.59,
+			SimplifiedDiscount: 607.20,
+		},
+		{
+			Kind:               models.PayrollTaxIRRF,
+			ValidFrom:          time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+			Description:        "IRRF a partir de janeiro/2026, com redução mensal (Lei nº 15.270/2025)",
+			Brackets:           `[{"up_to":2428.80,"rate":0},{"up_to":2826.65,"rate":7.5,"deduction":182.16},{"up_to":3751.05,"rate":15,"deduction":394.16},{"up_to":4664.68,"rate":22.5,"deduction":675.49},{"up_to":0,"rate":27.5,"deduction":908.73}]`,
+			DependentDeduction: 189.59,
+			SimplifiedDiscount: 607.20,
+			ReductionFullUpTo:  5000.00,
+			ReductionMax:       312.89,
+			ReductionUpTo:      7350.00,
+			ReductionConstant:  978.62,
+			ReductionRate:      13.3145,
+		},
+		{
+			Kind:        models.PayrollTaxFGTS,
+			ValidFrom:   time.Date(1990, time.May, 11, 0, 0, 0, 0, time.UTC),
+			Description: "FGTS (Lei nº 8.036/1990)",
+			Brackets:    `[{"up_to":0,"rate":8}]`,
+		},
+	}
+}
+
+// SeedDefaultTables grava as vigências padrão posteriores às já cadastradas de cada
+// tipo (novas tabelas oficiais chegam aos bancos existentes; as do admin prevalecem)
+func (e *PayrollEngine) SeedDefaultTables() {
+	for _, table := range DefaultPayrollTaxTables() {
+		var count int64
+		config.DB.Model(&models.PayrollTaxTable{}).Where("kind = ? AND valid_from >= ?", table.Kind, table.ValidFrom).Count(&count)
+		if count > 0 {
+			continue
+		}
+		if err := config.DB.Create(&table).Error; err != nil {
+			log.Printf("⚠️ Folha: erro ao criar tabela %s: %v", table.Kind, err)
+		}
+	}
+}
+
+// SaveTable cadastra uma nova vigência de tabela após validação
+func (e *PayrollEngine) SaveTable(table *models.PayrollTaxTable) error {
+	if table.ValidFrom.IsZero() {
+		return fmt.Errorf("%w: informe o início da vigência", ErrPayrollInvalidTaxTable)
+	}
+	if _, err := ParsePayrollTaxTable(table); err != nil {
+		return err
+	}
+	return config.DB.Create(table).Error
+}
+
+// ListTables lista as tabelas cadastradas, mais recentes primeiro
+func (e *PayrollEngine) ListTables() ([]models.PayrollTaxTable, error) {
+	var tables []models.PayrollTaxTable
+	err := config.DB.Order("kind ASC, valid_from DESC").Find(&tables).Error
+	return tables, err
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// defaultPayrollTables tabelas padrão vigentes em junho/2025
+func defaultPayrollTables(t *testing.T) PayrollTables {
+	t.Helper()
+	return payrollTablesAt(t, time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// payrollTablesAt tabelas padrão vigentes na data (as vigências de cada tipo estão em
+// ordem crescente, então a última até a data prevalece)
+func payrollTablesAt(t *testing.T, at time.Time) PayrollTables {
+	t.Helper()
+	var tables PayrollTables
+	for _, table := range DefaultPayrollTaxTables() {
+		table := table
+		if table.ValidFrom.After(at) {
+			continue
+		}
+		rule, err := ParsePayrollTaxTable(&table)
+		require.NoError(t, err)
+		switch table.Kind {
+		case models.PayrollTaxINSS:
+			tables.INSS = rule
+		case models.PayrollTaxIRRF:
+			tables.IRRF = rule
+		case models.PayrollTaxFGTS:
+			tables.FGTS = rule
+		}
+	}
+	return tables
+}
+
+func payrollItem(t *testing.T, r *PayrollResult, code string) models.PayslipItem {
+	t.Helper()
+	for _, item := range r.Items {
+		if item.Code == code {
+			return item
+		}
+	}
+	t.Fatalf("item %s não gerado", code)
+	return models.PayslipItem{}
+}
+
+func TestProgressiveINSS(t *testing.T) {
+	inss := defaultPayrollTables(t).INSS
+
+	assert.Equal(t, 113.85, inss.Progressive(1518))
+	assert.Equal(t, 253.41, inss.Progressive(3000))
+	// Acima do teto a contribuição fica limitada
+	assert.Equal(t, 951.63, inss.Progressive(8157.41))
+	assert.Equal(t, 951.63, inss.Progressive(20000))
+	assert.Equal(t, 0.0, inss.Progressive(0))
+}
+
+func TestCalculateIRRFChoosesBestDeduction(t *testing.T) {
+	irrf := defaultPayrollTables(t).IRRF
+
+	// Sem dependentes o desconto simplificado (607,20) supera o INSS
+	r := CalculateIRRF(5000, 509.60, 0, 0, irrf)
+	assert.True(t, r.Simplified)
+	assert.Equal(t, 4392.80, r.Base)
+	assert.Equal(t, 312.89, r.Tax)
+	assert.Equal(t, 22.5, r.Rate)
+
+	// Com dois dependentes as deduções legais são maiores
+	r = CalculateIRRF(5000, 509.60, 2, 0, irrf)
+	assert.False(t, r.Simplified)
+	assert.Equal(t, 4111.22, r.Base)
+	assert.Equal(t, 249.53, r.Tax)
+
+	r = CalculateIRRF(2500, 200, 0, 0, irrf)
+	assert.Equal(t, 0.0, r.Tax)
+	assert.Equal(t, 0.0, r.Rate)
+}
+
+func TestCalculatePayrollMonthly(t *testing.T) {
+	tables := defaultPayrollTables(t)
+
+	r, err := CalculatePayroll(PayrollInput{
+		PayslipType: "mensal",
+		BaseSalary:  5000,
+		Rubricas: []PayrollRubrica{
+			{Type: "earning", Code: "VT", Description: "Auxílio transporte", Amount: 200, NoINSS: true, NoIRRF: true, NoFGTS: true},
+			{Type: "deduction", Code: "VTD", Description: "Desconto vale transporte", Amount: 300},
+		},
+	}, tables)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5000.0, payrollItem(t, r, PayrollCodeSalary).Amount)
+	assert.Equal(t, 5000.0, r.INSSBase)
+	assert.Equal(t, 509.60, payrollItem(t, r, PayrollCodeINSS).Amount)
+	assert.Equal(t, 312.89, payrollItem(t, r, PayrollCodeIRRF).Amount)
+	assert.Equal(t, 400.0, r.FGTSAmount)
+	assert.Equal(t, 5200.0, r.GrossTotal)
+	assert.Equal(t, 1122.49, r.DeductionTotal)
+	assert.Equal(t, 4077.51, r.NetTotal)
+}
+
+func TestCalculatePayrollProportionalDaysAndAbsences(t *testing.T) {
+	r, err := CalculatePayroll(PayrollInput{
+		BaseSalary: 3000,
+		WorkedDays: 15,
+		Rubricas: []PayrollRubrica{
+			{Type: "deduction", Code: "FAL", Description: "Faltas", Amount: 100, ReducesBases: true},
+		},
+	}, defaultPayrollTables(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1500.0, payrollItem(t, r, PayrollCodeSalary).Amount)
+	assert.Equal(t, 1400.0, r.INSSBase)
+	assert.Equal(t, 1400.0, r.FGTSBase)
+	assert.Equal(t, 105.0, payrollItem(t, r, PayrollCodeINSS).Amount)
+}
+
+func TestCalculatePayrollVacation(t *testing.T) {
+	r, err := CalculatePayroll(PayrollInput{PayslipType: "ferias", BaseSalary: 3000, VacationDays: 30}, defaultPayrollTables(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, 3000.0, payrollItem(t, r, PayrollCodeVacation).Amount)
+	assert.Equal(t, 1000.0, payrollItem(t, r, PayrollCodeVacationThird).Amount)
+	assert.Equal(t, 373.41, r.INSS)
+	assert.Equal(t, 114.76, r.IRRF.Tax)
+	assert.Equal(t, 320.0, r.FGTSAmount)
+
+	_, err = CalculatePayroll(PayrollInput{PayslipType: "ferias", BaseSalary: 3000}, defaultPayrollTables(t))
+	assert.ErrorIs(t, err, ErrPayrollInvalidInput)
+}
+
+func TestCalculatePayrollThirteenth(t *testing.T) {
+	tables := defaultPayrollTables(t)
+
+	first, err := CalculatePayroll(PayrollInput{PayslipType: "13_primeira", BaseSalary: 3000, MonthsWorked: 6}, tables)
+	require.NoError(t, err)
+	assert.Equal(t, 750.0, payrollItem(t, first, PayrollCodeThirteenthAdv).Amount)
+	assert.Equal(t, 0.0, first.INSS)
+	assert.Equal(t, 0.0, first.DeductionTotal)
+	assert.Equal(t, 60.0, first.FGTSAmount)
+
+	second, err := CalculatePayroll(PayrollInput{PayslipType: "13_segunda", BaseSalary: 3000}, tables)
+	require.NoError(t, err)
+	assert.Equal(t, 3000.0, payrollItem(t, second, PayrollCodeThirteenth).Amount)
+	assert.Equal(t, 1500.0, payrollItem(t, second, PayrollCodeThirteenthDed).Amount)
+	assert.Equal(t, 253.41, second.INSS)
+	assert.Equal(t, 1500.0, second.FGTSBase)
+	assert.Equal(t, 1246.59, second.NetTotal)
+
+	_, err = CalculatePayroll(PayrollInput{PayslipType: "rescisao", BaseSalary: 3000}, tables)
+	assert.ErrorIs(t, err, ErrPayrollUnsupportedType)
+}
+
+func TestParsePayrollTaxTableValidation(t *testing.T) {
+	cases := []models.PayrollTaxTable{
+		{Kind: "iss", Brackets: `[{"up_to":0,"rate":5}]`},
+		{Kind: models.PayrollTaxINSS, Brackets: `[]`},
+		{Kind: models.PayrollTaxINSS, Brackets: `[{"up_to":2000,"rate":9},{"up_to":1000,"rate":12}]`},
+		{Kind: models.PayrollTaxIRRF, Brackets: `[{"up_to":0,"rate":0},{"up_to":3000,"rate":15}]`},
+		{Kind: models.PayrollTaxFGTS, Brackets: `[{"up_to":0,"rate":8},{"up_to":0,"rate":2}]`},
+		{Kind: models.PayrollTaxINSS, Brackets: `[{"up_to":1000,"rate":150}]`},
+		{Kind: models.PayrollTaxINSS, Brackets: `não é json`},
+	}
+	for _, table := range cases {
+		table := table
+		_, err := ParsePayrollTaxTable(&table)
+		assert.ErrorIs(t, err, ErrPayrollInvalidTaxTable, table.Brackets)
+	}
+}
+
+func TestPayrollEngineUsesEffectiveTables(t *testing.T) {
+	var requested []time.Time
+	defaults := DefaultPayrollTaxTables()
+	engine := &PayrollEngine{loadTable: func(kind models.PayrollTaxKind, at time.Time) (*models.PayrollTaxTable, error) {
+		requested = append(requested, at)
+		for i := range defaults {
+			if defaults[i].Kind == kind && !defaults[i].ValidFrom.After(at) {
+				return &defaults[i], nil
+			}
+		}
+		return nil, ErrPayrollTableNotFound
+	}}
+
+	payment := time.Date(2025, time.August, 5, 0, 0, 0, 0, time.UTC)
+	r, err := engine.Calculate(PayrollInput{ReferenceMonth: 7, ReferenceYear: 2025, PaymentDate: &payment, BaseSalary: 3000})
+	require.NoError(t, err)
+	assert.Equal(t, 253.41, r.INSS)
+	// INSS e FGTS pela competência, IRRF pela data de pagamento
+	assert.Equal(t, []time.Time{time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC), payment, time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)}, requested)
+
+	// Antes da vigência da tabela de IRRF não há cálculo
+	_, err = engine.Calculate(PayrollInput{ReferenceMonth: 1, ReferenceYear: 2025, BaseSalary: 3000})
+	assert.ErrorIs(t, err, ErrPayrollTableNotFound)
+}
+
+func TestCrossCheckPayslip(t *testing.T) {
+	tables := defaultPayrollTables(t)
+	p := &models.Payslip{
+		PayslipType: "mensal",
+		FGTSAmount:  400,
+		Items: []models.PayslipItem{
+			{Type: "earning", Code: "001", Description: "Salário", Amount: 5000},
+			{Type: "deduction", Code: "900", Description: "INSS", Amount: 509.60},
+			{Type: "deduction", Code: "901", Description: "Imposto de Renda", Amount: 312.89},
+		},
+	}
+	assert.Empty(t, CrossCheckPayslip(p, tables, 0))
+
+	p.Items[1].Amount = 500
+	p.Items = p.Items[:2]
+	p.FGTSAmount = 350
+	issues := CrossCheckPayslip(p, tables, 0)
+	assert.Len(t, issues, 3)
+	assert.Contains(t, issues[0], "INSS")
+	assert.Contains(t, issues[1], "IRRF não informado")
+	assert.Contains(t, issues[2], "FGTS")
+
+	// Tipos sem cálculo automático não são conferidos
+	p.PayslipType = "rescisao"
+	assert.Empty(t, CrossCheckPayslip(p, tables, 0))
+}
+
+func TestCalculateIRRFMonthlyReduction2026(t *testing.T) {
+	irrf := payrollTablesAt(t, time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)).IRRF
+
+	cases := []struct {
+		income    float64
+		reduction float64
+		tax       float64
+	}{
+		{2428.80, 0, 0},      // Isento pela tabela
+		{5000.00, 312.89, 0}, // Limite da redução integral: imposto zerado
+		{5000.01, 312.89, 0}, // Início da redução decrescente
+		{6000.00, 179.75, 394.54},
+		{7350.00, 0, 945.54}, // Limite da redução (978,62 - 7.350 × 13,3145%)
+		{7350.01, 0, 945.54}, // Sem redução
+		{10000.00, 0, 1674.29},
+	}
+	for _, c := range cases {
+		r := CalculateIRRF(c.income, 0, 0, 0, irrf)
+		assert.Equal(t, c.reduction, r.Reduction, "redução para %.2f", c.income)
+		assert.Equal(t, c.tax, r.Tax, "imposto para %.2f", c.income)
+	}
+
+	// A redução é calculada sobre os rendimentos, não sobre a base após deduções
+	r := CalculateIRRF(6000, 600, 2, 0, irrf)
+	assert.False(t, r.Simplified)
+	assert.Equal(t, 179.75, r.Reduction)
+
+	// 13º salário: tributação exclusiva, sem redução
+	r = CalculateThirteenthIRRF(5000, 0, 0, 0, irrf)
+	assert.Equal(t, 0.0, r.Reduction)
+	assert.Equal(t, 312.89, r.Tax)
+
+	// Tabelas anteriores a 2026 não têm redução
+	r = CalculateIRRF(5000, 0, 0, 0, defaultPayrollTables(t).IRRF)
+	assert.Equal(t, 0.0, r.Reduction)
+	assert.Equal(t, 312.89, r.Tax)
+}
+
+func TestCalculatePayrollMonthlyReduction2026(t *testing.T) {
+	tables := payrollTablesAt(t, time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	monthly, err := CalculatePayroll(PayrollInput{PayslipType: "mensal", BaseSalary: 5000}, tables)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, monthly.IRRF.Tax)
+	assert.Equal(t, 312.89, monthly.IRRF.Reduction)
+
+	thirteenth, err := CalculatePayroll(PayrollInput{PayslipType: "13_segunda", BaseSalary: 5000}, tables)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, thirteenth.IRRF.Reduction)
+	assert.Equal(t, 312.89, payrollItem(t, thirteenth, PayrollCodeIRRF).Amount)
+}
+
+func TestParsePayrollTaxTableReduction(t *testing.T) {
+	valid := models.PayrollTaxTable{
+		Kind:              models.PayrollTaxIRRF,
+		Brackets:          `[{"up_to":0,"rate":27.5,"deduction":908.73}]`,
+		ReductionFullUpTo: 5000,
+		ReductionMax:      312.89,
+		ReductionUpTo:     7350,
+		ReductionConstant: 978.62,
+		ReductionRate:     13.3145,
+	}
+	rule, err := ParsePayrollTaxTable(&valid)
+	require.NoError(t, err)
+	assert.Equal(t, 7350.0, rule.Reduction.UpTo)
+
+	inverted := valid
+	inverted.ReductionUpTo = 4000
+	_, err = ParsePayrollTaxTable(&inverted)
+	assert.ErrorIs(t, err, ErrPayrollInvalidTaxTable)
+
+	inss := valid
+	inss.Kind = models.PayrollTaxINSS
+	_, err = ParsePayrollTaxTable(&inss)
+	assert.ErrorIs(t, err, ErrPayrollInvalidTaxTable)
+}
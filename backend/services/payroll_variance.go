@@ -0,0 +1,589 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== Variação da folha entre competências ====================
+//
+// Compara os holerites de duas competências (qualquer status, para revisão antes
+// da publicação) por colaborador e por rubrica, sinalizando as anomalias que a
+// equipe de folha precisa conferir.
+
+var ErrVariancePeriod = errors.New("competências inválidas para comparação")
+
+// Tipos de anomalia do relatório
+const (
+	AnomalyNetChange      = "net_change"      // Líquido variou acima do limite
+	AnomalyNewRubrica     = "new_rubrica"     // Rubrica que não existia na competência base
+	AnomalyRemovedRubrica = "removed_rubrica" // Rubrica da base que desapareceu
+	AnomalyMissingPayslip = "missing_payslip" // Colaborador ativo (ou da base) sem holerite
+	AnomalyNegativeNet    = "negative_net"    // Líquido negativo
+)
+
+// anomalyLabels descrição das anomalias (CSV)
+var anomalyLabels = map[string]string{
+	AnomalyNetChange:      "Variação do líquido",
+	AnomalyNewRubrica:     "Rubrica nova",
+	AnomalyRemovedRubrica: "Rubrica removida",
+	AnomalyMissingPayslip: "Holerite ausente",
+	AnomalyNegativeNet:    "Líquido negativo",
+}
+
+// Situação da rubrica entre as competências
+const (
+	RubricaChangeNew       = "new"
+	RubricaChangeRemoved   = "removed"
+	RubricaChangeChanged   = "changed"
+	RubricaChangeUnchanged = "unchanged"
+)
+
+// PayrollVarianceOptions competências e filtros da comparação
+type PayrollVarianceOptions struct {
+	BaseMonth       int     `json:"base_month"`
+	BaseYear        int     `json:"base_year"`
+	CompareMonth    int     `json:"compare_month"`
+	CompareYear     int     `json:"compare_year"`
+	Branch          string  `json:"branch,omitempty"`
+	PayslipType     string  `json:"payslip_type"`
+	ThresholdPct    float64 `json:"threshold_pct"`    // Variação % do líquido que gera alerta
+	ThresholdAmount float64 `json:"threshold_amount"` // Variação absoluta (R$) que gera alerta (0 = desligado)
+}
+
+// normalize aplica os padrões: competência base = mês anterior à comparada, tipo mensal, 10%
+func (o *PayrollVarianceOptions) normalize() error {
+	if o.CompareMonth < 1 || o.CompareMonth > 12 || o.CompareYear < 2000 {
+		return ErrVariancePeriod
+	}
+	if o.BaseMonth == 0 && o.BaseYear == 0 {
+		prev := time.Date(o.CompareYear, time.Month(o.CompareMonth), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+		o.BaseMonth, o.BaseYear = int(prev.Month()), prev.Year()
+	}
+	if o.BaseMonth < 1 || o.BaseMonth > 12 || o.BaseYear < 2000 {
+		return ErrVariancePeriod
+	}
+	if o.BaseMonth == o.CompareMonth && o.BaseYear == o.CompareYear {
+		return ErrVariancePeriod
+	}
+	if o.PayslipType == "" {
+		o.PayslipType = "mensal"
+	}
+	if o.ThresholdPct <= 0 {
+		o.ThresholdPct = 10
+	}
+	return nil
+}
+
+// PayrollAnomaly alerta de um colaborador
+type PayrollAnomaly struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// PayrollRubricaDelta diferença de uma rubrica (por colaborador ou no total da folha)
+type PayrollRubricaDelta struct {
+	Type         string  `json:"type"` // earning ou deduction
+	Code         string  `json:"code"`
+	Description  string  `json:"description"`
+	Base         float64 `json:"base"`
+	Compare      float64 `json:"compare"`
+	Delta        float64 `json:"delta"`
+	Change       string  `json:"change"`
+	BaseCount    int     `json:"base_count,omitempty"`    // Colaboradores com a rubrica (total da folha)
+	CompareCount int     `json:"compare_count,omitempty"` // Idem, na competência comparada
+}
+
+// PayrollEmployeeVariance comparação de um colaborador
+type PayrollEmployeeVariance struct {
+	EmployeeID       string                `json:"employee_id,omitempty"`
+	EmployeeName     string                `json:"employee_name"`
+	EmployeeCPF      string                `json:"employee_cpf"`
+	Branch           string                `json:"branch"`
+	BasePayslipID    string                `json:"base_payslip_id,omitempty"`
+	ComparePayslipID string                `json:"compare_payslip_id,omitempty"`
+	BaseGross        float64               `json:"base_gross"`
+	CompareGross     float64               `json:"compare_gross"`
+	BaseNet          float64               `json:"base_net"`
+	CompareNet       float64               `json:"compare_net"`
+	NetDelta         float64               `json:"net_delta"`
+	NetDeltaPct      *float64              `json:"net_delta_pct"` // nil quando não há líquido base
+	Rubricas         []PayrollRubricaDelta `json:"rubricas"`
+	Anomalies        []PayrollAnomaly      `json:"anomalies"`
+}
+
+// PayrollVarianceTotals totais da folha nas duas competências
+type PayrollVarianceTotals struct {
+	BaseCount    int     `json:"base_count"`
+	CompareCount int     `json:"compare_count"`
+	BaseGross    float64 `json:"base_gross"`
+	CompareGross float64 `json:"compare_gross"`
+	BaseNet      float64 `json:"base_net"`
+	CompareNet   float64 `json:"compare_net"`
+	NetDelta     float64 `json:"net_delta"`
+}
+
+// PayrollVarianceReport relatório de variação entre competências
+type PayrollVarianceReport struct {
+	Options       PayrollVarianceOptions    `json:"options"`
+	Totals        PayrollVarianceTotals     `json:"totals"`
+	Employees     []PayrollEmployeeVariance `json:"employees"`
+	Rubricas      []PayrollRubricaDelta     `json:"rubricas"`
+	AnomalyCounts map[string]int            `json:"anomaly_counts"`
+	Warnings      []string                  `json:"warnings,omitempty"` // Verificações que não puderam ser feitas
+	GeneratedAt   time.Time                 `json:"generated_at"`
+}
+
+// ActiveColaborador colaborador ativo do cadastro (para detectar holerites ausentes)
+type ActiveColaborador struct {
+	Name          string
+	CPF           string
+	Branch        string
+	AdmissionDate *time.Time
+}
+
+// rubricaKey identifica a rubrica pelo código ou, sem código, pela descrição
+func rubricaKey(itemType, code, description string) string {
+	if code = strings.TrimSpace(code); code != "" {
+		return itemType + "|" + strings.ToUpper(code)
+	}
+	return itemType + "|" + normalizeImportKey(description)
+}
+
+// varianceItem rubrica somada em uma competência
+type varianceItem struct {
+	itemType    string
+	code        string
+	description string
+	amount      float64
+}
+
+// varianceSide holerites de um colaborador em uma competência
+type varianceSide struct {
+	payslipID string
+	gross     float64
+	net       float64
+	items     map[string]*varianceItem
+}
+
+func (s *varianceSide) add(p *models.Payslip) {
+	if s.payslipID == "" {
+		s.payslipID = p.ID
+	}
+	s.gross += p.GrossTotal
+	s.net += p.NetTotal
+	for _, item := range p.Items {
+		key := rubricaKey(item.Type, item.Code, item.Description)
+		it, ok := s.items[key]
+		if !ok {
+			it = &varianceItem{itemType: item.Type, code: item.Code, description: item.Description}
+			s.items[key] = it
+		}
+		it.amount += item.Amount
+	}
+}
+
+// varianceEmployee colaborador com os dois lados da comparação
+type varianceEmployee struct {
+	key     string
+	name    string
+	cpf     string
+	id      string
+	branch  string
+	base    *varianceSide
+	compare *varianceSide
+}
+
+// payslipEmployeeKey agrupa pela identidade do colaborador ou, sem vínculo, pelo CPF
+func payslipEmployeeKey(p *models.Payslip) string {
+	if p.EmployeeID != "" {
+		return p.EmployeeID
+	}
+	if cpf := cleanDigits(p.EmployeeCPF); cpf != "" {
+		return "cpf:" + cpf
+	}
+	return "payslip:" + p.ID
+}
+
+// ComparePayroll monta o relatório a partir dos holerites das duas competências e
+// dos colaboradores ativos (para os holerites ausentes na competência comparada)
+func ComparePayroll(opts PayrollVarianceOptions, base, compare []models.Payslip, active []ActiveColaborador) *PayrollVarianceReport {
+	report := &PayrollVarianceReport{
+		Options:       opts,
+		Employees:     []PayrollEmployeeVariance{},
+		Rubricas:      []PayrollRubricaDelta{},
+		AnomalyCounts: map[string]int{},
+	}
+
+	employees := map[string]*varianceEmployee{}
+	var order []string
+	collect := func(payslips []models.Payslip, isBase bool) {
+		for i := range payslips {
+			p := &payslips[i]
+			key := payslipEmployeeKey(p)
+			e, ok := employees[key]
+			if !ok {
+				e = &varianceEmployee{key: key}
+				employees[key] = e
+				order = append(order, key)
+			}
+			e.name, e.cpf, e.id, e.branch = p.EmployeeName, cleanDigits(p.EmployeeCPF), p.EmployeeID, p.Branch
+			side := &e.compare
+			if isBase {
+				side = &e.base
+				report.Totals.BaseGross += p.GrossTotal
+				report.Totals.BaseNet += p.NetTotal
+			} else {
+				report.Totals.CompareGross += p.GrossTotal
+				report.Totals.CompareNet += p.NetTotal
+			}
+			if *side == nil {
+				*side = &varianceSide{items: map[string]*varianceItem{}}
+			}
+			(*side).add(p)
+		}
+	}
+	collect(base, true)
+	collect(compare, false)
+
+	// Colaboradores ativos sem holerite em nenhuma das competências
+	compareEnd := time.Date(opts.CompareYear, time.Month(opts.CompareMonth), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	present := map[string]bool{}
+	for _, e := range employees {
+		if e.cpf != "" {
+			present[e.cpf] = true
+		}
+	}
+	for _, colaborador := range active {
+		cpf := cleanDigits(colaborador.CPF)
+		if cpf == "" || present[cpf] {
+			continue
+		}
+		if colaborador.AdmissionDate != nil && !colaborador.AdmissionDate.Before(compareEnd) {
+			continue
+		}
+		present[cpf] = true
+		key := "cpf:" + cpf
+		employees[key] = &varianceEmployee{key: key, name: colaborador.Name, cpf: cpf, branch: colaborador.Branch}
+		order = append(order, key)
+	}
+
+	totals := map[string]*PayrollRubricaDelta{}
+	var totalOrder []string
+	for _, key := range order {
+		e := employees[key]
+		v := compareEmployee(opts, e)
+		report.Employees = append(report.Employees, v)
+		for _, a := range v.Anomalies {
+			report.AnomalyCounts[a.Kind]++
+		}
+		if e.base != nil {
+			report.Totals.BaseCount++
+		}
+		if e.compare != nil {
+			report.Totals.CompareCount++
+		}
+
+		for _, r := range v.Rubricas {
+			k := rubricaKey(r.Type, r.Code, r.Description)
+			t, ok := totals[k]
+			if !ok {
+				t = &PayrollRubricaDelta{Type: r.Type, Code: r.Code, Description: r.Description}
+				totals[k] = t
+				totalOrder = append(totalOrder, k)
+			}
+			t.Base += r.Base
+			t.Compare += r.Compare
+			if r.Change != RubricaChangeNew {
+				t.BaseCount++
+			}
+			if r.Change != RubricaChangeRemoved {
+				t.CompareCount++
+			}
+		}
+	}
+
+	for _, k := range totalOrder {
+		t := totals[k]
+		t.Base, t.Compare = roundCents(t.Base), roundCents(t.Compare)
+		t.Delta = roundCents(t.Compare - t.Base)
+		t.Change = rubricaChange(t.BaseCount > 0, t.CompareCount > 0, t.Delta)
+		report.Rubricas = append(report.Rubricas, *t)
+	}
+	sort.SliceStable(report.Rubricas, func(i, j int) bool {
+		return math.Abs(report.Rubricas[i].Delta) > math.Abs(report.Rubricas[j].Delta)
+	})
+
+	// Colaboradores com anomalias primeiro, depois por nome
+	sort.SliceStable(report.Employees, func(i, j int) bool {
+		a, b := report.Employees[i], report.Employees[j]
+		if (len(a.Anomalies) > 0) != (len(b.Anomalies) > 0) {
+			return len(a.Anomalies) > 0
+		}
+		return a.EmployeeName < b.EmployeeName
+	})
+
+	report.Totals.BaseGross = roundCents(report.Totals.BaseGross)
+	report.Totals.CompareGross = roundCents(report.Totals.CompareGross)
+	report.Totals.BaseNet = roundCents(report.Totals.BaseNet)
+	report.Totals.CompareNet = roundCents(report.Totals.CompareNet)
+	report.Totals.NetDelta = roundCents(report.Totals.CompareNet - report.Totals.BaseNet)
+	return report
+}
+
+func rubricaChange(inBase, inCompare bool, delta float64) string {
+	switch {
+	case !inBase:
+		return RubricaChangeNew
+	case !inCompare:
+		return RubricaChangeRemoved
+	case !centsEqual(delta, 0):
+		return RubricaChangeChanged
+	}
+	return RubricaChangeUnchanged
+}
+
+// compareEmployee calcula as diferenças e anomalias de um colaborador
+func compareEmployee(opts PayrollVarianceOptions, e *varianceEmployee) PayrollEmployeeVariance {
+	v := PayrollEmployeeVariance{
+		EmployeeID:   e.id,
+		EmployeeName: e.name,
+		EmployeeCPF:  FormatCPF(e.cpf),
+		Branch:       e.branch,
+		Rubricas:     []PayrollRubricaDelta{},
+		Anomalies:    []PayrollAnomaly{},
+	}
+	flag := func(kind, message string) {
+		v.Anomalies = append(v.Anomalies, PayrollAnomaly{Kind: kind, Message: message})
+	}
+	basePeriod := fmt.Sprintf("%02d/%d", opts.BaseMonth, opts.BaseYear)
+	comparePeriod := fmt.Sprintf("%02d/%d", opts.CompareMonth, opts.CompareYear)
+
+	baseItems := map[string]*varianceItem{}
+	compareItems := map[string]*varianceItem{}
+	if e.base != nil {
+		v.BasePayslipID = e.base.payslipID
+		v.BaseGross, v.BaseNet = roundCents(e.base.gross), roundCents(e.base.net)
+		baseItems = e.base.items
+	}
+	if e.compare != nil {
+		v.ComparePayslipID = e.compare.payslipID
+		v.CompareGross, v.CompareNet = roundCents(e.compare.gross), roundCents(e.compare.net)
+		compareItems = e.compare.items
+	}
+	v.NetDelta = roundCents(v.CompareNet - v.BaseNet)
+
+	switch {
+	case e.compare == nil && e.base == nil:
+		flag(AnomalyMissingPayslip, fmt.Sprintf("Colaborador ativo sem holerite em %s", comparePeriod))
+	case e.compare == nil:
+		flag(AnomalyMissingPayslip, fmt.Sprintf("Tinha holerite em %s e não tem em %s", basePeriod, comparePeriod))
+	}
+
+	if e.base != nil && e.compare != nil && v.BaseNet > 0 {
+		pct := roundCents(v.NetDelta / v.BaseNet * 100)
+		v.NetDeltaPct = &pct
+		overPct := math.Abs(pct) > opts.ThresholdPct
+		overAmount := opts.ThresholdAmount > 0 && math.Abs(v.NetDelta) > opts.ThresholdAmount
+		if overPct || overAmount {
+			flag(AnomalyNetChange, fmt.Sprintf("Líquido variou %s%% (R$ %s)", FormatBRL(pct), FormatBRL(v.NetDelta)))
+		}
+	}
+	if e.base != nil && v.BaseNet < 0 {
+		flag(AnomalyNegativeNet, fmt.Sprintf("Líquido negativo em %s (R$ %s)", basePeriod, FormatBRL(v.BaseNet)))
+	}
+	if e.compare != nil && v.CompareNet < 0 {
+		flag(AnomalyNegativeNet, fmt.Sprintf("Líquido negativo em %s (R$ %s)", comparePeriod, FormatBRL(v.CompareNet)))
+	}
+
+	keys := map[string]bool{}
+	for k := range baseItems {
+		keys[k] = true
+	}
+	for k := range compareItems {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		b, inBase := baseItems[k]
+		c, inCompare := compareItems[k]
+		r := PayrollRubricaDelta{}
+		if inBase {
+			r.Type, r.Code, r.Description = b.itemType, b.code, b.description
+			r.Base = roundCents(b.amount)
+		}
+		if inCompare {
+			r.Type, r.Code, r.Description = c.itemType, c.code, c.description
+			r.Compare = roundCents(c.amount)
+		}
+		r.Delta = roundCents(r.Compare - r.Base)
+		r.Change = rubricaChange(inBase, inCompare, r.Delta)
+		v.Rubricas = append(v.Rubricas, r)
+
+		// Rubricas novas/removidas só fazem sentido com holerite nas duas competências
+		if e.base == nil || e.compare == nil {
+			continue
+		}
+		switch r.Change {
+		case RubricaChangeNew:
+			flag(AnomalyNewRubrica, fmt.Sprintf("%s (R$ %s)", r.Description, FormatBRL(r.Compare)))
+		case RubricaChangeRemoved:
+			flag(AnomalyRemovedRubrica, fmt.Sprintf("%s (R$ %s em %s)", r.Description, FormatBRL(r.Base), basePeriod))
+		}
+	}
+	return v
+}
+
+// VarianceCSV exporta o relatório (uma linha de líquido por colaborador e uma
+// por rubrica alterada), com as anomalias, para conferência antes da publicação
+func VarianceCSV(report *PayrollVarianceReport, onlyAnomalies bool) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = ';'
+
+	base := fmt.Sprintf("%02d/%d", report.Options.BaseMonth, report.Options.BaseYear)
+	compare := fmt.Sprintf("%02d/%d", report.Options.CompareMonth, report.Options.CompareYear)
+	w.Write([]string{"colaborador", "cpf", "filial", "linha", "tipo", base, compare, "diferenca", "variacao_pct", "situacao", "anomalias"})
+	for _, warning := range report.Warnings {
+		w.Write([]string{"", "", "", "AVISO", "", "", "", "", "", "", warning})
+	}
+
+	for _, e := range report.Employees {
+		if onlyAnomalies && len(e.Anomalies) == 0 {
+			continue
+		}
+		var anomalies []string
+		for _, a := range e.Anomalies {
+			anomalies = append(anomalies, anomalyLabels[a.Kind]+": "+a.Message)
+		}
+		pct := ""
+		if e.NetDeltaPct != nil {
+			pct = FormatBRL(*e.NetDeltaPct)
+		}
+		w.Write([]string{e.EmployeeName, e.EmployeeCPF, e.Branch, "LÍQUIDO", "", FormatBRL(e.BaseNet), FormatBRL(e.CompareNet), FormatBRL(e.NetDelta), pct, "", strings.Join(anomalies, " | ")})
+
+		for _, r := range e.Rubricas {
+			if r.Change == RubricaChangeUnchanged {
+				continue
+			}
+			line := r.Description
+			if r.Code != "" {
+				line = r.Code + " - " + r.Description
+			}
+			kind := "Provento"
+			if r.Type != "earning" {
+				kind = "Desconto"
+			}
+			w.Write([]string{e.EmployeeName, e.EmployeeCPF, e.Branch, line, kind, FormatBRL(r.Base), FormatBRL(r.Compare), FormatBRL(r.Delta), "", rubricaChangeLabel(r.Change), ""})
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func rubricaChangeLabel(change string) string {
+	switch change {
+	case RubricaChangeNew:
+		return "nova"
+	case RubricaChangeRemoved:
+		return "removida"
+	case RubricaChangeChanged:
+		return "alterada"
+	}
+	return ""
+}
+
+// ==================== Consulta ====================
+
+// PayrollVarianceService carrega as competências e monta o relatório
+type PayrollVarianceService struct {
+	now    func() time.Time
+	active func(branch string) ([]ActiveColaborador, error)
+}
+
+// PayrollVariance instância usada pelos handlers
+var PayrollVariance = &PayrollVarianceService{now: time.Now, active: activeColaboradores}
+
+// activeColaboradores colaboradores ativos de dbo.ColaboradoresFradema (opcionalmente de uma filial)
+func activeColaboradores(branch string) ([]ActiveColaborador, error) {
+	query := `
+		SELECT p.Nome AS name, p.Cpf AS cpf, LTRIM(RTRIM(ISNULL(c.Filial, ''))) AS branch, c.DataAdmissao AS admission_date
+		FROM dbo.ColaboradoresFradema c
+		INNER JOIN dbo.PessoasFisicasFradema p ON c.PessoaFisicaId = p.Id
+		WHERE c.Ativo = 1`
+	args := []interface{}{}
+	if branch != "" {
+		query += " AND LTRIM(RTRIM(c.Filial)) = ?"
+		args = append(args, branch)
+	}
+
+	var rows []ActiveColaborador
+	err := config.DB.Raw(query, args...).Scan(&rows).Error
+	return rows, err
+}
+
+// competencePayslips holerites de uma competência com as rubricas
+func competencePayslips(month, year int, opts PayrollVarianceOptions) ([]models.Payslip, error) {
+	var payslips []models.Payslip
+	err := config.DB.Preload("Items").
+		Where("reference_month = ? AND reference_year = ? AND payslip_type = ?", month, year, opts.PayslipType).
+		Scopes(func(db *gorm.DB) *gorm.DB {
+			if opts.Branch != "" {
+				return db.Where("branch = ?", opts.Branch)
+			}
+			return db
+		}).
+		Order("employee_name ASC").
+		Find(&payslips).Error
+	return payslips, err
+}
+
+// Report compara as competências das opções
+func (s *PayrollVarianceService) Report(opts PayrollVarianceOptions) (*PayrollVarianceReport, error) {
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+
+	base, err := competencePayslips(opts.BaseMonth, opts.BaseYear, opts)
+	if err != nil {
+		return nil, err
+	}
+	compare, err := competencePayslips(opts.CompareMonth, opts.CompareYear, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Holerites ausentes só são cobrados dos ativos na folha mensal
+	var active []ActiveColaborador
+	var warnings []string
+	if opts.PayslipType == "mensal" {
+		// Sem o cadastro o relatório sai, mas avisa que os ausentes não foram verificados
+		if active, err = s.active(opts.Branch); err != nil {
+			log.Printf("⚠️ Variação da folha: erro ao ler colaboradores ativos: %v", err)
+			active = nil
+			warnings = append(warnings, "Não foi possível ler o cadastro de colaboradores ativos; holerites ausentes não foram verificados.")
+		}
+	}
+
+	report := ComparePayroll(opts, base, compare, active)
+	report.Warnings = warnings
+	report.GeneratedAt = s.now()
+	return report, nil
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func variancePayslip(id, employeeID, cpf, name string, net float64, items ...models.PayslipItem) models.Payslip {
+	p := models.Payslip{ID: id, EmployeeID: employeeID, EmployeeCPF: cpf, EmployeeName: name, Branch: "Matriz", NetTotal: net, Items: items}
+	for _, item := range items {
+		if item.Type == "earning" {
+			p.GrossTotal += item.Amount
+		}
+	}
+	return p
+}
+
+func varianceOptions() PayrollVarianceOptions {
+	opts := PayrollVarianceOptions{CompareMonth: 3, CompareYear: 2026}
+	_ = opts.normalize()
+	return opts
+}
+
+func findVariance(t *testing.T, report *PayrollVarianceReport, name string) PayrollEmployeeVariance {
+	for _, e := range report.Employees {
+		if e.EmployeeName == name {
+			return e
+		}
+	}
+	t.Fatalf("colaborador %s não encontrado no relatório", name)
+	return PayrollEmployeeVariance{}
+}
+
+func anomalyKinds(e PayrollEmployeeVariance) []string {
+	kinds := []string{}
+	for _, a := range e.Anomalies {
+		kinds = append(kinds, a.Kind)
+	}
+	return kinds
+}
+
+func TestPayrollVarianceOptionsDefaults(t *testing.T) {
+	opts := PayrollVarianceOptions{CompareMonth: 1, CompareYear: 2026}
+	require.NoError(t, opts.normalize())
+	assert.Equal(t, 12, opts.BaseMonth)
+	assert.Equal(t, 2025, opts.BaseYear)
+	assert.Equal(t, "mensal", opts.PayslipType)
+	assert.Equal(t, 10.0, opts.ThresholdPct)
+
+	same := PayrollVarianceOptions{BaseMonth: 3, BaseYear: 2026, CompareMonth: 3, CompareYear: 2026}
+	assert.ErrorIs(t, same.normalize(), ErrVariancePeriod)
+	assert.ErrorIs(t, (&PayrollVarianceOptions{CompareMonth: 13, CompareYear: 2026}).normalize(), ErrVariancePeriod)
+}
+
+func TestComparePayroll(t *testing.T) {
+	base := []models.Payslip{
+		variancePayslip("b-ana", "e-ana", cpfAna, "Ana", 4000,
+			earning("Salário", 5000), deduction("INSS", "INSS", 500), deduction("", "Vale transporte", 300)),
+		variancePayslip("b-bruno", "e-bruno", cpfBruno, "Bruno", 2500, earning("Salário", 3000)),
+		variancePayslip("b-caio", "", "39053344705", "Caio", 1800, earning("Salário", 2000)),
+	}
+	compare := []models.Payslip{
+		// Ana: hora extra nova, vale transporte removido, líquido +25%
+		variancePayslip("c-ana", "e-ana", cpfAna, "Ana", 5000,
+			earning("Salário", 5000), earning("Hora extra", 800), deduction("INSS", "INSS", 550)),
+		// Bruno: variação pequena
+		variancePayslip("c-bruno", "e-bruno", cpfBruno, "Bruno", 2600, earning("Salário", 3100)),
+		// Davi: só na comparada, com líquido negativo
+		variancePayslip("c-davi", "", "86288366757", "Davi", -150, earning("Salário", 100)),
+	}
+	admitted := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	active := []ActiveColaborador{
+		{Name: "Ana", CPF: "529.982.247-25"},
+		{Name: "Eva", CPF: "71428793860", Branch: "Matriz"},
+		{Name: "Fábio", CPF: "52713059095", AdmissionDate: &admitted}, // Admitido depois da competência
+	}
+
+	report := ComparePayroll(varianceOptions(), base, compare, active)
+
+	ana := findVariance(t, report, "Ana")
+	assert.Equal(t, 1000.0, ana.NetDelta)
+	require.NotNil(t, ana.NetDeltaPct)
+	assert.Equal(t, 25.0, *ana.NetDeltaPct)
+	assert.ElementsMatch(t, []string{AnomalyNetChange, AnomalyNewRubrica, AnomalyRemovedRubrica}, anomalyKinds(ana))
+	changes := map[string]string{}
+	for _, r := range ana.Rubricas {
+		changes[r.Description] = r.Change
+	}
+	assert.Equal(t, RubricaChangeUnchanged, changes["Salário"])
+	assert.Equal(t, RubricaChangeChanged, changes["INSS"])
+	assert.Equal(t, RubricaChangeNew, changes["Hora extra"])
+	assert.Equal(t, RubricaChangeRemoved, changes["Vale transporte"])
+
+	bruno := findVariance(t, report, "Bruno")
+	assert.Empty(t, bruno.Anomalies)
+
+	caio := findVariance(t, report, "Caio")
+	assert.Equal(t, []string{AnomalyMissingPayslip}, anomalyKinds(caio))
+	assert.Equal(t, "b-caio", caio.BasePayslipID)
+	assert.Empty(t, caio.ComparePayslipID)
+
+	davi := findVariance(t, report, "Davi")
+	assert.Equal(t, []string{AnomalyNegativeNet}, anomalyKinds(davi))
+	assert.Nil(t, davi.NetDeltaPct)
+
+	eva := findVariance(t, report, "Eva")
+	assert.Equal(t, []string{AnomalyMissingPayslip}, anomalyKinds(eva))
+	assert.Equal(t, "714.287.938-60", eva.EmployeeCPF)
+
+	for _, e := range report.Employees {
+		assert.NotEqual(t, "Fábio", e.EmployeeName)
+	}
+	// Quem tem anomalia vem primeiro
+	assert.Equal(t, "Bruno", report.Employees[len(report.Employees)-1].EmployeeName)
+
+	assert.Equal(t, 3, report.Totals.BaseCount)
+	assert.Equal(t, 3, report.Totals.CompareCount)
+	assert.Equal(t, 8300.0, report.Totals.BaseNet)
+	assert.Equal(t, 7450.0, report.Totals.CompareNet)
+	assert.Equal(t, 2, report.AnomalyCounts[AnomalyMissingPayslip])
+
+	// Total da folha por rubrica: salário caiu com a saída do Caio
+	var salary PayrollRubricaDelta
+	for _, r := range report.Rubricas {
+		if r.Description == "Salário" {
+			salary = r
+		}
+	}
+	assert.Equal(t, 10000.0, salary.Base)
+	assert.Equal(t, 8200.0, salary.Compare)
+	assert.Equal(t, 3, salary.BaseCount)
+	assert.Equal(t, 3, salary.CompareCount)
+}
+
+func TestComparePayrollThresholdAmount(t *testing.T) {
+	opts := varianceOptions()
+	opts.ThresholdPct = 50
+	opts.ThresholdAmount = 50
+	base := []models.Payslip{variancePayslip("b", "e-ana", cpfAna, "Ana", 1000, earning("Salário", 1000))}
+	compare := []models.Payslip{variancePayslip("c", "e-ana", cpfAna, "Ana", 1080, earning("Salário", 1080))}
+
+	report := ComparePayroll(opts, base, compare, nil)
+	assert.Equal(t, []string{AnomalyNetChange}, anomalyKinds(report.Employees[0]))
+}
+
+func TestVarianceCSV(t *testing.T) {
+	base := []models.Payslip{variancePayslip("b", "e-ana", cpfAna, "Ana", 4000, earning("Salário", 5000), deduction("", "Vale transporte", 300))}
+	compare := []models.Payslip{variancePayslip("c", "e-ana", cpfAna, "Ana", 5000, earning("Salário", 5000))}
+	report := ComparePayroll(varianceOptions(), base, compare, nil)
+
+	data, err := VarianceCSV(report, false)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3) // cabeçalho, líquido e a rubrica removida
+	assert.Equal(t, "colaborador;cpf;filial;linha;tipo;02/2026;03/2026;diferenca;variacao_pct;situacao;anomalias", lines[0])
+	assert.Contains(t, lines[1], "LÍQUIDO;;4.000,00;5.000,00;1.000,00;25,00")
+	assert.Contains(t, lines[1], "Rubrica removida: Vale transporte (R$ 300,00 em 02/2026)")
+	assert.Contains(t, lines[2], "Vale transporte;Desconto;300,00;0,00;-300,00;;removida")
+
+	only, err := VarianceCSV(ComparePayroll(varianceOptions(), compare, compare[:0], nil), true)
+	require.NoError(t, err)
+	assert.Contains(t, string(only), "Holerite ausente")
+}
+
+func TestVarianceCSVWarnings(t *testing.T) {
+	report := ComparePayroll(varianceOptions(), nil, nil, nil)
+	report.Warnings = []string{"Cadastro indisponível"}
+
+	data, err := VarianceCSV(report, true)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, ";;;AVISO;;;;;;;Cadastro indisponível", lines[1])
+}
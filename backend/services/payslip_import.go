@@ -0,0 +1,1209 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// ==================== Importação de holerites por arquivo ====================
+//
+// Fluxo: o arquivo (CSV, XLSX ou TXT de largura fixa) é interpretado segundo um
+// template de mapeamento, validado em modo simulação (dry-run) e guardado como
+// lote. A confirmação do lote revalida e grava todos os holerites em uma única
+// transação.
+
+var (
+	ErrImportBatchNotFound     = errors.New("lote de importação não encontrado")
+	ErrImportBatchClosed       = errors.New("lote de importação já foi confirmado ou descartado")
+	ErrImportHasErrors         = errors.New("o lote possui erros de validação")
+	ErrImportReplacesPublished = errors.New("o lote substituiria um holerite já publicado")
+	ErrImportUnknownFormat     = errors.New("formato de arquivo não suportado (use CSV, XLSX ou TXT)")
+	ErrImportTemplate          = errors.New("template de importação inválido")
+)
+
+// Campos reconhecidos nos templates (nomes usados nos cabeçalhos do modelo padrão)
+const (
+	ImportFieldCPF            = "cpf"
+	ImportFieldName           = "nome"
+	ImportFieldColaboradorID  = "colaborador_id"
+	ImportFieldPosition       = "cargo"
+	ImportFieldDepartment     = "departamento"
+	ImportFieldBranch         = "filial"
+	ImportFieldAdmission      = "admissao"
+	ImportFieldMonth          = "mes"
+	ImportFieldYear           = "ano"
+	ImportFieldPeriod         = "competencia" // MM/AAAA (alternativa a mes + ano)
+	ImportFieldType           = "tipo"
+	ImportFieldPaymentDate    = "data_pagamento"
+	ImportFieldBaseSalary     = "salario_base"
+	ImportFieldWorkedDays     = "dias_trabalhados"
+	ImportFieldINSSBase       = "base_inss"
+	ImportFieldIRRFBase       = "base_irrf"
+	ImportFieldFGTSBase       = "base_fgts"
+	ImportFieldFGTSAmount     = "valor_fgts"
+	ImportFieldGrossTotal     = "total_proventos"
+	ImportFieldDeductionTotal = "total_descontos"
+	ImportFieldNetTotal       = "liquido"
+
+	ImportFieldItemCode        = "rubrica_codigo"
+	ImportFieldItemDescription = "rubrica_descricao"
+	ImportFieldItemType        = "rubrica_tipo"
+	ImportFieldItemReference   = "rubrica_referencia"
+	ImportFieldItemAmount      = "rubrica_valor"
+)
+
+// payslipImportFields campos de cabeçalho do holerite (o restante são campos de rubrica)
+var payslipImportFields = []string{
+	ImportFieldCPF, ImportFieldName, ImportFieldColaboradorID, ImportFieldPosition, ImportFieldDepartment,
+	ImportFieldBranch, ImportFieldAdmission, ImportFieldMonth, ImportFieldYear, ImportFieldPeriod, ImportFieldType,
+	ImportFieldPaymentDate, ImportFieldBaseSalary, ImportFieldWorkedDays, ImportFieldINSSBase, ImportFieldIRRFBase,
+	ImportFieldFGTSBase, ImportFieldFGTSAmount, ImportFieldGrossTotal, ImportFieldDeductionTotal, ImportFieldNetTotal,
+}
+
+var payslipImportItemFields = []string{
+	ImportFieldItemCode, ImportFieldItemDescription, ImportFieldItemType, ImportFieldItemReference, ImportFieldItemAmount,
+}
+
+// FixedField posição de um campo no TXT (início a partir de 1)
+type FixedField struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// FixedWidthLayout registros do TXT de largura fixa: um registro de colaborador
+// abre o holerite e os registros de rubrica seguintes pertencem a ele
+type FixedWidthLayout struct {
+	RecordType     FixedField            `json:"record_type"`
+	EmployeeRecord string                `json:"employee_record"`
+	ItemRecord     string                `json:"item_record"`
+	EmployeeFields map[string]FixedField `json:"employee_fields"`
+	ItemFields     map[string]FixedField `json:"item_fields"`
+}
+
+// PayslipImportConfig template já interpretado
+type PayslipImportConfig struct {
+	Format          models.PayslipImportFormat
+	Delimiter       rune
+	HeaderRow       int
+	DecimalComma    bool
+	ImpliedDecimals int
+	DateFormat      string
+	Columns         map[string]string
+	Layout          *FixedWidthLayout
+	RubricaTypes    map[string]string
+}
+
+// DefaultFixedWidthLayout layout TXT padrão (registro 1 = colaborador, 2 = rubrica)
+func DefaultFixedWidthLayout() *FixedWidthLayout {
+	return &FixedWidthLayout{
+		RecordType:     FixedField{Start: 1, Length: 1},
+		EmployeeRecord: "1",
+		ItemRecord:     "2",
+		EmployeeFields: map[string]FixedField{
+			ImportFieldCPF:         {Start: 2, Length: 11},
+			ImportFieldName:        {Start: 13, Length: 50},
+			ImportFieldPeriod:      {Start: 63, Length: 6}, // MMAAAA
+			ImportFieldType:        {Start: 69, Length: 12},
+			ImportFieldPaymentDate: {Start: 81, Length: 8}, // DDMMAAAA
+			ImportFieldPosition:    {Start: 89, Length: 40},
+			ImportFieldDepartment:  {Start: 129, Length: 40},
+			ImportFieldBranch:      {Start: 169, Length: 30},
+			ImportFieldBaseSalary:  {Start: 199, Length: 12},
+			ImportFieldWorkedDays:  {Start: 211, Length: 2},
+			ImportFieldINSSBase:    {Start: 213, Length: 12},
+			ImportFieldIRRFBase:    {Start: 225, Length: 12},
+			ImportFieldFGTSBase:    {Start: 237, Length: 12},
+			ImportFieldFGTSAmount:  {Start: 249, Length: 12},
+		},
+		ItemFields: map[string]FixedField{
+			ImportFieldItemCode:        {Start: 2, Length: 5},
+			ImportFieldItemDescription: {Start: 7, Length: 40},
+			ImportFieldItemType:        {Start: 47, Length: 1}, // P = provento, D = desconto
+			ImportFieldItemReference:   {Start: 48, Length: 8},
+			ImportFieldItemAmount:      {Start: 56, Length: 12},
+		},
+	}
+}
+
+// DefaultImportConfig configuração usada quando nenhum template é informado:
+// cabeçalhos com os próprios nomes dos campos (CSV/XLSX) ou o layout TXT padrão
+func DefaultImportConfig(format models.PayslipImportFormat) PayslipImportConfig {
+	cfg := PayslipImportConfig{Format: format, HeaderRow: 1, DecimalComma: true, DateFormat: "02/01/2006"}
+	if format == models.PayslipImportFixed {
+		cfg.HeaderRow = 0
+		cfg.ImpliedDecimals = 2
+		cfg.DateFormat = "02012006"
+		cfg.Layout = DefaultFixedWidthLayout()
+		return cfg
+	}
+	cfg.Columns = map[string]string{}
+	for _, field := range append(append([]string{}, payslipImportFields...), payslipImportItemFields...) {
+		cfg.Columns[field] = field
+	}
+	return cfg
+}
+
+// ImportConfigFromTemplate interpreta um template salvo
+func ImportConfigFromTemplate(t *models.PayslipImportTemplate) (PayslipImportConfig, error) {
+	cfg := DefaultImportConfig(t.Format)
+	cfg.HeaderRow = t.HeaderRow
+	cfg.DecimalComma = t.DecimalComma
+	if t.ImpliedDecimals > 0 || t.Format == models.PayslipImportFixed {
+		cfg.ImpliedDecimals = t.ImpliedDecimals
+	}
+	if t.DateFormat != "" {
+		cfg.DateFormat = t.DateFormat
+	}
+	if t.Delimiter != "" {
+		cfg.Delimiter, _ = utf8.DecodeRuneInString(t.Delimiter)
+		if t.Delimiter == `\t` {
+			cfg.Delimiter = '\t'
+		}
+	}
+	if t.RubricaTypes != "" {
+		if err := json.Unmarshal([]byte(t.RubricaTypes), &cfg.RubricaTypes); err != nil {
+			return cfg, fmt.Errorf("%w: tipos de rubrica devem ser um objeto JSON", ErrImportTemplate)
+		}
+	}
+
+	switch t.Format {
+	case models.PayslipImportCSV, models.PayslipImportXLSX:
+		var columns map[string]string
+		if err := json.Unmarshal([]byte(t.Columns), &columns); err != nil {
+			return cfg, fmt.Errorf("%w: colunas devem ser um objeto JSON (campo -> coluna)", ErrImportTemplate)
+		}
+		cfg.Columns = map[string]string{}
+		for field, column := range columns {
+			if !isImportField(field) {
+				return cfg, fmt.Errorf("%w: campo desconhecido %q", ErrImportTemplate, field)
+			}
+			if column = strings.TrimSpace(column); column != "" {
+				cfg.Columns[field] = column
+			}
+		}
+		if cfg.Columns[ImportFieldCPF] == "" {
+			return cfg, fmt.Errorf("%w: o mapeamento da coluna de CPF é obrigatório", ErrImportTemplate)
+		}
+		if cfg.Columns[ImportFieldPeriod] == "" && (cfg.Columns[ImportFieldMonth] == "" || cfg.Columns[ImportFieldYear] == "") {
+			return cfg, fmt.Errorf("%w: mapeie a competência ou as colunas de mês e ano", ErrImportTemplate)
+		}
+	case models.PayslipImportFixed:
+		if t.Layout != "" {
+			var layout FixedWidthLayout
+			if err := json.Unmarshal([]byte(t.Layout), &layout); err != nil {
+				return cfg, fmt.Errorf("%w: layout deve ser um objeto JSON", ErrImportTemplate)
+			}
+			cfg.Layout = &layout
+		}
+		if cfg.Layout.EmployeeRecord == "" || cfg.Layout.EmployeeFields[ImportFieldCPF].Length == 0 {
+			return cfg, fmt.Errorf("%w: o layout precisa do registro de colaborador com a posição do CPF", ErrImportTemplate)
+		}
+	default:
+		return cfg, ErrImportUnknownFormat
+	}
+	return cfg, nil
+}
+
+func isImportField(field string) bool {
+	for _, f := range payslipImportFields {
+		if f == field {
+			return true
+		}
+	}
+	for _, f := range payslipImportItemFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectImportFormat deduz o formato pela extensão do arquivo
+func DetectImportFormat(fileName string) (models.PayslipImportFormat, error) {
+	name := strings.ToLower(fileName)
+	switch {
+	case strings.HasSuffix(name, ".csv"):
+		return models.PayslipImportCSV, nil
+	case strings.HasSuffix(name, ".xlsx"):
+		return models.PayslipImportXLSX, nil
+	case strings.HasSuffix(name, ".txt"), strings.HasSuffix(name, ".rem"):
+		return models.PayslipImportFixed, nil
+	}
+	return "", ErrImportUnknownFormat
+}
+
+// ==================== Interpretação ====================
+
+// ImportIssue problema encontrado na validação
+type ImportIssue struct {
+	Row      int    `json:"row,omitempty"` // Linha do arquivo
+	CPF      string `json:"cpf,omitempty"`
+	Severity string `json:"severity"` // error (impede a confirmação) ou warning
+	Message  string `json:"message"`
+}
+
+// ImportedPayslip holerite interpretado do arquivo
+type ImportedPayslip struct {
+	Rows    []int          `json:"rows"`
+	Payslip models.Payslip `json:"payslip"`
+
+	// Totais declarados no arquivo (conferidos com a soma das rubricas)
+	DeclaredGross     *float64 `json:"declared_gross,omitempty"`
+	DeclaredDeduction *float64 `json:"declared_deduction,omitempty"`
+	DeclaredNet       *float64 `json:"declared_net,omitempty"`
+
+	Issues []ImportIssue `json:"issues,omitempty"` // Problemas encontrados na leitura do arquivo
+}
+
+// importRecord linha do arquivo já mapeada para os campos
+type importRecord struct {
+	row    int
+	values map[string]string
+}
+
+// ParsePayslipFile interpreta o arquivo e agrupa as linhas em holerites
+func ParsePayslipFile(data []byte, cfg PayslipImportConfig) ([]*ImportedPayslip, error) {
+	switch cfg.Format {
+	case models.PayslipImportCSV:
+		rows, err := readImportCSV(decodeImportText(data), cfg.Delimiter)
+		if err != nil {
+			return nil, err
+		}
+		return groupTabularRecords(rows, cfg), nil
+	case models.PayslipImportXLSX:
+		rows, err := ReadXLSX(data)
+		if err != nil {
+			return nil, err
+		}
+		return groupTabularRecords(rows, cfg), nil
+	case models.PayslipImportFixed:
+		return parseFixedWidth(decodeImportText(data), cfg), nil
+	}
+	return nil, ErrImportUnknownFormat
+}
+
+// decodeImportText converte exportações em Windows-1252 (comuns nos sistemas de folha) para UTF-8
+func decodeImportText(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	decoded, err := charmap.Windows1252.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+func readImportCSV(text string, delimiter rune) ([][]string, error) {
+	if delimiter == 0 {
+		firstLine := text
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			firstLine = text[:i]
+		}
+		delimiter = ';'
+		if strings.Count(firstLine, ",") > strings.Count(firstLine, ";") {
+			delimiter = ','
+		}
+	}
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV inválido: %w", err)
+		}
+		rows = append(rows, record)
+	}
+	return rows, nil
+}
+
+// mapTabularRows resolve as colunas do template (nome do cabeçalho ou número) em cada
+// linha; hasItems indica se a coluna de valor da rubrica existe no arquivo
+func mapTabularRows(rows [][]string, cfg PayslipImportConfig) (records []importRecord, hasItems bool) {
+	indexes := map[string]int{}
+	var header []string
+	if cfg.HeaderRow > 0 && cfg.HeaderRow <= len(rows) {
+		header = rows[cfg.HeaderRow-1]
+	}
+	for field, column := range cfg.Columns {
+		if n, err := strconv.Atoi(column); err == nil && n > 0 {
+			indexes[field] = n - 1
+			continue
+		}
+		for i, name := range header {
+			if normalizeImportKey(name) == normalizeImportKey(column) {
+				indexes[field] = i
+				break
+			}
+		}
+	}
+
+	_, hasItems = indexes[ImportFieldItemAmount]
+	for i := cfg.HeaderRow; i < len(rows); i++ {
+		row := rows[i]
+		values := map[string]string{}
+		empty := true
+		for field, idx := range indexes {
+			if idx < len(row) {
+				if v := strings.TrimSpace(row[idx]); v != "" {
+					values[field] = v
+					empty = false
+				}
+			}
+		}
+		if !empty {
+			records = append(records, importRecord{row: i + 1, values: values})
+		}
+	}
+	return records, hasItems
+}
+
+// normalizeImportKey compara cabeçalhos sem diferenciar maiúsculas, acentos e espaços
+func normalizeImportKey(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, _ := transform.String(t, strings.ToLower(strings.TrimSpace(s)))
+	return strings.Join(strings.Fields(folded), "_")
+}
+
+// groupTabularRecords junta as linhas de um mesmo holerite. Com colunas de rubrica,
+// cada linha é uma rubrica e as linhas com mesmo CPF/competência/tipo formam um
+// holerite; sem elas, cada linha é um holerite completo.
+func groupTabularRecords(rows [][]string, cfg PayslipImportConfig) []*ImportedPayslip {
+	records, hasItems := mapTabularRows(rows, cfg)
+
+	var result []*ImportedPayslip
+	groups := map[string]*ImportedPayslip{}
+	for _, record := range records {
+		if hasItems {
+			key := importGroupKey(record.values)
+			if imported, ok := groups[key]; ok {
+				imported.Rows = append(imported.Rows, record.row)
+				addImportedItem(imported, record, cfg)
+				continue
+			}
+			imported := newImportedPayslip(record, cfg)
+			addImportedItem(imported, record, cfg)
+			groups[key] = imported
+			result = append(result, imported)
+			continue
+		}
+		result = append(result, newImportedPayslip(record, cfg))
+	}
+	return result
+}
+
+func importGroupKey(values map[string]string) string {
+	return strings.Join([]string{
+		cleanDigits(values[ImportFieldCPF]), values[ImportFieldPeriod], values[ImportFieldMonth], values[ImportFieldYear], values[ImportFieldType],
+	}, "|")
+}
+
+// parseFixedWidth lê o TXT: registro de colaborador inicia um holerite e os de rubrica se somam a ele
+func parseFixedWidth(text string, cfg PayslipImportConfig) []*ImportedPayslip {
+	layout := cfg.Layout
+	var result []*ImportedPayslip
+	var current *ImportedPayslip
+	for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		row := i + 1
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		runes := []rune(line)
+		recordType := strings.TrimSpace(fixedSlice(runes, layout.RecordType))
+
+		switch recordType {
+		case layout.EmployeeRecord:
+			current = newImportedPayslip(importRecord{row: row, values: fixedValues(runes, layout.EmployeeFields)}, cfg)
+			result = append(result, current)
+		case layout.ItemRecord:
+			if current == nil {
+				result = append(result, &ImportedPayslip{Rows: []int{row}, Issues: []ImportIssue{{
+					Row: row, Severity: "error", Message: "Rubrica sem registro de colaborador antes dela",
+				}}})
+				continue
+			}
+			current.Rows = append(current.Rows, row)
+			addImportedItem(current, importRecord{row: row, values: fixedValues(runes, layout.ItemFields)}, cfg)
+		}
+		// Outros registros (cabeçalho/trailer do arquivo) são ignorados
+	}
+	return result
+}
+
+func fixedSlice(runes []rune, f FixedField) string {
+	start := f.Start - 1
+	if start < 0 || f.Length <= 0 || start >= len(runes) {
+		return ""
+	}
+	end := start + f.Length
+	if end > len(runes) {
+		end = len(runes)
+	}
+	return string(runes[start:end])
+}
+
+func fixedValues(runes []rune, fields map[string]FixedField) map[string]string {
+	values := map[string]string{}
+	for field, pos := range fields {
+		if v := strings.TrimSpace(fixedSlice(runes, pos)); v != "" {
+			values[field] = v
+		}
+	}
+	return values
+}
+
+// newImportedPayslip monta o holerite a partir dos campos de cabeçalho
+func newImportedPayslip(record importRecord, cfg PayslipImportConfig) *ImportedPayslip {
+	imported := &ImportedPayslip{Rows: []int{record.row}}
+	v := record.values
+	cpf := cleanDigits(v[ImportFieldCPF])
+	issue := func(message string) {
+		imported.Issues = append(imported.Issues, ImportIssue{Row: record.row, CPF: cpf, Severity: "error", Message: message})
+	}
+	number := func(field string) float64 {
+		n, err := parseImportNumber(v[field], cfg)
+		if err != nil {
+			issue(fmt.Sprintf("Valor inválido em %s: %q", field, v[field]))
+		}
+		return n
+	}
+	date := func(field string) *time.Time {
+		if v[field] == "" {
+			return nil
+		}
+		d, err := parseImportDate(v[field], cfg)
+		if err != nil {
+			issue(fmt.Sprintf("Data inválida em %s: %q", field, v[field]))
+			return nil
+		}
+		return &d
+	}
+
+	p := &imported.Payslip
+	p.EmployeeCPF = cpf
+	p.EmployeeName = v[ImportFieldName]
+	p.Position = v[ImportFieldPosition]
+	p.Department = v[ImportFieldDepartment]
+	p.Branch = v[ImportFieldBranch]
+	p.ColaboradorID, _ = strconv.Atoi(v[ImportFieldColaboradorID])
+	p.AdmissionDate = date(ImportFieldAdmission)
+	p.PaymentDate = date(ImportFieldPaymentDate)
+	p.BaseSalary = number(ImportFieldBaseSalary)
+	if v[ImportFieldWorkedDays] != "" {
+		days, err := strconv.Atoi(v[ImportFieldWorkedDays])
+		if err != nil {
+			issue(fmt.Sprintf("Dias trabalhados inválidos: %q", v[ImportFieldWorkedDays]))
+		}
+		p.WorkedDays = days
+	}
+	p.INSSBase = number(ImportFieldINSSBase)
+	p.IRRFBase = number(ImportFieldIRRFBase)
+	p.FGTSBase = number(ImportFieldFGTSBase)
+	p.FGTSAmount = number(ImportFieldFGTSAmount)
+	p.Status = models.PayslipStatusDraft
+
+	if v[ImportFieldPeriod] != "" {
+		month, year, ok := parseImportPeriod(v[ImportFieldPeriod])
+		if !ok {
+			issue(fmt.Sprintf("Competência inválida: %q", v[ImportFieldPeriod]))
+		}
+		p.ReferenceMonth, p.ReferenceYear = month, year
+	} else {
+		p.ReferenceMonth, _ = strconv.Atoi(v[ImportFieldMonth])
+		p.ReferenceYear, _ = strconv.Atoi(v[ImportFieldYear])
+	}
+
+	payslipType, ok := normalizeImportPayslipType(v[ImportFieldType])
+	if !ok {
+		issue(fmt.Sprintf("Tipo de holerite desconhecido: %q", v[ImportFieldType]))
+	}
+	p.PayslipType = payslipType
+
+	declared := func(field string) *float64 {
+		if v[field] == "" {
+			return nil
+		}
+		n := number(field)
+		return &n
+	}
+	imported.DeclaredGross = declared(ImportFieldGrossTotal)
+	imported.DeclaredDeduction = declared(ImportFieldDeductionTotal)
+	imported.DeclaredNet = declared(ImportFieldNetTotal)
+	return imported
+}
+
+// addImportedItem adiciona a rubrica da linha (se houver valor)
+func addImportedItem(imported *ImportedPayslip, record importRecord, cfg PayslipImportConfig) {
+	v := record.values
+	if v[ImportFieldItemAmount] == "" && v[ImportFieldItemCode] == "" {
+		return
+	}
+	issue := func(message string) {
+		imported.Issues = append(imported.Issues, ImportIssue{Row: record.row, CPF: imported.Payslip.EmployeeCPF, Severity: "error", Message: message})
+	}
+
+	amount, err := parseImportNumber(v[ImportFieldItemAmount], cfg)
+	if err != nil {
+		issue(fmt.Sprintf("Valor da rubrica inválido: %q", v[ImportFieldItemAmount]))
+		return
+	}
+	reference, err := parseImportNumber(v[ImportFieldItemReference], cfg)
+	if err != nil {
+		issue(fmt.Sprintf("Referência da rubrica inválida: %q", v[ImportFieldItemReference]))
+	}
+
+	code := v[ImportFieldItemCode]
+	itemType := normalizeRubricaType(v[ImportFieldItemType])
+	if itemType == "" && cfg.RubricaTypes != nil {
+		itemType = normalizeRubricaType(cfg.RubricaTypes[code])
+	}
+	if itemType == "" && amount < 0 {
+		itemType = "deduction"
+	}
+	if itemType == "" {
+		issue(fmt.Sprintf("Não foi possível identificar se a rubrica %s é provento ou desconto", code))
+		return
+	}
+
+	description := v[ImportFieldItemDescription]
+	if description == "" {
+		description = "Rubrica " + code
+	}
+	imported.Payslip.Items = append(imported.Payslip.Items, models.PayslipItem{
+		Type:        itemType,
+		Code:        code,
+		Description: description,
+		Reference:   reference,
+		Amount:      math.Abs(amount),
+	})
+}
+
+// normalizeRubricaType aceita as siglas usadas pelos sistemas de folha
+func normalizeRubricaType(value string) string {
+	switch normalizeImportKey(value) {
+	case "earning", "provento", "proventos", "vencimento", "vencimentos", "p", "v", "c", "credito", "+":
+		return "earning"
+	case "deduction", "desconto", "descontos", "d", "debito", "-":
+		return "deduction"
+	}
+	return ""
+}
+
+// normalizeImportPayslipType converte o tipo do arquivo para os tipos do sistema
+func normalizeImportPayslipType(value string) (string, bool) {
+	switch normalizeImportKey(value) {
+	case "", "mensal", "folha", "folha_mensal", "fm", "mes":
+		return "mensal", true
+	case "13_primeira", "13_1", "131", "13_parcela_1", "13o_1a_parcela", "13_1a_parcela", "primeira_parcela_13":
+		return "13_primeira", true
+	case "13_segunda", "13_2", "132", "13_parcela_2", "13o_2a_parcela", "13_2a_parcela", "segunda_parcela_13":
+		return "13_segunda", true
+	case "ferias", "fe":
+		return "ferias", true
+	case "rescisao", "re":
+		return "rescisao", true
+	case "adiantamento", "ad", "vale":
+		return "adiantamento", true
+	}
+	return value, false
+}
+
+var thousandsOnly = regexp.MustCompile(`^-?\d{1,3}(\.\d{3})+$`)
+
+// parseImportNumber lê valores como "1.234,56", "1234.56", "R$ 10" ou, com casas
+// decimais implícitas, "000000123456"
+func parseImportNumber(value string, cfg PayslipImportConfig) (float64, error) {
+	s := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), "R$"))
+	s = strings.ReplaceAll(s, " ", "")
+	if s == "" {
+		return 0, nil
+	}
+	negative := false
+	if strings.HasSuffix(s, "-") {
+		negative = true
+		s = strings.TrimSuffix(s, "-")
+	}
+
+	comma := strings.LastIndex(s, ",")
+	dot := strings.LastIndex(s, ".")
+	switch {
+	case comma >= 0 && dot >= 0:
+		// O último separador é o decimal
+		if comma > dot {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case comma >= 0:
+		if cfg.DecimalComma {
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case dot >= 0:
+		if cfg.DecimalComma && thousandsOnly.MatchString(s) {
+			s = strings.ReplaceAll(s, ".", "")
+		}
+	case cfg.ImpliedDecimals > 0:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		f := float64(n) / math.Pow10(cfg.ImpliedDecimals)
+		if negative {
+			f = -f
+		}
+		return f, nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		f = -f
+	}
+	return f, nil
+}
+
+// parseImportDate aceita o formato do template, ISO e o número serial do Excel
+func parseImportDate(value string, cfg PayslipImportConfig) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{cfg.DateFormat, "02/01/2006", "2006-01-02", "02012006"} {
+		if layout == "" {
+			continue
+		}
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 20000 && serial < 80000 {
+		base := time.Date(1899, 12, 30, 0, 0, 0, 0, time.Local)
+		return base.AddDate(0, 0, int(serial)), nil
+	}
+	return time.Time{}, fmt.Errorf("data inválida: %s", value)
+}
+
+// parseImportPeriod lê a competência em MM/AAAA, AAAA-MM ou MMAAAA
+func parseImportPeriod(value string) (month, year int, ok bool) {
+	value = strings.TrimSpace(value)
+	var a, b string
+	switch {
+	case strings.ContainsAny(value, "/-"):
+		parts := strings.FieldsFunc(value, func(r rune) bool { return r == '/' || r == '-' })
+		if len(parts) != 2 {
+			return 0, 0, false
+		}
+		a, b = parts[0], parts[1]
+	case len(value) == 6:
+		a, b = value[:2], value[2:]
+		if strings.HasPrefix(value, "19") || strings.HasPrefix(value, "20") {
+			a, b = value[:4], value[4:]
+		}
+	default:
+		return 0, 0, false
+	}
+	x, err1 := strconv.Atoi(a)
+	y, err2 := strconv.Atoi(b)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	if len(a) == 4 {
+		x, y = y, x
+	}
+	if x < 1 || x > 12 {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+func cleanDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// IsValidCPF confere os dígitos verificadores do CPF
+func IsValidCPF(cpf string) bool {
+	digits := cleanDigits(cpf)
+	if len(digits) != 11 || strings.Count(digits, digits[:1]) == 11 {
+		return false
+	}
+	for _, size := range []int{9, 10} {
+		sum := 0
+		for i := 0; i < size; i++ {
+			sum += int(digits[i]-'0') * (size + 1 - i)
+		}
+		check := sum * 10 % 11
+		if check == 10 {
+			check = 0
+		}
+		if check != int(digits[size]-'0') {
+			return false
+		}
+	}
+	return true
+}
+
+// ==================== Validação (dry-run) ====================
+
+// PayslipImportReport relatório da simulação
+type PayslipImportReport struct {
+	Payslips       int                    `json:"payslips"`
+	Items          int                    `json:"items"`
+	TotalGross     float64                `json:"total_gross"`
+	TotalNet       float64                `json:"total_net"`
+	Errors         []ImportIssue          `json:"errors"`
+	Warnings       []ImportIssue          `json:"warnings"`
+	Entries        []PayslipImportSummary `json:"entries"`
+	ReplaceCount   int                    `json:"replace_count"` // Holerites existentes que serão substituídos
+	UnmatchedUsers int                    `json:"unmatched_users"`
+}
+
+// PayslipImportSummary linha do relatório por holerite
+type PayslipImportSummary struct {
+	Rows           []int   `json:"rows"`
+	CPF            string  `json:"cpf"`
+	EmployeeName   string  `json:"employee_name"`
+	ReferenceMonth int     `json:"reference_month"`
+	ReferenceYear  int     `json:"reference_year"`
+	PayslipType    string  `json:"payslip_type"`
+	Items          int     `json:"items"`
+	NetTotal       float64 `json:"net_total"`
+	Valid          bool    `json:"valid"`
+}
+
+// payslipOwner colaborador encontrado para o CPF
+type payslipOwner struct {
+	EmployeeID    string
+	UserID        string
+	ColaboradorID int
+	Name          string
+}
+
+// PayslipImporter valida e grava lotes de holerites
+type PayslipImporter struct {
+	now          func() time.Time
+	lookupOwner  func(cpf string) (*payslipOwner, error)
+	existingSlip func(cpf string, month, year int, payslipType string) ([]string, error) // Status dos holerites já gravados
+}
+
+// PayslipImports instância usada pelos handlers de importação
+var PayslipImports = NewPayslipImporter()
+
+// NewPayslipImporter cria o importador com as consultas ao banco
+func NewPayslipImporter() *PayslipImporter {
+	return &PayslipImporter{now: time.Now, lookupOwner: lookupPayslipOwner, existingSlip: payslipExists}
+}
+
+// lookupPayslipOwner identidade do CPF (usuário e/ou colaborador do cadastro)
+func lookupPayslipOwner(cpf string) (*payslipOwner, error) {
+	identity, err := EmployeeIdentities.ForCPF(cpf)
+	if errors.Is(err, ErrIdentityNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &payslipOwner{
+		EmployeeID:    identity.ID,
+		UserID:        identity.UserID,
+		ColaboradorID: identity.ColaboradorID,
+		Name:          identity.Name,
+	}, nil
+}
+
+func payslipExists(cpf string, month, year int, payslipType string) ([]string, error) {
+	var statuses []string
+	err := config.DB.Model(&models.Payslip{}).Scopes(PayslipsByCPF(cpf)).
+		Where("reference_month = ? AND reference_year = ? AND payslip_type = ?", month, year, payslipType).
+		Pluck("status", &statuses).Error
+	return statuses, err
+}
+
+// replaceableSlips indica se todos os holerites existentes ainda podem ser substituídos
+func replaceableSlips(statuses []string) bool {
+	for _, status := range statuses {
+		if !IsPayslipReplaceable(status) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate confere cada holerite e monta o relatório. Também completa os dados
+// derivados (usuário/colaborador e totais calculados a partir das rubricas).
+func (s *PayslipImporter) Validate(payslips []*ImportedPayslip, replaceExisting bool) (*PayslipImportReport, error) {
+	report := &PayslipImportReport{Errors: []ImportIssue{}, Warnings: []ImportIssue{}, Entries: []PayslipImportSummary{}}
+	seen := map[string][]int{}
+	owners := map[string]*payslipOwner{}
+
+	for _, imported := range payslips {
+		p := &imported.Payslip
+		firstRow := 0
+		if len(imported.Rows) > 0 {
+			firstRow = imported.Rows[0]
+		}
+		issues := append([]ImportIssue{}, imported.Issues...)
+		fail := func(message string) {
+			issues = append(issues, ImportIssue{Row: firstRow, CPF: p.EmployeeCPF, Severity: "error", Message: message})
+		}
+		warn := func(message string) {
+			issues = append(issues, ImportIssue{Row: firstRow, CPF: p.EmployeeCPF, Severity: "warning", Message: message})
+		}
+
+		// CPF e vínculo com o colaborador
+		if !IsValidCPF(p.EmployeeCPF) {
+			fail(fmt.Sprintf("CPF inválido: %q", p.EmployeeCPF))
+		} else {
+			owner, cached := owners[p.EmployeeCPF]
+			if !cached {
+				var err error
+				if owner, err = s.lookupOwner(p.EmployeeCPF); err != nil {
+					return nil, err
+				}
+				owners[p.EmployeeCPF] = owner
+			}
+			if owner == nil {
+				fail("CPF não encontrado no cadastro de colaboradores")
+			} else {
+				p.UserID = owner.UserID
+				p.EmployeeID, p.LinkStatus = owner.EmployeeID, models.PayslipLinkLinked
+				if p.ColaboradorID == 0 {
+					p.ColaboradorID = owner.ColaboradorID
+				}
+				if p.EmployeeName == "" {
+					p.EmployeeName = owner.Name
+				}
+				if owner.UserID == "" {
+					report.UnmatchedUsers++
+					warn("Colaborador ainda sem conta no sistema: o holerite ficará disponível após a ativação")
+				}
+			}
+		}
+
+		if p.ReferenceMonth < 1 || p.ReferenceMonth > 12 {
+			fail(fmt.Sprintf("Mês de referência inválido: %d", p.ReferenceMonth))
+		}
+		if p.ReferenceYear < 2000 || p.ReferenceYear > s.now().Year()+1 {
+			fail(fmt.Sprintf("Ano de referência inválido: %d", p.ReferenceYear))
+		}
+
+		checkImportTotals(imported, fail, warn)
+
+		// Duplicidades no arquivo e no banco
+		key := fmt.Sprintf("%s|%d|%d|%s", p.EmployeeCPF, p.ReferenceMonth, p.ReferenceYear, p.PayslipType)
+		if rows, dup := seen[key]; dup {
+			fail(fmt.Sprintf("Holerite duplicado no arquivo (mesmo CPF, competência e tipo da linha %d)", rows[0]))
+		} else {
+			seen[key] = imported.Rows
+			if IsValidCPF(p.EmployeeCPF) && p.ReferenceMonth >= 1 && p.ReferenceMonth <= 12 {
+				statuses, err := s.existingSlip(p.EmployeeCPF, p.ReferenceMonth, p.ReferenceYear, p.PayslipType)
+				if err != nil {
+					return nil, err
+				}
+				exists := len(statuses) > 0
+				if exists && !replaceableSlips(statuses) {
+					// Publicado ou com ciência do colaborador: correção só por retificação
+					fail("Já existe holerite publicado deste colaborador para a mesma competência e tipo; não pode ser substituído")
+				} else if exists && replaceExisting {
+					report.ReplaceCount++
+					warn("Já existe holerite desta competência e tipo: será substituído")
+				} else if exists {
+					fail("Já existe holerite deste colaborador para a mesma competência e tipo")
+				}
+			}
+		}
+
+		valid := true
+		for _, issue := range issues {
+			if issue.Severity == "error" {
+				valid = false
+				report.Errors = append(report.Errors, issue)
+			} else {
+				report.Warnings = append(report.Warnings, issue)
+			}
+		}
+
+		report.Payslips++
+		report.Items += len(p.Items)
+		report.TotalGross += p.GrossTotal
+		report.TotalNet += p.NetTotal
+		report.Entries = append(report.Entries, PayslipImportSummary{
+			Rows:           imported.Rows,
+			CPF:            maskCPF(p.EmployeeCPF),
+			EmployeeName:   p.EmployeeName,
+			ReferenceMonth: p.ReferenceMonth,
+			ReferenceYear:  p.ReferenceYear,
+			PayslipType:    p.PayslipType,
+			Items:          len(p.Items),
+			NetTotal:       p.NetTotal,
+			Valid:          valid,
+		})
+	}
+
+	report.TotalGross = roundCents(report.TotalGross)
+	report.TotalNet = roundCents(report.TotalNet)
+	if report.Payslips == 0 {
+		report.Errors = append(report.Errors, ImportIssue{Severity: "error", Message: "Nenhum holerite encontrado no arquivo. Confira o template e o cabeçalho."})
+	}
+	return report, nil
+}
+
+// checkImportTotals calcula os totais pelas rubricas e confere com os declarados
+func checkImportTotals(imported *ImportedPayslip, fail, warn func(string)) {
+	p := &imported.Payslip
+	if len(p.Items) == 0 {
+		if imported.DeclaredGross == nil && imported.DeclaredNet == nil {
+			fail("Holerite sem rubricas e sem totais")
+			return
+		}
+		warn("Holerite sem rubricas: apenas os totais serão importados")
+		if imported.DeclaredGross != nil {
+			p.GrossTotal = *imported.DeclaredGross
+		}
+		if imported.DeclaredDeduction != nil {
+			p.DeductionTotal = *imported.DeclaredDeduction
+		}
+		p.NetTotal = roundCents(p.GrossTotal - p.DeductionTotal)
+		if imported.DeclaredNet != nil {
+			if imported.DeclaredGross == nil {
+				p.NetTotal = *imported.DeclaredNet
+			} else if !centsEqual(*imported.DeclaredNet, p.NetTotal) {
+				fail(fmt.Sprintf("Líquido informado (%s) difere de proventos - descontos (%s)", FormatBRL(*imported.DeclaredNet), FormatBRL(p.NetTotal)))
+			}
+		}
+		return
+	}
+
+	var gross, deduction float64
+	codes := map[string]bool{}
+	for _, item := range p.Items {
+		if item.Type == "earning" {
+			gross += item.Amount
+		} else {
+			deduction += item.Amount
+		}
+		key := item.Type + "|" + item.Code
+		if item.Code != "" && codes[key] {
+			warn(fmt.Sprintf("Rubrica %s aparece mais de uma vez", item.Code))
+		}
+		codes[key] = true
+	}
+	p.GrossTotal = roundCents(gross)
+	p.DeductionTotal = roundCents(deduction)
+	p.NetTotal = roundCents(gross - deduction)
+
+	if d := imported.DeclaredGross; d != nil && !centsEqual(*d, p.GrossTotal) {
+		fail(fmt.Sprintf("Total de proventos informado (%s) difere da soma das rubricas (%s)", FormatBRL(*d), FormatBRL(p.GrossTotal)))
+	}
+	if d := imported.DeclaredDeduction; d != nil && !centsEqual(*d, p.DeductionTotal) {
+		fail(fmt.Sprintf("Total de descontos informado (%s) difere da soma das rubricas (%s)", FormatBRL(*d), FormatBRL(p.DeductionTotal)))
+	}
+	if d := imported.DeclaredNet; d != nil && !centsEqual(*d, p.NetTotal) {
+		fail(fmt.Sprintf("Líquido informado (%s) difere do calculado pelas rubricas (%s)", FormatBRL(*d), FormatBRL(p.NetTotal)))
+	}
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func centsEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
+
+// ==================== Lotes ====================
+
+// Preview interpreta e valida o arquivo e guarda o lote para confirmação posterior
+func (s *PayslipImporter) Preview(data []byte, fileName string, cfg PayslipImportConfig, templateID string, replaceExisting bool, userID string) (*models.PayslipImportBatch, *PayslipImportReport, error) {
+	payslips, err := ParsePayslipFile(data, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	report, err := s.Validate(payslips, replaceExisting)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payload, err := json.Marshal(payslips)
+	if err != nil {
+		return nil, nil, err
+	}
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	batch := &models.PayslipImportBatch{
+		FileName:        truncateString(fileName, 255),
+		Format:          cfg.Format,
+		TemplateID:      templateID,
+		ReplaceExisting: replaceExisting,
+		Status:          models.PayslipImportValidated,
+		PayslipCount:    report.Payslips,
+		ErrorCount:      len(report.Errors),
+		WarningCount:    len(report.Warnings),
+		Report:          string(reportJSON),
+		Payload:         string(payload),
+		CreatedBy:       userID,
+	}
+	if err := config.DB.Create(batch).Error; err != nil {
+		return nil, nil, err
+	}
+	return batch, report, nil
+}
+
+// Batch carrega um lote com o relatório da simulação
+func (s *PayslipImporter) Batch(id string) (*models.PayslipImportBatch, *PayslipImportReport, error) {
+	var batch models.PayslipImportBatch
+	if err := config.DB.First(&batch, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrImportBatchNotFound
+		}
+		return nil, nil, err
+	}
+	var report PayslipImportReport
+	if batch.Report != "" {
+		if err := json.Unmarshal([]byte(batch.Report), &report); err != nil {
+			return nil, nil, err
+		}
+	}
+	return &batch, &report, nil
+}
+
+// Commit revalida o lote (o banco pode ter mudado desde a simulação) e grava
+// todos os holerites em uma única transação: qualquer falha desfaz o lote inteiro.
+func (s *PayslipImporter) Commit(id, userID string) (*models.PayslipImportBatch, *PayslipImportReport, error) {
+	batch, _, err := s.Batch(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if batch.Status != models.PayslipImportValidated {
+		return batch, nil, ErrImportBatchClosed
+	}
+
+	var payslips []*ImportedPayslip
+	if err := json.Unmarshal([]byte(batch.Payload), &payslips); err != nil {
+		return nil, nil, err
+	}
+	report, err := s.Validate(payslips, batch.ReplaceExisting)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(report.Errors) > 0 {
+		return batch, report, ErrImportHasErrors
+	}
+
+	now := s.now()
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, imported := range payslips {
+			p := imported.Payslip
+			if batch.ReplaceExisting {
+				if err := deleteExistingPayslips(tx, &p); err != nil {
+					return err
+				}
+			}
+			// Holerite e rubricas juntos: erro em qualquer rubrica desfaz o lote
+			if err := tx.Create(&p).Error; err != nil {
+				return fmt.Errorf("holerite do CPF %s (linha %d): %w", maskCPF(p.EmployeeCPF), imported.Rows[0], err)
+			}
+		}
+		res := tx.Model(&models.PayslipImportBatch{}).
+			Where("id = ? AND status = ?", batch.ID, models.PayslipImportValidated).
+			Updates(map[string]interface{}{
+				"status":       models.PayslipImportCommitted,
+				"committed_by": userID,
+				"committed_at": now,
+				"payload":      "", // Dados pessoais do arquivo não ficam retidos após a gravação
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			// Outra confirmação simultânea venceu
+			return ErrImportBatchClosed
+		}
+		return nil
+	})
+	if err != nil {
+		return batch, report, err
+	}
+
+	batch.Status = models.PayslipImportCommitted
+	batch.Payload = ""
+	batch.CommittedBy = userID
+	batch.CommittedAt = &now
+	return batch, report, nil
+}
+
+// deleteExistingPayslips remove o holerite da mesma competência/tipo que será substituído.
+// Só holerites ainda não publicados podem ser substituídos; um publicado (ou já com
+// ciência) desfaz o lote com ErrImportReplacesPublished.
+func deleteExistingPayslips(tx *gorm.DB, p *models.Payslip) error {
+	var existing []models.Payslip
+	if err := tx.Select("id", "status").Scopes(PayslipsByCPF(p.EmployeeCPF)).
+		Where("reference_month = ? AND reference_year = ? AND payslip_type = ?", p.ReferenceMonth, p.ReferenceYear, p.PayslipType).
+		Find(&existing).Error; err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(existing))
+	for _, e := range existing {
+		if !IsPayslipReplaceable(e.Status) {
+			return fmt.Errorf("%w (CPF %s, %02d/%d)", ErrImportReplacesPublished, maskCPF(p.EmployeeCPF), p.ReferenceMonth, p.ReferenceYear)
+		}
+		ids = append(ids, e.ID)
+	}
+	if err := tx.Where("payslip_id IN ?", ids).Delete(&models.PayslipItem{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id IN ?", ids).Delete(&models.Payslip{}).Error
+}
+
+// Discard descarta um lote não confirmado
+func (s *PayslipImporter) Discard(id string) error {
+	res := config.DB.Model(&models.PayslipImportBatch{}).
+		Where("id = ? AND status = ?", id, models.PayslipImportValidated).
+		Updates(map[string]interface{}{"status": models.PayslipImportDiscarded, "payload": ""})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrImportBatchNotFound
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	cpfAna   = "52998224725"
+	cpfBruno = "11144477735"
+)
+
+// testImporter importador com cadastro e holerites existentes em memória
+func testImporter(existing ...string) *PayslipImporter {
+	return &PayslipImporter{
+		now: func() time.Time { return time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC) },
+		lookupOwner: func(cpf string) (*payslipOwner, error) {
+			switch cpf {
+			case cpfAna:
+				return &payslipOwner{EmployeeID: "e-ana", UserID: "u-ana", ColaboradorID: 10, Name: "Ana"}, nil
+			case cpfBruno:
+				return &payslipOwner{EmployeeID: "e-bruno", ColaboradorID: 11, Name: "Bruno"}, nil
+			}
+			return nil, nil
+		},
+		existingSlip: func(cpf string, month, year int, payslipType string) ([]string, error) {
+			// "cpf|mês|ano|tipo" (rascunho) ou "cpf|mês|ano|tipo@status"
+			key := fmt.Sprintf("%s|%d|%d|%s", cpf, month, year, payslipType)
+			var statuses []string
+			for _, e := range existing {
+				slip, status, found := strings.Cut(e, "@")
+				if !found {
+					status = models.PayslipStatusDraft
+				}
+				if slip == key {
+					statuses = append(statuses, status)
+				}
+			}
+			return statuses, nil
+		},
+	}
+}
+
+func messages(issues []ImportIssue) string {
+	var all []string
+	for _, issue := range issues {
+		all = append(all, issue.Message)
+	}
+	return strings.Join(all, "\n")
+}
+
+func TestIsValidCPF(t *testing.T) {
+	assert.True(t, IsValidCPF("529.982.247-25"))
+	assert.True(t, IsValidCPF(cpfBruno))
+	assert.False(t, IsValidCPF("529.982.247-24"))
+	assert.False(t, IsValidCPF("11111111111"))
+	assert.False(t, IsValidCPF("123"))
+}
+
+func TestParseImportNumber(t *testing.T) {
+	brl := PayslipImportConfig{DecimalComma: true}
+	for input, expected := range map[string]float64{
+		"1.234,56":  1234.56,
+		"R$ 10,5":   10.5,
+		"1234.56":   1234.56, // célula numérica do XLSX
+		"1.234":     1234,
+		"1,234.56":  1234.56,
+		"150,00-":   -150,
+		"":          0,
+		" 3.000,00": 3000,
+	} {
+		got, err := parseImportNumber(input, brl)
+		require.NoError(t, err, input)
+		assert.InDelta(t, expected, got, 0.0001, input)
+	}
+
+	implied := PayslipImportConfig{ImpliedDecimals: 2}
+	got, err := parseImportNumber("000000123456", implied)
+	require.NoError(t, err)
+	assert.InDelta(t, 1234.56, got, 0.0001)
+
+	_, err = parseImportNumber("abc", brl)
+	assert.Error(t, err)
+}
+
+func TestParseImportPeriod(t *testing.T) {
+	for input, expected := range map[string][2]int{
+		"03/2026": {3, 2026},
+		"2026-03": {3, 2026},
+		"032026":  {3, 2026},
+		"202603":  {3, 2026},
+	} {
+		month, year, ok := parseImportPeriod(input)
+		assert.True(t, ok, input)
+		assert.Equal(t, expected, [2]int{month, year}, input)
+	}
+	_, _, ok := parseImportPeriod("13/2026")
+	assert.False(t, ok)
+}
+
+func TestImportCSVGroupsItemsAndValidates(t *testing.T) {
+	csv := "CPF;Nome;Competência;Tipo;Rubrica Código;Rubrica Descrição;Rubrica Tipo;Rubrica Valor;Líquido\n" +
+		"529.982.247-25;Ana;03/2026;mensal;001;Salário;P;5.000,00;4.000,00\n" +
+		"529.982.247-25;Ana;03/2026;mensal;201;INSS;D;1.000,00;4.000,00\n" +
+		"111.444.777-35;Bruno;03/2026;mensal;001;Salário;P;3.000,00;2.900,00\n" +
+		"111.444.777-35;Bruno;03/2026;mensal;201;INSS;D;300,00;2.900,00\n" +
+		"000.000.000-00;Fulano;03/2026;mensal;001;Salário;P;1.000,00;\n"
+
+	cfg := DefaultImportConfig(models.PayslipImportCSV)
+	cfg.Columns = map[string]string{
+		ImportFieldCPF: "CPF", ImportFieldName: "Nome", ImportFieldPeriod: "Competencia", ImportFieldType: "Tipo",
+		ImportFieldItemCode: "Rubrica Codigo", ImportFieldItemDescription: "Rubrica Descrição",
+		ImportFieldItemType: "rubrica tipo", ImportFieldItemAmount: "Rubrica Valor", ImportFieldNetTotal: "Líquido",
+	}
+
+	payslips, err := ParsePayslipFile([]byte(csv), cfg)
+	require.NoError(t, err)
+	require.Len(t, payslips, 3)
+	assert.Equal(t, []int{2, 3}, payslips[0].Rows)
+	assert.Len(t, payslips[0].Payslip.Items, 2)
+
+	report, err := testImporter().Validate(payslips, false)
+	require.NoError(t, err)
+
+	ana := payslips[0].Payslip
+	assert.Equal(t, "u-ana", ana.UserID)
+	assert.Equal(t, "e-ana", ana.EmployeeID)
+	assert.Equal(t, models.PayslipLinkLinked, ana.LinkStatus)
+	assert.Equal(t, 10, ana.ColaboradorID)
+	assert.Equal(t, 5000.0, ana.GrossTotal)
+	assert.Equal(t, 4000.0, ana.NetTotal)
+
+	// Bruno: líquido declarado (2.900) não bate com as rubricas (2.700) e ainda não tem conta
+	// Fulano: CPF inválido
+	assert.Len(t, report.Errors, 2)
+	assert.Contains(t, messages(report.Errors), "Líquido informado (2.900,00) difere")
+	assert.Contains(t, messages(report.Errors), "CPF inválido")
+	assert.Contains(t, messages(report.Warnings), "sem conta")
+	assert.Equal(t, 1, report.UnmatchedUsers)
+	assert.True(t, report.Entries[0].Valid)
+	assert.False(t, report.Entries[1].Valid)
+}
+
+func TestImportDuplicatesAndExisting(t *testing.T) {
+	csv := "cpf,mes,ano,total_proventos,total_descontos,liquido\n" +
+		"52998224725,3,2026,\"5000,00\",\"1000,00\",\"4000,00\"\n" +
+		"52998224725,3,2026,\"5000,00\",\"1000,00\",\"4000,00\"\n" +
+		"11144477735,3,2026,\"3000,00\",\"300,00\",\"2700,00\"\n" +
+		"99999999999,3,2026,\"1,00\",,\n"
+
+	payslips, err := ParsePayslipFile([]byte(csv), DefaultImportConfig(models.PayslipImportCSV))
+	require.NoError(t, err)
+	require.Len(t, payslips, 4)
+
+	importer := testImporter(cpfBruno + "|3|2026|mensal")
+	report, err := importer.Validate(payslips, false)
+	require.NoError(t, err)
+	errs := messages(report.Errors)
+	assert.Contains(t, errs, "duplicado no arquivo (mesmo CPF, competência e tipo da linha 2)")
+	assert.Contains(t, errs, "Já existe holerite")
+	assert.Contains(t, errs, "CPF inválido")
+
+	// Com substituição, o holerite existente vira aviso
+	report, err = importer.Validate(payslips[2:3], true)
+	require.NoError(t, err)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 1, report.ReplaceCount)
+	assert.Equal(t, 2700.0, payslips[2].Payslip.NetTotal)
+}
+
+func TestImportDoesNotReplacePublishedPayslip(t *testing.T) {
+	payslips := []*ImportedPayslip{{Rows: []int{2}, Payslip: models.Payslip{
+		EmployeeCPF: cpfBruno, ReferenceMonth: 3, ReferenceYear: 2026, PayslipType: "mensal",
+		Items: []models.PayslipItem{{Type: "earning", Code: "001", Amount: 100}},
+	}}}
+
+	for _, status := range []string{models.PayslipStatusPublished, models.PayslipStatusAcknowledged, models.PayslipStatusLegacy, models.PayslipStatusBlocked} {
+		report, err := testImporter(cpfBruno+"|3|2026|mensal@"+status).Validate(payslips, true)
+		require.NoError(t, err)
+		assert.Contains(t, messages(report.Errors), "não pode ser substituído", status)
+		assert.Zero(t, report.ReplaceCount, status)
+	}
+
+	report, err := testImporter(cpfBruno+"|3|2026|mensal@"+models.PayslipStatusScheduled).Validate(payslips, true)
+	require.NoError(t, err)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 1, report.ReplaceCount)
+}
+
+func TestImportUnknownCPF(t *testing.T) {
+	// CPF válido, mas fora do cadastro
+	payslips := []*ImportedPayslip{{Rows: []int{2}, Payslip: models.Payslip{
+		EmployeeCPF: "39053344705", ReferenceMonth: 3, ReferenceYear: 2026, PayslipType: "mensal",
+		Items: []models.PayslipItem{{Type: "earning", Code: "001", Amount: 100}},
+	}}}
+	report, err := testImporter().Validate(payslips, false)
+	require.NoError(t, err)
+	assert.Contains(t, messages(report.Errors), "CPF não encontrado")
+}
+
+func fixedLine(fields ...string) string {
+	return strings.Join(fields, "")
+}
+
+func pad(s string, n int) string {
+	return s + strings.Repeat(" ", n-len([]rune(s)))
+}
+
+func TestImportFixedWidth(t *testing.T) {
+	employee := fixedLine("1", cpfAna, pad("ANA SOUZA", 50), "032026", pad("MENSAL", 12), "05042026",
+		pad("ANALISTA", 40), pad("FISCAL", 40), pad("MATRIZ", 30), "000000500000", "30",
+		"000000500000", "000000400000", "000000500000", "000000040000")
+	items := []string{
+		fixedLine("2", "00001", pad("SALARIO BASE", 40), "P", "00003000", "000000500000"),
+		fixedLine("2", "00201", pad("INSS", 40), "D", "00001400", "000000070000"),
+		fixedLine("2", "00301", pad("IRRF", 40), "D", "00000000", "000000030000"),
+	}
+	content := strings.Join(append([]string{"0CABECALHO", employee}, items...), "\r\n") + "\r\n9TRAILER\r\n"
+
+	payslips, err := ParsePayslipFile([]byte(content), DefaultImportConfig(models.PayslipImportFixed))
+	require.NoError(t, err)
+	require.Len(t, payslips, 1)
+
+	p := payslips[0].Payslip
+	assert.Empty(t, payslips[0].Issues)
+	assert.Equal(t, "ANA SOUZA", p.EmployeeName)
+	assert.Equal(t, 3, p.ReferenceMonth)
+	assert.Equal(t, 2026, p.ReferenceYear)
+	assert.Equal(t, 30, p.WorkedDays)
+	assert.Equal(t, 5000.0, p.BaseSalary)
+	assert.Equal(t, 400.0, p.FGTSAmount)
+	require.NotNil(t, p.PaymentDate)
+	assert.Equal(t, "2026-04-05", p.PaymentDate.Format("2006-01-02"))
+	require.Len(t, p.Items, 3)
+	assert.Equal(t, "deduction", p.Items[1].Type)
+	assert.Equal(t, 14.0, p.Items[1].Reference)
+
+	report, err := testImporter().Validate(payslips, false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 4000.0, payslips[0].Payslip.NetTotal)
+}
+
+func TestImportFixedWidthWindows1252(t *testing.T) {
+	employee := fixedLine("1", cpfAna, pad("JOAO", 50), "032026")
+	item := fixedLine("2", "00001", pad("SAL\xc1RIO", 40), "P", "00000000", "000000100000")
+	payslips, err := ParsePayslipFile([]byte(employee+"\n"+item), DefaultImportConfig(models.PayslipImportFixed))
+	require.NoError(t, err)
+	require.Len(t, payslips[0].Payslip.Items, 1)
+	assert.Equal(t, "SALÁRIO", payslips[0].Payslip.Items[0].Description)
+}
+
+// buildXLSX monta um XLSX mínimo com strings compartilhadas e células numéricas
+func buildXLSX(t *testing.T) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	files := map[string]string{
+		"xl/workbook.xml":            `<?xml version="1.0"?><workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Folha" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="worksheet" Target="worksheets/folha.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       `<?xml version="1.0"?><sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>cpf</t></si><si><t>mes</t></si><si><t>ano</t></si><si><t>rubrica_codigo</t></si><si><r><t>rubrica_</t></r><r><t>valor</t></r></si><si><t>rubrica_tipo</t></si><si><t>D</t></si></sst>`,
+		"xl/worksheets/folha.xml": `<?xml version="1.0"?><worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="s"><v>3</v></c><c r="E1" t="s"><v>4</v></c><c r="F1" t="s"><v>5</v></c></row>` +
+			`<row r="2"><c r="A2" t="inlineStr"><is><t>529.982.247-25</t></is></c><c r="B2"><v>3</v></c><c r="C2"><v>2026</v></c><c r="D2" t="str"><v>001</v></c><c r="E2"><v>2500.5</v></c><c r="F2" t="inlineStr"><is><t>P</t></is></c></row>` +
+			`<row r="4"><c r="A4" t="inlineStr"><is><t>529.982.247-25</t></is></c><c r="B4"><v>3</v></c><c r="C4"><v>2026</v></c><c r="D4" t="str"><v>201</v></c><c r="E4"><v>200</v></c><c r="F4" t="s"><v>6</v></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+	for name, content := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestImportXLSX(t *testing.T) {
+	rows, err := ReadXLSX(buildXLSX(t))
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	assert.Equal(t, "rubrica_valor", rows[0][4])
+	assert.Nil(t, rows[2]) // linha 3 vazia preservada
+
+	payslips, err := ParsePayslipFile(buildXLSX(t), DefaultImportConfig(models.PayslipImportXLSX))
+	require.NoError(t, err)
+	require.Len(t, payslips, 1)
+	assert.Equal(t, []int{2, 4}, payslips[0].Rows)
+
+	report, err := testImporter().Validate(payslips, false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Errors)
+	assert.InDelta(t, 2300.5, payslips[0].Payslip.NetTotal, 0.001)
+
+	_, err = ReadXLSX([]byte("não é zip"))
+	assert.ErrorIs(t, err, ErrInvalidXLSX)
+}
+
+func TestImportConfigFromTemplate(t *testing.T) {
+	_, err := ImportConfigFromTemplate(&models.PayslipImportTemplate{Name: "x", Format: models.PayslipImportCSV, Columns: `{"nome":"Nome"}`})
+	assert.ErrorIs(t, err, ErrImportTemplate)
+
+	_, err = ImportConfigFromTemplate(&models.PayslipImportTemplate{Name: "x", Format: models.PayslipImportCSV, Columns: `{"cpf":"CPF","salario":"X"}`})
+	assert.ErrorIs(t, err, ErrImportTemplate)
+
+	cfg, err := ImportConfigFromTemplate(&models.PayslipImportTemplate{
+		Name: "Folha", Format: models.PayslipImportCSV, Delimiter: `\t`, HeaderRow: 0, DecimalComma: true,
+		Columns: `{"cpf":"1","competencia":"2","rubrica_codigo":"3","rubrica_valor":"4"}`, RubricaTypes: `{"001":"provento","201":"desconto"}`,
+	})
+	require.NoError(t, err)
+	payslips, err := ParsePayslipFile([]byte("52998224725\t03/2026\t001\t1000,00\n52998224725\t03/2026\t201\t80,00\n"), cfg)
+	require.NoError(t, err)
+	require.Len(t, payslips, 1)
+	require.Len(t, payslips[0].Payslip.Items, 2)
+	assert.Equal(t, "deduction", payslips[0].Payslip.Items[1].Type)
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== Emissão e verificação de holerites em PDF ====================
+
+var ErrIssuanceNotFound = errors.New("documento não encontrado para o código ou arquivo informado")
+
+// PayslipIssuer emite os PDFs assinados e confirma a autenticidade de arquivos apresentados
+type PayslipIssuer struct {
+	now func() time.Time
+	key func() []byte
+}
+
+// PayslipDocs instância usada pelos handlers de holerite
+var PayslipDocs = NewPayslipIssuer()
+
+// NewPayslipIssuer cria o emissor. A chave de assinatura vem de PAYSLIP_SIGNING_KEY ou,
+// sem ela, é derivada do segredo JWT (HKDF): o segredo JWT nunca assina holerites.
+func NewPayslipIssuer() *PayslipIssuer {
+	return &PayslipIssuer{now: time.Now, key: payslipSigningKey}
+}
+
+// payslipSigningLabel rótulo fixo da derivação; alterá-lo invalida as assinaturas emitidas
+const payslipSigningLabel = "frappyou/payslip-issuance/hmac-sha256/v1"
+
+func payslipSigningKey() []byte {
+	if key := os.Getenv("PAYSLIP_SIGNING_KEY"); key != "" {
+		return []byte(key)
+	}
+	return config.DeriveKey(payslipSigningLabel)
+}
+
+// PayslipVerification resultado da verificação pública (sem valores do holerite)
+type PayslipVerification struct {
+	Valid            bool      `json:"valid"`
+	DataIntact       bool      `json:"data_intact"` // holerite não foi alterado após a emissão
+	VerificationCode string    `json:"verification_code"`
+	IssuedAt         time.Time `json:"issued_at"`
+	EmployeeName     string    `json:"employee_name"`
+	EmployeeCPF      string    `json:"employee_cpf"` // mascarado
+	ReferenceMonth   int       `json:"reference_month"`
+	ReferenceYear    int       `json:"reference_year"`
+	PayslipType      string    `json:"payslip_type"`
+	DocumentHash     string    `json:"document_hash"`
+}
+
+// PayslipVerifyURL link público de verificação do código
+func PayslipVerifyURL(code string) string {
+	base := strings.TrimRight(envOrDefault("FRONTEND_URL", "http://localhost:3000"), "/")
+	return base + "/verificar-holerite?codigo=" + url.QueryEscape(code)
+}
+
+// newVerificationCode gera um código no formato XXXX-XXXX-XXXX-XXXX
+func newVerificationCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	raw := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)
+	parts := make([]string, 0, 4)
+	for i := 0; i < len(raw); i += 4 {
+		parts = append(parts, raw[i:i+4])
+	}
+	return strings.Join(parts, "-"), nil
+}
+
+// NormalizeVerificationCode aceita o código com ou sem hífens e em minúsculas
+func NormalizeVerificationCode(code string) string {
+	raw := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	if len(raw) != 16 {
+		return raw
+	}
+	return raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16]
+}
+
+// HashDocument SHA-256 (hex) do arquivo
+func HashDocument(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// payslipDataHash resume os dados que aparecem no holerite, para detectar
+// alterações feitas depois da emissão
+func payslipDataHash(p *models.Payslip) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s|%s|%s|%d|%d|%s|", p.ID, p.EmployeeName, p.EmployeeCPF, p.ReferenceMonth, p.ReferenceYear, p.PayslipType)
+	fmt.Fprintf(&b, "%.2f|%.2f|%.2f|%.2f|%.2f|%.2f|%.2f|%.2f", p.GrossTotal, p.DeductionTotal, p.NetTotal, p.BaseSalary, p.INSSBase, p.IRRFBase, p.FGTSBase, p.FGTSAmount)
+	// Os itens não têm ordem garantida no banco: ordena as linhas antes do hash
+	lines := make([]string, len(p.Items))
+	for i, item := range p.Items {
+		lines[i] = fmt.Sprintf("%s;%s;%s;%.2f;%.2f", item.Type, item.Code, item.Description, item.Reference, item.Amount)
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		b.WriteString("|" + line)
+	}
+	return HashDocument([]byte(b.String()))
+}
+
+// sign assinatura HMAC-SHA256 da emissão
+func (s *PayslipIssuer) sign(issuance *models.PayslipIssuance) string {
+	mac := hmac.New(sha256.New, s.key())
+	fmt.Fprintf(mac, "%s|%s|%s|%s|%d", issuance.VerificationCode, issuance.PayslipID, issuance.DocumentHash, issuance.DataHash, issuance.IssuedAt.Unix())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Issue gera o PDF do holerite e registra a emissão (código, hash e assinatura).
+// O holerite deve estar carregado com os itens.
+func (s *PayslipIssuer) Issue(payslip *models.Payslip, userID, requestIP string) ([]byte, *models.PayslipIssuance, error) {
+	code, err := newVerificationCode()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Segundos inteiros: o instante impresso é o mesmo usado na assinatura
+	issuedAt := s.now().Truncate(time.Second)
+	pdf := RenderPayslipPDF(payslip, PayslipPDFInfo{
+		CompanyName:      envOrDefault("PAYSLIP_COMPANY_NAME", "Fradema"),
+		VerificationCode: code,
+		VerifyURL:        PayslipVerifyURL(code),
+		IssuedAt:         issuedAt,
+	})
+
+	issuance := &models.PayslipIssuance{
+		PayslipID:        payslip.ID,
+		IssuedTo:         userID,
+		VerificationCode: code,
+		DocumentHash:     HashDocument(pdf),
+		DataHash:         payslipDataHash(payslip),
+		IssuedAt:         issuedAt,
+		RequestIP:        truncateString(requestIP, 64),
+	}
+	issuance.Signature = s.sign(issuance)
+
+	if err := config.DB.Create(issuance).Error; err != nil {
+		return nil, nil, err
+	}
+	return pdf, issuance, nil
+}
+
+// VerifyCode confirma um código de verificação impresso no holerite
+func (s *PayslipIssuer) VerifyCode(code string) (*PayslipVerification, error) {
+	var issuance models.PayslipIssuance
+	if err := config.DB.Where("verification_code = ?", NormalizeVerificationCode(code)).First(&issuance).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIssuanceNotFound
+		}
+		return nil, err
+	}
+	return s.verify(&issuance)
+}
+
+// VerifyDocument confirma que o arquivo apresentado é idêntico a um PDF emitido
+func (s *PayslipIssuer) VerifyDocument(content []byte) (*PayslipVerification, error) {
+	return s.VerifyHash(HashDocument(content))
+}
+
+// VerifyHash confirma um hash SHA-256 (hex) de PDF emitido
+func (s *PayslipIssuer) VerifyHash(hash string) (*PayslipVerification, error) {
+	var issuance models.PayslipIssuance
+	if err := config.DB.Where("document_hash = ?", strings.ToLower(strings.TrimSpace(hash))).
+		Order("issued_at DESC").First(&issuance).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIssuanceNotFound
+		}
+		return nil, err
+	}
+	return s.verify(&issuance)
+}
+
+func (s *PayslipIssuer) verify(issuance *models.PayslipIssuance) (*PayslipVerification, error) {
+	result := &PayslipVerification{
+		VerificationCode: issuance.VerificationCode,
+		IssuedAt:         issuance.IssuedAt,
+		DocumentHash:     issuance.DocumentHash,
+		Valid:            hmac.Equal([]byte(s.sign(issuance)), []byte(issuance.Signature)),
+	}
+
+	var payslip models.Payslip
+	err := config.DB.Preload("Items").First(&payslip, "id = ?", issuance.PayslipID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Holerite removido depois da emissão: a assinatura ainda é conferida
+			return result, nil
+		}
+		return nil, err
+	}
+
+	result.DataIntact = payslipDataHash(&payslip) == issuance.DataHash
+	result.EmployeeName = payslip.EmployeeName
+	result.EmployeeCPF = maskCPF(payslip.EmployeeCPF)
+	result.ReferenceMonth = payslip.ReferenceMonth
+	result.ReferenceYear = payslip.ReferenceYear
+	result.PayslipType = payslip.PayslipType
+	return result, nil
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/frappyou/backend/models"
+)
+
+// ==================== PDF do holerite (contracheque) ====================
+
+// PayslipPDFInfo dados da emissão impressos no rodapé do holerite
+type PayslipPDFInfo struct {
+	CompanyName      string
+	VerificationCode string
+	VerifyURL        string
+	IssuedAt         time.Time
+}
+
+// Layout do recibo (pontos, a partir do topo da página)
+const (
+	payslipMarginX     = 30.0
+	payslipRight       = PDFPageWidth - payslipMarginX
+	payslipTableTop    = 150.0
+	payslipRowHeight   = 13.0
+	payslipRowsPerPage = 36
+	// Fim da tabela (cabeçalho + linhas); abaixo ficam totais, bases e verificação
+	payslipBodyBottom = payslipTableTop + (payslipRowsPerPage+1)*payslipRowHeight
+)
+
+// Colunas da tabela de rubricas (limites à esquerda e margem direita)
+var payslipColumns = []float64{payslipMarginX, 75, 320, 390, 477.64, payslipRight}
+
+// PayslipTypeLabel nome do tipo de holerite para exibição
+func PayslipTypeLabel(payslipType string) string {
+	switch payslipType {
+	case "", "mensal":
+		return "Folha Mensal"
+	case "13_primeira":
+		return "13º Salário - 1ª Parcela"
+	case "13_segunda":
+		return "13º Salário - 2ª Parcela"
+	case "ferias":
+		return "Férias"
+	case "rescisao":
+		return "Rescisão"
+	case "adiantamento":
+		return "Adiantamento"
+	}
+	return payslipType
+}
+
+// FormatBRL formata um valor no padrão brasileiro (1.234,56)
+func FormatBRL(value float64) string {
+	cents := int64(math.Round(math.Abs(value) * 100))
+	intPart := fmt.Sprintf("%d", cents/100)
+
+	var grouped strings.Builder
+	for i, digit := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(digit)
+	}
+
+	sign := ""
+	if value < 0 && cents > 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s%s,%02d", sign, grouped.String(), cents%100)
+}
+
+// formatReference formata a coluna de referência (horas, dias, %), vazia quando zero
+func formatReference(value float64) string {
+	if value == 0 {
+		return ""
+	}
+	return FormatBRL(value)
+}
+
+// maskCPF exibe apenas os dígitos centrais do CPF (***.456.789-**)
+func maskCPF(cpf string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, cpf)
+	if len(digits) != 11 {
+		return cpf
+	}
+	return "***." + digits[3:6] + "." + digits[6:9] + "-**"
+}
+
+// RenderPayslipPDF gera o recibo de pagamento no layout padrão de contracheque:
+// proventos e descontos em colunas, totais, líquido, bases de INSS/FGTS/IRRF e
+// o código de verificação da emissão no rodapé.
+func RenderPayslipPDF(payslip *models.Payslip, info PayslipPDFInfo) []byte {
+	title := fmt.Sprintf("Holerite %02d/%d - %s", payslip.ReferenceMonth, payslip.ReferenceYear, payslip.EmployeeName)
+	doc := NewPDFDocument(title, info.IssuedAt)
+
+	// Proventos primeiro, depois descontos (ordem usual do recibo)
+	var items []models.PayslipItem
+	for _, item := range payslip.Items {
+		if item.Type == "earning" {
+			items = append(items, item)
+		}
+	}
+	for _, item := range payslip.Items {
+		if item.Type != "earning" {
+			items = append(items, item)
+		}
+	}
+
+	pages := (len(items) + payslipRowsPerPage - 1) / payslipRowsPerPage
+	if pages == 0 {
+		pages = 1
+	}
+
+	for page := 0; page < pages; page++ {
+		doc.AddPage()
+		drawPayslipHeader(doc, payslip, info, page+1, pages)
+
+		start := page * payslipRowsPerPage
+		end := start + payslipRowsPerPage
+		if end > len(items) {
+			end = len(items)
+		}
+		drawPayslipItems(doc, items[start:end])
+
+		if page == pages-1 {
+			drawPayslipTotals(doc, payslip)
+		} else {
+			doc.TextRight(payslipRight, payslipBodyBottom+14, 8, false, "Continua na próxima página")
+		}
+		drawPayslipVerification(doc, info)
+	}
+
+	return doc.Bytes()
+}
+
+func drawPayslipHeader(doc *PDFDocument, p *models.Payslip, info PayslipPDFInfo, page, pages int) {
+	width := payslipRight - payslipMarginX
+
+	// Empresa e identificação do documento
+	doc.Rect(payslipMarginX, 30, width, 52, 0.8)
+	doc.Text(payslipMarginX+8, 48, 12, true, info.CompanyName)
+	if p.Branch != "" {
+		doc.Text(payslipMarginX+8, 62, 8, false, "Filial: "+p.Branch)
+	}
+	doc.Text(payslipMarginX+8, 74, 7, false, fmt.Sprintf("Página %d de %d", page, pages))
+	doc.TextRight(payslipRight-8, 46, 11, true, "Recibo de Pagamento de Salário")
+	doc.TextRight(payslipRight-8, 60, 9, false, fmt.Sprintf("Referência: %s/%d", getMonthName(p.ReferenceMonth), p.ReferenceYear))
+	doc.TextRight(payslipRight-8, 73, 8, false, PayslipTypeLabel(p.PayslipType))
+
+	// Dados do colaborador
+	doc.Rect(payslipMarginX, 86, width, 56, 0.8)
+	field := func(x, y float64, label, value string, maxWidth float64) {
+		doc.Text(x, y, 6.5, false, label)
+		doc.Text(x, y+10, 8.5, true, PDFFitText(value, 8.5, true, maxWidth))
+	}
+	admission := "-"
+	if p.AdmissionDate != nil {
+		admission = p.AdmissionDate.Format("02/01/2006")
+	}
+	payment := "-"
+	if p.PaymentDate != nil {
+		payment = p.PaymentDate.Format("02/01/2006")
+	}
+	field(payslipMarginX+8, 96, "NOME DO COLABORADOR", p.EmployeeName, 300)
+	field(350, 96, "CPF", maskCPF(p.EmployeeCPF), 90)
+	field(460, 96, "ADMISSÃO", admission, 95)
+	field(payslipMarginX+8, 120, "CARGO", p.Position, 200)
+	field(245, 120, "DEPARTAMENTO", p.Department, 170)
+	field(430, 120, "PAGAMENTO", payment, 55)
+	field(500, 120, "DIAS TRAB.", fmt.Sprintf("%d", p.WorkedDays), 55)
+}
+
+func drawPayslipItems(doc *PDFDocument, items []models.PayslipItem) {
+	width := payslipRight - payslipMarginX
+	top := payslipTableTop
+
+	// Cabeçalho da tabela
+	doc.FillRect(payslipMarginX, top, width, payslipRowHeight, 0.9)
+	doc.Rect(payslipMarginX, top, width, payslipBodyBottom-top, 0.8)
+	doc.Line(payslipMarginX, top+payslipRowHeight, payslipRight, top+payslipRowHeight, 0.5)
+	for _, x := range payslipColumns[1 : len(payslipColumns)-1] {
+		doc.Line(x, top, x, payslipBodyBottom, 0.5)
+	}
+	headerY := top + 9.5
+	doc.Text(payslipColumns[0]+4, headerY, 7.5, true, "Cód.")
+	doc.Text(payslipColumns[1]+4, headerY, 7.5, true, "Descrição")
+	doc.TextRight(payslipColumns[3]-4, headerY, 7.5, true, "Referência")
+	doc.TextRight(payslipColumns[4]-4, headerY, 7.5, true, "Vencimentos")
+	doc.TextRight(payslipColumns[5]-4, headerY, 7.5, true, "Descontos")
+
+	y := top + payslipRowHeight + 9.5
+	for _, item := range items {
+		doc.Text(payslipColumns[0]+4, y, 8, false, PDFFitText(item.Code, 8, false, payslipColumns[1]-payslipColumns[0]-8))
+		doc.Text(payslipColumns[1]+4, y, 8, false, PDFFitText(item.Description, 8, false, payslipColumns[2]-payslipColumns[1]-8))
+		doc.TextRight(payslipColumns[3]-4, y, 8, false, formatReference(item.Reference))
+		amountColumn := payslipColumns[5]
+		if item.Type == "earning" {
+			amountColumn = payslipColumns[4]
+		}
+		doc.TextRight(amountColumn-4, y, 8, false, FormatBRL(item.Amount))
+		y += payslipRowHeight
+	}
+}
+
+func drawPayslipTotals(doc *PDFDocument, p *models.Payslip) {
+	width := payslipRight - payslipMarginX
+	top := payslipBodyBottom
+
+	// Totais de vencimentos e descontos alinhados às colunas de valores
+	doc.Rect(payslipColumns[3], top, payslipRight-payslipColumns[3], 44, 0.8)
+	doc.Line(payslipColumns[4], top, payslipColumns[4], top+22, 0.5)
+	doc.Line(payslipColumns[3], top+22, payslipRight, top+22, 0.5)
+	doc.Text(payslipColumns[3]+4, top+8, 6.5, false, "TOTAL DE VENCIMENTOS")
+	doc.TextRight(payslipColumns[4]-4, top+18, 9, true, FormatBRL(p.GrossTotal))
+	doc.Text(payslipColumns[4]+4, top+8, 6.5, false, "TOTAL DE DESCONTOS")
+	doc.TextRight(payslipColumns[5]-4, top+18, 9, true, FormatBRL(p.DeductionTotal))
+	doc.Text(payslipColumns[3]+4, top+36, 8, false, "VALOR LÍQUIDO")
+	doc.TextRight(payslipRight-4, top+37, 11, true, "R$ "+FormatBRL(p.NetTotal))
+
+	// Bases de cálculo
+	bases := []struct {
+		label string
+		value float64
+	}{
+		{"SALÁRIO BASE", p.BaseSalary},
+		{"SAL. CONTR. INSS", p.INSSBase},
+		{"BASE CÁLC. FGTS", p.FGTSBase},
+		{"FGTS DO MÊS", p.FGTSAmount},
+		{"BASE CÁLC. IRRF", p.IRRFBase},
+	}
+	basesTop := top + 50
+	cell := width / float64(len(bases))
+	doc.Rect(payslipMarginX, basesTop, width, 26, 0.8)
+	for i, base := range bases {
+		x := payslipMarginX + float64(i)*cell
+		if i > 0 {
+			doc.Line(x, basesTop, x, basesTop+26, 0.5)
+		}
+		doc.Text(x+4, basesTop+9, 6.5, false, base.label)
+		doc.TextRight(x+cell-4, basesTop+21, 9, true, FormatBRL(base.value))
+	}
+}
+
+func drawPayslipVerification(doc *PDFDocument, info PayslipPDFInfo) {
+	top := 740.0
+	width := payslipRight - payslipMarginX
+
+	doc.Rect(payslipMarginX, top, width, 56, 0.8)
+	doc.Text(payslipMarginX+8, top+14, 8, true, "Documento emitido eletronicamente - autenticidade verificável")
+	doc.Text(payslipMarginX+8, top+27, 8, false, "Código de verificação: ")
+	doc.Text(payslipMarginX+8+PDFTextWidth("Código de verificação: ", 8, false), top+27, 9, true, info.VerificationCode)
+	doc.Text(payslipMarginX+8, top+39, 7.5, false, PDFFitText("Verifique em: "+info.VerifyURL, 7.5, false, width-16))
+	doc.Text(payslipMarginX+8, top+50, 7, false, "Emitido em "+info.IssuedAt.Format("02/01/2006 15:04:05")+". Qualquer alteração no arquivo invalida a verificação.")
+}
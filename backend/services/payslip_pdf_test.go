@@ -0,0 +1,145 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func samplePayslip(items int) *models.Payslip {
+	p := &models.Payslip{
+		ID:             "ps-1",
+		EmployeeName:   "Ana (Souza) Conceição",
+		EmployeeCPF:    "123.456.789-09",
+		Position:       "Analista",
+		Department:     "Fiscal",
+		ReferenceMonth: 3,
+		ReferenceYear:  2026,
+		PayslipType:    "mensal",
+		GrossTotal:     5000,
+		DeductionTotal: 1234.56,
+		NetTotal:       3765.44,
+		INSSBase:       5000,
+		IRRFBase:       4450.75,
+		FGTSBase:       5000,
+		FGTSAmount:     400,
+	}
+	for i := 0; i < items; i++ {
+		itemType := "earning"
+		if i%2 == 1 {
+			itemType = "deduction"
+		}
+		p.Items = append(p.Items, models.PayslipItem{Type: itemType, Code: strconv.Itoa(100 + i), Description: fmt.Sprintf("Rubrica %d", i), Amount: float64(i) * 10})
+	}
+	return p
+}
+
+func TestFormatBRL(t *testing.T) {
+	assert.Equal(t, "0,00", FormatBRL(0))
+	assert.Equal(t, "12,30", FormatBRL(12.3))
+	assert.Equal(t, "1.234,56", FormatBRL(1234.56))
+	assert.Equal(t, "1.000.000,01", FormatBRL(1000000.005))
+	assert.Equal(t, "-987,65", FormatBRL(-987.65))
+}
+
+func TestMaskCPF(t *testing.T) {
+	assert.Equal(t, "***.456.789-**", maskCPF("123.456.789-09"))
+	assert.Equal(t, "***.456.789-**", maskCPF("12345678909"))
+	assert.Equal(t, "123", maskCPF("123"))
+}
+
+func TestPDFEscape(t *testing.T) {
+	assert.Equal(t, `a\(b\)\\c`, pdfEscape(`a(b)\c`))
+	// "ç" e "ã" em WinAnsi (octal)
+	assert.Equal(t, `Concei\347\343o`, pdfEscape("Conceição"))
+}
+
+func TestRenderPayslipPDFStructure(t *testing.T) {
+	issuedAt := time.Date(2026, 4, 5, 10, 30, 0, 0, time.UTC)
+	info := PayslipPDFInfo{CompanyName: "Fradema", VerificationCode: "ABCD-EFGH-IJKL-MNOP", VerifyURL: PayslipVerifyURL("ABCD-EFGH-IJKL-MNOP"), IssuedAt: issuedAt}
+
+	pdf := RenderPayslipPDF(samplePayslip(4), info)
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4")))
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	assert.Contains(t, string(pdf), "/Count 1")
+	assert.Contains(t, string(pdf), "ABCD-EFGH-IJKL-MNOP")
+	assert.Contains(t, string(pdf), "(R$ 3.765,44)")
+
+	// Cada entrada do xref aponta para o início do objeto correspondente
+	xrefAt := bytes.LastIndex(pdf, []byte("\nxref\n")) + 1
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllStringSubmatch(string(pdf[xrefAt:]), -1)
+	require.NotEmpty(t, entries)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(entry[1])
+		assert.True(t, strings.HasPrefix(string(pdf[offset:]), fmt.Sprintf("%d 0 obj", i+1)), "objeto %d", i+1)
+	}
+	assert.Contains(t, string(pdf), fmt.Sprintf("startxref\n%d\n", xrefAt))
+
+	// Mesmo conteúdo gera o mesmo arquivo (o hash identifica a emissão)
+	assert.Equal(t, pdf, RenderPayslipPDF(samplePayslip(4), info))
+
+	// Rubricas além da capacidade da página continuam em páginas seguintes
+	long := RenderPayslipPDF(samplePayslip(payslipRowsPerPage+1), info)
+	assert.Contains(t, string(long), "/Count 2")
+}
+
+func TestPayslipDataHashIgnoresItemOrder(t *testing.T) {
+	p := samplePayslip(3)
+	hash := payslipDataHash(p)
+
+	p.Items[0], p.Items[2] = p.Items[2], p.Items[0]
+	assert.Equal(t, hash, payslipDataHash(p))
+
+	p.NetTotal += 0.01
+	assert.NotEqual(t, hash, payslipDataHash(p))
+}
+
+func TestPayslipIssuanceSignature(t *testing.T) {
+	issuer := &PayslipIssuer{now: time.Now, key: func() []byte { return []byte("chave-de-teste") }}
+	issuance := &models.PayslipIssuance{
+		PayslipID:        "ps-1",
+		VerificationCode: "ABCD-EFGH-IJKL-MNOP",
+		DocumentHash:     HashDocument([]byte("pdf")),
+		DataHash:         payslipDataHash(samplePayslip(2)),
+		IssuedAt:         time.Unix(1775385000, 0),
+	}
+	signature := issuer.sign(issuance)
+	assert.Len(t, signature, 64)
+
+	tampered := *issuance
+	tampered.DocumentHash = HashDocument([]byte("pdf alterado"))
+	assert.NotEqual(t, signature, issuer.sign(&tampered))
+
+	other := &PayslipIssuer{now: time.Now, key: func() []byte { return []byte("outra-chave") }}
+	assert.NotEqual(t, signature, other.sign(issuance))
+}
+
+func TestPayslipSigningKeyDerivation(t *testing.T) {
+	t.Setenv("PAYSLIP_SIGNING_KEY", "")
+
+	// Sem chave dedicada: derivada do segredo JWT, distinta dele e da chave dos links
+	key := payslipSigningKey()
+	assert.Len(t, key, 32)
+	assert.Equal(t, config.DeriveKey(payslipSigningLabel), key)
+	assert.NotEqual(t, config.JWTSecret, key)
+	assert.NotEqual(t, config.DeriveKey(urlSigningLabel), key)
+
+	t.Setenv("PAYSLIP_SIGNING_KEY", "chave-dedicada")
+	assert.Equal(t, []byte("chave-dedicada"), payslipSigningKey())
+}
+
+func TestVerificationCode(t *testing.T) {
+	code, err := newVerificationCode()
+	require.NoError(t, err)
+	assert.Regexp(t, `^[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}$`, code)
+	assert.Equal(t, code, NormalizeVerificationCode(strings.ToLower(strings.ReplaceAll(code, "-", ""))))
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== Ciclo de publicação de holerites ====================
+//
+// Holerites criados pelo admin ou importados nascem como rascunho e só ficam
+// visíveis para o colaborador depois de revisados e publicados em lote
+// (competência + filial), na data agendada. O colaborador registra a ciência
+// do recebimento, guardada com data, IP e hash dos dados como evidência.
+
+var (
+	ErrPublicationNotFound   = errors.New("publicação não encontrada")
+	ErrPublicationClosed     = errors.New("a publicação já foi concluída ou cancelada")
+	ErrPublicationEmpty      = errors.New("nenhum holerite revisado encontrado para a competência informada")
+	ErrPublicationPeriod     = errors.New("competência inválida")
+	ErrPayslipNotPublished   = errors.New("o holerite ainda não foi publicado")
+	ErrPayslipAlreadyAcked   = errors.New("a ciência deste holerite já foi registrada")
+	ErrPayslipReviewSelector = errors.New("informe os holerites ou a competência a revisar")
+)
+
+// PayslipPublisher controla as transições de status dos holerites
+type PayslipPublisher struct {
+	now func() time.Time
+}
+
+// PayslipPublications instância usada pelos handlers e pelo agendador
+var PayslipPublications = NewPayslipPublisher()
+
+// NewPayslipPublisher cria o publicador
+func NewPayslipPublisher() *PayslipPublisher {
+	return &PayslipPublisher{now: time.Now}
+}
+
+// PublishedPayslips escopo GORM que restringe aos holerites visíveis ao colaborador
+func PublishedPayslips(db *gorm.DB) *gorm.DB {
+	return db.Where("status IN ?", models.PayslipPublishedStatuses)
+}
+
+// IsPayslipPublished indica se o holerite já pode ser consultado pelo colaborador
+func IsPayslipPublished(status string) bool {
+	return containsStatus(models.PayslipPublishedStatuses, status)
+}
+
+// IsPayslipReplaceable indica se o holerite ainda pode ser substituído por uma importação
+func IsPayslipReplaceable(status string) bool {
+	return containsStatus(models.PayslipUnpublishedStatuses, status)
+}
+
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
+
+// PayslipSelection filtro de holerites por competência, filial e tipo
+type PayslipSelection struct {
+	IDs            []string `json:"ids"`
+	ReferenceMonth int      `json:"reference_month"`
+	ReferenceYear  int      `json:"reference_year"`
+	Branch         string   `json:"branch"`
+	PayslipType    string   `json:"payslip_type"`
+}
+
+func (s PayslipSelection) validPeriod() bool {
+	return s.ReferenceMonth >= 1 && s.ReferenceMonth <= 12 && s.ReferenceYear >= 1900
+}
+
+func (s PayslipSelection) apply(db *gorm.DB) *gorm.DB {
+	if len(s.IDs) > 0 {
+		db = db.Where("id IN ?", s.IDs)
+	}
+	if s.ReferenceMonth > 0 {
+		db = db.Where("reference_month = ?", s.ReferenceMonth)
+	}
+	if s.ReferenceYear > 0 {
+		db = db.Where("reference_year = ?", s.ReferenceYear)
+	}
+	if s.Branch != "" {
+		db = db.Where("branch = ?", s.Branch)
+	}
+	if s.PayslipType != "" {
+		db = db.Where("payslip_type = ?", s.PayslipType)
+	}
+	return db
+}
+
+// Review marca como revisados os rascunhos selecionados
+func (p *PayslipPublisher) Review(sel PayslipSelection, userID string) (int, error) {
+	if len(sel.IDs) == 0 && !sel.validPeriod() {
+		return 0, ErrPayslipReviewSelector
+	}
+	now := p.now()
+	result := sel.apply(config.DB.Model(&models.Payslip{})).
+		Where("status = ?", models.PayslipStatusDraft).
+		Updates(map[string]interface{}{
+			"status":      models.PayslipStatusReviewed,
+			"reviewed_by": userID,
+			"reviewed_at": now,
+		})
+	return int(result.RowsAffected), result.Error
+}
+
+// ReturnToDraft devolve holerites revisados para rascunho (ex: correções)
+func (p *PayslipPublisher) ReturnToDraft(sel PayslipSelection) (int, error) {
+	if len(sel.IDs) == 0 && !sel.validPeriod() {
+		return 0, ErrPayslipReviewSelector
+	}
+	result := sel.apply(config.DB.Model(&models.Payslip{})).
+		Where("status = ?", models.PayslipStatusReviewed).
+		Updates(map[string]interface{}{
+			"status":      models.PayslipStatusDraft,
+			"reviewed_by": "",
+			"reviewed_at": nil,
+		})
+	return int(result.RowsAffected), result.Error
+}
+
+// Schedule cria a publicação dos holerites revisados da competência. Sem data
+// (ou com data passada) a publicação acontece imediatamente.
+func (p *PayslipPublisher) Schedule(sel PayslipSelection, scheduledFor time.Time, userID string) (*models.PayslipPublication, error) {
+	if !sel.validPeriod() {
+		return nil, ErrPublicationPeriod
+	}
+	sel.IDs = nil
+
+	now := p.now()
+	if scheduledFor.IsZero() {
+		scheduledFor = now
+	}
+	publication := models.PayslipPublication{
+		ReferenceMonth: sel.ReferenceMonth,
+		ReferenceYear:  sel.ReferenceYear,
+		Branch:         sel.Branch,
+		PayslipType:    sel.PayslipType,
+		ScheduledFor:   scheduledFor,
+		Status:         models.PayslipPublicationScheduled,
+		CreatedBy:      userID,
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&publication).Error; err != nil {
+			return err
+		}
+		result := sel.apply(tx.Model(&models.Payslip{})).
+			Where("status = ?", models.PayslipStatusReviewed).
+			Updates(map[string]interface{}{
+				"status":         models.PayslipStatusScheduled,
+				"publication_id": publication.ID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPublicationEmpty
+		}
+		publication.PayslipCount = int(result.RowsAffected)
+		return tx.Model(&publication).Update("payslip_count", publication.PayslipCount).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !scheduledFor.After(now) {
+		if err := p.publish(&publication); err != nil {
+			return nil, err
+		}
+	}
+	return &publication, nil
+}
+
+// Cancel cancela uma publicação ainda agendada; os holerites voltam a revisados
+func (p *PayslipPublisher) Cancel(publicationID, userID string) (*models.PayslipPublication, error) {
+	var publication models.PayslipPublication
+	if err := config.DB.First(&publication, "id = ?", publicationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPublicationNotFound
+		}
+		return nil, err
+	}
+
+	now := p.now()
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Atualização condicional: o agendador pode estar publicando o mesmo lote
+		result := tx.Model(&models.PayslipPublication{}).
+			Where("id = ? AND status = ?", publication.ID, models.PayslipPublicationScheduled).
+			Updates(map[string]interface{}{
+				"status":      models.PayslipPublicationCanceled,
+				"canceled_by": userID,
+				"canceled_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPublicationClosed
+		}
+		return tx.Model(&models.Payslip{}).
+			Where("publication_id = ? AND status = ?", publication.ID, models.PayslipStatusScheduled).
+			Updates(map[string]interface{}{
+				"status":         models.PayslipStatusReviewed,
+				"publication_id": "",
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publication.Status = models.PayslipPublicationCanceled
+	publication.CanceledBy = userID
+	publication.CanceledAt = &now
+	return &publication, nil
+}
+
+// List retorna as publicações, mais recentes primeiro
+func (p *PayslipPublisher) List(year int) ([]models.PayslipPublication, error) {
+	query := config.DB.Order("scheduled_for DESC")
+	if year > 0 {
+		query = query.Where("reference_year = ?", year)
+	}
+	var publications []models.PayslipPublication
+	err := query.Find(&publications).Error
+	return publications, err
+}
+
+// PublishDue publica os lotes cuja data agendada já passou
+func (p *PayslipPublisher) PublishDue() (int, error) {
+	var due []models.PayslipPublication
+	err := config.DB.Where("status = ? AND scheduled_for <= ?", models.PayslipPublicationScheduled, p.now()).
+		Order("scheduled_for ASC").
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for i := range due {
+		if err := p.publish(&due[i]); err != nil {
+			if errors.Is(err, ErrPublicationClosed) {
+				continue
+			}
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+// publish libera os holerites do lote e notifica cada colaborador
+func (p *PayslipPublisher) publish(publication *models.PayslipPublication) error {
+	now := p.now()
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Apenas uma instância publica o lote
+		result := tx.Model(&models.PayslipPublication{}).
+			Where("id = ? AND status = ?", publication.ID, models.PayslipPublicationScheduled).
+			Updates(map[string]interface{}{
+				"status":       models.PayslipPublicationPublished,
+				"published_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPublicationClosed
+		}
+		return tx.Model(&models.Payslip{}).
+			Where("publication_id = ? AND status = ?", publication.ID, models.PayslipStatusScheduled).
+			Updates(map[string]interface{}{
+				"status":       models.PayslipStatusPublished,
+				"published_at": now,
+			}).Error
+	})
+	if err != nil {
+		return err
+	}
+	publication.Status = models.PayslipPublicationPublished
+	publication.PublishedAt = &now
+
+	var payslips []models.Payslip
+	if err := config.DB.Select("id, employee_id, reference_month, reference_year, payslip_type").
+		Where("publication_id = ? AND status = ?", publication.ID, models.PayslipStatusPublished).
+		Find(&payslips).Error; err != nil {
+		return fmt.Errorf("lote %s publicado, mas os colaboradores não foram notificados: %w", publication.ID, err)
+	}
+
+	notified := 0
+	for _, payslip := range payslips {
+		if p.notifyPublished(payslip) {
+			notified++
+		}
+	}
+	publication.NotifiedCount = notified
+	config.DB.Model(&models.PayslipPublication{}).Where("id = ?", publication.ID).Update("notified_count", notified)
+	return nil
+}
+
+// notifyPublished avisa o colaborador (se tiver conta no portal) que o holerite está disponível
+func (p *PayslipPublisher) notifyPublished(payslip models.Payslip) bool {
+	if payslip.EmployeeID == "" {
+		return false
+	}
+	var identity models.EmployeeIdentity
+	if config.DB.Select("id, user_id").First(&identity, "id = ?", payslip.EmployeeID).Error != nil || identity.UserID == "" {
+		return false
+	}
+	userID := identity.UserID
+
+	notification := models.Notification{
+		UserID:   userID,
+		Title:    "Holerite disponível",
+		Message:  fmt.Sprintf("Seu holerite %s de %s/%d está disponível. Confira e confirme o recebimento.", PayslipTypeLabel(payslip.PayslipType), getMonthName(payslip.ReferenceMonth), payslip.ReferenceYear),
+		Type:     models.NotificationTypeDocument,
+		Category: models.NotificationCategoryDocument,
+		Link:     "/holerite",
+	}
+	if config.DB.Create(&notification).Error != nil {
+		return false
+	}
+	Events.Publish(EventNotificationCreated, userID, notification)
+	return true
+}
+
+// Acknowledge registra a ciência do colaborador. O holerite deve estar carregado com os itens.
+func (p *PayslipPublisher) Acknowledge(payslip *models.Payslip, userID, ip, userAgent string) (*models.PayslipAcknowledgement, error) {
+	if payslip.Status == models.PayslipStatusAcknowledged {
+		return nil, ErrPayslipAlreadyAcked
+	}
+	if !IsPayslipPublished(payslip.Status) {
+		return nil, ErrPayslipNotPublished
+	}
+
+	now := p.now()
+	ack := models.PayslipAcknowledgement{
+		PayslipID:      payslip.ID,
+		UserID:         userID,
+		AcknowledgedAt: now,
+		IPAddress:      ip,
+		UserAgent:      truncateString(userAgent, 500),
+		DataHash:       payslipDataHash(payslip),
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Payslip{}).
+			Where("id = ? AND status = ?", payslip.ID, payslip.Status).
+			Updates(map[string]interface{}{
+				"status":          models.PayslipStatusAcknowledged,
+				"acknowledged_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPayslipAlreadyAcked
+		}
+		return tx.Create(&ack).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	payslip.Status = models.PayslipStatusAcknowledged
+	payslip.AcknowledgedAt = &now
+	return &ack, nil
+}
+
+// PayslipAcknowledgementRow linha do relatório de ciência por competência
+type PayslipAcknowledgementRow struct {
+	PayslipID      string     `json:"payslip_id"`
+	EmployeeName   string     `json:"employee_name"`
+	EmployeeCPF    string     `json:"employee_cpf"`
+	Branch         string     `json:"branch"`
+	PayslipType    string     `json:"payslip_type"`
+	Status         string     `json:"status"`
+	PublishedAt    *time.Time `json:"published_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	IPAddress      string     `json:"ip_address"`
+	models.Sealed
+}
+
+// AcknowledgementReport lista os holerites publicados da competência com a ciência de cada colaborador
+func (p *PayslipPublisher) AcknowledgementReport(sel PayslipSelection) ([]PayslipAcknowledgementRow, error) {
+	if !sel.validPeriod() {
+		return nil, ErrPublicationPeriod
+	}
+	sel.IDs = nil
+
+	var rows []PayslipAcknowledgementRow
+	err := sel.apply(config.DB.Table("payslips")).
+		Scopes(PublishedPayslips).
+		Select(`payslips.id AS payslip_id, payslips.employee_name, payslips.employee_cpf, payslips.branch,
+			payslips.payslip_type, payslips.status, payslips.published_at, a.acknowledged_at, a.ip_address,
+			payslips.enc_key_id, payslips.enc_data_key, payslips.enc_data`).
+		Joins("LEFT JOIN payslip_acknowledgements a ON a.payslip_id = payslips.id").
+		Order("payslips.branch ASC, payslips.employee_name ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Scan não passa pelos hooks do modelo: o CPF é decifrado aqui
+	for i := range rows {
+		if rows[i].EmployeeCPF, err = models.OpenPayslipCPF(rows[i].Sealed, rows[i].EmployeeCPF); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// StartScheduler publica periodicamente os lotes agendados
+func (p *PayslipPublisher) StartScheduler(ctx context.Context, interval time.Duration) {
+	if config.DB == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if published, err := p.PublishDue(); err != nil {
+				log.Printf("⚠️ Holerites: erro ao publicar lotes agendados: %v", err)
+			} else if published > 0 {
+				log.Printf("📄 Holerites: %d lotes publicados", published)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPayslipPublished(t *testing.T) {
+	// Lista de permitidos: bloqueado, vazio ou desconhecido ficam ocultos
+	for _, status := range []string{models.PayslipStatusDraft, models.PayslipStatusReviewed, models.PayslipStatusScheduled, models.PayslipStatusBlocked, "", "desconhecido"} {
+		assert.False(t, IsPayslipPublished(status), status)
+	}
+	for _, status := range []string{models.PayslipStatusPublished, models.PayslipStatusAcknowledged, models.PayslipStatusLegacy} {
+		assert.True(t, IsPayslipPublished(status), status)
+	}
+}
+
+func TestPayslipPublisherRejectsInvalidSelection(t *testing.T) {
+	p := NewPayslipPublisher()
+
+	_, err := p.Review(PayslipSelection{Branch: "Matriz"}, "admin")
+	assert.ErrorIs(t, err, ErrPayslipReviewSelector)
+
+	_, err = p.ReturnToDraft(PayslipSelection{ReferenceMonth: 13, ReferenceYear: 2026})
+	assert.ErrorIs(t, err, ErrPayslipReviewSelector)
+
+	_, err = p.Schedule(PayslipSelection{ReferenceYear: 2026}, time.Now(), "admin")
+	assert.ErrorIs(t, err, ErrPublicationPeriod)
+
+	_, err = p.AcknowledgementReport(PayslipSelection{ReferenceMonth: 3})
+	assert.ErrorIs(t, err, ErrPublicationPeriod)
+}
+
+func TestAcknowledgeRequiresPublishedPayslip(t *testing.T) {
+	p := NewPayslipPublisher()
+
+	for _, status := range models.PayslipUnpublishedStatuses {
+		_, err := p.Acknowledge(&models.Payslip{ID: "ps-1", Status: status}, "user-1", "127.0.0.1", "test")
+		assert.ErrorIs(t, err, ErrPayslipNotPublished, status)
+	}
+
+	_, err := p.Acknowledge(&models.Payslip{ID: "ps-1", Status: models.PayslipStatusAcknowledged}, "user-1", "127.0.0.1", "test")
+	assert.ErrorIs(t, err, ErrPayslipAlreadyAcked)
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ==================== Gerador de PDF simples ====================
+//
+// Gera PDFs de texto e linhas com as fontes padrão Helvetica (sem fontes
+// embutidas), suficiente para documentos tabulares como holerites e informes.
+// As coordenadas são em pontos a partir do canto SUPERIOR esquerdo da página A4.
+
+const (
+	PDFPageWidth  = 595.28
+	PDFPageHeight = 841.89
+)
+
+// PDFDocument documento em construção
+type PDFDocument struct {
+	pages    []*bytes.Buffer
+	current  *bytes.Buffer
+	title    string
+	creation time.Time
+}
+
+// NewPDFDocument cria um documento vazio (use AddPage antes de desenhar)
+func NewPDFDocument(title string, creation time.Time) *PDFDocument {
+	return &PDFDocument{title: title, creation: creation}
+}
+
+// AddPage inicia uma nova página A4
+func (d *PDFDocument) AddPage() {
+	d.current = &bytes.Buffer{}
+	d.pages = append(d.pages, d.current)
+}
+
+// PageCount número de páginas
+func (d *PDFDocument) PageCount() int {
+	return len(d.pages)
+}
+
+func pdfNum(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-" {
+		return "0"
+	}
+	return s
+}
+
+// Text escreve um texto com a linha de base em (x, y)
+func (d *PDFDocument) Text(x, y, size float64, bold bool, text string) {
+	if text == "" {
+		return
+	}
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.current, "BT /%s %s Tf %s %s Td (%s) Tj ET\n", font, pdfNum(size), pdfNum(x), pdfNum(PDFPageHeight-y), pdfEscape(text))
+}
+
+// TextRight escreve o texto alinhado à direita em x
+func (d *PDFDocument) TextRight(x, y, size float64, bold bool, text string) {
+	d.Text(x-PDFTextWidth(text, size, bold), y, size, bold, text)
+}
+
+// TextCenter escreve o texto centralizado em x
+func (d *PDFDocument) TextCenter(x, y, size float64, bold bool, text string) {
+	d.Text(x-PDFTextWidth(text, size, bold)/2, y, size, bold, text)
+}
+
+// Line desenha uma linha
+func (d *PDFDocument) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.current, "%s w %s %s m %s %s l S\n", pdfNum(width), pdfNum(x1), pdfNum(PDFPageHeight-y1), pdfNum(x2), pdfNum(PDFPageHeight-y2))
+}
+
+// Rect desenha um retângulo (contorno) com canto superior esquerdo em (x, y)
+func (d *PDFDocument) Rect(x, y, w, h, width float64) {
+	fmt.Fprintf(d.current, "%s w %s %s %s %s re S\n", pdfNum(width), pdfNum(x), pdfNum(PDFPageHeight-y-h), pdfNum(w), pdfNum(h))
+}
+
+// FillRect preenche um retângulo em tons de cinza (0 = preto, 1 = branco)
+func (d *PDFDocument) FillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(d.current, "q %s g %s %s %s %s re f Q\n", pdfNum(gray), pdfNum(x), pdfNum(PDFPageHeight-y-h), pdfNum(w), pdfNum(h))
+}
+
+// Bytes serializa o documento. A saída é determinística para o mesmo conteúdo.
+func (d *PDFDocument) Bytes() []byte {
+	var out bytes.Buffer
+	offsets := []int{}
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1: catálogo, 2: árvore de páginas, 3-4: fontes, 5: metadados, 6+: páginas e conteúdos
+	const firstPageObj = 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageObj+i*2)
+	}
+
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	obj(fmt.Sprintf("<< /Title (%s) /Producer (FrappYOU) /CreationDate (D:%s) >>", pdfEscape(d.title), d.creation.UTC().Format("20060102150405")+"Z"))
+
+	for i, page := range d.pages {
+		contentObj := firstPageObj + i*2 + 1
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfNum(PDFPageWidth), pdfNum(PDFPageHeight), contentObj))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// pdfEscape converte o texto para WinAnsi e escapa os delimitadores de string do PDF
+func pdfEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 160 && r <= 255:
+			// Latin-1 coincide com WinAnsi nessa faixa (acentos do português)
+			fmt.Fprintf(&b, "\\%03o", r)
+		case r == '€':
+			b.WriteString("\\200")
+		case r == '–' || r == '—':
+			b.WriteByte('-')
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// Larguras (1/1000 em) dos caracteres ASCII 32-126 das fontes Helvetica
+var (
+	helveticaWidths = []int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = []int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// PDFTextWidth largura do texto em pontos (acentuados usam a largura média)
+func PDFTextWidth(text string, size float64, bold bool) float64 {
+	widths := helveticaWidths
+	if bold {
+		widths = helveticaBoldWidths
+	}
+	total := 0
+	for _, r := range text {
+		if r >= 32 && r < 127 {
+			total += widths[r-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// PDFFitText corta o texto (com reticências) para caber na largura informada
+func PDFFitText(text string, size float64, bold bool, maxWidth float64) string {
+	if PDFTextWidth(text, size, bold) <= maxWidth {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && PDFTextWidth(string(runes)+"...", size, bold) > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
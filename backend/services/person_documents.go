@@ -0,0 +1,45 @@
+package services
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/frappyou/backend/encryption"
+)
+
+// ==================== RG/CNH (dbo.PessoasFisicasFradema) ====================
+
+// personDocumentsEnabled RG e CNH gravados cifrados na dbo.PessoasFisicasFradema
+// (ENCRYPTION_PERSON_DOCUMENTS=true). A tabela é compartilhada com o cadastro da
+// empresa: só ligar quando as colunas comportarem o token (nvarchar(255)) e os demais
+// sistemas não dependerem do valor em claro. A leitura decifra sempre.
+func personDocumentsEnabled() bool {
+	return encryption.Enabled() && strings.EqualFold(os.Getenv("ENCRYPTION_PERSON_DOCUMENTS"), "true")
+}
+
+// SealPersonDocument cifra RG/CNH antes da gravação (nil mantém o valor atual no UPDATE)
+func SealPersonDocument(value *string) (*string, error) {
+	if value == nil || *value == "" || !personDocumentsEnabled() || encryption.IsToken(*value) {
+		return value, nil
+	}
+	sealed, err := encryption.SealString(*value)
+	if err != nil {
+		return nil, err
+	}
+	return &sealed, nil
+}
+
+// OpenPersonDocument decifra RG/CNH lidos da tabela; valores em claro passam direto.
+// Um token que não decifra (chave removida do chaveiro) não é exposto.
+func OpenPersonDocument(value *string) *string {
+	if value == nil || !encryption.IsToken(*value) {
+		return value
+	}
+	plain, err := encryption.OpenString(*value)
+	if err != nil {
+		log.Printf("⚠️ Criptografia: documento pessoal ilegível (chave %s): %v", encryption.TokenKeyID(*value), err)
+		return nil
+	}
+	return &plain
+}
@@ -1,11 +1,15 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"regexp"
 	"sort"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/frappyou/backend/config"
 	"github.com/frappyou/backend/models"
@@ -29,14 +33,29 @@ type SearchResult struct {
 	Article  models.KnowledgeArticle
 	Score    float64
 	Snippets []string
+	Passages []string // Trechos do artigo encontrados pelo índice (contexto para o chat)
 }
 
-// Search busca artigos relevantes para uma query
+// Search busca artigos relevantes para uma query: índice híbrido (BM25 + embeddings)
+// e, enquanto o índice não estiver construído, a busca por palavras
 func (r *RAGService) Search(query string, category string, limit int) ([]SearchResult, error) {
 	if limit <= 0 {
 		limit = 5
 	}
 
+	results, err := KnowledgeIndex.Search(context.Background(), query, category, limit)
+	if err == nil {
+		return results, nil
+	}
+	if !errors.Is(err, ErrKnowledgeIndexEmpty) {
+		log.Printf("⚠️ Base de conhecimento: erro no índice, usando busca por palavras: %v", err)
+	}
+	return r.searchByTokens(query, category, limit)
+}
+
+// searchByTokens busca por palavras em todos os artigos publicados
+func (r *RAGService) searchByTokens(query string, category string, limit int) ([]SearchResult, error) {
+
 	// Normaliza e tokeniza a query
 	queryTokens := r.tokenize(query)
 	if len(queryTokens) == 0 {
@@ -103,11 +122,8 @@ func (r *RAGService) SearchForChat(query string, limit int) (string, []models.Kn
 			contextBuilder.WriteString(fmt.Sprintf("**Resumo:** %s\n", result.Article.Summary))
 		}
 
-		// Adiciona conteúdo (limitado)
-		content := result.Article.Content
-		if len(content) > 1500 {
-			content = content[:1500] + "..."
-		}
+		// Adiciona os trechos encontrados ou o conteúdo (limitado)
+		content := r.resultContent(result, 1500)
 		contextBuilder.WriteString(fmt.Sprintf("\n%s\n\n", content))
 
 		// Adiciona snippets relevantes
@@ -315,6 +331,9 @@ func (r *RAGService) extractSnippet(text, query string) string {
 
 // ==================== Helpers ====================
 
+// nonWordChars caracteres removidos na tokenização
+var nonWordChars = regexp.MustCompile(`[^a-z0-9\s]`)
+
 // tokenize normaliza e tokeniza um texto
 func (r *RAGService) tokenize(text string) []string {
 	// Remove acentos
@@ -324,8 +343,7 @@ func (r *RAGService) tokenize(text string) []string {
 	text = strings.ToLower(text)
 
 	// Remove caracteres especiais mantendo apenas letras, números e espaços
-	reg := regexp.MustCompile(`[^a-z0-9\s]`)
-	text = reg.ReplaceAllString(text, " ")
+	text = nonWordChars.ReplaceAllString(text, " ")
 
 	// Split por espaços
 	words := strings.Fields(text)
@@ -502,10 +520,7 @@ func (r *RAGService) GetContextForQuery(query string) string {
 		contextBuilder.WriteString(fmt.Sprintf("### %d. %s\n", i+1, result.Article.Title))
 
 		// Conteúdo limitado
-		content := result.Article.Content
-		if len(content) > 1000 {
-			content = content[:1000] + "..."
-		}
+		content := r.resultContent(result, 1000)
 		contextBuilder.WriteString(fmt.Sprintf("%s\n\n", content))
 	}
 
@@ -517,6 +532,22 @@ func (r *RAGService) GetContextForQuery(query string) string {
 	return contextBuilder.String()
 }
 
+// resultContent trechos encontrados pelo índice ou o início do artigo, até limit bytes
+func (r *RAGService) resultContent(result SearchResult, limit int) string {
+	content := result.Article.Content
+	if len(result.Passages) > 0 {
+		content = strings.Join(result.Passages, "\n\n")
+	}
+	if len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		content = content[:cut] + "..."
+	}
+	return content
+}
+
 // formatArticlesAsContext formata artigos como contexto
 func (r *RAGService) formatArticlesAsContext(articles []models.KnowledgeArticle) string {
 	if len(articles) == 0 {
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== Sessões e refresh tokens ====================
+
+var (
+	ErrSessionInvalid     = errors.New("sessão inválida ou expirada")
+	ErrRefreshTokenReused = errors.New("refresh token já utilizado; a sessão foi encerrada por segurança")
+)
+
+const (
+	RefreshTokenTTL           = 24 * time.Hour
+	RememberMeRefreshTokenTTL = 30 * 24 * time.Hour
+
+	// PrefixRevokedSession denylist no Redis; a entrada dura o tempo de vida do access token
+	PrefixRevokedSession = "session:revoked:"
+)
+
+// TokenPair tokens devolvidos no login e na renovação
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Segundos até o access token expirar
+	SessionID    string `json:"session_id"`
+}
+
+// SessionClient dados do dispositivo que abriu/renovou a sessão
+type SessionClient struct {
+	IP        string
+	UserAgent string
+}
+
+// SessionService emite, rotaciona e revoga sessões (tabela user_sessions)
+type SessionService struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	undenied map[string]time.Time // Revogadas que não entraram na denylist do Redis (até quando vale o access token)
+}
+
+// Sessions sessões compartilhadas pelos handlers de autenticação, middleware e GraphQL
+var Sessions = NewSessionService()
+
+// NewSessionService cria o serviço de sessões
+func NewSessionService() *SessionService {
+	return &SessionService{now: time.Now, undenied: make(map[string]time.Time)}
+}
+
+// hashToken retorna o SHA-256 (hex) do refresh token; o token em si não é armazenado
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newRefreshToken gera um refresh token opaco aleatório
+func newRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// refreshTTL duração da sessão conforme o "lembrar de mim"
+func refreshTTL(rememberMe bool) time.Duration {
+	if rememberMe {
+		return RememberMeRefreshTokenTTL
+	}
+	return RefreshTokenTTL
+}
+
+func truncateString(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
+
+// Create abre uma sessão para o usuário e emite o par de tokens
+func (s *SessionService) Create(user *models.User, rememberMe bool, client SessionClient) (*TokenPair, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	session := models.UserSession{
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		RememberMe:       rememberMe,
+		UserAgent:        truncateString(client.UserAgent, 500),
+		IPAddress:        truncateString(client.IP, 64),
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(refreshTTL(rememberMe)),
+	}
+	if err := config.DB.Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	return s.issue(user, session.ID, refreshToken)
+}
+
+// Refresh troca o refresh token por um novo par (rotação). Reapresentar um
+// refresh token já rotacionado indica roubo: a sessão inteira é revogada.
+func (s *SessionService) Refresh(refreshToken string, client SessionClient) (*TokenPair, error) {
+	if refreshToken == "" {
+		return nil, ErrSessionInvalid
+	}
+	hash := hashToken(refreshToken)
+
+	var session models.UserSession
+	if err := config.DB.Where("refresh_token_hash = ?", hash).First(&session).Error; err != nil {
+		var reused models.UserSession
+		if config.DB.Where("previous_token_hash = ? AND revoked_at IS NULL", hash).First(&reused).Error == nil {
+			s.revokeWhere(config.DB.Where("id = ?", reused.ID), models.SessionRevokedTokenReuse)
+			log.Printf("⚠️ Reuso de refresh token detectado (usuário %s, sessão %s)", reused.UserID, reused.ID)
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, ErrSessionInvalid
+	}
+
+	now := s.now()
+	if !session.Active(now) {
+		return nil, ErrSessionInvalid
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", session.UserID).Error; err != nil {
+		s.revokeWhere(config.DB.Where("id = ?", session.ID), models.SessionRevokedUserDeleted)
+		return nil, ErrSessionInvalid
+	}
+
+	newToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Atualização condicional: duas renovações simultâneas com o mesmo token não geram dois pares
+	result := config.DB.Model(&models.UserSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, hash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  hashToken(newToken),
+			"previous_token_hash": hash,
+			"last_used_at":        now,
+			"ip_address":          truncateString(client.IP, 64),
+			"user_agent":          truncateString(client.UserAgent, 500),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrSessionInvalid
+	}
+
+	return s.issue(&user, session.ID, newToken)
+}
+
+// issue gera o access token da sessão com o papel atual do usuário
+func (s *SessionService) issue(user *models.User, sessionID, refreshToken string) (*TokenPair, error) {
+	accessToken, err := config.GenerateAccessToken(user.ID, user.Email, user.Role, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(config.AccessTokenTTL.Seconds()),
+		SessionID:    sessionID,
+	}, nil
+}
+
+// IsActive verifica se a sessão do access token ainda vale. Com Redis consulta
+// apenas a denylist; sem Redis (ou se ele falhar) consulta a tabela de sessões, assim
+// como para as sessões cuja inclusão na denylist falhou.
+func (s *SessionService) IsActive(sessionID string) bool {
+	if sessionID == "" {
+		return false
+	}
+
+	if config.RedisEnabled && config.RedisClient != nil && !s.isUndenied(sessionID) {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		exists, err := config.RedisClient.Exists(ctx, PrefixRevokedSession+sessionID).Result()
+		if err == nil {
+			return exists == 0
+		}
+	}
+
+	var count int64
+	config.DB.Model(&models.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, s.now()).
+		Count(&count)
+	return count > 0
+}
+
+// ActiveSessions lista as sessões ativas do usuário (mais recentes primeiro)
+func (s *SessionService) ActiveSessions(userID string) ([]models.UserSession, error) {
+	sessions := []models.UserSession{}
+	err := config.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, s.now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Revoke encerra uma sessão do usuário
+func (s *SessionService) Revoke(userID, sessionID, reason string) error {
+	revoked, err := s.revokeWhere(config.DB.Where("id = ? AND user_id = ?", sessionID, userID), reason)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return ErrSessionInvalid
+	}
+	return nil
+}
+
+// RevokeAll encerra todas as sessões do usuário, exceto exceptSessionID (opcional)
+func (s *SessionService) RevokeAll(userID, reason, exceptSessionID string) (int64, error) {
+	query := config.DB.Where("user_id = ?", userID)
+	if exceptSessionID != "" {
+		query = query.Where("id <> ?", exceptSessionID)
+	}
+	return s.revokeWhere(query, reason)
+}
+
+// revokeWhere marca como revogadas as sessões ativas do filtro e as coloca na denylist
+func (s *SessionService) revokeWhere(query *gorm.DB, reason string) (int64, error) {
+	var ids []string
+	if err := query.Model(&models.UserSession{}).Where("revoked_at IS NULL").Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	now := s.now()
+	result := config.DB.Model(&models.UserSession{}).
+		Where("id IN ? AND revoked_at IS NULL", ids).
+		Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": reason})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	if config.RedisEnabled && config.RedisClient != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		for _, id := range ids {
+			if err := config.RedisClient.Set(ctx, PrefixRevokedSession+id, reason, config.AccessTokenTTL).Err(); err != nil {
+				log.Printf("⚠️ Erro ao incluir a sessão %s na denylist (consultando o banco): %v", id, err)
+				s.markUndenied(id, now.Add(config.AccessTokenTTL))
+			}
+		}
+	}
+
+	return result.RowsAffected, nil
+}
+
+// markUndenied faz IsActive consultar o banco para a sessão revogada fora da denylist,
+// até o último access token dela expirar
+func (s *SessionService) markUndenied(sessionID string, until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.undenied[sessionID] = until
+}
+
+// isUndenied indica se a revogação da sessão não chegou ao Redis (descarta as vencidas)
+func (s *SessionService) isUndenied(sessionID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.undenied) == 0 {
+		return false
+	}
+
+	now := s.now()
+	for id, until := range s.undenied {
+		if !now.Before(until) {
+			delete(s.undenied, id)
+		}
+	}
+	_, ok := s.undenied[sessionID]
+	return ok
+}
+
+// PurgeExpired remove sessões expiradas ou revogadas há mais de retention
+func (s *SessionService) PurgeExpired(retention time.Duration) int64 {
+	cutoff := s.now().Add(-retention)
+	result := config.DB.
+		Where("expires_at < ? OR (revoked_at IS NOT NULL AND revoked_at < ?)", cutoff, cutoff).
+		Delete(&models.UserSession{})
+	return result.RowsAffected
+}
+
+// StartCleanupWorker remove periodicamente as sessões antigas
+func (s *SessionService) StartCleanupWorker(ctx context.Context, interval, retention time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if removed := s.PurgeExpired(retention); removed > 0 {
+					log.Printf("🧹 %d sessões expiradas removidas", removed)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshTokenGeneration(t *testing.T) {
+	first, err := newRefreshToken()
+	require.NoError(t, err)
+	second, err := newRefreshToken()
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+	assert.Len(t, first, 43) // 32 bytes em base64url sem padding
+
+	// Apenas o hash é armazenado: determinístico e diferente do token
+	assert.Equal(t, hashToken(first), hashToken(first))
+	assert.NotEqual(t, first, hashToken(first))
+	assert.Len(t, hashToken(first), 64)
+}
+
+func TestRefreshTTL(t *testing.T) {
+	assert.Equal(t, 24*time.Hour, refreshTTL(false))
+	assert.Equal(t, 30*24*time.Hour, refreshTTL(true))
+}
+
+func TestUserSessionActive(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	session := models.UserSession{ExpiresAt: now.Add(time.Hour)}
+	assert.True(t, session.Active(now))
+	assert.False(t, session.Active(now.Add(2*time.Hour)))
+
+	revokedAt := now.Add(-time.Minute)
+	session.RevokedAt = &revokedAt
+	assert.False(t, session.Active(now))
+}
+
+func TestUndeniedSessionFallsBackToDatabase(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	s := NewSessionService()
+	s.now = func() time.Time { return now }
+	assert.False(t, s.isUndenied("sessao"))
+
+	// Falha no Redis ao revogar: a sessão é conferida no banco até o access token expirar
+	s.markUndenied("sessao", now.Add(15*time.Minute))
+	assert.True(t, s.isUndenied("sessao"))
+	assert.False(t, s.isUndenied("outra"))
+
+	now = now.Add(15 * time.Minute)
+	assert.False(t, s.isUndenied("sessao"))
+	assert.Empty(t, s.undenied)
+}
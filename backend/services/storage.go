@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frappyou/backend/config"
+)
+
+// ==================== ARMAZENAMENTO DE ARQUIVOS ====================
+
+var (
+	ErrObjectNotFound     = errors.New("arquivo não encontrado no armazenamento")
+	ErrInvalidObjectKey   = errors.New("caminho de arquivo inválido")
+	ErrSignedURLExpired   = errors.New("link de download expirado")
+	ErrSignedURLSignature = errors.New("link de download inválido")
+)
+
+// ObjectStorage armazenamento dos arquivos enviados (documentos, vídeos e imagens dos
+// cursos). As chaves são caminhos relativos: "documents/<usuário>/<arquivo>",
+// "videos/<arquivo>" e "images/<arquivo>".
+type ObjectStorage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get abre o arquivo para leitura (ErrObjectNotFound se não existir)
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	// PresignGet URL direta e temporária para o arquivo; vazia quando o backend não
+	// oferece acesso direto e o arquivo é entregue pela própria API
+	PresignGet(key string, ttl time.Duration) (string, error)
+	Name() string
+}
+
+// Storage backend em uso (STORAGE_BACKEND=local|s3)
+var Storage = newStorageFromEnv()
+
+func newStorageFromEnv() ObjectStorage {
+	switch strings.ToLower(os.Getenv("STORAGE_BACKEND")) {
+	case "", "local":
+		return NewLocalStorage(envOrDefault("STORAGE_LOCAL_DIR", "./uploads"))
+	case "s3":
+		storage, err := NewS3Storage(S3ConfigFromEnv())
+		if err != nil {
+			panic("ERRO CRÍTICO: armazenamento S3 mal configurado: " + err.Error())
+		}
+		return storage
+	default:
+		panic("ERRO CRÍTICO: STORAGE_BACKEND deve ser local ou s3")
+	}
+}
+
+// cleanObjectKey valida a chave: caminho relativo, sem subir de diretório
+func cleanObjectKey(key string) (string, error) {
+	key = strings.TrimPrefix(filepath.ToSlash(key), "/")
+	cleaned := path.Clean(key)
+	if key == "" || cleaned == "." || cleaned != key || strings.HasPrefix(cleaned, "../") || cleaned == ".." {
+		return "", ErrInvalidObjectKey
+	}
+	return cleaned, nil
+}
+
+// ==================== REFERÊNCIAS GRAVADAS NO BANCO ====================
+
+const uploadsPrefix = "/uploads/"
+
+// UploadKey chave de armazenamento de uma referência gravada no banco: aceita a URL
+// pública ("/uploads/videos/x.mp4", com ou sem assinatura), os caminhos locais legados
+// ("uploads/documents/u/x.pdf", "./uploads/documents/u/x.pdf") ou a própria chave
+func UploadKey(ref string) string {
+	ref = filepath.ToSlash(ref)
+	if i := strings.IndexByte(ref, '?'); i >= 0 {
+		ref = ref[:i]
+	}
+	ref = strings.TrimPrefix(strings.TrimPrefix(ref, "."), "/")
+	return strings.TrimPrefix(ref, "uploads/")
+}
+
+// UploadRef referência gravada no banco para mídia enviada ("/uploads/<chave>").
+// URLs externas (YouTube, Vimeo) e URLs assinadas devolvidas à tela voltam sem alteração
+// ou sem a assinatura.
+func UploadRef(ref string) string {
+	if !strings.HasPrefix(ref, uploadsPrefix) {
+		return ref
+	}
+	return uploadsPrefix + UploadKey(ref)
+}
+
+// ==================== URLS ASSINADAS ====================
+
+// Validade dos links entregues ao navegador
+const (
+	MediaURLTTL    = 6 * time.Hour   // Vídeos das lições (tempo para assistir com pausas)
+	DocumentURLTTL = 5 * time.Minute // Download de documentos pessoais
+)
+
+// urlSigner assina os links temporários entregues ao navegador: arquivos em
+// /uploads/<chave> e downloads de documentos em /api/files/documents/<id>
+type urlSigner struct {
+	key []byte
+	now func() time.Time
+}
+
+var signedURLs = newURLSigner()
+
+// urlSigningLabel rótulo fixo da derivação; alterá-lo invalida os links já entregues
+const urlSigningLabel = "frappyou/signed-urls/hmac-sha256/v1"
+
+// newURLSigner usa STORAGE_SIGNING_KEY ou, sem ela, uma chave derivada do JWT_SECRET (HKDF)
+func newURLSigner() *urlSigner {
+	key := []byte(os.Getenv("STORAGE_SIGNING_KEY"))
+	if len(key) == 0 {
+		key = config.DeriveKey(urlSigningLabel)
+	}
+	return &urlSigner{key: key, now: time.Now}
+}
+
+func (s *urlSigner) signature(resource string, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%s\n%d", resource, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// query parâmetros expires e signature do recurso, válidos por ttl
+func (s *urlSigner) query(resource string, ttl time.Duration) string {
+	expires := s.now().Add(ttl).Unix()
+	return fmt.Sprintf("expires=%d&signature=%s", expires, s.signature(resource, expires))
+}
+
+// verify confere a assinatura e a validade do link
+func (s *urlSigner) verify(resource, expires, signature string) error {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(s.signature(resource, exp))) {
+		return ErrSignedURLSignature
+	}
+	if s.now().Unix() > exp {
+		return ErrSignedURLExpired
+	}
+	return nil
+}
+
+// SignedUploadURL link temporário da API para o arquivo (ver handlers.ServeUpload)
+func SignedUploadURL(key string, ttl time.Duration) string {
+	escaped := (&url.URL{Path: key}).EscapedPath()
+	return uploadsPrefix + escaped + "?" + signedURLs.query("uploads/"+key, ttl)
+}
+
+// VerifyUploadURL confere um link gerado por SignedUploadURL
+func VerifyUploadURL(key, expires, signature string) error {
+	return signedURLs.verify("uploads/"+key, expires, signature)
+}
+
+// SignedDocumentURL link temporário para baixar o documento (ver handlers.ServeDocumentFile)
+func SignedDocumentURL(documentID string, ttl time.Duration) string {
+	return "/api/files/documents/" + url.PathEscape(documentID) + "?" + signedURLs.query("documents/"+documentID, ttl)
+}
+
+// VerifyDocumentURL confere um link gerado por SignedDocumentURL
+func VerifyDocumentURL(documentID, expires, signature string) error {
+	return signedURLs.verify("documents/"+documentID, expires, signature)
+}
+
+// SignMediaURL assina a URL de um vídeo enviado; imagens e URLs externas ficam como estão
+func SignMediaURL(ref string) string {
+	key := UploadKey(ref)
+	if !strings.HasPrefix(ref, uploadsPrefix) || !strings.HasPrefix(key, "videos/") {
+		return ref
+	}
+	return SignedUploadURL(key, MediaURLTTL)
+}
+
+// ==================== DISCO LOCAL ====================
+
+// LocalStorage arquivos em um diretório local (padrão ./uploads)
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage armazenamento no diretório informado
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{root: root}
+}
+
+func (s *LocalStorage) Name() string { return "local" }
+
+// Path caminho do arquivo no disco
+func (s *LocalStorage) Path(key string) (string, error) {
+	key, err := cleanObjectKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	target, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	// Grava em arquivo temporário e renomeia, para não deixar o arquivo pela metade
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.Path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(target)
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) Exists(ctx context.Context, key string) (bool, error) {
+	target, err := s.Path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(target)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// PresignGet o disco local não tem acesso direto: o arquivo é servido pela API
+func (s *LocalStorage) PresignGet(key string, ttl time.Duration) (string, error) {
+	return "", nil
+}
+
+// ==================== LEITURA E GRAVAÇÃO ====================
+
+// ReadObject lê o arquivo inteiro
+func ReadObject(ctx context.Context, key string) ([]byte, error) {
+	body, err := Storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== MIGRAÇÃO DO ARMAZENAMENTO ====================
+
+// StorageMigrationResult arquivos copiados para o armazenamento configurado
+type StorageMigrationResult struct {
+	Backend   string   `json:"backend"`
+	Copied    int      `json:"copied"`
+	Skipped   int      `json:"skipped"` // Já existiam no destino
+	Documents int      `json:"documents"`
+	Failed    int      `json:"failed"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+func (r *StorageMigrationResult) fail(format string, args ...interface{}) {
+	r.Failed++
+	if len(r.Errors) < maxRotationErrors {
+		r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+	}
+}
+
+// StorageMigrator copia os arquivos de um diretório local (o antigo ./uploads) para o
+// armazenamento configurado e normaliza os caminhos dos documentos para chaves
+type StorageMigrator struct {
+	batchSize int
+}
+
+// StorageMigration instância global da migração do armazenamento
+var StorageMigration = &StorageMigrator{batchSize: 200}
+
+// Run copia os arquivos de sourceDir que ainda não existem no destino. Os originais
+// ficam no disco: remover só depois de conferir a migração. Com dryRun nada é gravado.
+func (m *StorageMigrator) Run(ctx context.Context, sourceDir string, dryRun bool) (*StorageMigrationResult, error) {
+	result := &StorageMigrationResult{Backend: Storage.Name()}
+
+	if local, ok := Storage.(*LocalStorage); ok && samePath(local.root, sourceDir) {
+		// Mesmo diretório: os arquivos já estão no lugar, só os caminhos mudam
+		return result, m.normalizeDocumentPaths(result, dryRun)
+	}
+
+	err := filepath.WalkDir(sourceDir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || strings.HasSuffix(entry.Name(), ".tmp") {
+			return nil // Temporários de gravações interrompidas
+		}
+		rel, err := filepath.Rel(sourceDir, file)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+
+		exists, err := Storage.Exists(ctx, key)
+		if err != nil {
+			result.fail("%s: %v", key, err)
+			return nil
+		}
+		if exists {
+			result.Skipped++
+			return nil
+		}
+		if !dryRun {
+			if err := copyToStorage(ctx, file, key); err != nil {
+				result.fail("%s: %v", key, err)
+				return nil
+			}
+		}
+		result.Copied++
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, m.normalizeDocumentPaths(result, dryRun)
+}
+
+// copyToStorage envia um arquivo local para o armazenamento
+func copyToStorage(ctx context.Context, file, key string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if strings.HasPrefix(key, "documents/") || contentType == "" {
+		contentType = "application/octet-stream" // Documentos podem estar cifrados
+	}
+	return Storage.Put(ctx, key, f, info.Size(), contentType)
+}
+
+// normalizeDocumentPaths troca os caminhos locais gravados nos documentos
+// ("uploads/documents/...") pela chave no armazenamento ("documents/...")
+func (m *StorageMigrator) normalizeDocumentPaths(result *StorageMigrationResult, dryRun bool) error {
+	var chunk []models.Document
+	return config.DB.Select("id", "path").
+		Where("path LIKE ? OR path LIKE ? OR path LIKE ?", "uploads/%", "./uploads/%", "/uploads/%").
+		FindInBatches(&chunk, m.batchSize, func(tx *gorm.DB, _ int) error {
+			for _, doc := range chunk {
+				if !dryRun {
+					if err := config.DB.Model(&models.Document{}).Where("id = ?", doc.ID).
+						UpdateColumn("path", UploadKey(doc.Path)).Error; err != nil {
+						result.fail("Documento %s: %v", doc.ID, err)
+						continue
+					}
+				}
+				result.Documents++
+			}
+			return nil
+		}).Error
+}
+
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
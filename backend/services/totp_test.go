@@ -0,0 +1,106 @@
+package services
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Segredo do apêndice B da RFC 6238 (SHA-1)
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	key, err := decodeTOTPSecret(rfcSecret)
+	require.NoError(t, err)
+
+	// Últimos 6 dígitos dos vetores de 8 dígitos da RFC
+	for unix, expected := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		assert.Equal(t, expected, totpCode(key, totpStep(time.Unix(unix, 0))), "T=%d", unix)
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+
+	step, ok := MatchTOTP(rfcSecret, "081804", at)
+	assert.True(t, ok)
+	assert.Equal(t, totpStep(at), step)
+
+	// Tolerância de um intervalo para relógios dessincronizados
+	_, ok = MatchTOTP(strings.ToLower(rfcSecret), "081804", at.Add(30*time.Second))
+	assert.True(t, ok)
+	_, ok = MatchTOTP(rfcSecret, "081804", at.Add(2*time.Minute))
+	assert.False(t, ok)
+
+	_, ok = MatchTOTP(rfcSecret, "12345", at)
+	assert.False(t, ok)
+}
+
+func TestGenerateTOTPSecretAndURI(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	uri := TOTPProvisioningURI(secret, "ana@fradema.com.br", TwoFactorIssuer)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/FrappYOU:ana@fradema.com.br?"))
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=FrappYOU")
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	code, err := newRecoveryCode()
+	require.NoError(t, err)
+	assert.Len(t, code, recoveryCodeLength+1)
+	assert.True(t, isRecoveryCode(code))
+	assert.True(t, isRecoveryCode(strings.ToUpper(code)))
+	assert.False(t, isRecoveryCode("123456"))
+	assert.Equal(t, normalizeRecoveryCode(code), normalizeRecoveryCode(" "+strings.ToUpper(code)+" "))
+}
+
+func TestTwoFactorRequiredFor(t *testing.T) {
+	policy := models.SecuritySettings{RequireTwoFactorPrivileged: true}
+	assert.True(t, twoFactorRequiredFor(policy, RoleAdmin))
+	assert.True(t, twoFactorRequiredFor(policy, RolePayroll))
+	assert.False(t, twoFactorRequiredFor(policy, RoleHR))
+	assert.False(t, twoFactorRequiredFor(policy, "employee"))
+	assert.False(t, twoFactorRequiredFor(DefaultSecuritySettings(), RoleAdmin))
+}
+
+func TestTwoFactorLimits(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// Abaixo dos limites nada muda
+	assert.Empty(t, twoFactorLimits(models.UserTwoFactor{FailedAttempts: 3, ChallengeID: "c", ChallengeFailures: 3}, now))
+
+	// Desafio esgotado é invalidado sem bloquear o usuário
+	limits := twoFactorLimits(models.UserTwoFactor{FailedAttempts: TwoFactorMaxChallengeAttempts, ChallengeID: "c", ChallengeFailures: TwoFactorMaxChallengeAttempts}, now)
+	assert.Equal(t, map[string]interface{}{"challenge_id": "", "challenge_failures": 0}, limits)
+
+	// Limite do usuário bloqueia e encerra o desafio em aberto
+	limits = twoFactorLimits(models.UserTwoFactor{FailedAttempts: TwoFactorMaxFailedAttempts, ChallengeID: "c", ChallengeFailures: 1}, now)
+	assert.Equal(t, now.Add(TwoFactorLockout), limits["locked_until"])
+	assert.Equal(t, 0, limits["failed_attempts"])
+	assert.Equal(t, "", limits["challenge_id"])
+
+	// Sem desafio, o bloqueio não mexe nele
+	limits = twoFactorLimits(models.UserTwoFactor{FailedAttempts: TwoFactorMaxFailedAttempts}, now)
+	assert.NotContains(t, limits, "challenge_id")
+}
+
+func TestTwoFactorLocked(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	until := now.Add(time.Minute)
+	assert.False(t, twoFactorLocked(models.UserTwoFactor{}, now))
+	assert.True(t, twoFactorLocked(models.UserTwoFactor{LockedUntil: &until}, now))
+	assert.False(t, twoFactorLocked(models.UserTwoFactor{LockedUntil: &until}, until))
+}
@@ -0,0 +1,463 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ==================== Autenticação em dois fatores ====================
+
+var (
+	ErrTwoFactorInvalidCode    = errors.New("código de verificação inválido")
+	ErrTwoFactorNotEnabled     = errors.New("autenticação em dois fatores não está ativada")
+	ErrTwoFactorAlreadyEnabled = errors.New("autenticação em dois fatores já está ativada")
+	ErrTwoFactorMandatory      = errors.New("autenticação em dois fatores é obrigatória para o seu perfil")
+	ErrTwoFactorLocked         = errors.New("muitos códigos inválidos; aguarde alguns minutos e tente novamente")
+)
+
+const (
+	TwoFactorIssuer   = "FrappYOU"
+	RecoveryCodeCount = 10
+
+	recoveryCodeAlphabet = "abcdefghijkmnpqrstuvwxyz23456789" // 32 símbolos, sem l/o/0/1
+	recoveryCodeLength   = 10
+
+	// Limites contra força bruta do código (6 dígitos)
+	TwoFactorMaxChallengeAttempts = 5                // Códigos inválidos por desafio; depois exige novo login com senha
+	TwoFactorMaxFailedAttempts    = 10               // Códigos inválidos seguidos do usuário antes do bloqueio
+	TwoFactorLockout              = 15 * time.Minute // Duração do bloqueio
+)
+
+// TwoFactorEnrollment dados para cadastrar o autenticador (QR code ou digitação manual)
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// TwoFactorChallenge resposta do login quando a senha confere mas falta o código TOTP
+type TwoFactorChallenge struct {
+	ChallengeToken string               `json:"challenge_token"`
+	SetupRequired  bool                 `json:"setup_required"`       // Papel exige 2FA e o usuário ainda não cadastrou
+	Enrollment     *TwoFactorEnrollment `json:"enrollment,omitempty"` // Presente quando SetupRequired
+}
+
+// TwoFactorStatus situação do 2FA do usuário
+type TwoFactorStatus struct {
+	Enabled                bool  `json:"enabled"`
+	Pending                bool  `json:"pending"`  // Cadastro iniciado, aguardando o primeiro código
+	Required               bool  `json:"required"` // Exigido pela política de segurança
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorService cadastro, verificação e política de 2FA
+type TwoFactorService struct {
+	now func() time.Time
+}
+
+// TwoFactor instância usada pelo login (REST e GraphQL) e pelas rotas de conta
+var TwoFactor = NewTwoFactorService()
+
+// NewTwoFactorService cria o serviço de 2FA
+func NewTwoFactorService() *TwoFactorService {
+	return &TwoFactorService{now: time.Now}
+}
+
+// DefaultSecuritySettings política padrão: 2FA opcional para todos
+func DefaultSecuritySettings() models.SecuritySettings {
+	return models.SecuritySettings{RequireTwoFactorPrivileged: false}
+}
+
+// LoadSecuritySettings carrega a política de segurança, usando o padrão se não houver registro
+func LoadSecuritySettings() models.SecuritySettings {
+	var settings models.SecuritySettings
+	if err := config.DB.First(&settings).Error; err != nil {
+		return DefaultSecuritySettings()
+	}
+	return settings
+}
+
+// twoFactorRequiredFor aplica a política ao papel (admin e folha de pagamento)
+func twoFactorRequiredFor(settings models.SecuritySettings, role string) bool {
+	if !settings.RequireTwoFactorPrivileged {
+		return false
+	}
+	role, _ = NormalizeRole(role)
+	return role == RoleAdmin || role == RolePayroll
+}
+
+// Required indica se a política exige 2FA para o papel
+func (s *TwoFactorService) Required(role string) bool {
+	return twoFactorRequiredFor(LoadSecuritySettings(), role)
+}
+
+func (s *TwoFactorService) load(userID string) (*models.UserTwoFactor, error) {
+	var tf models.UserTwoFactor
+	if err := config.DB.Where("user_id = ?", userID).First(&tf).Error; err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+// Enabled indica se o usuário concluiu o cadastro do 2FA
+func (s *TwoFactorService) Enabled(userID string) bool {
+	tf, err := s.load(userID)
+	return err == nil && tf.Enabled
+}
+
+// Status situação do 2FA do usuário
+func (s *TwoFactorService) Status(user *models.User) TwoFactorStatus {
+	status := TwoFactorStatus{Required: s.Required(user.Role)}
+	if tf, err := s.load(user.ID); err == nil {
+		status.Enabled = tf.Enabled
+		status.Pending = !tf.Enabled
+	}
+	if status.Enabled {
+		config.DB.Model(&models.TwoFactorRecoveryCode{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Count(&status.RecoveryCodesRemaining)
+	}
+	return status
+}
+
+// BeginEnrollment inicia (ou retoma) o cadastro do autenticador. Um cadastro
+// pendente mantém o mesmo segredo, para não invalidar um QR code já lido.
+func (s *TwoFactorService) BeginEnrollment(user *models.User) (*TwoFactorEnrollment, error) {
+	tf, err := s.load(user.ID)
+	switch {
+	case err == nil && tf.Enabled:
+		return nil, ErrTwoFactorAlreadyEnabled
+	case err == nil:
+		// Cadastro pendente: reaproveita o segredo
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		secret, err := GenerateTOTPSecret()
+		if err != nil {
+			return nil, err
+		}
+		tf = &models.UserTwoFactor{UserID: user.ID, Secret: secret}
+		if err := config.DB.Create(tf).Error; err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	return &TwoFactorEnrollment{
+		Secret:     tf.Secret,
+		OTPAuthURL: TOTPProvisioningURI(tf.Secret, user.Email, TwoFactorIssuer),
+	}, nil
+}
+
+// ConfirmEnrollment ativa o 2FA com o primeiro código do autenticador e
+// devolve os códigos de recuperação (exibidos uma única vez)
+func (s *TwoFactorService) ConfirmEnrollment(userID, code string) ([]string, error) {
+	tf, err := s.load(userID)
+	if err != nil {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	var codes []string
+	err = s.attempt(tf, "", func() error {
+		codes, err = s.confirmEnrollment(tf, code)
+		return err
+	})
+	return codes, err
+}
+
+func (s *TwoFactorService) confirmEnrollment(tf *models.UserTwoFactor, code string) ([]string, error) {
+	now := s.now()
+	step, ok := MatchTOTP(tf.Secret, code, now)
+	if !ok {
+		return nil, ErrTwoFactorInvalidCode
+	}
+
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(tf).Updates(map[string]interface{}{
+			"enabled":        true,
+			"confirmed_at":   now,
+			"last_used_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, tf.UserID)
+		return err
+	})
+	return codes, err
+}
+
+// Verify valida um código TOTP ou de recuperação do usuário
+func (s *TwoFactorService) Verify(userID, code string) error {
+	tf, err := s.load(userID)
+	if err != nil || !tf.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+	return s.attempt(tf, "", func() error { return s.verifyCode(tf, code) })
+}
+
+func (s *TwoFactorService) verifyCode(tf *models.UserTwoFactor, code string) error {
+	if isRecoveryCode(code) {
+		return s.useRecoveryCode(tf.UserID, code)
+	}
+
+	step, ok := MatchTOTP(tf.Secret, code, s.now())
+	if !ok || step <= tf.LastUsedStep {
+		return ErrTwoFactorInvalidCode
+	}
+
+	// Atualização condicional: o mesmo código não é aceito duas vezes
+	result := config.DB.Model(&models.UserTwoFactor{}).
+		Where("id = ? AND last_used_step < ?", tf.ID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTwoFactorInvalidCode
+	}
+	return nil
+}
+
+func (s *TwoFactorService) useRecoveryCode(userID, code string) error {
+	result := config.DB.Model(&models.TwoFactorRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashToken(normalizeRecoveryCode(code))).
+		Update("used_at", s.now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTwoFactorInvalidCode
+	}
+	return nil
+}
+
+// attempt aplica os limites de tentativas a uma verificação de código: recusa enquanto o
+// usuário estiver bloqueado e conta os códigos inválidos (no usuário e no desafio)
+func (s *TwoFactorService) attempt(tf *models.UserTwoFactor, challengeID string, verify func() error) error {
+	if twoFactorLocked(*tf, s.now()) {
+		return ErrTwoFactorLocked
+	}
+
+	err := verify()
+	if errors.Is(err, ErrTwoFactorInvalidCode) {
+		if recordErr := s.recordFailure(tf.ID, challengeID != ""); recordErr != nil {
+			return recordErr
+		}
+		return err
+	}
+	if err == nil && tf.FailedAttempts > 0 {
+		config.DB.Model(&models.UserTwoFactor{}).Where("id = ?", tf.ID).Update("failed_attempts", 0)
+	}
+	return err
+}
+
+// recordFailure conta um código inválido (incremento no banco, para tentativas
+// simultâneas) e aplica o bloqueio ou a invalidação do desafio ao atingir o limite
+func (s *TwoFactorService) recordFailure(id string, inChallenge bool) error {
+	updates := map[string]interface{}{"failed_attempts": gorm.Expr("failed_attempts + 1")}
+	if inChallenge {
+		updates["challenge_failures"] = gorm.Expr("challenge_failures + 1")
+	}
+	if err := config.DB.Model(&models.UserTwoFactor{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	var current models.UserTwoFactor
+	if err := config.DB.Select("id", "failed_attempts", "challenge_id", "challenge_failures").First(&current, "id = ?", id).Error; err != nil {
+		return err
+	}
+	if limits := twoFactorLimits(current, s.now()); len(limits) > 0 {
+		return config.DB.Model(&models.UserTwoFactor{}).Where("id = ?", id).Updates(limits).Error
+	}
+	return nil
+}
+
+// twoFactorLocked indica se as verificações do usuário estão bloqueadas
+func twoFactorLocked(tf models.UserTwoFactor, now time.Time) bool {
+	return tf.LockedUntil != nil && now.Before(*tf.LockedUntil)
+}
+
+// twoFactorLimits alterações quando os contadores atingem os limites: o bloqueio do
+// usuário também encerra o desafio em aberto
+func twoFactorLimits(tf models.UserTwoFactor, now time.Time) map[string]interface{} {
+	updates := map[string]interface{}{}
+	if tf.FailedAttempts >= TwoFactorMaxFailedAttempts {
+		updates["locked_until"] = now.Add(TwoFactorLockout)
+		updates["failed_attempts"] = 0
+	}
+	if tf.ChallengeID != "" && (tf.ChallengeFailures >= TwoFactorMaxChallengeAttempts || len(updates) > 0) {
+		updates["challenge_id"] = ""
+		updates["challenge_failures"] = 0
+	}
+	return updates
+}
+
+// RegenerateRecoveryCodes invalida os códigos de recuperação e gera novos
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID, code string) ([]string, error) {
+	if err := s.Verify(userID, code); err != nil {
+		return nil, err
+	}
+	return replaceRecoveryCodes(config.DB, userID)
+}
+
+// Disable desativa o 2FA (exige um código válido e não vale para papéis obrigatórios)
+func (s *TwoFactorService) Disable(user *models.User, code string) error {
+	if s.Required(user.Role) {
+		return ErrTwoFactorMandatory
+	}
+	if err := s.Verify(user.ID, code); err != nil {
+		return err
+	}
+	return s.Reset(user.ID)
+}
+
+// Reset remove o 2FA do usuário (ex: admin atendendo perda do celular)
+func (s *TwoFactorService) Reset(userID string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserTwoFactor{}).Error
+	})
+}
+
+// ==================== Integração com o login ====================
+
+// LoginChallenge decide se o login com senha correta precisa do segundo fator.
+// Retorna nil quando a sessão pode ser aberta diretamente.
+func (s *TwoFactorService) LoginChallenge(user *models.User, rememberMe bool) (*TwoFactorChallenge, error) {
+	challenge := &TwoFactorChallenge{}
+
+	if !s.Enabled(user.ID) {
+		if !s.Required(user.Role) {
+			return nil, nil
+		}
+		enrollment, err := s.BeginEnrollment(user)
+		if err != nil {
+			return nil, err
+		}
+		challenge.SetupRequired = true
+		challenge.Enrollment = enrollment
+	}
+
+	// Só o desafio mais recente vale, com contagem de tentativas própria
+	challengeID := uuid.New().String()
+	if err := config.DB.Model(&models.UserTwoFactor{}).Where("user_id = ?", user.ID).
+		Updates(map[string]interface{}{"challenge_id": challengeID, "challenge_failures": 0}).Error; err != nil {
+		return nil, err
+	}
+
+	token, err := config.GenerateTwoFactorChallenge(user.ID, challengeID, rememberMe)
+	if err != nil {
+		return nil, err
+	}
+	challenge.ChallengeToken = token
+	return challenge, nil
+}
+
+// CompleteLogin valida o código do desafio e abre a sessão. Se o desafio era de
+// cadastro obrigatório, ativa o 2FA e devolve os códigos de recuperação. O desafio é
+// de uso único e deixa de valer após TwoFactorMaxChallengeAttempts códigos inválidos.
+func (s *TwoFactorService) CompleteLogin(challengeToken, code string, client SessionClient) (*models.User, *TokenPair, []string, error) {
+	claims, err := config.ValidateTwoFactorChallenge(challengeToken)
+	if err != nil {
+		return nil, nil, nil, ErrSessionInvalid
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", claims.Subject).Error; err != nil {
+		return nil, nil, nil, ErrSessionInvalid
+	}
+
+	tf, err := s.load(user.ID)
+	if err != nil || tf.ChallengeID == "" || tf.ChallengeID != claims.ID {
+		return nil, nil, nil, ErrSessionInvalid
+	}
+
+	var recoveryCodes []string
+	err = s.attempt(tf, claims.ID, func() error {
+		if tf.Enabled {
+			return s.verifyCode(tf, code)
+		}
+		var err error
+		recoveryCodes, err = s.confirmEnrollment(tf, code)
+		return err
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Consome o desafio
+	result := config.DB.Model(&models.UserTwoFactor{}).Where("id = ? AND challenge_id = ?", tf.ID, claims.ID).
+		Updates(map[string]interface{}{"challenge_id": "", "challenge_failures": 0})
+	if result.Error != nil {
+		return nil, nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, nil, ErrSessionInvalid
+	}
+
+	tokens, err := Sessions.Create(&user, claims.RememberMe, client)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return &user, tokens, recoveryCodes, nil
+}
+
+// ==================== Códigos de recuperação ====================
+
+// replaceRecoveryCodes apaga os códigos anteriores e grava novos (somente hash)
+func replaceRecoveryCodes(tx *gorm.DB, userID string) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, RecoveryCodeCount)
+	records := make([]models.TwoFactorRecoveryCode, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.TwoFactorRecoveryCode{UserID: userID, CodeHash: hashToken(normalizeRecoveryCode(code))})
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// newRecoveryCode gera um código no formato xxxxx-xxxxx
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := make([]byte, recoveryCodeLength)
+	for i, b := range buf {
+		code[i] = recoveryCodeAlphabet[b&31]
+	}
+	half := recoveryCodeLength / 2
+	return string(code[:half]) + "-" + string(code[half:]), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// isRecoveryCode diferencia códigos de recuperação dos códigos TOTP (6 dígitos)
+func isRecoveryCode(code string) bool {
+	return len(normalizeRecoveryCode(code)) == recoveryCodeLength
+}
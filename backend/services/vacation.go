@@ -0,0 +1,75 @@
+package services
+
+import (
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+)
+
+// ==================== Regras de Férias ====================
+
+// DefaultVacationSettings retorna as configurações padrão de férias
+func DefaultVacationSettings() models.VacationSettings {
+	return models.VacationSettings{
+		TotalDaysPerYear:      30,
+		MinDaysPerRequest:     5,
+		MaxDaysPerRequest:     30,
+		MaxSplits:             3,
+		MinAdvanceDays:        30,
+		AllowWeekendStart:     false,
+		AllowSellVacation:     true,
+		MaxSellDays:           10,
+		MinSellDays:           1,
+		AllowAbono:            true,
+		MaxAbonoDays:          3,
+		AbonoRequiresApproval: true,
+		PeriodMonths:          12,
+		AllowCarryOver:        false,
+		MaxCarryOverDays:      10,
+		WelcomeMessage:        "Bem-vindo ao sistema de férias! Aqui você pode solicitar, acompanhar e gerenciar suas férias.",
+		RulesMessage:          "• Mínimo de 5 dias por solicitação\n• Máximo de 3 fracionamentos por período\n• Antecedência mínima de 30 dias\n• Férias devem ser tiradas dentro do período aquisitivo",
+	}
+}
+
+// LoadVacationSettings carrega as configurações de férias, usando o padrão se não houver registro
+func LoadVacationSettings() models.VacationSettings {
+	var settings models.VacationSettings
+	if err := config.DB.First(&settings).Error; err != nil {
+		return DefaultVacationSettings()
+	}
+	return settings
+}
+
+// VacationPeriod resultado do cálculo de dias de uma solicitação
+type VacationPeriod struct {
+	TotalDays    int           `json:"total_days"`    // Dias descontados do saldo
+	BusinessDays int           `json:"business_days"` // Dias úteis dentro do período
+	Holidays     []HolidayInfo `json:"holidays"`      // Feriados dentro do período
+	Filial       string        `json:"filial,omitempty"`
+}
+
+// CalculateVacationPeriod calcula os dias de uma ausência usando o calendário de
+// dias úteis do colaborador e valida a data de início das férias (CLT art. 134, §3º)
+func CalculateVacationPeriod(userID string, vacationType models.VacationType, start, end time.Time, settings models.VacationSettings) (*VacationPeriod, error) {
+	wc := LoadWorkCalendar(userID, start, end)
+
+	if vacationType == models.VacationTypeFerias {
+		if err := wc.CheckVacationStart(start, settings.AllowWeekendStart); err != nil {
+			return nil, err
+		}
+	}
+
+	period := &VacationPeriod{
+		TotalDays:    wc.CountAbsenceDays(vacationType, start, end),
+		BusinessDays: wc.CountWorkingDays(start, end),
+		Holidays:     wc.Holidays(start, end),
+		Filial:       wc.Filial,
+	}
+
+	if period.TotalDays == 0 {
+		return nil, ErrAbsenceWithoutWorkingDays
+	}
+
+	return period, nil
+}
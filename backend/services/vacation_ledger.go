@@ -0,0 +1,850 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ==================== Extrato (Ledger) de Férias ====================
+
+// PeriodBalance saldo de um período aquisitivo, derivado dos lançamentos
+type PeriodBalance struct {
+	Period    models.VacationAcquisitionPeriod `json:"period"`
+	Entitled  int                              `json:"entitled_days"`
+	Used      int                              `json:"used_days"`
+	Pending   int                              `json:"pending_days"`
+	Available int                              `json:"available_days"`
+	Abono     int                              `json:"abono_days"` // Abonos lançados no período (não afetam o saldo)
+}
+
+// VacationLedgerStatement extrato completo de férias do colaborador
+type VacationLedgerStatement struct {
+	Balance *models.VacationBalance      `json:"balance"`
+	Periods []PeriodBalance              `json:"periods"`
+	Entries []models.VacationLedgerEntry `json:"entries"`
+}
+
+// BalanceAdjustment valores desejados pelo RH/DP para o saldo consolidado
+type BalanceAdjustment struct {
+	TotalDays     *int
+	UsedDays      *int
+	PendingDays   *int
+	AvailableDays *int
+	PeriodStart   *time.Time
+	PeriodEnd     *time.Time
+	AbonoDays     *int // Direito a abonos por período
+	UsedAbono     *int // Abonos já usados no período atual
+	Reason        string
+}
+
+// ledgerAllocation quantidade de dias lançada em um período
+type ledgerAllocation struct {
+	PeriodID string
+	Days     int
+}
+
+// VacationLedger registra e consolida os lançamentos de férias.
+// VacationBalance passa a ser apenas um retrato do extrato, recalculado a cada lançamento.
+type VacationLedger struct {
+	now func() time.Time
+}
+
+// NewVacationLedger cria o serviço de extrato de férias
+func NewVacationLedger() *VacationLedger {
+	return &VacationLedger{now: time.Now}
+}
+
+// Balance retorna o saldo consolidado sem gravar nada. Períodos aquisitivos já iniciados
+// e prazos concessivos vencidos entram no cálculo, mas só são gravados pelas escritas
+// (Sync e demais lançamentos) e pela rotina diária.
+func (l *VacationLedger) Balance(db *gorm.DB, userID string) (*models.VacationBalance, error) {
+	state, err := l.load(db, userID, LoadVacationSettings())
+	if err != nil {
+		return nil, err
+	}
+	return state.balance(), nil
+}
+
+// Sync grava os períodos aquisitivos iniciados, encerra os que passaram do prazo
+// concessivo e atualiza o retrato do saldo, na transação informada
+func (l *VacationLedger) Sync(tx *gorm.DB, userID string) (*models.VacationBalance, error) {
+	return l.run(tx, userID, nil)
+}
+
+// SyncVacation ajusta os lançamentos de uma solicitação de férias ao seu status atual:
+// pendente reserva os dias, aprovada consome, interrompida consome apenas os dias gozados
+// e rejeitada/cancelada libera tudo. Abonos aprovados são lançados à parte e não afetam
+// o saldo de férias. Roda na transação que alterou a solicitação.
+func (l *VacationLedger) SyncVacation(tx *gorm.DB, vacation models.Vacation, actorID string) (*models.VacationBalance, error) {
+	reserved, consumed, abono := 0, 0, 0
+	switch vacation.Type {
+	case models.VacationTypeFerias:
+		switch vacation.Status {
+		case models.VacationStatusPending:
+			reserved = vacation.TotalDays
+		case models.VacationStatusApproved:
+			consumed = vacation.TotalDays
+		case models.VacationStatusInterrupted:
+			consumed = vacation.ActualDays
+		}
+	case models.VacationTypeAbono:
+		if vacation.Status == models.VacationStatusApproved {
+			abono = vacation.TotalDays
+		}
+	}
+
+	return l.run(tx, vacation.UserID, func(state *ledgerState) error {
+		state.syncVacation(vacation.ID, reserved, consumed, abono, actorID)
+		return nil
+	})
+}
+
+// ClearVacation estorna todos os lançamentos de uma solicitação removida
+func (l *VacationLedger) ClearVacation(tx *gorm.DB, vacation models.Vacation, actorID string) (*models.VacationBalance, error) {
+	return l.run(tx, vacation.UserID, func(state *ledgerState) error {
+		state.syncVacation(vacation.ID, 0, 0, 0, actorID)
+		return nil
+	})
+}
+
+// SyncSale ajusta os lançamentos de uma venda de férias (abono pecuniário) ao seu status
+func (l *VacationLedger) SyncSale(tx *gorm.DB, sale models.VacationSellRequest, actorID string) (*models.VacationBalance, error) {
+	desired := 0
+	if sale.Status == models.VacationSellStatusApproved {
+		desired = sale.DaysToSell
+	}
+
+	return l.run(tx, sale.UserID, func(state *ledgerState) error {
+		state.syncSale(sale.ID, desired, actorID)
+		return nil
+	})
+}
+
+// Adjust registra ajustes manuais para que o saldo consolidado atinja os valores informados.
+// Os ajustes são lançados no período aquisitivo mais recente.
+func (l *VacationLedger) Adjust(tx *gorm.DB, userID string, adj BalanceAdjustment, actorID string) (*models.VacationBalance, error) {
+	return l.run(tx, userID, func(state *ledgerState) error {
+		return state.adjust(adj, actorID)
+	})
+}
+
+// Statement retorna o extrato completo de férias do colaborador (somente leitura)
+func (l *VacationLedger) Statement(userID string) (*VacationLedgerStatement, error) {
+	state, err := l.load(config.DB, userID, LoadVacationSettings())
+	if err != nil {
+		return nil, err
+	}
+
+	return &VacationLedgerStatement{
+		Balance: state.balance(),
+		Periods: derivePeriodBalances(state.periods, state.entries),
+		Entries: state.entries,
+	}, nil
+}
+
+// SyncAll grava os períodos iniciados e as expirações de todos os colaboradores
+func (l *VacationLedger) SyncAll() (int, error) {
+	var userIDs []string
+	if err := config.DB.Model(&models.User{}).Pluck("id", &userIDs).Error; err != nil {
+		return 0, err
+	}
+
+	synced := 0
+	for _, userID := range userIDs {
+		if _, err := l.Sync(config.DB, userID); err != nil {
+			log.Printf("⚠️ Férias: erro ao atualizar o extrato de %s: %v", shortID(userID), err)
+			continue
+		}
+		synced++
+	}
+	return synced, nil
+}
+
+// StartWorker atualiza os extratos periodicamente, para o retrato em VacationBalance
+// acompanhar a virada dos períodos mesmo sem novas solicitações
+func (l *VacationLedger) StartWorker(ctx context.Context, interval time.Duration) {
+	if config.DB == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if synced, err := l.SyncAll(); err != nil {
+				log.Printf("⚠️ Férias: erro ao atualizar os extratos: %v", err)
+			} else {
+				log.Printf("🏖️ Férias: %d extratos atualizados", synced)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// run carrega o extrato em dia, aplica a operação e grava os lançamentos e o retrato em
+// VacationBalance. Usa a transação do chamador (savepoint) ou abre uma em config.DB.
+func (l *VacationLedger) run(db *gorm.DB, userID string, op func(state *ledgerState) error) (*models.VacationBalance, error) {
+	var balance *models.VacationBalance
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// O worker diário e as aprovações podem gravar o mesmo extrato ao mesmo tempo:
+		// sem a trava, os dois encerrariam o mesmo período e lançariam a expiração em dobro
+		if err := lockLedger(tx, userID); err != nil {
+			return err
+		}
+
+		state, err := l.load(tx, userID, LoadVacationSettings())
+		if err != nil {
+			return err
+		}
+		if op != nil {
+			if err := op(state); err != nil {
+				return err
+			}
+		}
+		if err := state.save(tx); err != nil {
+			return err
+		}
+
+		balance = state.balance()
+		return tx.Save(balance).Error
+	})
+
+	return balance, err
+}
+
+// lockLedger trava a linha do colaborador até o fim da transação (do chamador, se houver).
+// O SQL Server não aceita SELECT ... FOR UPDATE (clause.Locking), daí a dica UPDLOCK.
+func lockLedger(tx *gorm.DB, userID string) error {
+	return tx.Exec("SELECT id FROM users WITH (UPDLOCK, ROWLOCK) WHERE id = ?", userID).Error
+}
+
+// ==================== ESTADO DO EXTRATO ====================
+
+// ledgerState períodos e lançamentos de um colaborador. Os lançamentos novos ficam só em
+// memória até save: a leitura (Balance) usa o mesmo cálculo sem gravar nada.
+type ledgerState struct {
+	userID   string
+	today    time.Time
+	settings models.VacationSettings
+	snapshot *models.VacationBalance // Retrato gravado (nil se ainda não existe)
+
+	periods []models.VacationAcquisitionPeriod // Do mais antigo ao mais recente
+	entries []models.VacationLedgerEntry       // Em ordem de lançamento
+
+	storedPeriods int             // periods[:storedPeriods] já estão gravados
+	storedEntries int             // entries[:storedEntries] já estão gravados
+	changed       map[string]bool // Períodos gravados que foram alterados (encerrados ou ajustados)
+}
+
+// load lê o extrato do colaborador e planeja o que falta para deixá-lo em dia
+func (l *VacationLedger) load(db *gorm.DB, userID string, settings models.VacationSettings) (*ledgerState, error) {
+	state := &ledgerState{
+		userID:   userID,
+		today:    truncateDay(l.now()),
+		settings: settings,
+		changed:  map[string]bool{},
+	}
+
+	if err := db.Where("user_id = ?", userID).Order("period_start ASC").Find(&state.periods).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&state.entries).Error; err != nil {
+		return nil, err
+	}
+	state.storedPeriods, state.storedEntries = len(state.periods), len(state.entries)
+
+	var snapshot models.VacationBalance
+	err := db.Where("user_id = ?", userID).First(&snapshot).Error
+	if err == nil {
+		state.snapshot = &snapshot
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if len(state.periods) == 0 {
+		if err := state.openInitialPeriod(db); err != nil {
+			return nil, err
+		}
+	}
+	state.openStartedPeriods()
+	state.closeExpiredPeriods()
+	state.migrateAbono()
+	return state, nil
+}
+
+// save grava os períodos e lançamentos planejados
+func (s *ledgerState) save(tx *gorm.DB) error {
+	for i := range s.periods {
+		if i >= s.storedPeriods {
+			if err := tx.Create(&s.periods[i]).Error; err != nil {
+				return err
+			}
+		} else if s.changed[s.periods[i].ID] {
+			if err := tx.Save(&s.periods[i]).Error; err != nil {
+				return err
+			}
+		}
+	}
+	for i := s.storedEntries; i < len(s.entries); i++ {
+		if err := tx.Create(&s.entries[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	s.storedPeriods, s.storedEntries = len(s.periods), len(s.entries)
+	s.changed = map[string]bool{}
+	return nil
+}
+
+// balance retrato do saldo a partir dos períodos ainda abertos
+func (s *ledgerState) balance() *models.VacationBalance {
+	balance := models.VacationBalance{UserID: s.userID, AbonoDays: s.settings.MaxAbonoDays}
+	if s.snapshot != nil {
+		balance = *s.snapshot
+	}
+
+	open := s.openBalances()
+	totals := sumPeriodBalances(open)
+	balance.TotalDays = totals.Entitled
+	balance.UsedDays = totals.Used
+	balance.PendingDays = totals.Pending
+	balance.AvailableDays = totals.Available
+	balance.UsedAbono = 0
+	if len(open) > 0 {
+		current := open[len(open)-1]
+		balance.PeriodStart = current.Period.PeriodStart
+		balance.PeriodEnd = current.Period.PeriodEnd
+		balance.UsedAbono = current.Abono // Abonos contam por período aquisitivo
+	}
+	return &balance
+}
+
+// post acrescenta um lançamento
+func (s *ledgerState) post(entry models.VacationLedgerEntry) {
+	if entry.Days == 0 {
+		return
+	}
+	entry.ID = uuid.New().String()
+	s.entries = append(s.entries, entry)
+}
+
+// openPeriod acrescenta um período com o direito de dias correspondente
+func (s *ledgerState) openPeriod(period models.VacationAcquisitionPeriod, entitled int, description string) {
+	period.ID = uuid.New().String()
+	period.UserID = s.userID
+	s.periods = append(s.periods, period)
+	s.post(newLedgerEntry(s.userID, period.ID, models.VacationLedgerAccrual, entitled, "", description))
+}
+
+// openBalances saldos dos períodos ainda abertos, do mais antigo ao mais recente
+func (s *ledgerState) openBalances() []PeriodBalance {
+	var open []models.VacationAcquisitionPeriod
+	for _, p := range s.periods {
+		if p.ClosedAt == nil {
+			open = append(open, p)
+		}
+	}
+	if len(open) == 0 {
+		return nil
+	}
+	return derivePeriodBalances(open, s.entries)
+}
+
+// entriesOf lançamentos que satisfazem o filtro, em ordem de lançamento
+func (s *ledgerState) entriesOf(match func(e models.VacationLedgerEntry) bool) []models.VacationLedgerEntry {
+	var result []models.VacationLedgerEntry
+	for _, e := range s.entries {
+		if match(e) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// openInitialPeriod cria o primeiro período do colaborador. Se já existir um
+// VacationBalance (controle anterior por contadores), o saldo é migrado para o extrato.
+func (s *ledgerState) openInitialPeriod(db *gorm.DB) error {
+	months := periodMonths(s.settings)
+	balance := s.snapshot
+	migrating := balance != nil && !balance.PeriodStart.IsZero()
+
+	var period models.VacationAcquisitionPeriod
+	entitled := s.settings.TotalDaysPerYear
+
+	if migrating {
+		period.PeriodStart = truncateDay(balance.PeriodStart)
+		period.PeriodEnd = truncateDay(balance.PeriodEnd)
+		if !period.PeriodEnd.After(period.PeriodStart) {
+			period.PeriodEnd = period.PeriodStart.AddDate(0, months, -1)
+		}
+		entitled = balance.TotalDays
+	} else {
+		// Período atual contado a partir da admissão
+		var user models.User
+		if err := db.Select("id", "hire_date", "created_at").First(&user, "id = ?", s.userID).Error; err != nil {
+			return err
+		}
+
+		start := truncateDay(user.CreatedAt)
+		if user.HireDate != nil && !user.HireDate.IsZero() {
+			start = truncateDay(*user.HireDate)
+		}
+		if start.IsZero() || start.After(s.today) {
+			start = s.today
+		}
+		for !start.AddDate(0, months, 0).After(s.today) {
+			start = start.AddDate(0, months, 0)
+		}
+		period.PeriodStart = start
+		period.PeriodEnd = start.AddDate(0, months, -1)
+	}
+	period.ConcessiveEnd = period.PeriodEnd.AddDate(0, months, 0)
+
+	description := "Direito do período aquisitivo"
+	if migrating {
+		description = "Saldo migrado do controle anterior"
+	}
+	s.openPeriod(period, entitled, description)
+	periodID := s.periods[len(s.periods)-1].ID
+
+	// Vincula as férias já marcadas no período aos lançamentos do extrato
+	var vacations []models.Vacation
+	if err := db.Where("user_id = ? AND type = ? AND status IN ? AND start_date >= ?",
+		s.userID, models.VacationTypeFerias,
+		[]models.VacationStatus{models.VacationStatusPending, models.VacationStatusApproved, models.VacationStatusInterrupted},
+		period.PeriodStart).
+		Find(&vacations).Error; err != nil {
+		return err
+	}
+
+	linkedUsed := 0
+	for _, v := range vacations {
+		kind, days := models.VacationLedgerReservation, v.TotalDays
+		switch v.Status {
+		case models.VacationStatusApproved:
+			kind = models.VacationLedgerConsumption
+		case models.VacationStatusInterrupted:
+			kind, days = models.VacationLedgerConsumption, v.ActualDays
+		}
+		if kind == models.VacationLedgerConsumption {
+			linkedUsed += days
+		}
+
+		entry := newLedgerEntry(s.userID, periodID, kind, days, "", "Solicitação migrada do controle anterior")
+		vacationID := v.ID
+		entry.VacationID = &vacationID
+		s.post(entry)
+	}
+
+	// Dias usados registrados apenas no contador antigo
+	if migrating && balance.UsedDays != linkedUsed {
+		s.post(newLedgerEntry(s.userID, periodID, models.VacationLedgerConsumption, balance.UsedDays-linkedUsed, "", "Ajuste de migração (dias usados sem solicitação vinculada)"))
+	}
+	return nil
+}
+
+// openStartedPeriods abre os períodos aquisitivos seguintes que já começaram
+func (s *ledgerState) openStartedPeriods() {
+	months := periodMonths(s.settings)
+	last := s.periods[len(s.periods)-1]
+
+	for s.today.After(last.PeriodEnd) {
+		start := last.PeriodEnd.AddDate(0, 0, 1)
+		next := models.VacationAcquisitionPeriod{
+			PeriodStart: start,
+			PeriodEnd:   start.AddDate(0, months, -1),
+		}
+		next.ConcessiveEnd = next.PeriodEnd.AddDate(0, months, 0)
+		s.openPeriod(next, s.settings.TotalDaysPerYear, "Direito do período aquisitivo")
+		last = s.periods[len(s.periods)-1]
+	}
+}
+
+// closeExpiredPeriods encerra os períodos cujo prazo concessivo terminou. O saldo
+// restante é transferido para o período mais recente (AllowCarryOver, até MaxCarryOverDays)
+// e o excedente é lançado como expirado.
+func (s *ledgerState) closeExpiredPeriods() {
+	open := s.openBalances()
+	if len(open) == 0 {
+		return
+	}
+	newest := open[len(open)-1]
+
+	for _, pb := range open {
+		if !s.today.After(pb.Period.ConcessiveEnd) {
+			continue
+		}
+
+		if remaining := pb.Available; remaining > 0 {
+			carry := 0
+			if s.settings.AllowCarryOver && newest.Period.ID != pb.Period.ID && !s.today.After(newest.Period.ConcessiveEnd) {
+				carry = remaining
+				if carry > s.settings.MaxCarryOverDays {
+					carry = s.settings.MaxCarryOverDays
+				}
+			}
+
+			if carry > 0 {
+				s.post(newLedgerEntry(s.userID, pb.Period.ID, models.VacationLedgerCarryOver, -carry, "", "Saldo transferido para o período seguinte"))
+				s.post(newLedgerEntry(s.userID, newest.Period.ID, models.VacationLedgerCarryOver, carry, "", "Saldo recebido do período anterior"))
+			}
+			s.post(newLedgerEntry(s.userID, pb.Period.ID, models.VacationLedgerExpiry, -(remaining - carry), "", "Dias não gozados até o fim do período concessivo"))
+		}
+
+		for i := range s.periods {
+			if s.periods[i].ID == pb.Period.ID {
+				closedAt := s.today
+				s.periods[i].ClosedAt = &closedAt
+				s.changed[pb.Period.ID] = true
+			}
+		}
+	}
+}
+
+// migrateAbono leva para o extrato os abonos registrados só no contador antigo
+// (VacationBalance.UsedAbono), uma única vez
+func (s *ledgerState) migrateAbono() {
+	if s.snapshot == nil || s.snapshot.UsedAbono <= 0 {
+		return
+	}
+	if len(s.entriesOf(func(e models.VacationLedgerEntry) bool { return e.Kind == models.VacationLedgerAbono })) > 0 {
+		return
+	}
+	open := s.openBalances()
+	if len(open) == 0 {
+		return
+	}
+	s.post(newLedgerEntry(s.userID, open[len(open)-1].Period.ID, models.VacationLedgerAbono, s.snapshot.UsedAbono, "", "Abonos migrados do controle anterior"))
+}
+
+// syncVacation lança a diferença entre os dias reservados/consumidos/abonados por uma
+// solicitação e os valores desejados. Reservas são convertidas em consumo no mesmo
+// período; estornos saem dos períodos mais recentes e novos dias entram nos mais antigos.
+func (s *ledgerState) syncVacation(vacationID string, reserved, consumed, abono int, actorID string) {
+	periods := s.openBalances()
+	entries := s.entriesOf(func(e models.VacationLedgerEntry) bool {
+		return e.VacationID != nil && *e.VacationID == vacationID
+	})
+
+	heldReserved := sumByPeriod(entries, models.VacationLedgerReservation, 1)
+	heldConsumed := sumByPeriod(entries, models.VacationLedgerConsumption, 1)
+	heldAbono := sumByPeriod(entries, models.VacationLedgerAbono, 1)
+
+	post := func(kind models.VacationLedgerKind, allocations []ledgerAllocation, description string) {
+		for _, a := range allocations {
+			entry := newLedgerEntry(s.userID, a.PeriodID, kind, a.Days, actorID, description)
+			id := vacationID
+			entry.VacationID = &id
+			s.post(entry)
+		}
+	}
+
+	resDelta := reserved - totalAllocated(heldReserved)
+	consDelta := consumed - totalAllocated(heldConsumed)
+
+	// Aprovação: converte a reserva em consumo nos mesmos períodos
+	if resDelta < 0 && consDelta > 0 {
+		convert := -resDelta
+		if consDelta < convert {
+			convert = consDelta
+		}
+		moved := releaseDays(heldReserved, convert)
+		post(models.VacationLedgerReservation, negate(moved), "Reserva convertida em férias gozadas")
+		post(models.VacationLedgerConsumption, moved, "Férias aprovadas")
+		heldReserved = subtract(heldReserved, moved)
+		resDelta += convert
+		consDelta -= convert
+	}
+
+	if resDelta < 0 {
+		post(models.VacationLedgerReservation, negate(releaseDays(heldReserved, -resDelta)), "Reserva liberada")
+	}
+	if consDelta < 0 {
+		post(models.VacationLedgerConsumption, negate(releaseDays(heldConsumed, -consDelta)), "Dias de férias estornados")
+	}
+	if resDelta > 0 {
+		post(models.VacationLedgerReservation, allocateDays(periods, resDelta), "Dias reservados para solicitação pendente")
+	}
+	if consDelta > 0 {
+		post(models.VacationLedgerConsumption, allocateDays(periods, consDelta), "Férias aprovadas")
+	}
+
+	// Abonos entram no período aquisitivo atual, sem afetar o saldo de férias
+	abonoDelta := abono - totalAllocated(heldAbono)
+	if abonoDelta < 0 {
+		post(models.VacationLedgerAbono, negate(releaseDays(heldAbono, -abonoDelta)), "Abono estornado")
+	}
+	if abonoDelta > 0 && len(periods) > 0 {
+		post(models.VacationLedgerAbono, []ledgerAllocation{{PeriodID: periods[len(periods)-1].Period.ID, Days: abonoDelta}}, "Abono aprovado")
+	}
+}
+
+// syncSale lança a diferença entre os dias vendidos e os desejados
+func (s *ledgerState) syncSale(saleID string, desired int, actorID string) {
+	periods := s.openBalances()
+	entries := s.entriesOf(func(e models.VacationLedgerEntry) bool {
+		return e.SellRequestID != nil && *e.SellRequestID == saleID
+	})
+
+	held := sumByPeriod(entries, models.VacationLedgerSale, -1)
+	delta := desired - totalAllocated(held)
+
+	var allocations []ledgerAllocation
+	if delta > 0 {
+		allocations = allocateDays(periods, delta)
+	} else if delta < 0 {
+		allocations = negate(releaseDays(held, -delta))
+	}
+
+	for _, a := range allocations {
+		entry := newLedgerEntry(s.userID, a.PeriodID, models.VacationLedgerSale, -a.Days, actorID, "Venda de férias (abono pecuniário)")
+		id := saleID
+		entry.SellRequestID = &id
+		s.post(entry)
+	}
+}
+
+// adjust lança os ajustes manuais no período aquisitivo mais recente
+func (s *ledgerState) adjust(adj BalanceAdjustment, actorID string) error {
+	periods := s.openBalances()
+	if len(periods) == 0 {
+		return errors.New("nenhum período aquisitivo aberto")
+	}
+	current := periods[len(periods)-1]
+
+	if adj.PeriodStart != nil || adj.PeriodEnd != nil {
+		for i := range s.periods {
+			if s.periods[i].ID != current.Period.ID {
+				continue
+			}
+			if adj.PeriodStart != nil {
+				s.periods[i].PeriodStart = truncateDay(*adj.PeriodStart)
+			}
+			if adj.PeriodEnd != nil {
+				s.periods[i].PeriodEnd = truncateDay(*adj.PeriodEnd)
+			}
+			s.periods[i].ConcessiveEnd = s.periods[i].PeriodEnd.AddDate(0, periodMonths(s.settings), 0)
+			s.changed[current.Period.ID] = true
+		}
+	}
+
+	totals := sumPeriodBalances(periods)
+	reason := adj.Reason
+	if reason == "" {
+		reason = "Ajuste manual de saldo"
+	}
+	post := func(kind models.VacationLedgerKind, days int) {
+		s.post(newLedgerEntry(s.userID, current.Period.ID, kind, days, actorID, reason))
+	}
+
+	if adj.TotalDays != nil {
+		post(models.VacationLedgerAdjustment, *adj.TotalDays-totals.Entitled)
+		totals.Entitled = *adj.TotalDays
+	}
+	if adj.UsedDays != nil {
+		post(models.VacationLedgerConsumption, *adj.UsedDays-totals.Used)
+		totals.Used = *adj.UsedDays
+	}
+	if adj.PendingDays != nil {
+		post(models.VacationLedgerReservation, *adj.PendingDays-totals.Pending)
+		totals.Pending = *adj.PendingDays
+	}
+	if adj.AvailableDays != nil {
+		available := totals.Entitled - totals.Used - totals.Pending
+		post(models.VacationLedgerAdjustment, *adj.AvailableDays-available)
+	}
+	if adj.UsedAbono != nil {
+		post(models.VacationLedgerAbono, *adj.UsedAbono-current.Abono)
+	}
+
+	// Direito a abonos é configuração do colaborador, guardada no retrato
+	if adj.AbonoDays != nil {
+		if s.snapshot == nil {
+			s.snapshot = &models.VacationBalance{UserID: s.userID}
+		}
+		s.snapshot.AbonoDays = *adj.AbonoDays
+	}
+	return nil
+}
+
+// derivePeriodBalances soma os lançamentos de cada período
+func derivePeriodBalances(periods []models.VacationAcquisitionPeriod, entries []models.VacationLedgerEntry) []PeriodBalance {
+	index := make(map[string]int, len(periods))
+	result := make([]PeriodBalance, len(periods))
+	for i, p := range periods {
+		index[p.ID] = i
+		result[i].Period = p
+	}
+
+	for _, e := range entries {
+		i, ok := index[e.PeriodID]
+		if !ok {
+			continue
+		}
+		switch e.Kind {
+		case models.VacationLedgerReservation:
+			result[i].Pending += e.Days
+		case models.VacationLedgerConsumption:
+			result[i].Used += e.Days
+		case models.VacationLedgerAbono:
+			result[i].Abono += e.Days
+		default:
+			result[i].Entitled += e.Days
+		}
+	}
+
+	for i := range result {
+		result[i].Available = result[i].Entitled - result[i].Used - result[i].Pending
+	}
+	return result
+}
+
+// sumPeriodBalances consolida os saldos de vários períodos
+func sumPeriodBalances(periods []PeriodBalance) PeriodBalance {
+	var total PeriodBalance
+	for _, p := range periods {
+		total.Entitled += p.Entitled
+		total.Used += p.Used
+		total.Pending += p.Pending
+		total.Available += p.Available
+	}
+	return total
+}
+
+// allocateDays distribui os dias pelos períodos mais antigos primeiro (FIFO), respeitando
+// o disponível. O excedente fica no período mais recente (férias antecipadas).
+func allocateDays(periods []PeriodBalance, days int) []ledgerAllocation {
+	if days <= 0 || len(periods) == 0 {
+		return nil
+	}
+
+	var allocations []ledgerAllocation
+	for _, p := range periods {
+		if days == 0 {
+			break
+		}
+		if p.Available <= 0 {
+			continue
+		}
+		take := p.Available
+		if take > days {
+			take = days
+		}
+		allocations = append(allocations, ledgerAllocation{PeriodID: p.Period.ID, Days: take})
+		days -= take
+	}
+
+	if days > 0 {
+		newest := periods[len(periods)-1].Period.ID
+		if n := len(allocations); n > 0 && allocations[n-1].PeriodID == newest {
+			allocations[n-1].Days += days
+		} else {
+			allocations = append(allocations, ledgerAllocation{PeriodID: newest, Days: days})
+		}
+	}
+	return allocations
+}
+
+// releaseDays retira dias das alocações existentes, começando pela mais recente (LIFO)
+func releaseDays(held []ledgerAllocation, days int) []ledgerAllocation {
+	var released []ledgerAllocation
+	for i := len(held) - 1; i >= 0 && days > 0; i-- {
+		if held[i].Days <= 0 {
+			continue
+		}
+		take := held[i].Days
+		if take > days {
+			take = days
+		}
+		released = append(released, ledgerAllocation{PeriodID: held[i].PeriodID, Days: take})
+		days -= take
+	}
+	return released
+}
+
+// sumByPeriod soma os lançamentos de um tipo por período, na ordem em que os períodos
+// aparecem; sign inverte o sinal para tipos lançados como negativos (venda)
+func sumByPeriod(entries []models.VacationLedgerEntry, kind models.VacationLedgerKind, sign int) []ledgerAllocation {
+	totals := map[string]int{}
+	var order []string
+	for _, e := range entries {
+		if e.Kind != kind {
+			continue
+		}
+		if _, ok := totals[e.PeriodID]; !ok {
+			order = append(order, e.PeriodID)
+		}
+		totals[e.PeriodID] += e.Days * sign
+	}
+
+	result := make([]ledgerAllocation, 0, len(order))
+	for _, id := range order {
+		result = append(result, ledgerAllocation{PeriodID: id, Days: totals[id]})
+	}
+	return result
+}
+
+func totalAllocated(allocations []ledgerAllocation) int {
+	total := 0
+	for _, a := range allocations {
+		total += a.Days
+	}
+	return total
+}
+
+func negate(allocations []ledgerAllocation) []ledgerAllocation {
+	result := make([]ledgerAllocation, len(allocations))
+	for i, a := range allocations {
+		result[i] = ledgerAllocation{PeriodID: a.PeriodID, Days: -a.Days}
+	}
+	return result
+}
+
+func subtract(held, taken []ledgerAllocation) []ledgerAllocation {
+	byPeriod := map[string]int{}
+	for _, t := range taken {
+		byPeriod[t.PeriodID] += t.Days
+	}
+	result := make([]ledgerAllocation, len(held))
+	for i, h := range held {
+		result[i] = ledgerAllocation{PeriodID: h.PeriodID, Days: h.Days - byPeriod[h.PeriodID]}
+	}
+	return result
+}
+
+// newLedgerEntry monta um lançamento; actorID vazio indica lançamento automático
+func newLedgerEntry(userID, periodID string, kind models.VacationLedgerKind, days int, actorID, description string) models.VacationLedgerEntry {
+	entry := models.VacationLedgerEntry{
+		UserID:      userID,
+		PeriodID:    periodID,
+		Kind:        kind,
+		Days:        days,
+		Description: description,
+	}
+	if actorID != "" {
+		entry.CreatedBy = &actorID
+	}
+	return entry
+}
+
+// periodMonths duração do período aquisitivo configurada (padrão 12 meses)
+func periodMonths(settings models.VacationSettings) int {
+	if settings.PeriodMonths <= 0 {
+		return 12
+	}
+	return settings.PeriodMonths
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDerivePeriodBalances(t *testing.T) {
+	periods := []models.VacationAcquisitionPeriod{{ID: "p1"}, {ID: "p2"}}
+	entries := []models.VacationLedgerEntry{
+		{PeriodID: "p1", Kind: models.VacationLedgerAccrual, Days: 30},
+		{PeriodID: "p1", Kind: models.VacationLedgerConsumption, Days: 15},
+		{PeriodID: "p1", Kind: models.VacationLedgerSale, Days: -10},
+		{PeriodID: "p1", Kind: models.VacationLedgerReservation, Days: 5},
+		{PeriodID: "p1", Kind: models.VacationLedgerReservation, Days: -5}, // Reserva liberada
+		{PeriodID: "p2", Kind: models.VacationLedgerAccrual, Days: 30},
+		{PeriodID: "p2", Kind: models.VacationLedgerReservation, Days: 10},
+		{PeriodID: "p1", Kind: models.VacationLedgerCarryOver, Days: -5},
+		{PeriodID: "p2", Kind: models.VacationLedgerCarryOver, Days: 5},
+	}
+
+	balances := derivePeriodBalances(periods, entries)
+
+	assert.Equal(t, 15, balances[0].Entitled)
+	assert.Equal(t, 15, balances[0].Used)
+	assert.Equal(t, 0, balances[0].Pending)
+	assert.Equal(t, 0, balances[0].Available)
+
+	assert.Equal(t, 35, balances[1].Entitled)
+	assert.Equal(t, 10, balances[1].Pending)
+	assert.Equal(t, 25, balances[1].Available)
+
+	total := sumPeriodBalances(balances)
+	assert.Equal(t, 25, total.Available)
+}
+
+func TestAllocateDaysFIFO(t *testing.T) {
+	periods := []PeriodBalance{
+		{Period: models.VacationAcquisitionPeriod{ID: "old"}, Available: 8},
+		{Period: models.VacationAcquisitionPeriod{ID: "new"}, Available: 30},
+	}
+
+	assert.Equal(t, []ledgerAllocation{{PeriodID: "old", Days: 8}, {PeriodID: "new", Days: 7}}, allocateDays(periods, 15))
+	assert.Equal(t, []ledgerAllocation{{PeriodID: "old", Days: 5}}, allocateDays(periods, 5))
+
+	// Excedente fica no período mais recente (férias antecipadas)
+	assert.Equal(t, []ledgerAllocation{{PeriodID: "old", Days: 8}, {PeriodID: "new", Days: 32}}, allocateDays(periods, 40))
+}
+
+func TestReleaseDaysLIFO(t *testing.T) {
+	held := []ledgerAllocation{{PeriodID: "old", Days: 8}, {PeriodID: "new", Days: 7}}
+
+	assert.Equal(t, []ledgerAllocation{{PeriodID: "new", Days: 7}, {PeriodID: "old", Days: 3}}, releaseDays(held, 10))
+	assert.Equal(t, []ledgerAllocation{{PeriodID: "old", Days: 8}, {PeriodID: "new", Days: 2}}, subtract(held, []ledgerAllocation{{PeriodID: "new", Days: 5}}))
+}
+
+func ledgerDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// testLedgerState extrato gravado com o período 2025 (30 dias) na data informada
+func testLedgerState(today time.Time, settings models.VacationSettings) *ledgerState {
+	state := &ledgerState{userID: "user-1", today: today, settings: settings, changed: map[string]bool{}}
+	state.openPeriod(models.VacationAcquisitionPeriod{
+		PeriodStart:   ledgerDate(2025, 1, 1),
+		PeriodEnd:     ledgerDate(2025, 12, 31),
+		ConcessiveEnd: ledgerDate(2026, 12, 31),
+	}, 30, "Direito do período aquisitivo")
+	persistLedger(state)
+	return state
+}
+
+// persistLedger faz o papel de save sem banco: o que foi planejado passa a estar gravado
+func persistLedger(state *ledgerState) {
+	state.storedPeriods, state.storedEntries = len(state.periods), len(state.entries)
+	state.changed = map[string]bool{}
+}
+
+// refreshLedger repete o que load faz depois de ler o extrato
+func refreshLedger(state *ledgerState) {
+	state.openStartedPeriods()
+	state.closeExpiredPeriods()
+	state.migrateAbono()
+}
+
+func (s *ledgerState) unsaved() int {
+	return len(s.entries) - s.storedEntries + len(s.periods) - s.storedPeriods + len(s.changed)
+}
+
+func TestLedgerSyncIsIdempotent(t *testing.T) {
+	state := testLedgerState(ledgerDate(2025, 6, 1), models.VacationSettings{TotalDaysPerYear: 30})
+
+	state.syncVacation("v1", 10, 0, 0, "user-1")
+	assert.Equal(t, 1, state.unsaved())
+	persistLedger(state)
+
+	// Repetir a sincronização (ou reler o extrato) não lança nada novo
+	state.syncVacation("v1", 10, 0, 0, "user-1")
+	refreshLedger(state)
+	assert.Zero(t, state.unsaved())
+
+	balance := state.balance()
+	assert.Equal(t, 30, balance.TotalDays)
+	assert.Equal(t, 10, balance.PendingDays)
+	assert.Equal(t, 20, balance.AvailableDays)
+}
+
+func TestLedgerCancellationReversesEntries(t *testing.T) {
+	state := testLedgerState(ledgerDate(2025, 6, 1), models.VacationSettings{TotalDaysPerYear: 30})
+
+	state.syncVacation("v1", 10, 0, 0, "user-1") // Pendente
+	state.syncVacation("v1", 0, 10, 0, "gestor") // Aprovada
+	balance := state.balance()
+	assert.Equal(t, 10, balance.UsedDays)
+	assert.Equal(t, 0, balance.PendingDays)
+
+	state.syncVacation("v1", 0, 0, 0, "user-1") // Cancelada
+	balance = state.balance()
+	assert.Equal(t, 0, balance.UsedDays)
+	assert.Equal(t, 0, balance.PendingDays)
+	assert.Equal(t, 30, balance.AvailableDays)
+
+	net := map[models.VacationLedgerKind]int{}
+	for _, e := range state.entriesOf(func(e models.VacationLedgerEntry) bool { return e.VacationID != nil }) {
+		net[e.Kind] += e.Days
+	}
+	assert.Equal(t, map[models.VacationLedgerKind]int{models.VacationLedgerReservation: 0, models.VacationLedgerConsumption: 0}, net)
+}
+
+func TestLedgerOpensNextPeriod(t *testing.T) {
+	state := testLedgerState(ledgerDate(2026, 1, 15), models.VacationSettings{TotalDaysPerYear: 30})
+
+	refreshLedger(state)
+	require.Len(t, state.periods, 2)
+	next := state.periods[1]
+	assert.Equal(t, ledgerDate(2026, 1, 1), next.PeriodStart)
+	assert.Equal(t, ledgerDate(2026, 12, 31), next.PeriodEnd)
+	assert.Equal(t, ledgerDate(2027, 12, 31), next.ConcessiveEnd)
+	assert.Nil(t, state.periods[0].ClosedAt, "período anterior segue aberto até o fim do prazo concessivo")
+
+	balance := state.balance()
+	assert.Equal(t, 60, balance.TotalDays)
+	assert.Equal(t, next.PeriodStart, balance.PeriodStart)
+
+	persistLedger(state)
+	refreshLedger(state)
+	assert.Zero(t, state.unsaved())
+}
+
+func TestLedgerExpiresPeriodWithCarryOver(t *testing.T) {
+	settings := models.VacationSettings{TotalDaysPerYear: 30, AllowCarryOver: true, MaxCarryOverDays: 10}
+	state := testLedgerState(ledgerDate(2027, 1, 2), settings)
+	state.syncVacation("v1", 0, 5, 0, "gestor")
+	persistLedger(state)
+
+	refreshLedger(state)
+	require.Len(t, state.periods, 3)
+	require.NotNil(t, state.periods[0].ClosedAt)
+	assert.True(t, state.changed[state.periods[0].ID])
+
+	// 25 dias restantes: 10 transferidos para o período mais recente e 15 expirados
+	balances := derivePeriodBalances(state.periods, state.entries)
+	assert.Equal(t, 0, balances[0].Available)
+	assert.Equal(t, 40, balances[2].Entitled)
+	expired := state.entriesOf(func(e models.VacationLedgerEntry) bool { return e.Kind == models.VacationLedgerExpiry })
+	require.Len(t, expired, 1)
+	assert.Equal(t, -15, expired[0].Days)
+
+	balance := state.balance()
+	assert.Equal(t, 70, balance.TotalDays)
+	assert.Equal(t, 70, balance.AvailableDays)
+
+	persistLedger(state)
+	refreshLedger(state)
+	assert.Zero(t, state.unsaved())
+}
+
+func TestLedgerExpiresPeriodWithoutCarryOver(t *testing.T) {
+	state := testLedgerState(ledgerDate(2027, 1, 2), models.VacationSettings{TotalDaysPerYear: 30})
+
+	refreshLedger(state)
+	expired := state.entriesOf(func(e models.VacationLedgerEntry) bool { return e.Kind == models.VacationLedgerExpiry })
+	require.Len(t, expired, 1)
+	assert.Equal(t, -30, expired[0].Days)
+	assert.Empty(t, state.entriesOf(func(e models.VacationLedgerEntry) bool { return e.Kind == models.VacationLedgerCarryOver }))
+	assert.Equal(t, 60, state.balance().TotalDays)
+}
+
+func TestLedgerAbono(t *testing.T) {
+	state := testLedgerState(ledgerDate(2025, 12, 1), models.VacationSettings{TotalDaysPerYear: 30, MaxAbonoDays: 3})
+
+	state.syncVacation("a1", 0, 0, 2, "gestor")
+	abono := state.entriesOf(func(e models.VacationLedgerEntry) bool { return e.Kind == models.VacationLedgerAbono })
+	require.Len(t, abono, 1)
+	assert.Equal(t, state.periods[0].ID, abono[0].PeriodID)
+
+	balance := state.balance()
+	assert.Equal(t, 3, balance.AbonoDays)
+	assert.Equal(t, 2, balance.UsedAbono)
+	assert.Equal(t, 30, balance.AvailableDays, "abono não consome o saldo de férias")
+
+	// Estorno ao cancelar
+	state.syncVacation("a1", 0, 0, 0, "user-1")
+	assert.Equal(t, 0, state.balance().UsedAbono)
+
+	// Abonos contam por período aquisitivo
+	state.syncVacation("a2", 0, 0, 1, "gestor")
+	persistLedger(state)
+	state.today = ledgerDate(2026, 1, 2)
+	refreshLedger(state)
+	assert.Equal(t, 0, state.balance().UsedAbono)
+}
+
+func TestLedgerMigratesAbonoCounter(t *testing.T) {
+	state := testLedgerState(ledgerDate(2025, 6, 1), models.VacationSettings{TotalDaysPerYear: 30})
+	state.snapshot = &models.VacationBalance{UserID: "user-1", AbonoDays: 5, UsedAbono: 2}
+
+	refreshLedger(state)
+	balance := state.balance()
+	assert.Equal(t, 5, balance.AbonoDays)
+	assert.Equal(t, 2, balance.UsedAbono)
+
+	persistLedger(state)
+	state.snapshot = balance
+	refreshLedger(state)
+	assert.Zero(t, state.unsaved(), "migração acontece uma única vez")
+}
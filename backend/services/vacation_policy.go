@@ -0,0 +1,331 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+)
+
+// ==================== Motor de Políticas de Férias ====================
+
+// ViolationCode código estruturado de violação de política, exibido pela UI
+type ViolationCode string
+
+const (
+	ViolationInvalidPeriod       ViolationCode = "INVALID_PERIOD"
+	ViolationPastDate            ViolationCode = "PAST_DATE"
+	ViolationMinAdvanceDays      ViolationCode = "MIN_ADVANCE_DAYS"
+	ViolationMinDaysPerRequest   ViolationCode = "MIN_DAYS_PER_REQUEST"
+	ViolationMaxDaysPerRequest   ViolationCode = "MAX_DAYS_PER_REQUEST"
+	ViolationMaxSplits           ViolationCode = "MAX_SPLITS"
+	ViolationSplitMinDays        ViolationCode = "SPLIT_MIN_DAYS"
+	ViolationSplitLongPeriod     ViolationCode = "SPLIT_LONG_PERIOD_REQUIRED"
+	ViolationInsufficientBalance ViolationCode = "INSUFFICIENT_BALANCE"
+	ViolationAbonoNotAllowed     ViolationCode = "ABONO_NOT_ALLOWED"
+	ViolationInsufficientAbono   ViolationCode = "INSUFFICIENT_ABONO"
+	ViolationConcessivePeriod    ViolationCode = "CONCESSIVE_PERIOD_EXCEEDED"
+	ViolationCarryOverLimit      ViolationCode = "CARRY_OVER_LIMIT"
+	ViolationOverlap             ViolationCode = "OVERLAP"
+	ViolationStartOnHoliday      ViolationCode = "START_ON_HOLIDAY"
+	ViolationStartOnWeekend      ViolationCode = "START_ON_WEEKEND"
+	ViolationStartBeforeRest     ViolationCode = "START_BEFORE_REST_DAY"
+	ViolationNoWorkingDays       ViolationCode = "NO_WORKING_DAYS"
+)
+
+// Limites do fracionamento de férias (CLT art. 134, §1º)
+const (
+	cltMaxSplits      = 3
+	cltLongPeriodDays = 14
+	cltMinSplitDays   = 5
+)
+
+// PolicyViolation violação de uma regra de férias
+type PolicyViolation struct {
+	Code    ViolationCode `json:"code"`
+	Field   string        `json:"field,omitempty"`
+	Message string        `json:"message"`
+}
+
+// VacationPolicyError erro retornado quando a solicitação viola uma ou mais regras
+type VacationPolicyError struct {
+	Violations []PolicyViolation `json:"violations"`
+}
+
+func (e *VacationPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// AsVacationPolicyError extrai as violações de um erro, se for de política
+func AsVacationPolicyError(err error) (*VacationPolicyError, bool) {
+	var policyErr *VacationPolicyError
+	if errors.As(err, &policyErr) {
+		return policyErr, true
+	}
+	return nil, false
+}
+
+// VacationPolicyRequest dados da solicitação a ser validada
+type VacationPolicyRequest struct {
+	UserID    string
+	Type      models.VacationType
+	StartDate time.Time
+	EndDate   time.Time
+
+	// ExcludeVacationID ignora a própria solicitação ao editar
+	ExcludeVacationID string
+}
+
+// vacationPolicyState dados já carregados do banco usados na avaliação
+type vacationPolicyState struct {
+	Today           time.Time
+	Calendar        *WorkCalendar
+	Balance         *models.VacationBalance
+	ExistingPeriods []int // Dias de cada férias já marcadas no período aquisitivo
+	HasOverlap      bool
+}
+
+// VacationPolicy valida solicitações de férias/ausências contra VacationSettings e a CLT
+type VacationPolicy struct {
+	Settings models.VacationSettings
+	now      func() time.Time
+}
+
+// NewVacationPolicy cria o motor de políticas com as configurações atuais
+func NewVacationPolicy() *VacationPolicy {
+	return &VacationPolicy{
+		Settings: LoadVacationSettings(),
+		now:      time.Now,
+	}
+}
+
+// Validate valida a solicitação e retorna o período calculado.
+// Em caso de violação, o erro é um *VacationPolicyError com todos os códigos encontrados.
+func (p *VacationPolicy) Validate(req VacationPolicyRequest) (*VacationPeriod, error) {
+	if req.EndDate.Before(req.StartDate) {
+		return nil, &VacationPolicyError{Violations: []PolicyViolation{{
+			Code:    ViolationInvalidPeriod,
+			Field:   "end_date",
+			Message: "Data de término deve ser posterior à data de início",
+		}}}
+	}
+
+	state := vacationPolicyState{
+		Today:    truncateDay(p.now()),
+		Calendar: LoadWorkCalendar(req.UserID, req.StartDate, req.EndDate),
+	}
+
+	if balance, err := NewVacationLedger().Balance(config.DB, req.UserID); err == nil {
+		state.Balance = balance
+
+		// Ao editar, os dias já reservados/gozados/abonados pela própria solicitação voltam ao disponível
+		if req.ExcludeVacationID != "" {
+			var held, heldAbono int
+			config.DB.Model(&models.VacationLedgerEntry{}).
+				Where("vacation_id = ? AND kind IN ?", req.ExcludeVacationID,
+					[]models.VacationLedgerKind{models.VacationLedgerReservation, models.VacationLedgerConsumption}).
+				Select("COALESCE(SUM(days), 0)").
+				Scan(&held)
+			config.DB.Model(&models.VacationLedgerEntry{}).
+				Where("vacation_id = ? AND kind = ?", req.ExcludeVacationID, models.VacationLedgerAbono).
+				Select("COALESCE(SUM(days), 0)").
+				Scan(&heldAbono)
+			state.Balance.AvailableDays += held
+			state.Balance.UsedAbono -= heldAbono
+		}
+	}
+
+	// Conflito com outras solicitações pendentes ou aprovadas
+	var overlapping int64
+	overlapQuery := config.DB.Model(&models.Vacation{}).
+		Where("user_id = ? AND status IN ? AND start_date <= ? AND end_date >= ?",
+			req.UserID, []models.VacationStatus{models.VacationStatusPending, models.VacationStatusApproved},
+			req.EndDate, req.StartDate)
+	if req.ExcludeVacationID != "" {
+		overlapQuery = overlapQuery.Where("id <> ?", req.ExcludeVacationID)
+	}
+	overlapQuery.Count(&overlapping)
+	state.HasOverlap = overlapping > 0
+
+	// Férias já marcadas no período aquisitivo atual (fracionamento)
+	if req.Type == models.VacationTypeFerias {
+		query := config.DB.Model(&models.Vacation{}).
+			Where("user_id = ? AND type = ? AND status IN ?",
+				req.UserID, models.VacationTypeFerias,
+				[]models.VacationStatus{models.VacationStatusPending, models.VacationStatusApproved})
+		if state.Balance != nil && !state.Balance.PeriodStart.IsZero() {
+			query = query.Where("start_date >= ?", state.Balance.PeriodStart)
+		}
+		if req.ExcludeVacationID != "" {
+			query = query.Where("id <> ?", req.ExcludeVacationID)
+		}
+		query.Pluck("total_days", &state.ExistingPeriods)
+	}
+
+	return p.evaluate(req, state)
+}
+
+// evaluate aplica as regras sobre os dados carregados
+func (p *VacationPolicy) evaluate(req VacationPolicyRequest, state vacationPolicyState) (*VacationPeriod, error) {
+	s := p.Settings
+	wc := state.Calendar
+	var violations []PolicyViolation
+
+	add := func(code ViolationCode, field, format string, args ...interface{}) {
+		violations = append(violations, PolicyViolation{Code: code, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	start, end := truncateDay(req.StartDate), truncateDay(req.EndDate)
+
+	period := &VacationPeriod{
+		TotalDays:    wc.CountAbsenceDays(req.Type, start, end),
+		BusinessDays: wc.CountWorkingDays(start, end),
+		Holidays:     wc.Holidays(start, end),
+		Filial:       wc.Filial,
+	}
+
+	if start.Before(state.Today) {
+		add(ViolationPastDate, "start_date", "Não é possível solicitar férias para datas passadas")
+	}
+
+	if state.HasOverlap {
+		add(ViolationOverlap, "start_date", "Já existe uma solicitação para este período")
+	}
+
+	if period.TotalDays == 0 {
+		add(ViolationNoWorkingDays, "end_date", "O período informado não contém dias úteis")
+	}
+
+	switch req.Type {
+	case models.VacationTypeFerias:
+		p.evaluateFerias(start, end, period, state, add)
+	case models.VacationTypeAbono:
+		if !s.AllowAbono {
+			add(ViolationAbonoNotAllowed, "type", "Abonos não estão habilitados")
+		}
+		remaining := s.MaxAbonoDays
+		if state.Balance != nil {
+			remaining = state.Balance.AbonoDays - state.Balance.UsedAbono
+		}
+		if period.TotalDays > remaining {
+			add(ViolationInsufficientAbono, "end_date", "Saldo insuficiente de abono: %d dia(s) disponível(is)", remaining)
+		}
+	}
+
+	if len(violations) > 0 {
+		return nil, &VacationPolicyError{Violations: violations}
+	}
+	return period, nil
+}
+
+// evaluateFerias aplica as regras específicas de férias
+func (p *VacationPolicy) evaluateFerias(start, end time.Time, period *VacationPeriod, state vacationPolicyState, add func(ViolationCode, string, string, ...interface{})) {
+	s := p.Settings
+	days := period.TotalDays
+
+	if s.MinAdvanceDays > 0 && !start.Before(state.Today) && start.Before(state.Today.AddDate(0, 0, s.MinAdvanceDays)) {
+		add(ViolationMinAdvanceDays, "start_date", "As férias devem ser solicitadas com pelo menos %d dias de antecedência", s.MinAdvanceDays)
+	}
+
+	if s.MinDaysPerRequest > 0 && days < s.MinDaysPerRequest {
+		add(ViolationMinDaysPerRequest, "end_date", "O mínimo por solicitação é de %d dias", s.MinDaysPerRequest)
+	}
+
+	if s.MaxDaysPerRequest > 0 && days > s.MaxDaysPerRequest {
+		add(ViolationMaxDaysPerRequest, "end_date", "O máximo por solicitação é de %d dias", s.MaxDaysPerRequest)
+	}
+
+	if err := state.Calendar.CheckVacationStart(start, s.AllowWeekendStart); err != nil {
+		switch {
+		case errors.Is(err, ErrVacationStartOnHoliday):
+			add(ViolationStartOnHoliday, "start_date", "%s", err.Error())
+		case errors.Is(err, ErrVacationStartOnWeekend):
+			add(ViolationStartOnWeekend, "start_date", "%s", err.Error())
+		default:
+			add(ViolationStartBeforeRest, "start_date", "%s", err.Error())
+		}
+	}
+
+	available := s.TotalDaysPerYear
+	if state.Balance != nil {
+		available = state.Balance.AvailableDays
+	}
+	if days > available {
+		add(ViolationInsufficientBalance, "end_date", "Saldo insuficiente: você tem %d dias disponíveis e solicitou %d dias", available, days)
+	}
+
+	for _, v := range CheckVacationSplits(state.ExistingPeriods, days, available-days, s.MaxSplits) {
+		add(v.Code, v.Field, "%s", v.Message)
+	}
+
+	// Período concessivo: as férias devem terminar até 12 meses após o fim do período aquisitivo
+	if state.Balance != nil && !state.Balance.PeriodEnd.IsZero() {
+		months := s.PeriodMonths
+		if months <= 0 {
+			months = 12
+		}
+		deadline := truncateDay(state.Balance.PeriodEnd).AddDate(0, months, 0)
+		if end.After(deadline) {
+			if !s.AllowCarryOver {
+				add(ViolationConcessivePeriod, "end_date", "As férias devem ser concluídas até %s (fim do período concessivo)", deadline.Format("02/01/2006"))
+			} else if days > s.MaxCarryOverDays {
+				add(ViolationCarryOverLimit, "end_date", "Apenas %d dias podem ser acumulados para após %s", s.MaxCarryOverDays, deadline.Format("02/01/2006"))
+			}
+		}
+	}
+}
+
+// CheckVacationSplits valida o fracionamento das férias (CLT art. 134, §1º): até três
+// períodos, um deles com no mínimo 14 dias corridos e os demais com no mínimo 5.
+// existing são os dias dos períodos já marcados e remaining o saldo após a solicitação.
+func CheckVacationSplits(existing []int, requested, remaining, maxSplits int) []PolicyViolation {
+	var violations []PolicyViolation
+
+	limit := cltMaxSplits
+	if maxSplits > 0 && maxSplits < limit {
+		limit = maxSplits
+	}
+
+	periods := len(existing) + 1
+	if periods > limit {
+		violations = append(violations, PolicyViolation{
+			Code:    ViolationMaxSplits,
+			Field:   "start_date",
+			Message: fmt.Sprintf("As férias podem ser divididas em no máximo %d períodos", limit),
+		})
+	}
+
+	if requested < cltMinSplitDays {
+		violations = append(violations, PolicyViolation{
+			Code:    ViolationSplitMinDays,
+			Field:   "end_date",
+			Message: fmt.Sprintf("Cada período de férias deve ter no mínimo %d dias corridos", cltMinSplitDays),
+		})
+	}
+
+	hasLongPeriod := requested >= cltLongPeriodDays
+	for _, d := range existing {
+		if d >= cltLongPeriodDays {
+			hasLongPeriod = true
+		}
+	}
+
+	// Ainda é possível marcar o período de 14 dias com o saldo e os fracionamentos restantes?
+	canScheduleLong := periods < limit && remaining >= cltLongPeriodDays
+	if !hasLongPeriod && !canScheduleLong {
+		violations = append(violations, PolicyViolation{
+			Code:    ViolationSplitLongPeriod,
+			Field:   "end_date",
+			Message: fmt.Sprintf("Um dos períodos de férias deve ter no mínimo %d dias corridos", cltLongPeriodDays),
+		})
+	}
+
+	return violations
+}
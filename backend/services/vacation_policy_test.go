@@ -0,0 +1,93 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func violationCodes(err error) []ViolationCode {
+	policyErr, ok := AsVacationPolicyError(err)
+	if !ok {
+		return nil
+	}
+	codes := make([]ViolationCode, 0, len(policyErr.Violations))
+	for _, v := range policyErr.Violations {
+		codes = append(codes, v.Code)
+	}
+	return codes
+}
+
+func newTestPolicy(today time.Time) *VacationPolicy {
+	return &VacationPolicy{
+		Settings: DefaultVacationSettings(),
+		now:      func() time.Time { return today },
+	}
+}
+
+func TestCheckVacationSplits(t *testing.T) {
+	// Período único de 30 dias
+	assert.Empty(t, CheckVacationSplits(nil, 30, 0, 3))
+
+	// Primeiro período de 10 dias: ainda sobram 20 dias para o período de 14
+	assert.Empty(t, CheckVacationSplits(nil, 10, 20, 3))
+
+	// Segundo período curto sem saldo para o período de 14 dias
+	violations := CheckVacationSplits([]int{10}, 10, 10, 3)
+	assert.Len(t, violations, 1)
+	assert.Equal(t, ViolationSplitLongPeriod, violations[0].Code)
+
+	// Quarto período excede o limite da CLT
+	violations = CheckVacationSplits([]int{14, 5, 5}, 5, 1, 5)
+	assert.Equal(t, ViolationMaxSplits, violations[0].Code)
+
+	// Período menor que 5 dias
+	violations = CheckVacationSplits([]int{14}, 4, 12, 3)
+	assert.Equal(t, ViolationSplitMinDays, violations[0].Code)
+}
+
+func TestVacationPolicyEvaluate(t *testing.T) {
+	today := date(2025, time.May, 2)
+	policy := newTestPolicy(today)
+
+	wc := NewWorkCalendar()
+	wc.addNationalHolidays(2025)
+	state := vacationPolicyState{
+		Today:    today,
+		Calendar: wc,
+		Balance:  &models.VacationBalance{AvailableDays: 30, AbonoDays: 3},
+	}
+
+	// 02/06 (segunda) a 16/06: 15 dias, antecedência respeitada
+	period, err := policy.evaluate(VacationPolicyRequest{
+		Type:      models.VacationTypeFerias,
+		StartDate: date(2025, time.June, 2),
+		EndDate:   date(2025, time.June, 16),
+	}, state)
+	assert.NoError(t, err)
+	assert.Equal(t, 15, period.TotalDays)
+	assert.Equal(t, 11, period.BusinessDays) // Corpus Christi não é feriado nacional
+
+	// Início em dez dias, antecedendo o DSR, com apenas 3 dias
+	_, err = policy.evaluate(VacationPolicyRequest{
+		Type:      models.VacationTypeFerias,
+		StartDate: date(2025, time.May, 16),
+		EndDate:   date(2025, time.May, 18),
+	}, state)
+	codes := violationCodes(err)
+	assert.Contains(t, codes, ViolationMinAdvanceDays)
+	assert.Contains(t, codes, ViolationMinDaysPerRequest)
+	assert.Contains(t, codes, ViolationStartBeforeRest)
+	assert.Contains(t, codes, ViolationSplitMinDays)
+
+	// Abono maior que o saldo disponível e em conflito com outra solicitação
+	state.HasOverlap = true
+	_, err = policy.evaluate(VacationPolicyRequest{
+		Type:      models.VacationTypeAbono,
+		StartDate: date(2025, time.May, 5),
+		EndDate:   date(2025, time.May, 9),
+	}, state)
+	assert.ElementsMatch(t, []ViolationCode{ViolationOverlap, ViolationInsufficientAbono}, violationCodes(err))
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+)
+
+// ==================== Calendário de Dias Úteis ====================
+
+const calendarDateLayout = "2006-01-02"
+
+var (
+	ErrVacationStartOnHoliday    = errors.New("as férias não podem iniciar em feriado")
+	ErrVacationStartOnWeekend    = errors.New("as férias não podem iniciar em fim de semana")
+	ErrVacationStartBeforeRest   = errors.New("as férias não podem iniciar nos dois dias que antecedem feriado ou repouso semanal remunerado (CLT art. 134, §3º)")
+	ErrAbsenceWithoutWorkingDays = errors.New("o período informado não contém dias úteis")
+)
+
+// HolidayInfo feriado resolvido para uma data específica
+type HolidayInfo struct {
+	Date  time.Time           `json:"date"`
+	Name  string              `json:"name"`
+	Scope models.HolidayScope `json:"scope"`
+}
+
+// WorkCalendar calendário de trabalho de um colaborador (feriados da filial + DSR)
+type WorkCalendar struct {
+	Filial string `json:"filial,omitempty"`
+	State  string `json:"state,omitempty"`
+	City   string `json:"city,omitempty"`
+
+	holidays    map[string]HolidayInfo
+	weeklyRest  map[time.Weekday]bool // Repouso semanal remunerado (DSR)
+	nonWorking  map[time.Weekday]bool // Dias sem expediente (não contam como úteis)
+	loadedYears map[int]bool
+}
+
+// NewWorkCalendar cria um calendário com DSR aos domingos e expediente de segunda a sexta
+func NewWorkCalendar() *WorkCalendar {
+	return &WorkCalendar{
+		holidays:    make(map[string]HolidayInfo),
+		weeklyRest:  map[time.Weekday]bool{time.Sunday: true},
+		nonWorking:  map[time.Weekday]bool{time.Saturday: true, time.Sunday: true},
+		loadedYears: make(map[int]bool),
+	}
+}
+
+// LoadWorkCalendar monta o calendário do colaborador para o intervalo informado,
+// combinando feriados nacionais, estaduais e municipais da filial e eventos do tipo "feriado"
+func LoadWorkCalendar(userID string, from, to time.Time) *WorkCalendar {
+	wc := NewWorkCalendar()
+
+	// A regra da CLT olha dois dias à frente do início
+	to = to.AddDate(0, 0, 2)
+
+	wc.Filial = GetUserFilial(userID)
+	if wc.Filial != "" {
+		var location models.FilialLocation
+		if config.DB.Where("filial = ?", wc.Filial).First(&location).Error == nil {
+			wc.State = strings.ToUpper(location.State)
+			wc.City = location.City
+		}
+	}
+
+	for year := from.Year(); year <= to.Year(); year++ {
+		wc.addNationalHolidays(year)
+	}
+
+	// Feriados cadastrados (nacionais adicionais, estaduais e municipais da filial)
+	var holidays []models.Holiday
+	query := config.DB.Where("scope = ?", models.HolidayScopeNacional)
+	if wc.State != "" {
+		query = query.
+			Or("scope = ? AND state = ?", models.HolidayScopeEstadual, wc.State).
+			Or("scope = ? AND state = ? AND city = ?", models.HolidayScopeMunicipal, wc.State, wc.City)
+	}
+	query.Find(&holidays)
+
+	for _, h := range holidays {
+		if h.Recurring {
+			for year := from.Year(); year <= to.Year(); year++ {
+				date := time.Date(year, h.Date.Month(), h.Date.Day(), 0, 0, 0, 0, time.UTC)
+				wc.AddHoliday(date, h.Name, h.Scope)
+			}
+		} else if !h.Date.Before(truncateDay(from)) && !h.Date.After(to) {
+			wc.AddHoliday(h.Date, h.Name, h.Scope)
+		}
+	}
+
+	// Feriados e pontes lançados no calendário corporativo
+	var events []models.CalendarEvent
+	config.DB.Where("type = ? AND start_date <= ? AND end_date >= ?", "feriado", to, truncateDay(from)).
+		Find(&events)
+
+	for _, e := range events {
+		for d := truncateDay(e.StartDate); !d.After(truncateDay(e.EndDate)); d = d.AddDate(0, 0, 1) {
+			wc.AddHoliday(d, e.Title, "")
+		}
+	}
+
+	return wc
+}
+
+// GetUserFilial retorna a filial do colaborador (dbo.ColaboradoresFradema) a partir do CPF do usuário
+func GetUserFilial(userID string) string {
+	var user models.User
+	if err := config.DB.Select("id", "cpf").First(&user, "id = ?", userID).Error; err != nil || user.CPF == "" {
+		return ""
+	}
+
+	cleanCPF := strings.NewReplacer(".", "", "-", "", " ", "").Replace(user.CPF)
+
+	var filial string
+	config.DB.Raw(`
+		SELECT TOP 1 ISNULL(c.Filial, '')
+		FROM dbo.ColaboradoresFradema c
+		INNER JOIN dbo.PessoasFisicasFradema p ON c.PessoaFisicaId = p.Id
+		WHERE REPLACE(REPLACE(REPLACE(p.Cpf, '.', ''), '-', ''), ' ', '') = ?
+		ORDER BY c.Ativo DESC
+	`, cleanCPF).Scan(&filial)
+
+	return strings.TrimSpace(filial)
+}
+
+// AddHoliday registra um feriado no calendário
+func (wc *WorkCalendar) AddHoliday(date time.Time, name string, scope models.HolidayScope) {
+	key := date.Format(calendarDateLayout)
+	if _, exists := wc.holidays[key]; exists {
+		return
+	}
+	wc.holidays[key] = HolidayInfo{Date: truncateDay(date), Name: name, Scope: scope}
+}
+
+// addNationalHolidays adiciona os feriados nacionais fixos e móveis do ano
+func (wc *WorkCalendar) addNationalHolidays(year int) {
+	if wc.loadedYears[year] {
+		return
+	}
+	wc.loadedYears[year] = true
+
+	fixed := []struct {
+		month time.Month
+		day   int
+		name  string
+	}{
+		{time.January, 1, "Confraternização Universal"},
+		{time.April, 21, "Tiradentes"},
+		{time.May, 1, "Dia do Trabalho"},
+		{time.September, 7, "Independência do Brasil"},
+		{time.October, 12, "Nossa Senhora Aparecida"},
+		{time.November, 2, "Finados"},
+		{time.November, 15, "Proclamação da República"},
+		{time.November, 20, "Dia Nacional de Zumbi e da Consciência Negra"},
+		{time.December, 25, "Natal"},
+	}
+
+	for _, h := range fixed {
+		wc.AddHoliday(time.Date(year, h.month, h.day, 0, 0, 0, 0, time.UTC), h.name, models.HolidayScopeNacional)
+	}
+
+	wc.AddHoliday(easterSunday(year).AddDate(0, 0, -2), "Sexta-feira Santa", models.HolidayScopeNacional)
+}
+
+// HolidayName retorna o nome do feriado na data, se houver
+func (wc *WorkCalendar) HolidayName(date time.Time) (string, bool) {
+	h, ok := wc.holidays[date.Format(calendarDateLayout)]
+	return h.Name, ok
+}
+
+// IsWeeklyRest verifica se a data é dia de repouso semanal remunerado
+func (wc *WorkCalendar) IsWeeklyRest(date time.Time) bool {
+	return wc.weeklyRest[date.Weekday()]
+}
+
+// IsWorkingDay verifica se a data é dia útil (com expediente e sem feriado)
+func (wc *WorkCalendar) IsWorkingDay(date time.Time) bool {
+	if wc.nonWorking[date.Weekday()] {
+		return false
+	}
+	_, holiday := wc.HolidayName(date)
+	return !holiday
+}
+
+// CountWorkingDays conta os dias úteis entre start e end (inclusive)
+func (wc *WorkCalendar) CountWorkingDays(start, end time.Time) int {
+	count := 0
+	for d := truncateDay(start); !d.After(truncateDay(end)); d = d.AddDate(0, 0, 1) {
+		if wc.IsWorkingDay(d) {
+			count++
+		}
+	}
+	return count
+}
+
+// CountAbsenceDays conta os dias de uma ausência. Férias, licenças e atestados
+// são contados em dias corridos (CLT art. 130); folgas, abonos e home office em dias úteis.
+func (wc *WorkCalendar) CountAbsenceDays(vacationType models.VacationType, start, end time.Time) int {
+	switch vacationType {
+	case models.VacationTypeAbono, models.VacationTypeFolga, models.VacationTypeHomeOffice:
+		return wc.CountWorkingDays(start, end)
+	default:
+		return int(truncateDay(end).Sub(truncateDay(start)).Hours()/24) + 1
+	}
+}
+
+// CheckVacationStart valida a data de início das férias conforme a CLT
+// e a configuração AllowWeekendStart
+func (wc *WorkCalendar) CheckVacationStart(start time.Time, allowWeekendStart bool) error {
+	start = truncateDay(start)
+
+	if name, ok := wc.HolidayName(start); ok {
+		return fmt.Errorf("%w (%s)", ErrVacationStartOnHoliday, name)
+	}
+
+	if !allowWeekendStart && wc.nonWorking[start.Weekday()] {
+		return ErrVacationStartOnWeekend
+	}
+
+	for i := 1; i <= 2; i++ {
+		d := start.AddDate(0, 0, i)
+		if name, ok := wc.HolidayName(d); ok {
+			return fmt.Errorf("%w: %s em %s", ErrVacationStartBeforeRest, name, d.Format("02/01/2006"))
+		}
+		if wc.IsWeeklyRest(d) {
+			return fmt.Errorf("%w: repouso semanal em %s", ErrVacationStartBeforeRest, d.Format("02/01/2006"))
+		}
+	}
+
+	return nil
+}
+
+// Holidays lista os feriados do calendário no intervalo, em ordem cronológica
+func (wc *WorkCalendar) Holidays(from, to time.Time) []HolidayInfo {
+	from, to = truncateDay(from), truncateDay(to)
+
+	result := []HolidayInfo{}
+	for _, h := range wc.holidays {
+		if !h.Date.Before(from) && !h.Date.After(to) {
+			result = append(result, h)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date.Before(result[j].Date)
+	})
+	return result
+}
+
+// easterSunday calcula o domingo de Páscoa (algoritmo de Meeus/Jones/Butcher)
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := ((h + l - 7*m + 114) % 31) + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// truncateDay normaliza a data para meia-noite UTC
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestEasterSunday(t *testing.T) {
+	assert.Equal(t, date(2024, time.March, 31), easterSunday(2024))
+	assert.Equal(t, date(2025, time.April, 20), easterSunday(2025))
+	assert.Equal(t, date(2026, time.April, 5), easterSunday(2026))
+}
+
+func TestWorkCalendarNationalHolidays(t *testing.T) {
+	wc := NewWorkCalendar()
+	wc.addNationalHolidays(2025)
+
+	name, ok := wc.HolidayName(date(2025, time.April, 18))
+	assert.True(t, ok)
+	assert.Equal(t, "Sexta-feira Santa", name)
+
+	_, ok = wc.HolidayName(date(2025, time.November, 20))
+	assert.True(t, ok)
+
+	assert.False(t, wc.IsWorkingDay(date(2025, time.December, 25)))
+	assert.True(t, wc.IsWorkingDay(date(2025, time.December, 26)))
+}
+
+func TestWorkCalendarCountDays(t *testing.T) {
+	wc := NewWorkCalendar()
+	wc.addNationalHolidays(2025)
+
+	// 14/04 (seg) a 25/04 (sex): 10 dias úteis menos Sexta-feira Santa (18) e Tiradentes (21)
+	start, end := date(2025, time.April, 14), date(2025, time.April, 25)
+	assert.Equal(t, 8, wc.CountWorkingDays(start, end))
+	assert.Equal(t, 8, wc.CountAbsenceDays(models.VacationTypeFolga, start, end))
+	assert.Equal(t, 12, wc.CountAbsenceDays(models.VacationTypeFerias, start, end))
+}
+
+func TestWorkCalendarCheckVacationStart(t *testing.T) {
+	wc := NewWorkCalendar()
+	wc.addNationalHolidays(2025)
+	wc.AddHoliday(date(2025, time.July, 9), "Revolução Constitucionalista", models.HolidayScopeEstadual)
+
+	// Segunda-feira sem feriados próximos
+	assert.NoError(t, wc.CheckVacationStart(date(2025, time.June, 2), false))
+
+	// Sexta-feira antecede o DSR de domingo
+	err := wc.CheckVacationStart(date(2025, time.June, 6), true)
+	assert.True(t, errors.Is(err, ErrVacationStartBeforeRest))
+
+	// Segunda-feira 07/07 antecede feriado estadual em 09/07
+	err = wc.CheckVacationStart(date(2025, time.July, 7), false)
+	assert.True(t, errors.Is(err, ErrVacationStartBeforeRest))
+
+	// Início no próprio feriado
+	err = wc.CheckVacationStart(date(2025, time.December, 25), true)
+	assert.True(t, errors.Is(err, ErrVacationStartOnHoliday))
+
+	// Sábado sem permissão para início em fim de semana
+	err = wc.CheckVacationStart(date(2025, time.June, 7), false)
+	assert.True(t, errors.Is(err, ErrVacationStartOnWeekend))
+}
@@ -0,0 +1,968 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== Workflow de Aprovações ====================
+
+var (
+	ErrApprovalNotFound       = errors.New("solicitação não encontrada ou já processada")
+	ErrApprovalForbidden      = errors.New("você não é o aprovador desta etapa")
+	ErrApprovalSelf           = errors.New("não é possível aprovar a própria solicitação")
+	ErrApprovalUnknownSubject = errors.New("tipo de aprovação não suportado")
+	ErrInvalidWorkflowChain   = errors.New("cadeia de aprovação inválida")
+)
+
+// Limite de saltos ao seguir delegações/ausências (evita ciclos)
+const maxApproverHops = 5
+
+// ApprovalDecision decisão tomada em uma etapa
+type ApprovalDecision string
+
+const (
+	ApprovalApprove ApprovalDecision = "approve"
+	ApprovalReject  ApprovalDecision = "reject"
+)
+
+// ApprovalSubject item submetido ao fluxo de aprovação
+type ApprovalSubject struct {
+	Type        models.ApprovalSubjectType
+	ID          string
+	RequesterID string
+	Summary     string
+	ManagerID   string // Gestor informado no próprio item (ex: PDI); vazio usa o ManagerResolver
+}
+
+// ApprovalSubjectHandler conecta um tipo de item ao workflow: carrega o item pendente
+// e aplica o resultado final (status, extrato de férias). Approve e Reject rodam na
+// transação da decisão: um erro desfaz também a decisão da etapa.
+type ApprovalSubjectHandler interface {
+	Load(tx *gorm.DB, subjectID string) (*ApprovalSubject, error) // nil quando o item não está mais pendente
+	Pending() ([]ApprovalSubject, error)
+	Approve(tx *gorm.DB, subjectID, actorID, comment string) error
+	Reject(tx *gorm.DB, subjectID, actorID, comment string) error
+	Notify(subjectID string) // Avisa o solicitante do resultado, depois do commit
+}
+
+// ManagerResolver retorna o ID do gestor direto do usuário ("" quando não há)
+type ManagerResolver func(userID string) string
+
+// ApprovalOutcome resultado de uma decisão
+type ApprovalOutcome struct {
+	Request  models.ApprovalRequest
+	Final    bool                 // A decisão encerrou o fluxo (aprovado ou rejeitado)
+	NextTask *models.ApprovalTask // Próxima etapa quando o fluxo continua
+}
+
+// WorkflowEngine motor genérico de aprovações multinível
+type WorkflowEngine struct {
+	mu       sync.RWMutex
+	subjects map[models.ApprovalSubjectType]ApprovalSubjectHandler
+
+	ResolveManager ManagerResolver
+	EscalationRole string        // Papel que recebe as tarefas sem aprovador disponível (RH)
+	Authz          *AuthzService // Abrangência de quem decide pelo papel da etapa
+
+	now func() time.Time
+}
+
+// Workflow motor compartilhado pelos handlers REST, resolvers GraphQL e chat
+var Workflow = NewWorkflowEngine()
+
+// NewWorkflowEngine cria o motor com os tipos de aprovação padrão registrados
+func NewWorkflowEngine() *WorkflowEngine {
+	e := &WorkflowEngine{
+		subjects:       make(map[models.ApprovalSubjectType]ApprovalSubjectHandler),
+		ResolveManager: func(userID string) string { return Org.ManagerOf(userID) },
+		EscalationRole: RoleHR,
+		Authz:          Authz,
+		now:            time.Now,
+	}
+	registerDefaultApprovalSubjects(e)
+	return e
+}
+
+// RegisterSubject registra (ou substitui) o handler de um tipo de item
+func (e *WorkflowEngine) RegisterSubject(subjectType models.ApprovalSubjectType, handler ApprovalSubjectHandler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.subjects[subjectType] = handler
+}
+
+func (e *WorkflowEngine) subject(subjectType models.ApprovalSubjectType) (ApprovalSubjectHandler, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	handler, ok := e.subjects[subjectType]
+	return handler, ok
+}
+
+// ==================== CADEIAS ====================
+
+// ApprovalSubjectTypes tipos de item com fluxo de aprovação
+var ApprovalSubjectTypes = []models.ApprovalSubjectType{
+	models.ApprovalSubjectVacation,
+	models.ApprovalSubjectVacationSell,
+	models.ApprovalSubjectDocument,
+	models.ApprovalSubjectPDI,
+}
+
+// DefaultWorkflowChain cadeia usada enquanto o RH não configura uma própria
+func DefaultWorkflowChain(subjectType models.ApprovalSubjectType) models.WorkflowChain {
+	manager := models.WorkflowChainStep{StepOrder: 1, Name: "Gestor direto", ApproverType: models.ApproverDirectManager, SLAHours: 48}
+	hr := models.WorkflowChainStep{StepOrder: 2, Name: "RH", ApproverType: models.ApproverRole, ApproverValue: RoleHR, SLAHours: 72}
+
+	chain := models.WorkflowChain{SubjectType: subjectType, Active: true}
+	switch subjectType {
+	case models.ApprovalSubjectVacation:
+		chain.Name = "Férias e ausências"
+		chain.Steps = []models.WorkflowChainStep{manager, hr}
+	case models.ApprovalSubjectVacationSell:
+		chain.Name = "Venda de férias"
+		chain.Steps = []models.WorkflowChainStep{manager, hr}
+	case models.ApprovalSubjectDocument:
+		hr.StepOrder = 1
+		chain.Name = "Documentos"
+		chain.Steps = []models.WorkflowChainStep{hr}
+	case models.ApprovalSubjectPDI:
+		manager.SLAHours = 120
+		chain.Name = "PDI"
+		chain.Steps = []models.WorkflowChainStep{manager}
+	}
+	return chain
+}
+
+// Chain retorna a cadeia ativa configurada para o tipo ou a cadeia padrão
+func (e *WorkflowEngine) Chain(subjectType models.ApprovalSubjectType) models.WorkflowChain {
+	var chain models.WorkflowChain
+	err := config.DB.Preload("Steps", func(db *gorm.DB) *gorm.DB {
+		return db.Order("step_order ASC")
+	}).Where("subject_type = ? AND active = ?", subjectType, true).First(&chain).Error
+	if err == nil && len(chain.Steps) > 0 {
+		return chain
+	}
+	return DefaultWorkflowChain(subjectType)
+}
+
+// Chains lista as cadeias de todos os tipos de aprovação
+func (e *WorkflowEngine) Chains() []models.WorkflowChain {
+	chains := make([]models.WorkflowChain, 0, len(ApprovalSubjectTypes))
+	for _, subjectType := range ApprovalSubjectTypes {
+		chains = append(chains, e.Chain(subjectType))
+	}
+	return chains
+}
+
+// ValidateWorkflowChain valida e renumera as etapas de uma cadeia
+func ValidateWorkflowChain(chain *models.WorkflowChain) error {
+	if len(chain.Steps) == 0 {
+		return fmt.Errorf("%w: informe ao menos uma etapa", ErrInvalidWorkflowChain)
+	}
+	for i := range chain.Steps {
+		step := &chain.Steps[i]
+		step.StepOrder = i + 1
+		step.Name = strings.TrimSpace(step.Name)
+		if step.Name == "" {
+			return fmt.Errorf("%w: etapa %d sem nome", ErrInvalidWorkflowChain, step.StepOrder)
+		}
+		switch step.ApproverType {
+		case models.ApproverDirectManager:
+			step.ApproverValue = ""
+		case models.ApproverRole, models.ApproverUser:
+			if step.ApproverValue == "" {
+				return fmt.Errorf("%w: etapa %d sem aprovador", ErrInvalidWorkflowChain, step.StepOrder)
+			}
+		default:
+			return fmt.Errorf("%w: tipo de aprovador inválido na etapa %d", ErrInvalidWorkflowChain, step.StepOrder)
+		}
+		if step.SLAHours < 0 {
+			step.SLAHours = 0
+		}
+	}
+	return nil
+}
+
+// SaveChain substitui a cadeia de um tipo de item. Fluxos em andamento mantêm as tarefas já criadas
+// e seguem as novas etapas a partir da próxima decisão.
+func (e *WorkflowEngine) SaveChain(chain models.WorkflowChain) (*models.WorkflowChain, error) {
+	if _, ok := e.subject(chain.SubjectType); !ok {
+		return nil, ErrApprovalUnknownSubject
+	}
+	if err := ValidateWorkflowChain(&chain); err != nil {
+		return nil, err
+	}
+	if chain.Name == "" {
+		chain.Name = DefaultWorkflowChain(chain.SubjectType).Name
+	}
+
+	steps := chain.Steps
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var existing models.WorkflowChain
+		if err := tx.Where("subject_type = ?", chain.SubjectType).First(&existing).Error; err == nil {
+			existing.Name = chain.Name
+			existing.Active = chain.Active
+			if err := tx.Model(&existing).Select("name", "active").Updates(&existing).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("chain_id = ?", existing.ID).Delete(&models.WorkflowChainStep{}).Error; err != nil {
+				return err
+			}
+			chain = existing
+		} else {
+			chain.ID = ""
+			chain.Steps = nil
+			if err := tx.Create(&chain).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range steps {
+			steps[i].ID = ""
+			steps[i].ChainID = chain.ID
+		}
+		chain.Steps = steps
+		return tx.Create(&chain.Steps).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &chain, nil
+}
+
+// ==================== CICLO DE VIDA ====================
+
+// Submit inicia o fluxo de aprovação de um item já salvo como pendente. Recebe a
+// transação em que o item foi gravado (ou config.DB), para os dois serem desfeitos juntos;
+// depois do commit o chamador avisa os aprovadores com NotifySubmitted.
+func (e *WorkflowEngine) Submit(tx *gorm.DB, subjectType models.ApprovalSubjectType, subjectID string) (*models.ApprovalRequest, error) {
+	handler, ok := e.subject(subjectType)
+	if !ok {
+		return nil, ErrApprovalUnknownSubject
+	}
+	subject, err := handler.Load(tx, subjectID)
+	if err != nil || subject == nil {
+		return nil, ErrApprovalNotFound
+	}
+	return e.Start(tx, *subject)
+}
+
+// Start cria a solicitação de aprovação e a tarefa da primeira etapa aplicável (em
+// request.Tasks). Um novo envio do mesmo item (ex: PDI devolvido para revisão) cancela o
+// fluxo anterior. Não notifica: a transação do chamador ainda pode ser desfeita.
+func (e *WorkflowEngine) Start(db *gorm.DB, subject ApprovalSubject) (*models.ApprovalRequest, error) {
+	if _, ok := e.subject(subject.Type); !ok {
+		return nil, ErrApprovalUnknownSubject
+	}
+
+	chain := e.Chain(subject.Type)
+	request := models.ApprovalRequest{
+		SubjectType: subject.Type,
+		SubjectID:   subject.ID,
+		Summary:     truncateSummary(subject.Summary),
+		RequesterID: subject.RequesterID,
+		Status:      models.ApprovalRequestPending,
+	}
+	if chain.ID != "" {
+		request.ChainID = &chain.ID
+	}
+
+	var task *models.ApprovalTask
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := cancelPendingRequests(tx, subject.Type, subject.ID, e.now()); err != nil {
+			return err
+		}
+		if err := tx.Create(&request).Error; err != nil {
+			return err
+		}
+
+		var err error
+		task, err = e.advance(tx, &request, subject, chain.Steps, 0)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			// Nenhuma etapa aplicável (ex: sem gestor cadastrado): vai direto para o RH
+			fallback := e.newTask(models.WorkflowChainStep{StepOrder: lastStepOrder(chain.Steps), Name: "RH", SLAHours: 72})
+			fallback.RequestID = request.ID
+			fallback.AssigneeRole = e.EscalationRole
+			if err := tx.Create(&fallback).Error; err != nil {
+				return err
+			}
+			task = &fallback
+			request.CurrentStep = fallback.StepOrder
+		}
+		return tx.Model(&request).Update("current_step", request.CurrentStep).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	request.Tasks = []models.ApprovalTask{*task}
+	return &request, nil
+}
+
+// NotifySubmitted avisa os aprovadores da primeira etapa de um fluxo iniciado por
+// Submit/Start, depois que a transação que criou o item foi confirmada
+func (e *WorkflowEngine) NotifySubmitted(request *models.ApprovalRequest) {
+	if request == nil {
+		return
+	}
+	for _, task := range request.Tasks {
+		if task.Status == models.ApprovalTaskPending {
+			e.notifyAssignees(task, *request)
+		}
+	}
+}
+
+// Cancel encerra o fluxo pendente de um item (ex: férias canceladas pelo colaborador),
+// na transação que alterou o item (ou config.DB)
+func (e *WorkflowEngine) Cancel(db *gorm.DB, subjectType models.ApprovalSubjectType, subjectID string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return cancelPendingRequests(tx, subjectType, subjectID, e.now())
+	})
+}
+
+func cancelPendingRequests(tx *gorm.DB, subjectType models.ApprovalSubjectType, subjectID string, now time.Time) error {
+	var requestIDs []string
+	if err := tx.Model(&models.ApprovalRequest{}).
+		Where("subject_type = ? AND subject_id = ? AND status = ?", subjectType, subjectID, models.ApprovalRequestPending).
+		Pluck("id", &requestIDs).Error; err != nil {
+		return err
+	}
+	if len(requestIDs) == 0 {
+		return nil
+	}
+
+	if err := tx.Model(&models.ApprovalTask{}).
+		Where("request_id IN ? AND status = ?", requestIDs, models.ApprovalTaskPending).
+		Update("status", models.ApprovalTaskCanceled).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.ApprovalRequest{}).
+		Where("id IN ?", requestIDs).
+		Updates(map[string]interface{}{
+			"status":       models.ApprovalRequestCanceled,
+			"completed_at": now,
+		}).Error
+}
+
+// Decide registra a decisão na etapa atual do item. Itens pendentes criados antes do
+// workflow (sem solicitação) entram no fluxo automaticamente.
+func (e *WorkflowEngine) Decide(subjectType models.ApprovalSubjectType, subjectID, actorID string, decision ApprovalDecision, comment string) (*ApprovalOutcome, error) {
+	handler, ok := e.subject(subjectType)
+	if !ok {
+		return nil, ErrApprovalUnknownSubject
+	}
+
+	var request models.ApprovalRequest
+	err := config.DB.Where("subject_type = ? AND subject_id = ? AND status = ?",
+		subjectType, subjectID, models.ApprovalRequestPending).
+		Order("created_at DESC").First(&request).Error
+	if err != nil {
+		started, startErr := e.Submit(config.DB, subjectType, subjectID)
+		if startErr != nil {
+			return nil, startErr
+		}
+		e.NotifySubmitted(started)
+		request = *started
+	}
+
+	var task models.ApprovalTask
+	if err := config.DB.Where("request_id = ? AND status = ?", request.ID, models.ApprovalTaskPending).
+		Order("step_order DESC").First(&task).Error; err != nil {
+		return nil, ErrApprovalNotFound
+	}
+
+	return e.decide(handler, request, task, actorID, decision, comment)
+}
+
+// DecideTask registra a decisão a partir de uma tarefa da caixa de aprovações
+func (e *WorkflowEngine) DecideTask(taskID, actorID string, decision ApprovalDecision, comment string) (*ApprovalOutcome, error) {
+	var task models.ApprovalTask
+	if err := config.DB.Where("id = ? AND status = ?", taskID, models.ApprovalTaskPending).First(&task).Error; err != nil {
+		return nil, ErrApprovalNotFound
+	}
+
+	var request models.ApprovalRequest
+	if err := config.DB.Where("id = ? AND status = ?", task.RequestID, models.ApprovalRequestPending).First(&request).Error; err != nil {
+		return nil, ErrApprovalNotFound
+	}
+
+	handler, ok := e.subject(request.SubjectType)
+	if !ok {
+		return nil, ErrApprovalUnknownSubject
+	}
+	return e.decide(handler, request, task, actorID, decision, comment)
+}
+
+func (e *WorkflowEngine) decide(handler ApprovalSubjectHandler, request models.ApprovalRequest, task models.ApprovalTask, actorID string, decision ApprovalDecision, comment string) (*ApprovalOutcome, error) {
+	var actor models.User
+	if err := config.DB.Where("id = ?", actorID).First(&actor).Error; err != nil {
+		return nil, ErrApprovalForbidden
+	}
+	if actor.ID == request.RequesterID {
+		return nil, ErrApprovalSelf
+	}
+	delegators := delegatorIDs(e.activeDelegationsTo(actor.ID), request.SubjectType)
+	access, _ := e.Authz.Resolve(actor.ID, approvalResource(request.SubjectType), ActionApprove)
+	if !canDecideTask(task, actor.ID, delegators, access, request.RequesterID) {
+		return nil, ErrApprovalForbidden
+	}
+
+	// Recarrega o item para resolver o gestor das próximas etapas
+	subject := ApprovalSubject{Type: request.SubjectType, ID: request.SubjectID, RequesterID: request.RequesterID, Summary: request.Summary}
+	if loaded, err := handler.Load(config.DB, request.SubjectID); err == nil && loaded != nil {
+		subject = *loaded
+	}
+
+	now := e.now()
+	taskStatus := models.ApprovalTaskApproved
+	if decision == ApprovalReject {
+		taskStatus = models.ApprovalTaskRejected
+	}
+
+	outcome := &ApprovalOutcome{}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Atualização condicional evita duas decisões simultâneas na mesma tarefa
+		result := tx.Model(&models.ApprovalTask{}).
+			Where("id = ? AND status = ?", task.ID, models.ApprovalTaskPending).
+			Updates(map[string]interface{}{
+				"status":     taskStatus,
+				"decided_by": actor.ID,
+				"decided_at": now,
+				"comment":    comment,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrApprovalNotFound
+		}
+
+		if decision == ApprovalApprove {
+			next, err := e.advance(tx, &request, subject, e.Chain(request.SubjectType).Steps, task.StepOrder)
+			if err != nil {
+				return err
+			}
+			if next != nil {
+				outcome.NextTask = next
+				return tx.Model(&request).Update("current_step", request.CurrentStep).Error
+			}
+		}
+
+		outcome.Final = true
+		request.Status = models.ApprovalRequestApproved
+		if decision == ApprovalReject {
+			request.Status = models.ApprovalRequestRejected
+		}
+		request.CompletedAt = &now
+		if err := tx.Model(&request).Updates(map[string]interface{}{
+			"status":       request.Status,
+			"completed_at": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		// O resultado no item faz parte da decisão: se falhar, a etapa volta a ficar pendente
+		if decision == ApprovalReject {
+			return handler.Reject(tx, request.SubjectID, actor.ID, comment)
+		}
+		return handler.Approve(tx, request.SubjectID, actor.ID, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	outcome.Request = request
+
+	if !outcome.Final {
+		e.notifyAssignees(*outcome.NextTask, request)
+		return outcome, nil
+	}
+	handler.Notify(request.SubjectID)
+	return outcome, nil
+}
+
+// advance cria a tarefa da próxima etapa aplicável após a etapa informada.
+// Retorna nil quando não há mais etapas.
+func (e *WorkflowEngine) advance(tx *gorm.DB, request *models.ApprovalRequest, subject ApprovalSubject, steps []models.WorkflowChainStep, after int) (*models.ApprovalTask, error) {
+	for _, step := range steps {
+		if step.StepOrder <= after {
+			continue
+		}
+		task, ok := e.buildTask(step, subject)
+		if !ok {
+			continue
+		}
+		task.RequestID = request.ID
+		if err := tx.Create(&task).Error; err != nil {
+			return nil, err
+		}
+		request.CurrentStep = step.StepOrder
+		return &task, nil
+	}
+	return nil, nil
+}
+
+func lastStepOrder(steps []models.WorkflowChainStep) int {
+	last := 1
+	for _, step := range steps {
+		if step.StepOrder > last {
+			last = step.StepOrder
+		}
+	}
+	return last
+}
+
+func (e *WorkflowEngine) newTask(step models.WorkflowChainStep) models.ApprovalTask {
+	task := models.ApprovalTask{
+		StepOrder: step.StepOrder,
+		StepName:  step.Name,
+		Status:    models.ApprovalTaskPending,
+	}
+	if step.SLAHours > 0 {
+		due := e.now().Add(time.Duration(step.SLAHours) * time.Hour)
+		task.DueAt = &due
+	}
+	return task
+}
+
+// buildTask resolve o aprovador da etapa. Etapas sem aprovador aplicável são puladas
+// (ex: colaborador sem gestor cadastrado, ou o próprio solicitante como aprovador).
+func (e *WorkflowEngine) buildTask(step models.WorkflowChainStep, subject ApprovalSubject) (models.ApprovalTask, bool) {
+	task := e.newTask(step)
+
+	switch step.ApproverType {
+	case models.ApproverDirectManager:
+		managerID := subject.ManagerID
+		if managerID == "" {
+			managerID = e.ResolveManager(subject.RequesterID)
+		}
+		if managerID == "" || managerID == subject.RequesterID {
+			return task, false
+		}
+		e.assignUser(&task, managerID, subject)
+	case models.ApproverUser:
+		if step.ApproverValue == "" || step.ApproverValue == subject.RequesterID {
+			return task, false
+		}
+		e.assignUser(&task, step.ApproverValue, subject)
+	case models.ApproverRole:
+		if step.ApproverValue == "" {
+			return task, false
+		}
+		task.AssigneeRole = step.ApproverValue
+	default:
+		return task, false
+	}
+	return task, true
+}
+
+// assignUser atribui a tarefa ao aprovador disponível, seguindo delegações e ausências
+func (e *WorkflowEngine) assignUser(task *models.ApprovalTask, userID string, subject ApprovalSubject) {
+	assignee, reason := resolveApprover(userID,
+		func(id string) string { return e.delegateOf(id, subject.Type) },
+		e.isAway,
+		e.ResolveManager,
+	)
+
+	if assignee == "" || assignee == subject.RequesterID {
+		// Ninguém disponível na linha hierárquica: a tarefa vai para o RH
+		task.AssigneeRole = e.EscalationRole
+		task.OriginalAssigneeID = &userID
+		task.DelegationReason = "Aprovador ausente sem substituto"
+		return
+	}
+
+	task.AssigneeID = &assignee
+	if assignee != userID {
+		task.OriginalAssigneeID = &userID
+		task.DelegationReason = reason
+	}
+}
+
+// resolveApprover segue delegações explícitas e, se o aprovador estiver ausente sem
+// delegação, sobe para o gestor dele. Retorna "" quando não encontra ninguém disponível.
+func resolveApprover(userID string, delegateOf func(string) string, isAway func(string) bool, managerOf func(string) string) (string, string) {
+	seen := make(map[string]bool)
+	current := userID
+	reason := ""
+
+	for hop := 0; hop < maxApproverHops; hop++ {
+		if current == "" || seen[current] {
+			return "", reason
+		}
+		seen[current] = true
+
+		if delegate := delegateOf(current); delegate != "" {
+			current = delegate
+			reason = "Delegação de aprovação"
+			continue
+		}
+		if isAway(current) {
+			current = managerOf(current)
+			reason = "Aprovador em férias/ausente"
+			continue
+		}
+		return current, reason
+	}
+	return "", reason
+}
+
+// canDecideTask verifica se o usuário pode decidir a tarefa: o próprio aprovador ou um
+// delegado ativo. Fora isso vale só a permissão de aprovação resolvida pelo Authz: o papel
+// efetivo precisa ser o da etapa (ou admin) e a abrangência precisa alcançar o solicitante
+// (filial do RH, equipe do gestor). access é nil quando o papel não aprova o recurso.
+func canDecideTask(task models.ApprovalTask, actorID string, delegators []string, access *Access, requesterID string) bool {
+	if task.AssigneeID != nil {
+		if *task.AssigneeID == actorID {
+			return true
+		}
+		for _, id := range delegators {
+			if id == *task.AssigneeID {
+				return true
+			}
+		}
+	}
+	if access == nil || !access.Allows(requesterID) {
+		return false
+	}
+	return access.Role == RoleAdmin || (task.AssigneeRole != "" && task.AssigneeRole == access.Role)
+}
+
+// approvalResource recurso do Authz cuja permissão de aprovação vale para o tipo de item
+func approvalResource(subjectType models.ApprovalSubjectType) string {
+	switch subjectType {
+	case models.ApprovalSubjectVacation, models.ApprovalSubjectVacationSell:
+		return ResourceVacation
+	case models.ApprovalSubjectDocument:
+		return ResourceDocument
+	case models.ApprovalSubjectPDI:
+		return ResourcePDI
+	}
+	return ""
+}
+
+// ==================== DELEGAÇÃO E AUSÊNCIAS ====================
+
+func (e *WorkflowEngine) activeDelegationsFrom(userID string) []models.ApprovalDelegation {
+	today := truncateDay(e.now())
+	var delegations []models.ApprovalDelegation
+	config.DB.Where("delegator_id = ? AND start_date <= ? AND end_date >= ?", userID, today, today).
+		Order("created_at DESC").
+		Find(&delegations)
+	return delegations
+}
+
+func (e *WorkflowEngine) activeDelegationsTo(userID string) []models.ApprovalDelegation {
+	today := truncateDay(e.now())
+	var delegations []models.ApprovalDelegation
+	config.DB.Where("delegate_id = ? AND start_date <= ? AND end_date >= ?", userID, today, today).
+		Find(&delegations)
+	return delegations
+}
+
+func (e *WorkflowEngine) delegateOf(userID string, subjectType models.ApprovalSubjectType) string {
+	for _, d := range e.activeDelegationsFrom(userID) {
+		if d.SubjectType == "" || d.SubjectType == subjectType {
+			return d.DelegateID
+		}
+	}
+	return ""
+}
+
+// delegatorIDs usuários cujas aprovações do tipo foram delegadas
+func delegatorIDs(delegations []models.ApprovalDelegation, subjectType models.ApprovalSubjectType) []string {
+	var ids []string
+	for _, d := range delegations {
+		if d.SubjectType == "" || d.SubjectType == subjectType {
+			ids = append(ids, d.DelegatorID)
+		}
+	}
+	return ids
+}
+
+// isAway indica se o usuário está em férias/ausência aprovada hoje
+func (e *WorkflowEngine) isAway(userID string) bool {
+	today := truncateDay(e.now())
+	var count int64
+	config.DB.Model(&models.Vacation{}).
+		Where("user_id = ? AND status = ? AND type <> ? AND start_date <= ? AND end_date >= ?",
+			userID, models.VacationStatusApproved, models.VacationTypeHomeOffice, today, today).
+		Count(&count)
+	return count > 0
+}
+
+// ==================== CAIXA DE APROVAÇÕES ====================
+
+// Inbox retorna as tarefas pendentes que o usuário pode decidir: atribuídas a ele,
+// ao seu papel ou a quem delegou aprovações para ele
+func (e *WorkflowEngine) Inbox(userID string) ([]models.ApprovalTask, error) {
+	var user models.User
+	if err := config.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, err
+	}
+
+	delegations := e.activeDelegationsTo(userID)
+	assignees := []string{userID}
+	for _, d := range delegations {
+		assignees = append(assignees, d.DelegatorID)
+	}
+
+	var tasks []models.ApprovalTask
+	err := config.DB.Preload("Request").Preload("Request.Requester").Preload("Assignee").
+		Where("status = ?", models.ApprovalTaskPending).
+		Where("assignee_id IN ? OR assignee_role = ?", assignees, e.Authz.EffectiveRole(userID, user.Role)).
+		Order("due_at ASC, created_at ASC").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// As tarefas do papel só entram na caixa quando o solicitante está na abrangência
+	// de aprovação do usuário (ex: RH vê apenas a própria filial)
+	scopes := make(map[string]*Access)
+	accessFor := func(subjectType models.ApprovalSubjectType) *Access {
+		resource := approvalResource(subjectType)
+		access, ok := scopes[resource]
+		if !ok {
+			access, _ = e.Authz.ResolveRole(userID, user.Role, resource, ActionApprove)
+			scopes[resource] = access
+		}
+		return access
+	}
+
+	inbox := make([]models.ApprovalTask, 0, len(tasks))
+	for _, task := range tasks {
+		if task.Request == nil || task.Request.RequesterID == userID {
+			continue
+		}
+		subjectType := task.Request.SubjectType
+		if canDecideTask(task, userID, delegatorIDs(delegations, subjectType), accessFor(subjectType), task.Request.RequesterID) {
+			inbox = append(inbox, task)
+		}
+	}
+	return inbox, nil
+}
+
+// History retorna as solicitações de aprovação de um item com todas as tarefas
+func (e *WorkflowEngine) History(subjectType models.ApprovalSubjectType, subjectID string) ([]models.ApprovalRequest, error) {
+	var requests []models.ApprovalRequest
+	err := config.DB.Preload("Tasks", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).Preload("Tasks.Assignee").
+		Where("subject_type = ? AND subject_id = ?", subjectType, subjectID).
+		Order("created_at DESC").
+		Find(&requests).Error
+	return requests, err
+}
+
+// ==================== SLA E ESCALAÇÃO ====================
+
+// EscalateOverdue repassa as tarefas com prazo vencido ao gestor do aprovador ou ao RH
+func (e *WorkflowEngine) EscalateOverdue() (int, error) {
+	now := e.now()
+
+	var tasks []models.ApprovalTask
+	if err := config.DB.Preload("Request").
+		Where("status = ? AND due_at IS NOT NULL AND due_at < ?", models.ApprovalTaskPending, now).
+		Find(&tasks).Error; err != nil {
+		return 0, err
+	}
+
+	escalated := 0
+	for _, task := range tasks {
+		if task.Request == nil || task.Request.Status != models.ApprovalRequestPending {
+			continue
+		}
+		if e.escalate(task, now) {
+			escalated++
+		}
+	}
+	return escalated, nil
+}
+
+func (e *WorkflowEngine) escalate(task models.ApprovalTask, now time.Time) bool {
+	request := *task.Request
+	sla := task.DueAt.Sub(task.CreatedAt)
+	next := models.ApprovalTask{
+		RequestID:          task.RequestID,
+		StepOrder:          task.StepOrder,
+		StepName:           task.StepName,
+		Status:             models.ApprovalTaskPending,
+		OriginalAssigneeID: task.AssigneeID,
+		DelegationReason:   "Escalada por prazo vencido",
+	}
+	if sla > 0 {
+		due := now.Add(sla)
+		next.DueAt = &due
+	}
+
+	switch {
+	case task.AssigneeID != nil:
+		managerID := e.ResolveManager(*task.AssigneeID)
+		if managerID != "" && managerID != request.RequesterID {
+			subject := ApprovalSubject{Type: request.SubjectType, ID: request.SubjectID, RequesterID: request.RequesterID}
+			e.assignUser(&next, managerID, subject)
+			next.OriginalAssigneeID = task.AssigneeID
+			next.DelegationReason = "Escalada por prazo vencido"
+		} else {
+			next.AssigneeRole = e.EscalationRole
+		}
+	case task.AssigneeRole != e.EscalationRole:
+		next.AssigneeRole = e.EscalationRole
+	default:
+		// Já está no topo da escalação: apenas reforça o aviso e remove o prazo
+		config.DB.Model(&models.ApprovalTask{}).Where("id = ?", task.ID).Update("due_at", nil)
+		e.notify(e.recipients(task), "Aprovação em atraso",
+			fmt.Sprintf("%s aguarda aprovação (%s) além do prazo", request.Summary, task.StepName))
+		return false
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&next).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.ApprovalTask{}).
+			Where("id = ? AND status = ?", task.ID, models.ApprovalTaskPending).
+			Updates(map[string]interface{}{
+				"status":       models.ApprovalTaskEscalated,
+				"escalated_to": next.ID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrApprovalNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return false
+	}
+
+	e.notifyAssignees(next, request)
+	return true
+}
+
+// BackfillPending cria fluxos para itens pendentes anteriores ao workflow
+func (e *WorkflowEngine) BackfillPending() (int, error) {
+	e.mu.RLock()
+	handlers := make(map[models.ApprovalSubjectType]ApprovalSubjectHandler, len(e.subjects))
+	for subjectType, handler := range e.subjects {
+		handlers[subjectType] = handler
+	}
+	e.mu.RUnlock()
+
+	started := 0
+	for subjectType, handler := range handlers {
+		subjects, err := handler.Pending()
+		if err != nil {
+			return started, err
+		}
+
+		var withRequest []string
+		config.DB.Model(&models.ApprovalRequest{}).
+			Where("subject_type = ? AND status = ?", subjectType, models.ApprovalRequestPending).
+			Pluck("subject_id", &withRequest)
+		known := make(map[string]bool, len(withRequest))
+		for _, id := range withRequest {
+			known[id] = true
+		}
+
+		for _, subject := range subjects {
+			if known[subject.ID] {
+				continue
+			}
+			if request, err := e.Start(config.DB, subject); err == nil {
+				e.NotifySubmitted(request)
+				started++
+			}
+		}
+	}
+	return started, nil
+}
+
+// StartEscalationWorker verifica periodicamente os prazos das tarefas pendentes
+func (e *WorkflowEngine) StartEscalationWorker(ctx context.Context, interval time.Duration) {
+	if config.DB == nil {
+		return
+	}
+
+	go func() {
+		if started, err := e.BackfillPending(); err != nil {
+			log.Printf("⚠️ Workflow: erro ao migrar aprovações pendentes: %v", err)
+		} else if started > 0 {
+			log.Printf("📋 Workflow: %d aprovações pendentes migradas", started)
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if escalated, err := e.EscalateOverdue(); err != nil {
+				log.Printf("⚠️ Workflow: erro ao escalar aprovações: %v", err)
+			} else if escalated > 0 {
+				log.Printf("⏰ Workflow: %d aprovações escaladas por prazo", escalated)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// ==================== NOTIFICAÇÕES ====================
+
+func (e *WorkflowEngine) recipients(task models.ApprovalTask) []string {
+	if task.AssigneeID != nil {
+		return []string{*task.AssigneeID}
+	}
+	var ids []string
+	config.DB.Model(&models.User{}).Where("role = ?", task.AssigneeRole).Pluck("id", &ids)
+	return ids
+}
+
+func (e *WorkflowEngine) notifyAssignees(task models.ApprovalTask, request models.ApprovalRequest) {
+	message := fmt.Sprintf("%s aguarda sua aprovação (%s)", request.Summary, task.StepName)
+	if task.DueAt != nil {
+		message += " até " + task.DueAt.Format("02/01/2006 15:04")
+	}
+	e.notify(e.recipients(task), "Nova aprovação pendente", message)
+}
+
+func (e *WorkflowEngine) notify(userIDs []string, title, message string) {
+	for _, userID := range userIDs {
+		notification := models.Notification{
+			UserID:   userID,
+			Title:    title,
+			Message:  truncateSummary(message),
+			Type:     models.NotificationTypeInfo,
+			Category: models.NotificationCategoryApproval,
+			Link:     "/aprovacoes",
+		}
+		if config.DB.Create(&notification).Error == nil {
+			Events.Publish(EventNotificationCreated, userID, notification)
+		}
+	}
+}
+
+func truncateSummary(s string) string {
+	runes := []rune(s)
+	if len(runes) > 500 {
+		return string(runes[:497]) + "..."
+	}
+	return s
+}
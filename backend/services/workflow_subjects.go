@@ -0,0 +1,365 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/frappyou/backend/config"
+	"github.com/frappyou/backend/models"
+	"gorm.io/gorm"
+)
+
+// ==================== Itens do Workflow de Aprovações ====================
+
+func registerDefaultApprovalSubjects(e *WorkflowEngine) {
+	ledger := NewVacationLedger()
+	e.RegisterSubject(models.ApprovalSubjectVacation, vacationApprovalSubject{ledger: ledger})
+	e.RegisterSubject(models.ApprovalSubjectVacationSell, vacationSellApprovalSubject{ledger: ledger})
+	e.RegisterSubject(models.ApprovalSubjectDocument, documentApprovalSubject{})
+	e.RegisterSubject(models.ApprovalSubjectPDI, pdiApprovalSubject{})
+}
+
+func notifyApprovalResult(notification models.Notification) {
+	if config.DB.Create(&notification).Error == nil {
+		Events.Publish(EventNotificationCreated, notification.UserID, notification)
+	}
+}
+
+func approvalStatusText(approved bool) string {
+	if approved {
+		return "aprovada"
+	}
+	return "rejeitada"
+}
+
+// ==================== FÉRIAS ====================
+
+type vacationApprovalSubject struct {
+	ledger *VacationLedger
+}
+
+func vacationSubject(v models.Vacation) ApprovalSubject {
+	return ApprovalSubject{
+		Type:        models.ApprovalSubjectVacation,
+		ID:          v.ID,
+		RequesterID: v.UserID,
+		Summary: fmt.Sprintf("%s de %s: %s a %s (%d dias)", v.GetTypeLabel(), v.User.Name,
+			v.StartDate.Format("02/01/2006"), v.EndDate.Format("02/01/2006"), v.TotalDays),
+	}
+}
+
+func (s vacationApprovalSubject) Load(tx *gorm.DB, subjectID string) (*ApprovalSubject, error) {
+	var vacation models.Vacation
+	if err := tx.Preload("User").Where("id = ?", subjectID).First(&vacation).Error; err != nil {
+		return nil, err
+	}
+	if vacation.Status != models.VacationStatusPending {
+		return nil, nil
+	}
+	subject := vacationSubject(vacation)
+	return &subject, nil
+}
+
+func (s vacationApprovalSubject) Pending() ([]ApprovalSubject, error) {
+	var vacations []models.Vacation
+	if err := config.DB.Preload("User").Where("status = ?", models.VacationStatusPending).Find(&vacations).Error; err != nil {
+		return nil, err
+	}
+	subjects := make([]ApprovalSubject, 0, len(vacations))
+	for _, v := range vacations {
+		subjects = append(subjects, vacationSubject(v))
+	}
+	return subjects, nil
+}
+
+func (s vacationApprovalSubject) Approve(tx *gorm.DB, subjectID, actorID, comment string) error {
+	return s.finish(tx, subjectID, actorID, models.VacationStatusApproved, comment)
+}
+
+func (s vacationApprovalSubject) Reject(tx *gorm.DB, subjectID, actorID, comment string) error {
+	return s.finish(tx, subjectID, actorID, models.VacationStatusRejected, comment)
+}
+
+func (s vacationApprovalSubject) finish(tx *gorm.DB, subjectID, actorID string, status models.VacationStatus, comment string) error {
+	var vacation models.Vacation
+	if err := tx.Where("id = ? AND status = ?", subjectID, models.VacationStatusPending).First(&vacation).Error; err != nil {
+		return ErrApprovalNotFound
+	}
+
+	now := time.Now()
+	vacation.Status = status
+	vacation.ApprovedBy = &actorID
+	vacation.ApprovedAt = &now
+	if status == models.VacationStatusRejected {
+		vacation.RejectReason = comment
+	}
+
+	if err := tx.Save(&vacation).Error; err != nil {
+		return err
+	}
+
+	// Converte a reserva em consumo (aprovada) ou libera os dias (rejeitada); abonos
+	// aprovados entram no extrato como lançamento próprio
+	_, err := s.ledger.SyncVacation(tx, vacation, actorID)
+	return err
+}
+
+func (s vacationApprovalSubject) Notify(subjectID string) {
+	var vacation models.Vacation
+	if err := config.DB.Where("id = ?", subjectID).First(&vacation).Error; err != nil {
+		return
+	}
+	Events.Publish(EventVacationUpdated, vacation.UserID, vacation)
+
+	statusMsg := approvalStatusText(vacation.Status == models.VacationStatusApproved)
+	notifyApprovalResult(models.Notification{
+		UserID:   vacation.UserID,
+		Title:    vacation.GetTypeLabel() + " " + statusMsg,
+		Message:  fmt.Sprintf("Sua solicitação de %s a %s foi %s", vacation.StartDate.Format("02/01/2006"), vacation.EndDate.Format("02/01/2006"), statusMsg),
+		Type:     models.NotificationTypeVacation,
+		Category: models.NotificationCategoryVacation,
+	})
+}
+
+// ==================== VENDA DE FÉRIAS ====================
+
+type vacationSellApprovalSubject struct {
+	ledger *VacationLedger
+}
+
+func vacationSellSubject(r models.VacationSellRequest) ApprovalSubject {
+	return ApprovalSubject{
+		Type:        models.ApprovalSubjectVacationSell,
+		ID:          r.ID,
+		RequesterID: r.UserID,
+		Summary:     fmt.Sprintf("Venda de %d dias de férias de %s", r.DaysToSell, r.User.Name),
+	}
+}
+
+func (s vacationSellApprovalSubject) Load(tx *gorm.DB, subjectID string) (*ApprovalSubject, error) {
+	var request models.VacationSellRequest
+	if err := tx.Preload("User").Where("id = ?", subjectID).First(&request).Error; err != nil {
+		return nil, err
+	}
+	if request.Status != models.VacationSellStatusPending {
+		return nil, nil
+	}
+	subject := vacationSellSubject(request)
+	return &subject, nil
+}
+
+func (s vacationSellApprovalSubject) Pending() ([]ApprovalSubject, error) {
+	var requests []models.VacationSellRequest
+	if err := config.DB.Preload("User").Where("status = ?", models.VacationSellStatusPending).Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	subjects := make([]ApprovalSubject, 0, len(requests))
+	for _, r := range requests {
+		subjects = append(subjects, vacationSellSubject(r))
+	}
+	return subjects, nil
+}
+
+func (s vacationSellApprovalSubject) Approve(tx *gorm.DB, subjectID, actorID, comment string) error {
+	return s.finish(tx, subjectID, actorID, models.VacationSellStatusApproved, comment)
+}
+
+func (s vacationSellApprovalSubject) Reject(tx *gorm.DB, subjectID, actorID, comment string) error {
+	return s.finish(tx, subjectID, actorID, models.VacationSellStatusRejected, comment)
+}
+
+func (s vacationSellApprovalSubject) finish(tx *gorm.DB, subjectID, actorID string, status models.VacationSellStatus, comment string) error {
+	var sellRequest models.VacationSellRequest
+	if err := tx.Where("id = ? AND status = ?", subjectID, models.VacationSellStatusPending).First(&sellRequest).Error; err != nil {
+		return ErrApprovalNotFound
+	}
+
+	now := time.Now()
+	sellRequest.Status = status
+	sellRequest.ApprovedBy = &actorID
+	sellRequest.ApprovedAt = &now
+	if status == models.VacationSellStatusRejected {
+		sellRequest.RejectReason = comment
+	}
+
+	if err := tx.Save(&sellRequest).Error; err != nil {
+		return err
+	}
+
+	// Se aprovado, lança a venda no extrato de férias
+	_, err := s.ledger.SyncSale(tx, sellRequest, actorID)
+	return err
+}
+
+func (s vacationSellApprovalSubject) Notify(subjectID string) {
+	var sellRequest models.VacationSellRequest
+	if err := config.DB.Where("id = ?", subjectID).First(&sellRequest).Error; err != nil {
+		return
+	}
+
+	statusMsg := approvalStatusText(sellRequest.Status == models.VacationSellStatusApproved)
+	notifyApprovalResult(models.Notification{
+		UserID:  sellRequest.UserID,
+		Title:   "Venda de Férias " + statusMsg,
+		Message: fmt.Sprintf("Sua solicitação de venda de %d dias de férias foi %s", sellRequest.DaysToSell, statusMsg),
+		Type:    "vacation_sell",
+	})
+}
+
+// ==================== DOCUMENTOS ====================
+
+type documentApprovalSubject struct{}
+
+func documentSubject(d models.Document) ApprovalSubject {
+	return ApprovalSubject{
+		Type:        models.ApprovalSubjectDocument,
+		ID:          d.ID,
+		RequesterID: d.UserID,
+		Summary:     fmt.Sprintf("Documento %s (%s) de %s", d.OriginalName, d.Type, d.User.Name),
+	}
+}
+
+func (s documentApprovalSubject) Load(tx *gorm.DB, subjectID string) (*ApprovalSubject, error) {
+	var document models.Document
+	if err := tx.Preload("User").Where("id = ?", subjectID).First(&document).Error; err != nil {
+		return nil, err
+	}
+	if document.Status != models.DocumentStatusPending {
+		return nil, nil
+	}
+	subject := documentSubject(document)
+	return &subject, nil
+}
+
+func (s documentApprovalSubject) Pending() ([]ApprovalSubject, error) {
+	var documents []models.Document
+	if err := config.DB.Preload("User").Where("status = ?", models.DocumentStatusPending).Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	subjects := make([]ApprovalSubject, 0, len(documents))
+	for _, d := range documents {
+		subjects = append(subjects, documentSubject(d))
+	}
+	return subjects, nil
+}
+
+func (s documentApprovalSubject) Approve(tx *gorm.DB, subjectID, actorID, comment string) error {
+	return s.finish(tx, subjectID, actorID, models.DocumentStatusApproved, "")
+}
+
+func (s documentApprovalSubject) Reject(tx *gorm.DB, subjectID, actorID, comment string) error {
+	return s.finish(tx, subjectID, actorID, models.DocumentStatusRejected, comment)
+}
+
+func (s documentApprovalSubject) finish(tx *gorm.DB, subjectID, actorID string, status models.DocumentStatus, reason string) error {
+	// Atualização condicional: o documento pode ter saído de pendente desde o carregamento
+	// (excluído, revisado por outra via); nesse caso a decisão é desfeita
+	now := time.Now()
+	result := tx.Model(&models.Document{}).
+		Where("id = ? AND status = ?", subjectID, models.DocumentStatusPending).
+		Updates(map[string]interface{}{
+			"status":        status,
+			"reviewed_by":   actorID,
+			"reviewed_at":   now,
+			"reject_reason": reason,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrApprovalNotFound
+	}
+	return nil
+}
+
+func (s documentApprovalSubject) Notify(subjectID string) {
+	var document models.Document
+	if err := config.DB.First(&document, "id = ?", subjectID).Error; err == nil {
+		Events.Publish(EventDocumentUpdated, document.UserID, document)
+	}
+}
+
+// ==================== PDI ====================
+
+type pdiApprovalSubject struct{}
+
+func pdiSubject(p models.PDI) ApprovalSubject {
+	subject := ApprovalSubject{
+		Type:        models.ApprovalSubjectPDI,
+		ID:          p.ID,
+		RequesterID: p.UserID,
+		Summary:     "PDI " + p.Title,
+	}
+	if p.User != nil {
+		subject.Summary += " de " + p.User.Name
+	}
+	if p.ManagerID != nil {
+		subject.ManagerID = *p.ManagerID
+	}
+	return subject
+}
+
+func (s pdiApprovalSubject) Load(tx *gorm.DB, subjectID string) (*ApprovalSubject, error) {
+	var pdi models.PDI
+	if err := tx.Preload("User").Where("id = ?", subjectID).First(&pdi).Error; err != nil {
+		return nil, err
+	}
+	if pdi.Status != models.PDIStatusPending {
+		return nil, nil
+	}
+	subject := pdiSubject(pdi)
+	return &subject, nil
+}
+
+func (s pdiApprovalSubject) Pending() ([]ApprovalSubject, error) {
+	var pdis []models.PDI
+	if err := config.DB.Preload("User").Where("status = ?", models.PDIStatusPending).Find(&pdis).Error; err != nil {
+		return nil, err
+	}
+	subjects := make([]ApprovalSubject, 0, len(pdis))
+	for _, p := range pdis {
+		subjects = append(subjects, pdiSubject(p))
+	}
+	return subjects, nil
+}
+
+func (s pdiApprovalSubject) Approve(tx *gorm.DB, subjectID, actorID, comment string) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      models.PDIStatusApproved,
+		"approved_at": &now,
+	}
+	if comment != "" {
+		updates["manager_feedback"] = comment
+	}
+	return finishPendingPDI(tx, subjectID, updates)
+}
+
+// Reject devolve o PDI para rascunho, permitindo revisão e novo envio
+func (s pdiApprovalSubject) Reject(tx *gorm.DB, subjectID, actorID, comment string) error {
+	updates := map[string]interface{}{
+		"status": models.PDIStatusDraft,
+	}
+	if comment != "" {
+		updates["manager_feedback"] = comment
+	}
+	return finishPendingPDI(tx, subjectID, updates)
+}
+
+// finishPendingPDI aplica o resultado só se o PDI ainda estiver pendente (o colaborador
+// pode tê-lo editado ou excluído desde o envio); caso contrário desfaz a decisão
+func finishPendingPDI(tx *gorm.DB, subjectID string, updates map[string]interface{}) error {
+	result := tx.Model(&models.PDI{}).
+		Where("id = ? AND status = ?", subjectID, models.PDIStatusPending).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrApprovalNotFound
+	}
+	return nil
+}
+
+// Notify o resultado do PDI aparece no próprio PDI; não há aviso separado
+func (s pdiApprovalSubject) Notify(subjectID string) {}
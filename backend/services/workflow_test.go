@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/frappyou/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveApprover(t *testing.T) {
+	delegations := map[string]string{"gestor": "substituto"}
+	away := map[string]bool{"diretor": true, "substituto": false}
+	managers := map[string]string{"diretor": "presidente", "gestor": "diretor"}
+
+	delegateOf := func(id string) string { return delegations[id] }
+	isAway := func(id string) bool { return away[id] }
+	managerOf := func(id string) string { return managers[id] }
+
+	// Aprovador disponível
+	assignee, reason := resolveApprover("rh", delegateOf, isAway, managerOf)
+	assert.Equal(t, "rh", assignee)
+	assert.Empty(t, reason)
+
+	// Delegação explícita
+	assignee, _ = resolveApprover("gestor", delegateOf, isAway, managerOf)
+	assert.Equal(t, "substituto", assignee)
+
+	// Aprovador em férias sem delegação sobe para o gestor dele
+	assignee, reason = resolveApprover("diretor", delegateOf, isAway, managerOf)
+	assert.Equal(t, "presidente", assignee)
+	assert.NotEmpty(t, reason)
+
+	// Ciclo de delegações não encontra ninguém
+	delegations["substituto"] = "gestor"
+	assignee, _ = resolveApprover("gestor", delegateOf, isAway, managerOf)
+	assert.Empty(t, assignee)
+
+	// Ausente sem gestor cadastrado
+	away["presidente"] = true
+	assignee, _ = resolveApprover("presidente", delegateOf, isAway, managerOf)
+	assert.Empty(t, assignee)
+}
+
+func TestCanDecideTask(t *testing.T) {
+	authz := newTestAuthz()
+	admin, err := authz.ResolveRole("admin", RoleAdmin, ResourceVacation, ActionApprove)
+	require.NoError(t, err)
+
+	gestor := "gestor"
+	task := models.ApprovalTask{AssigneeID: &gestor}
+	assert.True(t, canDecideTask(task, "gestor", nil, nil, "analista"))
+	assert.False(t, canDecideTask(task, "outro", nil, nil, "analista"))
+	assert.True(t, canDecideTask(task, "outro", []string{"gestor"}, nil, "analista"))
+	assert.True(t, canDecideTask(task, "admin", nil, admin, "analista"))
+
+	// Colaborador com subordinados decide as etapas do papel manager dentro da equipe
+	manager, err := authz.ResolveRole("gestor", RoleEmployee, ResourceVacation, ActionApprove)
+	require.NoError(t, err)
+	roleTask := models.ApprovalTask{AssigneeRole: RoleManager}
+	assert.True(t, canDecideTask(roleTask, "gestor", nil, manager, "analista"))
+	assert.False(t, canDecideTask(roleTask, "gestor", nil, manager, "operador"))
+	assert.False(t, canDecideTask(roleTask, "analista", []string{"gestor"}, nil, "assistente"))
+
+	// A etapa do RH e as escalações ficam com o papel hr
+	hr, err := authz.ResolveRole("rh", RoleHR, ResourceVacation, ActionApprove)
+	require.NoError(t, err)
+	hrTask := models.ApprovalTask{AssigneeRole: NewWorkflowEngine().EscalationRole}
+	assert.True(t, canDecideTask(hrTask, "rh", nil, hr, "operador"))
+	assert.False(t, canDecideTask(hrTask, "gestor", nil, manager, "analista"))
+}
+
+func TestCanDecideTaskOutsideFilial(t *testing.T) {
+	// RH da filial A não decide solicitações da filial B, nem pela etapa do RH nem
+	// pela tarefa escalada; o administrador continua alcançando as duas
+	filiais := map[string]string{"rh-a": "A", "colab-a": "A", "rh-b": "B", "colab-b": "B"}
+	authz := &AuthzService{
+		RoleOf:    func(userID string) (string, error) { return RoleHR, nil },
+		IsManager: func(userID string) bool { return false },
+		ReportsOf: func(userID string) []string { return nil },
+		FilialMembers: func(userID string) []string {
+			var members []string
+			for id, filial := range filiais {
+				if filial == filiais[userID] {
+					members = append(members, id)
+				}
+			}
+			return members
+		},
+	}
+	hrTask := models.ApprovalTask{AssigneeRole: RoleHR}
+
+	for _, resource := range []string{ResourceVacation, ResourceDocument} {
+		access, err := authz.Resolve("rh-a", resource, ActionApprove)
+		require.NoError(t, err)
+		assert.True(t, canDecideTask(hrTask, "rh-a", nil, access, "colab-a"), resource)
+		assert.False(t, canDecideTask(hrTask, "rh-a", nil, access, "colab-b"), resource)
+		assert.False(t, authz.Can("rh-a", resource, ActionApprove, "colab-b"), resource)
+	}
+
+	admin, err := authz.ResolveRole("admin", RoleAdmin, ResourceDocument, ActionApprove)
+	require.NoError(t, err)
+	assert.True(t, canDecideTask(hrTask, "admin", nil, admin, "colab-b"))
+}
+
+func TestApprovalResource(t *testing.T) {
+	assert.Equal(t, ResourceVacation, approvalResource(models.ApprovalSubjectVacationSell))
+	assert.Equal(t, ResourceDocument, approvalResource(models.ApprovalSubjectDocument))
+	assert.Equal(t, ResourcePDI, approvalResource(models.ApprovalSubjectPDI))
+	for _, subjectType := range ApprovalSubjectTypes {
+		assert.NotEmpty(t, approvalResource(subjectType))
+	}
+}
+
+func TestDelegatorIDs(t *testing.T) {
+	delegations := []models.ApprovalDelegation{
+		{DelegatorID: "a"},
+		{DelegatorID: "b", SubjectType: models.ApprovalSubjectDocument},
+	}
+	assert.Equal(t, []string{"a"}, delegatorIDs(delegations, models.ApprovalSubjectVacation))
+	assert.Equal(t, []string{"a", "b"}, delegatorIDs(delegations, models.ApprovalSubjectDocument))
+}
+
+func TestValidateWorkflowChain(t *testing.T) {
+	chain := models.WorkflowChain{
+		SubjectType: models.ApprovalSubjectVacation,
+		Steps: []models.WorkflowChainStep{
+			{Name: "Gestor", ApproverType: models.ApproverDirectManager, ApproverValue: "ignorado"},
+			{Name: " RH ", ApproverType: models.ApproverRole, ApproverValue: "admin", SLAHours: -1},
+			{Name: "Diretor", ApproverType: models.ApproverUser, ApproverValue: "diretor-id"},
+		},
+	}
+	assert.NoError(t, ValidateWorkflowChain(&chain))
+	assert.Equal(t, 3, chain.Steps[2].StepOrder)
+	assert.Equal(t, "RH", chain.Steps[1].Name)
+	assert.Equal(t, 0, chain.Steps[1].SLAHours)
+	assert.Empty(t, chain.Steps[0].ApproverValue)
+
+	invalid := models.WorkflowChain{Steps: []models.WorkflowChainStep{{Name: "Diretor", ApproverType: models.ApproverUser}}}
+	assert.True(t, errors.Is(ValidateWorkflowChain(&invalid), ErrInvalidWorkflowChain))
+
+	empty := models.WorkflowChain{}
+	assert.True(t, errors.Is(ValidateWorkflowChain(&empty), ErrInvalidWorkflowChain))
+}
+
+func TestDefaultWorkflowChain(t *testing.T) {
+	for _, subjectType := range ApprovalSubjectTypes {
+		chain := DefaultWorkflowChain(subjectType)
+		assert.NoError(t, ValidateWorkflowChain(&chain), subjectType)
+	}
+	vacation := DefaultWorkflowChain(models.ApprovalSubjectVacation)
+	assert.Len(t, vacation.Steps, 2)
+	assert.Equal(t, RoleHR, vacation.Steps[1].ApproverValue)
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// ==================== Leitor simples de XLSX ====================
+//
+// Lê a primeira planilha de um arquivo .xlsx (Office Open XML) como linhas de
+// texto. Números são devolvidos como gravados no arquivo (ponto decimal) e
+// datas como número serial do Excel.
+
+var ErrInvalidXLSX = errors.New("arquivo XLSX inválido")
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (r xlsxRichText) String() string {
+	if len(r.Runs) == 0 {
+		return r.Text
+	}
+	var b strings.Builder
+	for _, run := range r.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string       `xml:"r,attr"`
+			Type   string       `xml:"t,attr"`
+			Value  string       `xml:"v"`
+			Inline xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSX retorna as linhas da primeira planilha
+func ReadXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrInvalidXLSX
+	}
+	files := map[string]*zip.File{}
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := xlsxFirstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared xlsxSharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := xlsxDecode(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	var sheet xlsxSheet
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, ErrInvalidXLSX
+	}
+	if err := xlsxDecode(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		// Linhas vazias não são gravadas: mantém a numeração original
+		for row.Number > len(rows)+1 {
+			rows = append(rows, nil)
+		}
+		var values []string
+		for i, cell := range row.Cells {
+			col := xlsxColumnIndex(cell.Ref)
+			if col < 0 {
+				col = i
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				idx := 0
+				for _, r := range cell.Value {
+					idx = idx*10 + int(r-'0')
+				}
+				if idx < len(shared.Items) {
+					values[col] = shared.Items[idx].String()
+				}
+			case "inlineStr":
+				values[col] = cell.Inline.String()
+			default:
+				values[col] = cell.Value
+			}
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// xlsxFirstSheet resolve o caminho da primeira planilha pelo workbook
+func xlsxFirstSheet(files map[string]*zip.File) (string, error) {
+	var workbook xlsxWorkbook
+	var rels xlsxRelationships
+	wb, okWb := files["xl/workbook.xml"]
+	rel, okRel := files["xl/_rels/workbook.xml.rels"]
+	if okWb && okRel && xlsxDecode(wb, &workbook) == nil && xlsxDecode(rel, &rels) == nil && len(workbook.Sheets) > 0 {
+		for _, r := range rels.Relationships {
+			if r.ID == workbook.Sheets[0].RID {
+				target := strings.TrimPrefix(r.Target, "/")
+				if !strings.HasPrefix(target, "xl/") {
+					target = path.Join("xl", target)
+				}
+				return target, nil
+			}
+		}
+	}
+	if _, ok := files["xl/worksheets/sheet1.xml"]; ok {
+		return "xl/worksheets/sheet1.xml", nil
+	}
+	return "", ErrInvalidXLSX
+}
+
+func xlsxDecode(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return ErrInvalidXLSX
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, 64<<20)).Decode(v); err != nil {
+		return ErrInvalidXLSX
+	}
+	return nil
+}
+
+// xlsxColumnIndex converte a referência da célula (ex: "AB12") no índice da coluna (0 = A)
+func xlsxColumnIndex(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A') + 1
+	}
+	return col - 1
+}
@@ -313,6 +313,8 @@ export default function AdminDocumentosPage() {
         return { bg: "rgba(245, 158, 11, 0.2)", color: "#F59E0B" };
       case "rejected":
         return { bg: "rgba(239, 68, 68, 0.2)", color: "#EF4444" };
+      case "quarantined":
+        return { bg: "rgba(139, 92, 246, 0.2)", color: "#8B5CF6" };
       default:
         return { bg: "rgba(107, 114, 128, 0.2)", color: "#6B7280" };
     }
@@ -323,6 +325,7 @@ export default function AdminDocumentosPage() {
       pending: "Pendente",
       approved: "Aprovado",
       rejected: "Rejeitado",
+      quarantined: "Em verificação",
     };
     return labels[status] || status;
   };
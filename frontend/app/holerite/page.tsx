@@ -84,6 +84,11 @@ export default function HoleritePage() {
   const [earnings, setEarnings] = useState<PayslipItem[]>([]);
   const [deductions, setDeductions] = useState<PayslipItem[]>([]);
   const [loadingDetail, setLoadingDetail] = useState(false);
+  const [downloading, setDownloading] = useState(false);
+  const [acknowledging, setAcknowledging] = useState(false);
+  const [ackError, setAckError] = useState("");
+  const [downloadingStatement, setDownloadingStatement] = useState(false);
+  const [statementError, setStatementError] = useState("");
 
   useEffect(() => {
     setMounted(true);
@@ -149,6 +154,74 @@ export default function HoleritePage() {
     window.print();
   };
 
+  const handleDownloadPdf = async () => {
+    if (!selectedPayslip) return;
+    setDownloading(true);
+    try {
+      const blob = await payslipAPI.downloadPdf(selectedPayslip.id);
+      const url = URL.createObjectURL(blob);
+      const link = document.createElement("a");
+      link.href = url;
+      link.download = `holerite-${selectedPayslip.reference_year}-${String(
+        selectedPayslip.reference_month
+      ).padStart(2, "0")}.pdf`;
+      link.click();
+      URL.revokeObjectURL(url);
+    } catch (error) {
+      console.error("Erro ao baixar PDF:", error);
+    } finally {
+      setDownloading(false);
+    }
+  };
+
+  // Informe de rendimentos do ano selecionado (declaração do IR)
+  const handleDownloadIncomeStatement = async () => {
+    setDownloadingStatement(true);
+    setStatementError("");
+    try {
+      const blob = await payslipAPI.downloadIncomeStatementPdf(selectedYear);
+      const url = URL.createObjectURL(blob);
+      const link = document.createElement("a");
+      link.href = url;
+      link.download = `informe-rendimentos-${selectedYear}.pdf`;
+      link.click();
+      URL.revokeObjectURL(url);
+    } catch (error) {
+      setStatementError(
+        error instanceof Error ? error.message : "Erro ao gerar informe"
+      );
+    } finally {
+      setDownloadingStatement(false);
+    }
+  };
+
+  const handleAcknowledge = async () => {
+    if (!selectedPayslip) return;
+    setAcknowledging(true);
+    setAckError("");
+    try {
+      const res = await payslipAPI.acknowledge(selectedPayslip.id);
+      setSelectedPayslip({
+        ...selectedPayslip,
+        status: "ciente",
+        acknowledged_at: res.acknowledged_at,
+      });
+      setPayslips((prev) =>
+        prev.map((p) =>
+          p.id === selectedPayslip.id
+            ? { ...p, status: "ciente", acknowledged_at: res.acknowledged_at }
+            : p
+        )
+      );
+    } catch (error) {
+      setAckError(
+        error instanceof Error ? error.message : "Erro ao confirmar recebimento"
+      );
+    } finally {
+      setAcknowledging(false);
+    }
+  };
+
   const formatCurrency = (value: number) => {
     return new Intl.NumberFormat("pt-BR", {
       style: "currency",
@@ -237,35 +310,51 @@ export default function HoleritePage() {
               </Box>
             </Stack>
 
-            <FormControl size="small" sx={{ minWidth: 120 }}>
-              <Select
-                value={selectedYear}
-                onChange={(e) => setSelectedYear(e.target.value as number)}
-                sx={{
-                  color: "white",
-                  background: "rgba(255,255,255,0.05)",
-                  borderRadius: "12px",
-                  "& .MuiOutlinedInput-notchedOutline": {
-                    borderColor: "rgba(255,255,255,0.1)",
-                  },
-                  "&:hover .MuiOutlinedInput-notchedOutline": {
-                    borderColor: "rgba(16, 185, 129, 0.5)",
-                  },
-                }}
+            <Stack direction="row" spacing={1.5} alignItems="center">
+              <Button
+                startIcon={
+                  downloadingStatement ? (
+                    <CircularProgress size={16} sx={{ color: "#10B981" }} />
+                  ) : (
+                    <DownloadIcon />
+                  )
+                }
+                onClick={handleDownloadIncomeStatement}
+                disabled={downloadingStatement}
+                sx={{ color: "#10B981", textTransform: "none" }}
               >
-                {years.length > 0 ? (
-                  years.map((year) => (
-                    <MenuItem key={year} value={year}>
-                      {year}
+                Informe de Rendimentos
+              </Button>
+              <FormControl size="small" sx={{ minWidth: 120 }}>
+                <Select
+                  value={selectedYear}
+                  onChange={(e) => setSelectedYear(e.target.value as number)}
+                  sx={{
+                    color: "white",
+                    background: "rgba(255,255,255,0.05)",
+                    borderRadius: "12px",
+                    "& .MuiOutlinedInput-notchedOutline": {
+                      borderColor: "rgba(255,255,255,0.1)",
+                    },
+                    "&:hover .MuiOutlinedInput-notchedOutline": {
+                      borderColor: "rgba(16, 185, 129, 0.5)",
+                    },
+                  }}
+                >
+                  {years.length > 0 ? (
+                    years.map((year) => (
+                      <MenuItem key={year} value={year}>
+                        {year}
+                      </MenuItem>
+                    ))
+                  ) : (
+                    <MenuItem value={new Date().getFullYear()}>
+                      {new Date().getFullYear()}
                     </MenuItem>
-                  ))
-                ) : (
-                  <MenuItem value={new Date().getFullYear()}>
-                    {new Date().getFullYear()}
-                  </MenuItem>
-                )}
-              </Select>
-            </FormControl>
+                  )}
+                </Select>
+              </FormControl>
+            </Stack>
           </Stack>
         </Box>
 
@@ -273,6 +362,16 @@ export default function HoleritePage() {
         <Box
           sx={{ p: { xs: 2, sm: 3, md: 4 }, maxWidth: "1400px", mx: "auto" }}
         >
+          {statementError && (
+            <Alert
+              severity="warning"
+              onClose={() => setStatementError("")}
+              sx={{ mb: 3 }}
+            >
+              {statementError}
+            </Alert>
+          )}
+
           {/* Stats Cards */}
           <Grid container spacing={3} sx={{ mb: 4 }}>
             <Grid size={{ xs: 12, sm: 4 }}>
@@ -607,13 +706,23 @@ export default function HoleritePage() {
                       </Typography>
                     </Box>
                   </Stack>
-                  <Button
-                    startIcon={<PrintIcon />}
-                    onClick={handlePrint}
-                    sx={{ color: "#10B981" }}
-                  >
-                    Imprimir
-                  </Button>
+                  <Stack direction="row" spacing={1}>
+                    <Button
+                      startIcon={<DownloadIcon />}
+                      onClick={handleDownloadPdf}
+                      disabled={downloading}
+                      sx={{ color: "#10B981" }}
+                    >
+                      {downloading ? "Gerando..." : "Baixar PDF"}
+                    </Button>
+                    <Button
+                      startIcon={<PrintIcon />}
+                      onClick={handlePrint}
+                      sx={{ color: "#10B981" }}
+                    >
+                      Imprimir
+                    </Button>
+                  </Stack>
                 </Stack>
               </DialogTitle>
               <DialogContent sx={{ pt: 3 }}>
@@ -916,8 +1025,37 @@ export default function HoleritePage() {
                     </Grid>
                   </Paper>
                 )}
+
+                {ackError && (
+                  <Alert severity="error" sx={{ mt: 3 }}>
+                    {ackError}
+                  </Alert>
+                )}
               </DialogContent>
               <DialogActions sx={{ p: 3 }}>
+                {selectedPayslip.acknowledged_at ? (
+                  <Typography
+                    variant="body2"
+                    color="rgba(255,255,255,0.5)"
+                    sx={{ mr: "auto" }}
+                  >
+                    Recebimento confirmado em{" "}
+                    {new Date(selectedPayslip.acknowledged_at).toLocaleString(
+                      "pt-BR"
+                    )}
+                  </Typography>
+                ) : (
+                  <Button
+                    variant="contained"
+                    onClick={handleAcknowledge}
+                    disabled={acknowledging}
+                    sx={{ mr: "auto", bgcolor: "#10B981" }}
+                  >
+                    {acknowledging
+                      ? "Confirmando..."
+                      : "Confirmo o recebimento"}
+                  </Button>
+                )}
                 <Button
                   onClick={() => setDetailOpen(false)}
                   sx={{ color: "rgba(255,255,255,0.7)" }}
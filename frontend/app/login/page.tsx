@@ -23,7 +23,7 @@ import { ThemeProvider, createTheme } from "@mui/material/styles";
 import VisibilityIcon from "@mui/icons-material/Visibility";
 import VisibilityOffIcon from "@mui/icons-material/VisibilityOff";
 import ArrowBackIcon from "@mui/icons-material/ArrowBack";
-import { authAPI } from "@/lib/api";
+import { authAPI, AuthResponse } from "@/lib/api";
 
 const darkTheme = createTheme({
   palette: {
@@ -63,6 +63,10 @@ export default function LoginPage() {
   const [showPassword, setShowPassword] = useState(false);
   const [loginError, setLoginError] = useState<string | null>(null);
   const [loginLoading, setLoginLoading] = useState(false);
+  // Segundo fator: preenchido quando a senha confere e o login exige código TOTP
+  const [twoFactor, setTwoFactor] = useState<AuthResponse | null>(null);
+  const [twoFactorCode, setTwoFactorCode] = useState("");
+  const [recoveryCodes, setRecoveryCodes] = useState<string[] | null>(null);
 
   // Montar componente e verificar autenticação
   useEffect(() => {
@@ -87,7 +91,9 @@ export default function LoginPage() {
         password: loginData.password,
         remember_me: loginData.rememberMe,
       });
-      if (response.success) {
+      if (response.success && response.two_factor_required) {
+        setTwoFactor(response);
+      } else if (response.success) {
         router.push("/hub");
       } else {
         setLoginError(response.error || "Erro ao fazer login");
@@ -99,6 +105,30 @@ export default function LoginPage() {
     }
   };
 
+  const handleVerifyTwoFactor = async () => {
+    if (!twoFactor?.challenge_token) return;
+    setLoginLoading(true);
+    setLoginError(null);
+    try {
+      const response = await authAPI.verifyTwoFactor(
+        twoFactor.challenge_token,
+        twoFactorCode
+      );
+      if (response.recovery_codes?.length) {
+        // Cadastro concluído: mostra os códigos de recuperação antes de entrar
+        setRecoveryCodes(response.recovery_codes);
+      } else {
+        router.push("/hub");
+      }
+    } catch (err) {
+      setLoginError(
+        err instanceof Error ? err.message : "Código de verificação inválido"
+      );
+    } finally {
+      setLoginLoading(false);
+    }
+  };
+
   return (
     <ThemeProvider theme={darkTheme}>
       <Box
@@ -187,6 +217,77 @@ export default function LoginPage() {
                 </Alert>
               )}
 
+              {recoveryCodes ? (
+                <Stack spacing={3}>
+                  <Alert severity="warning">
+                    Guarde estes códigos de recuperação em local seguro. Cada
+                    um pode ser usado uma única vez caso você perca o acesso ao
+                    aplicativo autenticador.
+                  </Alert>
+                  <Box
+                    sx={{
+                      fontFamily: "monospace",
+                      color: "white",
+                      display: "grid",
+                      gridTemplateColumns: "1fr 1fr",
+                      gap: 1,
+                    }}
+                  >
+                    {recoveryCodes.map((code) => (
+                      <span key={code}>{code}</span>
+                    ))}
+                  </Box>
+                  <Button
+                    fullWidth
+                    variant="contained"
+                    onClick={() => router.push("/hub")}
+                    sx={{ borderRadius: "16px", py: 1.5, textTransform: "none" }}
+                  >
+                    Continuar
+                  </Button>
+                </Stack>
+              ) : twoFactor ? (
+                <Stack spacing={3}>
+                  {twoFactor.setup_required && twoFactor.enrollment && (
+                    <Alert severity="info">
+                      Seu perfil exige verificação em dois fatores. Adicione a
+                      conta no seu aplicativo autenticador com a chave{" "}
+                      <strong>{twoFactor.enrollment.secret}</strong> e informe
+                      o código gerado.
+                    </Alert>
+                  )}
+                  <TextField
+                    fullWidth
+                    label="Código de verificação"
+                    placeholder="000000"
+                    value={twoFactorCode}
+                    onChange={(e) => setTwoFactorCode(e.target.value)}
+                    onKeyDown={(e) =>
+                      e.key === "Enter" && handleVerifyTwoFactor()
+                    }
+                    helperText="Código do aplicativo autenticador ou código de recuperação"
+                    sx={textFieldStyles}
+                  />
+                  <Button
+                    fullWidth
+                    variant="contained"
+                    onClick={handleVerifyTwoFactor}
+                    disabled={!twoFactorCode || loginLoading}
+                    sx={{
+                      background:
+                        "linear-gradient(135deg, #3B82F6 0%, #1D4ED8 100%)",
+                      borderRadius: "16px",
+                      py: 1.5,
+                      textTransform: "none",
+                      fontWeight: 600,
+                      fontSize: "1rem",
+                      "&:disabled": { background: "rgba(255,255,255,0.1)" },
+                    }}
+                  >
+                    {loginLoading ? "Verificando..." : "Verificar"}
+                  </Button>
+                </Stack>
+              ) : (
               <Stack spacing={3}>
                 <TextField
                   fullWidth
@@ -261,12 +362,14 @@ export default function LoginPage() {
                       </Typography>
                     }
                   />
-                  <Button
-                    size="small"
-                    sx={{ textTransform: "none", color: "#3B82F6" }}
-                  >
-                    Esqueceu a senha?
-                  </Button>
+                  <Link href="/reset-password" style={{ textDecoration: "none" }}>
+                    <Button
+                      size="small"
+                      sx={{ textTransform: "none", color: "#3B82F6" }}
+                    >
+                      Esqueceu a senha?
+                    </Button>
+                  </Link>
                 </Box>
 
                 <Button
@@ -326,6 +429,7 @@ export default function LoginPage() {
                   </Link>
                 </Typography>
               </Stack>
+              )}
             </CardContent>
           </Card>
         </motion.div>
@@ -0,0 +1,212 @@
+"use client";
+
+import { Suspense, useState } from "react";
+import { useRouter, useSearchParams } from "next/navigation";
+import Link from "next/link";
+import {
+  Box,
+  Typography,
+  Button,
+  TextField,
+  Card,
+  CardContent,
+  IconButton,
+  Stack,
+  Alert,
+} from "@mui/material";
+import { ThemeProvider, createTheme } from "@mui/material/styles";
+import ArrowBackIcon from "@mui/icons-material/ArrowBack";
+import { authAPI } from "@/lib/api";
+
+const darkTheme = createTheme({
+  palette: {
+    mode: "dark",
+    primary: { main: "#e84b8a" },
+    secondary: { main: "#3B82F6" },
+  },
+  typography: {
+    fontFamily: "var(--font-nunito), system-ui, sans-serif",
+  },
+});
+
+const textFieldStyles = {
+  "& .MuiOutlinedInput-root": {
+    color: "#fff",
+    borderRadius: "16px",
+    backgroundColor: "rgba(255,255,255,0.03)",
+    "& fieldset": { borderColor: "rgba(255,255,255,0.1)" },
+    "&:hover fieldset": { borderColor: "rgba(255,255,255,0.2)" },
+    "&.Mui-focused fieldset": { borderColor: "#3B82F6" },
+  },
+  "& .MuiInputLabel-root": {
+    color: "rgba(255,255,255,0.5)",
+    "&.Mui-focused": { color: "#3B82F6" },
+  },
+};
+
+const buttonStyles = {
+  background: "linear-gradient(135deg, #3B82F6 0%, #1D4ED8 100%)",
+  borderRadius: "16px",
+  py: 1.5,
+  textTransform: "none",
+  fontWeight: 600,
+  fontSize: "1rem",
+  "&:disabled": { background: "rgba(255,255,255,0.1)" },
+};
+
+// Sem token: solicita o link pelo CPF. Com token (link do e-mail): define a nova senha.
+function ResetPasswordForm() {
+  const router = useRouter();
+  const token = useSearchParams().get("token");
+  const [cpf, setCpf] = useState("");
+  const [password, setPassword] = useState("");
+  const [confirmPassword, setConfirmPassword] = useState("");
+  const [message, setMessage] = useState<string | null>(null);
+  const [error, setError] = useState<string | null>(null);
+  const [loading, setLoading] = useState(false);
+
+  const handleRequest = async () => {
+    setLoading(true);
+    setError(null);
+    try {
+      const response = await authAPI.forgotPassword(cpf);
+      setMessage(response.message || "Verifique seu e-mail.");
+    } catch (err) {
+      setError(err instanceof Error ? err.message : "Erro ao solicitar link");
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleReset = async () => {
+    if (password !== confirmPassword) {
+      setError("As senhas não coincidem");
+      return;
+    }
+    setLoading(true);
+    setError(null);
+    try {
+      const response = await authAPI.resetPassword(token || "", password);
+      setMessage(response.message || "Senha redefinida com sucesso!");
+      setTimeout(() => router.push("/login"), 2000);
+    } catch (err) {
+      setError(err instanceof Error ? err.message : "Erro ao redefinir senha");
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <Card
+      sx={{
+        width: "100%",
+        maxWidth: 450,
+        background: "rgba(255,255,255,0.03)",
+        border: "1px solid rgba(255,255,255,0.08)",
+        borderRadius: "32px",
+      }}
+    >
+      <CardContent sx={{ p: 5 }}>
+        <Box mb={4}>
+          <Link href="/login">
+            <IconButton sx={{ color: "rgba(255,255,255,0.5)", mb: 2 }}>
+              <ArrowBackIcon />
+            </IconButton>
+          </Link>
+          <Typography variant="h4" fontWeight="bold" color="white">
+            {token ? "Nova senha" : "Esqueceu a senha?"}
+          </Typography>
+          <Typography variant="body1" color="rgba(255,255,255,0.5)" mt={1}>
+            {token
+              ? "Escolha uma nova senha para a sua conta."
+              : "Informe seu CPF e enviaremos um link para o e-mail da conta."}
+          </Typography>
+        </Box>
+
+        {error && (
+          <Alert severity="error" sx={{ mb: 3 }}>
+            {error}
+          </Alert>
+        )}
+        {message && (
+          <Alert severity="success" sx={{ mb: 3 }}>
+            {message}
+          </Alert>
+        )}
+
+        {token ? (
+          <Stack spacing={3}>
+            <TextField
+              fullWidth
+              label="Nova senha"
+              type="password"
+              value={password}
+              onChange={(e) => setPassword(e.target.value)}
+              sx={textFieldStyles}
+            />
+            <TextField
+              fullWidth
+              label="Confirmar senha"
+              type="password"
+              value={confirmPassword}
+              onChange={(e) => setConfirmPassword(e.target.value)}
+              sx={textFieldStyles}
+            />
+            <Button
+              fullWidth
+              variant="contained"
+              onClick={handleReset}
+              disabled={password.length < 6 || loading || !!message}
+              sx={buttonStyles}
+            >
+              {loading ? "Salvando..." : "Redefinir senha"}
+            </Button>
+          </Stack>
+        ) : (
+          <Stack spacing={3}>
+            <TextField
+              fullWidth
+              label="CPF"
+              placeholder="000.000.000-00"
+              value={cpf}
+              onChange={(e) => setCpf(e.target.value)}
+              onKeyDown={(e) => e.key === "Enter" && handleRequest()}
+              sx={textFieldStyles}
+            />
+            <Button
+              fullWidth
+              variant="contained"
+              onClick={handleRequest}
+              disabled={!cpf || loading || !!message}
+              sx={buttonStyles}
+            >
+              {loading ? "Enviando..." : "Enviar link"}
+            </Button>
+          </Stack>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
+
+export default function ResetPasswordPage() {
+  return (
+    <ThemeProvider theme={darkTheme}>
+      <Box
+        sx={{
+          minHeight: "100vh",
+          background:
+            "linear-gradient(135deg, #0a0a12 0%, #12121c 50%, #0a0a12 100%)",
+          display: "flex",
+          alignItems: "center",
+          justifyContent: "center",
+          p: 3,
+        }}
+      >
+        <Suspense fallback={null}>
+          <ResetPasswordForm />
+        </Suspense>
+      </Box>
+    </ThemeProvider>
+  );
+}
@@ -0,0 +1,197 @@
+"use client";
+
+import { Suspense, useEffect, useState } from "react";
+import { useSearchParams } from "next/navigation";
+import {
+  Box,
+  Typography,
+  Button,
+  TextField,
+  Card,
+  CardContent,
+  Stack,
+  Alert,
+  Divider,
+} from "@mui/material";
+import { ThemeProvider, createTheme } from "@mui/material/styles";
+import VerifiedIcon from "@mui/icons-material/Verified";
+import UploadFileIcon from "@mui/icons-material/UploadFile";
+import {
+  payslipAPI,
+  PayslipVerificationResponse,
+  MONTH_NAMES,
+  PAYSLIP_TYPES,
+} from "@/lib/api";
+
+const darkTheme = createTheme({
+  palette: {
+    mode: "dark",
+    primary: { main: "#10B981" },
+  },
+  typography: {
+    fontFamily: "var(--font-nunito), system-ui, sans-serif",
+  },
+});
+
+const textFieldStyles = {
+  "& .MuiOutlinedInput-root": {
+    color: "#fff",
+    borderRadius: "16px",
+    backgroundColor: "rgba(255,255,255,0.03)",
+    "& fieldset": { borderColor: "rgba(255,255,255,0.1)" },
+    "&:hover fieldset": { borderColor: "rgba(255,255,255,0.2)" },
+    "&.Mui-focused fieldset": { borderColor: "#10B981" },
+  },
+  "& .MuiInputLabel-root": {
+    color: "rgba(255,255,255,0.5)",
+    "&.Mui-focused": { color: "#10B981" },
+  },
+};
+
+// Página pública: confere o código impresso no holerite ou o próprio arquivo PDF
+function VerifyPayslipForm() {
+  const initialCode = useSearchParams().get("codigo") || "";
+  const [code, setCode] = useState(initialCode);
+  const [result, setResult] = useState<PayslipVerificationResponse | null>(
+    null
+  );
+  const [error, setError] = useState<string | null>(null);
+  const [loading, setLoading] = useState(false);
+
+  const run = async (verify: () => Promise<PayslipVerificationResponse>) => {
+    setLoading(true);
+    setError(null);
+    setResult(null);
+    try {
+      setResult(await verify());
+    } catch (err) {
+      setError(err instanceof Error ? err.message : "Erro na verificação");
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    if (initialCode) run(() => payslipAPI.verifyCode(initialCode));
+  }, [initialCode]);
+
+  const info = result?.verification;
+  const authentic = result?.valid && info?.data_intact;
+
+  return (
+    <Card
+      sx={{
+        width: "100%",
+        maxWidth: 520,
+        background: "rgba(255,255,255,0.03)",
+        border: "1px solid rgba(255,255,255,0.08)",
+        borderRadius: "32px",
+      }}
+    >
+      <CardContent sx={{ p: 5 }}>
+        <Box mb={4}>
+          <Typography variant="h4" fontWeight="bold" color="white">
+            Verificar holerite
+          </Typography>
+          <Typography variant="body1" color="rgba(255,255,255,0.5)" mt={1}>
+            Informe o código de verificação impresso no rodapé ou envie o
+            arquivo PDF para confirmar que ele não foi alterado.
+          </Typography>
+        </Box>
+
+        {error && (
+          <Alert severity="error" sx={{ mb: 3 }}>
+            {error}
+          </Alert>
+        )}
+        {result && info && (
+          <Alert
+            severity={authentic ? "success" : "warning"}
+            icon={authentic ? <VerifiedIcon /> : undefined}
+            sx={{ mb: 3 }}
+          >
+            <Typography fontWeight="bold">{result.message}</Typography>
+            {info.employee_name && (
+              <Typography variant="body2">
+                {info.employee_name} ({info.employee_cpf}) -{" "}
+                {MONTH_NAMES[info.reference_month - 1]}/{info.reference_year}{" "}
+                - {PAYSLIP_TYPES.find((t) => t.value === info.payslip_type)
+                  ?.label || info.payslip_type}
+              </Typography>
+            )}
+            <Typography variant="body2">
+              Código {info.verification_code}, emitido em{" "}
+              {new Date(info.issued_at).toLocaleString("pt-BR")}
+            </Typography>
+          </Alert>
+        )}
+
+        <Stack spacing={3}>
+          <TextField
+            fullWidth
+            label="Código de verificação"
+            placeholder="XXXX-XXXX-XXXX-XXXX"
+            value={code}
+            onChange={(e) => setCode(e.target.value)}
+            onKeyDown={(e) =>
+              e.key === "Enter" && code && run(() => payslipAPI.verifyCode(code))
+            }
+            sx={textFieldStyles}
+          />
+          <Button
+            fullWidth
+            variant="contained"
+            onClick={() => run(() => payslipAPI.verifyCode(code))}
+            disabled={!code || loading}
+            sx={{ borderRadius: "16px", py: 1.5, textTransform: "none" }}
+          >
+            {loading ? "Verificando..." : "Verificar código"}
+          </Button>
+          <Divider sx={{ color: "rgba(255,255,255,0.4)" }}>ou</Divider>
+          <Button
+            fullWidth
+            component="label"
+            variant="outlined"
+            startIcon={<UploadFileIcon />}
+            disabled={loading}
+            sx={{ borderRadius: "16px", py: 1.5, textTransform: "none" }}
+          >
+            Enviar arquivo PDF
+            <input
+              hidden
+              type="file"
+              accept="application/pdf"
+              onChange={(e) => {
+                const file = e.target.files?.[0];
+                if (file) run(() => payslipAPI.verifyFile(file));
+                e.target.value = "";
+              }}
+            />
+          </Button>
+        </Stack>
+      </CardContent>
+    </Card>
+  );
+}
+
+export default function VerifyPayslipPage() {
+  return (
+    <ThemeProvider theme={darkTheme}>
+      <Box
+        sx={{
+          minHeight: "100vh",
+          background:
+            "linear-gradient(135deg, #0F0F1A 0%, #1A1A2E 50%, #16213E 100%)",
+          display: "flex",
+          alignItems: "center",
+          justifyContent: "center",
+          p: 3,
+        }}
+      >
+        <Suspense fallback={null}>
+          <VerifyPayslipForm />
+        </Suspense>
+      </Box>
+    </ThemeProvider>
+  );
+}
@@ -21,6 +21,14 @@ export interface User {
 export interface AuthResponse {
   success: boolean;
   token: string;
+  refresh_token?: string;
+  expires_in?: number;
+  // Verificação em dois fatores (TOTP)
+  two_factor_required?: boolean;
+  challenge_token?: string;
+  setup_required?: boolean;
+  enrollment?: { secret: string; otpauth_url: string };
+  recovery_codes?: string[];
   user: User;
   error?: string;
   temp_password?: string;
@@ -68,10 +76,52 @@ export interface SurveyResults {
   created_at: string;
 }
 
+// Salva o par de tokens devolvido no login/renovação
+function storeTokens(response: { token: string; refresh_token?: string }) {
+  localStorage.setItem("token", response.token);
+  if (response.refresh_token) {
+    localStorage.setItem("refresh_token", response.refresh_token);
+  }
+}
+
+// Renovação em andamento (evita várias chamadas simultâneas com o mesmo refresh token)
+let refreshPromise: Promise<boolean> | null = null;
+
+// Troca o refresh token por um novo par; retorna false se a sessão acabou
+async function refreshSession(): Promise<boolean> {
+  const refreshToken =
+    typeof window !== "undefined" ? localStorage.getItem("refresh_token") : null;
+  if (!refreshToken) return false;
+
+  if (!refreshPromise) {
+    refreshPromise = fetch(`${API_URL}/auth/refresh`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ refresh_token: refreshToken }),
+    })
+      .then(async (response) => {
+        const data = await response.json();
+        if (!response.ok || !data.token) {
+          localStorage.removeItem("token");
+          localStorage.removeItem("refresh_token");
+          return false;
+        }
+        storeTokens(data);
+        return true;
+      })
+      .catch(() => false)
+      .finally(() => {
+        refreshPromise = null;
+      });
+  }
+  return refreshPromise;
+}
+
 // Helper para fazer requests
 async function fetchAPI<T>(
   endpoint: string,
-  options: RequestInit = {}
+  options: RequestInit = {},
+  retry = true
 ): Promise<T> {
   const token =
     typeof window !== "undefined" ? localStorage.getItem("token") : null;
@@ -87,6 +137,11 @@ async function fetchAPI<T>(
     headers,
   });
 
+  // Access token expirado: renova a sessão e repete a requisição uma vez
+  if (response.status === 401 && retry && token && (await refreshSession())) {
+    return fetchAPI<T>(endpoint, options, false);
+  }
+
   const data = await response.json();
 
   if (!response.ok) {
@@ -105,7 +160,7 @@ export const authAPI = {
     });
 
     if (response.success && response.token) {
-      localStorage.setItem("token", response.token);
+      storeTokens(response);
       localStorage.setItem("user", JSON.stringify(response.user));
     }
 
@@ -119,7 +174,24 @@ export const authAPI = {
     });
 
     if (response.success && response.token) {
-      localStorage.setItem("token", response.token);
+      storeTokens(response);
+      localStorage.setItem("user", JSON.stringify(response.user));
+    }
+
+    return response;
+  },
+
+  verifyTwoFactor: async (
+    challengeToken: string,
+    code: string
+  ): Promise<AuthResponse> => {
+    const response = await fetchAPI<AuthResponse>("/auth/2fa/verify", {
+      method: "POST",
+      body: JSON.stringify({ challenge_token: challengeToken, code }),
+    });
+
+    if (response.success && response.token) {
+      storeTokens(response);
       localStorage.setItem("user", JSON.stringify(response.user));
     }
 
@@ -169,8 +241,32 @@ export const authAPI = {
     return result;
   },
 
+  forgotPassword: async (
+    cpf: string
+  ): Promise<{ success: boolean; message?: string; error?: string }> => {
+    return fetchAPI("/auth/forgot-password", {
+      method: "POST",
+      body: JSON.stringify({ cpf }),
+    });
+  },
+
+  resetPassword: async (
+    token: string,
+    newPassword: string
+  ): Promise<{ success: boolean; message?: string; error?: string }> => {
+    return fetchAPI("/auth/reset-password", {
+      method: "POST",
+      body: JSON.stringify({ token, new_password: newPassword }),
+    });
+  },
+
   logout: () => {
+    // Encerra a sessão no servidor (sem bloquear a saída local)
+    if (localStorage.getItem("token")) {
+      fetchAPI("/auth/logout", { method: "POST" }, false).catch(() => {});
+    }
     localStorage.removeItem("token");
+    localStorage.removeItem("refresh_token");
     localStorage.removeItem("user");
   },
 
@@ -989,7 +1085,15 @@ export const calendarAPI = {
 };
 
 // Document Types
-export type DocumentStatus = "pending" | "approved" | "rejected";
+export type DocumentStatus = "quarantined" | "pending" | "approved" | "rejected";
+
+export type DocumentScanStatus =
+  | "pending"
+  | "running"
+  | "clean"
+  | "infected"
+  | "invalid"
+  | "failed";
 
 export interface Document {
   id: string;
@@ -1002,6 +1106,10 @@ export interface Document {
   path: string;
   description?: string;
   status: DocumentStatus;
+  scan_status?: DocumentScanStatus;
+  scan_result?: string;
+  scanned_at?: string;
+  has_thumbnail?: boolean;
   reviewed_by?: string;
   reviewed_at?: string;
   reject_reason?: string;
@@ -1082,6 +1190,14 @@ export const documentsAPI = {
     document.body.removeChild(a);
   },
 
+  // Link temporário (5 min) para abrir o documento em nova aba sem o token
+  link: async (id: string): Promise<string> => {
+    const data = await fetchAPI<{ success: boolean; url: string }>(
+      `/documents/${id}/link`
+    );
+    return new URL(data.url, API_URL).toString();
+  },
+
   delete: async (
     id: string
   ): Promise<{ success: boolean; message: string }> => {
@@ -1112,6 +1228,7 @@ export const documentsAPI = {
     getStats: async (): Promise<{
       success: boolean;
       stats: {
+        quarantined: number;
         pending: number;
         approved: number;
         rejected: number;
@@ -1121,6 +1238,14 @@ export const documentsAPI = {
       return fetchAPI("/documents/admin/stats");
     },
 
+    rescan: async (
+      id: string
+    ): Promise<{ success: boolean; message: string }> => {
+      return fetchAPI(`/documents/admin/${id}/rescan`, {
+        method: "POST",
+      });
+    },
+
     approve: async (
       id: string
     ): Promise<{ success: boolean; message: string; document: Document }> => {
@@ -1139,6 +1264,13 @@ export const documentsAPI = {
       });
     },
 
+    link: async (id: string): Promise<string> => {
+      const data = await fetchAPI<{ success: boolean; url: string }>(
+        `/documents/admin/${id}/link`
+      );
+      return new URL(data.url, API_URL).toString();
+    },
+
     download: async (id: string, filename: string): Promise<void> => {
       const token =
         typeof window !== "undefined" ? localStorage.getItem("token") : null;
@@ -2238,6 +2370,9 @@ export interface Payslip {
   id: string;
   user_id: string;
   colaborador_id: number;
+  employee_id?: string; // Identidade do colaborador (autoriza o acesso)
+  link_status: "linked" | "manual" | "unmatched" | "ambiguous" | "";
+  link_note?: string;
   employee_name: string;
   employee_cpf: string;
   position: string;
@@ -2258,6 +2393,8 @@ export interface Payslip {
   worked_days: number;
   base_salary: number;
   status: string;
+  published_at?: string | null;
+  acknowledged_at?: string | null;
   items?: PayslipItem[];
   created_at: string;
 }
@@ -2272,8 +2409,317 @@ export interface PayslipSummary {
   net_total: number;
   payment_date: string | null;
   status: string;
+  published_at?: string | null;
+  acknowledged_at?: string | null;
+}
+
+export interface PayslipPublication {
+  id: string;
+  reference_month: number;
+  reference_year: number;
+  branch: string;
+  payslip_type: string;
+  scheduled_for: string;
+  status: "scheduled" | "published" | "canceled";
+  payslip_count: number;
+  notified_count: number;
+  published_at?: string | null;
+  created_at: string;
+}
+
+export interface PayslipAcknowledgementRow {
+  payslip_id: string;
+  employee_name: string;
+  employee_cpf: string;
+  branch: string;
+  payslip_type: string;
+  status: string;
+  published_at: string | null;
+  acknowledged_at: string | null;
+  ip_address: string;
+}
+
+export interface PayrollTaxTable {
+  id: string;
+  kind: "inss" | "irrf" | "fgts";
+  valid_from: string;
+  description: string;
+  brackets: string; // JSON: [{ up_to, rate (%), deduction }]
+  dependent_deduction: number;
+  simplified_discount: number;
+  // IRRF: redução mensal (Lei nº 15.270/2025); reduction_up_to 0 = sem redução
+  reduction_full_up_to: number;
+  reduction_max: number;
+  reduction_up_to: number;
+  reduction_constant: number;
+  reduction_rate: number; // %
+  created_at: string;
+}
+
+export interface PayrollRubrica {
+  type: "earning" | "deduction";
+  code: string;
+  description: string;
+  reference?: number;
+  amount: number;
+  no_inss?: boolean;
+  no_irrf?: boolean;
+  no_fgts?: boolean;
+  reduces_bases?: boolean;
+  irrf_deductible?: boolean;
+}
+
+export interface PayrollCalculationInput {
+  payslip_type: string;
+  reference_month: number;
+  reference_year: number;
+  payment_date?: string;
+  base_salary: number;
+  worked_days?: number;
+  dependents?: number;
+  vacation_days?: number;
+  months_worked?: number;
+  advance_paid?: number;
+  rubricas?: PayrollRubrica[];
 }
 
+export interface PayrollCalculationResult {
+  items: PayslipItem[];
+  gross_total: number;
+  deduction_total: number;
+  net_total: number;
+  inss_base: number;
+  irrf_base: number;
+  fgts_base: number;
+  fgts_amount: number;
+  inss: number;
+  irrf: {
+    base: number;
+    deductions: number;
+    simplified: boolean;
+    rate: number;
+    tax: number;
+  };
+}
+
+export interface PayslipImportTemplate {
+  id: string;
+  name: string;
+  format: "csv" | "xlsx" | "fixed";
+  delimiter: string;
+  header_row: number;
+  decimal_comma: boolean;
+  implied_decimals: number;
+  date_format: string;
+  columns: string; // JSON: campo -> coluna
+  layout?: string; // JSON do layout TXT
+  rubrica_types: string; // JSON: código -> earning/deduction
+}
+
+export interface PayslipImportIssue {
+  row?: number;
+  cpf?: string;
+  severity: "error" | "warning";
+  message: string;
+}
+
+export interface PayslipImportReport {
+  payslips: number;
+  items: number;
+  total_gross: number;
+  total_net: number;
+  errors: PayslipImportIssue[];
+  warnings: PayslipImportIssue[];
+  replace_count: number;
+  unmatched_users: number;
+  entries: {
+    rows: number[];
+    cpf: string;
+    employee_name: string;
+    reference_month: number;
+    reference_year: number;
+    payslip_type: string;
+    items: number;
+    net_total: number;
+    valid: boolean;
+  }[];
+}
+
+export interface PayslipImportBatch {
+  id: string;
+  file_name: string;
+  format: string;
+  template_id?: string;
+  replace_existing: boolean;
+  status: "validated" | "committed" | "discarded";
+  payslip_count: number;
+  error_count: number;
+  warning_count: number;
+  created_at: string;
+  committed_at?: string;
+}
+
+export interface PayslipVerificationResponse {
+  success: boolean;
+  valid: boolean;
+  message: string;
+  verification: {
+    valid: boolean;
+    data_intact: boolean;
+    verification_code: string;
+    issued_at: string;
+    employee_name: string;
+    employee_cpf: string;
+    reference_month: number;
+    reference_year: number;
+    payslip_type: string;
+    document_hash: string;
+  };
+}
+
+export interface IncomeStatementNote {
+  description: string;
+  amount: number;
+}
+
+// Comprovante de Rendimentos Pagos e de IRRF (quadros do modelo da Receita)
+export interface IncomeStatement {
+  year: number;
+  exercise_year: number;
+  payer: { name: string; document: string };
+  beneficiary: { name: string; document: string };
+  nature: string;
+  taxable: {
+    total_income: number;
+    official_pension: number;
+    private_pension: number;
+    alimony: number;
+    withheld_tax: number;
+  };
+  exempt: {
+    allowances: number;
+    indemnities: number;
+    other: number;
+    other_details?: IncomeStatementNote[];
+  };
+  exclusive: {
+    thirteenth: number;
+    thirteenth_withheld_tax: number;
+  };
+  complementary?: IncomeStatementNote[];
+  monthly: {
+    month: number;
+    taxable_income: number;
+    inss: number;
+    withheld_tax: number;
+  }[];
+  payslip_count: number;
+  preliminary: boolean;
+  responsible: string;
+  generated_at: string;
+}
+
+export interface IncomeStatementRecord {
+  id: string;
+  year: number;
+  cpf: string;
+  user_id?: string;
+  employee_name: string;
+  taxable_income: number;
+  withheld_tax: number;
+  thirteenth: number;
+  payslip_count: number;
+  batch_id?: string;
+  generated_at: string;
+}
+
+export interface IncomeStatementBatch {
+  id: string;
+  year: number;
+  status: "running" | "completed" | "failed";
+  total: number;
+  generated: number;
+  failed: number;
+  errors: string;
+  started_by: string;
+  finished_at: string | null;
+  created_at: string;
+}
+
+export interface PayrollRubricaDelta {
+  type: "earning" | "deduction";
+  code: string;
+  description: string;
+  base: number;
+  compare: number;
+  delta: number;
+  change: "new" | "removed" | "changed" | "unchanged";
+  base_count?: number;
+  compare_count?: number;
+}
+
+export interface PayrollEmployeeVariance {
+  employee_id?: string;
+  employee_name: string;
+  employee_cpf: string;
+  branch: string;
+  base_payslip_id?: string;
+  compare_payslip_id?: string;
+  base_gross: number;
+  compare_gross: number;
+  base_net: number;
+  compare_net: number;
+  net_delta: number;
+  net_delta_pct: number | null;
+  rubricas: PayrollRubricaDelta[];
+  anomalies: {
+    kind:
+      | "net_change"
+      | "new_rubrica"
+      | "removed_rubrica"
+      | "missing_payslip"
+      | "negative_net";
+    message: string;
+  }[];
+}
+
+export interface PayrollVarianceParams {
+  compare_month: number;
+  compare_year: number;
+  base_month?: number; // padrão: mês anterior à competência comparada
+  base_year?: number;
+  branch?: string;
+  payslip_type?: string;
+  threshold_pct?: number;
+  threshold_amount?: number;
+}
+
+export interface PayrollVarianceReport {
+  options: Required<PayrollVarianceParams>;
+  totals: {
+    base_count: number;
+    compare_count: number;
+    base_gross: number;
+    compare_gross: number;
+    base_net: number;
+    compare_net: number;
+    net_delta: number;
+  };
+  employees: PayrollEmployeeVariance[];
+  rubricas: PayrollRubricaDelta[];
+  anomaly_counts: Record<string, number>;
+  warnings?: string[]; // Verificações que não puderam ser feitas
+  generated_at: string;
+}
+
+const varianceQuery = (params: PayrollVarianceParams) => {
+  const query = new URLSearchParams();
+  Object.entries(params).forEach(([key, value]) => {
+    if (value !== undefined && value !== "") query.set(key, String(value));
+  });
+  return query;
+};
+
 export const payslipAPI = {
   // Colaborador
   getMyPayslips: async (
@@ -2299,6 +2745,81 @@ export const payslipAPI = {
     return fetchAPI(`/payslip/${id}`);
   },
 
+  // Baixa o PDF com código de verificação
+  downloadPdf: async (id: string, retry = true): Promise<Blob> => {
+    const token = localStorage.getItem("token");
+    const response = await fetch(`${API_URL}/payslip/${id}/pdf`, {
+      headers: token ? { Authorization: `Bearer ${token}` } : {},
+    });
+    if (response.status === 401 && retry && token && (await refreshSession())) {
+      return payslipAPI.downloadPdf(id, false);
+    }
+    if (!response.ok) {
+      const data = await response.json().catch(() => ({}));
+      throw new Error(data.error || "Erro ao gerar PDF");
+    }
+    return response.blob();
+  },
+
+  // Ciência do colaborador ("confirmo o recebimento")
+  acknowledge: async (
+    id: string
+  ): Promise<{ success: boolean; message: string; acknowledged_at: string }> => {
+    return fetchAPI(`/payslip/${id}/acknowledge`, { method: "POST" });
+  },
+
+  // Informe de rendimentos (anos com holerites publicados)
+  getIncomeStatementYears: async (): Promise<{
+    success: boolean;
+    years: number[];
+  }> => {
+    return fetchAPI("/payslip/income-statements");
+  },
+
+  getIncomeStatement: async (
+    year: number
+  ): Promise<{ success: boolean; statement: IncomeStatement }> => {
+    return fetchAPI(`/payslip/income-statements/${year}`);
+  },
+
+  downloadIncomeStatementPdf: async (
+    year: number,
+    retry = true
+  ): Promise<Blob> => {
+    const token = localStorage.getItem("token");
+    const response = await fetch(
+      `${API_URL}/payslip/income-statements/${year}/pdf`,
+      { headers: token ? { Authorization: `Bearer ${token}` } : {} }
+    );
+    if (response.status === 401 && retry && token && (await refreshSession())) {
+      return payslipAPI.downloadIncomeStatementPdf(year, false);
+    }
+    if (!response.ok) {
+      const data = await response.json().catch(() => ({}));
+      throw new Error(data.error || "Erro ao gerar informe de rendimentos");
+    }
+    return response.blob();
+  },
+
+  // Verificação pública de autenticidade
+  verifyCode: async (code: string): Promise<PayslipVerificationResponse> => {
+    return fetchAPI(`/public/payslip/verify/${encodeURIComponent(code)}`);
+  },
+
+  verifyFile: async (file: File): Promise<PayslipVerificationResponse> => {
+    const formData = new FormData();
+    formData.append("file", file);
+    const response = await fetch(`${API_URL}/public/payslip/verify`, {
+      method: "POST",
+      body: formData,
+    });
+    const data = await response.json();
+    if (!response.ok) {
+      throw new Error(data.error || "Erro na verificação");
+    }
+    return data;
+  },
+
   // Admin
   admin: {
     getAll: async (params?: {
@@ -2348,11 +2869,21 @@ export const payslipAPI = {
       return fetchAPI(`/payslip/admin/stats?${query.toString()}`);
     },
 
+    // calculate: true gera salário, INSS, IRRF e FGTS (items = rubricas adicionais);
+    // sem cálculo, os itens informados são conferidos e as divergências vêm em warnings
     create: async (
-      data: Partial<Payslip> & { items?: Partial<PayslipItem>[] }
+      data: Partial<Payslip> & {
+        items?: Partial<PayrollRubrica>[];
+        calculate?: boolean;
+        dependents?: number;
+        vacation_days?: number;
+        months_worked?: number;
+        advance_paid?: number;
+      }
     ): Promise<{
       success: boolean;
       payslip: Payslip;
+      warnings: string[] | null;
       message: string;
     }> => {
       return fetchAPI("/payslip/admin", {
@@ -2375,6 +2906,328 @@ export const payslipAPI = {
       });
     },
 
+    // Importação por arquivo (CSV, XLSX ou TXT): simulação e confirmação do lote
+    previewImportFile: async (
+      file: File,
+      options?: { templateId?: string; format?: string; replaceExisting?: boolean }
+    ): Promise<{
+      success: boolean;
+      batch: PayslipImportBatch;
+      report: PayslipImportReport;
+    }> => {
+      const token =
+        typeof window !== "undefined" ? localStorage.getItem("token") : null;
+
+      const formData = new FormData();
+      formData.append("file", file);
+      if (options?.templateId) formData.append("template_id", options.templateId);
+      if (options?.format) formData.append("format", options.format);
+      if (options?.replaceExisting) formData.append("replace_existing", "true");
+
+      const response = await fetch(`${API_URL}/payslip/admin/import/file`, {
+        method: "POST",
+        headers: {
+          ...(token && { Authorization: `Bearer ${token}` }),
+        },
+        body: formData,
+      });
+
+      const data = await response.json();
+      if (!response.ok) {
+        throw new Error(data.error || "Erro ao processar arquivo");
+      }
+      return data;
+    },
+
+    getImportBatch: async (
+      id: string
+    ): Promise<{
+      success: boolean;
+      batch: PayslipImportBatch;
+      report: PayslipImportReport;
+    }> => {
+      return fetchAPI(`/payslip/admin/import/batches/${id}`);
+    },
+
+    commitImport: async (
+      id: string
+    ): Promise<{
+      success: boolean;
+      message: string;
+      batch: PayslipImportBatch;
+      report: PayslipImportReport;
+    }> => {
+      return fetchAPI(`/payslip/admin/import/batches/${id}/commit`, {
+        method: "POST",
+      });
+    },
+
+    discardImport: async (
+      id: string
+    ): Promise<{ success: boolean; message: string }> => {
+      return fetchAPI(`/payslip/admin/import/batches/${id}`, {
+        method: "DELETE",
+      });
+    },
+
+    getImportTemplates: async (): Promise<{
+      success: boolean;
+      templates: PayslipImportTemplate[];
+    }> => {
+      return fetchAPI("/payslip/admin/import/templates");
+    },
+
+    saveImportTemplate: async (
+      template: Partial<PayslipImportTemplate>
+    ): Promise<{
+      success: boolean;
+      message: string;
+      template: PayslipImportTemplate;
+    }> => {
+      return fetchAPI(
+        template.id
+          ? `/payslip/admin/import/templates/${template.id}`
+          : "/payslip/admin/import/templates",
+        {
+          method: template.id ? "PUT" : "POST",
+          body: JSON.stringify(template),
+        }
+      );
+    },
+
+    deleteImportTemplate: async (
+      id: string
+    ): Promise<{ success: boolean; message: string }> => {
+      return fetchAPI(`/payslip/admin/import/templates/${id}`, {
+        method: "DELETE",
+      });
+    },
+
+    // Cálculo da folha (INSS, IRRF e FGTS) com as tabelas vigentes
+    calculate: async (
+      input: PayrollCalculationInput
+    ): Promise<{ success: boolean; result: PayrollCalculationResult }> => {
+      return fetchAPI("/payslip/admin/calculate", {
+        method: "POST",
+        body: JSON.stringify(input),
+      });
+    },
+
+    getTaxTables: async (): Promise<{
+      success: boolean;
+      tables: PayrollTaxTable[];
+    }> => {
+      return fetchAPI("/payslip/admin/tax-tables");
+    },
+
+    // valid_from em ISO 8601 (ex: 2026-01-01T00:00:00Z)
+    createTaxTable: async (
+      table: Omit<PayrollTaxTable, "id" | "created_at">
+    ): Promise<{ success: boolean; message: string; table: PayrollTaxTable }> => {
+      return fetchAPI("/payslip/admin/tax-tables", {
+        method: "POST",
+        body: JSON.stringify(table),
+      });
+    },
+
+    // Variação entre competências (por colaborador e rubrica, com anomalias)
+    getVariance: async (
+      params: PayrollVarianceParams
+    ): Promise<{ success: boolean; report: PayrollVarianceReport }> => {
+      return fetchAPI(
+        `/payslip/admin/variance?${varianceQuery(params).toString()}`
+      );
+    },
+
+    downloadVarianceCsv: async (
+      params: PayrollVarianceParams,
+      onlyAnomalies = false,
+      retry = true
+    ): Promise<Blob> => {
+      const query = varianceQuery(params);
+      query.set("format", "csv");
+      if (onlyAnomalies) query.set("only_anomalies", "true");
+      const token = localStorage.getItem("token");
+      const response = await fetch(
+        `${API_URL}/payslip/admin/variance?${query.toString()}`,
+        { headers: token ? { Authorization: `Bearer ${token}` } : {} }
+      );
+      if (response.status === 401 && retry && token && (await refreshSession())) {
+        return payslipAPI.admin.downloadVarianceCsv(params, onlyAnomalies, false);
+      }
+      if (!response.ok) {
+        const data = await response.json().catch(() => ({}));
+        throw new Error(data.error || "Erro ao exportar relatório");
+      }
+      return response.blob();
+    },
+
+    // Ciclo de publicação: rascunho → revisado → agendado → publicado → ciente
+    review: async (selection: {
+      ids?: string[];
+      reference_month?: number;
+      reference_year?: number;
+      branch?: string;
+      payslip_type?: string;
+    }): Promise<{ success: boolean; message: string; count: number }> => {
+      return fetchAPI("/payslip/admin/review", {
+        method: "POST",
+        body: JSON.stringify(selection),
+      });
+    },
+
+    returnToDraft: async (selection: {
+      ids?: string[];
+      reference_month?: number;
+      reference_year?: number;
+      branch?: string;
+      payslip_type?: string;
+    }): Promise<{ success: boolean; message: string; count: number }> => {
+      return fetchAPI("/payslip/admin/review/undo", {
+        method: "POST",
+        body: JSON.stringify(selection),
+      });
+    },
+
+    getPublications: async (
+      year?: number
+    ): Promise<{ success: boolean; publications: PayslipPublication[] }> => {
+      return fetchAPI(`/payslip/admin/publications${year ? `?year=${year}` : ""}`);
+    },
+
+    schedulePublication: async (data: {
+      reference_month: number;
+      reference_year: number;
+      branch?: string;
+      payslip_type?: string;
+      scheduled_for?: string; // ISO 8601; vazio = publica imediatamente
+    }): Promise<{
+      success: boolean;
+      message: string;
+      publication: PayslipPublication;
+    }> => {
+      return fetchAPI("/payslip/admin/publications", {
+        method: "POST",
+        body: JSON.stringify(data),
+      });
+    },
+
+    cancelPublication: async (
+      id: string
+    ): Promise<{
+      success: boolean;
+      message: string;
+      publication: PayslipPublication;
+    }> => {
+      return fetchAPI(`/payslip/admin/publications/${id}`, {
+        method: "DELETE",
+      });
+    },
+
+    getAcknowledgements: async (params: {
+      month: number;
+      year: number;
+      branch?: string;
+    }): Promise<{
+      success: boolean;
+      rows: PayslipAcknowledgementRow[];
+      total: number;
+      acknowledged: number;
+      pending: number;
+    }> => {
+      const query = new URLSearchParams({
+        month: String(params.month),
+        year: String(params.year),
+      });
+      if (params.branch) query.append("branch", params.branch);
+      return fetchAPI(`/payslip/admin/acknowledgements?${query.toString()}`);
+    },
+
+    // Informes de rendimentos: geração em lote e consulta por CPF
+    getIncomeStatements: async (
+      year: number
+    ): Promise<{
+      success: boolean;
+      year: number;
+      statements: IncomeStatementRecord[];
+      total: number;
+      last_batch: IncomeStatementBatch | null;
+    }> => {
+      return fetchAPI(`/payslip/admin/income-statements?year=${year}`);
+    },
+
+    startIncomeStatementBatch: async (
+      year: number
+    ): Promise<{
+      success: boolean;
+      message: string;
+      batch: IncomeStatementBatch;
+    }> => {
+      return fetchAPI("/payslip/admin/income-statements/batches", {
+        method: "POST",
+        body: JSON.stringify({ year }),
+      });
+    },
+
+    getIncomeStatementBatch: async (
+      id: string
+    ): Promise<{ success: boolean; batch: IncomeStatementBatch }> => {
+      return fetchAPI(`/payslip/admin/income-statements/batches/${id}`);
+    },
+
+    getIncomeStatement: async (
+      year: number,
+      cpf: string
+    ): Promise<{ success: boolean; statement: IncomeStatement }> => {
+      return fetchAPI(`/payslip/admin/income-statements/${year}/${cpf}`);
+    },
+
+    // Vínculo dos holerites com a identidade do colaborador
+    getUnlinked: async (params?: {
+      status?: "unmatched" | "ambiguous";
+      page?: number;
+      limit?: number;
+    }): Promise<{
+      success: boolean;
+      payslips: Payslip[];
+      counts: { unmatched: number; ambiguous: number };
+      pagination: {
+        page: number;
+        limit: number;
+        total: number;
+        total_pages: number;
+      };
+    }> => {
+      const query = new URLSearchParams();
+      if (params?.status) query.set("status", params.status);
+      if (params?.page) query.set("page", String(params.page));
+      if (params?.limit) query.set("limit", String(params.limit));
+      return fetchAPI(`/payslip/admin/identity/pending?${query.toString()}`);
+    },
+
+    backfillIdentities: async (): Promise<{
+      success: boolean;
+      message: string;
+      result: {
+        processed: number;
+        linked: number;
+        unmatched: number;
+        ambiguous: number;
+      };
+    }> => {
+      return fetchAPI("/payslip/admin/identity/backfill", { method: "POST" });
+    },
+
+    linkIdentity: async (
+      payslipIds: string[],
+      cpf: string
+    ): Promise<{ success: boolean; message: string; count: number }> => {
+      return fetchAPI("/payslip/admin/identity/link", {
+        method: "POST",
+        body: JSON.stringify({ payslip_ids: payslipIds, cpf }),
+      });
+    },
+
     delete: async (
       id: string
     ): Promise<{ success: boolean; message: string }> => {
@@ -9,10 +9,30 @@ export const LOGIN_MUTATION = gql`
     login(input: $input) {
       success
       token
+      refreshToken
       user {
         ...UserFields
       }
       error
+      twoFactorRequired
+      challengeToken
+      otpauthUrl
+    }
+  }
+`;
+
+export const VERIFY_TWO_FACTOR_MUTATION = gql`
+  ${USER_FRAGMENT}
+  mutation VerifyTwoFactor($challengeToken: String!, $code: String!) {
+    verifyTwoFactor(challengeToken: $challengeToken, code: $code) {
+      success
+      token
+      refreshToken
+      user {
+        ...UserFields
+      }
+      error
+      recoveryCodes
     }
   }
 `;
@@ -2,4 +2,6 @@
 # Script para executar o backend FrappYOU
 
 echo "🚀 Iniciando FrappYOU Backend..."
+# Desenvolvimento local: sem MAIL_DRIVER, os e-mails são gravados em backend/tmp/mail
+export APP_ENV="${APP_ENV:-development}"
 cd backend && go run main.go